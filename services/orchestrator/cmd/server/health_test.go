@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"optiinfra/services/orchestrator/internal/handlers"
+	"optiinfra/services/orchestrator/internal/registry"
+	"optiinfra/services/orchestrator/internal/task"
+)
+
+func TestHealthScoreDropsWithUnhealthyAgentsAndFailures(t *testing.T) {
+	// The agent rejects every task
+	agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "bad input", http.StatusBadRequest)
+	}))
+	defer agent.Close()
+	host, portStr, _ := net.SplitHostPort(agent.Listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+
+	reg := registry.NewRegistryWithStore(registry.NewMemoryAgentStore())
+	cfg := task.DefaultConfig()
+	cfg.RetryDelay = 10 * time.Millisecond
+	tr := task.NewRouterWithConfig(task.NewMemoryTaskStore(), reg, cfg)
+	tr.Start()
+	defer tr.Stop()
+
+	var agentIDs []string
+	for _, name := range []string{"cost-1", "cost-2"} {
+		resp, err := reg.Register(&registry.RegistrationRequest{
+			Name:         name,
+			Type:         registry.AgentTypeCost,
+			Host:         host,
+			Port:         port,
+			Capabilities: []string{string(task.TaskTypeAnalyzeCost)},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		agentIDs = append(agentIDs, resp.AgentID)
+	}
+
+	weights := handlers.DefaultHealthWeights()
+	score := func() float64 {
+		return handlers.ComputeHealthScore(collectHealthSignals(reg, tr), weights).Score
+	}
+	healthy := score()
+
+	// One of the two agents reports itself unhealthy
+	if _, err := reg.Heartbeat(agentIDs[1], &registry.HeartbeatRequest{Status: registry.AgentStatusUnhealthy}); err != nil {
+		t.Fatal(err)
+	}
+	unhealthyAgent := score()
+	if unhealthyAgent >= healthy {
+		t.Fatalf("score with an unhealthy agent = %v, want below %v", unhealthyAgent, healthy)
+	}
+
+	// Tasks start failing
+	resp, err := tr.SubmitTask(context.Background(), &task.TaskSubmitRequest{TaskType: task.TaskTypeAnalyzeCost, AgentType: "cost"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status, err := tr.GetTaskStatus(resp.TaskID)
+		if err == nil && status.Status == task.TaskStatusFailed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("task %s did not fail", resp.TaskID)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if failing := score(); failing >= unhealthyAgent {
+		t.Errorf("score with failing tasks = %v, want below %v", failing, unhealthyAgent)
+	}
+}
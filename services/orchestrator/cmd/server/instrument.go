@@ -0,0 +1,71 @@
+package main
+
+import (
+	"sync"
+
+	"optiinfra/services/orchestrator/internal/coordination"
+	"optiinfra/services/orchestrator/internal/metrics"
+	"optiinfra/services/orchestrator/internal/registry"
+	"optiinfra/services/orchestrator/internal/task"
+)
+
+// instrumentMetrics feeds the Prometheus metrics served by /metrics and
+// /stats from task outcomes, registry events and coordination
+func instrumentMetrics(m *metrics.Metrics, reg *registry.Registry, tr *task.Router, coord *coordination.Coordinator) {
+	tr.SubscribeOutcomes(func(outcome task.TaskOutcome) {
+		m.RecordTaskRouted(outcome.AgentID, string(outcome.Type), string(outcome.Status))
+	})
+
+	counter := &activeAgentCounter{metrics: m, reg: reg, seen: make(map[registry.AgentType]bool)}
+	reg.Subscribe(func(event registry.Event) {
+		switch event.Type {
+		case registry.EventAgentRegistered:
+			m.RecordAgentRegistration()
+		case registry.EventAgentUnregistered:
+			m.RecordAgentDeregistration()
+		case registry.EventAgentReplaced, registry.EventAgentUpdated:
+		default:
+			return
+		}
+		counter.refresh()
+	})
+	counter.refresh()
+
+	coord.SetMetricsRecorder(m)
+}
+
+// activeAgentCounter recounts registered agents by type for the
+// active_agents gauge
+type activeAgentCounter struct {
+	mu      sync.Mutex
+	metrics *metrics.Metrics
+	reg     *registry.Registry
+	seen    map[registry.AgentType]bool // Types reported before, reset to 0 when gone
+}
+
+func (c *activeAgentCounter) refresh() {
+	listing := c.reg.ListAgents()
+	if listing.Err != nil {
+		return
+	}
+
+	counts := make(map[registry.AgentType]int)
+	for _, agentType := range registry.AgentTypes {
+		counts[agentType] = 0
+	}
+	for _, agent := range listing.Agents {
+		counts[agent.Type]++
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for agentType := range c.seen {
+		if _, ok := counts[agentType]; !ok {
+			counts[agentType] = 0
+		}
+	}
+	for agentType, count := range counts {
+		c.seen[agentType] = true
+		c.metrics.UpdateActiveAgents(string(agentType), float64(count))
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"optiinfra/services/orchestrator/internal/coordination"
+	"optiinfra/services/orchestrator/internal/metrics"
+	"optiinfra/services/orchestrator/internal/registry"
+	"optiinfra/services/orchestrator/internal/task"
+)
+
+func TestStatsCountRoutedTasksAndAgents(t *testing.T) {
+	agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var taskReq task.TaskRequest
+		json.NewDecoder(req.Body).Decode(&taskReq)
+		json.NewEncoder(w).Encode(task.TaskResponse{TaskID: taskReq.TaskID, Status: task.TaskStatusCompleted})
+	}))
+	defer agent.Close()
+
+	reg := registry.NewRegistryWithStore(registry.NewMemoryAgentStore())
+	tr := task.NewRouterWithConfig(task.NewMemoryTaskStore(), reg, task.DefaultConfig())
+	tr.Start()
+	defer tr.Stop()
+	instrumentMetrics(metrics.NewMetrics(), reg, tr, coordination.NewCoordinator())
+
+	host, portStr, _ := net.SplitHostPort(agent.Listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+	if _, err := reg.Register(&registry.RegistrationRequest{
+		Name:         "cost-1",
+		Type:         registry.AgentTypeCost,
+		Host:         host,
+		Port:         port,
+		Capabilities: []string{string(task.TaskTypeAnalyzeCost)},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := tr.SubmitTask(context.Background(), &task.TaskSubmitRequest{TaskType: task.TaskTypeAnalyzeCost, AgentType: "cost"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status, err := tr.GetTaskStatus(resp.TaskID)
+		if err == nil && status.Status == task.TaskStatusCompleted {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("task %s did not complete", resp.TaskID)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	snapshot, err := metrics.TakeSnapshot(prometheus.DefaultGatherer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.TasksRoutedByStatus["completed"] != 1 {
+		t.Errorf("completed tasks routed = %v, want 1", snapshot.TasksRoutedByStatus["completed"])
+	}
+	if snapshot.AgentRegistrations != 1 {
+		t.Errorf("agent registrations = %v, want 1", snapshot.AgentRegistrations)
+	}
+	if snapshot.ActiveAgents["cost"] != 1 || snapshot.ActiveAgents["performance"] != 0 {
+		t.Errorf("active agents = %v, want one cost agent", snapshot.ActiveAgents)
+	}
+}
@@ -2,51 +2,365 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
+	"optiinfra/services/orchestrator/internal/admin"
+	"optiinfra/services/orchestrator/internal/codec"
+	"optiinfra/services/orchestrator/internal/config"
 	"optiinfra/services/orchestrator/internal/coordination"
+	"optiinfra/services/orchestrator/internal/handlers"
+	"optiinfra/services/orchestrator/internal/lifecycle"
+	"optiinfra/services/orchestrator/internal/metrics"
 	"optiinfra/services/orchestrator/internal/registry"
 	"optiinfra/services/orchestrator/internal/task"
 )
 
+const (
+	// shutdownTimeout bounds how long the HTTP server waits for in-flight requests
+	shutdownTimeout = 5 * time.Second
+
+	// Defaults for per-request limits
+	defaultMaxRequestBodyBytes = 1 << 20 // 1 MiB
+	defaultRequestTimeout      = 30 * time.Second
+)
+
 func main() {
-	// Initialize Redis
-	redisClient := redis.NewClient(&redis.Options{
-		Addr:     getEnv("REDIS_ADDR", "localhost:6379"),
-		Password: getEnv("REDIS_PASSWORD", ""),
-		DB:       0,
-	})
+	// Timeouts, retries and agent liveness, with env overrides
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Invalid configuration:", err)
+	}
+
+	// Initialize storage (Redis unless STORAGE_BACKEND=memory)
+	var agentStore registry.AgentStore
+	var taskStore task.TaskStore
 
-	// Test Redis connection
-	ctx := context.Background()
-	if err := redisClient.Ping(ctx).Err(); err != nil {
-		log.Fatal("Failed to connect to Redis:", err)
+	storageCodec, err := codec.Parse(getEnv("STORAGE_CODEC", codec.JSON.Name()))
+	if err != nil {
+		log.Fatal("Invalid STORAGE_CODEC:", err)
 	}
-	log.Println("Connected to Redis")
+	codec.SetDefault(storageCodec)
+
+	backend := getEnv("STORAGE_BACKEND", "redis")
+	switch backend {
+	case "memory":
+		agentStore = registry.NewMemoryAgentStore()
+		taskStore = task.NewMemoryTaskStore()
+		log.Println("Using in-memory storage (single replica only)")
+	case "redis":
+		// Agents and tasks may live in separate databases or servers; stores
+		// with identical settings share a client
+		type clientKey struct {
+			addr, password string
+			db             int
+		}
+		clients := make(map[clientKey]*redis.Client)
+		connect := func(prefix string) *redis.Client {
+			opts := redisOptions(prefix)
+			key := clientKey{opts.Addr, opts.Password, opts.DB}
+			if client, ok := clients[key]; ok {
+				return client
+			}
+			client := redis.NewClient(&opts)
+
+			// Test Redis connection
+			if err := client.Ping(context.Background()).Err(); err != nil {
+				log.Fatalf("Failed to connect to Redis at %s (db %d): %v", opts.Addr, opts.DB, err)
+			}
+			log.Printf("Connected to Redis at %s (db %d)", opts.Addr, opts.DB)
+			clients[key] = client
+			return client
+		}
+
+		agentStore = registry.NewRedisAgentStore(connect("REDIS_AGENTS"))
+		taskStore = task.NewRedisTaskStore(connect("REDIS_TASKS"))
+	default:
+		log.Fatalf("Unknown STORAGE_BACKEND: %q", backend)
+	}
+
+	// Background components are started in order and stopped in reverse
+	lc := lifecycle.NewManager()
 
 	// Initialize Agent Registry
-	agentRegistry := registry.NewRegistry(redisClient)
-	agentRegistry.Start()
-	defer agentRegistry.Stop()
+	agentRegistry := registry.NewRegistryWithConfig(agentStore, cfg.Registry)
+	if spec, ok := os.LookupEnv("AGENT_DEFAULT_CAPABILITIES"); ok {
+		defaults, err := registry.ParseDefaultCapabilities(spec)
+		if err != nil {
+			log.Fatal("Invalid AGENT_DEFAULT_CAPABILITIES:", err)
+		}
+		agentRegistry.SetDefaultCapabilities(defaults)
+	}
+	if spec, ok := os.LookupEnv("AGENT_METADATA_SCHEMAS"); ok {
+		schemas, err := registry.ParseMetadataSchemas(spec)
+		if err != nil {
+			log.Fatal("Invalid AGENT_METADATA_SCHEMAS:", err)
+		}
+		agentRegistry.SetMetadataSchemas(schemas, getEnv("AGENT_METADATA_COERCE", "false") == "true")
+	}
+	if name := getEnv("AGENT_REACHABILITY_CHECK", ""); name != "" {
+		mode, err := registry.ParseReachabilityMode(name)
+		if err != nil {
+			log.Fatal("Invalid AGENT_REACHABILITY_CHECK:", err)
+		}
+		agentRegistry.SetReachabilityProbe(mode, getEnvDuration("AGENT_REACHABILITY_TIMEOUT", 0))
+	}
+	limits := registry.RegistrationLimits{MaxAgents: getEnvInt("MAX_AGENTS", 0)}
+	if spec := getEnv("MAX_AGENTS_PER_TYPE", ""); spec != "" {
+		byType, err := registry.ParseAgentTypeLimits(spec)
+		if err != nil {
+			log.Fatal("Invalid MAX_AGENTS_PER_TYPE:", err)
+		}
+		limits.MaxAgentsByType = byType
+	}
+	if limits.MaxAgents < 0 {
+		log.Fatal("MAX_AGENTS must not be negative")
+	}
+	agentRegistry.SetRegistrationLimits(limits)
+	if rate := getEnv("AGENT_REGISTRATION_RATE", ""); rate != "" {
+		perSecond, err := strconv.ParseFloat(rate, 64)
+		if err != nil || perSecond < 0 {
+			log.Fatalf("Invalid AGENT_REGISTRATION_RATE: %q", rate)
+		}
+		agentRegistry.SetRegistrationRate(perSecond, getEnvInt("AGENT_REGISTRATION_BURST", 10))
+	}
+	agentRegistry.SetLeaderElection(getEnv("HEALTH_CHECK_LEADER_ELECTION", "false") == "true")
+	lc.Add("agent registry", agentRegistry)
 
 	// Initialize Task Router
-	taskRouter := task.NewRouter(redisClient, agentRegistry)
+	taskRouter := task.NewRouterWithConfig(taskStore, agentRegistry, cfg.Task)
+	transport := task.DefaultTransportConfig()
+	transport.MaxIdleConnsPerHost = getEnvInt("AGENT_MAX_IDLE_CONNS_PER_HOST", transport.MaxIdleConnsPerHost)
+	transport.MaxConnsPerHost = getEnvInt("AGENT_MAX_CONNS_PER_HOST", transport.MaxConnsPerHost)
+	transport.IdleConnTimeout = getEnvDuration("AGENT_IDLE_CONN_TIMEOUT", transport.IdleConnTimeout)
+	transport.AttemptTimeout = getEnvDuration("AGENT_ATTEMPT_TIMEOUT", transport.AttemptTimeout)
+	taskRouter.SetTransportConfig(transport)
+	if rate := getEnv("AGENT_DISPATCH_RATE", ""); rate != "" {
+		perSecond, err := strconv.ParseFloat(rate, 64)
+		if err != nil || perSecond < 0 {
+			log.Fatalf("Invalid AGENT_DISPATCH_RATE: %q", rate)
+		}
+		taskRouter.SetAgentDispatchRate(perSecond, getEnvInt("AGENT_DISPATCH_BURST", 1))
+	}
+	if rate := getEnv("TASK_PRIORITY_AGING_PER_MINUTE", ""); rate != "" {
+		r, err := strconv.ParseFloat(rate, 64)
+		if err != nil || r < 0 {
+			log.Fatalf("Invalid TASK_PRIORITY_AGING_PER_MINUTE: %q", rate)
+		}
+		taskRouter.SetPriorityAgingRate(r)
+	}
+	runtimeCfg := taskRouter.RuntimeConfig()
+	if strategy := getEnv("LOAD_BALANCING_STRATEGY", ""); strategy != "" {
+		runtimeCfg.LoadBalancing = task.LoadBalancingStrategy(strategy)
+	}
+	if weight := getEnv("COST_WEIGHT", ""); weight != "" {
+		w, err := strconv.ParseFloat(weight, 64)
+		if err != nil {
+			log.Fatalf("Invalid COST_WEIGHT: %q", weight)
+		}
+		runtimeCfg.CostWeight = w
+	}
+	if err := taskRouter.UpdateRuntimeConfig(runtimeCfg); err != nil {
+		log.Fatal("Invalid load balancing settings:", err)
+	}
+	if url := getEnv("TASK_ADMISSION_WEBHOOK_URL", ""); url != "" {
+		taskRouter.SetAdmissionWebhook(task.NewAdmissionWebhook(url,
+			getEnvDuration("TASK_ADMISSION_TIMEOUT", 5*time.Second),
+			getEnv("TASK_ADMISSION_FAIL_OPEN", "false") == "true"))
+	}
+	if spec := getEnv("TASK_CUSTOMER_DEFAULTS", ""); spec != "" {
+		defaults, err := task.ParseCustomerDefaults(spec)
+		if err != nil {
+			log.Fatal("Invalid TASK_CUSTOMER_DEFAULTS:", err)
+		}
+		taskRouter.SetCustomerDefaults(defaults)
+	}
+	if name := getEnv("ORPHANED_TASK_POLICY", ""); name != "" {
+		policy, err := task.ParseOrphanPolicy(name)
+		if err != nil {
+			log.Fatal("Invalid ORPHANED_TASK_POLICY:", err)
+		}
+		taskRouter.SetOrphanPolicy(policy)
+	}
+	if threshold := getEnv("POISON_TASK_AGENT_THRESHOLD", ""); threshold != "" {
+		n, err := strconv.Atoi(threshold)
+		if err != nil || n < 0 {
+			log.Fatalf("Invalid POISON_TASK_AGENT_THRESHOLD: %q", threshold)
+		}
+		taskRouter.SetPoisonThreshold(n)
+	}
+	scanInterval, timeoutGrace := taskRouter.TimeoutWatchdog()
+	scanInterval = getEnvDuration("TASK_TIMEOUT_SCAN_INTERVAL", scanInterval)
+	timeoutGrace = getEnvDuration("TASK_TIMEOUT_GRACE", timeoutGrace)
+	if scanInterval < 0 || timeoutGrace < 0 {
+		log.Fatal("TASK_TIMEOUT_SCAN_INTERVAL and TASK_TIMEOUT_GRACE must not be negative")
+	}
+	taskRouter.SetTimeoutWatchdog(scanInterval, timeoutGrace)
+	if name := getEnv("TASK_WATCHDOG_POLICY", ""); name != "" {
+		policy, err := task.ParseWatchdogPolicy(name)
+		if err != nil {
+			log.Fatal("Invalid TASK_WATCHDOG_POLICY:", err)
+		}
+		taskRouter.SetWatchdogPolicy(policy)
+	}
+	if boost := getEnv("RETRY_PRIORITY_BOOST", ""); boost != "" {
+		n, err := strconv.Atoi(boost)
+		if err != nil || n < 0 {
+			log.Fatalf("Invalid RETRY_PRIORITY_BOOST: %q", boost)
+		}
+		taskRouter.SetRetryPriorityBoost(n)
+	}
+	if keys := getEnv("TASK_REDACT_KEYS", ""); keys != "" {
+		taskRouter.SetRedactedKeys(strings.Split(keys, ","))
+	}
+	if types := getEnv("TASK_EXTRA_TYPES", ""); types != "" {
+		taskRouter.SetExtraTaskTypes(strings.Split(types, ","))
+	}
+	maxParameterBytes := getEnvInt("TASK_MAX_PARAMETER_BYTES", 0)
+	if maxParameterBytes < 0 {
+		log.Fatal("TASK_MAX_PARAMETER_BYTES must not be negative")
+	}
+	taskRouter.SetMaxParameterBytes(maxParameterBytes)
+	maxQueued := getEnvInt("MAX_QUEUED_TASKS", 0)
+	if maxQueued < 0 {
+		log.Fatal("MAX_QUEUED_TASKS must not be negative")
+	}
+	taskRouter.SetMaxQueuedTasks(maxQueued)
+	lc.Add("task router", taskRouter)
 	log.Println("Task router initialized")
 
 	// Initialize Coordinator
 	coordinator := coordination.NewCoordinator()
+	retention := coordination.DefaultRetentionPolicy()
+	retention.Approvals = getEnvDuration("APPROVAL_RETENTION", retention.Approvals)
+	retention.Plans = getEnvDuration("PLAN_RETENTION", retention.Plans)
+	if retention.Approvals < 0 || retention.Plans < 0 {
+		log.Fatal("APPROVAL_RETENTION and PLAN_RETENTION must not be negative")
+	}
+	coordinator.SetRetentionPolicy(retention)
+	if getEnv("PLAN_STEPS_VIA_ROUTER", "false") == "true" {
+		coordinator.SetStepRunner(coordination.NewTaskStepRunner(taskRouter, agentRegistry))
+	}
+	if maxSteps := getEnv("MAX_PLAN_STEPS", ""); maxSteps != "" {
+		n, err := strconv.Atoi(maxSteps)
+		if err != nil || n <= 0 {
+			log.Fatalf("Invalid MAX_PLAN_STEPS: %q", maxSteps)
+		}
+		coordinator.SetMaxPlanSteps(n)
+	}
+	if spec := getEnv("ACTION_CONCURRENCY_LIMITS", ""); spec != "" {
+		limits, err := coordination.ParseActionLimits(spec)
+		if err != nil {
+			log.Fatal("Invalid ACTION_CONCURRENCY_LIMITS:", err)
+		}
+		coordinator.SetActionLimits(limits)
+	}
+	if name := getEnv("COORDINATOR_SHUTDOWN_POLICY", ""); name != "" {
+		policy, err := coordination.ParseShutdownPolicy(name)
+		if err != nil {
+			log.Fatal("Invalid COORDINATOR_SHUTDOWN_POLICY:", err)
+		}
+		coordinator.SetShutdownPolicy(policy)
+	}
+	// State checkpointed as plans run and flushed at shutdown is reloaded at
+	// startup
+	var stateFile *coordination.FileStateSink
+	if path := getEnv("COORDINATION_STATE_FILE", ""); path != "" {
+		stateFile = coordination.NewFileStateSink(path)
+		coordinator.SetStateSink(stateFile)
+	}
+	recoveryPolicy, err := coordination.ParseRecoveryPolicy(getEnv("PLAN_RECOVERY_POLICY", string(coordination.RecoveryResume)))
+	if err != nil {
+		log.Fatal("Invalid PLAN_RECOVERY_POLICY:", err)
+	}
+	if name := getEnv("CONFLICT_RESOLUTION_STRATEGY", ""); name != "" {
+		strategy, err := coordination.ParseResolutionStrategy(name)
+		if err != nil {
+			log.Fatal("Invalid CONFLICT_RESOLUTION_STRATEGY:", err)
+		}
+		coordinator.SetResolutionStrategy(strategy)
+	}
+	if getEnv("AGENT_FEEDBACK_ENABLED", "false") == "true" {
+		coordinator.SetFeedbackNotifier(coordination.NewHTTPFeedbackNotifier(func(agentID string) (string, error) {
+			agent, err := agentRegistry.GetAgent(agentID)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("http://%s:%d", agent.Host, agent.Port), nil
+		}))
+	}
+	skewTolerance := getEnvDuration("APPROVAL_EXPIRY_SKEW_TOLERANCE", 0)
+	if skewTolerance < 0 {
+		log.Fatal("APPROVAL_EXPIRY_SKEW_TOLERANCE must not be negative")
+	}
+	coordinator.SetApprovalSkewTolerance(skewTolerance)
+	coordinator.SetScopedActionConflicts(getEnv("SCOPED_ACTION_CONFLICTS", "true") == "true")
+	coordinator.SetConflictCompaction(getEnv("COMPACT_CONFLICTS", "false") == "true")
+	if spec := getEnv("CONFLICT_SEVERITY_POLICIES", ""); spec != "" {
+		policies, err := coordination.ParseSeverityPolicies(spec)
+		if err != nil {
+			log.Fatal("Invalid CONFLICT_SEVERITY_POLICIES:", err)
+		}
+		coordinator.SetSeverityPolicies(policies)
+	}
+	if spec := getEnv("MAINTENANCE_WINDOWS", ""); spec != "" {
+		loc, err := time.LoadLocation(getEnv("MAINTENANCE_TIMEZONE", "UTC"))
+		if err != nil {
+			log.Fatal("Invalid MAINTENANCE_TIMEZONE:", err)
+		}
+		schedule, err := coordination.ParseMaintenanceSchedule(spec, loc)
+		if err != nil {
+			log.Fatal("Invalid MAINTENANCE_WINDOWS:", err)
+		}
+		coordinator.SetMaintenanceSchedule(schedule, getEnv("MAINTENANCE_DEFER", "true") == "true")
+		log.Printf("Execution restricted to maintenance windows: %s (%s)", spec, loc)
+	}
+	lc.Add("coordinator", coordinator)
 	log.Println("Coordinator initialized")
 
 	// Initialize Gin
-	router := gin.Default()
+	router := gin.New()
+	// Sensitive headers, query parameters and body fields are masked in logs
+	requestLog := handlers.RequestLogConfig{
+		Redactor: handlers.NewLogRedactor(strings.Split(getEnv("LOG_REDACT_FIELDS", ""), ",")...),
+		Bodies:   getEnv("LOG_REQUEST_BODIES", "false") == "true",
+		// Heartbeats arrive every few seconds per agent and drown real signal
+		Skip: func(c *gin.Context) bool {
+			return c.FullPath() == "/agents/:id/heartbeat"
+		},
+	}
+	if headers := getEnv("LOG_REQUEST_HEADERS", ""); headers != "" {
+		requestLog.Headers = strings.Split(headers, ",")
+	}
+	router.Use(handlers.RequestLogger(requestLog))
+	router.Use(gin.Recovery())
+	router.HandleMethodNotAllowed = true
+	router.NoRoute(handlers.NotFound)
+	router.NoMethod(handlers.MethodNotAllowed)
+	router.Use(handlers.BodyLimit(int64(getEnvInt("MAX_REQUEST_BODY_BYTES", defaultMaxRequestBodyBytes))))
+	// Unknown JSON fields are ignored unless strict decoding is enabled
+	handlers.SetStrictJSON(getEnv("STRICT_JSON", "false") == "true")
+
+	orchestratorMetrics := metrics.NewMetrics()
+	router.Use(metrics.GinMiddleware(orchestratorMetrics))
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	router.GET("/stats", metrics.SnapshotHandler(prometheus.DefaultGatherer))
+	instrumentMetrics(orchestratorMetrics, agentRegistry, taskRouter, coordinator)
+
+	// Service and build info
+	router.GET("/", handlers.Root(handlers.BuildInfo(getEnv("SERVICE_NAME", "orchestrator"))))
 
 	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
@@ -62,6 +376,18 @@ func main() {
 		})
 	})
 
+	// Composite health score
+	healthWeights := handlers.DefaultHealthWeights()
+	if spec := getEnv("HEALTH_SCORE_WEIGHTS", ""); spec != "" {
+		healthWeights, err = handlers.ParseHealthWeights(spec)
+		if err != nil {
+			log.Fatal("Invalid HEALTH_SCORE_WEIGHTS:", err)
+		}
+	}
+	router.GET("/health/score", handlers.HealthScoreHandler(func() handlers.HealthSignals {
+		return collectHealthSignals(agentRegistry, taskRouter)
+	}, healthWeights))
+
 	// Register routes
 	registryHandler := registry.NewHandler(agentRegistry)
 	registryHandler.RegisterRoutes(router)
@@ -70,23 +396,81 @@ func main() {
 	taskHandler.RegisterRoutes(router)
 
 	coordinationHandler := coordination.NewHandler(coordinator)
+	if spec := getEnv("COORDINATION_ERROR_STATUSES", ""); spec != "" {
+		statuses, err := coordination.ParseErrorStatuses(spec)
+		if err != nil {
+			log.Fatal("Invalid COORDINATION_ERROR_STATUSES:", err)
+		}
+		coordinationHandler.SetErrorStatuses(statuses)
+	}
 	coordinationHandler.RegisterRoutes(router)
 
 	// Start server
 	port := getEnv("PORT", "8080")
+
+	// Settings reported by /admin/config that need a restart to change
+	staticConfig := map[string]interface{}{
+		"port":                         port,
+		"storage_backend":              backend,
+		"storage_codec":                storageCodec.Name(),
+		"max_idle_conns_per_host":      transport.MaxIdleConnsPerHost,
+		"max_conns_per_host":           transport.MaxConnsPerHost,
+		"health_check_leader_election": getEnv("HEALTH_CHECK_LEADER_ELECTION", "false") == "true",
+		"strict_json":                  getEnv("STRICT_JSON", "false") == "true",
+		"max_request_body_bytes":       getEnvInt("MAX_REQUEST_BODY_BYTES", defaultMaxRequestBodyBytes),
+		"request_timeout":              getEnvDuration("REQUEST_TIMEOUT", defaultRequestTimeout).String(),
+		"task_max_timeout":             cfg.Task.MaxTaskTimeout.String(),
+		"task_result_ttl":              cfg.Task.ResultTTL.String(),
+		"agent_ttl":                    cfg.Registry.AgentTTL.String(),
+		"health_check_interval":        cfg.Registry.HealthCheckInterval.String(),
+	}
+	// The admin API stays unmounted without a token unless explicitly opened
+	adminToken := getEnv("ADMIN_TOKEN", "")
+	switch {
+	case adminToken != "":
+		admin.NewHandler(taskRouter, agentRegistry, coordinator, staticConfig, adminToken).RegisterRoutes(router)
+	case getEnv("ADMIN_ALLOW_UNAUTHENTICATED", "false") == "true":
+		log.Println("Warning: ADMIN_TOKEN not set, admin endpoints are unauthenticated")
+		admin.NewHandler(taskRouter, agentRegistry, coordinator, staticConfig, "").RegisterRoutes(router)
+	default:
+		log.Println("ADMIN_TOKEN not set, admin endpoints disabled")
+	}
 	log.Printf("Starting orchestrator on port %s", port)
 
 	// Graceful shutdown
+	// Streaming exports and task streams flush incrementally and are exempt
+	// from the timeout
 	srv := &http.Server{
-		Addr:    ":" + port,
-		Handler: router,
+		Addr: ":" + port,
+		Handler: handlers.WithTimeout(router, getEnvDuration("REQUEST_TIMEOUT", defaultRequestTimeout),
+			"/coordination/history", "/metrics", "/tasks/*/stream"),
 	}
 
-	go func() {
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed: %v", err)
+	lc.Add("http server", lifecycle.Hooks{
+		OnStart: func() {
+			go func() {
+				if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+					log.Fatalf("Server failed: %v", err)
+				}
+			}()
+		},
+		OnStop: func() {
+			ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			defer cancel()
+			if err := srv.Shutdown(ctx); err != nil {
+				log.Printf("Server forced to shutdown: %v", err)
+			}
+		},
+	})
+
+	lc.Start()
+
+	// Resume or roll back plans the previous run left executing
+	if stateFile != nil {
+		if _, err := coordinator.Recover(stateFile, recoveryPolicy); err != nil {
+			log.Printf("Failed to recover coordinator state: %v", err)
 		}
-	}()
+	}
 
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
@@ -95,20 +479,79 @@ func main() {
 
 	log.Println("Shutting down server...")
 
-	// Graceful shutdown with timeout
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// Stop the HTTP server first, then drain background components
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 2*shutdownTimeout)
 	defer cancel()
 
-	if err := srv.Shutdown(shutdownCtx); err != nil {
-		log.Fatal("Server forced to shutdown:", err)
+	if err := lc.Shutdown(shutdownCtx); err != nil {
+		log.Fatal("Forced shutdown: ", err)
 	}
 
 	log.Println("Server exited")
 }
 
+// collectHealthSignals measures the inputs of the composite health score.
+// Reading the task counters doubles as the storage round trip; the failure
+// rate covers only recently finished tasks.
+func collectHealthSignals(reg *registry.Registry, tr *task.Router) handlers.HealthSignals {
+	var signals handlers.HealthSignals
+
+	start := time.Now()
+	_, err := tr.Stats()
+	signals.StorageLatency = time.Since(start)
+	signals.StorageErr = err
+	signals.TaskFailureRate, _ = tr.RecentFailureRate(0)
+
+	listing := reg.ListAgents()
+	signals.TotalAgents = len(listing.Agents) + len(listing.Unavailable)
+	for _, agent := range listing.Agents {
+		if agent.Status == registry.AgentStatusHealthy {
+			signals.HealthyAgents++
+		}
+	}
+
+	signals.QueueDepth, signals.QueueCapacity = tr.QueueDepth()
+	return signals
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+// redisOptions reads the connection settings for one store from
+// <prefix>_ADDR, <prefix>_PASSWORD and <prefix>_DB, falling back to the
+// shared REDIS_ADDR, REDIS_PASSWORD and REDIS_DB
+func redisOptions(prefix string) redis.Options {
+	return redis.Options{
+		Addr:     getEnv(prefix+"_ADDR", getEnv("REDIS_ADDR", "localhost:6379")),
+		Password: getEnv(prefix+"_PASSWORD", getEnv("REDIS_PASSWORD", "")),
+		DB:       getEnvInt(prefix+"_DB", getEnvInt("REDIS_DB", 0)),
+	}
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("Invalid %s: %q", key, value)
+	}
+	return n
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("Invalid %s: %q", key, value)
+	}
+	return d
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+
+	"optiinfra/services/orchestrator/internal/registry"
+	"optiinfra/services/orchestrator/internal/task"
+)
+
+func TestRedisOptionsFallBackToSharedSettings(t *testing.T) {
+	t.Setenv("REDIS_ADDR", "redis:6379")
+	t.Setenv("REDIS_PASSWORD", "shared")
+	t.Setenv("REDIS_DB", "3")
+	t.Setenv("REDIS_TASKS_ADDR", "tasks-redis:6379")
+	t.Setenv("REDIS_TASKS_DB", "5")
+
+	if opts := redisOptions("REDIS_AGENTS"); opts.Addr != "redis:6379" || opts.Password != "shared" || opts.DB != 3 {
+		t.Errorf("agent options = %+v, want the shared settings", opts)
+	}
+	if opts := redisOptions("REDIS_TASKS"); opts.Addr != "tasks-redis:6379" || opts.Password != "shared" || opts.DB != 5 {
+		t.Errorf("task options = %+v, want the task address and database", opts)
+	}
+}
+
+func TestTasksAndAgentsLandInConfiguredDatabases(t *testing.T) {
+	srv := miniredis.RunT(t)
+	t.Setenv("REDIS_ADDR", srv.Addr())
+	t.Setenv("REDIS_AGENTS_DB", "1")
+	t.Setenv("REDIS_TASKS_DB", "2")
+
+	client := func(prefix string) *redis.Client {
+		opts := redisOptions(prefix)
+		c := redis.NewClient(&opts)
+		t.Cleanup(func() { c.Close() })
+		return c
+	}
+	agentStore := registry.NewRedisAgentStore(client("REDIS_AGENTS"))
+	taskStore := task.NewRedisTaskStore(client("REDIS_TASKS"))
+
+	ctx := context.Background()
+	if err := agentStore.SaveAgent(ctx, &registry.Agent{ID: "agent-1", Type: registry.AgentTypeCost}); err != nil {
+		t.Fatal(err)
+	}
+	if err := taskStore.SaveTask(ctx, &task.Task{ID: "task-1", Type: task.TaskTypeAnalyzeCost, Status: task.TaskStatusPending, CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	agentKeys, taskKeys := srv.DB(1).Keys(), srv.DB(2).Keys()
+	if len(agentKeys) == 0 || len(taskKeys) == 0 {
+		t.Fatalf("agent db keys %v, task db keys %v", agentKeys, taskKeys)
+	}
+	for _, key := range agentKeys {
+		if strings.HasPrefix(key, "task") {
+			t.Errorf("task key %s in the agent database", key)
+		}
+	}
+	if !contains(taskKeys, "task:task-1") {
+		t.Errorf("task db keys = %v, want task:task-1", taskKeys)
+	}
+	for _, key := range taskKeys {
+		if strings.Contains(key, "agent-1") {
+			t.Errorf("agent key %s in the task database", key)
+		}
+	}
+	if keys := srv.DB(0).Keys(); len(keys) != 0 {
+		t.Errorf("default database keys = %v, want none", keys)
+	}
+}
+
+func contains(values []string, want string) bool {
+	for _, v := range values {
+		if v == want {
+			return true
+		}
+	}
+	return false
+}
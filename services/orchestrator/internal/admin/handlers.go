@@ -0,0 +1,261 @@
+package admin
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"optiinfra/services/orchestrator/internal/coordination"
+	"optiinfra/services/orchestrator/internal/registry"
+	"optiinfra/services/orchestrator/internal/task"
+)
+
+// Config is the hot-reloadable configuration exposed over the admin API.
+// Durations use Go duration syntax, e.g. "5s".
+type Config struct {
+	LoadBalancing      string  `json:"load_balancing"`
+	DefaultMaxRetries  int     `json:"default_max_retries"`
+	RetryDelay         string  `json:"retry_delay"`
+	DefaultTaskTimeout string  `json:"default_task_timeout"`
+	AttemptTimeout     string  `json:"attempt_timeout"`
+	PriorityAgingRate  float64 `json:"priority_aging_per_minute"`
+	CostWeight         float64 `json:"cost_weight"`
+	HeartbeatTimeout   string  `json:"heartbeat_timeout"`
+}
+
+// ConfigResponse is returned by GET and PATCH /admin/config
+type ConfigResponse struct {
+	Reloadable Config                 `json:"reloadable"`
+	Static     map[string]interface{} `json:"static"`
+}
+
+// PauseResponse is returned by the pause and resume endpoints
+type PauseResponse struct {
+	Paused   bool       `json:"paused"`
+	PausedAt *time.Time `json:"paused_at,omitempty"`
+}
+
+// Handler serves the admin API
+type Handler struct {
+	router      *task.Router
+	registry    *registry.Registry
+	coordinator *coordination.Coordinator
+	static      map[string]interface{}
+	token       string
+
+	// Serializes config updates so each PATCH applies as a whole
+	mu sync.Mutex
+}
+
+// NewHandler creates an admin handler. static lists settings that are
+// reported but require a restart to change. A non-empty token must be sent
+// as X-Admin-Token on every admin request.
+func NewHandler(router *task.Router, reg *registry.Registry, coordinator *coordination.Coordinator, static map[string]interface{}, token string) *Handler {
+	return &Handler{
+		router:      router,
+		registry:    reg,
+		coordinator: coordinator,
+		static:      static,
+		token:       token,
+	}
+}
+
+// RegisterRoutes registers all admin routes
+func (h *Handler) RegisterRoutes(r *gin.Engine) {
+	admin := r.Group("/admin", h.requireToken)
+	{
+		admin.GET("/config", h.GetConfig)
+		admin.PATCH("/config", h.UpdateConfig)
+		admin.GET("/pause", h.PauseStatus)
+		admin.POST("/pause", h.Pause)
+		admin.POST("/resume", h.Resume)
+	}
+}
+
+// GetConfig returns the current effective configuration
+func (h *Handler) GetConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, h.configResponse())
+}
+
+// UpdateConfig applies a partial update of reloadable settings. Fields that
+// are static or unknown reject the whole update.
+func (h *Handler) UpdateConfig(c *gin.Context) {
+	var patch map[string]json.RawMessage
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	for field := range patch {
+		if _, ok := h.static[field]; ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s is not reloadable; restart to change it", field)})
+			return
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	cfg, err := mergeConfig(h.currentConfig(), patch)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	runtime, heartbeatTimeout, err := cfg.parse()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.router.ValidateRuntimeConfig(runtime); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if heartbeatTimeout <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "heartbeat_timeout must be positive"})
+		return
+	}
+
+	// Everything is validated up front so the router and registry update together
+	if err := h.router.UpdateRuntimeConfig(runtime); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.registry.SetHeartbeatTimeout(heartbeatTimeout); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.configResponse())
+}
+
+// PauseStatus reports whether execution is paused
+func (h *Handler) PauseStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, h.pauseResponse())
+}
+
+// Pause halts all new task dispatch and plan execution. Submissions are
+// rejected with 503 and plans are deferred until resumed; work already
+// sent to agents continues.
+func (h *Handler) Pause(c *gin.Context) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.router.PauseExecution()
+	h.coordinator.PauseExecution()
+	c.JSON(http.StatusOK, h.pauseResponse())
+}
+
+// Resume re-enables task dispatch and starts deferred plans
+func (h *Handler) Resume(c *gin.Context) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.router.ResumeExecution()
+	h.coordinator.ResumeExecution()
+	c.JSON(http.StatusOK, h.pauseResponse())
+}
+
+func (h *Handler) pauseResponse() PauseResponse {
+	pausedAt := h.router.ExecutionPausedSince()
+	return PauseResponse{
+		Paused:   pausedAt != nil,
+		PausedAt: pausedAt,
+	}
+}
+
+func (h *Handler) requireToken(c *gin.Context) {
+	if h.token == "" {
+		c.Next()
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(c.GetHeader("X-Admin-Token")), []byte(h.token)) != 1 {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid admin token"})
+		return
+	}
+	c.Next()
+}
+
+func (h *Handler) configResponse() ConfigResponse {
+	return ConfigResponse{
+		Reloadable: h.currentConfig(),
+		Static:     h.static,
+	}
+}
+
+func (h *Handler) currentConfig() Config {
+	runtime := h.router.RuntimeConfig()
+	return Config{
+		LoadBalancing:      string(runtime.LoadBalancing),
+		DefaultMaxRetries:  runtime.DefaultMaxRetries,
+		RetryDelay:         runtime.RetryDelay.String(),
+		DefaultTaskTimeout: runtime.DefaultTaskTimeout.String(),
+		AttemptTimeout:     runtime.AttemptTimeout.String(),
+		PriorityAgingRate:  runtime.PriorityAgingRate,
+		CostWeight:         runtime.CostWeight,
+		HeartbeatTimeout:   h.registry.HeartbeatTimeout().String(),
+	}
+}
+
+// mergeConfig overlays the patch onto the current config, rejecting unknown fields
+func mergeConfig(current Config, patch map[string]json.RawMessage) (Config, error) {
+	base, err := json.Marshal(current)
+	if err != nil {
+		return Config{}, err
+	}
+	var merged map[string]json.RawMessage
+	if err := json.Unmarshal(base, &merged); err != nil {
+		return Config{}, err
+	}
+	for field, value := range patch {
+		if _, ok := merged[field]; !ok {
+			return Config{}, fmt.Errorf("unknown config field %q", field)
+		}
+		merged[field] = value
+	}
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return Config{}, err
+	}
+	var cfg Config
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("invalid config: %w", err)
+	}
+	return cfg, nil
+}
+
+// parse converts the API representation into router and registry settings
+func (cfg Config) parse() (task.RuntimeConfig, time.Duration, error) {
+	durations := map[string]string{
+		"retry_delay":          cfg.RetryDelay,
+		"default_task_timeout": cfg.DefaultTaskTimeout,
+		"attempt_timeout":      cfg.AttemptTimeout,
+		"heartbeat_timeout":    cfg.HeartbeatTimeout,
+	}
+	parsed := make(map[string]time.Duration, len(durations))
+	for field, value := range durations {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return task.RuntimeConfig{}, 0, fmt.Errorf("invalid %s: %w", field, err)
+		}
+		parsed[field] = d
+	}
+
+	return task.RuntimeConfig{
+		LoadBalancing:      task.LoadBalancingStrategy(cfg.LoadBalancing),
+		DefaultMaxRetries:  cfg.DefaultMaxRetries,
+		RetryDelay:         parsed["retry_delay"],
+		DefaultTaskTimeout: parsed["default_task_timeout"],
+		AttemptTimeout:     parsed["attempt_timeout"],
+		PriorityAgingRate:  cfg.PriorityAgingRate,
+		CostWeight:         cfg.CostWeight,
+	}, parsed["heartbeat_timeout"], nil
+}
@@ -0,0 +1,125 @@
+package admin
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"optiinfra/services/orchestrator/internal/coordination"
+	"optiinfra/services/orchestrator/internal/registry"
+	"optiinfra/services/orchestrator/internal/task"
+)
+
+const testToken = "secret"
+
+func newTestAdmin(t *testing.T) (*gin.Engine, *task.Router) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	reg := registry.NewRegistryWithStore(registry.NewMemoryAgentStore())
+	tr := task.NewRouterWithConfig(task.NewMemoryTaskStore(), reg, task.DefaultConfig())
+	static := map[string]interface{}{"port": "8080"}
+
+	router := gin.New()
+	NewHandler(tr, reg, coordination.NewCoordinator(), static, testToken).RegisterRoutes(router)
+	return router, tr
+}
+
+func doAdmin(t *testing.T, router *gin.Engine, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("X-Admin-Token", token)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func decodeConfig(t *testing.T, w *httptest.ResponseRecorder) ConfigResponse {
+	t.Helper()
+	var resp ConfigResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode %s: %v", w.Body.String(), err)
+	}
+	return resp
+}
+
+func TestGetConfig(t *testing.T) {
+	router, tr := newTestAdmin(t)
+
+	w := doAdmin(t, router, http.MethodGet, "/admin/config", testToken, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	resp := decodeConfig(t, w)
+	if want := tr.RuntimeConfig().DefaultMaxRetries; resp.Reloadable.DefaultMaxRetries != want {
+		t.Errorf("default_max_retries = %d, want %d", resp.Reloadable.DefaultMaxRetries, want)
+	}
+	if resp.Static["port"] != "8080" {
+		t.Errorf("static settings = %v", resp.Static)
+	}
+}
+
+func TestUpdateReloadableConfig(t *testing.T) {
+	router, tr := newTestAdmin(t)
+
+	w := doAdmin(t, router, http.MethodPatch, "/admin/config", testToken, map[string]interface{}{
+		"default_max_retries": 7,
+		"retry_delay":         "250ms",
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	if resp := decodeConfig(t, w); resp.Reloadable.DefaultMaxRetries != 7 || resp.Reloadable.RetryDelay != "250ms" {
+		t.Errorf("response config = %+v", resp.Reloadable)
+	}
+
+	// The router picks up the change without a restart
+	runtime := tr.RuntimeConfig()
+	if runtime.DefaultMaxRetries != 7 || runtime.RetryDelay.String() != "250ms" {
+		t.Errorf("router config = %+v", runtime)
+	}
+	if resp := decodeConfig(t, doAdmin(t, router, http.MethodGet, "/admin/config", testToken, nil)); resp.Reloadable.DefaultMaxRetries != 7 {
+		t.Errorf("GET after update = %+v", resp.Reloadable)
+	}
+}
+
+func TestUpdateConfigRejectsInvalidFields(t *testing.T) {
+	router, tr := newTestAdmin(t)
+	before := tr.RuntimeConfig()
+
+	for name, patch := range map[string]map[string]interface{}{
+		"static":   {"port": "9090", "default_max_retries": 7},
+		"unknown":  {"bogus": 1},
+		"duration": {"retry_delay": "soon"},
+	} {
+		if w := doAdmin(t, router, http.MethodPatch, "/admin/config", testToken, patch); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", name, w.Code)
+		}
+	}
+	// Rejected updates apply nothing
+	if after := tr.RuntimeConfig(); after.DefaultMaxRetries != before.DefaultMaxRetries || after.RetryDelay != before.RetryDelay {
+		t.Errorf("config changed by rejected updates: %+v", after)
+	}
+}
+
+func TestAdminRequiresToken(t *testing.T) {
+	router, _ := newTestAdmin(t)
+	for _, token := range []string{"", "wrong"} {
+		if w := doAdmin(t, router, http.MethodGet, "/admin/config", token, nil); w.Code != http.StatusUnauthorized {
+			t.Errorf("token %q: status = %d, want 401", token, w.Code)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"optiinfra/services/orchestrator/internal/coordination"
+	"optiinfra/services/orchestrator/internal/registry"
+	"optiinfra/services/orchestrator/internal/task"
+)
+
+type stepRunnerFunc func(ctx context.Context, step *coordination.ExecutionStep) (map[string]interface{}, error)
+
+func (f stepRunnerFunc) RunStep(ctx context.Context, step *coordination.ExecutionStep) (map[string]interface{}, error) {
+	return f(ctx, step)
+}
+
+// newPausableService serves the admin and task routes over a started router
+// with one cost agent that completes every task, and a coordinator whose
+// steps succeed immediately
+func newPausableService(t *testing.T) (*gin.Engine, *task.Router, *coordination.Coordinator) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var taskReq task.TaskRequest
+		json.NewDecoder(req.Body).Decode(&taskReq)
+		json.NewEncoder(w).Encode(task.TaskResponse{TaskID: taskReq.TaskID, Status: task.TaskStatusCompleted})
+	}))
+	t.Cleanup(agent.Close)
+	host, portStr, _ := strings.Cut(strings.TrimPrefix(agent.URL, "http://"), ":")
+	port, _ := strconv.Atoi(portStr)
+
+	reg := registry.NewRegistryWithStore(registry.NewMemoryAgentStore())
+	if _, err := reg.Register(&registry.RegistrationRequest{Name: "cost-1", Type: registry.AgentTypeCost, Host: host, Port: port}); err != nil {
+		t.Fatal(err)
+	}
+	tr := task.NewRouterWithConfig(task.NewMemoryTaskStore(), reg, task.DefaultConfig())
+	tr.Start()
+	t.Cleanup(tr.Stop)
+
+	coordinator := coordination.NewCoordinator()
+	coordinator.SetStepRunner(stepRunnerFunc(func(ctx context.Context, step *coordination.ExecutionStep) (map[string]interface{}, error) {
+		return nil, nil
+	}))
+
+	router := gin.New()
+	NewHandler(tr, reg, coordinator, nil, testToken).RegisterRoutes(router)
+	task.NewHandler(tr).RegisterRoutes(router)
+	return router, tr, coordinator
+}
+
+func submitTask(t *testing.T, router *gin.Engine) *httptest.ResponseRecorder {
+	t.Helper()
+	return doAdmin(t, router, http.MethodPost, "/tasks", "", map[string]string{"task_type": "analyze_cost", "agent_type": "cost"})
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestPauseRejectsSubmissionsUntilResume(t *testing.T) {
+	router, tr, _ := newPausableService(t)
+
+	w := doAdmin(t, router, http.MethodPost, "/admin/pause", testToken, nil)
+	var pause PauseResponse
+	json.Unmarshal(w.Body.Bytes(), &pause)
+	if w.Code != http.StatusOK || !pause.Paused || pause.PausedAt == nil {
+		t.Fatalf("pause: status %d: %s", w.Code, w.Body.String())
+	}
+	if w := submitTask(t, router); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("submit while paused: status %d, want 503", w.Code)
+	}
+	if w := doAdmin(t, router, http.MethodGet, "/admin/pause", testToken, nil); !strings.Contains(w.Body.String(), `"paused":true`) {
+		t.Errorf("pause status = %s", w.Body.String())
+	}
+
+	w = doAdmin(t, router, http.MethodPost, "/admin/resume", testToken, nil)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"paused":false`) {
+		t.Fatalf("resume: status %d: %s", w.Code, w.Body.String())
+	}
+	w = submitTask(t, router)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("submit after resume: status %d: %s", w.Code, w.Body.String())
+	}
+	var resp task.TaskSubmitResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	waitFor(t, "the task to complete", func() bool {
+		status, err := tr.GetTaskStatus(resp.TaskID)
+		return err == nil && status.Status == task.TaskStatusCompleted
+	})
+}
+
+func TestPauseDefersPlansUntilResume(t *testing.T) {
+	router, _, coordinator := newPausableService(t)
+	doAdmin(t, router, http.MethodPost, "/admin/pause", testToken, nil)
+
+	resp, err := coordinator.Coordinate(&coordination.CoordinationRequest{
+		CustomerID: "cust-1",
+		Recommendations: []*coordination.Recommendation{{
+			ID:                "rec-1",
+			AgentID:           "agent-1",
+			AgentType:         "cost",
+			Type:              coordination.RecommendationTypeCost,
+			Action:            "migrate_to_spot",
+			RiskLevel:         coordination.RiskLevelLow,
+			EstimatedSavings:  100,
+			AffectedResources: []string{"node-1"},
+			Confidence:        0.9,
+			CreatedAt:         time.Now(),
+		}},
+		AutoApprove: true,
+		ExecuteNow:  true,
+	})
+	if err != nil || len(resp.ExecutionPlans) != 1 {
+		t.Fatalf("coordinate: %v", err)
+	}
+	planID := resp.ExecutionPlans[0].ID
+	planStatus := func() coordination.ExecutionStatus {
+		plan, err := coordinator.GetExecutionPlan(planID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return plan.Status
+	}
+	waitFor(t, "the plan to be deferred", func() bool { return planStatus() == coordination.ExecutionStatusDeferred })
+
+	doAdmin(t, router, http.MethodPost, "/admin/resume", testToken, nil)
+	waitFor(t, "the deferred plan to complete", func() bool { return planStatus() == coordination.ExecutionStatusCompleted })
+}
@@ -0,0 +1,111 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+
+	"github.com/ugorji/go/codec"
+)
+
+// Codec encodes values persisted in Redis
+type Codec interface {
+	Name() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var (
+	// JSON is the original, human-readable encoding
+	JSON Codec = jsonCodec{}
+	// Msgpack is a compact binary encoding honoring the same json tags
+	Msgpack Codec = newMsgpackCodec()
+)
+
+// defaultCodec holds the codec used for new writes
+var defaultCodec atomic.Value
+
+func init() {
+	defaultCodec.Store(holder{JSON})
+}
+
+// holder keeps atomic.Value's stored type consistent across codecs
+type holder struct{ Codec }
+
+// Parse returns the codec with the given name
+func Parse(name string) (Codec, error) {
+	switch name {
+	case JSON.Name():
+		return JSON, nil
+	case Msgpack.Name():
+		return Msgpack, nil
+	default:
+		return nil, fmt.Errorf("unknown codec %q", name)
+	}
+}
+
+// SetDefault sets the codec used to encode values from now on. Values
+// already stored stay readable whatever codec wrote them.
+func SetDefault(c Codec) {
+	defaultCodec.Store(holder{c})
+}
+
+// Default returns the codec used to encode values
+func Default() Codec {
+	return defaultCodec.Load().(holder).Codec
+}
+
+// Marshal encodes v with the default codec
+func Marshal(v interface{}) ([]byte, error) {
+	return Default().Marshal(v)
+}
+
+// Unmarshal decodes data written by any codec. Values are stored as JSON
+// objects or msgpack maps, so a leading '{' identifies JSON.
+func Unmarshal(data []byte, v interface{}) error {
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		return JSON.Unmarshal(data, v)
+	}
+	return Msgpack.Unmarshal(data, v)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string { return "json" }
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+type msgpackCodec struct {
+	handle *codec.MsgpackHandle
+}
+
+func newMsgpackCodec() msgpackCodec {
+	h := &codec.MsgpackHandle{WriteExt: true}
+	// Decode free-form maps and strings as encoding/json would
+	h.MapType = reflect.TypeOf(map[string]interface{}(nil))
+	h.RawToString = true
+	return msgpackCodec{handle: h}
+}
+
+func (msgpackCodec) Name() string { return "msgpack" }
+
+func (c msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	var data []byte
+	if err := codec.NewEncoderBytes(&data, c.handle).Encode(v); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (c msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	return codec.NewDecoderBytes(data, c.handle).Decode(v)
+}
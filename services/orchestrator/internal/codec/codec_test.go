@@ -0,0 +1,106 @@
+package codec
+
+import (
+	"testing"
+	"time"
+)
+
+type record struct {
+	ID        string                 `json:"id"`
+	Count     int                    `json:"count"`
+	Ratio     float64                `json:"ratio"`
+	Tags      []string               `json:"tags,omitempty"`
+	Params    map[string]interface{} `json:"params,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+	Done      *time.Time             `json:"done,omitempty"`
+	Skipped   string                 `json:"-"`
+}
+
+func sampleRecord() record {
+	created := time.Date(2024, 3, 1, 12, 30, 0, 123000000, time.UTC)
+	return record{
+		ID:        "task-1",
+		Count:     3,
+		Ratio:     0.25,
+		Tags:      []string{"spot", "gpu"},
+		Params:    map[string]interface{}{"region": "us-east-1", "savings": 12.5, "nested": map[string]interface{}{"ok": true}},
+		CreatedAt: created,
+		Skipped:   "not stored",
+	}
+}
+
+func checkRecord(t *testing.T, name string, got record) {
+	t.Helper()
+	want := sampleRecord()
+	if got.ID != want.ID || got.Count != want.Count || got.Ratio != want.Ratio || len(got.Tags) != 2 || got.Tags[1] != "gpu" {
+		t.Errorf("%s: decoded %+v", name, got)
+	}
+	if !got.CreatedAt.Equal(want.CreatedAt) || got.Done != nil || got.Skipped != "" {
+		t.Errorf("%s: times or skipped field = %v, %v, %q", name, got.CreatedAt, got.Done, got.Skipped)
+	}
+	nested, _ := got.Params["nested"].(map[string]interface{})
+	if got.Params["region"] != "us-east-1" || got.Params["savings"] != 12.5 || nested["ok"] != true {
+		t.Errorf("%s: params = %#v", name, got.Params)
+	}
+}
+
+func TestCodecsRoundTrip(t *testing.T) {
+	for _, c := range []Codec{JSON, Msgpack} {
+		data, err := c.Marshal(sampleRecord())
+		if err != nil {
+			t.Fatalf("%s marshal: %v", c.Name(), err)
+		}
+		var got record
+		if err := c.Unmarshal(data, &got); err != nil {
+			t.Fatalf("%s unmarshal: %v", c.Name(), err)
+		}
+		checkRecord(t, c.Name(), got)
+	}
+}
+
+func TestMsgpackIsSmaller(t *testing.T) {
+	jsonData, _ := JSON.Marshal(sampleRecord())
+	msgpackData, _ := Msgpack.Marshal(sampleRecord())
+	if len(msgpackData) >= len(jsonData) {
+		t.Errorf("msgpack %d bytes, json %d", len(msgpackData), len(jsonData))
+	}
+}
+
+func TestUnmarshalDetectsEncoding(t *testing.T) {
+	t.Cleanup(func() { SetDefault(JSON) })
+
+	var stored [][]byte
+	for _, c := range []Codec{JSON, Msgpack} {
+		SetDefault(c)
+		if Default() != c {
+			t.Fatalf("default = %s, want %s", Default().Name(), c.Name())
+		}
+		data, err := Marshal(sampleRecord())
+		if err != nil {
+			t.Fatal(err)
+		}
+		stored = append(stored, data)
+	}
+	// Leading whitespace does not hide JSON
+	stored = append(stored, append([]byte("\n "), stored[0]...))
+
+	// Whatever the default now, values written by either codec read back
+	for i, data := range stored {
+		var got record
+		if err := Unmarshal(data, &got); err != nil {
+			t.Fatalf("value %d: %v", i, err)
+		}
+		checkRecord(t, "mixed read", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, c := range []Codec{JSON, Msgpack} {
+		if parsed, err := Parse(c.Name()); err != nil || parsed != c {
+			t.Errorf("Parse(%q) = %v, %v", c.Name(), parsed, err)
+		}
+	}
+	if _, err := Parse("protobuf"); err == nil {
+		t.Error("unknown codec accepted")
+	}
+}
@@ -1,16 +1,25 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
+
+	"optiinfra/services/orchestrator/internal/registry"
+	"optiinfra/services/orchestrator/internal/task"
 )
 
 type Config struct {
 	Port        int
 	Environment string
 	LogLevel    string
+
+	// Task timeouts and retries, and agent liveness
+	Task     task.Config
+	Registry registry.Config
 }
 
 func Load() (*Config, error) {
@@ -24,11 +33,48 @@ func Load() (*Config, error) {
 		}
 	}
 
-	return &Config{
+	cfg := &Config{
 		Port:        port,
 		Environment: getEnv("ENVIRONMENT", "development"),
 		LogLevel:    getEnv("LOG_LEVEL", "info"),
-	}, nil
+		Task:        task.DefaultConfig(),
+		Registry:    registry.DefaultConfig(),
+	}
+
+	durations := []struct {
+		key   string
+		value *time.Duration
+	}{
+		{"TASK_DEFAULT_TIMEOUT", &cfg.Task.DefaultTaskTimeout},
+		{"TASK_MAX_TIMEOUT", &cfg.Task.MaxTaskTimeout},
+		{"TASK_RESULT_TTL", &cfg.Task.ResultTTL},
+		{"TASK_RETRY_DELAY", &cfg.Task.RetryDelay},
+		{"AGENT_SELECTION_TIMEOUT", &cfg.Task.AgentSelectionTimeout},
+		{"AGENT_LIST_CACHE_TTL", &cfg.Task.AgentListCacheTTL},
+		{"AGENT_TTL", &cfg.Registry.AgentTTL},
+		{"HEALTH_CHECK_INTERVAL", &cfg.Registry.HealthCheckInterval},
+		{"HEARTBEAT_TIMEOUT", &cfg.Registry.HeartbeatTimeout},
+	}
+	for _, d := range durations {
+		if err := getEnvDuration(d.key, d.value); err != nil {
+			return nil, err
+		}
+	}
+	if value := os.Getenv("TASK_DEFAULT_MAX_RETRIES"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TASK_DEFAULT_MAX_RETRIES: %q", value)
+		}
+		cfg.Task.DefaultMaxRetries = n
+	}
+
+	if err := cfg.Task.Validate(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Registry.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
 }
 
 func getEnv(key, defaultValue string) string {
@@ -37,3 +83,17 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvDuration overrides *value with the duration in key, if set
+func getEnvDuration(key string, value *time.Duration) error {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %q", key, raw)
+	}
+	*value = d
+	return nil
+}
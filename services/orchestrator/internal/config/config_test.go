@@ -0,0 +1,71 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"optiinfra/services/orchestrator/internal/registry"
+	"optiinfra/services/orchestrator/internal/task"
+)
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Task != task.DefaultConfig() || cfg.Registry != registry.DefaultConfig() {
+		t.Errorf("task %+v and registry %+v, want the defaults", cfg.Task, cfg.Registry)
+	}
+}
+
+func TestLoadOverrides(t *testing.T) {
+	t.Setenv("TASK_DEFAULT_TIMEOUT", "10s")
+	t.Setenv("TASK_MAX_TIMEOUT", "2m")
+	t.Setenv("TASK_RESULT_TTL", "30m")
+	t.Setenv("TASK_RETRY_DELAY", "250ms")
+	t.Setenv("TASK_DEFAULT_MAX_RETRIES", "0")
+	t.Setenv("AGENT_SELECTION_TIMEOUT", "0s")
+	t.Setenv("AGENT_LIST_CACHE_TTL", "5s")
+	t.Setenv("AGENT_TTL", "90s")
+	t.Setenv("HEALTH_CHECK_INTERVAL", "5s")
+	t.Setenv("HEARTBEAT_TIMEOUT", "20s")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantTask := task.Config{
+		DefaultTaskTimeout: 10 * time.Second,
+		MaxTaskTimeout:     2 * time.Minute,
+		ResultTTL:          30 * time.Minute,
+		DefaultMaxRetries:  0,
+		RetryDelay:         250 * time.Millisecond,
+
+		AgentSelectionTimeout: 0,
+		AgentListCacheTTL:     5 * time.Second,
+	}
+	wantRegistry := registry.Config{AgentTTL: 90 * time.Second, HealthCheckInterval: 5 * time.Second, HeartbeatTimeout: 20 * time.Second}
+	if cfg.Task != wantTask {
+		t.Errorf("task config = %+v, want %+v", cfg.Task, wantTask)
+	}
+	if cfg.Registry != wantRegistry {
+		t.Errorf("registry config = %+v, want %+v", cfg.Registry, wantRegistry)
+	}
+}
+
+func TestLoadRejectsInvalidOverrides(t *testing.T) {
+	for key, value := range map[string]string{
+		"TASK_RETRY_DELAY":         "soon",
+		"TASK_DEFAULT_MAX_RETRIES": "many",
+		"TASK_DEFAULT_TIMEOUT":     "10m", // Above the default maximum
+		"HEARTBEAT_TIMEOUT":        "0s",
+		"AGENT_LIST_CACHE_TTL":     "-1s",
+	} {
+		t.Run(key, func(t *testing.T) {
+			t.Setenv(key, value)
+			if _, err := Load(); err == nil {
+				t.Errorf("%s=%s loaded", key, value)
+			}
+		})
+	}
+}
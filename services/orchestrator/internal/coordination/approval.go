@@ -3,9 +3,11 @@ package coordination
 import (
 	"fmt"
 	"log"
+	"sort"
+	"sync"
 	"time"
 
-	"github.com/google/uuid"
+	"optiinfra/services/orchestrator/internal/idgen"
 )
 
 const (
@@ -14,17 +16,35 @@ const (
 	approvalExpirationMedium   = 48 * time.Hour     // 2 days
 	approvalExpirationHigh     = 24 * time.Hour     // 1 day
 	approvalExpirationCritical = 4 * time.Hour      // 4 hours
+
+	// Approver recorded on auto-approvals
+	autoApprover = "system"
 )
 
 // ApprovalManager manages approval workflows
 type ApprovalManager struct {
+	// Guards approvals, decided and every field of the stored approvals.
+	// Approvals handed out are copies.
+	mu        sync.Mutex
 	approvals map[string]*Approval // In-memory storage (should be PostgreSQL in production)
+
+	// Closed when a step approval is decided or expires, waking its plan
+	decided map[string]chan struct{}
+
+	// How long past its expiry an approval is still honoured, absorbing
+	// clock skew between replicas
+	skewTolerance time.Duration
+
+	// Generates approval IDs
+	ids idgen.IDGenerator
 }
 
 // NewApprovalManager creates a new approval manager
 func NewApprovalManager() *ApprovalManager {
 	return &ApprovalManager{
 		approvals: make(map[string]*Approval),
+		decided:   make(map[string]chan struct{}),
+		ids:       idgen.UUIDGenerator{},
 	}
 }
 
@@ -38,7 +58,7 @@ func (am *ApprovalManager) RequestApproval(rec *Recommendation) *Approval {
 
 	// Create approval
 	approval := &Approval{
-		ID:               uuid.New().String(),
+		ID:               am.ids.NewID(),
 		RecommendationID: rec.ID,
 		CustomerID:       rec.CustomerID,
 		RiskLevel:        rec.RiskLevel,
@@ -46,19 +66,92 @@ func (am *ApprovalManager) RequestApproval(rec *Recommendation) *Approval {
 		RequestedBy:      rec.AgentID,
 		RequestedAt:      time.Now(),
 		ExpiresAt:        am.calculateExpiration(rec.RiskLevel),
+		Rule:             fmt.Sprintf("approval required: risk=%s", rec.RiskLevel),
+		CorrelationID:    rec.CorrelationID,
 	}
 
 	// Store approval
+	am.mu.Lock()
 	am.approvals[approval.ID] = approval
+	am.mu.Unlock()
 
 	log.Printf("Approval requested: %s for recommendation %s (risk: %s, expires: %s)",
 		approval.ID, rec.ID, rec.RiskLevel, approval.ExpiresAt.Format(time.RFC3339))
 
-	return approval
+	return approval.snapshot()
+}
+
+// RequestStepApproval creates an approval gating one step of a plan
+func (am *ApprovalManager) RequestStepApproval(plan *ExecutionPlan, step *ExecutionStep) *Approval {
+	approval := &Approval{
+		ID:               am.ids.NewID(),
+		RecommendationID: plan.RecommendationID,
+		CustomerID:       plan.CustomerID,
+		PlanID:           plan.ID,
+		StepID:           step.ID,
+		RiskLevel:        plan.RiskLevel,
+		Status:           ApprovalStatusPending,
+		RequestedBy:      plan.RequestedBy,
+		RequestedAt:      time.Now(),
+		ExpiresAt:        am.calculateExpiration(plan.RiskLevel),
+		Notes:            fmt.Sprintf("Approve step %s of plan %s", step.Action, plan.ID),
+		Rule:             fmt.Sprintf("approval required: step %s requires approval", step.ID),
+		CorrelationID:    plan.CorrelationID,
+	}
+
+	am.mu.Lock()
+	am.approvals[approval.ID] = approval
+	am.decided[approval.ID] = make(chan struct{})
+	am.mu.Unlock()
+
+	log.Printf("Step approval requested: %s for step %s of plan %s (expires: %s)",
+		approval.ID, step.ID, plan.ID, approval.ExpiresAt.Format(time.RFC3339))
+
+	return approval.snapshot()
+}
+
+// StepDecision returns a channel closed once the step approval is decided.
+// It is nil if the approval was never waiting on a step or is already decided.
+func (am *ApprovalManager) StepDecision(approvalID string) <-chan struct{} {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	return am.decided[approvalID]
+}
+
+// ExpireStepApproval marks a step approval expired if it is still pending
+func (am *ApprovalManager) ExpireStepApproval(approvalID string) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	approval, ok := am.approvals[approvalID]
+	if !ok || approval.Status != ApprovalStatusPending {
+		return
+	}
+	approval.Status = ApprovalStatusExpired
+	am.markDecided(approvalID)
+	log.Printf("Step approval %s expired", approvalID)
+}
+
+// markDecided wakes any plan waiting on the approval. It must be called with
+// am.mu held.
+func (am *ApprovalManager) markDecided(approvalID string) {
+	if ch, ok := am.decided[approvalID]; ok {
+		close(ch)
+		delete(am.decided, approvalID)
+	}
 }
 
 // ProcessApproval processes an approval decision
 func (am *ApprovalManager) ProcessApproval(approvalID string, status ApprovalStatus, userID string, reason string) error {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	return am.processLocked(approvalID, status, userID, reason)
+}
+
+// processLocked records an approval decision. It must be called with am.mu
+// held.
+func (am *ApprovalManager) processLocked(approvalID string, status ApprovalStatus, userID string, reason string) error {
 	approval, ok := am.approvals[approvalID]
 	if !ok {
 		return fmt.Errorf("approval not found: %s", approvalID)
@@ -70,8 +163,9 @@ func (am *ApprovalManager) ProcessApproval(approvalID string, status ApprovalSta
 	}
 
 	// Check if expired
-	if time.Now().After(approval.ExpiresAt) {
+	if am.expired(approval, time.Now()) {
 		approval.Status = ApprovalStatusExpired
+		am.markDecided(approvalID)
 		return fmt.Errorf("approval expired: %s", approvalID)
 	}
 
@@ -89,31 +183,67 @@ func (am *ApprovalManager) ProcessApproval(approvalID string, status ApprovalSta
 		approval.RejectionReason = reason
 		log.Printf("Approval REJECTED: %s by %s (reason: %s)", approvalID, userID, reason)
 	}
+	am.markDecided(approvalID)
+
+	return nil
+}
+
+// ApproveWithChanges approves a pending approval while overriding some of
+// the recommendation's parameters, recording original and modified values
+func (am *ApprovalManager) ApproveWithChanges(approvalID string, userID string, original, overrides map[string]interface{}) error {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	if err := am.processLocked(approvalID, ApprovalStatusApproved, userID, ""); err != nil {
+		return err
+	}
+	if len(overrides) == 0 {
+		return nil
+	}
+
+	modifications := make(map[string]ParameterChange, len(overrides))
+	for key, value := range overrides {
+		modifications[key] = ParameterChange{
+			Original: original[key],
+			Modified: value,
+		}
+	}
+	am.approvals[approvalID].Modifications = modifications
 
+	log.Printf("Approval %s approved with %d parameter modifications by %s", approvalID, len(overrides), userID)
 	return nil
 }
 
-// GetApproval retrieves an approval by ID
+// GetApproval returns a copy of an approval by ID
 func (am *ApprovalManager) GetApproval(approvalID string) (*Approval, error) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
 	approval, ok := am.approvals[approvalID]
 	if !ok {
 		return nil, fmt.Errorf("approval not found: %s", approvalID)
 	}
-	return approval, nil
+	return approval.snapshot(), nil
 }
 
-// ListPendingApprovals returns all pending approvals for a customer
+// ListPendingApprovals returns copies of all pending approvals for a
+// customer, or for every customer when customerID is empty. Pending
+// approvals found past their expiry are marked expired.
 func (am *ApprovalManager) ListPendingApprovals(customerID string) []*Approval {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
 	pending := make([]*Approval, 0)
-	
+	now := time.Now()
 	for _, approval := range am.approvals {
-		if approval.CustomerID == customerID && approval.Status == ApprovalStatusPending {
+		if (customerID == "" || approval.CustomerID == customerID) && approval.Status == ApprovalStatusPending {
 			// Check if not expired
-			if time.Now().Before(approval.ExpiresAt) {
-				pending = append(pending, approval)
+			if !am.expired(approval, now) {
+				pending = append(pending, approval.snapshot())
 			} else {
-				// Mark as expired
+				// Mark as expired, waking a plan waiting on it
 				approval.Status = ApprovalStatusExpired
+				am.markDecided(approval.ID)
 			}
 		}
 	}
@@ -121,6 +251,80 @@ func (am *ApprovalManager) ListPendingApprovals(customerID string) []*Approval {
 	return pending
 }
 
+// SetExpirySkewTolerance sets how long past its expiry an approval is still
+// honoured, so replicas whose clocks disagree by less agree on expiry
+func (am *ApprovalManager) SetExpirySkewTolerance(tolerance time.Duration) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	am.skewTolerance = tolerance
+}
+
+// expired reports whether an approval is past its expiry and the skew
+// tolerance. Expiry set on this replica keeps its monotonic clock reading,
+// so wall clock jumps do not affect it; expiry read back from storage or
+// another replica is compared by wall clock. It must be called with am.mu
+// held.
+func (am *ApprovalManager) expired(approval *Approval, now time.Time) bool {
+	return now.After(approval.ExpiresAt.Add(am.skewTolerance))
+}
+
+// untilExpiry returns how long until an approval expires, skew tolerance
+// included
+func (am *ApprovalManager) untilExpiry(approval *Approval) time.Duration {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	return time.Until(approval.ExpiresAt.Add(am.skewTolerance))
+}
+
+// snapshot copies an approval. Modifications is replaced, never modified,
+// once set, so it is shared.
+func (a *Approval) snapshot() *Approval {
+	cp := *a
+	return &cp
+}
+
+// ExpireForRecommendation marks any pending approval for a recommendation as expired
+func (am *ApprovalManager) ExpireForRecommendation(recommendationID string) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	for _, approval := range am.approvals {
+		// Step approvals belong to a plan already created from the recommendation
+		if approval.PlanID != "" {
+			continue
+		}
+		if approval.RecommendationID == recommendationID && approval.Status == ApprovalStatusPending {
+			approval.Status = ApprovalStatusExpired
+			log.Printf("Approval %s expired with recommendation %s", approval.ID, recommendationID)
+		}
+	}
+}
+
+// ExpireForCorrelation marks the pending recommendation approvals of a
+// coordination run as expired and returns them
+func (am *ApprovalManager) ExpireForCorrelation(correlationID string) []*Approval {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	var expired []*Approval
+	for _, approval := range am.approvals {
+		// Step approvals end with their plan
+		if approval.PlanID != "" {
+			continue
+		}
+		if approval.CorrelationID == correlationID && approval.Status == ApprovalStatusPending {
+			approval.Status = ApprovalStatusExpired
+			am.markDecided(approval.ID)
+			expired = append(expired, approval.snapshot())
+			log.Printf("Approval %s expired with coordination %s", approval.ID, correlationID)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool {
+		return expired[i].RequestedAt.Before(expired[j].RequestedAt)
+	})
+	return expired
+}
+
 // AutoApprove automatically approves low-risk recommendations
 func (am *ApprovalManager) AutoApprove(rec *Recommendation) bool {
 	// Only auto-approve low-risk items
@@ -132,6 +336,55 @@ func (am *ApprovalManager) AutoApprove(rec *Recommendation) bool {
 	return true
 }
 
+// RecordAutoApproval stores an already approved approval for a
+// recommendation that needed no sign-off, keeping the rule that applied
+func (am *ApprovalManager) RecordAutoApproval(rec *Recommendation, rule string) *Approval {
+	now := time.Now()
+	approval := &Approval{
+		ID:               am.ids.NewID(),
+		RecommendationID: rec.ID,
+		CustomerID:       rec.CustomerID,
+		RiskLevel:        rec.RiskLevel,
+		Status:           ApprovalStatusApproved,
+		RequestedBy:      rec.AgentID,
+		RequestedAt:      now,
+		ApprovedBy:       autoApprover,
+		ApprovedAt:       &now,
+		ExpiresAt:        am.calculateExpiration(rec.RiskLevel),
+		Rule:             rule,
+		CorrelationID:    rec.CorrelationID,
+	}
+
+	am.mu.Lock()
+	am.approvals[approval.ID] = approval
+	am.mu.Unlock()
+	return approval.snapshot()
+}
+
+// Explain reports which policy rule decided an approval
+func (am *ApprovalManager) Explain(approvalID string) (*ApprovalExplanation, error) {
+	approval, err := am.GetApproval(approvalID)
+	if err != nil {
+		return nil, err
+	}
+
+	explanation := &ApprovalExplanation{
+		ApprovalID:       approval.ID,
+		RecommendationID: approval.RecommendationID,
+		PlanID:           approval.PlanID,
+		StepID:           approval.StepID,
+		RiskLevel:        approval.RiskLevel,
+		AutoApproved:     approval.ApprovedBy == autoApprover,
+		Rule:             approval.Rule,
+		Status:           approval.Status,
+		DecidedBy:        approval.ApprovedBy,
+	}
+	if approval.Status == ApprovalStatusRejected {
+		explanation.DecidedBy = approval.RejectedBy
+	}
+	return explanation, nil
+}
+
 // Helper methods
 func (am *ApprovalManager) requiresApproval(riskLevel RiskLevel) bool {
 	// Low risk: No approval needed
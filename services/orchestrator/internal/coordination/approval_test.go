@@ -0,0 +1,176 @@
+package coordination
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// pendingScaleDown submits a high-risk scale_down recommendation and returns
+// its approval ID
+func pendingScaleDown(t *testing.T, c *Coordinator) string {
+	t.Helper()
+	rec := lowRiskRec("rec-1", "scale_down", "node-1")
+	rec.RiskLevel = RiskLevelHigh
+	rec.Parameters = map[string]interface{}{"target_instances": 2, "region": "us-east-1"}
+
+	resp, err := c.Coordinate(&CoordinationRequest{
+		CustomerID:      "cust-1",
+		Recommendations: []*Recommendation{rec},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Approvals) != 1 {
+		t.Fatalf("approvals = %d, want 1", len(resp.Approvals))
+	}
+	return resp.Approvals[0].ID
+}
+
+// scaleStep returns the plan's step carrying the recommendation's parameters
+func scaleStep(t *testing.T, plan *ExecutionPlan) ExecutionStep {
+	t.Helper()
+	for _, step := range plan.Steps {
+		if step.Action == "scale_resources" {
+			return step
+		}
+	}
+	t.Fatalf("plan %s has no scale_resources step", plan.ID)
+	return ExecutionStep{}
+}
+
+func TestApproveWithChangesFlowsIntoPlan(t *testing.T) {
+	c := newTestCoordinator(t, succeedingRunner)
+	approvalID := pendingScaleDown(t, c)
+
+	plan, err := c.ApproveWithChanges(approvalID, "ops", map[string]interface{}{"target_instances": 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan == nil {
+		t.Fatal("no execution plan created")
+	}
+	params := scaleStep(t, plan).Parameters
+	if params["target_instances"] != 4 || params["region"] != "us-east-1" {
+		t.Errorf("step parameters = %v, want the override merged with the rest", params)
+	}
+
+	approval, err := c.approvalManager.GetApproval(approvalID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	change, ok := approval.Modifications["target_instances"]
+	if approval.Status != ApprovalStatusApproved || !ok || change.Original != 2 || change.Modified != 4 {
+		t.Errorf("approval %s with modifications %v", approval.Status, approval.Modifications)
+	}
+	if len(approval.Modifications) != 1 {
+		t.Errorf("unchanged parameters recorded as modifications: %v", approval.Modifications)
+	}
+}
+
+func TestApproveWithoutChangesKeepsParameters(t *testing.T) {
+	c := newTestCoordinator(t, succeedingRunner)
+	approvalID := pendingScaleDown(t, c)
+
+	plan, err := c.ApproveRecommendation(approvalID, "ops")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params := scaleStep(t, plan).Parameters; params["target_instances"] != 2 {
+		t.Errorf("step parameters = %v", params)
+	}
+	if approval, _ := c.approvalManager.GetApproval(approvalID); len(approval.Modifications) != 0 {
+		t.Errorf("modifications recorded without overrides: %v", approval.Modifications)
+	}
+}
+
+func TestApproveEndpointWithParameters(t *testing.T) {
+	c := newTestCoordinator(t, succeedingRunner)
+	router := newTestHandler(c)
+	approvalID := pendingScaleDown(t, c)
+
+	w := doJSON(t, router, http.MethodPost, "/coordination/approvals/"+approvalID+"/approve", map[string]interface{}{
+		"user_id":    "ops",
+		"parameters": map[string]interface{}{"target_instances": 4},
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Message string `json:"message"`
+		PlanID  string `json:"plan_id"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	plan, err := c.GetExecutionPlan(resp.PlanID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Numbers decoded from JSON are float64
+	if params := scaleStep(t, plan).Parameters; params["target_instances"] != float64(4) {
+		t.Errorf("step parameters = %v", params)
+	}
+	if resp.Message != "Recommendation approved with modifications" {
+		t.Errorf("message = %q", resp.Message)
+	}
+}
+
+// Run with -race: approvals are decided while others list them, and listing
+// expires overdue approvals in place
+func TestConcurrentApprovalAndListing(t *testing.T) {
+	am := NewApprovalManager()
+	var ids []string
+	for i := 0; i < 20; i++ {
+		rec := lowRiskRec(fmt.Sprintf("rec-%d", i), "scale_down", "node-1")
+		rec.RiskLevel = RiskLevelHigh
+		rec.CustomerID = "cust-1"
+		ids = append(ids, am.RequestApproval(rec).ID)
+	}
+	// Every other approval is already overdue, so listing expires it
+	am.mu.Lock()
+	for i, id := range ids {
+		if i%2 == 1 {
+			am.approvals[id].ExpiresAt = time.Now().Add(-time.Minute)
+		}
+	}
+	am.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		i, id := i, id
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			am.ProcessApproval(id, ApprovalStatusApproved, fmt.Sprintf("user-%d", i), "")
+		}()
+		go func() {
+			defer wg.Done()
+			for _, approval := range am.ListPendingApprovals("cust-1") {
+				if approval.Status != ApprovalStatusPending {
+					t.Errorf("listed approval %s is %s", approval.ID, approval.Status)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if pending := am.ListPendingApprovals(""); len(pending) != 0 {
+		t.Errorf("%d approvals still pending", len(pending))
+	}
+	for i, id := range ids {
+		approval, err := am.GetApproval(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i%2 == 0 && approval.Status != ApprovalStatusApproved {
+			t.Errorf("approval %d %s, want approved", i, approval.Status)
+		}
+		if i%2 == 1 && approval.Status != ApprovalStatusExpired {
+			t.Errorf("overdue approval %d %s, want expired", i, approval.Status)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package coordination
+
+// BlastRadius estimates how far a recommendation reaches within its batch
+type BlastRadius struct {
+	// Distinct resources the recommendation touches
+	AffectedResources int `json:"affected_resources"`
+
+	// Affected resources other recommendations in the batch also touch
+	SharedResources int `json:"shared_resources"`
+
+	// Other recommendations in the batch it has a resource conflict with
+	OverlappingRecommendations int `json:"overlapping_recommendations"`
+}
+
+// assignBlastRadius sets each recommendation's blast radius from the
+// resources touched across the batch and its resource conflicts
+func assignBlastRadius(recommendations []*Recommendation, conflicts []Conflict) {
+	// How many recommendations touch each resource
+	touchedBy := make(map[string]int)
+	for _, rec := range recommendations {
+		for resource := range distinctResources(rec) {
+			touchedBy[resource]++
+		}
+	}
+
+	overlaps := make(map[string]map[string]bool)
+	for _, conflict := range conflicts {
+		if !conflict.HasType(ConflictTypeResource) {
+			continue
+		}
+		for _, id := range conflict.Recommendations {
+			for _, other := range conflict.Recommendations {
+				if other == id {
+					continue
+				}
+				if overlaps[id] == nil {
+					overlaps[id] = make(map[string]bool)
+				}
+				overlaps[id][other] = true
+			}
+		}
+	}
+
+	for _, rec := range recommendations {
+		resources := distinctResources(rec)
+		radius := &BlastRadius{
+			AffectedResources:          len(resources),
+			OverlappingRecommendations: len(overlaps[rec.ID]),
+		}
+		for resource := range resources {
+			if touchedBy[resource] > 1 {
+				radius.SharedResources++
+			}
+		}
+		rec.BlastRadius = radius
+	}
+}
+
+func distinctResources(rec *Recommendation) map[string]bool {
+	resources := make(map[string]bool, len(rec.AffectedResources))
+	for _, resource := range rec.AffectedResources {
+		resources[resource] = true
+	}
+	return resources
+}
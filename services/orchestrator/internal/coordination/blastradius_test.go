@@ -0,0 +1,44 @@
+package coordination
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestBlastRadiusReflectsSharedResources(t *testing.T) {
+	c := newTestCoordinator(t, succeedingRunner)
+	a := lowRiskRec("rec-a", "right_size", "node-1", "node-2", "node-2")
+	b := lowRiskRec("rec-b", "enable_caching", "node-2", "node-3")
+	cc := lowRiskRec("rec-c", "scale_down", "node-3")
+	d := lowRiskRec("rec-d", "right_size", "node-9")
+
+	resp, err := c.Coordinate(&CoordinationRequest{
+		CustomerID:      "cust-1",
+		Recommendations: []*Recommendation{a, b, cc, d},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[*Recommendation]BlastRadius{
+		a:  {AffectedResources: 2, SharedResources: 1, OverlappingRecommendations: 1},
+		b:  {AffectedResources: 2, SharedResources: 2, OverlappingRecommendations: 2},
+		cc: {AffectedResources: 1, SharedResources: 1, OverlappingRecommendations: 1},
+		d:  {AffectedResources: 1},
+	}
+	for rec, radius := range want {
+		if rec.BlastRadius == nil || *rec.BlastRadius != radius {
+			t.Errorf("%s blast radius = %+v, want %+v", rec.ID, rec.BlastRadius, radius)
+		}
+	}
+
+	// The response carries each recommendation's blast radius
+	body, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(body), `"blast_radius"`); n != len(resp.Recommendations) || n == 0 {
+		t.Errorf("%d blast radii in a response with %d recommendations", n, len(resp.Recommendations))
+	}
+}
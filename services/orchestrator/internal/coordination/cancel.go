@@ -0,0 +1,265 @@
+package coordination
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+)
+
+// ErrPlanAborted is returned by a plan execution stopped because its
+// coordination was cancelled
+var ErrPlanAborted = errors.New("plan aborted by coordination cancel")
+
+// abortedBeforeStepKey is the plan metadata key recording the step a plan
+// was aborted before
+const abortedBeforeStepKey = "aborted_before_step"
+
+// CancelPlanAction is what cancelling a coordination does to its running plans
+type CancelPlanAction string
+
+const (
+	// Roll the plan back at its next step boundary
+	CancelPlanRollback CancelPlanAction = "rollback"
+
+	// Pause the plan before its next step, leaving it to be resumed or
+	// rolled back later
+	CancelPlanPause CancelPlanAction = "pause"
+)
+
+// ParseCancelPlanAction validates a cancel plan action name
+func ParseCancelPlanAction(name string) (CancelPlanAction, error) {
+	switch action := CancelPlanAction(name); action {
+	case CancelPlanRollback, CancelPlanPause:
+		return action, nil
+	default:
+		return "", fmt.Errorf("unknown plan action %q", name)
+	}
+}
+
+// CoordinationCancellation reports what cancelling a coordination run did
+type CoordinationCancellation struct {
+	CorrelationID  string           `json:"correlation_id"`
+	PlanAction     CancelPlanAction `json:"plan_action"`
+	CancelledTasks []string         `json:"cancelled_tasks"`
+
+	// Plans rolling back at their next step boundary, or pausing before
+	// their next step. Deferred plans roll back straight away.
+	RolledBackPlans []string `json:"rolled_back_plans"`
+	PausedPlans     []string `json:"paused_plans"`
+
+	// Plans that had not started, and recommendation approvals still
+	// pending, which the cancel ended
+	CancelledPlans   []string `json:"cancelled_plans"`
+	ExpiredApprovals []string `json:"expired_approvals"`
+}
+
+// CancelCoordination stops a coordination run: its pending approvals expire,
+// its pending plans are cancelled and its running plans roll back or pause
+// per action, then the unfinished step tasks they spawned are cancelled. A
+// step whose task is cancelled fails as usual, so a critical step rolls its
+// plan back even when pausing. Plans awaiting step approval stop waiting to
+// roll back; when pausing they stay gated by the approval.
+func (c *Coordinator) CancelCoordination(coordinationID string, action CancelPlanAction) (*CoordinationCancellation, error) {
+	// Expire approvals first so none can create a plan after plansFor
+	expired := c.approvalManager.ExpireForCorrelation(coordinationID)
+
+	plans := c.executionOrch.plansFor(coordinationID)
+	if len(plans) == 0 && len(expired) == 0 && len(c.History("", coordinationID)) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrCoordinationNotFound, coordinationID)
+	}
+	sort.Slice(plans, func(i, j int) bool {
+		return plans[i].CreatedAt.Before(plans[j].CreatedAt)
+	})
+
+	resp := &CoordinationCancellation{
+		CorrelationID:    coordinationID,
+		PlanAction:       action,
+		CancelledTasks:   make([]string, 0),
+		RolledBackPlans:  make([]string, 0),
+		PausedPlans:      make([]string, 0),
+		CancelledPlans:   make([]string, 0),
+		ExpiredApprovals: make([]string, 0),
+	}
+
+	for _, approval := range expired {
+		c.removeBufferedRecommendation(approval.RecommendationID)
+		resp.ExpiredApprovals = append(resp.ExpiredApprovals, approval.ID)
+	}
+
+	// Stop plans first so they submit no further steps
+	for _, plan := range plans {
+		if c.executionOrch.cancelPending(plan.ID) {
+			resp.CancelledPlans = append(resp.CancelledPlans, plan.ID)
+			continue
+		}
+		switch action {
+		case CancelPlanPause:
+			switch plan.Status {
+			case ExecutionStatusPaused:
+				resp.PausedPlans = append(resp.PausedPlans, plan.ID)
+			case ExecutionStatusRunning, ExecutionStatusDeferred:
+				if err := c.executionOrch.PausePlan(plan.ID); err != nil {
+					log.Printf("Cancelling coordination %s: %v", coordinationID, err)
+					continue
+				}
+				resp.PausedPlans = append(resp.PausedPlans, plan.ID)
+			}
+		default:
+			if c.executionOrch.abortDeferred(plan.ID) || c.executionOrch.requestAbort(plan.ID) {
+				resp.RolledBackPlans = append(resp.RolledBackPlans, plan.ID)
+			}
+		}
+	}
+
+	if canceller, ok := c.executionOrch.runner.(StepCanceller); ok {
+		reason := fmt.Sprintf("coordination %s cancelled", coordinationID)
+		resp.CancelledTasks = append(resp.CancelledTasks, canceller.CancelSteps(coordinationID, reason)...)
+	}
+
+	log.Printf("Coordination %s cancelled: %d tasks cancelled, %d plans rolling back, %d paused, %d cancelled, %d approvals expired",
+		coordinationID, len(resp.CancelledTasks), len(resp.RolledBackPlans), len(resp.PausedPlans),
+		len(resp.CancelledPlans), len(resp.ExpiredApprovals))
+	return resp, nil
+}
+
+// cancelPending marks a plan that has not started as cancelled and reports
+// whether it did so
+func (eo *ExecutionOrchestrator) cancelPending(planID string) bool {
+	eo.mu.Lock()
+	defer eo.mu.Unlock()
+
+	plan, ok := eo.plans[planID]
+	if !ok || plan.Status != ExecutionStatusPending {
+		return false
+	}
+	now := eo.now()
+	plan.Status = ExecutionStatusCancelled
+	plan.CompletedAt = &now
+	plan.EstimatedCompletion = nil
+	log.Printf("Plan %s cancelled before starting", planID)
+	return true
+}
+
+// abortDeferred rolls back a plan waiting on a maintenance window or the
+// kill switch, which has no running execution to notice an abort signal. It
+// reports whether the plan was deferred.
+func (eo *ExecutionOrchestrator) abortDeferred(planID string) bool {
+	eo.mu.Lock()
+	plan, ok := eo.plans[planID]
+	if !ok || plan.Status != ExecutionStatusDeferred {
+		eo.mu.Unlock()
+		return false
+	}
+	eo.stopWindowTimerLocked(planID)
+	eo.hold.mu.Lock()
+	for i, id := range eo.hold.deferred {
+		if id == planID {
+			eo.hold.deferred = append(eo.hold.deferred[:i], eo.hold.deferred[i+1:]...)
+			break
+		}
+	}
+	eo.hold.mu.Unlock()
+	// Claim the plan so no resume or window timer starts it meanwhile
+	plan.Status = ExecutionStatusRunning
+	nextStep := plan.CurrentStep
+	eo.mu.Unlock()
+
+	eo.abortPlan(plan, nextStep)
+	return true
+}
+
+// requestAbort signals a running, paused or approval-gated plan to roll back
+// at its next step boundary, waking it if paused. It reports whether the
+// plan was signalled.
+func (eo *ExecutionOrchestrator) requestAbort(planID string) bool {
+	eo.mu.RLock()
+	plan, ok := eo.plans[planID]
+	var status ExecutionStatus
+	if ok {
+		status = plan.Status
+	}
+	eo.mu.RUnlock()
+
+	switch status {
+	case ExecutionStatusRunning, ExecutionStatusPaused, ExecutionStatusAwaitingApproval:
+	default:
+		return false
+	}
+
+	eo.pauseMu.Lock()
+	defer eo.pauseMu.Unlock()
+
+	abort := eo.abortLocked(planID)
+	select {
+	case <-abort:
+		return false
+	default:
+	}
+	close(abort)
+
+	if resume, paused := eo.pauses[planID]; paused {
+		delete(eo.pauses, planID)
+		close(resume)
+	}
+
+	log.Printf("Abort requested for plan %s", planID)
+	return true
+}
+
+// abortSignal returns the channel closed when the plan is aborted
+func (eo *ExecutionOrchestrator) abortSignal(planID string) <-chan struct{} {
+	eo.pauseMu.Lock()
+	defer eo.pauseMu.Unlock()
+	return eo.abortLocked(planID)
+}
+
+// abortLocked returns the plan's abort channel, creating it if needed. It
+// must be called with eo.pauseMu held.
+func (eo *ExecutionOrchestrator) abortLocked(planID string) chan struct{} {
+	abort, ok := eo.aborts[planID]
+	if !ok {
+		abort = make(chan struct{})
+		eo.aborts[planID] = abort
+	}
+	return abort
+}
+
+// abortRequested reports whether the plan has been aborted
+func (eo *ExecutionOrchestrator) abortRequested(planID string) bool {
+	eo.pauseMu.Lock()
+	defer eo.pauseMu.Unlock()
+
+	abort, ok := eo.aborts[planID]
+	if !ok {
+		return false
+	}
+	select {
+	case <-abort:
+		return true
+	default:
+		return false
+	}
+}
+
+// clearAbort forgets a plan's abort signal once its execution ends
+func (eo *ExecutionOrchestrator) clearAbort(planID string) {
+	eo.pauseMu.Lock()
+	defer eo.pauseMu.Unlock()
+	delete(eo.aborts, planID)
+}
+
+// abortPlan rolls back an aborted plan's executed steps before nextStep
+func (eo *ExecutionOrchestrator) abortPlan(plan *ExecutionPlan, nextStep int) {
+	log.Printf("Coordination cancelled: rolling back plan %s before step %d", plan.ID, nextStep+1)
+	eo.rollbackPlan(plan, nextStep)
+
+	eo.mu.Lock()
+	defer eo.mu.Unlock()
+	if plan.Metadata == nil {
+		plan.Metadata = make(map[string]interface{})
+	}
+	plan.Metadata[abortedBeforeStepKey] = nextStep
+	plan.Status = ExecutionStatusRolledBack
+	plan.EstimatedCompletion = nil
+}
@@ -0,0 +1,259 @@
+package coordination
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"optiinfra/services/orchestrator/internal/registry"
+	"optiinfra/services/orchestrator/internal/task"
+)
+
+// heldTaskRouter returns a started task router whose cost agent holds every
+// task until it is cancelled or the test ends, and a coordinator running
+// steps through it
+func heldTaskRouter(t *testing.T) (*task.Router, *Coordinator) {
+	t.Helper()
+	reg := registry.NewRegistryWithStore(registry.NewMemoryAgentStore())
+	router := task.NewRouterWithConfig(task.NewMemoryTaskStore(), reg, task.DefaultConfig())
+	router.Start()
+	t.Cleanup(router.Stop)
+
+	release := make(chan struct{})
+	agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-release:
+		case <-req.Context().Done():
+		}
+	}))
+	t.Cleanup(agent.Close)
+	t.Cleanup(func() { close(release) })
+	host, portStr, _ := strings.Cut(strings.TrimPrefix(agent.URL, "http://"), ":")
+	port, _ := strconv.Atoi(portStr)
+	if _, err := reg.Register(&registry.RegistrationRequest{Name: "cost-1", Type: registry.AgentTypeCost, Host: host, Port: port, Capabilities: []string{"right_size"}}); err != nil {
+		t.Fatal(err)
+	}
+	return router, newTestCoordinator(t, NewTaskStepRunner(router, reg))
+}
+
+// sentTasks returns the IDs of the router's tasks waiting on their agent
+func sentTasks(t *testing.T, router *task.Router) []string {
+	t.Helper()
+	page, err := router.ListTasksPage(task.TaskStatusSent, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, tk := range page.Tasks {
+		ids = append(ids, tk.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestCancelCoordinationStopsTasksAndPlans(t *testing.T) {
+	router, c := heldTaskRouter(t)
+	handler := newTestHandler(c)
+
+	coordinate := func(recs ...*Recommendation) *CoordinationResponse {
+		resp, err := c.Coordinate(&CoordinationRequest{CustomerID: "cust-1", Recommendations: recs, AutoApprove: true, ExecuteNow: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	waitSent := func(n int) []string {
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			sent := sentTasks(t, router)
+			if len(sent) == n {
+				return sent
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("step tasks sent: %v, want %d", sent, n)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	cancelled := coordinate(lowRiskRec("rec-1", "right_size", "node-1"), lowRiskRec("rec-2", "right_size", "node-2"))
+	linked := make(map[string]bool)
+	for _, id := range waitSent(2) {
+		linked[id] = true
+	}
+	other := coordinate(lowRiskRec("rec-3", "right_size", "node-3"))
+	waitSent(3)
+
+	w := doJSON(t, handler, http.MethodPost, "/coordination/"+cancelled.ID+"/cancel", nil)
+	var resp CoordinationCancellation
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusAccepted {
+		t.Fatalf("cancel: status %d, body %s", w.Code, w.Body.String())
+	}
+	if resp.PlanAction != CancelPlanRollback || len(resp.RolledBackPlans) != 2 || len(resp.PausedPlans) != 0 {
+		t.Errorf("cancellation = %+v, want both plans rolling back", resp)
+	}
+	if len(resp.CancelledTasks) != 2 || !linked[resp.CancelledTasks[0]] || !linked[resp.CancelledTasks[1]] {
+		t.Errorf("cancelled tasks %v, want the two step tasks of the coordination", resp.CancelledTasks)
+	}
+
+	for id := range linked {
+		if status, err := router.GetTaskStatus(id); err != nil || status.Status != task.TaskStatusFailed || status.Error != "coordination "+cancelled.ID+" cancelled" {
+			t.Errorf("task %s: %+v, %v, want failed as cancelled", id, status, err)
+		}
+	}
+	for _, plan := range cancelled.ExecutionPlans {
+		waitForPlanStatus(t, c, plan.ID, ExecutionStatusRolledBack)
+	}
+
+	// The other coordination carries on
+	if plan, _ := c.GetExecutionPlan(other.ExecutionPlans[0].ID); plan.Status != ExecutionStatusRunning {
+		t.Errorf("other coordination's plan %s, want still running", plan.Status)
+	}
+	if sent := sentTasks(t, router); len(sent) != 1 || linked[sent[0]] {
+		t.Errorf("tasks still sent %v, want only the other coordination's", sent)
+	}
+}
+
+func TestCancelCoordinationPausesPlans(t *testing.T) {
+	steps := newHeldStepRunner()
+	c := newTestCoordinator(t, steps)
+	handler := newTestHandler(c)
+	planID := executeRec(t, c, lowRiskRec("rec-1", "migrate_to_spot", "node-1"))
+	plan := waitForPlan(t, c, planID, func(*ExecutionPlan) bool { return len(steps.actions()) == 1 })
+
+	w := doJSON(t, handler, http.MethodPost, "/coordination/"+plan.CorrelationID+"/cancel?plans=pause", nil)
+	var resp CoordinationCancellation
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusAccepted {
+		t.Fatalf("cancel: status %d, body %s", w.Code, w.Body.String())
+	}
+	if fmt.Sprint(resp.PausedPlans) != fmt.Sprint([]string{planID}) || len(resp.RolledBackPlans) != 0 || len(resp.CancelledTasks) != 0 {
+		t.Errorf("cancellation = %+v, want the plan paused", resp)
+	}
+
+	// The running step finishes and the plan stops before the next
+	steps.release <- struct{}{}
+	plan = waitForPlanStatus(t, c, planID, ExecutionStatusPaused)
+	if plan.CurrentStep != 1 || len(steps.actions()) != 1 {
+		t.Errorf("paused at step %d after running %v", plan.CurrentStep, steps.actions())
+	}
+}
+
+func TestCancelCoordinationRefusals(t *testing.T) {
+	c := newTestCoordinator(t, succeedingRunner)
+	handler := newTestHandler(c)
+	resp, err := c.Coordinate(&CoordinationRequest{CustomerID: "cust-1", Recommendations: []*Recommendation{lowRiskRec("rec-1", "right_size", "node-1")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if w := doJSON(t, handler, http.MethodPost, "/coordination/missing/cancel", nil); w.Code != http.StatusNotFound {
+		t.Errorf("unknown coordination: status %d, want 404", w.Code)
+	}
+	if w := doJSON(t, handler, http.MethodPost, "/coordination/"+resp.ID+"/cancel?plans=drop", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown plan action: status %d, want 400", w.Code)
+	}
+	// A coordination with nothing running cancels nothing
+	if w := doJSON(t, handler, http.MethodPost, "/coordination/"+resp.ID+"/cancel", nil); w.Code != http.StatusAccepted {
+		t.Errorf("idle coordination: status %d, want 202", w.Code)
+	}
+}
+
+func TestCancelCoordinationEndsPendingWork(t *testing.T) {
+	c := newTestCoordinator(t, succeedingRunner)
+	recs := []*Recommendation{lowRiskRec("rec-1", "right_size", "node-1"), lowRiskRec("rec-2", "migrate_to_spot", "node-2")}
+	for _, rec := range recs {
+		rec.RiskLevel = RiskLevelHigh
+	}
+	resp, err := c.Coordinate(&CoordinationRequest{CustomerID: "cust-1", Recommendations: recs})
+	if err != nil || len(resp.Approvals) != 2 {
+		t.Fatalf("coordinate: %v, %d approvals", err, len(resp.Approvals))
+	}
+	approved, waiting := resp.Approvals[0].ID, resp.Approvals[1].ID
+	plan, err := c.ApproveRecommendation(approved, "user-1")
+	if err != nil || plan == nil {
+		t.Fatalf("approve: %v", err)
+	}
+
+	cancelled, err := c.CancelCoordination(resp.ID, CancelPlanRollback)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(cancelled.CancelledPlans) != fmt.Sprint([]string{plan.ID}) || fmt.Sprint(cancelled.ExpiredApprovals) != fmt.Sprint([]string{waiting}) {
+		t.Errorf("cancellation = %+v, want the pending plan cancelled and the pending approval expired", cancelled)
+	}
+
+	if err := c.ExecutePlan(plan.ID); err == nil {
+		t.Error("cancelled plan executed")
+	}
+	if got, _ := c.GetExecutionPlan(plan.ID); got.Status != ExecutionStatusCancelled || got.CompletedAt == nil {
+		t.Errorf("plan %s, want cancelled", got.Status)
+	}
+	if approval, _ := c.approvalManager.GetApproval(waiting); approval.Status != ApprovalStatusExpired {
+		t.Errorf("approval %s, want expired", approval.Status)
+	}
+	if plan, err := c.ApproveRecommendation(waiting, "user-1"); err == nil || plan != nil {
+		t.Errorf("approving the expired approval created plan %v", plan)
+	}
+}
+
+func TestCancelCoordinationRollsBackDeferredPlans(t *testing.T) {
+	recs := []*Recommendation{lowRiskRec("rec-1", "migrate_to_spot", "node-1")}
+
+	t.Run("maintenance window", func(t *testing.T) {
+		c := maintenanceCoordinator(t, time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC), true)
+		timers := &fakeTimers{}
+		c.executionOrch.afterFunc = timers.afterFunc
+		resp, err := c.Coordinate(&CoordinationRequest{CustomerID: "cust-1", Recommendations: recs, AutoApprove: true, ExecuteNow: true})
+		if err != nil || len(resp.ExecutionPlans) != 1 {
+			t.Fatalf("coordinate: %v, %d plans", err, len(resp.ExecutionPlans))
+		}
+		planID := resp.ExecutionPlans[0].ID
+		waitForPlanStatus(t, c, planID, ExecutionStatusDeferred)
+
+		cancelled, err := c.CancelCoordination(resp.ID, CancelPlanRollback)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(cancelled.RolledBackPlans) != fmt.Sprint([]string{planID}) || timers.stopped != 1 {
+			t.Errorf("cancellation = %+v with %d timers stopped, want the deferred plan rolled back", cancelled, timers.stopped)
+		}
+
+		// A timer that fired anyway does not start the plan
+		timers.fns[0]()
+		if plan, _ := c.GetExecutionPlan(planID); plan.Status != ExecutionStatusRolledBack || plan.RolledBackAt == nil {
+			t.Errorf("plan %s, want rolled back", plan.Status)
+		}
+	})
+
+	t.Run("kill switch", func(t *testing.T) {
+		c := newTestCoordinator(t, succeedingRunner)
+		c.PauseExecution()
+		resp, err := c.Coordinate(&CoordinationRequest{CustomerID: "cust-1", Recommendations: recs, AutoApprove: true, ExecuteNow: true})
+		if err != nil || len(resp.ExecutionPlans) != 1 {
+			t.Fatalf("coordinate: %v, %d plans", err, len(resp.ExecutionPlans))
+		}
+		planID := resp.ExecutionPlans[0].ID
+		waitForPlanStatus(t, c, planID, ExecutionStatusDeferred)
+
+		cancelled, err := c.CancelCoordination(resp.ID, CancelPlanRollback)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(cancelled.RolledBackPlans) != fmt.Sprint([]string{planID}) {
+			t.Errorf("cancellation = %+v, want the deferred plan rolled back", cancelled)
+		}
+
+		c.ResumeExecution()
+		if err := c.ExecutePlan(planID); err == nil {
+			t.Error("aborted plan executed")
+		}
+		if plan, _ := c.GetExecutionPlan(planID); plan.Status != ExecutionStatusRolledBack {
+			t.Errorf("plan %s after resuming, want rolled back", plan.Status)
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package coordination
+
+import (
+	"log"
+	"time"
+)
+
+const (
+	// How often finished approvals and plans are checked for removal
+	artifactCleanupInterval = 10 * time.Minute
+
+	// Default time finished artifacts are kept after they were decided or finished
+	defaultApprovalRetention = 7 * 24 * time.Hour
+	defaultPlanRetention     = 7 * 24 * time.Hour
+)
+
+// RetentionPolicy sets how long finished coordination artifacts are kept. A
+// zero duration keeps that artifact type indefinitely.
+type RetentionPolicy struct {
+	// Approved, rejected and expired approvals, from their decision
+	Approvals time.Duration `json:"approvals"`
+	// Completed and rolled back plans, from when they finished
+	Plans time.Duration `json:"plans"`
+}
+
+// DefaultRetentionPolicy returns the retention used unless configured
+func DefaultRetentionPolicy() RetentionPolicy {
+	return RetentionPolicy{
+		Approvals: defaultApprovalRetention,
+		Plans:     defaultPlanRetention,
+	}
+}
+
+// CleanupResult counts the artifacts removed by one cleanup pass
+type CleanupResult struct {
+	Approvals int `json:"approvals"`
+	Plans     int `json:"plans"`
+}
+
+// SetRetentionPolicy sets how long finished approvals and plans are kept
+func (c *Coordinator) SetRetentionPolicy(policy RetentionPolicy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.retention = policy
+}
+
+// Cleanup removes finished approvals and plans older than the retention
+// policy allows
+func (c *Coordinator) Cleanup(now time.Time) CleanupResult {
+	c.mu.Lock()
+	policy := c.retention
+	c.mu.Unlock()
+
+	var result CleanupResult
+	if policy.Plans > 0 {
+		result.Plans = c.executionOrch.removeFinishedPlans(now.Add(-policy.Plans))
+	}
+	if policy.Approvals > 0 {
+		// A step approval stays while its plan is around to reference it
+		result.Approvals = c.approvalManager.removeDecided(now.Add(-policy.Approvals), func(approval *Approval) bool {
+			return c.executionOrch.hasPlan(approval.PlanID)
+		})
+	}
+
+	if result.Approvals > 0 || result.Plans > 0 {
+		log.Printf("Coordination cleanup removed %d approvals and %d plans", result.Approvals, result.Plans)
+	}
+	return result
+}
+
+// removeDecided deletes approvals decided before the cutoff, except those
+// keep retains, and returns how many it deleted
+func (am *ApprovalManager) removeDecided(cutoff time.Time, keep func(*Approval) bool) int {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	removed := 0
+	for id, approval := range am.approvals {
+		decidedAt := approval.ExpiresAt
+		switch approval.Status {
+		case ApprovalStatusApproved:
+			if approval.ApprovedAt != nil {
+				decidedAt = *approval.ApprovedAt
+			}
+		case ApprovalStatusRejected:
+			if approval.RejectedAt != nil {
+				decidedAt = *approval.RejectedAt
+			}
+		case ApprovalStatusExpired:
+		default:
+			continue
+		}
+		if !decidedAt.Before(cutoff) || keep(approval) {
+			continue
+		}
+		delete(am.approvals, id)
+		removed++
+	}
+	return removed
+}
+
+// removeFinishedPlans deletes completed, failed, rolled back and cancelled
+// plans that finished before the cutoff and returns how many it deleted
+func (eo *ExecutionOrchestrator) removeFinishedPlans(cutoff time.Time) int {
+	eo.mu.Lock()
+	defer eo.mu.Unlock()
+
+	removed := 0
+	for id, plan := range eo.plans {
+		var finishedAt *time.Time
+		switch plan.Status {
+		case ExecutionStatusCompleted, ExecutionStatusCancelled:
+			finishedAt = plan.CompletedAt
+		case ExecutionStatusFailed, ExecutionStatusRolledBack:
+			finishedAt = plan.RolledBackAt
+		}
+		if finishedAt == nil || !finishedAt.Before(cutoff) {
+			continue
+		}
+		delete(eo.plans, id)
+		removed++
+	}
+	return removed
+}
@@ -0,0 +1,66 @@
+package coordination
+
+import (
+	"testing"
+	"time"
+)
+
+// finishedArtifacts leaves c with a completed plan and its auto-approval, a
+// rejected approval and a pending one, and returns their IDs
+func finishedArtifacts(t *testing.T, c *Coordinator) (planID, rejected, pending string) {
+	t.Helper()
+	planID = executeRec(t, c, lowRiskRec("rec-1", "migrate_to_spot", "node-1"))
+	waitForPlanStatus(t, c, planID, ExecutionStatusCompleted)
+
+	rejected = pendingScaleDown(t, c)
+	if err := c.RejectRecommendation(rejected, "alice", "not now"); err != nil {
+		t.Fatal(err)
+	}
+	pending = pendingScaleDown(t, c)
+	return planID, rejected, pending
+}
+
+func TestCleanupRemovesOldArtifacts(t *testing.T) {
+	c := newTestCoordinator(t, succeedingRunner)
+	c.SetRetentionPolicy(RetentionPolicy{Approvals: 2 * time.Hour, Plans: 2 * time.Hour})
+	planID, rejected, pending := finishedArtifacts(t, c)
+
+	// Within the retention period everything is kept
+	if result := c.Cleanup(time.Now().Add(time.Hour)); result.Approvals != 0 || result.Plans != 0 {
+		t.Errorf("recent cleanup removed %+v, want nothing", result)
+	}
+	if _, err := c.GetExecutionPlan(planID); err != nil {
+		t.Errorf("recent plan removed: %v", err)
+	}
+
+	// The completed plan, its auto-approval and the rejection age out
+	result := c.Cleanup(time.Now().Add(3 * time.Hour))
+	if result.Plans != 1 || result.Approvals != 2 {
+		t.Errorf("cleanup removed %+v, want 1 plan and 2 approvals", result)
+	}
+	if _, err := c.GetExecutionPlan(planID); err == nil {
+		t.Error("old plan kept")
+	}
+	if _, err := c.approvalManager.GetApproval(rejected); err == nil {
+		t.Error("old rejection kept")
+	}
+	// Undecided approvals are never removed
+	if _, err := c.approvalManager.GetApproval(pending); err != nil {
+		t.Errorf("pending approval removed: %v", err)
+	}
+}
+
+func TestCleanupRetentionPerArtifactType(t *testing.T) {
+	c := newTestCoordinator(t, succeedingRunner)
+	c.SetRetentionPolicy(RetentionPolicy{Approvals: time.Hour})
+	planID, _, _ := finishedArtifacts(t, c)
+
+	// Plans have no retention, so only approvals are removed
+	result := c.Cleanup(time.Now().Add(30 * 24 * time.Hour))
+	if result.Plans != 0 || result.Approvals != 2 {
+		t.Errorf("cleanup removed %+v, want 2 approvals and no plans", result)
+	}
+	if _, err := c.GetExecutionPlan(planID); err != nil {
+		t.Errorf("plan removed without a plan retention: %v", err)
+	}
+}
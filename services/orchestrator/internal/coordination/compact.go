@@ -0,0 +1,69 @@
+package coordination
+
+import (
+	"log"
+	"strings"
+)
+
+// SetConflictCompaction toggles merging the conflicts detected between the
+// same pair of recommendations into one; disabling it restores one conflict
+// per type
+func (cd *ConflictDetector) SetConflictCompaction(compact bool) {
+	cd.compact = compact
+}
+
+// HasType reports whether the conflict is of the given type, counting every
+// type merged into a compacted conflict
+func (c Conflict) HasType(conflictType ConflictType) bool {
+	if len(c.Types) == 0 {
+		return c.Type == conflictType
+	}
+	for _, t := range c.Types {
+		if t == conflictType {
+			return true
+		}
+	}
+	return false
+}
+
+// compactConflicts merges conflicts between the same pair of recommendations,
+// in detection order. The merged conflict keeps the first conflict's ID and
+// type, lists every type in Types and takes the highest severity.
+func compactConflicts(conflicts []Conflict) []Conflict {
+	compacted := make([]Conflict, 0, len(conflicts))
+	byPair := make(map[string]int)
+
+	for _, conflict := range conflicts {
+		key := strings.Join(conflict.Recommendations, "|")
+		i, ok := byPair[key]
+		if !ok {
+			conflict.Types = []ConflictType{conflict.Type}
+			byPair[key] = len(compacted)
+			compacted = append(compacted, conflict)
+			continue
+		}
+
+		merged := &compacted[i]
+		merged.Types = append(merged.Types, conflict.Type)
+		merged.Description += "; " + conflict.Description
+		merged.ConflictingField += "," + conflict.ConflictingField
+		if severityRank(conflict.Severity) > severityRank(merged.Severity) {
+			merged.Severity = conflict.Severity
+		}
+	}
+
+	if len(compacted) < len(conflicts) {
+		log.Printf("Compacted %d conflicts into %d", len(conflicts), len(compacted))
+	}
+	return compacted
+}
+
+// severityRank orders conflict severities, unknown ones lowest
+func severityRank(severity string) int {
+	for i, s := range conflictSeverities {
+		if s == severity {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,67 @@
+package coordination
+
+import (
+	"fmt"
+	"testing"
+)
+
+// doublyConflicting returns a pair of recommendations in both resource and
+// action conflict, and a pair in resource conflict only
+func doublyConflicting() []*Recommendation {
+	return []*Recommendation{
+		lowRiskRec("rec-up", "scale_up", "node-1"),
+		lowRiskRec("rec-down", "scale_down", "node-1"),
+		lowRiskRec("rec-size", "right_size", "node-2"),
+		lowRiskRec("rec-spot", "migrate_to_spot", "node-2"),
+	}
+}
+
+func TestConflictCompaction(t *testing.T) {
+	cd := NewConflictDetector()
+
+	// By default each type is its own conflict
+	conflicts := cd.DetectConflicts(doublyConflicting())
+	if len(conflicts) != 3 {
+		t.Fatalf("%d conflicts without compaction, want 3", len(conflicts))
+	}
+	for _, conflict := range conflicts {
+		if len(conflict.Types) != 0 {
+			t.Errorf("uncompacted conflict lists types %v", conflict.Types)
+		}
+	}
+
+	cd.SetConflictCompaction(true)
+	conflicts = cd.DetectConflicts(doublyConflicting())
+	if len(conflicts) != 2 {
+		t.Fatalf("%d conflicts with compaction, want one per pair", len(conflicts))
+	}
+	merged, single := conflicts[0], conflicts[1]
+	if fmt.Sprint(merged.Recommendations) != "[rec-up rec-down]" || fmt.Sprint(merged.Types) != "[resource action]" {
+		t.Errorf("merged conflict between %v of types %v", merged.Recommendations, merged.Types)
+	}
+	if merged.Type != ConflictTypeResource || merged.Severity != "high" {
+		t.Errorf("merged conflict is %s at %s, want the first type at the highest severity", merged.Type, merged.Severity)
+	}
+	if !merged.HasType(ConflictTypeAction) || !merged.HasType(ConflictTypeResource) || merged.HasType(ConflictTypeDependency) {
+		t.Errorf("merged conflict types %v", merged.Types)
+	}
+	if merged.ConflictingField != "affected_resources,action" {
+		t.Errorf("conflicting field = %q", merged.ConflictingField)
+	}
+	if fmt.Sprint(single.Types) != "[resource]" || single.Severity != "low" {
+		t.Errorf("single conflict of types %v at %s", single.Types, single.Severity)
+	}
+}
+
+func TestCoordinateWithCompactedConflicts(t *testing.T) {
+	c := newTestCoordinator(t, succeedingRunner)
+	c.SetConflictCompaction(true)
+
+	resp, err := c.Coordinate(&CoordinationRequest{CustomerID: "cust-1", Recommendations: doublyConflicting()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ConflictsDetected != 2 || resp.RecommendationsKept != 2 {
+		t.Errorf("%d conflicts detected and %d recommendations kept, want 2 and 2", resp.ConflictsDetected, resp.RecommendationsKept)
+	}
+}
@@ -0,0 +1,131 @@
+package coordination
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrInvalidStepCondition is returned when a plan step's condition cannot
+// be evaluated
+var ErrInvalidStepCondition = errors.New("invalid step condition")
+
+// StepCondition gates a step on a field of an earlier step's result, such
+// as running a migration only if validate_quality reported a quality_score
+// of at least 0.9. Numbers are compared numerically; other values only
+// support "==" and "!=".
+type StepCondition struct {
+	Step     string      `json:"step"`     // ID or action of an earlier step; the latest completed match is used
+	Field    string      `json:"field"`    // Key in that step's result
+	Operator string      `json:"operator"` // ==, !=, >, >=, <, <=
+	Value    interface{} `json:"value"`
+}
+
+// Validate checks the condition is complete and its operator known
+func (sc *StepCondition) Validate() error {
+	if sc.Step == "" || sc.Field == "" {
+		return fmt.Errorf("%w: step and field are required", ErrInvalidStepCondition)
+	}
+	switch sc.Operator {
+	case "==", "!=":
+	case ">", ">=", "<", "<=":
+		if _, ok := toFloat(sc.Value); !ok {
+			return fmt.Errorf("%w: operator %s needs a numeric value", ErrInvalidStepCondition, sc.Operator)
+		}
+	default:
+		return fmt.Errorf("%w: unknown operator %q", ErrInvalidStepCondition, sc.Operator)
+	}
+	return nil
+}
+
+// applyStepConditions attaches the recommendation's conditions, keyed by
+// step action, to the matching steps
+func applyStepConditions(steps []ExecutionStep, conditions map[string]*StepCondition) error {
+	for action, condition := range conditions {
+		if err := condition.Validate(); err != nil {
+			return fmt.Errorf("step %s: %w", action, err)
+		}
+		for i := range steps {
+			if steps[i].Action == action {
+				cond := *condition
+				steps[i].Condition = &cond
+			}
+		}
+	}
+	return nil
+}
+
+// conditionMet evaluates the condition of step index i against the results
+// of the steps before it, returning why it is unmet if it is. It must be
+// called by the goroutine executing the plan.
+func conditionMet(plan *ExecutionPlan, i int) (bool, string) {
+	cond := plan.Steps[i].Condition
+	if cond == nil {
+		return true, ""
+	}
+
+	var source *ExecutionStep
+	for j := i - 1; j >= 0; j-- {
+		prior := &plan.Steps[j]
+		if prior.Status == ExecutionStatusCompleted && (prior.ID == cond.Step || prior.Action == cond.Step) {
+			source = prior
+			break
+		}
+	}
+	if source == nil {
+		return false, fmt.Sprintf("no completed step %s before it", cond.Step)
+	}
+	actual, ok := source.Result[cond.Field]
+	if !ok {
+		return false, fmt.Sprintf("step %s reported no %s", cond.Step, cond.Field)
+	}
+
+	if compareCondition(actual, cond.Operator, cond.Value) {
+		return true, ""
+	}
+	return false, fmt.Sprintf("%s.%s = %v, want %s %v", cond.Step, cond.Field, actual, cond.Operator, cond.Value)
+}
+
+func compareCondition(actual interface{}, operator string, want interface{}) bool {
+	a, aNum := toFloat(actual)
+	w, wNum := toFloat(want)
+	if aNum && wNum {
+		switch operator {
+		case "==":
+			return a == w
+		case "!=":
+			return a != w
+		case ">":
+			return a > w
+		case ">=":
+			return a >= w
+		case "<":
+			return a < w
+		case "<=":
+			return a <= w
+		}
+		return false
+	}
+
+	switch operator {
+	case "==":
+		return reflect.DeepEqual(actual, want)
+	case "!=":
+		return !reflect.DeepEqual(actual, want)
+	}
+	return false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
@@ -0,0 +1,90 @@
+package coordination
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// qualityRunner reports score from validate_quality steps and records every
+// action it runs
+type qualityRunner struct {
+	stepRecorder
+	score float64
+}
+
+func (r *qualityRunner) RunStep(ctx context.Context, step *ExecutionStep) (map[string]interface{}, error) {
+	r.stepRecorder.RunStep(ctx, step)
+	if step.Action == "validate_quality" {
+		return map[string]interface{}{"quality_score": r.score}, nil
+	}
+	return map[string]interface{}{}, nil
+}
+
+// conditionalScaleDown is a scale_down whose scaling step runs only if the
+// preceding validation scored at least 0.9
+func conditionalScaleDown() *Recommendation {
+	rec := lowRiskRec("rec-1", "scale_down", "node-1")
+	rec.StepConditions = map[string]*StepCondition{
+		"scale_resources": {Step: "validate_quality", Field: "quality_score", Operator: ">=", Value: 0.9},
+	}
+	return rec
+}
+
+func TestLowQualityScoreSkipsStep(t *testing.T) {
+	steps := &qualityRunner{score: 0.8}
+	c := newTestCoordinator(t, steps)
+	plan := waitForPlanStatus(t, c, executeRec(t, c, conditionalScaleDown()), ExecutionStatusCompleted)
+
+	if got := strings.Join(steps.actions(), ","); got != "validate_quality,validate_quality" {
+		t.Errorf("ran %s, want the scaling step skipped", got)
+	}
+	scale := plan.Steps[1]
+	if scale.Status != ExecutionStatusSkipped || !strings.Contains(scale.SkipReason, "quality_score = 0.8") {
+		t.Errorf("scaling step %s (%q), want skipped for the low score", scale.Status, scale.SkipReason)
+	}
+	if plan.Steps[2].Status != ExecutionStatusCompleted {
+		t.Errorf("step after the skipped one %s, want completed", plan.Steps[2].Status)
+	}
+}
+
+func TestMetConditionRunsStep(t *testing.T) {
+	steps := &qualityRunner{score: 0.95}
+	c := newTestCoordinator(t, steps)
+	plan := waitForPlanStatus(t, c, executeRec(t, c, conditionalScaleDown()), ExecutionStatusCompleted)
+
+	if got := strings.Join(steps.actions(), ","); got != "validate_quality,scale_resources,validate_quality" {
+		t.Errorf("ran %s, want every step", got)
+	}
+	if plan.Steps[1].Status != ExecutionStatusCompleted || plan.Steps[1].Condition == nil {
+		t.Errorf("scaling step %s with condition %v", plan.Steps[1].Status, plan.Steps[1].Condition)
+	}
+}
+
+func TestConditionOnMissingResultSkipsStep(t *testing.T) {
+	steps := &qualityRunner{score: 0.95}
+	c := newTestCoordinator(t, steps)
+	rec := conditionalScaleDown()
+	rec.StepConditions["scale_resources"].Field = "latency_ms"
+	plan := waitForPlanStatus(t, c, executeRec(t, c, rec), ExecutionStatusCompleted)
+
+	if scale := plan.Steps[1]; scale.Status != ExecutionStatusSkipped || !strings.Contains(scale.SkipReason, "reported no latency_ms") {
+		t.Errorf("scaling step %s (%q), want skipped without the field", scale.Status, scale.SkipReason)
+	}
+}
+
+func TestInvalidStepConditionRejected(t *testing.T) {
+	c := newTestCoordinator(t, succeedingRunner)
+	for _, cond := range []*StepCondition{
+		{Step: "validate_quality", Field: "quality_score", Operator: "~=", Value: 0.9},
+		{Step: "validate_quality", Field: "quality_score", Operator: ">", Value: "high"},
+		{Field: "quality_score", Operator: "==", Value: 1},
+	} {
+		rec := conditionalScaleDown()
+		rec.StepConditions["scale_resources"] = cond
+		if _, err := c.executionOrch.CreateExecutionPlan(rec); !errors.Is(err, ErrInvalidStepCondition) {
+			t.Errorf("condition %+v: err = %v, want ErrInvalidStepCondition", cond, err)
+		}
+	}
+}
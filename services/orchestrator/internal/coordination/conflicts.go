@@ -5,15 +5,35 @@ import (
 	"log"
 	"time"
 
-	"github.com/google/uuid"
+	"optiinfra/services/orchestrator/internal/idgen"
 )
 
 // ConflictDetector detects conflicts between recommendations
-type ConflictDetector struct{}
+type ConflictDetector struct {
+	// When set, contradictory actions only conflict if they share an
+	// affected resource; a recommendation without resources is global
+	scopedActions bool
+
+	// Severity policies by customer; others get DefaultSeverityPolicy
+	severityPolicies map[string]SeverityPolicy
+
+	// When set, conflicts between the same pair of recommendations are
+	// merged into one listing every type
+	compact bool
+
+	// Generates conflict IDs
+	ids idgen.IDGenerator
+}
 
 // NewConflictDetector creates a new conflict detector
 func NewConflictDetector() *ConflictDetector {
-	return &ConflictDetector{}
+	return &ConflictDetector{scopedActions: true, ids: idgen.UUIDGenerator{}}
+}
+
+// SetScopedActionConflicts toggles whether contradictory actions must share
+// scope to conflict; disabling it restores global action conflicts
+func (cd *ConflictDetector) SetScopedActionConflicts(scoped bool) {
+	cd.scopedActions = scoped
 }
 
 // DetectConflicts finds conflicts between recommendations
@@ -44,6 +64,9 @@ func (cd *ConflictDetector) DetectConflicts(recommendations []*Recommendation) [
 	}
 
 	log.Printf("Detected %d conflicts among %d recommendations", len(conflicts), len(recommendations))
+	if cd.compact {
+		conflicts = compactConflicts(conflicts)
+	}
 	return conflicts
 }
 
@@ -53,7 +76,7 @@ func (cd *ConflictDetector) checkResourceConflict(rec1, rec2 *Recommendation) *C
 	
 	if len(commonResources) > 0 {
 		return &Conflict{
-			ID:               uuid.New().String(),
+			ID:               cd.ids.NewID(),
 			Type:             ConflictTypeResource,
 			Recommendations:  []string{rec1.ID, rec2.ID},
 			Description:      fmt.Sprintf("Both recommendations affect resources: %v", commonResources),
@@ -81,11 +104,21 @@ func (cd *ConflictDetector) checkActionConflict(rec1, rec2 *Recommendation) *Con
 	if conflicts, ok := contradictory[rec1.Action]; ok {
 		for _, conflictAction := range conflicts {
 			if rec2.Action == conflictAction {
+				description := fmt.Sprintf("Contradictory actions: %s vs %s", rec1.Action, rec2.Action)
+				if cd.scopedActions && len(rec1.AffectedResources) > 0 && len(rec2.AffectedResources) > 0 {
+					shared := cd.findCommonResources(rec1.AffectedResources, rec2.AffectedResources)
+					if len(shared) == 0 {
+						// Different scopes can legitimately move in opposite directions
+						return nil
+					}
+					description = fmt.Sprintf("%s on resources: %v", description, shared)
+				}
+
 				return &Conflict{
-					ID:               uuid.New().String(),
+					ID:               cd.ids.NewID(),
 					Type:             ConflictTypeAction,
 					Recommendations:  []string{rec1.ID, rec2.ID},
-					Description:      fmt.Sprintf("Contradictory actions: %s vs %s", rec1.Action, rec2.Action),
+					Description:      description,
 					Severity:         "high",
 					ConflictingField: "action",
 					DetectedAt:       time.Now(),
@@ -106,7 +139,7 @@ func (cd *ConflictDetector) checkDependencyConflict(rec1, rec2 *Recommendation)
 
 	if rec1DependsOnRec2 && rec2DependsOnRec1 {
 		return &Conflict{
-			ID:               uuid.New().String(),
+			ID:               cd.ids.NewID(),
 			Type:             ConflictTypeDependency,
 			Recommendations:  []string{rec1.ID, rec2.ID},
 			Description:      "Circular dependency detected",
@@ -121,11 +154,13 @@ func (cd *ConflictDetector) checkDependencyConflict(rec1, rec2 *Recommendation)
 }
 
 // ConflictResolver resolves conflicts between recommendations
-type ConflictResolver struct{}
+type ConflictResolver struct {
+	strategy ResolutionStrategy
+}
 
 // NewConflictResolver creates a new conflict resolver
 func NewConflictResolver() *ConflictResolver {
-	return &ConflictResolver{}
+	return &ConflictResolver{strategy: StrategyPriority}
 }
 
 // ResolveConflicts resolves conflicts and returns filtered recommendations
@@ -199,42 +234,21 @@ func (cr *ConflictResolver) ResolveConflicts(
 	return filteredRecs, resolvedConflicts
 }
 
-// selectWinner chooses which recommendation to keep in a conflict
+// selectWinner chooses which recommendation to keep in a conflict by
+// applying the strategy's criteria in order
 func (cr *ConflictResolver) selectWinner(rec1, rec2 *Recommendation) *Recommendation {
-	// Priority 1: Higher priority wins
-	if rec1.Priority != rec2.Priority {
-		if rec1.Priority > rec2.Priority {
-			return rec1
-		}
-		return rec2
+	criteria, ok := strategyCriteria[cr.strategy]
+	if !ok {
+		criteria = strategyCriteria[StrategyPriority]
 	}
 
-	// Priority 2: Higher savings wins
-	if rec1.EstimatedSavings != rec2.EstimatedSavings {
-		if rec1.EstimatedSavings > rec2.EstimatedSavings {
-			return rec1
-		}
-		return rec2
-	}
-
-	// Priority 3: Higher confidence wins
-	if rec1.Confidence != rec2.Confidence {
-		if rec1.Confidence > rec2.Confidence {
-			return rec1
+	for _, compare := range criteria {
+		if c := compare(rec1, rec2); c != 0 {
+			if c > 0 {
+				return rec1
+			}
+			return rec2
 		}
-		return rec2
-	}
-
-	// Priority 4: Lower risk wins (safer)
-	riskScores := map[RiskLevel]int{
-		RiskLevelLow:      1,
-		RiskLevelMedium:   2,
-		RiskLevelHigh:     3,
-		RiskLevelCritical: 4,
-	}
-
-	if riskScores[rec1.RiskLevel] < riskScores[rec2.RiskLevel] {
-		return rec1
 	}
 
 	// Default: return first one
@@ -259,18 +273,10 @@ func (cd *ConflictDetector) findCommonResources(list1, list2 []string) []string
 	return common
 }
 
+// calculateSeverity rates a conflict under the policy of the
+// recommendations' customer
 func (cd *ConflictDetector) calculateSeverity(rec1, rec2 *Recommendation) string {
-	// High severity if both are high-risk
-	if rec1.RiskLevel == RiskLevelHigh && rec2.RiskLevel == RiskLevelHigh {
-		return "high"
-	}
-
-	// Medium severity if one is high-risk
-	if rec1.RiskLevel == RiskLevelHigh || rec2.RiskLevel == RiskLevelHigh {
-		return "medium"
-	}
-
-	return "low"
+	return cd.severityPolicy(rec1.CustomerID).severity(rec1, rec2)
 }
 
 func (cd *ConflictDetector) contains(slice []string, item string) bool {
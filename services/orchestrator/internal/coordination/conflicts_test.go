@@ -0,0 +1,62 @@
+package coordination
+
+import "testing"
+
+// actionConflicts returns the action conflicts detected among recs
+func actionConflicts(cd *ConflictDetector, recs ...*Recommendation) []Conflict {
+	var found []Conflict
+	for _, conflict := range cd.DetectConflicts(recs) {
+		if conflict.Type == ConflictTypeAction {
+			found = append(found, conflict)
+		}
+	}
+	return found
+}
+
+func TestActionConflictsRequireSharedScope(t *testing.T) {
+	cases := []struct {
+		name     string
+		down, up []string
+		conflict bool
+	}{
+		{"same scope", []string{"node-1"}, []string{"node-1"}, true},
+		{"overlapping scope", []string{"node-1", "node-2"}, []string{"node-2", "node-3"}, true},
+		{"different scope", []string{"node-1"}, []string{"node-2"}, false},
+		{"global", nil, []string{"node-2"}, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			down := lowRiskRec("rec-down", "scale_down", tc.down...)
+			down.AgentType = "resource"
+			up := lowRiskRec("rec-up", "scale_up", tc.up...)
+
+			conflicts := actionConflicts(NewConflictDetector(), down, up)
+			if got := len(conflicts) == 1; got != tc.conflict {
+				t.Errorf("action conflicts = %v, want conflict %v", conflicts, tc.conflict)
+			}
+		})
+	}
+}
+
+func TestSharedResourcesInActionConflict(t *testing.T) {
+	down := lowRiskRec("rec-down", "scale_down", "node-1", "node-2")
+	up := lowRiskRec("rec-up", "scale_up", "node-2")
+
+	conflicts := actionConflicts(NewConflictDetector(), down, up)
+	if len(conflicts) != 1 {
+		t.Fatalf("action conflicts = %d, want 1", len(conflicts))
+	}
+	if want := "Contradictory actions: scale_down vs scale_up on resources: [node-2]"; conflicts[0].Description != want {
+		t.Errorf("description = %q, want %q", conflicts[0].Description, want)
+	}
+}
+
+func TestUnscopedActionConflicts(t *testing.T) {
+	cd := NewConflictDetector()
+	cd.SetScopedActionConflicts(false)
+
+	conflicts := actionConflicts(cd, lowRiskRec("rec-down", "scale_down", "node-1"), lowRiskRec("rec-up", "scale_up", "node-2"))
+	if len(conflicts) != 1 {
+		t.Errorf("action conflicts across scopes = %d, want 1 with scoping disabled", len(conflicts))
+	}
+}
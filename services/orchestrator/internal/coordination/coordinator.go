@@ -3,62 +3,197 @@ package coordination
 import (
 	"fmt"
 	"log"
+	"strings"
+	"sync"
 	"time"
 
-	"github.com/google/uuid"
+	"optiinfra/services/orchestrator/internal/idgen"
 )
 
+// recommendationSweepInterval is how often buffered recommendations are checked for expiry
+const recommendationSweepInterval = 1 * time.Minute
+
 // Coordinator is the main coordination engine
 type Coordinator struct {
 	conflictDetector *ConflictDetector
 	conflictResolver *ConflictResolver
 	approvalManager  *ApprovalManager
 	executionOrch    *ExecutionOrchestrator
+
+	// Coordinate calls for the same customer run one at a time so they
+	// cannot produce conflicting plans for the same recommendations
+	customerLocks customerLocks
+
+	// Recommendations awaiting approval, keyed by recommendation ID
+	mu              sync.Mutex
+	recommendations map[string]*Recommendation
+
+	// Bounded record of recommendation outcomes for export
+	historyMu sync.RWMutex
+	history   []RecommendationOutcome
+
+	// Optional destination for state checkpointed as plans run and flushed
+	// at shutdown; checkpointMu orders the writes
+	stateSink    StateSink
+	checkpointMu sync.Mutex
+
+	// Optional delivery of plan outcomes to the originating agent
+	feedback FeedbackNotifier
+
+	// Optional destination for conflict and plan measurements
+	metrics MetricsRecorder
+
+	// How long finished approvals and plans are kept; guarded by mu
+	retention RetentionPolicy
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+
+	// Generates coordination IDs
+	ids idgen.IDGenerator
 }
 
 // NewCoordinator creates a new coordinator
 func NewCoordinator() *Coordinator {
-	return &Coordinator{
+	c := &Coordinator{
 		conflictDetector: NewConflictDetector(),
 		conflictResolver: NewConflictResolver(),
 		approvalManager:  NewApprovalManager(),
 		executionOrch:    NewExecutionOrchestrator(),
+		recommendations:  make(map[string]*Recommendation),
+		retention:        DefaultRetentionPolicy(),
+		stopCh:           make(chan struct{}),
+		ids:              idgen.UUIDGenerator{},
 	}
+	c.executionOrch.onFinished = c.planFinished
+	c.executionOrch.approvals = c.approvalManager
+	return c
+}
+
+// Start begins the background sweep of expired recommendations and
+// finished approvals and plans
+func (c *Coordinator) Start() {
+	c.wg.Add(1)
+	go c.expirySweeper()
+	log.Println("Coordinator started")
+}
+
+// Stop stops background work, drains running plans per the shutdown policy
+// and flushes pending approvals and unfinished plans to the state sink
+func (c *Coordinator) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.stopCh)
+	})
+	c.wg.Wait()
+	c.drain()
+	log.Println("Coordinator stopped")
 }
 
-// Coordinate coordinates multiple recommendations
+// Coordinate coordinates multiple recommendations. Calls for the same
+// customer are serialized; different customers coordinate in parallel.
+// Errors wrap ErrInvalidCoordination or ErrUnresolvableConflict.
 func (c *Coordinator) Coordinate(req *CoordinationRequest) (*CoordinationResponse, error) {
+	if err := validateCoordinationRequest(req); err != nil {
+		return nil, err
+	}
+
+	unlock := c.customerLocks.lock(req.CustomerID)
+	defer unlock()
+
 	log.Printf("Coordinating %d recommendations for customer %s",
 		len(req.Recommendations), req.CustomerID)
 
 	startTime := time.Now()
+	coordinationID := c.ids.NewID()
+
+	// Step 0: Drop recommendations that have already expired
+	activeRecs, expired := filterExpired(req.Recommendations, startTime)
+	for _, id := range expired {
+		log.Printf("Recommendation %s has expired, skipping", id)
+	}
+
+	// Collapse identical recommendations from different agents
+	activeRecs, merged := dedupeRecommendations(activeRecs)
+	duplicatesMerged := 0
+	for id, ids := range merged {
+		duplicatesMerged += len(ids)
+		log.Printf("Recommendation %s merged duplicates %v", id, ids)
+	}
+
+	// Drop recommendations not worth the customer's attention
+	var filteredOut []*Recommendation
+	if req.MinSavings > 0 {
+		activeRecs, filteredOut = filterBelowSavings(activeRecs, req.MinSavings)
+		for _, rec := range filteredOut {
+			log.Printf("Recommendation %s saves %.2f, below minimum %.2f, skipping", rec.ID, rec.EstimatedSavings, req.MinSavings)
+		}
+	}
+
+	// Everything created from this run carries its ID and customer
+	for _, rec := range activeRecs {
+		rec.CorrelationID = coordinationID
+		if rec.CustomerID == "" {
+			rec.CustomerID = req.CustomerID
+		}
+	}
 
 	// Step 1: Detect conflicts
-	conflicts := c.conflictDetector.DetectConflicts(req.Recommendations)
+	conflicts := c.conflictDetector.DetectConflicts(activeRecs)
+	assignBlastRadius(activeRecs, conflicts)
+	if c.metrics != nil {
+		for range conflicts {
+			c.metrics.RecordCoordinationConflict()
+		}
+	}
 
 	// Step 2: Resolve conflicts
 	resolvedRecs, resolvedConflicts := c.conflictResolver.ResolveConflicts(
-		req.Recommendations,
+		activeRecs,
 		conflicts,
 	)
+	if cycle := dependencyCycle(resolvedRecs); cycle != nil {
+		return nil, fmt.Errorf("%w: dependency cycle %s", ErrUnresolvableConflict, strings.Join(cycle, " -> "))
+	}
+
+	// Recommendations no agent can execute get neither approvals nor plans
+	var requiresAgent []MissingAgents
+	unexecutable := make(map[string]bool)
+	for _, rec := range resolvedRecs {
+		if actions := c.executionOrch.missingAgents(planSteps(rec)); len(actions) > 0 {
+			log.Printf("Recommendation %s has no agent for %s", rec.ID, strings.Join(actions, ", "))
+			rec.Status = "requires_agent"
+			unexecutable[rec.ID] = true
+			requiresAgent = append(requiresAgent, MissingAgents{RecommendationID: rec.ID, Actions: actions})
+		}
+	}
 
 	// Step 3: Request approvals
 	approvals := make([]Approval, 0)
 	autoApprovedCount := 0
 
 	for _, rec := range resolvedRecs {
+		if unexecutable[rec.ID] {
+			continue
+		}
 		if req.AutoApprove && c.approvalManager.AutoApprove(rec) {
 			autoApprovedCount++
 			rec.Status = "approved"
+			rule := fmt.Sprintf("auto-approved: risk=%s, auto_approve requested", rec.RiskLevel)
+			rec.ApprovalID = c.approvalManager.RecordAutoApproval(rec, rule).ID
 		} else {
 			approval := c.approvalManager.RequestApproval(rec)
 			if approval != nil {
 				approvals = append(approvals, *approval)
 				rec.Status = "pending_approval"
+				rec.ApprovalID = approval.ID
+				c.bufferRecommendation(rec)
 			} else {
 				// No approval needed (low risk)
 				autoApprovedCount++
 				rec.Status = "approved"
+				rule := fmt.Sprintf("auto-approved: risk=%s requires no approval", rec.RiskLevel)
+				rec.ApprovalID = c.approvalManager.RecordAutoApproval(rec, rule).ID
 			}
 		}
 	}
@@ -69,7 +204,12 @@ func (c *Coordinator) Coordinate(req *CoordinationRequest) (*CoordinationRespons
 	if req.ExecuteNow {
 		for _, rec := range resolvedRecs {
 			if rec.Status == "approved" {
-				plan := c.executionOrch.CreateExecutionPlan(rec)
+				plan, err := c.executionOrch.CreateExecutionPlan(rec)
+				if err != nil {
+					log.Printf("Skipping execution for recommendation %s: %v", rec.ID, err)
+					rec.Status = "plan_rejected"
+					continue
+				}
 				executionPlans = append(executionPlans, *plan)
 				
 				// Execute asynchronously
@@ -84,19 +224,26 @@ func (c *Coordinator) Coordinate(req *CoordinationRequest) (*CoordinationRespons
 
 	// Build response
 	response := &CoordinationResponse{
-		ID:                   uuid.New().String(),
-		TotalRecommendations: len(req.Recommendations),
-		ConflictsDetected:    len(conflicts),
-		ConflictsResolved:    len(resolvedConflicts),
-		RecommendationsKept:  len(resolvedRecs),
-		ApprovalsRequired:    len(approvals),
-		AutoApproved:         autoApprovedCount,
-		Conflicts:            resolvedConflicts,
-		Recommendations:      resolvedRecs,
-		Approvals:            approvals,
-		ExecutionPlans:       executionPlans,
-		CreatedAt:            time.Now(),
+		ID:                     coordinationID,
+		TotalRecommendations:   len(req.Recommendations),
+		ConflictsDetected:      len(conflicts),
+		ConflictsResolved:      len(resolvedConflicts),
+		RecommendationsKept:    len(resolvedRecs),
+		ApprovalsRequired:      len(approvals),
+		AutoApproved:           autoApprovedCount,
+		Conflicts:              resolvedConflicts,
+		Recommendations:        resolvedRecs,
+		Approvals:              approvals,
+		ExecutionPlans:         executionPlans,
+		ExpiredRecommendations: expired,
+		FilteredOut:            filteredOut,
+		RequiresAgent:          requiresAgent,
+		DuplicatesMerged:       duplicatesMerged,
+		MergedRecommendations:  merged,
+		Groups:                 groupByType(resolvedRecs),
+		CreatedAt:              time.Now(),
 	}
+	c.recordHistory(req, response)
 
 	duration := time.Since(startTime)
 	log.Printf("Coordination completed in %dms: %d recommendations → %d kept, %d conflicts resolved, %d approvals needed",
@@ -109,31 +256,91 @@ func (c *Coordinator) Coordinate(req *CoordinationRequest) (*CoordinationRespons
 	return response, nil
 }
 
-// ApproveRecommendation approves a pending recommendation
-func (c *Coordinator) ApproveRecommendation(approvalID string, userID string) error {
-	// Process approval
-	if err := c.approvalManager.ProcessApproval(
-		approvalID,
-		ApprovalStatusApproved,
-		userID,
-		"",
-	); err != nil {
-		return fmt.Errorf("failed to approve: %w", err)
-	}
+// BuildGraph returns the dependency and conflict graph for a recommendation set
+func (c *Coordinator) BuildGraph(recommendations []*Recommendation) *DependencyGraph {
+	return c.conflictDetector.BuildGraph(recommendations)
+}
+
+// ApproveRecommendation approves a pending recommendation and creates its
+// execution plan
+func (c *Coordinator) ApproveRecommendation(approvalID string, userID string) (*ExecutionPlan, error) {
+	return c.ApproveWithChanges(approvalID, userID, nil)
+}
 
+// ApproveWithChanges approves a pending recommendation after merging the
+// approver's parameter overrides into it, then creates its execution plan.
+// The plan is nil if the recommendation is no longer buffered.
+func (c *Coordinator) ApproveWithChanges(approvalID string, userID string, overrides map[string]interface{}) (*ExecutionPlan, error) {
 	// Get approval to find recommendation
 	approval, err := c.approvalManager.GetApproval(approvalID)
 	if err != nil {
-		return fmt.Errorf("failed to get approval: %w", err)
+		return nil, fmt.Errorf("failed to get approval: %w", err)
+	}
+	if approval.StepID != "" {
+		return c.approveStep(approval, userID, overrides)
+	}
+
+	rec := c.getBufferedRecommendation(approval.RecommendationID)
+	if rec != nil && isExpired(rec, time.Now()) {
+		c.removeBufferedRecommendation(rec.ID)
+		return nil, fmt.Errorf("recommendation %s expired at %s", rec.ID, rec.ExpiresAt.Format(time.RFC3339))
+	}
+
+	var original map[string]interface{}
+	if rec != nil {
+		original = rec.Parameters
+	}
+	if len(overrides) > 0 && rec == nil {
+		return nil, fmt.Errorf("recommendation %s is no longer available to modify", approval.RecommendationID)
+	}
+
+	// Process approval
+	if err := c.approvalManager.ApproveWithChanges(approvalID, userID, original, overrides); err != nil {
+		return nil, fmt.Errorf("failed to approve: %w", err)
 	}
 
-	log.Printf("Recommendation %s approved, creating execution plan", approval.RecommendationID)
+	if rec == nil {
+		log.Printf("Recommendation %s approved but no longer buffered; no execution plan created", approval.RecommendationID)
+		return nil, nil
+	}
+	c.removeBufferedRecommendation(rec.ID)
+
+	// Merge overrides into the recommendation before planning
+	if len(overrides) > 0 {
+		merged := make(map[string]interface{}, len(rec.Parameters)+len(overrides))
+		for k, v := range rec.Parameters {
+			merged[k] = v
+		}
+		for k, v := range overrides {
+			merged[k] = v
+		}
+		rec.Parameters = merged
+	}
+	rec.Status = "approved"
 
-	// TODO: Get recommendation and create execution plan
-	// For now, just log
-	log.Printf("Execution plan creation triggered for recommendation %s", approval.RecommendationID)
+	log.Printf("Recommendation %s approved, creating execution plan", rec.ID)
 
-	return nil
+	plan, err := c.executionOrch.CreateExecutionPlan(rec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create execution plan: %w", err)
+	}
+
+	return plan, nil
+}
+
+// approveStep signs off one step of a running plan, letting it proceed
+func (c *Coordinator) approveStep(approval *Approval, userID string, overrides map[string]interface{}) (*ExecutionPlan, error) {
+	if len(overrides) > 0 {
+		return nil, fmt.Errorf("parameter overrides are not supported for step approvals")
+	}
+	plan, err := c.executionOrch.GetPlan(approval.PlanID)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.approvalManager.ProcessApproval(approval.ID, ApprovalStatusApproved, userID, ""); err != nil {
+		return nil, fmt.Errorf("failed to approve: %w", err)
+	}
+	return plan, nil
 }
 
 // RejectRecommendation rejects a pending recommendation
@@ -146,6 +353,11 @@ func (c *Coordinator) RejectRecommendation(approvalID string, userID string, rea
 	)
 }
 
+// ExplainApproval reports which policy rule decided an approval
+func (c *Coordinator) ExplainApproval(approvalID string) (*ApprovalExplanation, error) {
+	return c.approvalManager.Explain(approvalID)
+}
+
 // GetPendingApprovals returns pending approvals for a customer
 func (c *Coordinator) GetPendingApprovals(customerID string) []*Approval {
 	return c.approvalManager.ListPendingApprovals(customerID)
@@ -156,7 +368,210 @@ func (c *Coordinator) GetExecutionPlan(planID string) (*ExecutionPlan, error) {
 	return c.executionOrch.GetPlan(planID)
 }
 
+// SetMaxPlanSteps caps the number of steps in a generated execution plan
+func (c *Coordinator) SetMaxPlanSteps(max int) {
+	c.executionOrch.SetMaxPlanSteps(max)
+}
+
+// SetActionLimits caps concurrent execution steps per action
+func (c *Coordinator) SetActionLimits(limits map[string]int) {
+	c.executionOrch.SetActionLimits(limits)
+}
+
+// SetApprovalSkewTolerance sets how long past its expiry an approval is
+// still honoured, absorbing clock skew between replicas
+func (c *Coordinator) SetApprovalSkewTolerance(tolerance time.Duration) {
+	c.approvalManager.SetExpirySkewTolerance(tolerance)
+}
+
+// SetScopedActionConflicts toggles whether contradictory actions only
+// conflict when they share affected resources
+func (c *Coordinator) SetScopedActionConflicts(scoped bool) {
+	c.conflictDetector.SetScopedActionConflicts(scoped)
+}
+
+// SetIDGenerator sets how coordination, conflict, approval, plan and step
+// IDs are generated; it must be called before the coordinator is used
+func (c *Coordinator) SetIDGenerator(ids idgen.IDGenerator) {
+	c.ids = ids
+	c.conflictDetector.ids = ids
+	c.approvalManager.ids = ids
+	c.executionOrch.ids = ids
+}
+
+// SetConflictCompaction toggles merging conflicts between the same pair of
+// recommendations into one conflict listing every type
+func (c *Coordinator) SetConflictCompaction(compact bool) {
+	c.conflictDetector.SetConflictCompaction(compact)
+}
+
+// SetMaintenanceSchedule restricts plan execution to maintenance windows
+func (c *Coordinator) SetMaintenanceSchedule(schedule *MaintenanceSchedule, deferToNext bool) {
+	c.executionOrch.SetMaintenanceSchedule(schedule, deferToNext)
+}
+
+// CheckMaintenanceWindow reports whether plans may execute right now
+func (c *Coordinator) CheckMaintenanceWindow() (bool, time.Time) {
+	return c.executionOrch.CheckMaintenanceWindow()
+}
+
+// PausePlan pauses a plan before its next step
+func (c *Coordinator) PausePlan(planID string) error {
+	return c.executionOrch.PausePlan(planID)
+}
+
+// ResumePlan resumes a paused plan
+func (c *Coordinator) ResumePlan(planID string) error {
+	return c.executionOrch.ResumePlan(planID)
+}
+
+// PauseExecution defers new plan executions until ResumeExecution
+func (c *Coordinator) PauseExecution() bool {
+	return c.executionOrch.PauseExecution()
+}
+
+// ResumeExecution starts plans deferred while execution was paused
+func (c *Coordinator) ResumeExecution() bool {
+	return c.executionOrch.ResumeExecution()
+}
+
+// ExecutionPaused reports whether plan execution is paused
+func (c *Coordinator) ExecutionPaused() bool {
+	return c.executionOrch.ExecutionPaused()
+}
+
 // ExecutePlan executes an approved execution plan
 func (c *Coordinator) ExecutePlan(planID string) error {
 	return c.executionOrch.ExecutePlan(planID)
 }
+
+// ===================================================================
+// RECOMMENDATION EXPIRY
+// ===================================================================
+
+func isExpired(rec *Recommendation, now time.Time) bool {
+	return rec.ExpiresAt != nil && !now.Before(*rec.ExpiresAt)
+}
+
+// filterExpired splits recommendations into active ones and the IDs of expired ones
+func filterExpired(recommendations []*Recommendation, now time.Time) ([]*Recommendation, []string) {
+	active := make([]*Recommendation, 0, len(recommendations))
+	expired := make([]string, 0)
+
+	for _, rec := range recommendations {
+		if isExpired(rec, now) {
+			rec.Status = "expired"
+			expired = append(expired, rec.ID)
+			continue
+		}
+		active = append(active, rec)
+	}
+
+	return active, expired
+}
+
+// filterBelowSavings splits recommendations into those saving at least
+// minSavings and those filtered out. A recommendation below the threshold
+// is kept when a kept recommendation depends on it, directly or transitively.
+func filterBelowSavings(recommendations []*Recommendation, minSavings float64) ([]*Recommendation, []*Recommendation) {
+	byID := make(map[string]*Recommendation, len(recommendations))
+	keep := make(map[string]bool, len(recommendations))
+	var pending []*Recommendation
+	for _, rec := range recommendations {
+		byID[rec.ID] = rec
+		if rec.EstimatedSavings >= minSavings {
+			keep[rec.ID] = true
+			pending = append(pending, rec)
+		}
+	}
+
+	// Walk dependencies of kept recommendations, keeping what they need
+	for len(pending) > 0 {
+		rec := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		for _, depID := range rec.Dependencies {
+			dep, ok := byID[depID]
+			if !ok || keep[depID] {
+				continue
+			}
+			keep[depID] = true
+			pending = append(pending, dep)
+		}
+	}
+
+	kept := make([]*Recommendation, 0, len(recommendations))
+	filtered := make([]*Recommendation, 0)
+	for _, rec := range recommendations {
+		if keep[rec.ID] {
+			kept = append(kept, rec)
+			continue
+		}
+		rec.Status = "filtered_out"
+		filtered = append(filtered, rec)
+	}
+
+	return kept, filtered
+}
+
+func (c *Coordinator) bufferRecommendation(rec *Recommendation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.recommendations[rec.ID] = rec
+}
+
+func (c *Coordinator) getBufferedRecommendation(recID string) *Recommendation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.recommendations[recID]
+}
+
+func (c *Coordinator) removeBufferedRecommendation(recID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.recommendations, recID)
+}
+
+func (c *Coordinator) expirySweeper() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(recommendationSweepInterval)
+	defer ticker.Stop()
+	cleanup := time.NewTicker(artifactCleanupInterval)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.sweepExpiredRecommendations()
+		case <-cleanup.C:
+			c.Cleanup(time.Now())
+		case <-c.stopCh:
+			return
+		}
+	}
+}
+
+// sweepExpiredRecommendations drops expired buffered recommendations and
+// expires any approval still pending for them
+func (c *Coordinator) sweepExpiredRecommendations() {
+	now := time.Now()
+
+	c.mu.Lock()
+	expired := make([]*Recommendation, 0)
+	for id, rec := range c.recommendations {
+		if isExpired(rec, now) {
+			expired = append(expired, rec)
+			delete(c.recommendations, id)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, rec := range expired {
+		rec.Status = "expired"
+		c.approvalManager.ExpireForRecommendation(rec.ID)
+	}
+
+	if len(expired) > 0 {
+		log.Printf("Expired %d buffered recommendations", len(expired))
+	}
+}
@@ -0,0 +1,184 @@
+package coordination
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpiredRecommendationSkipped(t *testing.T) {
+	c := newTestCoordinator(t, succeedingRunner)
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+
+	expired := lowRiskRec("rec-old", "right_size", "node-1")
+	expired.ExpiresAt = &past
+	valid := lowRiskRec("rec-new", "right_size", "node-2")
+	valid.ExpiresAt = &future
+
+	resp, err := c.Coordinate(&CoordinationRequest{
+		CustomerID:      "cust-1",
+		Recommendations: []*Recommendation{expired, valid},
+		AutoApprove:     true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.ExpiredRecommendations) != 1 || resp.ExpiredRecommendations[0] != "rec-old" {
+		t.Errorf("expired recommendations = %v, want [rec-old]", resp.ExpiredRecommendations)
+	}
+	if expired.Status != "expired" {
+		t.Errorf("expired recommendation status = %q", expired.Status)
+	}
+	if valid.Status != "approved" || resp.AutoApproved != 1 {
+		t.Errorf("valid recommendation status %q with %d auto-approved, want approved and 1", valid.Status, resp.AutoApproved)
+	}
+}
+
+func TestSweepExpiresBufferedRecommendation(t *testing.T) {
+	c := newTestCoordinator(t, succeedingRunner)
+	future := time.Now().Add(time.Hour)
+
+	expiring := lowRiskRec("rec-expiring", "right_size", "node-1")
+	expiring.RiskLevel = RiskLevelHigh
+	expiring.ExpiresAt = &future
+	lasting := lowRiskRec("rec-lasting", "right_size", "node-2")
+	lasting.RiskLevel = RiskLevelHigh
+	lasting.ExpiresAt = &future
+
+	resp, err := c.Coordinate(&CoordinationRequest{
+		CustomerID:      "cust-1",
+		Recommendations: []*Recommendation{expiring, lasting},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Approvals) != 2 {
+		t.Fatalf("approvals = %d, want 2", len(resp.Approvals))
+	}
+
+	// The first recommendation's deadline passes while it awaits approval
+	c.mu.Lock()
+	past := time.Now().Add(-time.Second)
+	c.recommendations["rec-expiring"].ExpiresAt = &past
+	c.mu.Unlock()
+	c.sweepExpiredRecommendations()
+
+	if c.getBufferedRecommendation("rec-expiring") != nil {
+		t.Error("expired recommendation still buffered")
+	}
+	if c.getBufferedRecommendation("rec-lasting") == nil {
+		t.Error("valid recommendation dropped by the sweep")
+	}
+	approval, err := c.approvalManager.GetApproval(expiring.ApprovalID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if approval.Status != ApprovalStatusExpired {
+		t.Errorf("approval for the expired recommendation is %s, want expired", approval.Status)
+	}
+	if _, err := c.ApproveRecommendation(expiring.ApprovalID, "ops"); err == nil {
+		t.Error("approved an expired recommendation")
+	}
+	if _, err := c.ApproveRecommendation(lasting.ApprovalID, "ops"); err != nil {
+		t.Errorf("approve valid recommendation: %v", err)
+	}
+}
+
+// filteredIDs returns the IDs of the recommendations filtered out, in order
+func filteredIDs(resp *CoordinationResponse) string {
+	ids := make([]string, 0, len(resp.FilteredOut))
+	for _, rec := range resp.FilteredOut {
+		ids = append(ids, rec.ID)
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestMinSavingsFiltersBeforeConflictDetection(t *testing.T) {
+	c := newTestCoordinator(t, succeedingRunner)
+	big := lowRiskRec("rec-big", "right_size", "node-1")
+	big.EstimatedSavings = 200
+	small := lowRiskRec("rec-small", "scale_down", "node-1")
+	small.EstimatedSavings = 5
+	exact := lowRiskRec("rec-exact", "right_size", "node-2")
+	exact.EstimatedSavings = 50
+
+	resp, err := c.Coordinate(&CoordinationRequest{
+		CustomerID:      "cust-1",
+		Recommendations: []*Recommendation{big, small, exact},
+		AutoApprove:     true,
+		MinSavings:      50,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := filteredIDs(resp); ids != "rec-small" || small.Status != "filtered_out" {
+		t.Errorf("filtered out %q with status %q, want rec-small", ids, small.Status)
+	}
+	// The filtered recommendation shared node-1, but is gone before conflicts are checked
+	if resp.ConflictsDetected != 0 {
+		t.Errorf("%d conflicts detected, want none", resp.ConflictsDetected)
+	}
+	if big.Status != "approved" || exact.Status != "approved" || resp.AutoApproved != 2 {
+		t.Errorf("kept statuses %q, %q with %d auto-approved", big.Status, exact.Status, resp.AutoApproved)
+	}
+
+	// Without a threshold nothing is filtered
+	resp, err = c.Coordinate(&CoordinationRequest{
+		CustomerID:      "cust-2",
+		Recommendations: []*Recommendation{lowRiskRec("rec-tiny", "right_size", "node-3")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.FilteredOut) != 0 {
+		t.Errorf("filtered out %q without a minimum", filteredIDs(resp))
+	}
+}
+
+func TestMinSavingsKeepsDependencies(t *testing.T) {
+	c := newTestCoordinator(t, succeedingRunner)
+	recs := make(map[string]*Recommendation)
+	for id, savings := range map[string]float64{"rec-main": 500, "rec-prep": 5, "rec-snapshot": 1, "rec-unrelated": 2} {
+		recs[id] = lowRiskRec(id, "right_size", "node-"+id)
+		recs[id].EstimatedSavings = savings
+	}
+	// rec-main needs rec-prep, which needs rec-snapshot
+	recs["rec-main"].Dependencies = []string{"rec-prep"}
+	recs["rec-prep"].Dependencies = []string{"rec-snapshot"}
+
+	resp, err := c.Coordinate(&CoordinationRequest{
+		CustomerID:      "cust-1",
+		Recommendations: []*Recommendation{recs["rec-main"], recs["rec-prep"], recs["rec-snapshot"], recs["rec-unrelated"]},
+		MinSavings:      100,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := filteredIDs(resp); ids != "rec-unrelated" {
+		t.Errorf("filtered out %q, want only rec-unrelated", ids)
+	}
+	for _, id := range []string{"rec-main", "rec-prep", "rec-snapshot"} {
+		if recs[id].Status == "filtered_out" {
+			t.Errorf("%s filtered out although rec-main depends on it", id)
+		}
+	}
+
+	// A low-savings recommendation depending on a kept one is still filtered
+	leaf := lowRiskRec("rec-leaf", "right_size", "node-leaf")
+	leaf.EstimatedSavings = 1
+	leaf.Dependencies = []string{"rec-root"}
+	root := lowRiskRec("rec-root", "right_size", "node-root")
+	root.EstimatedSavings = 500
+	resp, err = c.Coordinate(&CoordinationRequest{
+		CustomerID:      "cust-2",
+		Recommendations: []*Recommendation{root, leaf},
+		MinSavings:      100,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := filteredIDs(resp); ids != "rec-leaf" {
+		t.Errorf("filtered out %q, want rec-leaf", ids)
+	}
+}
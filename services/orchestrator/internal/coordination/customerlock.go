@@ -0,0 +1,43 @@
+package coordination
+
+import "sync"
+
+// customerLocks serializes work per customer while different customers
+// proceed in parallel. A customer's entry is dropped once nobody holds or
+// waits on it.
+type customerLocks struct {
+	mu    sync.Mutex
+	locks map[string]*customerLock
+}
+
+type customerLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// lock blocks until the customer's lock is free and returns its release func
+func (l *customerLocks) lock(customerID string) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*customerLock)
+	}
+	entry, ok := l.locks[customerID]
+	if !ok {
+		entry = &customerLock{}
+		l.locks[customerID] = entry
+	}
+	entry.refs++
+	l.mu.Unlock()
+
+	entry.mu.Lock()
+	return func() {
+		entry.mu.Unlock()
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		entry.refs--
+		if entry.refs == 0 {
+			delete(l.locks, customerID)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package coordination
+
+import (
+	"testing"
+	"time"
+)
+
+// gatedMetrics holds every coordination at its conflict count until
+// released, announcing each arrival
+type gatedMetrics struct {
+	entered chan string
+	release chan struct{}
+}
+
+func newGatedMetrics() *gatedMetrics {
+	return &gatedMetrics{entered: make(chan string, 10), release: make(chan struct{})}
+}
+
+func (m *gatedMetrics) RecordCoordinationConflict() {
+	m.entered <- "conflict"
+	<-m.release
+}
+
+func (m *gatedMetrics) UpdateActiveOptimizations(count float64) {}
+
+// coordinateConflicting runs a coordination with one resource conflict for
+// customerID in the background, returning a channel closed when it is done
+func coordinateConflicting(t *testing.T, c *Coordinator, customerID string) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := c.Coordinate(&CoordinationRequest{
+			CustomerID: customerID,
+			Recommendations: []*Recommendation{
+				lowRiskRec(customerID+"-a", "right_size", "node-1"),
+				lowRiskRec(customerID+"-b", "scale_down", "node-1"),
+			},
+		})
+		if err != nil {
+			t.Errorf("coordinate %s: %v", customerID, err)
+		}
+	}()
+	return done
+}
+
+func (m *gatedMetrics) waitEntered(t *testing.T) {
+	t.Helper()
+	select {
+	case <-m.entered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("coordination did not reach conflict detection")
+	}
+}
+
+func TestCoordinationSerializedPerCustomer(t *testing.T) {
+	c := newTestCoordinator(t, succeedingRunner)
+	metrics := newGatedMetrics()
+	c.SetMetricsRecorder(metrics)
+
+	first := coordinateConflicting(t, c, "cust-1")
+	metrics.waitEntered(t)
+	second := coordinateConflicting(t, c, "cust-1")
+
+	// The second call waits for the first to finish
+	select {
+	case <-metrics.entered:
+		t.Fatal("second coordination for the customer ran alongside the first")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(metrics.release)
+	<-first
+	<-second
+}
+
+func TestCoordinationParallelAcrossCustomers(t *testing.T) {
+	c := newTestCoordinator(t, succeedingRunner)
+	metrics := newGatedMetrics()
+	c.SetMetricsRecorder(metrics)
+
+	first := coordinateConflicting(t, c, "cust-1")
+	metrics.waitEntered(t)
+	second := coordinateConflicting(t, c, "cust-2")
+
+	// Another customer's coordination proceeds while the first is held
+	metrics.waitEntered(t)
+
+	close(metrics.release)
+	<-first
+	<-second
+	if len(c.customerLocks.locks) != 0 {
+		t.Errorf("%d customer locks left after both finished", len(c.customerLocks.locks))
+	}
+}
@@ -0,0 +1,86 @@
+package coordination
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// dedupeRecommendations collapses recommendations with the same action,
+// affected resources and parameters into the most confident of them,
+// earliest on a tie. It returns the survivors in submission order and the
+// IDs merged into each survivor. Dependencies on a merged recommendation
+// are redirected to its survivor.
+func dedupeRecommendations(recommendations []*Recommendation) ([]*Recommendation, map[string][]string) {
+	groups := make(map[string][]*Recommendation)
+	var order []string
+	for _, rec := range recommendations {
+		key := dedupKey(rec)
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], rec)
+	}
+	if len(order) == len(recommendations) {
+		return recommendations, nil
+	}
+
+	kept := make([]*Recommendation, 0, len(order))
+	merged := make(map[string][]string)
+	survivor := make(map[string]string)
+	for _, key := range order {
+		group := groups[key]
+		best := group[0]
+		for _, rec := range group[1:] {
+			if rec.Confidence > best.Confidence {
+				best = rec
+			}
+		}
+		for _, rec := range group {
+			if rec != best {
+				rec.Status = "merged"
+				merged[best.ID] = append(merged[best.ID], rec.ID)
+				survivor[rec.ID] = best.ID
+			}
+		}
+		kept = append(kept, best)
+	}
+
+	for _, rec := range kept {
+		rec.Dependencies = redirectDependencies(rec.ID, rec.Dependencies, survivor)
+	}
+	return kept, merged
+}
+
+// dedupKey identifies a recommendation by what it would do. Parameters are
+// compared by their JSON encoding, which sorts map keys.
+func dedupKey(rec *Recommendation) string {
+	resources := append([]string(nil), rec.AffectedResources...)
+	sort.Strings(resources)
+	params, err := json.Marshal(rec.Parameters)
+	if err != nil {
+		// Unencodable parameters never match another recommendation
+		return "id:" + rec.ID
+	}
+	return rec.Action + "\x00" + strings.Join(resources, "\x00") + "\x00" + string(params)
+}
+
+// redirectDependencies maps dependencies on merged recommendations to their
+// survivors, dropping duplicates and self-references
+func redirectDependencies(id string, dependencies []string, survivor map[string]string) []string {
+	if len(dependencies) == 0 {
+		return dependencies
+	}
+	seen := make(map[string]bool, len(dependencies))
+	out := make([]string, 0, len(dependencies))
+	for _, dep := range dependencies {
+		if to, ok := survivor[dep]; ok {
+			dep = to
+		}
+		if dep != id && !seen[dep] {
+			seen[dep] = true
+			out = append(out, dep)
+		}
+	}
+	return out
+}
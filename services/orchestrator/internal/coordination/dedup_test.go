@@ -0,0 +1,80 @@
+package coordination
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDuplicateRecommendationsCollapse(t *testing.T) {
+	c := newTestCoordinator(t, succeedingRunner)
+
+	// a and b do the same thing; c differs in parameters only
+	a := lowRiskRec("rec-a", "right_size", "node-1", "node-2")
+	a.Confidence = 0.8
+	a.Parameters = map[string]interface{}{"size": "small", "zone": "a"}
+	b := lowRiskRec("rec-b", "right_size", "node-2", "node-1")
+	b.Confidence = 0.95
+	b.Parameters = map[string]interface{}{"zone": "a", "size": "small"}
+	other := lowRiskRec("rec-c", "right_size", "node-3")
+	other.Parameters = map[string]interface{}{"size": "large"}
+	dependent := lowRiskRec("rec-d", "right_size", "node-4")
+	dependent.Dependencies = []string{"rec-a"}
+
+	resp, err := c.Coordinate(&CoordinationRequest{
+		CustomerID:      "cust-1",
+		Recommendations: []*Recommendation{a, b, other, dependent},
+		AutoApprove:     true,
+		ExecuteNow:      true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.DuplicatesMerged != 1 || fmt.Sprint(resp.MergedRecommendations) != "map[rec-b:[rec-a]]" {
+		t.Errorf("merged %d: %v, want rec-a into the more confident rec-b", resp.DuplicatesMerged, resp.MergedRecommendations)
+	}
+	var kept []string
+	for _, rec := range resp.Recommendations {
+		kept = append(kept, rec.ID)
+	}
+	if fmt.Sprint(kept) != "[rec-b rec-c rec-d]" {
+		t.Errorf("kept %v, want [rec-b rec-c rec-d]", kept)
+	}
+	if resp.ConflictsDetected != 0 || len(resp.ExecutionPlans) != 3 {
+		t.Errorf("%d conflicts and %d plans, want none and one per kept recommendation", resp.ConflictsDetected, len(resp.ExecutionPlans))
+	}
+	if fmt.Sprint(dependent.Dependencies) != "[rec-b]" {
+		t.Errorf("dependencies = %v, want redirected to rec-b", dependent.Dependencies)
+	}
+
+	for _, outcome := range c.History("cust-1", resp.ID) {
+		want := OutcomeKept
+		if outcome.RecommendationID == "rec-a" {
+			want = OutcomeMerged
+		}
+		if outcome.Outcome != want {
+			t.Errorf("%s outcome %s, want %s", outcome.RecommendationID, outcome.Outcome, want)
+		}
+	}
+}
+
+func TestDedupeKeepsEarliestOnTie(t *testing.T) {
+	recs := []*Recommendation{
+		lowRiskRec("rec-1", "scale_down", "node-1"),
+		lowRiskRec("rec-2", "scale_down", "node-1"),
+		lowRiskRec("rec-3", "scale_down", "node-1"),
+	}
+	kept, merged := dedupeRecommendations(recs)
+	if len(kept) != 1 || kept[0].ID != "rec-1" || fmt.Sprint(merged) != "map[rec-1:[rec-2 rec-3]]" {
+		t.Errorf("kept %d (first %s), merged %v", len(kept), kept[0].ID, merged)
+	}
+	if recs[1].Status != "merged" || recs[2].Status != "merged" {
+		t.Errorf("statuses %q, %q, want merged", recs[1].Status, recs[2].Status)
+	}
+
+	// Distinct recommendations pass through untouched
+	distinct := []*Recommendation{lowRiskRec("rec-4", "scale_down", "node-1"), lowRiskRec("rec-5", "scale_down", "node-2")}
+	if kept, merged := dedupeRecommendations(distinct); len(kept) != 2 || merged != nil {
+		t.Errorf("distinct recommendations: kept %d, merged %v", len(kept), merged)
+	}
+}
@@ -0,0 +1,91 @@
+package coordination
+
+import (
+	"sort"
+	"time"
+)
+
+// digestRiskOrder is the order risk groups appear in an approval digest
+var digestRiskOrder = []RiskLevel{RiskLevelCritical, RiskLevelHigh, RiskLevelMedium, RiskLevelLow}
+
+// ApprovalDigestEntry is a pending approval and how soon it expires
+type ApprovalDigestEntry struct {
+	Approval
+	ExpiresInSeconds int64  `json:"expires_in_seconds"`
+	ExpiresIn        string `json:"expires_in"`
+}
+
+// ApprovalDigestGroup holds the pending approvals of one risk level, most
+// urgent first
+type ApprovalDigestGroup struct {
+	RiskLevel RiskLevel             `json:"risk_level"`
+	Count     int                   `json:"count"`
+	Approvals []ApprovalDigestEntry `json:"approvals"`
+}
+
+// ApprovalDigest summarizes a customer's pending approvals by risk level
+type ApprovalDigest struct {
+	CustomerID  string                `json:"customer_id"`
+	Total       int                   `json:"total"`
+	Groups      []ApprovalDigestGroup `json:"groups"`
+	GeneratedAt time.Time             `json:"generated_at"`
+}
+
+// ApprovalDigest groups the customer's pending approvals by risk level,
+// highest risk first, each group sorted by how soon its approvals expire
+func (c *Coordinator) ApprovalDigest(customerID string) *ApprovalDigest {
+	now := time.Now()
+	pending := c.approvalManager.ListPendingApprovals(customerID)
+
+	byRisk := make(map[RiskLevel][]ApprovalDigestEntry)
+	for _, approval := range pending {
+		remaining := approval.ExpiresAt.Sub(now)
+		byRisk[approval.RiskLevel] = append(byRisk[approval.RiskLevel], ApprovalDigestEntry{
+			Approval:         *approval,
+			ExpiresInSeconds: int64(remaining.Seconds()),
+			ExpiresIn:        remaining.Round(time.Second).String(),
+		})
+	}
+
+	// Known levels in order, then any others by name
+	levels := append([]RiskLevel(nil), digestRiskOrder...)
+	var other []RiskLevel
+	for level := range byRisk {
+		if !containsRiskLevel(digestRiskOrder, level) {
+			other = append(other, level)
+		}
+	}
+	sort.Slice(other, func(i, j int) bool { return other[i] < other[j] })
+	levels = append(levels, other...)
+
+	digest := &ApprovalDigest{
+		CustomerID:  customerID,
+		Total:       len(pending),
+		Groups:      make([]ApprovalDigestGroup, 0, len(byRisk)),
+		GeneratedAt: now,
+	}
+	for _, level := range levels {
+		entries := byRisk[level]
+		if len(entries) == 0 {
+			continue
+		}
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].ExpiresAt.Before(entries[j].ExpiresAt)
+		})
+		digest.Groups = append(digest.Groups, ApprovalDigestGroup{
+			RiskLevel: level,
+			Count:     len(entries),
+			Approvals: entries,
+		})
+	}
+	return digest
+}
+
+func containsRiskLevel(levels []RiskLevel, level RiskLevel) bool {
+	for _, l := range levels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package coordination
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestApprovalDigestGroupsByRiskAndUrgency(t *testing.T) {
+	c := newTestCoordinator(t, succeedingRunner)
+	am := c.approvalManager
+
+	// Approvals for cust-1 and how long until each expires
+	requests := []struct {
+		id      string
+		risk    RiskLevel
+		expires time.Duration
+	}{
+		{"medium-late", RiskLevelMedium, 5 * time.Hour},
+		{"high-late", RiskLevelHigh, 3 * time.Hour},
+		{"medium-soon", RiskLevelMedium, 30 * time.Minute},
+		{"critical", RiskLevelCritical, 10 * time.Hour},
+		{"high-soon", RiskLevelHigh, time.Hour},
+	}
+	approvalIDs := make(map[string]string)
+	for _, r := range requests {
+		rec := lowRiskRec(r.id, "right_size", "node-"+r.id)
+		rec.RiskLevel = r.risk
+		rec.CustomerID = "cust-1"
+		approval := am.RequestApproval(rec)
+		approvalIDs[approval.ID] = r.id
+
+		am.mu.Lock()
+		am.approvals[approval.ID].ExpiresAt = time.Now().Add(r.expires)
+		am.mu.Unlock()
+	}
+	other := lowRiskRec("other-customer", "right_size", "node-x")
+	other.RiskLevel = RiskLevelHigh
+	other.CustomerID = "cust-2"
+	am.RequestApproval(other)
+	decided := lowRiskRec("decided", "right_size", "node-y")
+	decided.RiskLevel = RiskLevelHigh
+	decided.CustomerID = "cust-1"
+	if err := am.ProcessApproval(am.RequestApproval(decided).ID, ApprovalStatusApproved, "alice", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	w := doJSON(t, newTestHandler(c), http.MethodGet, "/coordination/approvals/digest?customer_id=cust-1", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+	var digest ApprovalDigest
+	if err := json.Unmarshal(w.Body.Bytes(), &digest); err != nil {
+		t.Fatal(err)
+	}
+
+	if digest.CustomerID != "cust-1" || digest.Total != 5 {
+		t.Errorf("digest for %s with %d approvals, want cust-1's 5 pending", digest.CustomerID, digest.Total)
+	}
+	want := []struct {
+		risk RiskLevel
+		ids  []string
+	}{
+		{RiskLevelCritical, []string{"critical"}},
+		{RiskLevelHigh, []string{"high-soon", "high-late"}},
+		{RiskLevelMedium, []string{"medium-soon", "medium-late"}},
+	}
+	if len(digest.Groups) != len(want) {
+		t.Fatalf("%d groups, want %d", len(digest.Groups), len(want))
+	}
+	for i, group := range digest.Groups {
+		if group.RiskLevel != want[i].risk || group.Count != len(want[i].ids) || len(group.Approvals) != len(want[i].ids) {
+			t.Errorf("group %d = %s with %d approvals, want %s with %d", i, group.RiskLevel, group.Count, want[i].risk, len(want[i].ids))
+			continue
+		}
+		for j, entry := range group.Approvals {
+			if got := approvalIDs[entry.ID]; got != want[i].ids[j] {
+				t.Errorf("%s group entry %d = %s, want %s", group.RiskLevel, j, got, want[i].ids[j])
+			}
+		}
+	}
+
+	soonest := digest.Groups[2].Approvals[0]
+	if soonest.ExpiresInSeconds <= 25*60 || soonest.ExpiresInSeconds > 30*60 || soonest.ExpiresIn == "" {
+		t.Errorf("medium-soon expires in %ds (%q), want about 30 minutes", soonest.ExpiresInSeconds, soonest.ExpiresIn)
+	}
+
+	if w := doJSON(t, newTestHandler(c), http.MethodGet, "/coordination/approvals/digest", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("digest without a customer: status %d, want 400", w.Code)
+	}
+}
@@ -0,0 +1,147 @@
+package coordination
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ShutdownPolicy decides what happens to plans still executing at shutdown
+type ShutdownPolicy string
+
+const (
+	// ShutdownCompleteStep lets the in-flight step finish, then stops the
+	// plan and marks it interrupted so it can be resumed later
+	ShutdownCompleteStep ShutdownPolicy = "complete_step"
+
+	// ShutdownRollback lets the in-flight step finish, then rolls back
+	// every completed step of the plan
+	ShutdownRollback ShutdownPolicy = "rollback"
+)
+
+// ParseShutdownPolicy validates a shutdown policy name
+func ParseShutdownPolicy(name string) (ShutdownPolicy, error) {
+	switch policy := ShutdownPolicy(name); policy {
+	case ShutdownCompleteStep, ShutdownRollback:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown shutdown policy %q", name)
+	}
+}
+
+// CoordinatorState is the in-memory coordination state flushed at shutdown
+type CoordinatorState struct {
+	SavedAt         time.Time         `json:"saved_at"`
+	Approvals       []*Approval       `json:"approvals"`
+	Recommendations []*Recommendation `json:"recommendations"`
+	Plans           []*ExecutionPlan  `json:"plans"`
+}
+
+// StateSink persists coordinator state during shutdown
+type StateSink interface {
+	SaveState(state *CoordinatorState) error
+}
+
+// FileStateSink writes coordinator state as JSON to a file
+type FileStateSink struct {
+	path string
+}
+
+// NewFileStateSink creates a sink writing to path
+func NewFileStateSink(path string) *FileStateSink {
+	return &FileStateSink{path: path}
+}
+
+// SaveState writes the state atomically via a temporary file
+func (s *FileStateSink) SaveState(state *CoordinatorState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".coordination-state-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// SetShutdownPolicy sets how plans still executing at shutdown are handled
+func (c *Coordinator) SetShutdownPolicy(policy ShutdownPolicy) {
+	c.executionOrch.SetShutdownPolicy(policy)
+}
+
+// SetStateSink sets where pending approvals and unfinished plans are saved
+// as plans progress and flushed on shutdown; without one they are only
+// logged
+func (c *Coordinator) SetStateSink(sink StateSink) {
+	c.stateSink = sink
+	c.executionOrch.onCheckpoint = nil
+	if sink != nil {
+		c.executionOrch.onCheckpoint = c.checkpoint
+	}
+}
+
+// checkpoint saves the current state while plans execute
+func (c *Coordinator) checkpoint() {
+	c.checkpointMu.Lock()
+	defer c.checkpointMu.Unlock()
+
+	if err := c.stateSink.SaveState(c.captureState()); err != nil {
+		log.Printf("Failed to checkpoint coordinator state: %v", err)
+	}
+}
+
+// captureState collects the pending approvals, buffered recommendations and
+// unfinished plans
+func (c *Coordinator) captureState() *CoordinatorState {
+	c.mu.Lock()
+	recommendations := make([]*Recommendation, 0, len(c.recommendations))
+	for _, rec := range c.recommendations {
+		recommendations = append(recommendations, rec)
+	}
+	c.mu.Unlock()
+
+	return &CoordinatorState{
+		SavedAt:         time.Now(),
+		Approvals:       c.approvalManager.ListPendingApprovals(""),
+		Recommendations: recommendations,
+		Plans:           c.executionOrch.unfinishedPlans(),
+	}
+}
+
+// drain stops plan execution at a safe point and flushes in-memory state
+func (c *Coordinator) drain() {
+	c.executionOrch.Drain()
+
+	c.checkpointMu.Lock()
+	defer c.checkpointMu.Unlock()
+
+	state := c.captureState()
+	if c.stateSink == nil {
+		if len(state.Approvals) > 0 || len(state.Plans) > 0 {
+			log.Printf("Discarding %d pending approvals and %d unfinished plans (no state sink configured)",
+				len(state.Approvals), len(state.Plans))
+		}
+		return
+	}
+
+	if err := c.stateSink.SaveState(state); err != nil {
+		log.Printf("Failed to flush coordinator state: %v", err)
+		return
+	}
+	log.Printf("Flushed %d pending approvals and %d unfinished plans", len(state.Approvals), len(state.Plans))
+}
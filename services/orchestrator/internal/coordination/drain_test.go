@@ -0,0 +1,130 @@
+package coordination
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryStateSink keeps the last state saved to it
+type memoryStateSink struct {
+	mu    sync.Mutex
+	state *CoordinatorState
+}
+
+func (s *memoryStateSink) SaveState(state *CoordinatorState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = state
+	return nil
+}
+
+func (s *memoryStateSink) last() *CoordinatorState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state
+}
+
+// stopMidPlan starts a migrate_to_spot plan, stops the coordinator while
+// its first step runs, then lets the step finish. It returns the plan once
+// Stop has returned.
+func stopMidPlan(t *testing.T, policy ShutdownPolicy) (*Coordinator, *ExecutionPlan, *memoryStateSink) {
+	t.Helper()
+	steps := newHeldStepRunner()
+	c := newTestCoordinator(t, steps)
+	c.SetShutdownPolicy(policy)
+	sink := &memoryStateSink{}
+	c.SetStateSink(sink)
+
+	planID := executeRec(t, c, lowRiskRec("rec-1", "migrate_to_spot", "node-1"))
+	waitForPlan(t, c, planID, func(*ExecutionPlan) bool { return len(steps.actions()) == 1 })
+
+	stopped := make(chan struct{})
+	go func() {
+		c.Stop()
+		close(stopped)
+	}()
+	// Stop waits for the in-flight step
+	select {
+	case <-stopped:
+		t.Fatal("stop returned while a step was running")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(steps.release)
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stop did not return after the step finished")
+	}
+
+	if ran := steps.actions(); len(ran) != 1 {
+		t.Errorf("steps run across shutdown: %v, want only the first", ran)
+	}
+	plan, err := c.GetExecutionPlan(planID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, plan, sink
+}
+
+func TestShutdownCompletesStepAndInterruptsPlan(t *testing.T) {
+	_, plan, sink := stopMidPlan(t, ShutdownCompleteStep)
+
+	if plan.Status != ExecutionStatusInterrupted || plan.Steps[0].Status != ExecutionStatusCompleted || plan.Steps[1].Status != ExecutionStatusPending {
+		t.Errorf("plan %s with steps %s, %s", plan.Status, plan.Steps[0].Status, plan.Steps[1].Status)
+	}
+	// The interrupted plan is flushed so it can be resumed
+	state := sink.last()
+	if state == nil || len(state.Plans) != 1 || state.Plans[0].ID != plan.ID || state.Plans[0].Status != ExecutionStatusInterrupted {
+		t.Fatalf("flushed state = %+v", state)
+	}
+	if next, _ := state.Plans[0].Metadata["interrupted_before_step"].(int); next != 1 {
+		t.Errorf("interrupted before step %v, want 1", state.Plans[0].Metadata["interrupted_before_step"])
+	}
+}
+
+func TestShutdownRollsBackPlan(t *testing.T) {
+	_, plan, sink := stopMidPlan(t, ShutdownRollback)
+
+	if plan.Status != ExecutionStatusRolledBack {
+		t.Errorf("plan status = %s, want rolled back", plan.Status)
+	}
+	if plan.Steps[0].RollbackStatus != RollbackStatusRolledBack {
+		t.Errorf("first step rollback status = %s", plan.Steps[0].RollbackStatus)
+	}
+	// A rolled-back plan is finished, so nothing is left to resume
+	if state := sink.last(); state == nil || len(state.Plans) != 0 {
+		t.Errorf("flushed state = %+v, want no unfinished plans", state)
+	}
+}
+
+func TestExecuteAfterShutdownRefused(t *testing.T) {
+	c := newTestCoordinator(t, succeedingRunner)
+	c.Stop()
+
+	resp, err := c.Coordinate(&CoordinationRequest{
+		CustomerID:      "cust-1",
+		Recommendations: []*Recommendation{lowRiskRec("rec-1", "right_size", "node-1")},
+		AutoApprove:     true,
+		ExecuteNow:      true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, plan := range resp.ExecutionPlans {
+		if got, _ := c.GetExecutionPlan(plan.ID); got.Status == ExecutionStatusRunning || got.Status == ExecutionStatusCompleted {
+			t.Errorf("plan %s ran after shutdown: %s", plan.ID, got.Status)
+		}
+	}
+}
+
+func TestParseShutdownPolicy(t *testing.T) {
+	for _, name := range []string{"complete_step", "rollback"} {
+		if policy, err := ParseShutdownPolicy(name); err != nil || string(policy) != name {
+			t.Errorf("parse %s: %v, %v", name, policy, err)
+		}
+	}
+	if _, err := ParseShutdownPolicy("abandon"); err == nil {
+		t.Error("unknown policy accepted")
+	}
+}
@@ -0,0 +1,185 @@
+package coordination
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"optiinfra/services/orchestrator/internal/handlers"
+)
+
+// Classes of coordination errors, reported at the status ErrorStatuses maps
+// them to
+var (
+	// The request is malformed or inconsistent
+	ErrInvalidCoordination = errors.New("invalid coordination request")
+
+	// Resolution left recommendations that cannot be ordered for execution
+	ErrUnresolvableConflict = errors.New("unresolvable conflict")
+
+	// Coordination state could not be persisted
+	ErrCoordinationStorage = errors.New("coordination storage unavailable")
+)
+
+// errorClasses names the error classes for ParseErrorStatuses
+var errorClasses = map[string]error{
+	"validation": ErrInvalidCoordination,
+	"conflict":   ErrUnresolvableConflict,
+	"storage":    ErrCoordinationStorage,
+}
+
+// DefaultErrorStatuses returns the status of each coordination error class
+func DefaultErrorStatuses() handlers.ErrorStatuses {
+	return handlers.ErrorStatuses{
+		ErrInvalidCoordination:  http.StatusBadRequest,
+		ErrUnresolvableConflict: http.StatusConflict,
+		ErrCoordinationStorage:  http.StatusServiceUnavailable,
+	}
+}
+
+// ParseErrorStatuses parses overrides like "conflict=422,storage=500" on top
+// of the defaults. Classes are validation, conflict and storage.
+func ParseErrorStatuses(spec string) (handlers.ErrorStatuses, error) {
+	statuses := DefaultErrorStatuses()
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(entry, "=")
+		class, known := errorClasses[strings.TrimSpace(name)]
+		if !ok || !known {
+			return nil, fmt.Errorf("invalid error status entry %q", entry)
+		}
+		status, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || status < 400 || status > 599 {
+			return nil, fmt.Errorf("invalid status for %s: %q", strings.TrimSpace(name), value)
+		}
+		statuses[class] = status
+	}
+	return statuses, nil
+}
+
+// SetErrorStatuses replaces the statuses coordination errors are reported at
+func (h *Handler) SetErrorStatuses(statuses handlers.ErrorStatuses) {
+	h.errorStatuses = statuses
+}
+
+// validateRiskLevel checks a recommendation's risk level, when set, is known
+func validateRiskLevel(rec *Recommendation) error {
+	if rec.RiskLevel == "" {
+		return nil
+	}
+	if _, err := ParseRiskLevel(string(rec.RiskLevel)); err != nil {
+		return fmt.Errorf("%w: recommendation %s: %v", ErrInvalidCoordination, rec.ID, err)
+	}
+	return nil
+}
+
+// validateRiskLevels checks the risk levels of a recommendation set
+func validateRiskLevels(recs []*Recommendation) error {
+	for _, rec := range recs {
+		if rec == nil {
+			continue
+		}
+		if err := validateRiskLevel(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateCoordinationRequest checks a request before anything is created
+// from it
+func validateCoordinationRequest(req *CoordinationRequest) error {
+	if req.MinSavings < 0 {
+		return fmt.Errorf("%w: min_savings must not be negative", ErrInvalidCoordination)
+	}
+
+	seen := make(map[string]bool, len(req.Recommendations))
+	for i, rec := range req.Recommendations {
+		if rec == nil {
+			return fmt.Errorf("%w: recommendation %d is null", ErrInvalidCoordination, i)
+		}
+		if rec.ID == "" {
+			return fmt.Errorf("%w: recommendation %d has no id", ErrInvalidCoordination, i)
+		}
+		if seen[rec.ID] {
+			return fmt.Errorf("%w: duplicate recommendation %s", ErrInvalidCoordination, rec.ID)
+		}
+		seen[rec.ID] = true
+
+		if rec.CustomerID != "" && rec.CustomerID != req.CustomerID {
+			return fmt.Errorf("%w: recommendation %s belongs to customer %s, not %s",
+				ErrInvalidCoordination, rec.ID, rec.CustomerID, req.CustomerID)
+		}
+		if err := validateRiskLevel(rec); err != nil {
+			return err
+		}
+		if rec.Confidence < 0 || rec.Confidence > 1 {
+			return fmt.Errorf("%w: recommendation %s has confidence %v outside 0-1", ErrInvalidCoordination, rec.ID, rec.Confidence)
+		}
+		for action, condition := range rec.StepConditions {
+			if err := condition.Validate(); err != nil {
+				return fmt.Errorf("%w: recommendation %s step %s: %v", ErrInvalidCoordination, rec.ID, action, err)
+			}
+		}
+	}
+	return nil
+}
+
+// dependencyCycle returns the IDs along a dependency cycle among the
+// recommendations, first ID repeated last, or nil if there is none.
+// Dependencies outside the set are ignored.
+func dependencyCycle(recommendations []*Recommendation) []string {
+	byID := make(map[string]*Recommendation, len(recommendations))
+	for _, rec := range recommendations {
+		byID[rec.ID] = rec
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(recommendations))
+	var path []string
+
+	var visit func(id string) []string
+	visit = func(id string) []string {
+		state[id] = visiting
+		path = append(path, id)
+		for _, dep := range byID[id].Dependencies {
+			if _, ok := byID[dep]; !ok {
+				continue
+			}
+			switch state[dep] {
+			case visiting:
+				for i, step := range path {
+					if step == dep {
+						return append(append([]string(nil), path[i:]...), dep)
+					}
+				}
+			case unvisited:
+				if cycle := visit(dep); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[id] = done
+		return nil
+	}
+
+	for _, rec := range recommendations {
+		if state[rec.ID] == unvisited {
+			if cycle := visit(rec.ID); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package coordination
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"optiinfra/services/orchestrator/internal/handlers"
+)
+
+func TestCoordinateErrorStatuses(t *testing.T) {
+	router := newTestHandler(newTestCoordinator(t, succeedingRunner))
+	// Pairwise detection only resolves direct cycles, so this one survives
+	cyclic := []*Recommendation{
+		lowRiskRec("rec-a", "right_size", "node-1"),
+		lowRiskRec("rec-b", "right_size", "node-2"),
+		lowRiskRec("rec-c", "right_size", "node-3"),
+	}
+	cyclic[0].Dependencies = []string{"rec-b"}
+	cyclic[1].Dependencies = []string{"rec-c"}
+	cyclic[2].Dependencies = []string{"rec-a"}
+	badConfidence := lowRiskRec("rec-1", "right_size", "node-1")
+	badConfidence.Confidence = 1.5
+
+	tests := []struct {
+		name   string
+		req    *CoordinationRequest
+		status int
+	}{
+		{"validation", &CoordinationRequest{CustomerID: "cust-1", Recommendations: []*Recommendation{badConfidence}}, http.StatusBadRequest},
+		{"negative minimum", &CoordinationRequest{CustomerID: "cust-1", Recommendations: []*Recommendation{lowRiskRec("rec-1", "right_size", "node-1")}, MinSavings: -1}, http.StatusBadRequest},
+		{"unresolvable conflict", &CoordinationRequest{CustomerID: "cust-1", Recommendations: cyclic}, http.StatusConflict},
+		{"valid", &CoordinationRequest{CustomerID: "cust-1", Recommendations: []*Recommendation{lowRiskRec("rec-1", "right_size", "node-1")}}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		if w := doJSON(t, router, http.MethodPost, "/coordination/coordinate", tt.req); w.Code != tt.status {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, w.Code, tt.status, w.Body.String())
+		}
+	}
+}
+
+func TestStorageFailureReportedUnavailable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	err := fmt.Errorf("%w: redis: connection refused", ErrCoordinationStorage)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	handlers.APIError(c, DefaultErrorStatuses(), err)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("storage failure: status %d, want 503", w.Code)
+	}
+	if status := DefaultErrorStatuses().Status(errors.New("unclassified")); status != http.StatusInternalServerError {
+		t.Errorf("unclassified error: status %d, want 500", status)
+	}
+}
+
+func TestParseErrorStatuses(t *testing.T) {
+	statuses, err := ParseErrorStatuses("conflict=422, storage=500")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statuses[ErrUnresolvableConflict] != 422 || statuses[ErrCoordinationStorage] != 500 || statuses[ErrInvalidCoordination] != 400 {
+		t.Errorf("statuses = %v, want overrides on top of the defaults", statuses)
+	}
+	for _, spec := range []string{"timeout=504", "storage=200", "storage", "validation=abc"} {
+		if _, err := ParseErrorStatuses(spec); err == nil {
+			t.Errorf("parsed %q", spec)
+		}
+	}
+}
@@ -1,167 +1,827 @@
 package coordination
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
+	"sync"
 	"time"
 
-	"github.com/google/uuid"
+	"optiinfra/services/orchestrator/internal/idgen"
 )
 
+// ErrOutsideMaintenanceWindow is returned when a plan is started outside the
+// configured maintenance window and deferral is disabled
+var ErrOutsideMaintenanceWindow = errors.New("outside maintenance window")
+
+// ErrPlanTooLarge is returned when a generated plan exceeds the step cap
+var ErrPlanTooLarge = errors.New("execution plan exceeds maximum step count")
+
+// ErrRequiresAgent is returned when no registered agent can execute one of
+// a plan's step actions
+var ErrRequiresAgent = errors.New("no agent available for action")
+
+// defaultMaxPlanSteps caps the steps per plan to prevent runaway fan-out of agent calls
+const defaultMaxPlanSteps = 20
+
 // ExecutionOrchestrator orchestrates multi-step executions
 type ExecutionOrchestrator struct {
+	// Guards plans and every field of the plans in it. A plan is only
+	// written by the goroutine executing it, which may read it unlocked;
+	// everyone else reads copies taken under the read lock.
+	mu    sync.RWMutex
 	plans map[string]*ExecutionPlan // In-memory storage
+
+	now          func() time.Time
+	maxPlanSteps int
+
+	// Runs f after d, returning a function that cancels it
+	afterFunc func(d time.Duration, f func()) (stop func() bool)
+
+	// Optional maintenance window gate; nil means execution is always allowed
+	maintenance       *MaintenanceSchedule
+	deferToNextWindow bool
+
+	// Timers starting plans deferred to the next maintenance window, by
+	// plan ID
+	windowTimers map[string]func() bool
+
+	// Pause requests by plan ID; closing the channel resumes the plan
+	pauseMu sync.Mutex
+	pauses  map[string]chan struct{}
+
+	// Abort signals by plan ID; closed when the plan's coordination is
+	// cancelled
+	aborts map[string]chan struct{}
+
+	// Shutdown drain: running plans stop at the next step boundary
+	shutdownPolicy ShutdownPolicy
+	runMu          sync.Mutex
+	draining       bool
+	drainCh        chan struct{}
+	running        sync.WaitGroup
+
+	// Called when a plan completes or rolls back
+	onFinished func(plan *ExecutionPlan, err error)
+
+	// Issues approvals for steps marked RequiresApproval
+	approvals *ApprovalManager
+
+	// Per-action semaphores bounding concurrent steps across plans
+	actionSlots map[string]chan struct{}
+
+	// Global kill switch deferring new plan executions
+	hold executionHold
+
+	// Runs steps instead of the built-in simulation when set
+	runner StepRunner
+
+	// Persists state after each plan transition when set
+	onCheckpoint func()
+
+	// Optional destination for the count of executing plans
+	metrics MetricsRecorder
+
+	// Generates plan, step and simulated snapshot IDs
+	ids idgen.IDGenerator
 }
 
 // NewExecutionOrchestrator creates a new execution orchestrator
 func NewExecutionOrchestrator() *ExecutionOrchestrator {
 	return &ExecutionOrchestrator{
-		plans: make(map[string]*ExecutionPlan),
+		plans:        make(map[string]*ExecutionPlan),
+		now:          time.Now,
+		maxPlanSteps: defaultMaxPlanSteps,
+		afterFunc: func(d time.Duration, f func()) func() bool {
+			return time.AfterFunc(d, f).Stop
+		},
+		windowTimers: make(map[string]func() bool),
+		pauses:       make(map[string]chan struct{}),
+		aborts:       make(map[string]chan struct{}),
+
+		shutdownPolicy: ShutdownCompleteStep,
+		drainCh:        make(chan struct{}),
+		ids:            idgen.UUIDGenerator{},
+	}
+}
+
+// SetShutdownPolicy sets how plans still executing at shutdown are handled
+func (eo *ExecutionOrchestrator) SetShutdownPolicy(policy ShutdownPolicy) {
+	eo.shutdownPolicy = policy
+}
+
+// Drain stops new executions and waits for running plans to reach a step
+// boundary, where they are interrupted or rolled back per the shutdown policy.
+// Plans deferred to the next maintenance window stay deferred.
+func (eo *ExecutionOrchestrator) Drain() {
+	eo.runMu.Lock()
+	if !eo.draining {
+		eo.draining = true
+		close(eo.drainCh)
+	}
+	eo.runMu.Unlock()
+
+	eo.mu.Lock()
+	for planID := range eo.windowTimers {
+		eo.stopWindowTimerLocked(planID)
+	}
+	eo.mu.Unlock()
+
+	eo.running.Wait()
+}
+
+// beginRun registers a plan execution unless the orchestrator is draining
+func (eo *ExecutionOrchestrator) beginRun() bool {
+	eo.runMu.Lock()
+	defer eo.runMu.Unlock()
+
+	if eo.draining {
+		return false
 	}
+	eo.running.Add(1)
+	return true
+}
+
+func (eo *ExecutionOrchestrator) isDraining() bool {
+	select {
+	case <-eo.drainCh:
+		return true
+	default:
+		return false
+	}
+}
+
+// unfinishedPlans returns copies of plans that have not finished
+func (eo *ExecutionOrchestrator) unfinishedPlans() []*ExecutionPlan {
+	eo.mu.RLock()
+	defer eo.mu.RUnlock()
+
+	plans := make([]*ExecutionPlan, 0)
+	for _, plan := range eo.plans {
+		if !plan.Status.finished() {
+			plans = append(plans, plan.snapshot())
+		}
+	}
+	return plans
+}
+
+// hasPlan reports whether a plan is still stored
+func (eo *ExecutionOrchestrator) hasPlan(planID string) bool {
+	eo.mu.RLock()
+	defer eo.mu.RUnlock()
+
+	_, ok := eo.plans[planID]
+	return ok
+}
+
+// interruptPlan stops a plan at a step boundary during shutdown
+func (eo *ExecutionOrchestrator) interruptPlan(plan *ExecutionPlan, nextStep int) {
+	if eo.shutdownPolicy == ShutdownRollback {
+		log.Printf("Shutting down: rolling back plan %s before step %d", plan.ID, nextStep+1)
+		eo.rollbackPlan(plan, nextStep)
+		eo.mu.Lock()
+		plan.Status = ExecutionStatusRolledBack
+		plan.EstimatedCompletion = nil
+		eo.mu.Unlock()
+		return
+	}
+
+	log.Printf("Shutting down: interrupting plan %s before step %d", plan.ID, nextStep+1)
+	eo.mu.Lock()
+	defer eo.mu.Unlock()
+	if plan.Metadata == nil {
+		plan.Metadata = make(map[string]interface{})
+	}
+	plan.Metadata["interrupted_before_step"] = nextStep
+	plan.Status = ExecutionStatusInterrupted
+	plan.EstimatedCompletion = nil
+}
+
+// SetMaxPlanSteps sets the maximum number of steps a plan may contain
+func (eo *ExecutionOrchestrator) SetMaxPlanSteps(max int) {
+	if max > 0 {
+		eo.maxPlanSteps = max
+	}
+}
+
+// SetMaintenanceSchedule restricts execution to the schedule's windows. When
+// deferToNext is set, plans started outside a window are queued for the next
+// opening instead of being refused.
+func (eo *ExecutionOrchestrator) SetMaintenanceSchedule(schedule *MaintenanceSchedule, deferToNext bool) {
+	eo.maintenance = schedule
+	eo.deferToNextWindow = deferToNext
+}
+
+// CheckMaintenanceWindow reports whether execution is currently allowed and,
+// if not, when the next window opens
+func (eo *ExecutionOrchestrator) CheckMaintenanceWindow() (bool, time.Time) {
+	if eo.maintenance == nil {
+		return true, time.Time{}
+	}
+	now := eo.now()
+	if eo.maintenance.Contains(now) {
+		return true, time.Time{}
+	}
+	return false, eo.maintenance.NextOpening(now)
 }
 
 // CreateExecutionPlan creates an execution plan from a recommendation
-func (eo *ExecutionOrchestrator) CreateExecutionPlan(rec *Recommendation) *ExecutionPlan {
+func (eo *ExecutionOrchestrator) CreateExecutionPlan(rec *Recommendation) (*ExecutionPlan, error) {
+	steps := planSteps(rec)
+	for i := range steps {
+		steps[i].CorrelationID = rec.CorrelationID
+	}
+	if err := applyStepApproval(steps, rec.StepApproval); err != nil {
+		return nil, fmt.Errorf("recommendation %s: %w", rec.ID, err)
+	}
+	if err := applyStepConditions(steps, rec.StepConditions); err != nil {
+		return nil, fmt.Errorf("recommendation %s: %w", rec.ID, err)
+	}
+	if missing := eo.missingAgents(steps); len(missing) > 0 {
+		return nil, fmt.Errorf("%w: recommendation %s: %s", ErrRequiresAgent, rec.ID, strings.Join(missing, ", "))
+	}
+	if len(steps) > eo.maxPlanSteps {
+		return nil, fmt.Errorf("%w: recommendation %s generated %d steps (max %d)",
+			ErrPlanTooLarge, rec.ID, len(steps), eo.maxPlanSteps)
+	}
+
+	planID := eo.ids.NewID()
+	for i := range steps {
+		steps[i].ID = eo.ids.NewID()
+	}
 	plan := &ExecutionPlan{
-		ID:               uuid.New().String(),
+		ID:               planID,
 		RecommendationID: rec.ID,
 		CustomerID:       rec.CustomerID,
-		Steps:            eo.generateSteps(rec),
+		RequestedBy:      rec.AgentID,
+		RiskLevel:        rec.RiskLevel,
+		Steps:            steps,
 		Status:           ExecutionStatusPending,
 		CurrentStep:      0,
 		CreatedAt:        time.Now(),
+		CorrelationID:    rec.CorrelationID,
+		ProjectedImpact:  projectImpact(rec, steps),
 	}
 
+	eo.mu.Lock()
 	eo.plans[plan.ID] = plan
+	eo.mu.Unlock()
 
-	log.Printf("Created execution plan %s for recommendation %s with %d steps",
-		plan.ID, rec.ID, len(plan.Steps))
+	log.Printf("Created execution plan %s for recommendation %s with %d steps (projected net savings %.2f)",
+		plan.ID, rec.ID, len(plan.Steps), plan.ProjectedImpact.NetSavings)
 
-	return plan
+	return plan.snapshot(), nil
 }
 
-// ExecutePlan executes an execution plan
-func (eo *ExecutionOrchestrator) ExecutePlan(planID string) error {
-	plan, ok := eo.plans[planID]
+// missingAgents returns the step actions the runner reports no agent for.
+// The built-in simulation runs every action, and registry errors are left to
+// surface when the step runs.
+func (eo *ExecutionOrchestrator) missingAgents(steps []ExecutionStep) []string {
+	preflight, ok := eo.runner.(StepPreflight)
 	if !ok {
-		return fmt.Errorf("plan not found: %s", planID)
+		return nil
 	}
 
-	// Check if already running or completed
-	if plan.Status == ExecutionStatusRunning {
-		return fmt.Errorf("plan already running: %s", planID)
-	}
-	if plan.Status == ExecutionStatusCompleted {
-		return fmt.Errorf("plan already completed: %s", planID)
+	var missing []string
+	seen := make(map[string]bool, len(steps))
+	for i := range steps {
+		step := &steps[i]
+		if seen[step.AgentType+"/"+step.Action] {
+			continue
+		}
+		seen[step.AgentType+"/"+step.Action] = true
+
+		available, err := preflight.CanRunStep(step)
+		if err != nil {
+			log.Printf("Preflight for step %s skipped: %v", step.Action, err)
+			continue
+		}
+		if !available {
+			missing = append(missing, step.Action)
+		}
 	}
+	return missing
+}
 
-	log.Printf("Executing plan %s (%d steps)", planID, len(plan.Steps))
+// ExecutePlan executes an execution plan
+func (eo *ExecutionOrchestrator) ExecutePlan(planID string) error {
+	err := eo.executePlan(planID)
+
+	if eo.onFinished != nil {
+		var finished *ExecutionPlan
+		eo.mu.RLock()
+		if plan, ok := eo.plans[planID]; ok {
+			if plan.Status.finished() {
+				finished = plan.snapshot()
+			}
+		}
+		eo.mu.RUnlock()
 
-	// Update plan status
-	plan.Status = ExecutionStatusRunning
-	now := time.Now()
-	plan.StartedAt = &now
+		if finished != nil {
+			eo.onFinished(finished, err)
+		}
+	}
+	return err
+}
+
+func (eo *ExecutionOrchestrator) executePlan(planID string) error {
+	plan, err := eo.startPlan(planID)
+	if plan == nil {
+		return err
+	}
+	defer eo.running.Done()
+	defer eo.clearAbort(planID)
+	defer eo.checkpoint()
+	eo.checkpoint()
+	eo.reportActivePlans()
+	defer eo.reportActivePlans()
+
+	eo.mu.RLock()
+	first := plan.CurrentStep
+	eo.mu.RUnlock()
+	if first > 0 {
+		log.Printf("Resuming plan %s at step %d/%d", planID, first+1, len(plan.Steps))
+	} else {
+		log.Printf("Executing plan %s (%d steps)", planID, len(plan.Steps))
+	}
 
 	// Execute each step
-	for i := 0; i < len(plan.Steps); i++ {
+	for i := first; i < len(plan.Steps); i++ {
 		step := &plan.Steps[i]
+		eo.mu.Lock()
 		plan.CurrentStep = i
+		eo.mu.Unlock()
+
+		eo.waitIfPaused(plan)
+		if eo.isDraining() {
+			eo.interruptPlan(plan, i)
+			return fmt.Errorf("plan %s interrupted by shutdown", planID)
+		}
+		if eo.abortRequested(planID) {
+			eo.abortPlan(plan, i)
+			return fmt.Errorf("%w: %s", ErrPlanAborted, planID)
+		}
+
+		if met, reason := conditionMet(plan, i); !met {
+			log.Printf("Skipping step %d/%d (%s): %s", i+1, len(plan.Steps), step.Action, reason)
+			eo.mu.Lock()
+			step.Status = ExecutionStatusSkipped
+			step.SkipReason = reason
+			plan.CurrentStep = i + 1
+			eo.updateProgress(plan)
+			eo.mu.Unlock()
+			eo.checkpoint()
+			continue
+		}
+
+		// A pause requested while the step awaited approval holds it here
+		approvalErr := eo.awaitStepApproval(plan, step)
+		if approvalErr == nil {
+			eo.waitIfPaused(plan)
+		}
+		if eo.isDraining() {
+			eo.interruptPlan(plan, i)
+			return fmt.Errorf("plan %s interrupted by shutdown", planID)
+		}
+		if eo.abortRequested(planID) {
+			eo.abortPlan(plan, i)
+			return fmt.Errorf("%w: %s", ErrPlanAborted, planID)
+		}
 
 		log.Printf("Executing step %d/%d: %s", i+1, len(plan.Steps), step.Action)
 
-		// Execute step
-		if err := eo.executeStep(step); err != nil {
+		// Execute step; a step refused sign-off fails without running
+		err := approvalErr
+		if err == nil {
+			release, ok := eo.acquireActionSlot(plan, step)
+			if !ok {
+				eo.interruptPlan(plan, i)
+				return fmt.Errorf("plan %s interrupted by shutdown", planID)
+			}
+			err = eo.executeStep(step)
+			release()
+		}
+		if err != nil {
 			log.Printf("Step %d failed: %v", i+1, err)
 
 			// If critical step failed, rollback
 			if step.Critical {
 				log.Printf("Critical step failed, rolling back...")
+				eo.mu.Lock()
+				step.Status = ExecutionStatusFailed
+				step.Error = err.Error()
+				eo.mu.Unlock()
 				eo.rollbackPlan(plan, i)
+				eo.mu.Lock()
 				plan.Status = ExecutionStatusRolledBack
+				plan.EstimatedCompletion = nil
+				eo.mu.Unlock()
 				return fmt.Errorf("critical step failed: %w", err)
 			}
 
 			// Non-critical step: log and continue
 			log.Printf("Non-critical step failed, continuing...")
+			eo.mu.Lock()
 			step.Status = ExecutionStatusFailed
 			step.Error = err.Error()
+			plan.CurrentStep = i + 1
+			eo.updateProgress(plan)
+			eo.mu.Unlock()
+			eo.checkpoint()
 			continue
 		}
 
+		// Past the step as soon as it finishes, so a plan recovered from
+		// this checkpoint neither re-runs it nor skips rolling it back
+		eo.mu.Lock()
 		step.Status = ExecutionStatusCompleted
+		plan.CurrentStep = i + 1
+		eo.updateProgress(plan)
+		eo.mu.Unlock()
+		eo.checkpoint()
 	}
 
 	// All steps completed
+	eo.mu.Lock()
 	plan.Status = ExecutionStatusCompleted
 	completedAt := time.Now()
 	plan.CompletedAt = &completedAt
+	plan.ProgressPercent = 100
+	plan.EstimatedCompletion = &completedAt
 	plan.TotalDuration = int(completedAt.Sub(*plan.StartedAt).Milliseconds())
+	eo.mu.Unlock()
 
 	log.Printf("Plan %s completed successfully (duration: %dms)", planID, plan.TotalDuration)
 
 	return nil
 }
 
+// startPlan checks a plan can run and marks it running, returning nil with
+// the reason if it cannot. A plan deferred by the kill switch or the
+// maintenance window is returned as nil with a nil error. On success the
+// caller must call eo.running.Done when execution ends.
+func (eo *ExecutionOrchestrator) startPlan(planID string) (*ExecutionPlan, error) {
+	eo.mu.Lock()
+	defer eo.mu.Unlock()
+
+	plan, ok := eo.plans[planID]
+	if !ok {
+		return nil, fmt.Errorf("plan not found: %s", planID)
+	}
+
+	// Check if already running or completed
+	if plan.Status == ExecutionStatusRunning {
+		return nil, fmt.Errorf("plan already running: %s", planID)
+	}
+	if plan.Status == ExecutionStatusCompleted {
+		return nil, fmt.Errorf("plan already completed: %s", planID)
+	}
+	if plan.Status == ExecutionStatusPaused {
+		return nil, fmt.Errorf("plan paused: %s", planID)
+	}
+	if plan.Status == ExecutionStatusAwaitingApproval {
+		return nil, fmt.Errorf("plan awaiting step approval: %s", planID)
+	}
+	if plan.Status == ExecutionStatusCancelled {
+		return nil, fmt.Errorf("plan cancelled: %s", planID)
+	}
+	if _, aborted := plan.Metadata[abortedBeforeStepKey]; aborted {
+		return nil, fmt.Errorf("plan aborted by coordination cancel: %s", planID)
+	}
+
+	if eo.deferWhilePaused(plan) {
+		return nil, nil
+	}
+
+	// Enforce maintenance window
+	if allowed, next := eo.CheckMaintenanceWindow(); !allowed {
+		return nil, eo.deferPlan(plan, next)
+	}
+
+	if !eo.beginRun() {
+		return nil, fmt.Errorf("orchestrator shutting down, not executing plan %s", planID)
+	}
+	eo.stopWindowTimerLocked(planID)
+
+	// An interrupted plan resumes at the step it stopped before; any other
+	// plan runs from the start
+	resuming := plan.Status == ExecutionStatusInterrupted && plan.CurrentStep <= len(plan.Steps)
+	if !resuming {
+		plan.CurrentStep = 0
+	}
+
+	// Update plan status
+	plan.Status = ExecutionStatusRunning
+	if !resuming || plan.StartedAt == nil {
+		now := time.Now()
+		plan.StartedAt = &now
+	}
+	return plan, nil
+}
+
+// PausePlan asks a plan to stop before its next step until resumed. A step
+// already in progress runs to completion, and a step awaiting approval does
+// not start once approved until the plan is resumed.
+func (eo *ExecutionOrchestrator) PausePlan(planID string) error {
+	eo.mu.RLock()
+	plan, ok := eo.plans[planID]
+	var status ExecutionStatus
+	if ok {
+		status = plan.Status
+	}
+	eo.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("plan not found: %s", planID)
+	}
+
+	switch status {
+	case ExecutionStatusPending, ExecutionStatusRunning, ExecutionStatusDeferred, ExecutionStatusAwaitingApproval:
+	default:
+		return fmt.Errorf("cannot pause plan %s in status %s", planID, status)
+	}
+
+	eo.pauseMu.Lock()
+	defer eo.pauseMu.Unlock()
+
+	if _, paused := eo.pauses[planID]; paused {
+		return fmt.Errorf("plan already paused: %s", planID)
+	}
+	eo.pauses[planID] = make(chan struct{})
+
+	log.Printf("Pause requested for plan %s", planID)
+	return nil
+}
+
+// ResumePlan releases a paused plan to continue with its next step
+func (eo *ExecutionOrchestrator) ResumePlan(planID string) error {
+	if !eo.hasPlan(planID) {
+		return fmt.Errorf("plan not found: %s", planID)
+	}
+
+	eo.pauseMu.Lock()
+	defer eo.pauseMu.Unlock()
+
+	resume, paused := eo.pauses[planID]
+	if !paused {
+		return fmt.Errorf("plan not paused: %s", planID)
+	}
+	delete(eo.pauses, planID)
+	close(resume)
+
+	log.Printf("Plan %s resumed", planID)
+	return nil
+}
+
+// waitIfPaused blocks between steps while a pause is requested for the plan
+func (eo *ExecutionOrchestrator) waitIfPaused(plan *ExecutionPlan) {
+	eo.pauseMu.Lock()
+	resume, paused := eo.pauses[plan.ID]
+	eo.pauseMu.Unlock()
+	if !paused {
+		return
+	}
+
+	log.Printf("Plan %s paused before step %d", plan.ID, plan.CurrentStep+1)
+	eo.setPlanStatus(plan, ExecutionStatusPaused)
+	select {
+	case <-resume:
+	case <-eo.drainCh:
+	}
+	eo.setPlanStatus(plan, ExecutionStatusRunning)
+}
+
+// checkpoint persists the coordinator state, if a state sink is set, so a
+// crash loses at most the step in progress. It must not be called with
+// eo.mu held.
+func (eo *ExecutionOrchestrator) checkpoint() {
+	if eo.onCheckpoint != nil {
+		eo.onCheckpoint()
+	}
+}
+
+func (eo *ExecutionOrchestrator) setPlanStatus(plan *ExecutionPlan, status ExecutionStatus) {
+	eo.mu.Lock()
+	defer eo.mu.Unlock()
+	plan.Status = status
+}
+
+// awaitStepApproval blocks before a step marked RequiresApproval until its
+// approval is decided, requesting one first if needed. It returns an error if
+// the approval is rejected or expires, and nil early if shutdown begins or
+// the plan is aborted.
+func (eo *ExecutionOrchestrator) awaitStepApproval(plan *ExecutionPlan, step *ExecutionStep) error {
+	if !step.RequiresApproval || eo.approvals == nil {
+		return nil
+	}
+
+	var approval *Approval
+	if step.ApprovalID != "" {
+		approval, _ = eo.approvals.GetApproval(step.ApprovalID)
+	}
+	// Reuse the approval from an interrupted run unless it expired
+	if approval == nil || approval.Status == ApprovalStatusExpired {
+		approval = eo.approvals.RequestStepApproval(plan, step)
+		eo.mu.Lock()
+		step.ApprovalID = approval.ID
+		eo.mu.Unlock()
+	}
+
+	if decided := eo.approvals.StepDecision(approval.ID); decided != nil {
+		log.Printf("Plan %s waiting for approval %s before step %d", plan.ID, approval.ID, plan.CurrentStep+1)
+		eo.setPlanStatus(plan, ExecutionStatusAwaitingApproval)
+
+		expiry := time.NewTimer(eo.approvals.untilExpiry(approval))
+		defer expiry.Stop()
+		select {
+		case <-decided:
+		case <-expiry.C:
+			eo.approvals.ExpireStepApproval(approval.ID)
+		case <-eo.drainCh:
+			return nil
+		case <-eo.abortSignal(plan.ID):
+			eo.setPlanStatus(plan, ExecutionStatusRunning)
+			return nil
+		}
+		eo.setPlanStatus(plan, ExecutionStatusRunning)
+	}
+
+	// Read the decision; the copy above may predate it
+	if current, err := eo.approvals.GetApproval(approval.ID); err == nil {
+		approval = current
+	}
+
+	switch approval.Status {
+	case ApprovalStatusApproved:
+		return nil
+	case ApprovalStatusRejected:
+		return fmt.Errorf("step %s rejected by %s: %s", step.ID, approval.RejectedBy, approval.RejectionReason)
+	default:
+		return fmt.Errorf("step %s approval %s", step.ID, approval.Status)
+	}
+}
+
+// updateProgress recomputes the plan's progress percentage and projects its
+// completion from the average duration of the steps run so far. It must
+// be called with eo.mu held.
+func (eo *ExecutionOrchestrator) updateProgress(plan *ExecutionPlan) {
+	if len(plan.Steps) == 0 {
+		return
+	}
+
+	finished := 0
+	ran := 0
+	totalDuration := 0
+	for _, step := range plan.Steps {
+		switch step.Status {
+		case ExecutionStatusCompleted, ExecutionStatusFailed:
+			finished++
+			ran++
+			totalDuration += step.Duration
+		case ExecutionStatusSkipped:
+			finished++
+		}
+	}
+
+	plan.ProgressPercent = float64(finished) * 100 / float64(len(plan.Steps))
+	if ran == 0 {
+		plan.EstimatedCompletion = nil
+		return
+	}
+
+	avgStep := time.Duration(totalDuration/ran) * time.Millisecond
+	eta := eo.now().Add(avgStep * time.Duration(len(plan.Steps)-finished))
+	plan.EstimatedCompletion = &eta
+}
+
+// deferPlan refuses a plan outside the maintenance window, optionally
+// scheduling it to run when the next window opens. A plan already scheduled
+// keeps its timer. It must be called with eo.mu held.
+func (eo *ExecutionOrchestrator) deferPlan(plan *ExecutionPlan, next time.Time) error {
+	if !eo.deferToNextWindow || next.IsZero() {
+		return fmt.Errorf("%w: plan %s (next window opens %s)",
+			ErrOutsideMaintenanceWindow, plan.ID, next.Format(time.RFC3339))
+	}
+	if _, scheduled := eo.windowTimers[plan.ID]; scheduled {
+		return nil
+	}
+
+	if plan.Metadata == nil {
+		plan.Metadata = make(map[string]interface{})
+	}
+	plan.Status = ExecutionStatusDeferred
+	plan.Metadata["deferred_until"] = next.Format(time.RFC3339)
+
+	planID := plan.ID
+	eo.windowTimers[planID] = eo.afterFunc(next.Sub(eo.now()), func() {
+		eo.mu.Lock()
+		delete(eo.windowTimers, planID)
+		eo.mu.Unlock()
+		if err := eo.ExecutePlan(planID); err != nil {
+			log.Printf("Deferred execution failed for plan %s: %v", planID, err)
+		}
+	})
+
+	log.Printf("Plan %s deferred until next maintenance window (%s)", plan.ID, next.Format(time.RFC3339))
+	return nil
+}
+
+// stopWindowTimerLocked cancels the timer starting a plan deferred to the
+// next maintenance window, if any. It must be called with eo.mu held.
+func (eo *ExecutionOrchestrator) stopWindowTimerLocked(planID string) {
+	if stop, ok := eo.windowTimers[planID]; ok {
+		stop()
+		delete(eo.windowTimers, planID)
+	}
+}
+
 // executeStep executes a single step
 func (eo *ExecutionOrchestrator) executeStep(step *ExecutionStep) error {
 	startTime := time.Now()
+	eo.mu.Lock()
 	step.Status = ExecutionStatusRunning
 	step.StartedAt = &startTime
+	current := *step
+	eo.mu.Unlock()
+
+	var result, rollbackData map[string]interface{}
+	var err error
+	if eo.runner != nil {
+		result, err = eo.runner.RunStep(context.Background(), &current)
+		if current.TaskID != "" {
+			eo.mu.Lock()
+			step.TaskID = current.TaskID
+			eo.mu.Unlock()
+		}
+	} else {
+		result, rollbackData, err = simulateStep(step.Action, eo.ids)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Update step timing
+	completedAt := time.Now()
+	eo.mu.Lock()
+	step.Result = result
+	step.RollbackData = rollbackData
+	step.CompletedAt = &completedAt
+	step.Duration = int(completedAt.Sub(startTime).Milliseconds())
+	eo.mu.Unlock()
+
+	log.Printf("Step completed: %s (duration: %dms)", step.Action, step.Duration)
+
+	return nil
+}
 
+// simulateStep stands in for an agent call, returning the step's result and
+// the data needed to roll it back
+func simulateStep(action string, ids idgen.IDGenerator) (map[string]interface{}, map[string]interface{}, error) {
 	// Simulate execution (in production, this would call agent APIs)
 	// For now, we'll simulate with a simple action-based logic
-	switch step.Action {
+	switch action {
 	case "take_snapshot":
 		// Simulate snapshot creation
 		time.Sleep(500 * time.Millisecond)
-		step.Result = map[string]interface{}{
-			"snapshot_id": fmt.Sprintf("snap-%s", uuid.New().String()[:8]),
+		snapshotID := fmt.Sprintf("snap-%s", ids.NewID())
+		return map[string]interface{}{
+			"snapshot_id": snapshotID,
 			"size_gb":     100,
-		}
-		step.RollbackData = map[string]interface{}{
-			"snapshot_id": step.Result["snapshot_id"],
-		}
+		}, map[string]interface{}{
+			"snapshot_id": snapshotID,
+		}, nil
 
 	case "scale_resources":
 		// Simulate scaling
 		time.Sleep(1 * time.Second)
-		step.Result = map[string]interface{}{
+		return map[string]interface{}{
 			"previous_count": 5,
 			"new_count":      3,
 			"scaled_down":    2,
-		}
-		step.RollbackData = map[string]interface{}{
+		}, map[string]interface{}{
 			"restore_count": 5,
-		}
+		}, nil
 
 	case "migrate_workload":
 		// Simulate migration
 		time.Sleep(2 * time.Second)
-		step.Result = map[string]interface{}{
+		return map[string]interface{}{
 			"migrated_instances": 3,
 			"status":             "completed",
-		}
+		}, nil, nil
 
 	case "validate_quality":
 		// Simulate validation
 		time.Sleep(500 * time.Millisecond)
-		step.Result = map[string]interface{}{
+		return map[string]interface{}{
 			"quality_score": 0.95,
 			"passed":        true,
-		}
+		}, nil, nil
 
 	default:
-		return fmt.Errorf("unknown action: %s", step.Action)
+		return nil, nil, fmt.Errorf("unknown action: %s", action)
 	}
-
-	// Update step timing
-	completedAt := time.Now()
-	step.CompletedAt = &completedAt
-	step.Duration = int(completedAt.Sub(startTime).Milliseconds())
-
-	log.Printf("Step completed: %s (duration: %dms)", step.Action, step.Duration)
-
-	return nil
 }
 
 // rollbackPlan rolls back executed steps
@@ -175,11 +835,13 @@ func (eo *ExecutionOrchestrator) rollbackPlan(plan *ExecutionPlan, failedStepInd
 		// Only roll back reversible steps
 		if !step.Reversible {
 			log.Printf("Step %d (%s) is not reversible, skipping", i+1, step.Action)
+			eo.setRollbackStatus(step, RollbackStatusIrreversible, nil)
 			continue
 		}
 
 		// Skip steps that didn't complete
 		if step.Status != ExecutionStatusCompleted {
+			eo.setRollbackStatus(step, RollbackStatusNotRun, nil)
 			continue
 		}
 
@@ -187,12 +849,32 @@ func (eo *ExecutionOrchestrator) rollbackPlan(plan *ExecutionPlan, failedStepInd
 
 		if err := eo.rollbackStep(step); err != nil {
 			log.Printf("Failed to rollback step %d: %v", i+1, err)
+			eo.setRollbackStatus(step, RollbackStatusFailed, err)
 			// Continue rolling back other steps
+			continue
 		}
+		eo.setRollbackStatus(step, RollbackStatusRolledBack, nil)
 	}
 
 	now := time.Now()
+	eo.mu.Lock()
 	plan.RolledBackAt = &now
+	eo.mu.Unlock()
+}
+
+// setRollbackStatus records how a step fared in its plan's rollback, and
+// when, if it was attempted
+func (eo *ExecutionOrchestrator) setRollbackStatus(step *ExecutionStep, status RollbackStatus, err error) {
+	now := time.Now()
+	eo.mu.Lock()
+	defer eo.mu.Unlock()
+	step.RollbackStatus = status
+	if status == RollbackStatusRolledBack || status == RollbackStatusFailed {
+		step.RolledBackAt = &now
+	}
+	if err != nil {
+		step.RollbackError = err.Error()
+	}
 }
 
 // rollbackStep rolls back a single step
@@ -221,17 +903,37 @@ func (eo *ExecutionOrchestrator) rollbackStep(step *ExecutionStep) error {
 	return nil
 }
 
-// GetPlan retrieves an execution plan
+// GetPlan returns a copy of an execution plan
 func (eo *ExecutionOrchestrator) GetPlan(planID string) (*ExecutionPlan, error) {
+	eo.mu.RLock()
+	defer eo.mu.RUnlock()
+
 	plan, ok := eo.plans[planID]
 	if !ok {
 		return nil, fmt.Errorf("plan not found: %s", planID)
 	}
-	return plan, nil
+	return plan.snapshot(), nil
 }
 
-// generateSteps generates execution steps based on recommendation type
-func (eo *ExecutionOrchestrator) generateSteps(rec *Recommendation) []ExecutionStep {
+// snapshot copies a plan's mutable state so the copy can be read while the
+// plan keeps executing. Step result maps are replaced, never modified, once
+// set, so they are shared.
+func (p *ExecutionPlan) snapshot() *ExecutionPlan {
+	cp := *p
+	cp.Steps = append([]ExecutionStep(nil), p.Steps...)
+	if p.Metadata != nil {
+		cp.Metadata = make(map[string]interface{}, len(p.Metadata))
+		for k, v := range p.Metadata {
+			cp.Metadata[k] = v
+		}
+	}
+	return &cp
+}
+
+// planSteps generates execution steps based on recommendation type. The
+// steps have no IDs until a plan is created from them, so checking a
+// recommendation's steps hands out none.
+func planSteps(rec *Recommendation) []ExecutionStep {
 	steps := make([]ExecutionStep, 0)
 
 	// Generate steps based on action type
@@ -239,25 +941,26 @@ func (eo *ExecutionOrchestrator) generateSteps(rec *Recommendation) []ExecutionS
 	case "migrate_to_spot":
 		steps = []ExecutionStep{
 			{
-				ID:         uuid.New().String(),
 				Action:     "take_snapshot",
 				AgentID:    rec.AgentID,
+				AgentType:  rec.AgentType,
 				Critical:   true,
 				Reversible: true,
 				Status:     ExecutionStatusPending,
 			},
 			{
-				ID:         uuid.New().String(),
 				Action:     "migrate_workload",
 				AgentID:    rec.AgentID,
+				AgentType:  rec.AgentType,
+				Parameters: rec.Parameters,
 				Critical:   true,
 				Reversible: true,
 				Status:     ExecutionStatusPending,
 			},
 			{
-				ID:         uuid.New().String(),
 				Action:     "validate_quality",
 				AgentID:    "application-agent",
+				AgentType:  "application",
 				Critical:   true,
 				Reversible: false,
 				Status:     ExecutionStatusPending,
@@ -267,25 +970,26 @@ func (eo *ExecutionOrchestrator) generateSteps(rec *Recommendation) []ExecutionS
 	case "scale_down":
 		steps = []ExecutionStep{
 			{
-				ID:         uuid.New().String(),
 				Action:     "validate_quality",
 				AgentID:    "application-agent",
+				AgentType:  "application",
 				Critical:   true,
 				Reversible: false,
 				Status:     ExecutionStatusPending,
 			},
 			{
-				ID:         uuid.New().String(),
 				Action:     "scale_resources",
 				AgentID:    rec.AgentID,
+				AgentType:  rec.AgentType,
+				Parameters: rec.Parameters,
 				Critical:   true,
 				Reversible: true,
 				Status:     ExecutionStatusPending,
 			},
 			{
-				ID:         uuid.New().String(),
 				Action:     "validate_quality",
 				AgentID:    "application-agent",
+				AgentType:  "application",
 				Critical:   true,
 				Reversible: false,
 				Status:     ExecutionStatusPending,
@@ -296,9 +1000,9 @@ func (eo *ExecutionOrchestrator) generateSteps(rec *Recommendation) []ExecutionS
 		// Simple single-step execution
 		steps = []ExecutionStep{
 			{
-				ID:         uuid.New().String(),
 				Action:     rec.Action,
 				AgentID:    rec.AgentID,
+				AgentType:  rec.AgentType,
 				Parameters: rec.Parameters,
 				Critical:   true,
 				Reversible: false,
@@ -0,0 +1,99 @@
+package coordination
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestPlanStepCap(t *testing.T) {
+	c := newTestCoordinator(t, succeedingRunner)
+	c.SetMaxPlanSteps(2)
+
+	// migrate_to_spot generates three steps
+	rec := lowRiskRec("rec-1", "migrate_to_spot", "node-1")
+	if _, err := c.executionOrch.CreateExecutionPlan(rec); !errors.Is(err, ErrPlanTooLarge) {
+		t.Fatalf("create 3-step plan with cap 2: %v, want ErrPlanTooLarge", err)
+	}
+
+	resp, err := c.Coordinate(&CoordinationRequest{
+		CustomerID:      "cust-1",
+		Recommendations: []*Recommendation{rec},
+		AutoApprove:     true,
+		ExecuteNow:      true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.ExecutionPlans) != 0 || rec.Status != "plan_rejected" {
+		t.Errorf("coordination created %d plans with recommendation %s, want none and plan_rejected", len(resp.ExecutionPlans), rec.Status)
+	}
+
+	c.SetMaxPlanSteps(3)
+	plan, err := c.executionOrch.CreateExecutionPlan(lowRiskRec("rec-2", "migrate_to_spot", "node-1"))
+	if err != nil {
+		t.Fatalf("create 3-step plan with cap 3: %v", err)
+	}
+	if len(plan.Steps) != 3 {
+		t.Errorf("plan has %d steps, want 3", len(plan.Steps))
+	}
+
+	// Non-positive caps keep the current one
+	c.SetMaxPlanSteps(0)
+	if c.executionOrch.maxPlanSteps != 3 {
+		t.Errorf("cap after SetMaxPlanSteps(0) = %d, want 3", c.executionOrch.maxPlanSteps)
+	}
+}
+
+// Run with -race: plans execute while other goroutines read them
+func TestConcurrentExecuteAndGetPlan(t *testing.T) {
+	c := newTestCoordinator(t, succeedingRunner)
+	eo := c.executionOrch
+
+	var planIDs []string
+	for i := 0; i < 10; i++ {
+		id := fmt.Sprintf("rec-%d", i)
+		plan, err := eo.CreateExecutionPlan(lowRiskRec(id, "migrate_to_spot", "node-"+id))
+		if err != nil {
+			t.Fatal(err)
+		}
+		planIDs = append(planIDs, plan.ID)
+	}
+
+	var wg sync.WaitGroup
+	for _, planID := range planIDs {
+		planID := planID
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := eo.ExecutePlan(planID); err != nil {
+				t.Errorf("execute %s: %v", planID, err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				plan, err := eo.GetPlan(planID)
+				if err != nil {
+					t.Errorf("get %s: %v", planID, err)
+					return
+				}
+				for _, step := range plan.Steps {
+					_ = step.Status
+				}
+				_ = plan.ProgressPercent
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, planID := range planIDs {
+		plan := waitForPlanStatus(t, c, planID, ExecutionStatusCompleted)
+		for _, step := range plan.Steps {
+			if step.Status != ExecutionStatusCompleted {
+				t.Errorf("plan %s step %s %s, want completed", planID, step.Action, step.Status)
+			}
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package coordination
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func explain(t *testing.T, c *Coordinator, approvalID string) ApprovalExplanation {
+	t.Helper()
+	w := doJSON(t, newTestHandler(c), http.MethodGet, "/coordination/approvals/"+approvalID+"/explain", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("explain %s: status %d: %s", approvalID, w.Code, w.Body.String())
+	}
+	var explanation ApprovalExplanation
+	if err := json.Unmarshal(w.Body.Bytes(), &explanation); err != nil {
+		t.Fatal(err)
+	}
+	return explanation
+}
+
+func TestExplainAutoApproval(t *testing.T) {
+	c := newTestCoordinator(t, succeedingRunner)
+
+	for autoApprove, rule := range map[bool]string{
+		true:  "auto-approved: risk=low, auto_approve requested",
+		false: "auto-approved: risk=low requires no approval",
+	} {
+		resp, err := c.Coordinate(&CoordinationRequest{
+			CustomerID:      "cust-1",
+			Recommendations: []*Recommendation{lowRiskRec("rec-1", "migrate_to_spot", "node-1")},
+			AutoApprove:     autoApprove,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := resp.Recommendations[0]
+		explanation := explain(t, c, rec.ApprovalID)
+		if !explanation.AutoApproved || explanation.Rule != rule || explanation.DecidedBy != autoApprover {
+			t.Errorf("auto_approve=%v: explanation = %+v, want rule %q", autoApprove, explanation, rule)
+		}
+		if explanation.RecommendationID != "rec-1" || explanation.RiskLevel != RiskLevelLow || explanation.Status != ApprovalStatusApproved {
+			t.Errorf("auto_approve=%v: explanation = %+v", autoApprove, explanation)
+		}
+	}
+}
+
+func TestExplainRequiredApproval(t *testing.T) {
+	c := newTestCoordinator(t, succeedingRunner)
+	router := newTestHandler(c)
+	approvalID := pendingScaleDown(t, c)
+
+	explanation := explain(t, c, approvalID)
+	if explanation.AutoApproved || explanation.Rule != "approval required: risk=high" || explanation.Status != ApprovalStatusPending || explanation.DecidedBy != "" {
+		t.Errorf("pending explanation = %+v", explanation)
+	}
+
+	// The rule is kept once a person decides
+	if w := doJSON(t, router, http.MethodPost, "/coordination/approvals/"+approvalID+"/approve", map[string]string{"user_id": "alice"}); w.Code != http.StatusOK {
+		t.Fatalf("approve: status %d: %s", w.Code, w.Body.String())
+	}
+	explanation = explain(t, c, approvalID)
+	if explanation.AutoApproved || explanation.Rule != "approval required: risk=high" || explanation.DecidedBy != "alice" || explanation.Status != ApprovalStatusApproved {
+		t.Errorf("approved explanation = %+v", explanation)
+	}
+
+	other := newTestCoordinator(t, succeedingRunner)
+	rejected := pendingScaleDown(t, other)
+	if err := other.RejectRecommendation(rejected, "bob", "too risky"); err != nil {
+		t.Fatal(err)
+	}
+	if explanation := explain(t, other, rejected); explanation.DecidedBy != "bob" || explanation.Status != ApprovalStatusRejected {
+		t.Errorf("rejected explanation = %+v", explanation)
+	}
+
+	if w := doJSON(t, router, http.MethodGet, "/coordination/approvals/missing/explain", nil); w.Code != http.StatusNotFound {
+		t.Errorf("unknown approval: status %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,108 @@
+package coordination
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Export formats for coordination history
+const (
+	ExportFormatJSON   = "json"
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson"
+)
+
+// exportFlushEvery is how many rows are written between flushes to the client
+const exportFlushEvery = 100
+
+var outcomeCSVHeader = []string{
+	"coordination_id",
+	"customer_id",
+	"recommendation_id",
+	"agent_type",
+	"action",
+	"outcome",
+	"status",
+	"estimated_savings",
+	"risk_level",
+	"coordinated_at",
+}
+
+// negotiateExportFormat picks the export format from ?format=, falling back
+// to the Accept header and then JSON
+func negotiateExportFormat(format, accept string) string {
+	switch strings.ToLower(format) {
+	case ExportFormatCSV, ExportFormatNDJSON, ExportFormatJSON:
+		return strings.ToLower(format)
+	}
+
+	switch {
+	case strings.Contains(accept, "text/csv"):
+		return ExportFormatCSV
+	case strings.Contains(accept, "application/x-ndjson"):
+		return ExportFormatNDJSON
+	}
+	return ExportFormatJSON
+}
+
+// writeOutcomesCSV writes outcomes as CSV with a header row, flushing
+// periodically so large exports stream to the client
+func writeOutcomesCSV(w io.Writer, outcomes []RecommendationOutcome) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(outcomeCSVHeader); err != nil {
+		return err
+	}
+
+	for i, o := range outcomes {
+		row := []string{
+			o.CoordinationID,
+			o.CustomerID,
+			o.RecommendationID,
+			o.AgentType,
+			o.Action,
+			o.Outcome,
+			o.Status,
+			strconv.FormatFloat(o.EstimatedSavings, 'f', 2, 64),
+			string(o.RiskLevel),
+			o.CoordinatedAt.Format(time.RFC3339),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+		if (i+1)%exportFlushEvery == 0 {
+			cw.Flush()
+			flush(w)
+		}
+	}
+
+	cw.Flush()
+	flush(w)
+	return cw.Error()
+}
+
+// writeOutcomesNDJSON writes one JSON object per line
+func writeOutcomesNDJSON(w io.Writer, outcomes []RecommendationOutcome) error {
+	enc := json.NewEncoder(w)
+	for i := range outcomes {
+		if err := enc.Encode(&outcomes[i]); err != nil {
+			return err
+		}
+		if (i+1)%exportFlushEvery == 0 {
+			flush(w)
+		}
+	}
+
+	flush(w)
+	return nil
+}
+
+func flush(w io.Writer) {
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,131 @@
+package coordination
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// coordinateForExport runs one coordination with a kept and an expired
+// recommendation and returns its ID
+func coordinateForExport(t *testing.T, c *Coordinator) string {
+	t.Helper()
+	past := time.Now().Add(-time.Minute)
+	kept := lowRiskRec("rec-kept", "right_size", "node-1")
+	kept.EstimatedSavings = 123.456
+	expired := lowRiskRec("rec-expired", "right_size", "node-2")
+	expired.ExpiresAt = &past
+
+	resp, err := c.Coordinate(&CoordinationRequest{
+		CustomerID:      "cust-1",
+		Recommendations: []*Recommendation{kept, expired},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.ID
+}
+
+func getExport(t *testing.T, c *Coordinator, path, accept string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	newTestHandler(c).ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET %s: status = %d: %s", path, w.Code, w.Body.String())
+	}
+	return w
+}
+
+func TestHistoryExportCSV(t *testing.T) {
+	c := newTestCoordinator(t, succeedingRunner)
+	id := coordinateForExport(t, c)
+
+	w := getExport(t, c, "/coordination/history/"+id+"?format=csv", "")
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("content type = %q", ct)
+	}
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("CSV has %d records, want a header and 2 rows", len(records))
+	}
+	if got := strings.Join(records[0], ","); got != strings.Join(outcomeCSVHeader, ",") {
+		t.Errorf("header = %s", got)
+	}
+
+	rows := make(map[string][]string)
+	for _, record := range records[1:] {
+		if len(record) != len(outcomeCSVHeader) {
+			t.Fatalf("row has %d fields, want %d", len(record), len(outcomeCSVHeader))
+		}
+		rows[record[2]] = record
+	}
+	if row := rows["rec-kept"]; row == nil || row[0] != id || row[1] != "cust-1" || row[5] != OutcomeKept || row[7] != "123.46" || row[8] != string(RiskLevelLow) {
+		t.Errorf("kept row = %v", row)
+	}
+	if row := rows["rec-expired"]; row == nil || row[5] != OutcomeExpired {
+		t.Errorf("expired row = %v", row)
+	}
+	if _, err := time.Parse(time.RFC3339, records[1][9]); err != nil {
+		t.Errorf("coordinated_at: %v", err)
+	}
+}
+
+func TestHistoryExportNDJSON(t *testing.T) {
+	c := newTestCoordinator(t, succeedingRunner)
+	id := coordinateForExport(t, c)
+
+	// Negotiated through the Accept header
+	w := getExport(t, c, "/coordination/history/"+id, "application/x-ndjson")
+	if ct := w.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("content type = %q", ct)
+	}
+
+	outcomes := make(map[string]RecommendationOutcome)
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		var outcome RecommendationOutcome
+		if err := json.Unmarshal(scanner.Bytes(), &outcome); err != nil {
+			t.Fatalf("line %q is not one JSON object: %v", scanner.Text(), err)
+		}
+		outcomes[outcome.RecommendationID] = outcome
+	}
+	if len(outcomes) != 2 {
+		t.Fatalf("%d lines, want 2", len(outcomes))
+	}
+	if o := outcomes["rec-kept"]; o.Outcome != OutcomeKept || o.CoordinationID != id || o.EstimatedSavings != 123.456 {
+		t.Errorf("kept outcome = %+v", o)
+	}
+	if o := outcomes["rec-expired"]; o.Outcome != OutcomeExpired {
+		t.Errorf("expired outcome = %+v", o)
+	}
+}
+
+func TestNegotiateExportFormat(t *testing.T) {
+	tests := []struct {
+		format, accept, want string
+	}{
+		{"", "", ExportFormatJSON},
+		{"CSV", "", ExportFormatCSV},
+		{"ndjson", "text/csv", ExportFormatNDJSON},
+		{"", "text/csv", ExportFormatCSV},
+		{"", "application/x-ndjson", ExportFormatNDJSON},
+		{"xml", "application/json", ExportFormatJSON},
+	}
+	for _, tt := range tests {
+		if got := negotiateExportFormat(tt.format, tt.accept); got != tt.want {
+			t.Errorf("format %q, accept %q: got %s, want %s", tt.format, tt.accept, got, tt.want)
+		}
+	}
+}
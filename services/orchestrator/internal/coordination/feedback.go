@@ -0,0 +1,137 @@
+package coordination
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	// Feedback delivery retries with exponential backoff from feedbackRetryDelay
+	feedbackMaxAttempts = 3
+	feedbackRetryDelay  = 2 * time.Second
+	feedbackTimeout     = 10 * time.Second
+)
+
+// ExecutionOutcome is sent to the agent that produced a recommendation once
+// its plan finishes
+type ExecutionOutcome struct {
+	PlanID           string          `json:"plan_id"`
+	RecommendationID string          `json:"recommendation_id"`
+	CustomerID       string          `json:"customer_id"`
+	Status           ExecutionStatus `json:"status"`
+	Error            string          `json:"error,omitempty"`
+	StepsCompleted   int             `json:"steps_completed"`
+	StepsTotal       int             `json:"steps_total"`
+	TotalDuration    int             `json:"total_duration_ms"`
+	FinishedAt       time.Time       `json:"finished_at"`
+}
+
+// FeedbackNotifier delivers execution outcomes to agents
+type FeedbackNotifier interface {
+	NotifyOutcome(ctx context.Context, agentID string, outcome *ExecutionOutcome) error
+}
+
+// AgentURLResolver returns the base URL of a registered agent
+type AgentURLResolver func(agentID string) (string, error)
+
+// HTTPFeedbackNotifier POSTs outcomes to {agent}/feedback
+type HTTPFeedbackNotifier struct {
+	resolve AgentURLResolver
+	client  *http.Client
+}
+
+// NewHTTPFeedbackNotifier creates a notifier that locates agents with resolve
+func NewHTTPFeedbackNotifier(resolve AgentURLResolver) *HTTPFeedbackNotifier {
+	return &HTTPFeedbackNotifier{
+		resolve: resolve,
+		client:  &http.Client{Timeout: feedbackTimeout},
+	}
+}
+
+// NotifyOutcome sends one outcome to the agent's feedback endpoint
+func (n *HTTPFeedbackNotifier) NotifyOutcome(ctx context.Context, agentID string, outcome *ExecutionOutcome) error {
+	baseURL, err := n.resolve(agentID)
+	if err != nil {
+		return fmt.Errorf("failed to locate agent %s: %w", agentID, err)
+	}
+
+	body, err := json.Marshal(outcome)
+	if err != nil {
+		return fmt.Errorf("failed to marshal outcome: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", baseURL+"/feedback", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send feedback: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("agent returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SetFeedbackNotifier enables sending plan outcomes to the agent that
+// produced each recommendation; nil disables it
+func (c *Coordinator) SetFeedbackNotifier(notifier FeedbackNotifier) {
+	c.feedback = notifier
+}
+
+// planFinished delivers a finished plan's outcome in the background
+func (c *Coordinator) planFinished(plan *ExecutionPlan, execErr error) {
+	if c.feedback == nil || plan.RequestedBy == "" {
+		return
+	}
+
+	outcome := &ExecutionOutcome{
+		PlanID:           plan.ID,
+		RecommendationID: plan.RecommendationID,
+		CustomerID:       plan.CustomerID,
+		Status:           plan.Status,
+		StepsTotal:       len(plan.Steps),
+		TotalDuration:    plan.TotalDuration,
+		FinishedAt:       time.Now(),
+	}
+	if execErr != nil {
+		outcome.Error = execErr.Error()
+	}
+	for _, step := range plan.Steps {
+		if step.Status == ExecutionStatusCompleted {
+			outcome.StepsCompleted++
+		}
+	}
+
+	go c.deliverFeedback(plan.RequestedBy, outcome)
+}
+
+func (c *Coordinator) deliverFeedback(agentID string, outcome *ExecutionOutcome) {
+	delay := feedbackRetryDelay
+	for attempt := 1; attempt <= feedbackMaxAttempts; attempt++ {
+		err := c.feedback.NotifyOutcome(context.Background(), agentID, outcome)
+		if err == nil {
+			log.Printf("Delivered outcome of plan %s to agent %s", outcome.PlanID, agentID)
+			return
+		}
+
+		log.Printf("Feedback for plan %s to agent %s failed (attempt %d/%d): %v",
+			outcome.PlanID, agentID, attempt, feedbackMaxAttempts, err)
+		if attempt < feedbackMaxAttempts {
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+}
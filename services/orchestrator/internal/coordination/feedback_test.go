@@ -0,0 +1,127 @@
+package coordination
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// feedbackAgent is an agent's feedback endpoint recording the outcomes it
+// receives
+func feedbackAgent(t *testing.T) (*httptest.Server, <-chan ExecutionOutcome) {
+	t.Helper()
+	outcomes := make(chan ExecutionOutcome, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/feedback" {
+			http.NotFound(w, req)
+			return
+		}
+		var outcome ExecutionOutcome
+		if err := json.NewDecoder(req.Body).Decode(&outcome); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		outcomes <- outcome
+	}))
+	t.Cleanup(srv.Close)
+	return srv, outcomes
+}
+
+// newFeedbackCoordinator returns a coordinator sending outcomes for agentID
+// to url
+func newFeedbackCoordinator(t *testing.T, runner StepRunner, agentID, url string) *Coordinator {
+	t.Helper()
+	c := newTestCoordinator(t, runner)
+	c.SetFeedbackNotifier(NewHTTPFeedbackNotifier(func(id string) (string, error) {
+		if id != agentID {
+			return "", fmt.Errorf("unknown agent %s", id)
+		}
+		return url, nil
+	}))
+	return c
+}
+
+func receiveOutcome(t *testing.T, outcomes <-chan ExecutionOutcome) ExecutionOutcome {
+	t.Helper()
+	select {
+	case outcome := <-outcomes:
+		return outcome
+	case <-time.After(5 * time.Second):
+		t.Fatal("agent received no outcome")
+		return ExecutionOutcome{}
+	}
+}
+
+func executeRec(t *testing.T, c *Coordinator, rec *Recommendation) string {
+	t.Helper()
+	resp, err := c.Coordinate(&CoordinationRequest{
+		CustomerID:      "cust-1",
+		Recommendations: []*Recommendation{rec},
+		AutoApprove:     true,
+		ExecuteNow:      true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.ExecutionPlans) != 1 {
+		t.Fatalf("execution plans = %d, want 1", len(resp.ExecutionPlans))
+	}
+	return resp.ExecutionPlans[0].ID
+}
+
+func TestFeedbackSentToOriginatingAgent(t *testing.T) {
+	agent, outcomes := feedbackAgent(t)
+	rec := lowRiskRec("rec-1", "migrate_to_spot", "node-1")
+	c := newFeedbackCoordinator(t, succeedingRunner, rec.AgentID, agent.URL)
+
+	planID := executeRec(t, c, rec)
+	outcome := receiveOutcome(t, outcomes)
+	if outcome.PlanID != planID || outcome.RecommendationID != "rec-1" || outcome.CustomerID != "cust-1" {
+		t.Errorf("outcome for plan %s, recommendation %s, customer %s", outcome.PlanID, outcome.RecommendationID, outcome.CustomerID)
+	}
+	if outcome.Status != ExecutionStatusCompleted || outcome.Error != "" {
+		t.Errorf("outcome status %s (error %q), want completed", outcome.Status, outcome.Error)
+	}
+	if outcome.StepsCompleted != outcome.StepsTotal || outcome.StepsTotal == 0 {
+		t.Errorf("steps completed %d of %d", outcome.StepsCompleted, outcome.StepsTotal)
+	}
+}
+
+func TestFeedbackReportsFailedPlan(t *testing.T) {
+	agent, outcomes := feedbackAgent(t)
+	rec := lowRiskRec("rec-1", "migrate_to_spot", "node-1")
+	failing := stepRunnerFunc(func(ctx context.Context, step *ExecutionStep) (map[string]interface{}, error) {
+		return nil, errors.New("agent unavailable")
+	})
+	c := newFeedbackCoordinator(t, failing, rec.AgentID, agent.URL)
+
+	planID := executeRec(t, c, rec)
+	outcome := receiveOutcome(t, outcomes)
+	if outcome.PlanID != planID || outcome.Status != ExecutionStatusRolledBack {
+		t.Errorf("outcome for plan %s with status %s, want %s rolled back", outcome.PlanID, outcome.Status, planID)
+	}
+	if outcome.Error == "" || outcome.StepsCompleted != 0 {
+		t.Errorf("outcome error %q with %d steps completed, want an error and none", outcome.Error, outcome.StepsCompleted)
+	}
+}
+
+func TestFinishedStatuses(t *testing.T) {
+	for _, status := range []ExecutionStatus{ExecutionStatusCompleted, ExecutionStatusFailed, ExecutionStatusRolledBack} {
+		if !status.finished() {
+			t.Errorf("%s not finished", status)
+		}
+	}
+	for _, status := range []ExecutionStatus{
+		ExecutionStatusPending, ExecutionStatusRunning, ExecutionStatusDeferred,
+		ExecutionStatusPaused, ExecutionStatusInterrupted, ExecutionStatusAwaitingApproval,
+	} {
+		if status.finished() {
+			t.Errorf("%s finished", status)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package coordination
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GraphEdgeKind distinguishes dependency edges from conflict edges
+type GraphEdgeKind string
+
+const (
+	GraphEdgeDependency GraphEdgeKind = "dependency"
+	GraphEdgeConflict   GraphEdgeKind = "conflict"
+)
+
+// GraphNode is a recommendation in the dependency graph
+type GraphNode struct {
+	ID               string             `json:"id"`
+	Title            string             `json:"title,omitempty"`
+	Type             RecommendationType `json:"type"`
+	Action           string             `json:"action"`
+	AgentID          string             `json:"agent_id"`
+	RiskLevel        RiskLevel          `json:"risk_level"`
+	EstimatedSavings float64            `json:"estimated_savings"`
+}
+
+// GraphEdge is a relationship between two recommendations. Dependency edges
+// point from the prerequisite to the recommendation that depends on it.
+type GraphEdge struct {
+	From         string        `json:"from"`
+	To           string        `json:"to"`
+	Kind         GraphEdgeKind `json:"kind"`
+	ConflictType ConflictType  `json:"conflict_type,omitempty"`
+	Severity     string        `json:"severity,omitempty"`
+	Description  string        `json:"description,omitempty"`
+}
+
+// DependencyGraph is a node/edge list of a recommendation set
+type DependencyGraph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// GraphRequest is the request body for building a dependency graph
+type GraphRequest struct {
+	Recommendations []*Recommendation `json:"recommendations" binding:"required"`
+}
+
+// BuildGraph builds the dependency and conflict graph for a recommendation set
+func (cd *ConflictDetector) BuildGraph(recommendations []*Recommendation) *DependencyGraph {
+	graph := &DependencyGraph{
+		Nodes: make([]GraphNode, 0, len(recommendations)),
+		Edges: make([]GraphEdge, 0),
+	}
+
+	known := make(map[string]bool, len(recommendations))
+	for _, rec := range recommendations {
+		known[rec.ID] = true
+		graph.Nodes = append(graph.Nodes, GraphNode{
+			ID:               rec.ID,
+			Title:            rec.Title,
+			Type:             rec.Type,
+			Action:           rec.Action,
+			AgentID:          rec.AgentID,
+			RiskLevel:        rec.RiskLevel,
+			EstimatedSavings: rec.EstimatedSavings,
+		})
+	}
+
+	// Dependency edges (only between recommendations in the set)
+	for _, rec := range recommendations {
+		for _, dep := range rec.Dependencies {
+			if !known[dep] {
+				continue
+			}
+			graph.Edges = append(graph.Edges, GraphEdge{
+				From: dep,
+				To:   rec.ID,
+				Kind: GraphEdgeDependency,
+			})
+		}
+	}
+
+	// Conflict edges
+	for _, conflict := range cd.DetectConflicts(recommendations) {
+		if len(conflict.Recommendations) < 2 {
+			continue
+		}
+		graph.Edges = append(graph.Edges, GraphEdge{
+			From:         conflict.Recommendations[0],
+			To:           conflict.Recommendations[1],
+			Kind:         GraphEdgeConflict,
+			ConflictType: conflict.Type,
+			Severity:     conflict.Severity,
+			Description:  conflict.Description,
+		})
+	}
+
+	return graph
+}
+
+// DOT renders the graph in Graphviz DOT format. Conflict edges are drawn
+// dashed and undirected.
+func (g *DependencyGraph) DOT() string {
+	var b strings.Builder
+
+	b.WriteString("digraph coordination {\n")
+	for _, node := range g.Nodes {
+		label := node.Action
+		if node.Title != "" {
+			label = node.Title
+		}
+		fmt.Fprintf(&b, "  %q [label=%q];\n", node.ID, fmt.Sprintf("%s\\n(%s, %s)", label, node.Type, node.RiskLevel))
+	}
+	for _, edge := range g.Edges {
+		if edge.Kind == GraphEdgeConflict {
+			fmt.Fprintf(&b, "  %q -> %q [style=dashed, dir=none, color=red, label=%q];\n",
+				edge.From, edge.To, string(edge.ConflictType))
+			continue
+		}
+		fmt.Fprintf(&b, "  %q -> %q;\n", edge.From, edge.To)
+	}
+	b.WriteString("}\n")
+
+	return b.String()
+}
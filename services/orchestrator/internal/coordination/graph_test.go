@@ -0,0 +1,83 @@
+package coordination
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// graphRecs returns recommendations where rec-2 conflicts with rec-1 over
+// node-1 and rec-3 depends on rec-1
+func graphRecs() []*Recommendation {
+	rec3 := lowRiskRec("rec-3", "right_size", "node-2")
+	rec3.Dependencies = []string{"rec-1", "rec-outside"}
+	return []*Recommendation{
+		lowRiskRec("rec-1", "migrate_to_spot", "node-1"),
+		lowRiskRec("rec-2", "scale_resources", "node-1"),
+		rec3,
+	}
+}
+
+func hasEdge(graph *DependencyGraph, from, to string, kind GraphEdgeKind) bool {
+	for _, edge := range graph.Edges {
+		if edge.Kind != kind {
+			continue
+		}
+		if edge.From == from && edge.To == to {
+			return true
+		}
+		// Conflicts have no direction
+		if kind == GraphEdgeConflict && edge.From == to && edge.To == from {
+			return true
+		}
+	}
+	return false
+}
+
+func TestGraphHasDependencyAndConflictEdges(t *testing.T) {
+	c := newTestCoordinator(t, succeedingRunner)
+	graph := c.BuildGraph(graphRecs())
+
+	if len(graph.Nodes) != 3 {
+		t.Errorf("graph has %d nodes, want 3", len(graph.Nodes))
+	}
+	if !hasEdge(graph, "rec-1", "rec-3", GraphEdgeDependency) {
+		t.Errorf("missing dependency edge rec-1 -> rec-3: %+v", graph.Edges)
+	}
+	if !hasEdge(graph, "rec-1", "rec-2", GraphEdgeConflict) {
+		t.Errorf("missing conflict edge rec-1 -- rec-2: %+v", graph.Edges)
+	}
+	// Dependencies outside the set are left out
+	for _, edge := range graph.Edges {
+		if edge.From == "rec-outside" || edge.To == "rec-outside" {
+			t.Errorf("edge to a recommendation outside the set: %+v", edge)
+		}
+	}
+}
+
+func TestGraphEndpoint(t *testing.T) {
+	router := newTestHandler(newTestCoordinator(t, succeedingRunner))
+	body := GraphRequest{Recommendations: graphRecs()}
+
+	w := doJSON(t, router, http.MethodPost, "/coordination/graph", body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+	var graph DependencyGraph
+	if err := json.Unmarshal(w.Body.Bytes(), &graph); err != nil {
+		t.Fatal(err)
+	}
+	if !hasEdge(&graph, "rec-1", "rec-3", GraphEdgeDependency) || !hasEdge(&graph, "rec-1", "rec-2", GraphEdgeConflict) {
+		t.Errorf("graph edges = %+v", graph.Edges)
+	}
+
+	w = doJSON(t, router, http.MethodPost, "/coordination/graph?format=dot", body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("dot status %d: %s", w.Code, w.Body.String())
+	}
+	dot := w.Body.String()
+	if !strings.HasPrefix(dot, "digraph coordination {") || !strings.Contains(dot, `"rec-1" -> "rec-3";`) || !strings.Contains(dot, "style=dashed") {
+		t.Errorf("dot output:\n%s", dot)
+	}
+}
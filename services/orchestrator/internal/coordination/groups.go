@@ -0,0 +1,26 @@
+package coordination
+
+// GroupSummary totals the kept recommendations of one optimization goal
+type GroupSummary struct {
+	Count             int      `json:"count"`
+	EstimatedSavings  float64  `json:"estimated_savings"`
+	RecommendationIDs []string `json:"recommendation_ids"`
+}
+
+// groupByType summarizes recommendations by type. One without a type is
+// grouped under its agent's type, which names the same goals.
+func groupByType(recs []*Recommendation) map[RecommendationType]GroupSummary {
+	groups := make(map[RecommendationType]GroupSummary)
+	for _, rec := range recs {
+		goal := rec.Type
+		if goal == "" {
+			goal = RecommendationType(rec.AgentType)
+		}
+		group := groups[goal]
+		group.Count++
+		group.EstimatedSavings += rec.EstimatedSavings
+		group.RecommendationIDs = append(group.RecommendationIDs, rec.ID)
+		groups[goal] = group
+	}
+	return groups
+}
@@ -0,0 +1,42 @@
+package coordination
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCoordinationGroupsByGoal(t *testing.T) {
+	c := newTestCoordinator(t, succeedingRunner)
+
+	costA := lowRiskRec("cost-a", "right_size", "node-1")
+	costA.EstimatedSavings = 120.5
+	costB := lowRiskRec("cost-b", "migrate_to_spot", "node-2")
+	costB.EstimatedSavings = 80
+	perf := lowRiskRec("perf-a", "tune_batching", "model-1")
+	perf.AgentType, perf.Type, perf.EstimatedSavings = "performance", RecommendationTypePerformance, 0
+	// Untyped recommendations are grouped by their agent's type
+	untyped := lowRiskRec("res-a", "resize_pool", "pool-1")
+	untyped.AgentType, untyped.Type, untyped.EstimatedSavings = "resource", "", 40
+
+	resp, err := c.Coordinate(&CoordinationRequest{
+		CustomerID:      "cust-1",
+		Recommendations: []*Recommendation{costA, perf, costB, untyped},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[RecommendationType]GroupSummary{
+		RecommendationTypeCost:        {Count: 2, EstimatedSavings: 200.5, RecommendationIDs: []string{"cost-a", "cost-b"}},
+		RecommendationTypePerformance: {Count: 1, EstimatedSavings: 0, RecommendationIDs: []string{"perf-a"}},
+		RecommendationTypeResource:    {Count: 1, EstimatedSavings: 40, RecommendationIDs: []string{"res-a"}},
+	}
+	if len(resp.Groups) != len(want) {
+		t.Fatalf("groups = %+v, want %d goals", resp.Groups, len(want))
+	}
+	for goal, group := range want {
+		if fmt.Sprint(resp.Groups[goal]) != fmt.Sprint(group) {
+			t.Errorf("%s group = %+v, want %+v", goal, resp.Groups[goal], group)
+		}
+	}
+}
@@ -1,20 +1,25 @@
 package coordination
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"optiinfra/services/orchestrator/internal/handlers"
 )
 
 // Handler provides HTTP handlers for coordination
 type Handler struct {
-	coordinator *Coordinator
+	coordinator   *Coordinator
+	errorStatuses handlers.ErrorStatuses
 }
 
 // NewHandler creates a new coordination handler
 func NewHandler(coordinator *Coordinator) *Handler {
 	return &Handler{
-		coordinator: coordinator,
+		coordinator:   coordinator,
+		errorStatuses: DefaultErrorStatuses(),
 	}
 }
 
@@ -23,31 +28,148 @@ func (h *Handler) RegisterRoutes(r *gin.Engine) {
 	coord := r.Group("/coordination")
 	{
 		coord.POST("/coordinate", h.Coordinate)
+		coord.POST("/graph", h.Graph)
+		coord.POST("/resolve/preview", h.PreviewResolution)
+		coord.GET("/history", h.History)
+		coord.GET("/history/:id", h.History)
+		coord.GET("/:id/trace", h.Trace)
+		coord.POST("/:id/cancel", h.CancelCoordination)
 		coord.GET("/approvals", h.ListApprovals)
+		coord.GET("/approvals/digest", h.ApprovalDigest)
 		coord.POST("/approvals/:id/approve", h.ApproveRecommendation)
 		coord.POST("/approvals/:id/reject", h.RejectRecommendation)
+		coord.GET("/approvals/:id/explain", h.ExplainApproval)
 		coord.GET("/plans/:id", h.GetExecutionPlan)
+		coord.GET("/plans/:id/rollback-report", h.RollbackReport)
 		coord.POST("/plans/:id/execute", h.ExecutePlan)
+		coord.POST("/plans/:id/pause", h.PausePlan)
+		coord.POST("/plans/:id/resume", h.ResumePlan)
 	}
 }
 
 // Coordinate handles coordination requests
 func (h *Handler) Coordinate(c *gin.Context) {
 	var req CoordinationRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := handlers.BindJSON(c, &req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	response, err := h.coordinator.Coordinate(&req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		handlers.APIError(c, h.errorStatuses, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
+// Graph returns the dependency/conflict graph for a recommendation set as a
+// node/edge list, or as Graphviz DOT when format=dot
+func (h *Handler) Graph(c *gin.Context) {
+	var req GraphRequest
+	if err := handlers.BindJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateRiskLevels(req.Recommendations); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	graph := h.coordinator.BuildGraph(req.Recommendations)
+
+	if c.Query("format") == "dot" {
+		c.String(http.StatusOK, graph.DOT())
+		return
+	}
+
+	c.JSON(http.StatusOK, graph)
+}
+
+// PreviewResolution shows which recommendations a strategy would keep,
+// without creating approvals or plans
+func (h *Handler) PreviewResolution(c *gin.Context) {
+	var req GraphRequest
+	if err := handlers.BindJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateRiskLevels(req.Recommendations); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	strategy, err := ParseResolutionStrategy(c.DefaultQuery("strategy", string(StrategyPriority)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.coordinator.PreviewResolution(req.Recommendations, strategy))
+}
+
+// History returns recorded recommendation outcomes, optionally for one
+// coordination run and customer, as JSON, CSV or NDJSON (?format= or Accept)
+func (h *Handler) History(c *gin.Context) {
+	outcomes := h.coordinator.History(c.Query("customer_id"), c.Param("id"))
+
+	switch negotiateExportFormat(c.Query("format"), c.GetHeader("Accept")) {
+	case ExportFormatCSV:
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", `attachment; filename="coordination_history.csv"`)
+		c.Status(http.StatusOK)
+		if err := writeOutcomesCSV(c.Writer, outcomes); err != nil {
+			c.Error(err)
+		}
+	case ExportFormatNDJSON:
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Status(http.StatusOK)
+		if err := writeOutcomesNDJSON(c.Writer, outcomes); err != nil {
+			c.Error(err)
+		}
+	default:
+		c.JSON(http.StatusOK, gin.H{
+			"outcomes": outcomes,
+			"count":    len(outcomes),
+		})
+	}
+}
+
+// Trace returns the recommendations, approvals, plans and step tasks
+// linked to a coordination run
+func (h *Handler) Trace(c *gin.Context) {
+	trace, err := h.coordinator.Trace(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, trace)
+}
+
+// CancelCoordination cancels the unfinished tasks of a coordination run and
+// rolls back its running plans, or pauses them with ?plans=pause
+func (h *Handler) CancelCoordination(c *gin.Context) {
+	action, err := ParseCancelPlanAction(c.DefaultQuery("plans", string(CancelPlanRollback)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cancellation, err := h.coordinator.CancelCoordination(c.Param("id"), action)
+	if err != nil {
+		if errors.Is(err, ErrCoordinationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, cancellation)
+}
+
 // ListApprovals lists pending approvals for a customer
 func (h *Handler) ListApprovals(c *gin.Context) {
 	customerID := c.Query("customer_id")
@@ -64,25 +186,62 @@ func (h *Handler) ListApprovals(c *gin.Context) {
 	})
 }
 
+// ApprovalDigest summarizes a customer's pending approvals by risk level
+// and expiry urgency
+func (h *Handler) ApprovalDigest(c *gin.Context) {
+	customerID := c.Query("customer_id")
+	if customerID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "customer_id required"})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.coordinator.ApprovalDigest(customerID))
+}
+
+// ExplainApproval reports the policy rule behind an approval decision
+func (h *Handler) ExplainApproval(c *gin.Context) {
+	explanation, err := h.coordinator.ExplainApproval(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, explanation)
+}
+
 // ApproveRecommendation approves a recommendation
 func (h *Handler) ApproveRecommendation(c *gin.Context) {
 	approvalID := c.Param("id")
 	
 	var req struct {
-		UserID string `json:"user_id" binding:"required"`
+		UserID     string                 `json:"user_id" binding:"required"`
+		Parameters map[string]interface{} `json:"parameters"` // Optional overrides
 	}
 	
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := handlers.BindJSON(c, &req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := h.coordinator.ApproveRecommendation(approvalID, req.UserID); err != nil {
+	plan, err := h.coordinator.ApproveWithChanges(approvalID, req.UserID, req.Parameters)
+	if errors.Is(err, ErrRequiresAgent) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Recommendation approved"})
+	resp := gin.H{"message": "Recommendation approved"}
+	if len(req.Parameters) > 0 {
+		resp["message"] = "Recommendation approved with modifications"
+	}
+	if plan != nil {
+		resp["plan_id"] = plan.ID
+		resp["projected_impact"] = plan.ProjectedImpact
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
 // RejectRecommendation rejects a recommendation
@@ -94,7 +253,7 @@ func (h *Handler) RejectRecommendation(c *gin.Context) {
 		Reason string `json:"reason" binding:"required"`
 	}
 	
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := handlers.BindJSON(c, &req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -120,10 +279,58 @@ func (h *Handler) GetExecutionPlan(c *gin.Context) {
 	c.JSON(http.StatusOK, plan)
 }
 
+// RollbackReport shows what a plan's rollback did to each step
+func (h *Handler) RollbackReport(c *gin.Context) {
+	report, err := h.coordinator.RollbackReport(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Plan not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
 // ExecutePlan executes an execution plan
 func (h *Handler) ExecutePlan(c *gin.Context) {
 	planID := c.Param("id")
 
+	if _, err := h.coordinator.GetExecutionPlan(planID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Plan not found"})
+		return
+	}
+
+	// Report the kill switch and maintenance window gates up front since
+	// execution is async
+	if h.coordinator.ExecutionPaused() {
+		if err := h.coordinator.ExecutePlan(planID); err != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "plan_id": planID})
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{
+			"message": "Execution deferred until execution resumes",
+			"plan_id": planID,
+			"status":  ExecutionStatusDeferred,
+		})
+		return
+	}
+	if allowed, next := h.coordinator.CheckMaintenanceWindow(); !allowed {
+		if err := h.coordinator.ExecutePlan(planID); err != nil {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":             err.Error(),
+				"plan_id":           planID,
+				"next_window_opens": next,
+			})
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{
+			"message":        "Execution deferred to next maintenance window",
+			"plan_id":        planID,
+			"status":         ExecutionStatusDeferred,
+			"deferred_until": next,
+		})
+		return
+	}
+
 	// Execute asynchronously
 	go func() {
 		if err := h.coordinator.ExecutePlan(planID); err != nil {
@@ -137,3 +344,43 @@ func (h *Handler) ExecutePlan(c *gin.Context) {
 		"plan_id": planID,
 	})
 }
+
+// PausePlan pauses a plan before its next step
+func (h *Handler) PausePlan(c *gin.Context) {
+	planID := c.Param("id")
+
+	if _, err := h.coordinator.GetExecutionPlan(planID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Plan not found"})
+		return
+	}
+
+	if err := h.coordinator.PausePlan(planID); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Plan will pause before its next step",
+		"plan_id": planID,
+	})
+}
+
+// ResumePlan resumes a paused plan
+func (h *Handler) ResumePlan(c *gin.Context) {
+	planID := c.Param("id")
+
+	if _, err := h.coordinator.GetExecutionPlan(planID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Plan not found"})
+		return
+	}
+
+	if err := h.coordinator.ResumePlan(planID); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Plan resumed",
+		"plan_id": planID,
+	})
+}
@@ -0,0 +1,125 @@
+package coordination
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// stepRunnerFunc adapts a function to StepRunner
+type stepRunnerFunc func(ctx context.Context, step *ExecutionStep) (map[string]interface{}, error)
+
+func (f stepRunnerFunc) RunStep(ctx context.Context, step *ExecutionStep) (map[string]interface{}, error) {
+	return f(ctx, step)
+}
+
+// succeedingRunner completes every step immediately
+var succeedingRunner = stepRunnerFunc(func(ctx context.Context, step *ExecutionStep) (map[string]interface{}, error) {
+	return map[string]interface{}{"action": step.Action}, nil
+})
+
+// newTestCoordinator returns a coordinator whose steps run on runner
+func newTestCoordinator(t *testing.T, runner StepRunner) *Coordinator {
+	t.Helper()
+	c := NewCoordinator()
+	c.SetStepRunner(runner)
+	return c
+}
+
+// newTestHandler serves the coordination routes for c
+func newTestHandler(c *Coordinator) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	NewHandler(c).RegisterRoutes(router)
+	return router
+}
+
+// doJSON sends body as JSON to the router and returns the recorded response
+func doJSON(t *testing.T, router http.Handler, method, path string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatalf("encode body: %v", err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// lowRiskRec returns a low-risk recommendation with a single-step action
+func lowRiskRec(id, action string, resources ...string) *Recommendation {
+	return &Recommendation{
+		ID:                id,
+		AgentID:           "agent-" + id,
+		AgentType:         "cost",
+		Type:              RecommendationTypeCost,
+		Action:            action,
+		RiskLevel:         RiskLevelLow,
+		EstimatedSavings:  100,
+		AffectedResources: resources,
+		Confidence:        0.9,
+		CreatedAt:         time.Now(),
+	}
+}
+
+// waitForPlan polls a plan until cond holds, failing the test after a few
+// seconds
+func waitForPlan(t *testing.T, c *Coordinator, planID string, cond func(*ExecutionPlan) bool) *ExecutionPlan {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		plan, err := c.GetExecutionPlan(planID)
+		if err == nil && cond(plan) {
+			return plan
+		}
+		if time.Now().After(deadline) {
+			if err != nil {
+				t.Fatalf("plan %s: %v", planID, err)
+			}
+			t.Fatalf("plan %s did not reach the expected state, last status %s", planID, plan.Status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// waitForPlanStatus polls a plan until it reaches status
+func waitForPlanStatus(t *testing.T, c *Coordinator, planID string, status ExecutionStatus) *ExecutionPlan {
+	t.Helper()
+	return waitForPlan(t, c, planID, func(p *ExecutionPlan) bool { return p.Status == status })
+}
+
+// recordingMetrics is a MetricsRecorder keeping the last values reported
+type recordingMetrics struct {
+	mu        sync.Mutex
+	conflicts int
+	active    []float64
+}
+
+func (m *recordingMetrics) RecordCoordinationConflict() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.conflicts++
+}
+
+func (m *recordingMetrics) UpdateActiveOptimizations(count float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.active = append(m.active, count)
+}
+
+func (m *recordingMetrics) snapshot() (int, []float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.conflicts, append([]float64(nil), m.active...)
+}
@@ -0,0 +1,103 @@
+package coordination
+
+import (
+	"time"
+)
+
+// maxHistoryOutcomes bounds the in-memory coordination history
+const maxHistoryOutcomes = 10000
+
+// Outcomes of a recommendation in a coordination run
+const (
+	OutcomeKept      = "kept"
+	OutcomeDiscarded = "discarded"
+	OutcomeExpired   = "expired"
+	OutcomeMerged    = "merged"
+)
+
+// RecommendationOutcome is one recommendation's result in a coordination run
+type RecommendationOutcome struct {
+	CoordinationID   string    `json:"coordination_id"`
+	CustomerID       string    `json:"customer_id"`
+	RecommendationID string    `json:"recommendation_id"`
+	AgentType        string    `json:"agent_type"`
+	Action           string    `json:"action"`
+	Outcome          string    `json:"outcome"`
+	Status           string    `json:"status,omitempty"`
+	EstimatedSavings float64   `json:"estimated_savings"`
+	RiskLevel        RiskLevel `json:"risk_level"`
+	CoordinatedAt    time.Time `json:"coordinated_at"`
+}
+
+// recordHistory appends the outcome of every submitted recommendation,
+// dropping the oldest entries once the history is full
+func (c *Coordinator) recordHistory(req *CoordinationRequest, resp *CoordinationResponse) {
+	kept := make(map[string]bool, len(resp.Recommendations))
+	for _, rec := range resp.Recommendations {
+		kept[rec.ID] = true
+	}
+	expired := make(map[string]bool, len(resp.ExpiredRecommendations))
+	for _, id := range resp.ExpiredRecommendations {
+		expired[id] = true
+	}
+	merged := make(map[string]bool)
+	for _, ids := range resp.MergedRecommendations {
+		for _, id := range ids {
+			merged[id] = true
+		}
+	}
+
+	outcomes := make([]RecommendationOutcome, 0, len(req.Recommendations))
+	for _, rec := range req.Recommendations {
+		outcome := OutcomeDiscarded
+		switch {
+		case expired[rec.ID]:
+			outcome = OutcomeExpired
+		case merged[rec.ID]:
+			outcome = OutcomeMerged
+		case kept[rec.ID]:
+			outcome = OutcomeKept
+		}
+
+		outcomes = append(outcomes, RecommendationOutcome{
+			CoordinationID:   resp.ID,
+			CustomerID:       req.CustomerID,
+			RecommendationID: rec.ID,
+			AgentType:        rec.AgentType,
+			Action:           rec.Action,
+			Outcome:          outcome,
+			Status:           rec.Status,
+			EstimatedSavings: rec.EstimatedSavings,
+			RiskLevel:        rec.RiskLevel,
+			CoordinatedAt:    resp.CreatedAt,
+		})
+	}
+
+	c.historyMu.Lock()
+	defer c.historyMu.Unlock()
+
+	c.history = append(c.history, outcomes...)
+	if overflow := len(c.history) - maxHistoryOutcomes; overflow > 0 {
+		c.history = append([]RecommendationOutcome(nil), c.history[overflow:]...)
+	}
+}
+
+// History returns recorded recommendation outcomes, oldest first, optionally
+// filtered by customer and coordination run
+func (c *Coordinator) History(customerID, coordinationID string) []RecommendationOutcome {
+	c.historyMu.RLock()
+	defer c.historyMu.RUnlock()
+
+	outcomes := make([]RecommendationOutcome, 0)
+	for _, o := range c.history {
+		if customerID != "" && o.CustomerID != customerID {
+			continue
+		}
+		if coordinationID != "" && o.CoordinationID != coordinationID {
+			continue
+		}
+		outcomes = append(outcomes, o)
+	}
+
+	return outcomes
+}
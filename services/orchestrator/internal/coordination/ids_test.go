@@ -0,0 +1,73 @@
+package coordination
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"optiinfra/services/orchestrator/internal/idgen"
+)
+
+// coordinationIDs runs one conflicting, auto-approved coordination on a
+// fresh coordinator with sequential IDs and returns every ID it handed out,
+// each labelled with what it identifies
+func coordinationIDs(t *testing.T) []string {
+	t.Helper()
+	c := newTestCoordinator(t, succeedingRunner)
+	c.SetIDGenerator(idgen.NewSequentialGenerator("id"))
+
+	resp, err := c.Coordinate(&CoordinationRequest{
+		CustomerID: "cust-1",
+		Recommendations: []*Recommendation{
+			lowRiskRec("rec-1", "right_size", "node-1"),
+			lowRiskRec("rec-2", "scale_down", "node-1"),
+			lowRiskRec("rec-3", "migrate_to_spot", "node-2"),
+		},
+		AutoApprove: true,
+		ExecuteNow:  true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := []string{"coordination " + resp.ID}
+	for _, conflict := range resp.Conflicts {
+		ids = append(ids, fmt.Sprintf("conflict %s", conflict.ID))
+	}
+	for _, rec := range resp.Recommendations {
+		ids = append(ids, fmt.Sprintf("approval of %s %s", rec.ID, rec.ApprovalID))
+	}
+	for _, plan := range resp.ExecutionPlans {
+		ids = append(ids, fmt.Sprintf("plan for %s %s", plan.RecommendationID, plan.ID))
+		for _, step := range plan.Steps {
+			ids = append(ids, fmt.Sprintf("step %s %s", step.Action, step.ID))
+		}
+	}
+	return ids
+}
+
+func TestPredictableCoordinationIDs(t *testing.T) {
+	// IDs are handed out in order with none skipped: checking the
+	// recommendations' steps before planning takes no IDs
+	want := []string{
+		"coordination id-1",
+		"conflict id-2",
+		"approval of rec-1 id-3",
+		"approval of rec-3 id-4",
+		"plan for rec-1 id-5",
+		"step right_size id-6",
+		"plan for rec-3 id-7",
+		"step take_snapshot id-8",
+		"step migrate_workload id-9",
+		"step validate_quality id-10",
+	}
+	first := coordinationIDs(t)
+	if strings.Join(first, "\n") != strings.Join(want, "\n") {
+		t.Errorf("ids:\n%s\nwant:\n%s", strings.Join(first, "\n"), strings.Join(want, "\n"))
+	}
+
+	// The same input gives the same IDs
+	if second := coordinationIDs(t); fmt.Sprint(second) != fmt.Sprint(first) {
+		t.Errorf("second run ids %v, want %v", second, first)
+	}
+}
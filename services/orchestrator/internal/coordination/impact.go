@@ -0,0 +1,30 @@
+package coordination
+
+// costDeltaParam is the step parameter declaring the step's change in cost;
+// positive values add cost, negative ones save
+const costDeltaParam = "cost_delta"
+
+// ProjectedImpact is a plan's expected cost effect, worked out when the
+// plan is created
+type ProjectedImpact struct {
+	EstimatedSavings float64 `json:"estimated_savings"` // From the recommendation
+	StepCostDelta    float64 `json:"step_cost_delta"`   // Sum of the steps' declared cost deltas
+	NetSavings       float64 `json:"net_savings"`       // EstimatedSavings less StepCostDelta
+	StepsWithCost    int     `json:"steps_with_cost"`
+}
+
+// projectImpact sums the recommendation's savings and the cost deltas its
+// plan's steps declare
+func projectImpact(rec *Recommendation, steps []ExecutionStep) *ProjectedImpact {
+	impact := &ProjectedImpact{EstimatedSavings: rec.EstimatedSavings}
+	for _, step := range steps {
+		delta, ok := toFloat(step.Parameters[costDeltaParam])
+		if !ok {
+			continue
+		}
+		impact.StepCostDelta += delta
+		impact.StepsWithCost++
+	}
+	impact.NetSavings = impact.EstimatedSavings - impact.StepCostDelta
+	return impact
+}
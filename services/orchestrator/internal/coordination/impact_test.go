@@ -0,0 +1,64 @@
+package coordination
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestProjectImpactAggregatesSteps(t *testing.T) {
+	rec := lowRiskRec("rec-1", "right_size", "node-1")
+	steps := []ExecutionStep{
+		{Parameters: map[string]interface{}{costDeltaParam: 10.0}},
+		{Parameters: map[string]interface{}{costDeltaParam: -4.0, "replicas": 2}},
+		{Parameters: map[string]interface{}{costDeltaParam: 2}},
+		{Parameters: map[string]interface{}{costDeltaParam: "cheap"}}, // Not a number
+		{},
+	}
+
+	impact := projectImpact(rec, steps)
+	want := ProjectedImpact{EstimatedSavings: 100, StepCostDelta: 8, NetSavings: 92, StepsWithCost: 3}
+	if *impact != want {
+		t.Errorf("impact = %+v, want %+v", *impact, want)
+	}
+
+	// Without declared costs the recommendation's savings stand alone
+	if impact := projectImpact(rec, steps[4:]); impact.NetSavings != 100 || impact.StepsWithCost != 0 {
+		t.Errorf("impact without step costs = %+v", *impact)
+	}
+}
+
+func TestPlanReportsProjectedImpact(t *testing.T) {
+	c := newTestCoordinator(t, succeedingRunner)
+	router := newTestHandler(c)
+
+	rec := lowRiskRec("rec-1", "scale_down", "node-1")
+	rec.EstimatedSavings = 250
+	rec.Parameters = map[string]interface{}{costDeltaParam: 40}
+	w := doJSON(t, router, http.MethodPost, "/coordination/coordinate", CoordinationRequest{
+		CustomerID:      "cust-1",
+		Recommendations: []*Recommendation{rec},
+		AutoApprove:     true,
+		ExecuteNow:      true,
+	})
+	var resp CoordinationResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK || len(resp.ExecutionPlans) != 1 {
+		t.Fatalf("coordinate: status %d, body %s", w.Code, w.Body.String())
+	}
+
+	// The delta is declared on scale_resources only, not the quality checks
+	want := ProjectedImpact{EstimatedSavings: 250, StepCostDelta: 40, NetSavings: 210, StepsWithCost: 1}
+	created := resp.ExecutionPlans[0]
+	if created.ProjectedImpact == nil || *created.ProjectedImpact != want {
+		t.Fatalf("impact at creation = %+v, want %+v", created.ProjectedImpact, want)
+	}
+
+	var plan ExecutionPlan
+	w = doJSON(t, router, http.MethodGet, "/coordination/plans/"+created.ID, nil)
+	if err := json.Unmarshal(w.Body.Bytes(), &plan); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("get plan: status %d, body %s", w.Code, w.Body.String())
+	}
+	if plan.ProjectedImpact == nil || *plan.ProjectedImpact != want {
+		t.Errorf("impact from GetPlan = %+v, want %+v", plan.ProjectedImpact, want)
+	}
+}
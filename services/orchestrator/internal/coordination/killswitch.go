@@ -0,0 +1,89 @@
+package coordination
+
+import (
+	"log"
+	"sync"
+)
+
+// executionHold is the global kill switch for plan execution. While paused,
+// plans started are deferred and run once execution resumes; plans already
+// running continue.
+type executionHold struct {
+	mu       sync.Mutex
+	paused   bool
+	deferred []string
+}
+
+// PauseExecution defers plans started from now on until ResumeExecution. It
+// reports whether execution was running before.
+func (eo *ExecutionOrchestrator) PauseExecution() bool {
+	eo.hold.mu.Lock()
+	defer eo.hold.mu.Unlock()
+
+	if eo.hold.paused {
+		return false
+	}
+	eo.hold.paused = true
+
+	log.Println("Plan execution paused")
+	return true
+}
+
+// ResumeExecution starts the plans deferred while paused. It reports whether
+// execution was paused before.
+func (eo *ExecutionOrchestrator) ResumeExecution() bool {
+	eo.hold.mu.Lock()
+	if !eo.hold.paused {
+		eo.hold.mu.Unlock()
+		return false
+	}
+	eo.hold.paused = false
+	deferred := eo.hold.deferred
+	eo.hold.deferred = nil
+	eo.hold.mu.Unlock()
+
+	log.Printf("Plan execution resumed, starting %d deferred plans", len(deferred))
+	for _, planID := range deferred {
+		eo.mu.Lock()
+		if plan, ok := eo.plans[planID]; ok {
+			delete(plan.Metadata, "deferred_reason")
+		}
+		eo.mu.Unlock()
+		go func(planID string) {
+			if err := eo.ExecutePlan(planID); err != nil {
+				log.Printf("Deferred execution failed for plan %s: %v", planID, err)
+			}
+		}(planID)
+	}
+	return true
+}
+
+// ExecutionPaused reports whether plan execution is paused
+func (eo *ExecutionOrchestrator) ExecutionPaused() bool {
+	eo.hold.mu.Lock()
+	defer eo.hold.mu.Unlock()
+
+	return eo.hold.paused
+}
+
+// deferWhilePaused defers a plan until execution resumes and reports
+// whether it did so. It must be called with eo.mu held.
+func (eo *ExecutionOrchestrator) deferWhilePaused(plan *ExecutionPlan) bool {
+	eo.hold.mu.Lock()
+	defer eo.hold.mu.Unlock()
+
+	if !eo.hold.paused {
+		return false
+	}
+	if plan.Metadata == nil {
+		plan.Metadata = make(map[string]interface{})
+	}
+	plan.Metadata["deferred_reason"] = "execution paused"
+	if plan.Status != ExecutionStatusDeferred {
+		plan.Status = ExecutionStatusDeferred
+		eo.hold.deferred = append(eo.hold.deferred, plan.ID)
+	}
+
+	log.Printf("Plan %s deferred until execution resumes", plan.ID)
+	return true
+}
@@ -0,0 +1,63 @@
+package coordination
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// ParseActionLimits parses per-action concurrency limits in the form
+// "migrate_workload=2,scale_resources=4"
+func ParseActionLimits(spec string) (map[string]int, error) {
+	limits := make(map[string]int)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		action, value, ok := strings.Cut(entry, "=")
+		action = strings.TrimSpace(action)
+		if !ok || action == "" {
+			return nil, fmt.Errorf("invalid action limit entry %q", entry)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid limit for action %s: %q", action, value)
+		}
+		limits[action] = n
+	}
+	return limits, nil
+}
+
+// SetActionLimits caps how many steps of each action run at once across all
+// plans. Actions without a limit run freely. It must be called before plans
+// start executing.
+func (eo *ExecutionOrchestrator) SetActionLimits(limits map[string]int) {
+	eo.actionSlots = make(map[string]chan struct{}, len(limits))
+	for action, n := range limits {
+		eo.actionSlots[action] = make(chan struct{}, n)
+	}
+}
+
+// acquireActionSlot waits for a free slot for the step's action. It returns
+// a release func, or false if shutdown began while waiting.
+func (eo *ExecutionOrchestrator) acquireActionSlot(plan *ExecutionPlan, step *ExecutionStep) (func(), bool) {
+	slots, limited := eo.actionSlots[step.Action]
+	if !limited {
+		return func() {}, true
+	}
+
+	select {
+	case slots <- struct{}{}:
+	default:
+		log.Printf("Plan %s waiting for a %s slot (limit %d)", plan.ID, step.Action, cap(slots))
+		select {
+		case slots <- struct{}{}:
+		case <-eo.drainCh:
+			return nil, false
+		}
+	}
+	return func() { <-slots }, true
+}
@@ -0,0 +1,114 @@
+package coordination
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// concurrencyRunner holds steps of one action until released, tracking how
+// many run at once; other actions complete immediately
+type concurrencyRunner struct {
+	action  string
+	release chan struct{}
+
+	mu       sync.Mutex
+	running  int
+	peak     int
+	finished map[string]int
+}
+
+func newConcurrencyRunner(action string) *concurrencyRunner {
+	return &concurrencyRunner{action: action, release: make(chan struct{}), finished: make(map[string]int)}
+}
+
+func (r *concurrencyRunner) RunStep(ctx context.Context, step *ExecutionStep) (map[string]interface{}, error) {
+	if step.Action == r.action {
+		r.mu.Lock()
+		r.running++
+		if r.running > r.peak {
+			r.peak = r.running
+		}
+		r.mu.Unlock()
+		select {
+		case <-r.release:
+		case <-ctx.Done():
+		}
+		r.mu.Lock()
+		r.running--
+		r.mu.Unlock()
+	}
+	r.mu.Lock()
+	r.finished[step.Action]++
+	r.mu.Unlock()
+	return nil, ctx.Err()
+}
+
+func (r *concurrencyRunner) state() (running, peak int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.running, r.peak
+}
+
+func TestMigrationLimitHoldsAcrossMixedPlans(t *testing.T) {
+	steps := newConcurrencyRunner("migrate_workload")
+	c := newTestCoordinator(t, steps)
+	c.SetActionLimits(map[string]int{"migrate_workload": 2})
+
+	var migrations, scaleDowns []string
+	for i := 0; i < 5; i++ {
+		id := fmt.Sprintf("spot-%d", i)
+		migrations = append(migrations, executeRec(t, c, lowRiskRec(id, "migrate_to_spot", "node-"+id)))
+	}
+	for i := 0; i < 3; i++ {
+		id := fmt.Sprintf("scale-%d", i)
+		scaleDowns = append(scaleDowns, executeRec(t, c, lowRiskRec(id, "scale_down", "node-"+id)))
+	}
+
+	// Unlimited actions proceed while migrations wait for a slot
+	for _, planID := range scaleDowns {
+		waitForPlanStatus(t, c, planID, ExecutionStatusCompleted)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for running, _ := steps.state(); running < 2; running, _ = steps.state() {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d migrations running, want the limit of 2", running)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if running, peak := steps.state(); running != 2 || peak != 2 {
+		t.Fatalf("%d migrations running (peak %d) with 5 plans waiting, want 2", running, peak)
+	}
+
+	close(steps.release)
+	for _, planID := range migrations {
+		waitForPlanStatus(t, c, planID, ExecutionStatusCompleted)
+	}
+	if _, peak := steps.state(); peak > 2 {
+		t.Errorf("peak of %d concurrent migrations, want at most 2", peak)
+	}
+	steps.mu.Lock()
+	defer steps.mu.Unlock()
+	if n := steps.finished["migrate_workload"]; n != len(migrations) {
+		t.Errorf("%d migrations ran, want %d", n, len(migrations))
+	}
+}
+
+func TestParseActionLimits(t *testing.T) {
+	limits, err := ParseActionLimits(" migrate_workload=2, scale_resources = 4 ,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(limits) != 2 || limits["migrate_workload"] != 2 || limits["scale_resources"] != 4 {
+		t.Errorf("limits = %v", limits)
+	}
+
+	for _, spec := range []string{"migrate_workload", "=2", "migrate_workload=0", "migrate_workload=many"} {
+		if _, err := ParseActionLimits(spec); err == nil {
+			t.Errorf("%q accepted", spec)
+		}
+	}
+}
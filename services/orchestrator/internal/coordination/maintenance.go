@@ -0,0 +1,185 @@
+package coordination
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaintenanceWindow is a recurring daily time range in which plans may execute.
+// Windows whose end is before their start wrap past midnight.
+type MaintenanceWindow struct {
+	Weekdays    []time.Weekday `json:"weekdays,omitempty"` // Empty means every day
+	StartMinute int            `json:"start_minute"`       // Minutes after midnight
+	EndMinute   int            `json:"end_minute"`         // Minutes after midnight (exclusive)
+}
+
+// MaintenanceSchedule is a set of windows evaluated in a fixed location
+type MaintenanceSchedule struct {
+	Windows  []MaintenanceWindow `json:"windows"`
+	Location *time.Location      `json:"-"`
+}
+
+// Contains reports whether t falls inside any window
+func (ms *MaintenanceSchedule) Contains(t time.Time) bool {
+	t = t.In(ms.location())
+	minute := t.Hour()*60 + t.Minute()
+
+	for _, w := range ms.Windows {
+		if w.StartMinute <= w.EndMinute {
+			if minute >= w.StartMinute && minute < w.EndMinute && w.appliesOn(t.Weekday()) {
+				return true
+			}
+			continue
+		}
+
+		// Window wraps midnight: the late part belongs to today's window,
+		// the early part to yesterday's
+		if minute >= w.StartMinute && w.appliesOn(t.Weekday()) {
+			return true
+		}
+		if minute < w.EndMinute && w.appliesOn(t.AddDate(0, 0, -1).Weekday()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NextOpening returns the earliest window start after t, or the zero time if
+// the schedule has no windows
+func (ms *MaintenanceSchedule) NextOpening(t time.Time) time.Time {
+	t = t.In(ms.location())
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+
+	var next time.Time
+	for day := 0; day <= 7; day++ {
+		date := midnight.AddDate(0, 0, day)
+		for _, w := range ms.Windows {
+			if !w.appliesOn(date.Weekday()) {
+				continue
+			}
+			start := date.Add(time.Duration(w.StartMinute) * time.Minute)
+			if start.After(t) && (next.IsZero() || start.Before(next)) {
+				next = start
+			}
+		}
+		if !next.IsZero() {
+			return next
+		}
+	}
+
+	return next
+}
+
+func (ms *MaintenanceSchedule) location() *time.Location {
+	if ms.Location == nil {
+		return time.UTC
+	}
+	return ms.Location
+}
+
+func (w MaintenanceWindow) appliesOn(day time.Weekday) bool {
+	if len(w.Weekdays) == 0 {
+		return true
+	}
+	for _, d := range w.Weekdays {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseMaintenanceSchedule parses a schedule spec such as
+// "sat,sun 00:00-23:59; * 02:00-04:00". Each window is a comma separated list
+// of weekdays (or "*" for every day) followed by an HH:MM-HH:MM range.
+func ParseMaintenanceSchedule(spec string, loc *time.Location) (*MaintenanceSchedule, error) {
+	schedule := &MaintenanceSchedule{Location: loc}
+
+	for _, part := range strings.Split(spec, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		fields := strings.Fields(part)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid maintenance window %q: expected \"<days> <HH:MM-HH:MM>\"", part)
+		}
+
+		window := MaintenanceWindow{}
+		if fields[0] != "*" {
+			for _, name := range strings.Split(fields[0], ",") {
+				day, err := parseWeekday(name)
+				if err != nil {
+					return nil, err
+				}
+				window.Weekdays = append(window.Weekdays, day)
+			}
+		}
+
+		bounds := strings.Split(fields[1], "-")
+		if len(bounds) != 2 {
+			return nil, fmt.Errorf("invalid maintenance window range %q", fields[1])
+		}
+		start, err := parseClockMinute(bounds[0])
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseClockMinute(bounds[1])
+		if err != nil {
+			return nil, err
+		}
+		if start == end {
+			return nil, fmt.Errorf("maintenance window %q is empty", fields[1])
+		}
+		window.StartMinute = start
+		window.EndMinute = end
+
+		schedule.Windows = append(schedule.Windows, window)
+	}
+
+	if len(schedule.Windows) == 0 {
+		return nil, fmt.Errorf("maintenance schedule has no windows")
+	}
+
+	return schedule, nil
+}
+
+func parseWeekday(name string) (time.Weekday, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "sun", "sunday":
+		return time.Sunday, nil
+	case "mon", "monday":
+		return time.Monday, nil
+	case "tue", "tuesday":
+		return time.Tuesday, nil
+	case "wed", "wednesday":
+		return time.Wednesday, nil
+	case "thu", "thursday":
+		return time.Thursday, nil
+	case "fri", "friday":
+		return time.Friday, nil
+	case "sat", "saturday":
+		return time.Saturday, nil
+	}
+	return 0, fmt.Errorf("unknown weekday: %s", name)
+}
+
+func parseClockMinute(value string) (int, error) {
+	parts := strings.Split(strings.TrimSpace(value), ":")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("invalid time of day %q: expected HH:MM", value)
+	}
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil || hour < 0 || hour > 24 {
+		return 0, fmt.Errorf("invalid hour in %q", value)
+	}
+	minute, err := strconv.Atoi(parts[1])
+	if err != nil || minute < 0 || minute > 59 || (hour == 24 && minute != 0) {
+		return 0, fmt.Errorf("invalid minute in %q", value)
+	}
+	return hour*60 + minute, nil
+}
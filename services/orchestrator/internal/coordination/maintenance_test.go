@@ -0,0 +1,180 @@
+package coordination
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// maintenanceCoordinator returns a coordinator restricted to 02:00-04:00 UTC
+// whose execution clock reads at
+func maintenanceCoordinator(t *testing.T, at time.Time, deferToNext bool) *Coordinator {
+	t.Helper()
+	schedule, err := ParseMaintenanceSchedule("* 02:00-04:00", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newTestCoordinator(t, succeedingRunner)
+	c.SetMaintenanceSchedule(schedule, deferToNext)
+	c.executionOrch.now = func() time.Time { return at }
+	return c
+}
+
+func createPlan(t *testing.T, c *Coordinator) string {
+	t.Helper()
+	plan, err := c.executionOrch.CreateExecutionPlan(lowRiskRec("rec-1", "migrate_to_spot", "node-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return plan.ID
+}
+
+func TestMaintenanceScheduleContains(t *testing.T) {
+	schedule, err := ParseMaintenanceSchedule("sat 22:00-02:00; * 12:00-13:00", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2026-10-17 is a Saturday
+	tests := []struct {
+		at   time.Time
+		want bool
+	}{
+		{time.Date(2026, 10, 17, 23, 0, 0, 0, time.UTC), true},
+		{time.Date(2026, 10, 18, 1, 59, 0, 0, time.UTC), true},  // Early Sunday belongs to Saturday's window
+		{time.Date(2026, 10, 18, 23, 0, 0, 0, time.UTC), false}, // Sunday evening
+		{time.Date(2026, 10, 19, 12, 30, 0, 0, time.UTC), true},
+		{time.Date(2026, 10, 19, 13, 0, 0, 0, time.UTC), false}, // End is exclusive
+	}
+	for _, tt := range tests {
+		if got := schedule.Contains(tt.at); got != tt.want {
+			t.Errorf("Contains(%s) = %v, want %v", tt.at.Format(time.RFC3339), got, tt.want)
+		}
+	}
+
+	for _, spec := range []string{"", "* 02:00", "xyz 01:00-02:00", "* 25:00-26:00", "* 02:00-02:00"} {
+		if _, err := ParseMaintenanceSchedule(spec, time.UTC); err == nil {
+			t.Errorf("ParseMaintenanceSchedule(%q) accepted", spec)
+		}
+	}
+}
+
+func TestExecuteInsideMaintenanceWindow(t *testing.T) {
+	c := maintenanceCoordinator(t, time.Date(2026, 10, 16, 3, 0, 0, 0, time.UTC), false)
+	planID := createPlan(t, c)
+
+	if err := c.ExecutePlan(planID); err != nil {
+		t.Fatalf("execute in window: %v", err)
+	}
+	waitForPlanStatus(t, c, planID, ExecutionStatusCompleted)
+}
+
+func TestExecuteOutsideMaintenanceWindow(t *testing.T) {
+	at := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	c := maintenanceCoordinator(t, at, false)
+	planID := createPlan(t, c)
+
+	allowed, next := c.CheckMaintenanceWindow()
+	if want := time.Date(2026, 10, 17, 2, 0, 0, 0, time.UTC); allowed || !next.Equal(want) {
+		t.Errorf("CheckMaintenanceWindow = %v, %s, want false, %s", allowed, next, want)
+	}
+	if err := c.ExecutePlan(planID); !errors.Is(err, ErrOutsideMaintenanceWindow) {
+		t.Fatalf("execute out of window: %v, want ErrOutsideMaintenanceWindow", err)
+	}
+	if plan, _ := c.GetExecutionPlan(planID); plan.Status != ExecutionStatusPending {
+		t.Errorf("refused plan status = %s, want pending", plan.Status)
+	}
+}
+
+func TestExecuteOutsideMaintenanceWindowDefers(t *testing.T) {
+	c := maintenanceCoordinator(t, time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC), true)
+	planID := createPlan(t, c)
+
+	if err := c.ExecutePlan(planID); err != nil {
+		t.Fatalf("execute out of window with deferral: %v", err)
+	}
+	plan, err := c.GetExecutionPlan(planID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Status != ExecutionStatusDeferred || plan.Metadata["deferred_until"] != "2026-10-17T02:00:00Z" {
+		t.Errorf("plan status %s deferred until %v, want deferred until the next window", plan.Status, plan.Metadata["deferred_until"])
+	}
+}
+
+// fakeTimers records the callbacks scheduled through an orchestrator's
+// afterFunc instead of arming real timers
+type fakeTimers struct {
+	mu      sync.Mutex
+	delays  []time.Duration
+	fns     []func()
+	stopped int
+}
+
+func (ft *fakeTimers) afterFunc(d time.Duration, f func()) func() bool {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+	ft.delays = append(ft.delays, d)
+	ft.fns = append(ft.fns, f)
+	return func() bool {
+		ft.mu.Lock()
+		defer ft.mu.Unlock()
+		ft.stopped++
+		return true
+	}
+}
+
+func TestDeferredPlanRunsWhenWindowOpens(t *testing.T) {
+	var mu sync.Mutex
+	at := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	c := maintenanceCoordinator(t, at, true)
+	c.executionOrch.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return at
+	}
+	timers := &fakeTimers{}
+	c.executionOrch.afterFunc = timers.afterFunc
+	planID := createPlan(t, c)
+
+	// Executing the deferred plan again keeps the one timer
+	for i := 0; i < 3; i++ {
+		if err := c.ExecutePlan(planID); err != nil {
+			t.Fatalf("execute out of window with deferral: %v", err)
+		}
+	}
+	if len(timers.fns) != 1 || timers.delays[0] != 16*time.Hour {
+		t.Fatalf("timers scheduled after %v, want one after 16h", timers.delays)
+	}
+	if plan, _ := c.GetExecutionPlan(planID); plan.Status != ExecutionStatusDeferred {
+		t.Fatalf("plan %s, want deferred", plan.Status)
+	}
+
+	// The window opens and the timer fires
+	mu.Lock()
+	at = time.Date(2026, 10, 17, 2, 0, 0, 0, time.UTC)
+	mu.Unlock()
+	timers.fns[0]()
+	waitForPlanStatus(t, c, planID, ExecutionStatusCompleted)
+	if len(timers.fns) != 1 {
+		t.Errorf("%d timers scheduled, want no more once the plan ran", len(timers.fns))
+	}
+}
+
+func TestDrainStopsWindowTimers(t *testing.T) {
+	c := maintenanceCoordinator(t, time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC), true)
+	timers := &fakeTimers{}
+	c.executionOrch.afterFunc = timers.afterFunc
+	planID := createPlan(t, c)
+	if err := c.ExecutePlan(planID); err != nil {
+		t.Fatal(err)
+	}
+
+	c.executionOrch.Drain()
+	if timers.stopped != 1 || len(c.executionOrch.windowTimers) != 0 {
+		t.Errorf("%d timers stopped, %d left, want the deferred plan's stopped", timers.stopped, len(c.executionOrch.windowTimers))
+	}
+	if plan, _ := c.GetExecutionPlan(planID); plan.Status != ExecutionStatusDeferred {
+		t.Errorf("plan %s after drain, want still deferred", plan.Status)
+	}
+}
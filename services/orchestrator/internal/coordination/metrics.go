@@ -0,0 +1,38 @@
+package coordination
+
+// MetricsRecorder receives coordination measurements, such as the
+// orchestrator's Prometheus metrics
+type MetricsRecorder interface {
+	// RecordCoordinationConflict counts one detected conflict
+	RecordCoordinationConflict()
+	// UpdateActiveOptimizations sets how many plans are executing
+	UpdateActiveOptimizations(count float64)
+}
+
+// SetMetricsRecorder reports detected conflicts and executing plans to
+// recorder; nil disables reporting. It must be called before the
+// coordinator is used.
+func (c *Coordinator) SetMetricsRecorder(recorder MetricsRecorder) {
+	c.metrics = recorder
+	c.executionOrch.metrics = recorder
+}
+
+// reportActivePlans reports how many plans are executing, counting those
+// paused or awaiting a step approval part way
+func (eo *ExecutionOrchestrator) reportActivePlans() {
+	if eo.metrics == nil {
+		return
+	}
+
+	eo.mu.RLock()
+	active := 0
+	for _, plan := range eo.plans {
+		switch plan.Status {
+		case ExecutionStatusRunning, ExecutionStatusPaused, ExecutionStatusAwaitingApproval:
+			active++
+		}
+	}
+	eo.mu.RUnlock()
+
+	eo.metrics.UpdateActiveOptimizations(float64(active))
+}
@@ -0,0 +1,67 @@
+package coordination
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCoordinateRecordsConflictsAndActivePlans(t *testing.T) {
+	release := make(chan struct{})
+	c := newTestCoordinator(t, stepRunnerFunc(func(ctx context.Context, step *ExecutionStep) (map[string]interface{}, error) {
+		<-release
+		return nil, nil
+	}))
+	recorder := &recordingMetrics{}
+	c.SetMetricsRecorder(recorder)
+
+	resp, err := c.Coordinate(&CoordinationRequest{
+		CustomerID: "cust-1",
+		Recommendations: []*Recommendation{
+			lowRiskRec("rec-1", "resize", "db-1"),
+			lowRiskRec("rec-2", "tune", "db-1"),
+		},
+		AutoApprove: true,
+		ExecuteNow:  true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ConflictsDetected != 1 {
+		t.Fatalf("conflicts detected = %d, want 1", resp.ConflictsDetected)
+	}
+	if conflicts, _ := recorder.snapshot(); conflicts != 1 {
+		t.Errorf("conflicts recorded = %d, want 1", conflicts)
+	}
+	if len(resp.ExecutionPlans) == 0 {
+		t.Fatal("no execution plans created")
+	}
+
+	// A running plan is reported as active
+	waitForPlanStatus(t, c, resp.ExecutionPlans[0].ID, ExecutionStatusRunning)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, active := recorder.snapshot(); len(active) > 0 && active[len(active)-1] >= 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("running plan never reported as active")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	close(release)
+	for _, plan := range resp.ExecutionPlans {
+		waitForPlanStatus(t, c, plan.ID, ExecutionStatusCompleted)
+	}
+	deadline = time.Now().Add(5 * time.Second)
+	for {
+		if _, active := recorder.snapshot(); active[len(active)-1] == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("finished plans still reported as active")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
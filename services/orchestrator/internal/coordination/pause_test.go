@@ -0,0 +1,81 @@
+package coordination
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+// heldStepRunner records steps and holds each one until released
+type heldStepRunner struct {
+	stepRecorder
+	release chan struct{}
+}
+
+func newHeldStepRunner() *heldStepRunner {
+	return &heldStepRunner{release: make(chan struct{})}
+}
+
+func (r *heldStepRunner) RunStep(ctx context.Context, step *ExecutionStep) (map[string]interface{}, error) {
+	r.stepRecorder.RunStep(ctx, step)
+	select {
+	case <-r.release:
+		return nil, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestPauseBetweenStepsAndResume(t *testing.T) {
+	steps := newHeldStepRunner()
+	c := newTestCoordinator(t, steps)
+	router := newTestHandler(c)
+	planID := executeRec(t, c, lowRiskRec("rec-1", "migrate_to_spot", "node-1"))
+
+	// Pause while the first step runs; the plan stops once it finishes
+	waitForPlan(t, c, planID, func(*ExecutionPlan) bool { return len(steps.actions()) == 1 })
+	if w := doJSON(t, router, http.MethodPost, "/coordination/plans/"+planID+"/pause", nil); w.Code != http.StatusAccepted {
+		t.Fatalf("pause: status %d: %s", w.Code, w.Body.String())
+	}
+	steps.release <- struct{}{}
+
+	plan := waitForPlanStatus(t, c, planID, ExecutionStatusPaused)
+	if plan.CurrentStep != 1 || plan.Steps[0].Status != ExecutionStatusCompleted || plan.Steps[1].Status != ExecutionStatusPending {
+		t.Errorf("paused at step %d with steps %s, %s", plan.CurrentStep, plan.Steps[0].Status, plan.Steps[1].Status)
+	}
+	if ran := steps.actions(); len(ran) != 1 {
+		t.Errorf("steps run while paused: %v", ran)
+	}
+
+	if w := doJSON(t, router, http.MethodPost, "/coordination/plans/"+planID+"/resume", nil); w.Code != http.StatusOK {
+		t.Fatalf("resume: status %d: %s", w.Code, w.Body.String())
+	}
+	close(steps.release)
+	waitForPlanStatus(t, c, planID, ExecutionStatusCompleted)
+	if ran := steps.actions(); len(ran) != 3 {
+		t.Errorf("steps run after resume: %v, want all 3", ran)
+	}
+}
+
+func TestPauseResumeErrors(t *testing.T) {
+	c := newTestCoordinator(t, succeedingRunner)
+	router := newTestHandler(c)
+	planID := executeRec(t, c, lowRiskRec("rec-1", "migrate_to_spot", "node-1"))
+	waitForPlanStatus(t, c, planID, ExecutionStatusCompleted)
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/coordination/plans/missing/pause", http.StatusNotFound},
+		{"/coordination/plans/missing/resume", http.StatusNotFound},
+		// A finished plan cannot pause, and one not paused cannot resume
+		{"/coordination/plans/" + planID + "/pause", http.StatusConflict},
+		{"/coordination/plans/" + planID + "/resume", http.StatusConflict},
+	}
+	for _, tt := range tests {
+		if w := doJSON(t, router, http.MethodPost, tt.path, nil); w.Code != tt.want {
+			t.Errorf("POST %s: status %d, want %d", tt.path, w.Code, tt.want)
+		}
+	}
+}
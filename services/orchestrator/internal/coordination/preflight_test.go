@@ -0,0 +1,92 @@
+package coordination
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+// preflightRunner runs every step and reports agents only for the actions
+// it is given
+type preflightRunner struct {
+	mu      sync.Mutex
+	actions map[string]bool
+}
+
+func newPreflightRunner(actions ...string) *preflightRunner {
+	r := &preflightRunner{actions: make(map[string]bool)}
+	for _, action := range actions {
+		r.actions[action] = true
+	}
+	return r
+}
+
+func (r *preflightRunner) RunStep(ctx context.Context, step *ExecutionStep) (map[string]interface{}, error) {
+	return nil, nil
+}
+
+func (r *preflightRunner) CanRunStep(step *ExecutionStep) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.actions[step.Action], nil
+}
+
+func (r *preflightRunner) remove(action string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.actions, action)
+}
+
+func TestRecommendationWithoutAgentRequiresAgent(t *testing.T) {
+	// No agent validates quality, which migrate_to_spot ends with
+	c := newTestCoordinator(t, newPreflightRunner("right_size", "take_snapshot", "migrate_workload"))
+	runnable := lowRiskRec("rec-1", "right_size", "node-1")
+	stranded := lowRiskRec("rec-2", "migrate_to_spot", "node-2")
+
+	resp, err := c.Coordinate(&CoordinationRequest{
+		CustomerID:      "cust-1",
+		Recommendations: []*Recommendation{runnable, stranded},
+		AutoApprove:     true,
+		ExecuteNow:      true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.RequiresAgent) != 1 || resp.RequiresAgent[0].RecommendationID != "rec-2" ||
+		len(resp.RequiresAgent[0].Actions) != 1 || resp.RequiresAgent[0].Actions[0] != "validate_quality" {
+		t.Errorf("requires_agent = %+v, want rec-2 missing validate_quality", resp.RequiresAgent)
+	}
+	if stranded.Status != "requires_agent" || stranded.ApprovalID != "" {
+		t.Errorf("stranded recommendation %q with approval %q, want requires_agent and no approval", stranded.Status, stranded.ApprovalID)
+	}
+	if len(resp.ExecutionPlans) != 1 || resp.ExecutionPlans[0].RecommendationID != "rec-1" {
+		t.Errorf("%d plans, want only rec-1's", len(resp.ExecutionPlans))
+	}
+
+	if _, err := c.executionOrch.CreateExecutionPlan(lowRiskRec("rec-3", "migrate_to_spot", "node-3")); !errors.Is(err, ErrRequiresAgent) {
+		t.Errorf("create plan without an agent: %v, want ErrRequiresAgent", err)
+	}
+}
+
+func TestApprovalAfterAgentLeavesConflicts(t *testing.T) {
+	steps := newPreflightRunner("right_size")
+	c := newTestCoordinator(t, steps)
+	rec := lowRiskRec("rec-1", "right_size", "node-1")
+	rec.RiskLevel = RiskLevelHigh
+	resp, err := c.Coordinate(&CoordinationRequest{CustomerID: "cust-1", Recommendations: []*Recommendation{rec}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Approvals) != 1 {
+		t.Fatalf("approvals = %d, want 1", len(resp.Approvals))
+	}
+
+	// The only agent able to run it goes away while it awaits approval
+	steps.remove("right_size")
+	w := doJSON(t, newTestHandler(c), http.MethodPost, "/coordination/approvals/"+resp.Approvals[0].ID+"/approve", map[string]string{"user_id": "alice"})
+	if w.Code != http.StatusConflict {
+		t.Errorf("approve: status %d, want 409: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,93 @@
+package coordination
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestUpdateProgressProjectsCompletion(t *testing.T) {
+	eo := NewExecutionOrchestrator()
+	now := time.Now()
+	eo.now = func() time.Time { return now }
+
+	plan := &ExecutionPlan{Steps: make([]ExecutionStep, 4)}
+	eo.updateProgress(plan)
+	if plan.ProgressPercent != 0 || plan.EstimatedCompletion != nil {
+		t.Fatalf("before any step: %v%%, eta %v", plan.ProgressPercent, plan.EstimatedCompletion)
+	}
+
+	var remaining []time.Duration
+	for i, duration := range []int{1000, 1500, 1000, 500} {
+		plan.Steps[i].Status = ExecutionStatusCompleted
+		plan.Steps[i].Duration = duration
+		eo.updateProgress(plan)
+
+		if want := float64(i+1) * 25; plan.ProgressPercent != want {
+			t.Errorf("after step %d: progress %v%%, want %v%%", i+1, plan.ProgressPercent, want)
+		}
+		if plan.EstimatedCompletion == nil {
+			t.Fatalf("after step %d: no estimated completion", i+1)
+		}
+		remaining = append(remaining, plan.EstimatedCompletion.Sub(now))
+	}
+
+	// Remaining steps at the average duration so far, shrinking to nothing
+	want := []time.Duration{3 * time.Second, 2500 * time.Millisecond, 1166 * time.Millisecond, 0}
+	for i := range want {
+		if remaining[i] != want[i] {
+			t.Errorf("after step %d: %v remaining, want %v", i+1, remaining[i], want[i])
+		}
+	}
+}
+
+func TestSkippedStepsCountAsProgressNotDuration(t *testing.T) {
+	eo := NewExecutionOrchestrator()
+	now := time.Now()
+	eo.now = func() time.Time { return now }
+
+	plan := &ExecutionPlan{Steps: []ExecutionStep{
+		{Status: ExecutionStatusCompleted, Duration: 1000},
+		{Status: ExecutionStatusSkipped},
+		{Status: ExecutionStatusPending},
+		{Status: ExecutionStatusPending},
+	}}
+	eo.updateProgress(plan)
+	if plan.ProgressPercent != 50 {
+		t.Errorf("progress = %v%%, want 50%%", plan.ProgressPercent)
+	}
+	if got := plan.EstimatedCompletion.Sub(now); got != 2*time.Second {
+		t.Errorf("remaining = %v, want 2s", got)
+	}
+}
+
+func TestPlanProgressAsStepsFinish(t *testing.T) {
+	proceed := make(chan struct{})
+	c := newTestCoordinator(t, stepRunnerFunc(func(ctx context.Context, step *ExecutionStep) (map[string]interface{}, error) {
+		select {
+		case <-proceed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		return nil, nil
+	}))
+	planID := executeRec(t, c, lowRiskRec("rec-1", "migrate_to_spot", "node-1"))
+
+	last := -1.0
+	for step := 1; step <= 3; step++ {
+		proceed <- struct{}{}
+		plan := waitForPlan(t, c, planID, func(p *ExecutionPlan) bool { return p.CurrentStep >= step })
+		if plan.ProgressPercent <= last {
+			t.Errorf("after step %d: progress %v%%, was %v%%", step, plan.ProgressPercent, last)
+		}
+		last = plan.ProgressPercent
+	}
+
+	plan := waitForPlanStatus(t, c, planID, ExecutionStatusCompleted)
+	if plan.ProgressPercent != 100 {
+		t.Errorf("completed plan progress = %v%%, want 100%%", plan.ProgressPercent)
+	}
+	if plan.EstimatedCompletion == nil || !plan.EstimatedCompletion.Equal(*plan.CompletedAt) {
+		t.Errorf("completed plan estimated completion %v, completed at %v", plan.EstimatedCompletion, plan.CompletedAt)
+	}
+}
@@ -0,0 +1,214 @@
+package coordination
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// RecoveryPolicy decides what happens at startup to plans that were still
+// executing when the previous run stopped
+type RecoveryPolicy string
+
+const (
+	// RecoveryResume runs the plan again from its current step
+	RecoveryResume RecoveryPolicy = "resume"
+
+	// RecoveryRollback rolls back every completed step of the plan
+	RecoveryRollback RecoveryPolicy = "rollback"
+)
+
+// ParseRecoveryPolicy validates a recovery policy name
+func ParseRecoveryPolicy(name string) (RecoveryPolicy, error) {
+	switch policy := RecoveryPolicy(name); policy {
+	case RecoveryResume, RecoveryRollback:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown recovery policy %q", name)
+	}
+}
+
+// StateSource loads coordinator state saved by a previous run
+type StateSource interface {
+	LoadState() (*CoordinatorState, error)
+}
+
+// StateArchiver is implemented by state sources that can set aside state
+// once it has been recovered, so it is not loaded again
+type StateArchiver interface {
+	ArchiveState() error
+}
+
+// LoadState reads the state file, returning nil if there is none
+func (s *FileStateSink) LoadState() (*CoordinatorState, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %w", err)
+	}
+
+	var state CoordinatorState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse state: %w", err)
+	}
+	return &state, nil
+}
+
+// ArchiveState renames the state file with a ".recovered" suffix, replacing
+// any earlier archive
+func (s *FileStateSink) ArchiveState() error {
+	if err := os.Rename(s.path, s.path+".recovered"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to archive state: %w", err)
+	}
+	return nil
+}
+
+// RecoveryDecision records what startup recovery did with one plan
+type RecoveryDecision struct {
+	PlanID   string         `json:"plan_id"`
+	Action   RecoveryPolicy `json:"action"`
+	FromStep int            `json:"from_step"` // Index of the step the plan stopped at
+}
+
+// Recover restores the approvals, recommendations and plans saved by a
+// previous run, then resumes or rolls back, per policy, each plan that was
+// running or interrupted. The loaded state is archived, if the source
+// supports it, and replaced by a checkpoint of the restored state. Plans are
+// recovered in the background, so the step runner's task router must
+// already be started.
+func (c *Coordinator) Recover(source StateSource, policy RecoveryPolicy) ([]RecoveryDecision, error) {
+	state, err := source.LoadState()
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, nil
+	}
+	if archiver, ok := source.(StateArchiver); ok {
+		if err := archiver.ArchiveState(); err != nil {
+			log.Printf("Recovery: %v", err)
+		}
+	}
+
+	c.approvalManager.restore(state.Approvals)
+
+	c.mu.Lock()
+	for _, rec := range state.Recommendations {
+		if _, ok := c.recommendations[rec.ID]; !ok {
+			c.recommendations[rec.ID] = rec
+		}
+	}
+	c.mu.Unlock()
+
+	stopped := c.executionOrch.restorePlans(state.Plans)
+	log.Printf("Restored %d approvals, %d recommendations and %d plans saved at %s",
+		len(state.Approvals), len(state.Recommendations), len(state.Plans), state.SavedAt.Format(time.RFC3339))
+	c.executionOrch.checkpoint()
+
+	decisions := make([]RecoveryDecision, 0, len(stopped))
+	for _, plan := range stopped {
+		decision := RecoveryDecision{PlanID: plan.ID, Action: policy, FromStep: plan.CurrentStep}
+		decisions = append(decisions, decision)
+
+		switch policy {
+		case RecoveryRollback:
+			log.Printf("Recovery: rolling back plan %s, stopped before step %d/%d",
+				plan.ID, plan.CurrentStep+1, len(plan.Steps))
+			go c.executionOrch.recoverByRollback(plan.ID)
+		default:
+			log.Printf("Recovery: resuming plan %s at step %d/%d",
+				plan.ID, plan.CurrentStep+1, len(plan.Steps))
+			go func(planID string) {
+				if err := c.executionOrch.ExecutePlan(planID); err != nil {
+					log.Printf("Recovery: plan %s failed to resume: %v", planID, err)
+				}
+			}(plan.ID)
+		}
+	}
+	return decisions, nil
+}
+
+// restore adds saved approvals not already known. Pending step approvals
+// get a fresh decision channel so a resumed plan can wait on them.
+func (am *ApprovalManager) restore(approvals []*Approval) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	for _, approval := range approvals {
+		if _, ok := am.approvals[approval.ID]; ok {
+			continue
+		}
+		am.approvals[approval.ID] = approval
+		if approval.StepID != "" && approval.Status == ApprovalStatusPending {
+			am.decided[approval.ID] = make(chan struct{})
+		}
+	}
+}
+
+// restorePlans adds saved plans not already known and returns snapshots of
+// those that were running or interrupted. A plan saved while running is
+// marked interrupted before its current step, which is re-run if resumed.
+func (eo *ExecutionOrchestrator) restorePlans(plans []*ExecutionPlan) []*ExecutionPlan {
+	eo.mu.Lock()
+	defer eo.mu.Unlock()
+
+	var stopped []*ExecutionPlan
+	for _, plan := range plans {
+		if _, ok := eo.plans[plan.ID]; ok {
+			continue
+		}
+		eo.plans[plan.ID] = plan
+
+		switch plan.Status {
+		case ExecutionStatusRunning:
+			if plan.Metadata == nil {
+				plan.Metadata = make(map[string]interface{})
+			}
+			plan.Metadata["interrupted_before_step"] = plan.CurrentStep
+			plan.Status = ExecutionStatusInterrupted
+		case ExecutionStatusInterrupted:
+		default:
+			continue
+		}
+		stopped = append(stopped, plan.snapshot())
+	}
+	return stopped
+}
+
+// recoverByRollback rolls back the completed steps of an interrupted plan
+func (eo *ExecutionOrchestrator) recoverByRollback(planID string) {
+	eo.mu.Lock()
+	plan, ok := eo.plans[planID]
+	if !ok || plan.Status != ExecutionStatusInterrupted {
+		eo.mu.Unlock()
+		return
+	}
+	if !eo.beginRun() {
+		eo.mu.Unlock()
+		log.Printf("Recovery: orchestrator shutting down, not rolling back plan %s", planID)
+		return
+	}
+	plan.Status = ExecutionStatusRunning
+	stoppedAt := plan.CurrentStep
+	eo.mu.Unlock()
+
+	eo.rollbackPlan(plan, stoppedAt)
+
+	eo.mu.Lock()
+	plan.Status = ExecutionStatusRolledBack
+	plan.EstimatedCompletion = nil
+	finished := plan.snapshot()
+	eo.mu.Unlock()
+	eo.checkpoint()
+	eo.running.Done()
+
+	log.Printf("Recovery: plan %s rolled back", planID)
+	if eo.onFinished != nil {
+		eo.onFinished(finished, errors.New("rolled back on recovery after restart"))
+	}
+}
@@ -0,0 +1,160 @@
+package coordination
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// persistMidExecution runs a three-step plan until its first step has
+// completed and the second is in progress, as if the process then crashed,
+// and returns a copy of the state file checkpointed at that point
+func persistMidExecution(t *testing.T) (path string, planID string) {
+	t.Helper()
+	block := make(chan struct{})
+	crashed := newTestCoordinator(t, stepRunnerFunc(func(ctx context.Context, step *ExecutionStep) (map[string]interface{}, error) {
+		if step.Action != "take_snapshot" {
+			<-block
+		}
+		return nil, nil
+	}))
+	crashedPath := filepath.Join(t.TempDir(), "state.json")
+	crashed.SetStateSink(NewFileStateSink(crashedPath))
+
+	resp, err := crashed.Coordinate(&CoordinationRequest{
+		CustomerID:      "cust-1",
+		Recommendations: []*Recommendation{lowRiskRec("rec-1", "migrate_to_spot", "node-1")},
+		AutoApprove:     true,
+		ExecuteNow:      true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.ExecutionPlans) != 1 {
+		t.Fatalf("execution plans = %d, want 1", len(resp.ExecutionPlans))
+	}
+	planID = resp.ExecutionPlans[0].ID
+	t.Cleanup(func() {
+		close(block)
+		waitForPlanStatus(t, crashed, planID, ExecutionStatusCompleted)
+	})
+
+	// The checkpoint taken after the first step records it as done
+	sink := NewFileStateSink(crashedPath)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		state, err := sink.LoadState()
+		if err == nil && state != nil && len(state.Plans) == 1 && state.Plans[0].CurrentStep == 1 {
+			if state.Plans[0].Status != ExecutionStatusRunning || state.Plans[0].Steps[0].Status != ExecutionStatusCompleted {
+				t.Fatalf("checkpointed plan status %s, first step %s", state.Plans[0].Status, state.Plans[0].Steps[0].Status)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no checkpoint after the first step")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	path = filepath.Join(t.TempDir(), "state.json")
+	copyFile(t, crashedPath, path)
+	return path, planID
+}
+
+func copyFile(t *testing.T, from, to string) {
+	t.Helper()
+	src, err := os.Open(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	dst, err := os.Create(to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Close()
+	if _, err := io.Copy(dst, src); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRecoverResumesPersistedPlan(t *testing.T) {
+	path, planID := persistMidExecution(t)
+
+	var mu sync.Mutex
+	var ran []string
+	c := newTestCoordinator(t, stepRunnerFunc(func(ctx context.Context, step *ExecutionStep) (map[string]interface{}, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		ran = append(ran, step.Action)
+		return nil, nil
+	}))
+	sink := NewFileStateSink(path)
+	c.SetStateSink(sink)
+
+	decisions, err := c.Recover(sink, RecoveryResume)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decisions) != 1 || decisions[0].PlanID != planID || decisions[0].FromStep != 1 {
+		t.Fatalf("decisions = %+v, want plan %s resumed from step 1", decisions, planID)
+	}
+	waitForPlanStatus(t, c, planID, ExecutionStatusCompleted)
+
+	// Only the steps that had not finished run again
+	mu.Lock()
+	if len(ran) != 2 || ran[0] != "migrate_workload" || ran[1] != "validate_quality" {
+		t.Errorf("steps run = %v, want migrate_workload then validate_quality", ran)
+	}
+	mu.Unlock()
+
+	// The loaded snapshot is set aside, and the state file no longer holds
+	// the finished plan, so another restart does not run it again
+	if _, err := os.Stat(path + ".recovered"); err != nil {
+		t.Errorf("loaded state not archived: %v", err)
+	}
+	state, err := sink.LoadState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state == nil || len(state.Plans) != 0 {
+		t.Errorf("state after completion = %+v, want no unfinished plans", state)
+	}
+}
+
+func TestRecoverRollsBackPersistedPlan(t *testing.T) {
+	path, planID := persistMidExecution(t)
+
+	c := newTestCoordinator(t, stepRunnerFunc(func(ctx context.Context, step *ExecutionStep) (map[string]interface{}, error) {
+		t.Errorf("step %s run during rollback recovery", step.Action)
+		return nil, nil
+	}))
+	sink := NewFileStateSink(path)
+	c.SetStateSink(sink)
+
+	if _, err := c.Recover(sink, RecoveryRollback); err != nil {
+		t.Fatal(err)
+	}
+	plan := waitForPlanStatus(t, c, planID, ExecutionStatusRolledBack)
+
+	// The completed snapshot step is undone; the interrupted step never
+	// finished, so there is nothing to undo
+	if plan.Steps[0].RollbackStatus != RollbackStatusRolledBack {
+		t.Errorf("first step rollback = %q, want rolled back", plan.Steps[0].RollbackStatus)
+	}
+	if plan.Steps[1].RollbackStatus != "" {
+		t.Errorf("interrupted step rollback = %q, want none", plan.Steps[1].RollbackStatus)
+	}
+
+	state, err := sink.LoadState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state == nil || len(state.Plans) != 0 {
+		t.Errorf("state after rollback = %+v, want no unfinished plans", state)
+	}
+}
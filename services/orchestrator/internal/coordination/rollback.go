@@ -0,0 +1,72 @@
+package coordination
+
+import (
+	"time"
+
+	"optiinfra/services/orchestrator/internal/handlers"
+)
+
+// RollbackStatus is the outcome of a step in its plan's rollback
+type RollbackStatus string
+
+const (
+	RollbackStatusRolledBack   RollbackStatus = "rolled_back"
+	RollbackStatusFailed       RollbackStatus = "failed"
+	RollbackStatusIrreversible RollbackStatus = "irreversible" // Not reversible, left as is
+	RollbackStatusNotRun       RollbackStatus = "not_run"      // Never completed, nothing to undo
+)
+
+// rollbackRedactor masks sensitive fields of rollback data in reports
+var rollbackRedactor = handlers.NewLogRedactor()
+
+// RollbackReport shows what a plan's rollback did to each step
+type RollbackReport struct {
+	PlanID       string               `json:"plan_id"`
+	Status       ExecutionStatus      `json:"status"`
+	RolledBack   bool                 `json:"rolled_back"`
+	RolledBackAt *time.Time           `json:"rolled_back_at,omitempty"`
+	Steps        []RollbackStepReport `json:"steps"`
+}
+
+// RollbackStepReport is one step's part in a rollback, with the rollback
+// data it used, sensitive fields masked
+type RollbackStepReport struct {
+	StepID         string                 `json:"step_id"`
+	Action         string                 `json:"action"`
+	Status         ExecutionStatus        `json:"status"`
+	Reversible     bool                   `json:"reversible"`
+	RollbackStatus RollbackStatus         `json:"rollback_status,omitempty"`
+	RollbackError  string                 `json:"rollback_error,omitempty"`
+	RollbackData   map[string]interface{} `json:"rollback_data,omitempty"`
+	RolledBackAt   *time.Time             `json:"rolled_back_at,omitempty"`
+}
+
+// RollbackReport returns the rollback report of a plan. Plans that were
+// not rolled back report their steps without a rollback status.
+func (c *Coordinator) RollbackReport(planID string) (*RollbackReport, error) {
+	plan, err := c.executionOrch.GetPlan(planID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &RollbackReport{
+		PlanID:       plan.ID,
+		Status:       plan.Status,
+		RolledBack:   plan.RolledBackAt != nil,
+		RolledBackAt: plan.RolledBackAt,
+		Steps:        make([]RollbackStepReport, 0, len(plan.Steps)),
+	}
+	for _, step := range plan.Steps {
+		report.Steps = append(report.Steps, RollbackStepReport{
+			StepID:         step.ID,
+			Action:         step.Action,
+			Status:         step.Status,
+			Reversible:     step.Reversible,
+			RollbackStatus: step.RollbackStatus,
+			RollbackError:  step.RollbackError,
+			RollbackData:   rollbackRedactor.Map(step.RollbackData),
+			RolledBackAt:   step.RolledBackAt,
+		})
+	}
+	return report, nil
+}
@@ -0,0 +1,103 @@
+package coordination
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// scaleDownFailingRunner fails the quality check after scale_resources has
+// run. Agent-backed steps carry no rollback data of their own, so it first
+// records the data an agent would have returned on the scaled step.
+func scaleDownFailingRunner(c *Coordinator) StepRunner {
+	var scaled bool
+	return stepRunnerFunc(func(ctx context.Context, step *ExecutionStep) (map[string]interface{}, error) {
+		if step.Action == "scale_resources" {
+			scaled = true
+			return nil, nil
+		}
+		if step.Action != "validate_quality" || !scaled {
+			return nil, nil
+		}
+
+		eo := c.executionOrch
+		eo.mu.Lock()
+		for _, plan := range eo.plans {
+			for i := range plan.Steps {
+				if plan.Steps[i].Action == "scale_resources" {
+					plan.Steps[i].RollbackData = map[string]interface{}{"restore_count": 5, "api_key": "s3cr3t"}
+				}
+			}
+		}
+		eo.mu.Unlock()
+		return nil, errors.New("quality dropped")
+	})
+}
+
+func TestRollbackReportForRolledBackPlan(t *testing.T) {
+	c := NewCoordinator()
+	c.SetStepRunner(scaleDownFailingRunner(c))
+	router := newTestHandler(c)
+
+	resp, err := c.Coordinate(&CoordinationRequest{
+		CustomerID:      "cust-1",
+		Recommendations: []*Recommendation{lowRiskRec("rec-1", "scale_down", "node-1")},
+		AutoApprove:     true,
+		ExecuteNow:      true,
+	})
+	if err != nil || len(resp.ExecutionPlans) != 1 {
+		t.Fatalf("coordinate: %v", err)
+	}
+	planID := resp.ExecutionPlans[0].ID
+	waitForPlanStatus(t, c, planID, ExecutionStatusRolledBack)
+
+	w := doJSON(t, router, http.MethodGet, "/coordination/plans/"+planID+"/rollback-report", nil)
+	var report RollbackReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("status %d, body %s", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "s3cr3t") {
+		t.Errorf("report leaks rollback data: %s", w.Body.String())
+	}
+
+	if !report.RolledBack || report.RolledBackAt == nil || report.Status != ExecutionStatusRolledBack || len(report.Steps) != 3 {
+		t.Fatalf("report = %+v", report)
+	}
+	check, scale, failed := report.Steps[0], report.Steps[1], report.Steps[2]
+	if check.RollbackStatus != RollbackStatusIrreversible || check.RolledBackAt != nil {
+		t.Errorf("first quality check = %+v, want irreversible and untouched", check)
+	}
+	if scale.RollbackStatus != RollbackStatusRolledBack || scale.RolledBackAt == nil || scale.Status != ExecutionStatusCompleted {
+		t.Errorf("scale step = %+v, want rolled back", scale)
+	}
+	if scale.RollbackData["restore_count"] != 5.0 || scale.RollbackData["api_key"] == "s3cr3t" {
+		t.Errorf("scale rollback data = %v, want restore_count kept and api_key masked", scale.RollbackData)
+	}
+	if failed.RollbackStatus != "" || failed.RolledBackAt != nil {
+		t.Errorf("failed step = %+v, want it left out of the rollback", failed)
+	}
+}
+
+func TestRollbackReportWithoutRollback(t *testing.T) {
+	c := newTestCoordinator(t, succeedingRunner)
+	router := newTestHandler(c)
+
+	plan, err := c.executionOrch.CreateExecutionPlan(lowRiskRec("rec-1", "scale_down", "node-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := c.RollbackReport(plan.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.RolledBack || len(report.Steps) != 3 || report.Steps[1].RollbackStatus != "" {
+		t.Errorf("report for a plan never rolled back = %+v", report)
+	}
+
+	if w := doJSON(t, router, http.MethodGet, "/coordination/plans/missing/rollback-report", nil); w.Code != http.StatusNotFound {
+		t.Errorf("unknown plan: status %d, want 404", w.Code)
+	}
+}
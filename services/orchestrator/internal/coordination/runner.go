@@ -0,0 +1,209 @@
+package coordination
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"optiinfra/services/orchestrator/internal/registry"
+	"optiinfra/services/orchestrator/internal/task"
+)
+
+const (
+	// How often a step's task is checked for completion, in case its outcome
+	// is not delivered by this replica's router
+	stepPollInterval = 250 * time.Millisecond
+
+	// Task metadata key naming the plan step a task runs
+	planStepIDKey = "plan_step_id"
+
+	// Backoff while the task router refuses new work
+	stepBackoffMin = 100 * time.Millisecond
+	stepBackoffMax = 5 * time.Second
+)
+
+// StepRunner runs plan steps in place of the built-in simulation,
+// returning the step's result. Runners that hand the step to a router task
+// set step.TaskID so the plan records it.
+type StepRunner interface {
+	RunStep(ctx context.Context, step *ExecutionStep) (map[string]interface{}, error)
+}
+
+// StepPreflight is implemented by step runners that can tell, before a plan
+// is created, whether an agent exists to run a step
+type StepPreflight interface {
+	CanRunStep(step *ExecutionStep) (bool, error)
+}
+
+// StepCanceller is implemented by step runners that can cancel the unfinished
+// step tasks of a coordination run, returning the IDs of the tasks cancelled
+type StepCanceller interface {
+	CancelSteps(correlationID, reason string) []string
+}
+
+// SetStepRunner runs plan steps through runner; nil restores the built-in
+// simulation
+func (c *Coordinator) SetStepRunner(runner StepRunner) {
+	c.executionOrch.runner = runner
+}
+
+// TaskStepRunner submits each step as a task through the task router, so
+// plan execution shares the router's queue. While the queue is full or
+// execution is paused, steps wait with backoff rather than piling on.
+type TaskStepRunner struct {
+	router   *task.Router
+	registry *registry.Registry
+
+	// Outcomes of step tasks, by the ID of the step waiting on them
+	mu      sync.Mutex
+	waiting map[string]chan task.TaskOutcome
+}
+
+// NewTaskStepRunner creates a runner submitting steps to router. Steps go to
+// their agent when it is registered, otherwise to any agent of the step's
+// type with the action as a capability. The router notifies the runner as
+// soon as a step's task completes or fails.
+func NewTaskStepRunner(router *task.Router, reg *registry.Registry) *TaskStepRunner {
+	r := &TaskStepRunner{
+		router:   router,
+		registry: reg,
+		waiting:  make(map[string]chan task.TaskOutcome),
+	}
+	router.SubscribeOutcomes(r.taskFinished)
+	return r
+}
+
+// taskFinished hands a step task's outcome to the step waiting on it,
+// without blocking the router
+func (r *TaskStepRunner) taskFinished(outcome task.TaskOutcome) {
+	stepID, _ := outcome.Metadata[planStepIDKey].(string)
+	if stepID == "" {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if ch, ok := r.waiting[stepID]; ok {
+		select {
+		case ch <- outcome:
+		default:
+		}
+	}
+}
+
+// RunStep submits the step as a task and waits for it to finish
+func (r *TaskStepRunner) RunStep(ctx context.Context, step *ExecutionStep) (map[string]interface{}, error) {
+	req := &task.TaskSubmitRequest{
+		TaskType:   task.TaskType(step.Action),
+		AgentType:  step.AgentType,
+		Parameters: step.Parameters,
+		Metadata:   map[string]interface{}{planStepIDKey: step.ID},
+	}
+	if step.CorrelationID != "" {
+		req.Metadata[task.CorrelationIDKey] = step.CorrelationID
+	}
+	if step.AgentID != "" {
+		if _, err := r.registry.GetAgent(step.AgentID); err == nil {
+			req.AgentID = step.AgentID
+		}
+	}
+
+	// Listen before submitting; the task may finish before submit returns
+	outcomes := make(chan task.TaskOutcome, 1)
+	r.mu.Lock()
+	r.waiting[step.ID] = outcomes
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		delete(r.waiting, step.ID)
+		r.mu.Unlock()
+	}()
+
+	taskID, err := r.submit(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	step.TaskID = taskID
+
+	ticker := time.NewTicker(stepPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case outcome := <-outcomes:
+			if outcome.TaskID != taskID {
+				continue
+			}
+			return stepOutcome(taskID, outcome.Status, outcome.Result, outcome.Error)
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		status, err := r.router.GetTaskStatus(taskID)
+		if err != nil {
+			return nil, err
+		}
+		if status.Status.IsTerminal() {
+			return stepOutcome(taskID, status.Status, status.Result, status.Error)
+		}
+	}
+}
+
+// stepOutcome turns a step task's terminal status into the step's result,
+// or an error unless the task completed
+func stepOutcome(taskID string, status task.TaskStatus, result map[string]interface{}, errMsg string) (map[string]interface{}, error) {
+	if status != task.TaskStatusCompleted {
+		return nil, fmt.Errorf("task %s %s: %s", taskID, status, errMsg)
+	}
+	return result, nil
+}
+
+// CanRunStep reports whether the step's agent is registered or a healthy
+// agent of its type has the action as a capability
+func (r *TaskStepRunner) CanRunStep(step *ExecutionStep) (bool, error) {
+	if step.AgentID != "" {
+		if _, err := r.registry.GetAgent(step.AgentID); err == nil {
+			return true, nil
+		}
+	}
+
+	agents, err := r.registry.GetAgentsWithCapability(step.Action, registry.AgentType(step.AgentType))
+	if err != nil {
+		return false, err
+	}
+	return len(agents) > 0, nil
+}
+
+// CancelSteps cancels the router tasks running steps of a coordination run
+func (r *TaskStepRunner) CancelSteps(correlationID, reason string) []string {
+	return r.router.CancelCorrelated(correlationID, reason)
+}
+
+// submit retries with backoff while the router is saturated or paused
+func (r *TaskStepRunner) submit(ctx context.Context, req *task.TaskSubmitRequest) (string, error) {
+	delay := stepBackoffMin
+	for {
+		resp, err := r.router.SubmitTask(ctx, req)
+		if err == nil {
+			return resp.TaskID, nil
+		}
+		if !errors.Is(err, task.ErrQueueFull) && !errors.Is(err, task.ErrExecutionPaused) {
+			return "", fmt.Errorf("failed to submit step %s: %w", req.TaskType, err)
+		}
+
+		if delay == stepBackoffMin {
+			log.Printf("Task router not accepting work, holding step %s: %v", req.TaskType, err)
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+		if delay *= 2; delay > stepBackoffMax {
+			delay = stepBackoffMax
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package coordination
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"optiinfra/services/orchestrator/internal/registry"
+	"optiinfra/services/orchestrator/internal/task"
+)
+
+// newStepRouter returns a task router that is not yet started, with a cost
+// agent completing every task, and a coordinator running steps through it
+func newStepRouter(t *testing.T) (*task.Router, *Coordinator) {
+	t.Helper()
+	agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var taskReq task.TaskRequest
+		json.NewDecoder(req.Body).Decode(&taskReq)
+		json.NewEncoder(w).Encode(task.TaskResponse{TaskID: taskReq.TaskID, Status: task.TaskStatusCompleted})
+	}))
+	t.Cleanup(agent.Close)
+	host, portStr, _ := strings.Cut(strings.TrimPrefix(agent.URL, "http://"), ":")
+	port, _ := strconv.Atoi(portStr)
+
+	reg := registry.NewRegistryWithStore(registry.NewMemoryAgentStore())
+	if _, err := reg.Register(&registry.RegistrationRequest{Name: "cost-1", Type: registry.AgentTypeCost, Host: host, Port: port, Capabilities: []string{"right_size"}}); err != nil {
+		t.Fatal(err)
+	}
+	router := task.NewRouterWithConfig(task.NewMemoryTaskStore(), reg, task.DefaultConfig())
+	t.Cleanup(router.Stop)
+	return router, newTestCoordinator(t, NewTaskStepRunner(router, reg))
+}
+
+func taskCount(t *testing.T, router *task.Router) int64 {
+	t.Helper()
+	page, err := router.ListTasksPage("", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return page.Total
+}
+
+func TestApprovedBatchThrottledByRouterQueue(t *testing.T) {
+	router, c := newStepRouter(t)
+	router.SetMaxQueuedTasks(2)
+
+	var recs []*Recommendation
+	for i := 0; i < 6; i++ {
+		id := fmt.Sprintf("rec-%d", i)
+		recs = append(recs, lowRiskRec(id, "right_size", "node-"+id))
+	}
+	resp, err := c.Coordinate(&CoordinationRequest{
+		CustomerID:      "cust-1",
+		Recommendations: recs,
+		AutoApprove:     true,
+		ExecuteNow:      true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.ExecutionPlans) != len(recs) {
+		t.Fatalf("execution plans = %d, want %d", len(resp.ExecutionPlans), len(recs))
+	}
+
+	// The router is not dispatching, so only two steps get in
+	deadline := time.Now().Add(5 * time.Second)
+	for queued, _ := router.QueueDepth(); queued < 2; queued, _ = router.QueueDepth() {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d steps queued, want the queue filled", queued)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(3 * stepBackoffMin)
+	if queued, max := router.QueueDepth(); queued != 2 || max != 2 {
+		t.Errorf("queue depth %d of %d, want held at 2", queued, max)
+	}
+	if total := taskCount(t, router); total != 2 {
+		t.Errorf("router holds %d tasks, want only the 2 admitted", total)
+	}
+	for _, plan := range resp.ExecutionPlans {
+		if plan, _ := c.GetExecutionPlan(plan.ID); plan.Status.finished() {
+			t.Errorf("plan %s %s while the router is saturated", plan.ID, plan.Status)
+		}
+	}
+
+	// Once the router drains its queue, the held steps follow
+	router.Start()
+	for _, plan := range resp.ExecutionPlans {
+		waitForPlanStatus(t, c, plan.ID, ExecutionStatusCompleted)
+	}
+	if total := taskCount(t, router); total != int64(len(recs)) {
+		t.Errorf("router ran %d tasks, want one per step", total)
+	}
+}
+
+// failingStepRouter returns a started task router whose agents complete
+// every task but scale_resources, which fails, and a coordinator running
+// steps through it
+func failingStepRouter(t *testing.T) (*TaskStepRunner, *Coordinator) {
+	t.Helper()
+	agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var taskReq task.TaskRequest
+		json.NewDecoder(req.Body).Decode(&taskReq)
+		if taskReq.TaskType == "scale_resources" {
+			retryable := false
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			json.NewEncoder(w).Encode(task.TaskResponse{TaskID: taskReq.TaskID, Status: task.TaskStatusFailed, Error: "quota exceeded", Retryable: &retryable})
+			return
+		}
+		json.NewEncoder(w).Encode(task.TaskResponse{TaskID: taskReq.TaskID, Status: task.TaskStatusCompleted})
+	}))
+	t.Cleanup(agent.Close)
+	host, portStr, _ := strings.Cut(strings.TrimPrefix(agent.URL, "http://"), ":")
+	port, _ := strconv.Atoi(portStr)
+
+	reg := registry.NewRegistryWithStore(registry.NewMemoryAgentStore())
+	for _, req := range []*registry.RegistrationRequest{
+		{Name: "cost-1", Type: registry.AgentTypeCost, Host: host, Port: port, Capabilities: []string{"scale_resources"}},
+		{Name: "app-1", Type: registry.AgentTypeApplication, Host: host, Port: port, Capabilities: []string{"validate_quality"}},
+	} {
+		if _, err := reg.Register(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	router := task.NewRouterWithConfig(task.NewMemoryTaskStore(), reg, task.DefaultConfig())
+	router.Start()
+	t.Cleanup(router.Stop)
+	runner := NewTaskStepRunner(router, reg)
+	return runner, newTestCoordinator(t, runner)
+}
+
+func TestStepTaskFailureRollsBackPlan(t *testing.T) {
+	_, c := failingStepRouter(t)
+
+	resp, err := c.Coordinate(&CoordinationRequest{
+		CustomerID:      "cust-1",
+		Recommendations: []*Recommendation{lowRiskRec("rec-1", "scale_down", "node-1")},
+		AutoApprove:     true,
+		ExecuteNow:      true,
+	})
+	if err != nil || len(resp.ExecutionPlans) != 1 {
+		t.Fatalf("coordinate: %v", err)
+	}
+	plan := waitForPlanStatus(t, c, resp.ExecutionPlans[0].ID, ExecutionStatusRolledBack)
+
+	check, scale, recheck := plan.Steps[0], plan.Steps[1], plan.Steps[2]
+	if check.Status != ExecutionStatusCompleted || check.TaskID == "" {
+		t.Errorf("first check %s with task %q, want completed through a task", check.Status, check.TaskID)
+	}
+	if scale.Status != ExecutionStatusFailed || !strings.Contains(scale.Error, "quota exceeded") || scale.TaskID == "" {
+		t.Errorf("scale step %s (%q), want failed with the task's error", scale.Status, scale.Error)
+	}
+	if recheck.Status != ExecutionStatusPending {
+		t.Errorf("step after the failure %s, want never run", recheck.Status)
+	}
+	if check.RollbackStatus != RollbackStatusIrreversible {
+		t.Errorf("first check rollback = %q, want irreversible", check.RollbackStatus)
+	}
+}
+
+func TestStepOutcomeDeliveredBeforePoll(t *testing.T) {
+	runner, _ := failingStepRouter(t)
+
+	start := time.Now()
+	_, err := runner.RunStep(context.Background(), &ExecutionStep{ID: "step-1", Action: "scale_resources", AgentType: "cost"})
+	if err == nil || !strings.Contains(err.Error(), "quota exceeded") {
+		t.Errorf("run = %v, want the task's failure", err)
+	}
+	// The router's notification ends the wait, not the fallback poll
+	if elapsed := time.Since(start); elapsed >= stepPollInterval {
+		t.Errorf("step took %v, want it back before the %v poll", elapsed, stepPollInterval)
+	}
+	if result, err := runner.RunStep(context.Background(), &ExecutionStep{ID: "step-2", Action: "validate_quality", AgentType: "application"}); err != nil {
+		t.Errorf("completing step: %v, %v", result, err)
+	}
+}
@@ -0,0 +1,128 @@
+package coordination
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Conflict severities, lowest first
+var conflictSeverities = []string{"low", "medium", "high", "critical"}
+
+// SeverityPolicy rates a conflict by how many of the two recommendations
+// are at or above a risk threshold
+type SeverityPolicy struct {
+	Threshold RiskLevel `json:"threshold"`
+	Both      string    `json:"both"`    // Both recommendations at or above the threshold
+	One       string    `json:"one"`     // Exactly one of them
+	Neither   string    `json:"neither"` // Neither of them
+}
+
+// DefaultSeverityPolicy rates conflicts high when both recommendations are
+// high-risk, medium when one is and low otherwise
+func DefaultSeverityPolicy() SeverityPolicy {
+	return SeverityPolicy{Threshold: RiskLevelHigh, Both: "high", One: "medium", Neither: "low"}
+}
+
+// Validate checks the threshold and severities are known
+func (p SeverityPolicy) Validate() error {
+	if _, err := ParseRiskLevel(string(p.Threshold)); err != nil {
+		return fmt.Errorf("threshold: %w", err)
+	}
+	for _, severity := range []string{p.Both, p.One, p.Neither} {
+		if !isConflictSeverity(severity) {
+			return fmt.Errorf("unknown severity %q", severity)
+		}
+	}
+	return nil
+}
+
+// severity rates a conflict between two recommendations
+func (p SeverityPolicy) severity(rec1, rec2 *Recommendation) string {
+	above := 0
+	for _, rec := range []*Recommendation{rec1, rec2} {
+		if riskScores[rec.RiskLevel] >= riskScores[p.Threshold] {
+			above++
+		}
+	}
+	switch above {
+	case 2:
+		return p.Both
+	case 1:
+		return p.One
+	default:
+		return p.Neither
+	}
+}
+
+func isConflictSeverity(severity string) bool {
+	for _, s := range conflictSeverities {
+		if s == severity {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseSeverityPolicies parses per-customer policies like
+// "acme=threshold:medium,both:critical,one:high". Settings left out keep
+// the default policy's value.
+func ParseSeverityPolicies(spec string) (map[string]SeverityPolicy, error) {
+	policies := make(map[string]SeverityPolicy)
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		customer, settings, ok := strings.Cut(entry, "=")
+		customer = strings.TrimSpace(customer)
+		if !ok || customer == "" {
+			return nil, fmt.Errorf("invalid severity policy entry %q", entry)
+		}
+
+		policy := DefaultSeverityPolicy()
+		for _, setting := range strings.Split(settings, ",") {
+			key, value, ok := strings.Cut(strings.TrimSpace(setting), ":")
+			value = strings.TrimSpace(value)
+			switch {
+			case !ok:
+				return nil, fmt.Errorf("invalid setting %q for customer %s", setting, customer)
+			case key == "threshold":
+				policy.Threshold = RiskLevel(value)
+			case key == "both":
+				policy.Both = value
+			case key == "one":
+				policy.One = value
+			case key == "neither":
+				policy.Neither = value
+			default:
+				return nil, fmt.Errorf("unknown setting %q for customer %s", key, customer)
+			}
+		}
+		if err := policy.Validate(); err != nil {
+			return nil, fmt.Errorf("customer %s: %w", customer, err)
+		}
+		policies[customer] = policy
+	}
+	return policies, nil
+}
+
+// SetSeverityPolicies sets per-customer conflict severity policies, used in
+// place of the default for recommendations of those customers. It must be
+// called before coordinating.
+func (cd *ConflictDetector) SetSeverityPolicies(policies map[string]SeverityPolicy) {
+	cd.severityPolicies = policies
+}
+
+// SetSeverityPolicies sets per-customer conflict severity policies
+func (c *Coordinator) SetSeverityPolicies(policies map[string]SeverityPolicy) {
+	c.conflictDetector.SetSeverityPolicies(policies)
+}
+
+// severityPolicy returns the policy for a customer, or the default
+func (cd *ConflictDetector) severityPolicy(customerID string) SeverityPolicy {
+	if policy, ok := cd.severityPolicies[customerID]; ok {
+		return policy
+	}
+	return DefaultSeverityPolicy()
+}
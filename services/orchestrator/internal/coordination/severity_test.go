@@ -0,0 +1,57 @@
+package coordination
+
+import (
+	"testing"
+)
+
+// conflictingPair returns a high-risk and a medium-risk recommendation on
+// the same node
+func conflictingPair() []*Recommendation {
+	high := lowRiskRec("rec-high", "right_size", "node-1")
+	high.RiskLevel = RiskLevelHigh
+	medium := lowRiskRec("rec-medium", "scale_down", "node-1")
+	medium.RiskLevel = RiskLevelMedium
+	return []*Recommendation{high, medium}
+}
+
+func TestSeverityPolicyPerCustomer(t *testing.T) {
+	c := newTestCoordinator(t, succeedingRunner)
+	c.SetSeverityPolicies(map[string]SeverityPolicy{
+		"strict":  {Threshold: RiskLevelMedium, Both: "critical", One: "high", Neither: "low"},
+		"lenient": {Threshold: RiskLevelHigh, Both: "medium", One: "low", Neither: "low"},
+	})
+
+	for customer, want := range map[string]string{"strict": "critical", "lenient": "low", "default": "medium"} {
+		// The recommendations take the request's customer
+		resp, err := c.Coordinate(&CoordinationRequest{CustomerID: customer, Recommendations: conflictingPair()})
+		if err != nil {
+			t.Fatalf("%s: %v", customer, err)
+		}
+		if len(resp.Conflicts) == 0 {
+			t.Fatalf("%s: no conflict detected", customer)
+		}
+		for _, conflict := range resp.Conflicts {
+			if conflict.Severity != want {
+				t.Errorf("%s: %s conflict severity %s, want %s", customer, conflict.Type, conflict.Severity, want)
+			}
+		}
+	}
+}
+
+func TestParseSeverityPolicies(t *testing.T) {
+	policies, err := ParseSeverityPolicies("strict=threshold:medium, both:critical ; lenient=one:low")
+	if err != nil {
+		t.Fatal(err)
+	}
+	strict := SeverityPolicy{Threshold: RiskLevelMedium, Both: "critical", One: "medium", Neither: "low"}
+	lenient := SeverityPolicy{Threshold: RiskLevelHigh, Both: "high", One: "low", Neither: "low"}
+	if len(policies) != 2 || policies["strict"] != strict || policies["lenient"] != lenient {
+		t.Errorf("policies = %+v", policies)
+	}
+
+	for _, spec := range []string{"strict", "=both:high", "strict=both", "strict=both:severe", "strict=threshold:extreme", "strict=floor:low"} {
+		if _, err := ParseSeverityPolicies(spec); err == nil {
+			t.Errorf("parsed %q", spec)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package coordination
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// expireAgo moves an approval's expiry into the past as a replica whose
+// clock runs ahead would have stored it: a wall clock time with no
+// monotonic reading
+func expireAgo(c *Coordinator, approvalID string, ago time.Duration) {
+	am := c.approvalManager
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	am.approvals[approvalID].ExpiresAt = time.Now().Add(-ago).Round(0)
+}
+
+func TestApprovalExpirySkewTolerance(t *testing.T) {
+	tests := []struct {
+		name      string
+		tolerance time.Duration
+		expired   bool
+	}{
+		{"no tolerance", 0, true},
+		{"skew within tolerance", time.Minute, false},
+		{"skew beyond tolerance", 10 * time.Second, true},
+	}
+	for _, tt := range tests {
+		c := newTestCoordinator(t, succeedingRunner)
+		c.SetApprovalSkewTolerance(tt.tolerance)
+		approvalID := pendingScaleDown(t, c)
+		expireAgo(c, approvalID, 30*time.Second)
+
+		pending := c.GetPendingApprovals("cust-1")
+		if listed := len(pending) == 1; listed == tt.expired {
+			t.Errorf("%s: listed as pending = %v", tt.name, listed)
+		}
+		if until := c.approvalManager.untilExpiry(c.approvalManager.approvals[approvalID]); (until <= 0) != tt.expired {
+			t.Errorf("%s: %v until expiry", tt.name, until)
+		}
+
+		err := c.approvalManager.ProcessApproval(approvalID, ApprovalStatusApproved, "alice", "")
+		if tt.expired {
+			if err == nil || !strings.Contains(err.Error(), "expired") {
+				t.Errorf("%s: approve = %v, want expired", tt.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: approve: %v", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package coordination
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidStepApproval is returned when a step approval selection names a
+// step the plan does not have
+var ErrInvalidStepApproval = errors.New("invalid step approval")
+
+// StepApproval selects the plan steps that need their own sign-off. In JSON
+// it is either true, for every step, or a list of step actions and 1-based
+// step numbers, such as ["migrate_to_spot", 3].
+type StepApproval struct {
+	All     bool
+	Actions []string
+	Steps   []int
+}
+
+// UnmarshalJSON accepts a boolean or a list of actions and step numbers
+func (sa *StepApproval) UnmarshalJSON(data []byte) error {
+	*sa = StepApproval{}
+
+	var all bool
+	if err := json.Unmarshal(data, &all); err == nil {
+		sa.All = all
+		return nil
+	}
+
+	var entries []json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("%w: want true or a list of step actions and numbers", ErrInvalidStepApproval)
+	}
+	for _, entry := range entries {
+		var action string
+		if err := json.Unmarshal(entry, &action); err == nil {
+			sa.Actions = append(sa.Actions, action)
+			continue
+		}
+		var step int
+		if err := json.Unmarshal(entry, &step); err != nil {
+			return fmt.Errorf("%w: %s is neither a step action nor a step number", ErrInvalidStepApproval, bytes.TrimSpace(entry))
+		}
+		sa.Steps = append(sa.Steps, step)
+	}
+	return nil
+}
+
+// MarshalJSON writes true for every step, or the list of actions and step
+// numbers
+func (sa StepApproval) MarshalJSON() ([]byte, error) {
+	if sa.All {
+		return []byte("true"), nil
+	}
+	entries := make([]interface{}, 0, len(sa.Actions)+len(sa.Steps))
+	for _, action := range sa.Actions {
+		entries = append(entries, action)
+	}
+	for _, step := range sa.Steps {
+		entries = append(entries, step)
+	}
+	return json.Marshal(entries)
+}
+
+// applyStepApproval marks the selected steps as requiring approval. Every
+// named action and step number must match a step.
+func applyStepApproval(steps []ExecutionStep, selection *StepApproval) error {
+	if selection == nil {
+		return nil
+	}
+	if selection.All {
+		for i := range steps {
+			steps[i].RequiresApproval = true
+		}
+		return nil
+	}
+
+	for _, n := range selection.Steps {
+		if n < 1 || n > len(steps) {
+			return fmt.Errorf("%w: step %d out of range (plan has %d steps)", ErrInvalidStepApproval, n, len(steps))
+		}
+		steps[n-1].RequiresApproval = true
+	}
+	for _, action := range selection.Actions {
+		found := false
+		for i := range steps {
+			if steps[i].Action == action {
+				steps[i].RequiresApproval = true
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w: no step with action %q", ErrInvalidStepApproval, action)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package coordination
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStepApprovalJSON(t *testing.T) {
+	tests := []struct {
+		in   string
+		want StepApproval
+	}{
+		{`true`, StepApproval{All: true}},
+		{`false`, StepApproval{}},
+		{`["migrate_workload", 3]`, StepApproval{Actions: []string{"migrate_workload"}, Steps: []int{3}}},
+	}
+	for _, tt := range tests {
+		var got StepApproval
+		if err := json.Unmarshal([]byte(tt.in), &got); err != nil {
+			t.Errorf("unmarshal %s: %v", tt.in, err)
+			continue
+		}
+		if got.All != tt.want.All || len(got.Actions) != len(tt.want.Actions) || len(got.Steps) != len(tt.want.Steps) {
+			t.Errorf("unmarshal %s = %+v, want %+v", tt.in, got, tt.want)
+			continue
+		}
+
+		data, err := json.Marshal(got)
+		if err != nil {
+			t.Errorf("marshal %+v: %v", got, err)
+			continue
+		}
+		var again StepApproval
+		if err := json.Unmarshal(data, &again); err != nil || again.All != got.All || len(again.Actions)+len(again.Steps) != len(got.Actions)+len(got.Steps) {
+			t.Errorf("round trip of %s gave %s", tt.in, data)
+		}
+	}
+
+	for _, in := range []string{`{"step": 1}`, `[true]`, `"all"`} {
+		var sa StepApproval
+		if err := json.Unmarshal([]byte(in), &sa); !errors.Is(err, ErrInvalidStepApproval) {
+			t.Errorf("unmarshal %s: %v, want ErrInvalidStepApproval", in, err)
+		}
+	}
+}
+
+func TestStepApprovalMustMatchPlanSteps(t *testing.T) {
+	c := newTestCoordinator(t, succeedingRunner)
+	for _, selection := range []*StepApproval{
+		{Actions: []string{"reboot"}},
+		{Steps: []int{4}},
+		{Steps: []int{0}},
+	} {
+		rec := lowRiskRec("rec-1", "migrate_to_spot", "node-1")
+		rec.StepApproval = selection
+		if _, err := c.executionOrch.CreateExecutionPlan(rec); !errors.Is(err, ErrInvalidStepApproval) {
+			t.Errorf("selection %+v: %v, want ErrInvalidStepApproval", selection, err)
+		}
+	}
+}
+
+// stepRecorder runs steps and records their actions in order
+type stepRecorder struct {
+	mu  sync.Mutex
+	ran []string
+}
+
+func (r *stepRecorder) RunStep(ctx context.Context, step *ExecutionStep) (map[string]interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ran = append(r.ran, step.Action)
+	return nil, nil
+}
+
+func (r *stepRecorder) actions() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.ran...)
+}
+
+// startGatedPlan coordinates a migrate_to_spot plan whose migrate_workload
+// step needs approval, waits for it to stall there and returns the plan ID
+// and the pending step approval's ID
+func startGatedPlan(t *testing.T, c *Coordinator, steps *stepRecorder) (string, string) {
+	t.Helper()
+	rec := lowRiskRec("rec-1", "migrate_to_spot", "node-1")
+	rec.StepApproval = &StepApproval{Actions: []string{"migrate_workload"}}
+
+	resp, err := c.Coordinate(&CoordinationRequest{
+		CustomerID:      "cust-1",
+		Recommendations: []*Recommendation{rec},
+		AutoApprove:     true,
+		ExecuteNow:      true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.ExecutionPlans) != 1 {
+		t.Fatalf("execution plans = %d, want 1", len(resp.ExecutionPlans))
+	}
+	planID := resp.ExecutionPlans[0].ID
+
+	plan := waitForPlanStatus(t, c, planID, ExecutionStatusAwaitingApproval)
+	if ran := steps.actions(); len(ran) != 1 || ran[0] != "take_snapshot" {
+		t.Fatalf("steps run before approval: %v, want [take_snapshot]", ran)
+	}
+	gated := plan.Steps[plan.CurrentStep]
+	if gated.Action != "migrate_workload" || gated.ApprovalID == "" {
+		t.Fatalf("plan stalled on step %s (approval %q), want migrate_workload", gated.Action, gated.ApprovalID)
+	}
+	return planID, gated.ApprovalID
+}
+
+func TestPlanStallsOnPendingStepApproval(t *testing.T) {
+	steps := &stepRecorder{}
+	c := newTestCoordinator(t, steps)
+	planID, approvalID := startGatedPlan(t, c, steps)
+
+	// The plan stays stalled until the step is approved
+	time.Sleep(50 * time.Millisecond)
+	if ran := steps.actions(); len(ran) != 1 {
+		t.Fatalf("steps run while approval pending: %v", ran)
+	}
+
+	if _, err := c.ApproveRecommendation(approvalID, "ops"); err != nil {
+		t.Fatalf("approve step: %v", err)
+	}
+	plan := waitForPlanStatus(t, c, planID, ExecutionStatusCompleted)
+
+	want := []string{"take_snapshot", "migrate_workload", "validate_quality"}
+	if ran := steps.actions(); len(ran) != len(want) {
+		t.Fatalf("steps run: %v, want %v", ran, want)
+	}
+	// Only the selected step was gated
+	for _, step := range plan.Steps {
+		if gated := step.Action == "migrate_workload"; step.RequiresApproval != gated || (step.ApprovalID != "") != gated {
+			t.Errorf("step %s requires approval %v (approval %q)", step.Action, step.RequiresApproval, step.ApprovalID)
+		}
+	}
+}
+
+func TestPausePlanAwaitingStepApproval(t *testing.T) {
+	steps := &stepRecorder{}
+	c := newTestCoordinator(t, steps)
+	planID, approvalID := startGatedPlan(t, c, steps)
+
+	if err := c.PausePlan(planID); err != nil {
+		t.Fatalf("pause while awaiting approval: %v", err)
+	}
+	if _, err := c.ApproveRecommendation(approvalID, "ops"); err != nil {
+		t.Fatalf("approve step: %v", err)
+	}
+
+	// Approval does not start the step while the plan is paused
+	waitForPlanStatus(t, c, planID, ExecutionStatusPaused)
+	if ran := steps.actions(); len(ran) != 1 {
+		t.Fatalf("steps run while paused: %v", ran)
+	}
+
+	if err := c.ResumePlan(planID); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	waitForPlanStatus(t, c, planID, ExecutionStatusCompleted)
+	if ran := steps.actions(); len(ran) != 3 {
+		t.Errorf("steps run after resume: %v, want all 3", ran)
+	}
+}
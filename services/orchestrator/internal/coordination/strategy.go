@@ -0,0 +1,120 @@
+package coordination
+
+import (
+	"fmt"
+	"time"
+)
+
+// ResolutionStrategy decides which recommendation wins a conflict
+type ResolutionStrategy string
+
+const (
+	// StrategyPriority prefers priority, then savings, confidence and risk
+	StrategyPriority ResolutionStrategy = "priority"
+	// StrategySavings prefers the larger estimated savings first
+	StrategySavings ResolutionStrategy = "savings"
+	// StrategyConfidence prefers the more confident recommendation first
+	StrategyConfidence ResolutionStrategy = "confidence"
+	// StrategyLowestRisk prefers the safer recommendation first
+	StrategyLowestRisk ResolutionStrategy = "lowest_risk"
+)
+
+// criterion compares two recommendations, returning >0 if a should win,
+// <0 if b should win and 0 on a tie
+type criterion func(a, b *Recommendation) int
+
+var riskScores = map[RiskLevel]int{
+	RiskLevelLow:      1,
+	RiskLevelMedium:   2,
+	RiskLevelHigh:     3,
+	RiskLevelCritical: 4,
+}
+
+func byPriority(a, b *Recommendation) int {
+	return compareFloat(float64(a.Priority), float64(b.Priority))
+}
+
+func bySavings(a, b *Recommendation) int {
+	return compareFloat(a.EstimatedSavings, b.EstimatedSavings)
+}
+
+func byConfidence(a, b *Recommendation) int {
+	return compareFloat(a.Confidence, b.Confidence)
+}
+
+func byLowerRisk(a, b *Recommendation) int {
+	return riskScores[b.RiskLevel] - riskScores[a.RiskLevel]
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a > b:
+		return 1
+	case a < b:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// strategyCriteria lists each strategy's tie-breaking order
+var strategyCriteria = map[ResolutionStrategy][]criterion{
+	StrategyPriority:   {byPriority, bySavings, byConfidence, byLowerRisk},
+	StrategySavings:    {bySavings, byPriority, byConfidence, byLowerRisk},
+	StrategyConfidence: {byConfidence, byPriority, bySavings, byLowerRisk},
+	StrategyLowestRisk: {byLowerRisk, byPriority, bySavings, byConfidence},
+}
+
+// ParseResolutionStrategy validates a strategy name
+func ParseResolutionStrategy(name string) (ResolutionStrategy, error) {
+	strategy := ResolutionStrategy(name)
+	if _, ok := strategyCriteria[strategy]; !ok {
+		return "", fmt.Errorf("unknown resolution strategy %q", name)
+	}
+	return strategy, nil
+}
+
+// ResolutionPreview shows which recommendations survive under a strategy
+type ResolutionPreview struct {
+	Strategy  ResolutionStrategy `json:"strategy"`
+	Conflicts []Conflict         `json:"conflicts"`
+	Kept      []string           `json:"kept"`
+	Discarded []string           `json:"discarded"`
+	Expired   []string           `json:"expired,omitempty"`
+}
+
+// SetResolutionStrategy sets the strategy used to resolve conflicts
+func (c *Coordinator) SetResolutionStrategy(strategy ResolutionStrategy) {
+	c.conflictResolver.strategy = strategy
+}
+
+// PreviewResolution runs conflict detection and resolution under the given
+// strategy without requesting approvals or creating plans
+func (c *Coordinator) PreviewResolution(recommendations []*Recommendation, strategy ResolutionStrategy) *ResolutionPreview {
+	active, expired := filterExpired(recommendations, time.Now())
+	conflicts := c.conflictDetector.DetectConflicts(active)
+
+	resolver := NewConflictResolver()
+	resolver.strategy = strategy
+	kept, resolved := resolver.ResolveConflicts(active, conflicts)
+
+	keptIDs := make(map[string]bool, len(kept))
+	preview := &ResolutionPreview{
+		Strategy:  strategy,
+		Conflicts: resolved,
+		Kept:      make([]string, 0, len(kept)),
+		Discarded: make([]string, 0),
+		Expired:   expired,
+	}
+	for _, rec := range kept {
+		keptIDs[rec.ID] = true
+		preview.Kept = append(preview.Kept, rec.ID)
+	}
+	for _, rec := range active {
+		if !keptIDs[rec.ID] {
+			preview.Discarded = append(preview.Discarded, rec.ID)
+		}
+	}
+
+	return preview
+}
@@ -0,0 +1,70 @@
+package coordination
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// rivalRecs returns two recommendations conflicting over node-1: rec-bold
+// saves more at higher risk, rec-safe saves less at low risk
+func rivalRecs() []*Recommendation {
+	bold := lowRiskRec("rec-bold", "migrate_to_spot", "node-1")
+	bold.EstimatedSavings = 900
+	bold.RiskLevel = RiskLevelHigh
+	safe := lowRiskRec("rec-safe", "right_size", "node-1")
+	safe.EstimatedSavings = 200
+	return []*Recommendation{bold, safe}
+}
+
+func previewResolution(t *testing.T, c *Coordinator, strategy string) *ResolutionPreview {
+	t.Helper()
+	w := doJSON(t, newTestHandler(c), http.MethodPost, "/coordination/resolve/preview?strategy="+strategy,
+		GraphRequest{Recommendations: rivalRecs()})
+	if w.Code != http.StatusOK {
+		t.Fatalf("preview %s: status %d: %s", strategy, w.Code, w.Body.String())
+	}
+	var preview ResolutionPreview
+	if err := json.Unmarshal(w.Body.Bytes(), &preview); err != nil {
+		t.Fatal(err)
+	}
+	return &preview
+}
+
+func TestPreviewResolutionAcrossStrategies(t *testing.T) {
+	c := newTestCoordinator(t, succeedingRunner)
+
+	tests := []struct {
+		strategy        string
+		kept, discarded string
+	}{
+		{"savings", "rec-bold", "rec-safe"},
+		{"lowest_risk", "rec-safe", "rec-bold"},
+	}
+	for _, tt := range tests {
+		preview := previewResolution(t, c, tt.strategy)
+		if string(preview.Strategy) != tt.strategy || len(preview.Conflicts) == 0 {
+			t.Errorf("%s: strategy %s with %d conflicts", tt.strategy, preview.Strategy, len(preview.Conflicts))
+		}
+		if strings.Join(preview.Kept, ",") != tt.kept || strings.Join(preview.Discarded, ",") != tt.discarded {
+			t.Errorf("%s: kept %v, discarded %v; want %s kept", tt.strategy, preview.Kept, preview.Discarded, tt.kept)
+		}
+	}
+
+	// Previews create nothing
+	if approvals := c.GetPendingApprovals(""); len(approvals) != 0 {
+		t.Errorf("preview created %d approvals", len(approvals))
+	}
+	if rec := c.getBufferedRecommendation("rec-bold"); rec != nil {
+		t.Error("preview buffered a recommendation")
+	}
+}
+
+func TestPreviewRejectsUnknownStrategy(t *testing.T) {
+	w := doJSON(t, newTestHandler(newTestCoordinator(t, succeedingRunner)), http.MethodPost,
+		"/coordination/resolve/preview?strategy=coin_flip", GraphRequest{Recommendations: rivalRecs()})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", w.Code)
+	}
+}
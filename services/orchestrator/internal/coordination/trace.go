@@ -0,0 +1,103 @@
+package coordination
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrCoordinationNotFound is returned when no history is held for a
+// coordination run
+var ErrCoordinationNotFound = errors.New("coordination not found")
+
+// CoordinationTrace links a coordination run to the approvals, plans and
+// step tasks created from its recommendations
+type CoordinationTrace struct {
+	CorrelationID   string                 `json:"correlation_id"`
+	CustomerID      string                 `json:"customer_id"`
+	Recommendations []*RecommendationTrace `json:"recommendations"`
+}
+
+// RecommendationTrace is one recommendation's outcome in the run with its
+// approvals, step approvals included, and execution plans. Steps carry the
+// approval and router task that gated and ran them.
+type RecommendationTrace struct {
+	RecommendationOutcome
+	Approvals []*Approval      `json:"approvals"`
+	Plans     []*ExecutionPlan `json:"plans"`
+}
+
+// Trace returns the linked tree of a coordination run. Approvals and plans
+// appear while the coordinator still holds them.
+func (c *Coordinator) Trace(coordinationID string) (*CoordinationTrace, error) {
+	outcomes := c.History("", coordinationID)
+	if len(outcomes) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrCoordinationNotFound, coordinationID)
+	}
+
+	trace := &CoordinationTrace{
+		CorrelationID:   coordinationID,
+		CustomerID:      outcomes[0].CustomerID,
+		Recommendations: make([]*RecommendationTrace, 0, len(outcomes)),
+	}
+	byRecommendation := make(map[string]*RecommendationTrace, len(outcomes))
+	for _, outcome := range outcomes {
+		rt := &RecommendationTrace{
+			RecommendationOutcome: outcome,
+			Approvals:             make([]*Approval, 0),
+			Plans:                 make([]*ExecutionPlan, 0),
+		}
+		trace.Recommendations = append(trace.Recommendations, rt)
+		byRecommendation[outcome.RecommendationID] = rt
+	}
+
+	for _, approval := range c.approvalManager.approvalsFor(coordinationID) {
+		if rt, ok := byRecommendation[approval.RecommendationID]; ok {
+			rt.Approvals = append(rt.Approvals, approval)
+		}
+	}
+	for _, plan := range c.executionOrch.plansFor(coordinationID) {
+		if rt, ok := byRecommendation[plan.RecommendationID]; ok {
+			rt.Plans = append(rt.Plans, plan)
+		}
+	}
+
+	for _, rt := range trace.Recommendations {
+		sort.Slice(rt.Approvals, func(i, j int) bool {
+			return rt.Approvals[i].RequestedAt.Before(rt.Approvals[j].RequestedAt)
+		})
+		sort.Slice(rt.Plans, func(i, j int) bool {
+			return rt.Plans[i].CreatedAt.Before(rt.Plans[j].CreatedAt)
+		})
+	}
+
+	return trace, nil
+}
+
+// approvalsFor returns copies of the approvals carrying a correlation ID
+func (am *ApprovalManager) approvalsFor(correlationID string) []*Approval {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	var approvals []*Approval
+	for _, approval := range am.approvals {
+		if approval.CorrelationID == correlationID {
+			approvals = append(approvals, approval.snapshot())
+		}
+	}
+	return approvals
+}
+
+// plansFor returns copies of the plans carrying a correlation ID
+func (eo *ExecutionOrchestrator) plansFor(correlationID string) []*ExecutionPlan {
+	eo.mu.RLock()
+	defer eo.mu.RUnlock()
+
+	var plans []*ExecutionPlan
+	for _, plan := range eo.plans {
+		if plan.CorrelationID == correlationID {
+			plans = append(plans, plan.snapshot())
+		}
+	}
+	return plans
+}
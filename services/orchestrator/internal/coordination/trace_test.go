@@ -0,0 +1,83 @@
+package coordination
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"optiinfra/services/orchestrator/internal/task"
+)
+
+func TestTraceLinksRecommendationToTasks(t *testing.T) {
+	router, c := newStepRouter(t)
+	router.Start()
+
+	executed := lowRiskRec("rec-1", "right_size", "node-1")
+	pending := lowRiskRec("rec-2", "right_size", "node-2")
+	pending.RiskLevel = RiskLevelHigh
+	resp, err := c.Coordinate(&CoordinationRequest{
+		CustomerID:      "cust-1",
+		Recommendations: []*Recommendation{executed, pending},
+		AutoApprove:     true,
+		ExecuteNow:      true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.ExecutionPlans) != 1 {
+		t.Fatalf("execution plans = %d, want 1", len(resp.ExecutionPlans))
+	}
+	waitForPlanStatus(t, c, resp.ExecutionPlans[0].ID, ExecutionStatusCompleted)
+
+	w := doJSON(t, newTestHandler(c), http.MethodGet, "/coordination/"+resp.ID+"/trace", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("trace: status %d: %s", w.Code, w.Body.String())
+	}
+	var trace CoordinationTrace
+	if err := json.Unmarshal(w.Body.Bytes(), &trace); err != nil {
+		t.Fatal(err)
+	}
+	if trace.CorrelationID != resp.ID || trace.CustomerID != "cust-1" || len(trace.Recommendations) != 2 {
+		t.Fatalf("trace %s for %s with %d recommendations", trace.CorrelationID, trace.CustomerID, len(trace.Recommendations))
+	}
+	byID := make(map[string]*RecommendationTrace)
+	for _, rt := range trace.Recommendations {
+		byID[rt.RecommendationID] = rt
+	}
+
+	// The executed recommendation leads through its approval and plan to the
+	// router task that ran its step
+	rt := byID["rec-1"]
+	if rt == nil || len(rt.Approvals) != 1 || len(rt.Plans) != 1 || len(rt.Plans[0].Steps) != 1 {
+		t.Fatalf("rec-1 trace = %+v, want one approval and a one-step plan", rt)
+	}
+	if rt.Approvals[0].CorrelationID != resp.ID || rt.Plans[0].CorrelationID != resp.ID {
+		t.Errorf("approval correlation %q, plan correlation %q, want %s", rt.Approvals[0].CorrelationID, rt.Plans[0].CorrelationID, resp.ID)
+	}
+	step := rt.Plans[0].Steps[0]
+	if step.TaskID == "" || step.CorrelationID != resp.ID {
+		t.Fatalf("step task %q with correlation %q, want a task correlated to %s", step.TaskID, step.CorrelationID, resp.ID)
+	}
+	tasks, err := router.ListTasks("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stepTask *task.Task
+	for _, tk := range tasks {
+		if tk.ID == step.TaskID {
+			stepTask = tk
+		}
+	}
+	if stepTask == nil || stepTask.Metadata[task.CorrelationIDKey] != resp.ID || stepTask.Status != task.TaskStatusCompleted {
+		t.Errorf("router task %s = %+v, want completed and correlated to %s", step.TaskID, stepTask, resp.ID)
+	}
+
+	// The pending recommendation has its approval and no plan yet
+	if rt := byID["rec-2"]; rt == nil || len(rt.Approvals) != 1 || rt.Approvals[0].Status != ApprovalStatusPending || len(rt.Plans) != 0 {
+		t.Errorf("rec-2 trace = %+v, want a pending approval and no plans", rt)
+	}
+
+	if w := doJSON(t, newTestHandler(c), http.MethodGet, "/coordination/missing/trace", nil); w.Code != http.StatusNotFound {
+		t.Errorf("unknown coordination: status %d, want 404", w.Code)
+	}
+}
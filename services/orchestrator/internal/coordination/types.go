@@ -1,6 +1,8 @@
 package coordination
 
 import (
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -24,6 +26,21 @@ const (
 	RiskLevelCritical RiskLevel = "critical"
 )
 
+// RiskLevels lists every risk level, lowest first
+var RiskLevels = []RiskLevel{RiskLevelLow, RiskLevelMedium, RiskLevelHigh, RiskLevelCritical}
+
+// ParseRiskLevel validates a risk level name
+func ParseRiskLevel(name string) (RiskLevel, error) {
+	valid := make([]string, len(RiskLevels))
+	for i, level := range RiskLevels {
+		if string(level) == name {
+			return level, nil
+		}
+		valid[i] = string(level)
+	}
+	return "", fmt.Errorf("unknown risk level %q (valid: %s)", name, strings.Join(valid, ", "))
+}
+
 // ApprovalStatus represents the approval state
 type ApprovalStatus string
 
@@ -38,13 +55,31 @@ const (
 type ExecutionStatus string
 
 const (
-	ExecutionStatusPending    ExecutionStatus = "pending"
-	ExecutionStatusRunning    ExecutionStatus = "running"
-	ExecutionStatusCompleted  ExecutionStatus = "completed"
-	ExecutionStatusFailed     ExecutionStatus = "failed"
-	ExecutionStatusRolledBack ExecutionStatus = "rolled_back"
+	ExecutionStatusPending     ExecutionStatus = "pending"
+	ExecutionStatusRunning     ExecutionStatus = "running"
+	ExecutionStatusCompleted   ExecutionStatus = "completed"
+	ExecutionStatusFailed      ExecutionStatus = "failed"
+	ExecutionStatusRolledBack  ExecutionStatus = "rolled_back"
+	ExecutionStatusDeferred    ExecutionStatus = "deferred"
+	ExecutionStatusPaused      ExecutionStatus = "paused"
+	ExecutionStatusInterrupted ExecutionStatus = "interrupted"
+
+	// Plan never ran because its coordination was cancelled
+	ExecutionStatusCancelled ExecutionStatus = "cancelled"
+
+	// Plan is waiting for sign-off on its next step
+	ExecutionStatusAwaitingApproval ExecutionStatus = "awaiting_approval"
+
+	// Step did not run because its condition was not met
+	ExecutionStatusSkipped ExecutionStatus = "skipped"
 )
 
+// finished reports whether a plan in this status will not run again
+func (s ExecutionStatus) finished() bool {
+	return s == ExecutionStatusCompleted || s == ExecutionStatusFailed || s == ExecutionStatusRolledBack ||
+		s == ExecutionStatusCancelled
+}
+
 // ConflictType represents the type of conflict
 type ConflictType string
 
@@ -77,20 +112,37 @@ type Recommendation struct {
 	ExpiresAt         *time.Time             `json:"expires_at,omitempty"`
 	Status            string                 `json:"status"`
 	Metadata          map[string]interface{} `json:"metadata,omitempty"`
+
+	// Steps of the execution plan that each need a separate approval
+	StepApproval *StepApproval `json:"step_approval,omitempty"`
+
+	// Conditions on plan steps, keyed by step action
+	StepConditions map[string]*StepCondition `json:"step_conditions,omitempty"`
+
+	// Approval recording how the recommendation was approved or gated
+	ApprovalID string `json:"approval_id,omitempty"`
+
+	// Reach within the coordinated batch, set by Coordinate
+	BlastRadius *BlastRadius `json:"blast_radius,omitempty"`
+
+	// ID of the coordination run that kept the recommendation, carried by
+	// its approvals, plans and step tasks
+	CorrelationID string `json:"correlation_id,omitempty"`
 }
 
 // Conflict represents a conflict between recommendations
 type Conflict struct {
-	ID               string       `json:"id"`
-	Type             ConflictType `json:"type"`
-	Recommendations  []string     `json:"recommendation_ids"` // IDs of conflicting recommendations
-	Description      string       `json:"description"`
-	Severity         string       `json:"severity"` // low, medium, high
-	ConflictingField string       `json:"conflicting_field"`
-	DetectedAt       time.Time    `json:"detected_at"`
-	Resolved         bool         `json:"resolved"`
-	Resolution       string       `json:"resolution,omitempty"`
-	ResolvedAt       *time.Time   `json:"resolved_at,omitempty"`
+	ID               string         `json:"id"`
+	Type             ConflictType   `json:"type"`
+	Types            []ConflictType `json:"types,omitempty"`    // Every type found for the pair, when compacted
+	Recommendations  []string       `json:"recommendation_ids"` // IDs of conflicting recommendations
+	Description      string         `json:"description"`
+	Severity         string         `json:"severity"` // low, medium, high, critical
+	ConflictingField string         `json:"conflicting_field"`
+	DetectedAt       time.Time      `json:"detected_at"`
+	Resolved         bool           `json:"resolved"`
+	Resolution       string         `json:"resolution,omitempty"`
+	ResolvedAt       *time.Time     `json:"resolved_at,omitempty"`
 }
 
 // Approval represents an approval request for a recommendation
@@ -109,6 +161,38 @@ type Approval struct {
 	RejectionReason  string         `json:"rejection_reason,omitempty"`
 	ExpiresAt        time.Time      `json:"expires_at"`
 	Notes            string         `json:"notes,omitempty"`
+
+	// Set when the approval gates a single step of an execution plan
+	PlanID string `json:"plan_id,omitempty"`
+	StepID string `json:"step_id,omitempty"`
+
+	// Parameter overrides applied when approved with modifications
+	Modifications map[string]ParameterChange `json:"modifications,omitempty"`
+
+	// Policy rule that auto-approved the recommendation or required approval
+	Rule string `json:"rule,omitempty"`
+
+	// Coordination run the recommendation came from
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+// ApprovalExplanation describes which policy rule decided an approval
+type ApprovalExplanation struct {
+	ApprovalID       string         `json:"approval_id"`
+	RecommendationID string         `json:"recommendation_id"`
+	PlanID           string         `json:"plan_id,omitempty"`
+	StepID           string         `json:"step_id,omitempty"`
+	RiskLevel        RiskLevel      `json:"risk_level"`
+	AutoApproved     bool           `json:"auto_approved"`
+	Rule             string         `json:"rule"`
+	Status           ApprovalStatus `json:"status"`
+	DecidedBy        string         `json:"decided_by,omitempty"`
+}
+
+// ParameterChange records an approver's override of a recommendation parameter
+type ParameterChange struct {
+	Original interface{} `json:"original"`
+	Modified interface{} `json:"modified"`
 }
 
 // ExecutionStep represents a single step in an execution plan
@@ -116,6 +200,7 @@ type ExecutionStep struct {
 	ID           string                 `json:"id"`
 	Action       string                 `json:"action"`
 	AgentID      string                 `json:"agent_id"`
+	AgentType    string                 `json:"agent_type,omitempty"`
 	Parameters   map[string]interface{} `json:"parameters"`
 	Critical     bool                   `json:"critical"`      // If true, failure causes rollback
 	Reversible   bool                   `json:"reversible"`    // Can this step be rolled back?
@@ -126,6 +211,22 @@ type ExecutionStep struct {
 	CompletedAt  *time.Time             `json:"completed_at,omitempty"`
 	Duration     int                    `json:"duration_ms"`
 	RollbackData map[string]interface{} `json:"rollback_data,omitempty"` // Data needed for rollback
+
+	// Step-by-step sign-off: the plan stops before this step until approved
+	RequiresApproval bool   `json:"requires_approval,omitempty"`
+	ApprovalID       string `json:"approval_id,omitempty"`
+
+	// Run only if an earlier step's result meets this condition
+	Condition  *StepCondition `json:"condition,omitempty"`
+	SkipReason string         `json:"skip_reason,omitempty"`
+
+	// Coordination run of the plan, and the router task that ran the step
+	CorrelationID string `json:"correlation_id,omitempty"`
+	TaskID        string `json:"task_id,omitempty"`
+	// Outcome of the step in its plan's rollback
+	RollbackStatus RollbackStatus `json:"rollback_status,omitempty"`
+	RollbackError  string         `json:"rollback_error,omitempty"`
+	RolledBackAt   *time.Time     `json:"rolled_back_at,omitempty"`
 }
 
 // ExecutionPlan represents a multi-step execution plan
@@ -133,6 +234,8 @@ type ExecutionPlan struct {
 	ID               string                 `json:"id"`
 	RecommendationID string                 `json:"recommendation_id"`
 	CustomerID       string                 `json:"customer_id"`
+	RequestedBy      string                 `json:"requested_by,omitempty"` // Agent that produced the recommendation
+	RiskLevel        RiskLevel              `json:"risk_level,omitempty"`
 	Steps            []ExecutionStep        `json:"steps"`
 	Status           ExecutionStatus        `json:"status"`
 	CurrentStep      int                    `json:"current_step"`
@@ -142,6 +245,12 @@ type ExecutionPlan struct {
 	RolledBackAt     *time.Time             `json:"rolled_back_at,omitempty"`
 	TotalDuration    int                    `json:"total_duration_ms"`
 	Metadata         map[string]interface{} `json:"metadata,omitempty"`
+	CorrelationID    string                 `json:"correlation_id,omitempty"` // Coordination run of the recommendation
+	ProjectedImpact  *ProjectedImpact       `json:"projected_impact,omitempty"`
+
+	// Share of steps finished and projected end, from average step duration
+	ProgressPercent     float64    `json:"progress_percent"`
+	EstimatedCompletion *time.Time `json:"estimated_completion,omitempty"`
 }
 
 // CoordinationRequest represents a request to coordinate multiple recommendations
@@ -150,20 +259,40 @@ type CoordinationRequest struct {
 	Recommendations []*Recommendation `json:"recommendations" binding:"required"`
 	AutoApprove     bool              `json:"auto_approve"`      // Auto-approve low-risk items
 	ExecuteNow      bool              `json:"execute_now"`       // Execute immediately after approval
+
+	// Recommendations saving less are dropped, unless a kept one depends on them
+	MinSavings float64 `json:"min_savings,omitempty"`
+}
+
+// MissingAgents lists the step actions of a kept recommendation that no
+// registered agent can execute
+type MissingAgents struct {
+	RecommendationID string   `json:"recommendation_id"`
+	Actions          []string `json:"actions"`
 }
 
 // CoordinationResponse represents the result of coordination
 type CoordinationResponse struct {
-	ID                   string            `json:"id"`
-	TotalRecommendations int               `json:"total_recommendations"`
-	ConflictsDetected    int               `json:"conflicts_detected"`
-	ConflictsResolved    int               `json:"conflicts_resolved"`
-	RecommendationsKept  int               `json:"recommendations_kept"`
-	ApprovalsRequired    int               `json:"approvals_required"`
-	AutoApproved         int               `json:"auto_approved"`
-	Conflicts            []Conflict        `json:"conflicts,omitempty"`
-	Recommendations      []*Recommendation `json:"recommendations"`
-	Approvals            []Approval        `json:"approvals"`
-	ExecutionPlans       []ExecutionPlan   `json:"execution_plans,omitempty"`
-	CreatedAt            time.Time         `json:"created_at"`
+	ID                     string            `json:"id"`
+	TotalRecommendations   int               `json:"total_recommendations"`
+	ConflictsDetected      int               `json:"conflicts_detected"`
+	ConflictsResolved      int               `json:"conflicts_resolved"`
+	RecommendationsKept    int               `json:"recommendations_kept"`
+	ApprovalsRequired      int               `json:"approvals_required"`
+	AutoApproved           int               `json:"auto_approved"`
+	Conflicts              []Conflict        `json:"conflicts,omitempty"`
+	Recommendations        []*Recommendation `json:"recommendations"`
+	Approvals              []Approval        `json:"approvals"`
+	ExecutionPlans         []ExecutionPlan   `json:"execution_plans,omitempty"`
+	ExpiredRecommendations []string          `json:"expired_recommendations,omitempty"`
+	FilteredOut            []*Recommendation `json:"filtered_out,omitempty"` // Below MinSavings
+	RequiresAgent          []MissingAgents   `json:"requires_agent,omitempty"`
+	CreatedAt              time.Time         `json:"created_at"`
+
+	// Kept recommendations by optimization goal
+	Groups map[RecommendationType]GroupSummary `json:"groups"`
+
+	// Identical recommendations collapsed into one, by surviving ID
+	DuplicatesMerged      int                 `json:"duplicates_merged,omitempty"`
+	MergedRecommendations map[string][]string `json:"merged_recommendations,omitempty"`
 }
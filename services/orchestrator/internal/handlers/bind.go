@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// strictJSONKey marks a request for strict decoding via StrictJSON
+const strictJSONKey = "strict_json"
+
+// strictJSONGlobal enables strict decoding for every route
+var strictJSONGlobal atomic.Bool
+
+// SetStrictJSON enables or disables strict decoding for all routes
+func SetStrictJSON(enabled bool) {
+	strictJSONGlobal.Store(enabled)
+}
+
+// StrictJSON enables strict decoding for the routes it is applied to
+func StrictJSON() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(strictJSONKey, true)
+		c.Next()
+	}
+}
+
+// UnknownFieldsError lists top-level JSON fields the target does not accept
+type UnknownFieldsError struct {
+	Fields []string
+}
+
+func (e *UnknownFieldsError) Error() string {
+	return fmt.Sprintf("unknown fields: %s", strings.Join(e.Fields, ", "))
+}
+
+// BindJSON decodes and validates the request body like ShouldBindJSON. In
+// strict mode, unknown top-level fields fail with an UnknownFieldsError
+// naming all of them.
+func BindJSON(c *gin.Context, obj interface{}) error {
+	if !strictJSONGlobal.Load() && !c.GetBool(strictJSONKey) {
+		return c.ShouldBindJSON(obj)
+	}
+
+	if c.Request.Body == nil {
+		return fmt.Errorf("request body is empty")
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return err
+	}
+
+	if unknown := unknownFields(body, obj); len(unknown) > 0 {
+		return &UnknownFieldsError{Fields: unknown}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(obj); err != nil {
+		return err
+	}
+	return binding.Validator.ValidateStruct(obj)
+}
+
+// unknownFields compares the body's top-level keys with the JSON names of
+// the target struct's fields; non-object bodies and targets are skipped
+func unknownFields(body []byte, obj interface{}) []string {
+	t := reflect.TypeOf(obj)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil
+	}
+
+	known := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		known[strings.ToLower(name)] = true
+	}
+
+	var unknown []string
+	for key := range raw {
+		// encoding/json matches field names case-insensitively
+		if !known[strings.ToLower(key)] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type bindRequest struct {
+	TaskType string `json:"task_type" binding:"required"`
+	Timeout  int    `json:"timeout_seconds"`
+	Internal string `json:"-"`
+}
+
+// bindRouter serves POST /lenient and POST /strict, the latter with
+// StrictJSON, answering 400 with the binding error or 200 with the request
+func bindRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handle := func(c *gin.Context) {
+		var req bindRequest
+		if err := BindJSON(c, &req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, req)
+	}
+	router.POST("/lenient", handle)
+	router.POST("/strict", StrictJSON(), handle)
+	return router
+}
+
+func postBody(router http.Handler, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+const typoBody = `{"task_type": "analyze_cost", "timout_seconds": 30, "Internal": "x", "extra": true}`
+
+func TestLenientBindingIgnoresUnknownFields(t *testing.T) {
+	rec := postBody(bindRouter(), "/lenient", typoBody)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var req bindRequest
+	if err := json.Unmarshal(rec.Body.Bytes(), &req); err != nil {
+		t.Fatal(err)
+	}
+	if req.TaskType != "analyze_cost" || req.Timeout != 0 {
+		t.Errorf("decoded %+v", req)
+	}
+}
+
+func TestStrictBindingListsUnknownFields(t *testing.T) {
+	rec := postBody(bindRouter(), "/strict", typoBody)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", rec.Code)
+	}
+	// Fields tagged "-" are not accepted either
+	if want := `unknown fields: Internal, extra, timout_seconds`; !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("body = %s, want %q", rec.Body.String(), want)
+	}
+
+	// Known fields still decode and validate, matching names case-insensitively
+	if rec := postBody(bindRouter(), "/strict", `{"Task_Type": "analyze_cost", "timeout_seconds": 30}`); rec.Code != http.StatusOK {
+		t.Errorf("valid body: status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := postBody(bindRouter(), "/strict", `{"timeout_seconds": 30}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("missing required field: status = %d, want 400", rec.Code)
+	}
+}
+
+func TestStrictBindingGlobally(t *testing.T) {
+	SetStrictJSON(true)
+	t.Cleanup(func() { SetStrictJSON(false) })
+
+	if rec := postBody(bindRouter(), "/lenient", typoBody); rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400 with strict decoding enabled globally", rec.Code)
+	}
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorStatuses maps classes of errors, matched with errors.Is, to the HTTP
+// status reported for them. An error should belong to at most one class.
+type ErrorStatuses map[error]int
+
+// Status returns the status of err's class, or 500 if it has none
+func (s ErrorStatuses) Status(err error) int {
+	for class, status := range s {
+		if errors.Is(err, class) {
+			return status
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+// APIError replies with err in the API's error shape, at the status its
+// class maps to
+func APIError(c *gin.Context, statuses ErrorStatuses, err error) {
+	c.JSON(statuses.Status(err), gin.H{"error": err.Error()})
+}
@@ -22,7 +22,7 @@ func HealthCheck(c *gin.Context) {
 	response := HealthResponse{
 		Status:    "healthy",
 		Timestamp: time.Now(),
-		Version:   "0.1.0",
+		Version:   Version,
 		Uptime:    uptime.String(),
 	}
 
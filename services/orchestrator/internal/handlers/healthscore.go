@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// Storage round trips at or below storageLatencyGood score 100, falling
+	// linearly to 0 at storageLatencyBad
+	storageLatencyGood = 10 * time.Millisecond
+	storageLatencyBad  = 500 * time.Millisecond
+
+	// Queue depth scoring 0 when the queue has no configured bound
+	unboundedBacklogBad = 1000
+
+	// Score thresholds for the reported status
+	healthyScore  = 80
+	degradedScore = 50
+)
+
+// HealthSignals are the measurements the composite health score is built from
+type HealthSignals struct {
+	StorageLatency time.Duration
+	StorageErr     error
+
+	HealthyAgents int
+	TotalAgents   int
+
+	// Failed tasks as a share of recently finished ones, 0 to 1
+	TaskFailureRate float64
+
+	QueueDepth    int
+	QueueCapacity int // 0 when unbounded
+}
+
+// HealthWeights sets how much each factor counts toward the composite score.
+// Weights are relative; they need not sum to anything.
+type HealthWeights struct {
+	Storage  float64 `json:"storage"`
+	Agents   float64 `json:"agents"`
+	Failures float64 `json:"failures"`
+	Backlog  float64 `json:"backlog"`
+}
+
+// DefaultHealthWeights returns the default factor weights
+func DefaultHealthWeights() HealthWeights {
+	return HealthWeights{Storage: 30, Agents: 30, Failures: 25, Backlog: 15}
+}
+
+// ParseHealthWeights parses overrides like "agents=50,backlog=5" on top of
+// the defaults
+func ParseHealthWeights(spec string) (HealthWeights, error) {
+	weights := DefaultHealthWeights()
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		w, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if !ok || err != nil || w < 0 {
+			return weights, fmt.Errorf("invalid health weight entry %q", entry)
+		}
+		switch name {
+		case "storage":
+			weights.Storage = w
+		case "agents":
+			weights.Agents = w
+		case "failures":
+			weights.Failures = w
+		case "backlog":
+			weights.Backlog = w
+		default:
+			return weights, fmt.Errorf("unknown health factor %q", name)
+		}
+	}
+	if weights.Storage+weights.Agents+weights.Failures+weights.Backlog == 0 {
+		return weights, fmt.Errorf("health weights must not all be zero")
+	}
+	return weights, nil
+}
+
+// HealthFactor is one factor's contribution to the composite score
+type HealthFactor struct {
+	Name   string  `json:"name"`
+	Score  float64 `json:"score"` // 0-100
+	Weight float64 `json:"weight"`
+	Detail string  `json:"detail"`
+}
+
+// HealthScore is the composite health of the orchestrator, 0 to 100
+type HealthScore struct {
+	Score     float64        `json:"score"`
+	Status    string         `json:"status"`
+	Factors   []HealthFactor `json:"factors"`
+	Timestamp time.Time      `json:"timestamp"`
+}
+
+// ComputeHealthScore combines the signals into a weighted score
+func ComputeHealthScore(signals HealthSignals, weights HealthWeights) HealthScore {
+	factors := []HealthFactor{
+		storageFactor(signals, weights.Storage),
+		agentsFactor(signals, weights.Agents),
+		{
+			Name:   "failures",
+			Score:  100 * (1 - clamp01(signals.TaskFailureRate)),
+			Weight: weights.Failures,
+			Detail: fmt.Sprintf("%.1f%% of recently finished tasks failed", 100*signals.TaskFailureRate),
+		},
+		backlogFactor(signals, weights.Backlog),
+	}
+
+	var total, weightSum float64
+	for _, f := range factors {
+		total += f.Score * f.Weight
+		weightSum += f.Weight
+	}
+	score := 0.0
+	if weightSum > 0 {
+		score = math.Round(total/weightSum*10) / 10
+	}
+
+	status := "unhealthy"
+	switch {
+	case score >= healthyScore:
+		status = "healthy"
+	case score >= degradedScore:
+		status = "degraded"
+	}
+
+	return HealthScore{Score: score, Status: status, Factors: factors, Timestamp: time.Now()}
+}
+
+func storageFactor(signals HealthSignals, weight float64) HealthFactor {
+	f := HealthFactor{Name: "storage", Weight: weight}
+	if signals.StorageErr != nil {
+		f.Detail = signals.StorageErr.Error()
+		return f
+	}
+	span := float64(storageLatencyBad - storageLatencyGood)
+	f.Score = 100 * (1 - clamp01(float64(signals.StorageLatency-storageLatencyGood)/span))
+	f.Detail = fmt.Sprintf("round trip %s", signals.StorageLatency.Round(time.Microsecond))
+	return f
+}
+
+func agentsFactor(signals HealthSignals, weight float64) HealthFactor {
+	f := HealthFactor{Name: "agents", Weight: weight}
+	f.Detail = fmt.Sprintf("%d of %d agents healthy", signals.HealthyAgents, signals.TotalAgents)
+	if signals.TotalAgents > 0 {
+		f.Score = 100 * float64(signals.HealthyAgents) / float64(signals.TotalAgents)
+	}
+	return f
+}
+
+func backlogFactor(signals HealthSignals, weight float64) HealthFactor {
+	f := HealthFactor{Name: "backlog", Weight: weight}
+	limit := signals.QueueCapacity
+	if limit <= 0 {
+		limit = unboundedBacklogBad
+		f.Detail = fmt.Sprintf("%d tasks queued", signals.QueueDepth)
+	} else {
+		f.Detail = fmt.Sprintf("%d of %d queue slots used", signals.QueueDepth, limit)
+	}
+	f.Score = 100 * (1 - clamp01(float64(signals.QueueDepth)/float64(limit)))
+	return f
+}
+
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+// HealthScoreHandler reports the composite health score from signals
+// collected on each request
+func HealthScoreHandler(collect func() HealthSignals, weights HealthWeights) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, ComputeHealthScore(collect(), weights))
+	}
+}
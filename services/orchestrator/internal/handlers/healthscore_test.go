@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func healthySignals() HealthSignals {
+	return HealthSignals{
+		StorageLatency: time.Millisecond,
+		HealthyAgents:  4,
+		TotalAgents:    4,
+		QueueDepth:     0,
+		QueueCapacity:  100,
+	}
+}
+
+func TestHealthScoreDropsWithUnhealthyAgents(t *testing.T) {
+	weights := DefaultHealthWeights()
+	healthy := ComputeHealthScore(healthySignals(), weights)
+	if healthy.Score != 100 || healthy.Status != "healthy" {
+		t.Fatalf("all-healthy score = %v (%s), want 100 (healthy)", healthy.Score, healthy.Status)
+	}
+
+	signals := healthySignals()
+	signals.HealthyAgents = 1
+	degraded := ComputeHealthScore(signals, weights)
+	if degraded.Score >= healthy.Score {
+		t.Errorf("score with 1 of 4 agents healthy = %v, want below %v", degraded.Score, healthy.Score)
+	}
+
+	signals.HealthyAgents = 0
+	if none := ComputeHealthScore(signals, weights); none.Score >= degraded.Score {
+		t.Errorf("score with no healthy agents = %v, want below %v", none.Score, degraded.Score)
+	}
+}
+
+func TestHealthScoreDropsAsFailuresRise(t *testing.T) {
+	weights := DefaultHealthWeights()
+	previous := ComputeHealthScore(healthySignals(), weights).Score
+	for _, rate := range []float64{0.1, 0.5, 1} {
+		signals := healthySignals()
+		signals.TaskFailureRate = rate
+		score := ComputeHealthScore(signals, weights).Score
+		if score >= previous {
+			t.Errorf("score at failure rate %v = %v, want below %v", rate, score, previous)
+		}
+		previous = score
+	}
+}
+
+func TestHealthScoreOtherFactors(t *testing.T) {
+	weights := DefaultHealthWeights()
+	base := ComputeHealthScore(healthySignals(), weights).Score
+
+	for name, mutate := range map[string]func(*HealthSignals){
+		"storage down": func(s *HealthSignals) { s.StorageErr = errors.New("connection refused") },
+		"storage slow": func(s *HealthSignals) { s.StorageLatency = time.Second },
+		"backlog full": func(s *HealthSignals) { s.QueueDepth = 100 },
+	} {
+		signals := healthySignals()
+		mutate(&signals)
+		if score := ComputeHealthScore(signals, weights).Score; score >= base {
+			t.Errorf("%s: score %v, want below %v", name, score, base)
+		}
+	}
+}
+
+func TestParseHealthWeights(t *testing.T) {
+	weights, err := ParseHealthWeights("agents=50, backlog=0")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := DefaultHealthWeights()
+	want.Agents, want.Backlog = 50, 0
+	if weights != want {
+		t.Errorf("weights = %+v, want %+v", weights, want)
+	}
+
+	// Only the agents factor counts
+	signals := healthySignals()
+	signals.HealthyAgents = 2
+	signals.StorageErr = errors.New("down")
+	agentsOnly := HealthWeights{Agents: 1}
+	if score := ComputeHealthScore(signals, agentsOnly).Score; score != 50 {
+		t.Errorf("agents-only score = %v, want 50", score)
+	}
+
+	for _, spec := range []string{"agents", "agents=-1", "latency=5", "storage=0,agents=0,failures=0,backlog=0"} {
+		if _, err := ParseHealthWeights(spec); err == nil {
+			t.Errorf("ParseHealthWeights(%q) accepted", spec)
+		}
+	}
+}
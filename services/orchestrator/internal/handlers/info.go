@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Version is the orchestrator release, overridden at build time with
+// -ldflags "-X optiinfra/services/orchestrator/internal/handlers.Version=1.2.3"
+var Version = "0.1.0"
+
+// ServiceInfo identifies the running service on the root route
+type ServiceInfo struct {
+	Service   string `json:"service"`
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	GoVersion string `json:"go_version,omitempty"`
+}
+
+// BuildInfo describes the named service from Version and the VCS revision
+// and Go version embedded in the binary, when available
+func BuildInfo(service string) ServiceInfo {
+	info := ServiceInfo{Service: service, Version: Version}
+
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	info.GoVersion = build.GoVersion
+	for _, setting := range build.Settings {
+		if setting.Key == "vcs.revision" {
+			info.Commit = setting.Value
+		}
+	}
+	return info
+}
+
+// Root reports the service info
+func Root(info ServiceInfo) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, info)
+	}
+}
+
+// NotFound replies 404 in the API's error shape for unknown routes
+func NotFound(c *gin.Context) {
+	c.JSON(http.StatusNotFound, gin.H{
+		"error": fmt.Sprintf("no route for %s %s", c.Request.Method, c.Request.URL.Path),
+	})
+}
+
+// MethodNotAllowed replies 405 in the API's error shape for known routes
+// requested with the wrong method. Gin only calls it when the engine's
+// HandleMethodNotAllowed is set.
+func MethodNotAllowed(c *gin.Context) {
+	c.JSON(http.StatusMethodNotAllowed, gin.H{
+		"error": fmt.Sprintf("method %s not allowed for %s", c.Request.Method, c.Request.URL.Path),
+	})
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// infoRouter serves the root route and GET /health with the JSON 404 and 405
+// handlers, as the server wires them
+func infoRouter(info ServiceInfo) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.HandleMethodNotAllowed = true
+	router.NoRoute(NotFound)
+	router.NoMethod(MethodNotAllowed)
+	router.GET("/", Root(info))
+	router.GET("/health", HealthCheck)
+	return router
+}
+
+func serve(router http.Handler, method, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	return rec
+}
+
+func TestUnknownRoutesAnswerInErrorShape(t *testing.T) {
+	router := infoRouter(BuildInfo("orchestrator"))
+	tests := []struct {
+		method, path string
+		status       int
+		message      string
+	}{
+		{http.MethodGet, "/no-such-route", http.StatusNotFound, "no route for GET /no-such-route"},
+		{http.MethodPost, "/tasks/abc/unknown", http.StatusNotFound, "no route for POST /tasks/abc/unknown"},
+		{http.MethodDelete, "/health", http.StatusMethodNotAllowed, "method DELETE not allowed for /health"},
+		{http.MethodPost, "/", http.StatusMethodNotAllowed, "method POST not allowed for /"},
+	}
+	for _, tt := range tests {
+		rec := serve(router, tt.method, tt.path)
+		if rec.Code != tt.status {
+			t.Errorf("%s %s: status %d, want %d", tt.method, tt.path, rec.Code, tt.status)
+		}
+		if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+			t.Errorf("%s %s: content type %q, want JSON", tt.method, tt.path, ct)
+		}
+		var body map[string]string
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body["error"] != tt.message || len(body) != 1 {
+			t.Errorf("%s %s: body %s, want error %q", tt.method, tt.path, rec.Body.String(), tt.message)
+		}
+	}
+}
+
+func TestRootReportsBuildInfo(t *testing.T) {
+	previous := Version
+	Version = "1.2.3"
+	t.Cleanup(func() { Version = previous })
+
+	info := BuildInfo("optimizer")
+	if info.Service != "optimizer" || info.Version != "1.2.3" || info.GoVersion == "" {
+		t.Errorf("build info = %+v", info)
+	}
+
+	rec := serve(infoRouter(info), http.MethodGet, "/")
+	var got ServiceInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || rec.Code != http.StatusOK || got != info {
+		t.Errorf("root: status %d, body %s, want %+v", rec.Code, rec.Body.String(), info)
+	}
+
+	// The health check reports the same version
+	var health HealthResponse
+	json.Unmarshal(serve(infoRouter(info), http.MethodGet, "/health").Body.Bytes(), &health)
+	if health.Version != "1.2.3" {
+		t.Errorf("health version = %q, want 1.2.3", health.Version)
+	}
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BodyLimit rejects request bodies larger than maxBytes with 413. Bodies
+// without a declared length are capped by http.MaxBytesReader, which fails
+// the handler's read once the limit is passed.
+func BodyLimit(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > maxBytes {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "request body too large"})
+			return
+		}
+
+		if c.Request.Body != nil {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+		}
+		c.Next()
+	}
+}
+
+// WithTimeout bounds each request to timeout, cancelling its context and
+// replying 503 when exceeded. Requests whose path starts with one of the
+// exempt prefixes (streaming endpoints) are passed through untouched, since
+// the timeout handler buffers responses and cannot flush. A "*" segment in a
+// prefix matches any one path segment, as in "/tasks/*/stream".
+func WithTimeout(h http.Handler, timeout time.Duration, exemptPrefixes ...string) http.Handler {
+	limited := http.TimeoutHandler(h, timeout, `{"error":"request timed out"}`)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, prefix := range exemptPrefixes {
+			if hasPathPrefix(r.URL.Path, prefix) {
+				h.ServeHTTP(w, r)
+				return
+			}
+		}
+		limited.ServeHTTP(w, r)
+	})
+}
+
+// hasPathPrefix reports whether path starts with prefix, matching "*"
+// segments of prefix against any single segment of path
+func hasPathPrefix(path, prefix string) bool {
+	if !strings.Contains(prefix, "*") {
+		return strings.HasPrefix(path, prefix)
+	}
+
+	pathSegments := strings.Split(path, "/")
+	prefixSegments := strings.Split(prefix, "/")
+	if len(pathSegments) < len(prefixSegments) {
+		return false
+	}
+	for i, segment := range prefixSegments {
+		if segment != "*" && segment != pathSegments[i] {
+			return false
+		}
+	}
+	return true
+}
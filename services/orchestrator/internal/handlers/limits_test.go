@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// limitedRouter serves POST /echo, which reads the whole body, behind a
+// BodyLimit of maxBytes
+func limitedRouter(maxBytes int64) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(BodyLimit(maxBytes))
+	router.POST("/echo", func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+			return
+		}
+		c.String(http.StatusOK, "%d", len(body))
+	})
+	return router
+}
+
+func TestBodyLimit(t *testing.T) {
+	router := limitedRouter(16)
+	for name, tc := range map[string]struct {
+		body          string
+		unknownLength bool
+		want          int
+	}{
+		"within limit":            {body: "0123456789", want: http.StatusOK},
+		"at limit":                {body: strings.Repeat("x", 16), want: http.StatusOK},
+		"declared oversized":      {body: strings.Repeat("x", 17), want: http.StatusRequestEntityTooLarge},
+		"undeclared oversized":    {body: strings.Repeat("x", 64), unknownLength: true, want: http.StatusRequestEntityTooLarge},
+		"undeclared within limit": {body: "0123456789", unknownLength: true, want: http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(tc.body))
+		if tc.unknownLength {
+			req.ContentLength = -1
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("%s: status %d, want %d", name, rec.Code, tc.want)
+		}
+	}
+}
+
+func TestWithTimeoutAbortsSlowHandler(t *testing.T) {
+	cancelled := make(chan struct{})
+	h := WithTimeout(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+			if errors.Is(r.Context().Err(), context.DeadlineExceeded) {
+				close(cancelled)
+			}
+		case <-time.After(5 * time.Second):
+			w.WriteHeader(http.StatusOK)
+		}
+	}), 20*time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/coordination/coordinate", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status %d, want 503", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "request timed out") {
+		t.Errorf("body = %q", rec.Body.String())
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("handler context not cancelled at the deadline")
+	}
+}
+
+func TestHasPathPrefix(t *testing.T) {
+	tests := []struct {
+		path, prefix string
+		want         bool
+	}{
+		{"/metrics", "/metrics", true},
+		{"/coordination/history/export", "/coordination/history", true},
+		{"/tasks/abc/stream", "/tasks/*/stream", true},
+		{"/tasks/abc/stream/more", "/tasks/*/stream", true},
+		{"/tasks/abc", "/tasks/*/stream", false},
+		{"/tasks/abc/events", "/tasks/*/stream", false},
+		{"/agents/abc/stream", "/tasks/*/stream", false},
+	}
+	for _, tt := range tests {
+		if got := hasPathPrefix(tt.path, tt.prefix); got != tt.want {
+			t.Errorf("hasPathPrefix(%q, %q) = %v, want %v", tt.path, tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestWithTimeoutExemptsStreams(t *testing.T) {
+	h := WithTimeout(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Only the unwrapped writer can flush
+		if _, ok := w.(http.Flusher); !ok {
+			w.WriteHeader(http.StatusTeapot)
+		}
+	}), time.Second, "/tasks/*/stream")
+
+	for path, want := range map[string]int{
+		"/tasks/abc/stream": http.StatusOK,
+		"/tasks/abc":        http.StatusTeapot,
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != want {
+			t.Errorf("%s: status %d, want %d", path, rec.Code, want)
+		}
+	}
+}
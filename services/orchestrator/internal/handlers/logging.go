@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// redactedValue replaces sensitive values in request logs
+const redactedValue = "[REDACTED]"
+
+// maxLoggedBody caps how much of a request body is captured for logging
+const maxLoggedBody = 4 << 10
+
+// loggedBodyKey holds the redacted request body for the log formatter
+const loggedBodyKey = "logged_body"
+
+// DefaultRedactedFields are always masked in request logs
+var DefaultRedactedFields = []string{
+	"Authorization", "Cookie", "X-Admin-Token", "X-Agent-Token",
+	"token", "password", "secret", "api_key",
+}
+
+// LogRedactor masks sensitive request fields before they are logged. Names
+// match case-insensitively against headers, query parameters and JSON body
+// fields at any depth.
+type LogRedactor struct {
+	fields map[string]bool
+}
+
+// NewLogRedactor masks the default fields plus the given ones
+func NewLogRedactor(fields ...string) *LogRedactor {
+	r := &LogRedactor{fields: make(map[string]bool)}
+	for _, field := range append(append([]string(nil), DefaultRedactedFields...), fields...) {
+		if field = strings.ToLower(strings.TrimSpace(field)); field != "" {
+			r.fields[field] = true
+		}
+	}
+	return r
+}
+
+func (r *LogRedactor) redacts(name string) bool {
+	return r.fields[strings.ToLower(name)]
+}
+
+// Query returns a raw query string with the values of sensitive parameters
+// masked
+func (r *LogRedactor) Query(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return redactedValue
+	}
+	for key := range values {
+		if r.redacts(key) {
+			for i := range values[key] {
+				values[key][i] = redactedValue
+			}
+		}
+	}
+	return values.Encode()
+}
+
+// Body returns a JSON body with the values of sensitive fields masked, or
+// false if it is not a JSON object or array
+func (r *LogRedactor) Body(body []byte) (string, bool) {
+	var decoded interface{}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return "", false
+	}
+	switch decoded.(type) {
+	case map[string]interface{}, []interface{}:
+	default:
+		return "", false
+	}
+	redacted, err := json.Marshal(r.value(decoded))
+	if err != nil {
+		return "", false
+	}
+	return string(redacted), true
+}
+
+// Map returns a copy of m with the values of sensitive keys masked at any
+// depth
+func (r *LogRedactor) Map(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	return r.value(m).(map[string]interface{})
+}
+
+func (r *LogRedactor) value(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			if r.redacts(key) {
+				out[key] = redactedValue
+			} else {
+				out[key] = r.value(item)
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = r.value(item)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+// RequestLogConfig configures RequestLogger
+type RequestLogConfig struct {
+	Redactor *LogRedactor
+
+	// Request headers included in each log line, masked if sensitive
+	Headers []string
+
+	// Include JSON request bodies, masked, up to maxLoggedBody bytes
+	Bodies bool
+
+	// Requests for which nothing is logged
+	Skip func(c *gin.Context) bool
+
+	// Destination; gin.DefaultWriter if nil
+	Output io.Writer
+}
+
+// RequestLogger logs one line per request in Gin's format, with sensitive
+// query parameters, headers and body fields masked
+func RequestLogger(cfg RequestLogConfig) gin.HandlerFunc {
+	redactor := cfg.Redactor
+	if redactor == nil {
+		redactor = NewLogRedactor()
+	}
+	var headers []string
+	for _, name := range cfg.Headers {
+		if name = strings.TrimSpace(name); name != "" {
+			headers = append(headers, name)
+		}
+	}
+	sort.Strings(headers)
+
+	logger := gin.LoggerWithConfig(gin.LoggerConfig{
+		Output: cfg.Output,
+		Skip:   cfg.Skip,
+		Formatter: func(param gin.LogFormatterParams) string {
+			path := param.Request.URL.Path
+			if query := redactor.Query(param.Request.URL.RawQuery); query != "" {
+				path += "?" + query
+			}
+
+			var extra strings.Builder
+			for _, name := range headers {
+				value := param.Request.Header.Get(name)
+				if value == "" {
+					continue
+				}
+				if redactor.redacts(name) {
+					value = redactedValue
+				}
+				fmt.Fprintf(&extra, " %s=%q", name, value)
+			}
+			if body, ok := param.Keys[loggedBodyKey].(string); ok {
+				fmt.Fprintf(&extra, " body=%s", body)
+			}
+
+			return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %-7s %#v%s\n%s",
+				param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+				param.StatusCode,
+				param.Latency.Round(time.Microsecond),
+				param.ClientIP,
+				param.Method,
+				path,
+				extra.String(),
+				param.ErrorMessage,
+			)
+		},
+	})
+
+	return func(c *gin.Context) {
+		if cfg.Bodies && (cfg.Skip == nil || !cfg.Skip(c)) {
+			captureBody(c, redactor)
+		}
+		logger(c)
+	}
+}
+
+// captureBody stores the redacted JSON body for the log line, leaving the
+// request body readable by the handler
+func captureBody(c *gin.Context, redactor *LogRedactor) {
+	if c.Request.Body == nil || c.ContentType() != gin.MIMEJSON {
+		return
+	}
+
+	prefix, err := io.ReadAll(io.LimitReader(c.Request.Body, maxLoggedBody+1))
+	c.Request.Body = readCloser{io.MultiReader(bytes.NewReader(prefix), c.Request.Body), c.Request.Body}
+	if err != nil || len(prefix) == 0 {
+		return
+	}
+
+	switch body, ok := redactor.Body(prefix); {
+	case len(prefix) > maxLoggedBody:
+		c.Set(loggedBodyKey, "(truncated, not logged)")
+	case ok:
+		c.Set(loggedBodyKey, body)
+	default:
+		c.Set(loggedBodyKey, "(not a JSON object, not logged)")
+	}
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,38 @@
+package idgen
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+)
+
+// IDGenerator produces the IDs of tasks, agents, plans and other records
+type IDGenerator interface {
+	NewID() string
+}
+
+// UUIDGenerator generates random UUIDs; it is the default everywhere
+type UUIDGenerator struct{}
+
+// NewID returns a new random UUID
+func (UUIDGenerator) NewID() string {
+	return uuid.New().String()
+}
+
+// SequentialGenerator generates predictable IDs like "task-1", "task-2",
+// so tests can refer to records by ID. It is safe for concurrent use.
+type SequentialGenerator struct {
+	prefix string
+	next   atomic.Uint64
+}
+
+// NewSequentialGenerator creates a generator counting from 1 after prefix
+func NewSequentialGenerator(prefix string) *SequentialGenerator {
+	return &SequentialGenerator{prefix: prefix}
+}
+
+// NewID returns the next ID in sequence
+func (g *SequentialGenerator) NewID() string {
+	return fmt.Sprintf("%s-%d", g.prefix, g.next.Add(1))
+}
@@ -0,0 +1,54 @@
+package idgen
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestSequentialGenerator(t *testing.T) {
+	g := NewSequentialGenerator("task")
+	for _, want := range []string{"task-1", "task-2", "task-3"} {
+		if id := g.NewID(); id != want {
+			t.Errorf("id = %s, want %s", id, want)
+		}
+	}
+
+	// Another generator counts on its own
+	if id := NewSequentialGenerator("plan").NewID(); id != "plan-1" {
+		t.Errorf("fresh generator id = %s, want plan-1", id)
+	}
+}
+
+// Run with -race: tasks and plans are created concurrently
+func TestSequentialGeneratorConcurrent(t *testing.T) {
+	g := NewSequentialGenerator("id")
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				id := g.NewID()
+				mu.Lock()
+				seen[id] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(seen) != 800 || !seen["id-1"] || !seen["id-800"] {
+		t.Errorf("%d distinct IDs, want id-1 through id-800", len(seen))
+	}
+}
+
+func TestUUIDGenerator(t *testing.T) {
+	var g IDGenerator = UUIDGenerator{}
+	first, second := g.NewID(), g.NewID()
+	if _, err := uuid.Parse(first); err != nil || first == second {
+		t.Errorf("ids %q and %q, want two distinct UUIDs", first, second)
+	}
+}
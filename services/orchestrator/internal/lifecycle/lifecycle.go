@@ -0,0 +1,108 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// Component is a background component with a start/stop lifecycle
+type Component interface {
+	Start()
+	Stop()
+}
+
+// Hooks adapts a pair of functions to the Component interface
+type Hooks struct {
+	OnStart func()
+	OnStop  func()
+}
+
+// Start calls OnStart if set
+func (h Hooks) Start() {
+	if h.OnStart != nil {
+		h.OnStart()
+	}
+}
+
+// Stop calls OnStop if set
+func (h Hooks) Stop() {
+	if h.OnStop != nil {
+		h.OnStop()
+	}
+}
+
+type namedComponent struct {
+	name      string
+	component Component
+}
+
+// Manager starts components in registration order and stops them in reverse
+// order
+type Manager struct {
+	mu         sync.Mutex
+	components []namedComponent
+	started    int
+	shutdown   bool
+}
+
+// NewManager creates a new lifecycle manager
+func NewManager() *Manager {
+	return &Manager{}
+}
+
+// Add registers a component. Components are started in the order added.
+func (m *Manager) Add(name string, component Component) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.components = append(m.components, namedComponent{name: name, component: component})
+}
+
+// Start starts all components that have not been started yet
+func (m *Manager) Start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for ; m.started < len(m.components); m.started++ {
+		c := m.components[m.started]
+		c.component.Start()
+		log.Printf("Started %s", c.name)
+	}
+}
+
+// Shutdown stops started components in reverse order. If ctx expires before a component stops, the remaining
+// components are abandoned and an error naming them is returned.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.shutdown {
+		return nil
+	}
+	m.shutdown = true
+
+	for i := m.started - 1; i >= 0; i-- {
+		c := m.components[i]
+
+		done := make(chan struct{})
+		go func() {
+			c.component.Stop()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+			log.Printf("Stopped %s", c.name)
+		case <-ctx.Done():
+			remaining := make([]string, 0, i+1)
+			for j := i; j >= 0; j-- {
+				remaining = append(remaining, m.components[j].name)
+			}
+			return fmt.Errorf("shutdown timed out waiting for %v: %w", remaining, ctx.Err())
+		}
+	}
+
+	return nil
+}
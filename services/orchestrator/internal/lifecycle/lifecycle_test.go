@@ -0,0 +1,92 @@
+package lifecycle
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+)
+
+// worker is a component running a goroutine until stopped
+type worker struct {
+	name string
+	log  *[]string
+	mu   *sync.Mutex
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func (w *worker) Start() {
+	w.stop = make(chan struct{})
+	w.done = make(chan struct{})
+	go func() {
+		defer close(w.done)
+		<-w.stop
+	}()
+	w.record("start " + w.name)
+}
+
+func (w *worker) Stop() {
+	close(w.stop)
+	<-w.done
+	w.record("stop " + w.name)
+}
+
+func (w *worker) record(event string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	*w.log = append(*w.log, event)
+}
+
+func TestManagerStartsAndStopsWithoutLeaks(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	var mu sync.Mutex
+	var events []string
+	m := NewManager()
+	for _, name := range []string{"store", "registry", "router"} {
+		m.Add(name, &worker{name: name, log: &events, mu: &mu})
+	}
+	m.Start()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := m.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+
+	want := "start store,start registry,start router,stop router,stop registry,stop store"
+	if got := strings.Join(events, ","); got != want {
+		t.Errorf("events = %s, want %s", got, want)
+	}
+
+	// A second shutdown is a no-op
+	if err := m.Shutdown(ctx); err != nil {
+		t.Errorf("second shutdown: %v", err)
+	}
+}
+
+func TestShutdownTimesOutOnStuckComponent(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	stopped := false
+	m := NewManager()
+	m.Add("first", Hooks{OnStop: func() { stopped = true }})
+	m.Add("stuck", Hooks{OnStop: func() { <-release }})
+	m.Start()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := m.Shutdown(ctx)
+	if err == nil || !strings.Contains(err.Error(), "stuck") || !strings.Contains(err.Error(), "first") {
+		t.Fatalf("shutdown error = %v, want one naming the abandoned components", err)
+	}
+	if stopped {
+		t.Error("component before the stuck one was stopped")
+	}
+}
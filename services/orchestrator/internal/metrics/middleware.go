@@ -4,8 +4,14 @@ import (
 	"net/http"
 	"strconv"
 	"time"
+
+	"github.com/gin-gonic/gin"
 )
 
+// unmatchedRoute is the endpoint label for requests that match no route, so
+// scans of random paths don't create new series
+const unmatchedRoute = "unmatched"
+
 // responseWriter wraps http.ResponseWriter to capture status code
 type responseWriter struct {
 	http.ResponseWriter
@@ -21,7 +27,9 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
-// HTTPMetricsMiddleware creates middleware for automatic HTTP metrics tracking
+// HTTPMetricsMiddleware creates middleware for automatic HTTP metrics tracking.
+// It labels by raw URL path, so only use it for handlers without path
+// parameters; Gin routes should use GinMiddleware.
 func HTTPMetricsMiddleware(m *Metrics) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -42,6 +50,26 @@ func HTTPMetricsMiddleware(m *Metrics) func(http.Handler) http.Handler {
 	}
 }
 
+// GinMiddleware records HTTP metrics labelled by route template (e.g.
+// /tasks/:id) rather than the raw path, keeping label cardinality bounded
+func GinMiddleware(m *Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		endpoint := c.FullPath()
+		if endpoint == "" {
+			endpoint = unmatchedRoute
+		}
+
+		duration := time.Since(start).Seconds()
+		status := strconv.Itoa(c.Writer.Status())
+
+		m.RecordHTTPRequest(c.Request.Method, endpoint, status, duration)
+	}
+}
+
 // MetricsHandler returns an HTTP handler for the /metrics endpoint
 func MetricsHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// newHTTPMetrics returns metrics with only the HTTP collectors, unregistered
+func newHTTPMetrics() *Metrics {
+	return &Metrics{
+		HTTPRequestsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "http_requests_total"},
+			[]string{"method", "endpoint", "status"}),
+		HTTPRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "http_request_duration_seconds"},
+			[]string{"method", "endpoint"}),
+	}
+}
+
+func TestGinMiddlewareLabelsByRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := newHTTPMetrics()
+	router := gin.New()
+	router.Use(GinMiddleware(m))
+	router.GET("/tasks/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, path := range []string{"/tasks/a", "/tasks/b", "/tasks/c", "/random/1", "/random/2"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if n := testutil.CollectAndCount(m.HTTPRequestsTotal); n != 2 {
+		t.Errorf("request series = %d, want 2 (route and unmatched)", n)
+	}
+	if got := testutil.ToFloat64(m.HTTPRequestsTotal.WithLabelValues("GET", "/tasks/:id", "200")); got != 3 {
+		t.Errorf("/tasks/:id requests = %v, want 3", got)
+	}
+	if got := testutil.ToFloat64(m.HTTPRequestsTotal.WithLabelValues("GET", unmatchedRoute, "404")); got != 2 {
+		t.Errorf("unmatched requests = %v, want 2", got)
+	}
+}
@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Snapshot is a point-in-time JSON view of the key orchestrator metrics for
+// environments that cannot scrape Prometheus
+type Snapshot struct {
+	TasksRouted           float64            `json:"tasks_routed_total"`
+	TasksRoutedByStatus   map[string]float64 `json:"tasks_routed_by_status"`
+	ActiveAgents          map[string]float64 `json:"active_agents_by_type"`
+	AgentRegistrations    float64            `json:"agent_registrations_total"`
+	AgentDeregistrations  float64            `json:"agent_deregistrations_total"`
+	CoordinationConflicts float64            `json:"coordination_conflicts_total"`
+	ActiveOptimizations   float64            `json:"active_optimizations"`
+	HTTPRequests          float64            `json:"http_requests_total"`
+	GeneratedAt           time.Time          `json:"generated_at"`
+}
+
+// TakeSnapshot gathers the current metric values from a Prometheus registry
+func TakeSnapshot(gatherer prometheus.Gatherer) (*Snapshot, error) {
+	families, err := gatherer.Gather()
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &Snapshot{
+		TasksRoutedByStatus: make(map[string]float64),
+		ActiveAgents:        make(map[string]float64),
+		GeneratedAt:         time.Now(),
+	}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			labels := make(map[string]string, len(metric.GetLabel()))
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			counter := metric.GetCounter().GetValue()
+			gauge := metric.GetGauge().GetValue()
+
+			switch family.GetName() {
+			case "tasks_routed_total":
+				snapshot.TasksRouted += counter
+				snapshot.TasksRoutedByStatus[labels["status"]] += counter
+			case "active_agents":
+				snapshot.ActiveAgents[labels["agent_type"]] = gauge
+			case "agent_registrations_total":
+				snapshot.AgentRegistrations += counter
+			case "agent_deregistrations_total":
+				snapshot.AgentDeregistrations += counter
+			case "coordination_conflicts_total":
+				snapshot.CoordinationConflicts += counter
+			case "active_optimizations":
+				snapshot.ActiveOptimizations = gauge
+			case "http_requests_total":
+				snapshot.HTTPRequests += counter
+			}
+		}
+	}
+	return snapshot, nil
+}
+
+// SnapshotHandler serves the JSON snapshot of the given registry
+func SnapshotHandler(gatherer prometheus.Gatherer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		snapshot, err := TakeSnapshot(gatherer)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, snapshot)
+	}
+}
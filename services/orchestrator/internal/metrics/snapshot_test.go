@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestTakeSnapshot(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	routed := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "tasks_routed_total"}, []string{"agent", "task_type", "status"})
+	agents := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "active_agents"}, []string{"agent_type"})
+	registrations := prometheus.NewCounter(prometheus.CounterOpts{Name: "agent_registrations_total"})
+	reg.MustRegister(routed, agents, registrations)
+
+	routed.WithLabelValues("agent-1", "analyze_cost", "completed").Inc()
+	routed.WithLabelValues("agent-2", "analyze_cost", "failed").Inc()
+	agents.WithLabelValues("cost").Set(2)
+	registrations.Inc()
+
+	snapshot, err := TakeSnapshot(reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.TasksRouted != 2 || snapshot.TasksRoutedByStatus["completed"] != 1 {
+		t.Errorf("tasks routed = %v by status %v", snapshot.TasksRouted, snapshot.TasksRoutedByStatus)
+	}
+	if snapshot.ActiveAgents["cost"] != 2 {
+		t.Errorf("active agents = %v", snapshot.ActiveAgents)
+	}
+	if snapshot.AgentRegistrations != 1 {
+		t.Errorf("registrations = %v", snapshot.AgentRegistrations)
+	}
+}
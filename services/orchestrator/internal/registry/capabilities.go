@@ -0,0 +1,103 @@
+package registry
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultCapabilities returns the standard capabilities for each agent type,
+// matching the task types the router dispatches to them
+func DefaultCapabilities() map[AgentType][]string {
+	return map[AgentType][]string{
+		AgentTypeCost:        {"analyze_cost", "migrate_to_spot", "right_size"},
+		AgentTypePerformance: {"optimize_kv_cache", "tune_inference"},
+		AgentTypeResource:    {"predict_scaling", "balance_load"},
+		AgentTypeApplication: {"validate_quality", "detect_regression"},
+	}
+}
+
+// ParseDefaultCapabilities parses a spec like
+// "cost=analyze_cost,right_size;resource=predict_scaling". Types not named
+// in the spec get no defaults.
+func ParseDefaultCapabilities(spec string) (map[AgentType][]string, error) {
+	defaults := make(map[AgentType][]string)
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		agentType, list, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(agentType) == "" {
+			return nil, fmt.Errorf("invalid default capability entry %q", entry)
+		}
+
+		var capabilities []string
+		for _, capability := range strings.Split(list, ",") {
+			if capability = strings.TrimSpace(capability); capability != "" {
+				capabilities = append(capabilities, capability)
+			}
+		}
+		defaults[AgentType(strings.TrimSpace(agentType))] = capabilities
+	}
+
+	return defaults, nil
+}
+
+// SetDefaultCapabilities replaces the capabilities merged into registrations
+// by agent type
+func (r *Registry) SetDefaultCapabilities(defaults map[AgentType][]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.defaultCapabilities = defaults
+}
+
+// withDefaultCapabilities appends the type's default capabilities the agent
+// did not advertise under any version, returning the merged list and what
+// was added
+func withDefaultCapabilities(capabilities []string, defaults []string) ([]string, []string) {
+	seen := make(map[string]bool, len(capabilities))
+	named := make(map[string]bool, len(capabilities))
+	merged := make([]string, 0, len(capabilities)+len(defaults))
+	for _, capability := range capabilities {
+		if !seen[capability] {
+			seen[capability] = true
+			name, _ := SplitCapability(capability)
+			named[name] = true
+			merged = append(merged, capability)
+		}
+	}
+
+	var added []string
+	for _, capability := range defaults {
+		name, _ := SplitCapability(capability)
+		if !seen[capability] && !named[name] {
+			seen[capability] = true
+			named[name] = true
+			merged = append(merged, capability)
+			added = append(added, capability)
+		}
+	}
+
+	return merged, added
+}
+
+// supportedPreferences keeps the preferred task types the agent has a
+// capability for, returning them and the ones dropped
+func supportedPreferences(preferred []string, capabilities []string) ([]string, []string) {
+	supported := make(map[string]bool, len(capabilities))
+	for _, capability := range capabilities {
+		name, _ := SplitCapability(capability)
+		supported[name] = true
+	}
+
+	var kept, dropped []string
+	for _, taskType := range preferred {
+		if supported[taskType] {
+			kept = append(kept, taskType)
+		} else {
+			dropped = append(dropped, taskType)
+		}
+	}
+	return kept, dropped
+}
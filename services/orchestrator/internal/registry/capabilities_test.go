@@ -0,0 +1,72 @@
+package registry
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCostAgentGetsDefaultCapabilities(t *testing.T) {
+	reg := newTestRegistry(t)
+	id := registerWithStatus(t, reg, registration("cost-1", AgentTypeCost), AgentStatusHealthy)
+
+	agent, err := reg.GetAgent(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(agent.Capabilities, ","); got != "analyze_cost,migrate_to_spot,right_size" {
+		t.Errorf("capabilities = %s, want the cost defaults", got)
+	}
+
+	// The defaults make the agent routable for its type's tasks
+	for _, capability := range DefaultCapabilities()[AgentTypeCost] {
+		agents, err := reg.GetAgentsWithCapability(capability, AgentTypeCost)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(agents) != 1 || agents[0].ID != id {
+			t.Errorf("agents with %s = %d, want cost-1", capability, len(agents))
+		}
+	}
+}
+
+func TestDefaultCapabilitiesMergeWithoutDuplicates(t *testing.T) {
+	merged, added := withDefaultCapabilities(
+		[]string{"right_size@2.1", "forecast_spend", "forecast_spend"},
+		[]string{"analyze_cost", "right_size", "forecast_spend"},
+	)
+	// A versioned capability counts as advertising its name
+	if got := strings.Join(merged, ","); got != "right_size@2.1,forecast_spend,analyze_cost" {
+		t.Errorf("merged = %s", got)
+	}
+	if len(added) != 1 || added[0] != "analyze_cost" {
+		t.Errorf("added = %v, want [analyze_cost]", added)
+	}
+}
+
+func TestConfiguredDefaultCapabilities(t *testing.T) {
+	defaults, err := ParseDefaultCapabilities(" cost = analyze_cost , forecast_spend ; resource=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(defaults[AgentTypeCost], ","); got != "analyze_cost,forecast_spend" {
+		t.Errorf("cost defaults = %s", got)
+	}
+	if list, ok := defaults[AgentTypeResource]; !ok || len(list) != 0 {
+		t.Errorf("resource defaults = %v, want none", list)
+	}
+	if _, err := ParseDefaultCapabilities("analyze_cost"); err == nil {
+		t.Error("entry without a type accepted")
+	}
+
+	reg := newTestRegistry(t)
+	reg.SetDefaultCapabilities(defaults)
+	cost := registerWithStatus(t, reg, registration("cost-1", AgentTypeCost), AgentStatusHealthy)
+	resource := registerWithStatus(t, reg, registration("resource-1", AgentTypeResource, "balance_load"), AgentStatusHealthy)
+
+	if agent, _ := reg.GetAgent(cost); strings.Join(agent.Capabilities, ",") != "analyze_cost,forecast_spend" {
+		t.Errorf("cost capabilities = %v", agent.Capabilities)
+	}
+	if agent, _ := reg.GetAgent(resource); strings.Join(agent.Capabilities, ",") != "balance_load" {
+		t.Errorf("resource capabilities = %v", agent.Capabilities)
+	}
+}
@@ -0,0 +1,176 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidCapability is returned for a capability or version constraint
+// that cannot be parsed
+var ErrInvalidCapability = errors.New("invalid capability")
+
+// Capabilities may carry a version as "name@version", where the version is
+// dot-separated numbers such as "2" or "1.4". A requirement is either a plain
+// name, matching any version, or "name@constraint".
+
+// SplitCapability splits "name@suffix" into its name and suffix, which is
+// empty for a plain name
+func SplitCapability(capability string) (string, string) {
+	name, suffix, _ := strings.Cut(capability, "@")
+	return name, suffix
+}
+
+// VersionConstraint is a comma-separated list of comparisons that must all
+// hold, such as ">=2", "<3" or ">=2,<3". A bare version means "=".
+type VersionConstraint []versionBound
+
+type versionBound struct {
+	op      string
+	version []int
+}
+
+// ParseVersionConstraint parses a version constraint
+func ParseVersionConstraint(spec string) (VersionConstraint, error) {
+	var constraint VersionConstraint
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		op := "="
+		for _, candidate := range []string{">=", "<=", ">", "<", "="} {
+			if strings.HasPrefix(part, candidate) {
+				op = candidate
+				part = strings.TrimSpace(part[len(candidate):])
+				break
+			}
+		}
+		version, err := parseVersion(part)
+		if err != nil {
+			return nil, fmt.Errorf("%w: constraint %q: %v", ErrInvalidCapability, spec, err)
+		}
+		constraint = append(constraint, versionBound{op: op, version: version})
+	}
+	if len(constraint) == 0 {
+		return nil, fmt.Errorf("%w: empty version constraint", ErrInvalidCapability)
+	}
+	return constraint, nil
+}
+
+// Allows reports whether a version satisfies every bound of the constraint
+func (c VersionConstraint) Allows(version []int) bool {
+	for _, bound := range c {
+		cmp := compareVersions(version, bound.version)
+		var ok bool
+		switch bound.op {
+		case ">=":
+			ok = cmp >= 0
+		case "<=":
+			ok = cmp <= 0
+		case ">":
+			ok = cmp > 0
+		case "<":
+			ok = cmp < 0
+		default:
+			ok = cmp == 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateCapability checks that an advertised capability's version, if
+// any, parses
+func ValidateCapability(capability string) error {
+	name, version, versioned := strings.Cut(capability, "@")
+	if name == "" {
+		return fmt.Errorf("%w: %q has no name", ErrInvalidCapability, capability)
+	}
+	if !versioned {
+		return nil
+	}
+	if _, err := parseVersion(version); err != nil {
+		return fmt.Errorf("%w: %q: %v", ErrInvalidCapability, capability, err)
+	}
+	return nil
+}
+
+// MatchCapability reports whether an advertised capability meets a
+// requirement. A plain requirement matches any version of the name; a
+// constrained one only matches advertised versions it allows, so an
+// unversioned capability does not satisfy it.
+func MatchCapability(advertised, requirement string) bool {
+	name, version := SplitCapability(advertised)
+	wantName, spec := SplitCapability(requirement)
+	if name != wantName {
+		return false
+	}
+	if spec == "" {
+		return true
+	}
+	if version == "" {
+		return false
+	}
+
+	constraint, err := ParseVersionConstraint(spec)
+	if err != nil {
+		return false
+	}
+	v, err := parseVersion(version)
+	if err != nil {
+		return false
+	}
+	return constraint.Allows(v)
+}
+
+// HasCapability reports whether the agent advertises a capability meeting
+// the requirement
+func (a *Agent) HasCapability(requirement string) bool {
+	for _, capability := range a.Capabilities {
+		if MatchCapability(capability, requirement) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseVersion(s string) ([]int, error) {
+	if s == "" {
+		return nil, fmt.Errorf("empty version")
+	}
+	parts := strings.Split(s, ".")
+	version := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("version %q must be dot-separated numbers", s)
+		}
+		version[i] = n
+	}
+	return version, nil
+}
+
+// compareVersions compares two versions, treating missing trailing parts as 0
+func compareVersions(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
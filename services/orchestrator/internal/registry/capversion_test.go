@@ -0,0 +1,118 @@
+package registry
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestMatchCapability(t *testing.T) {
+	tests := []struct {
+		advertised, requirement string
+		want                    bool
+	}{
+		// Exact
+		{"analyze_cost@2", "analyze_cost@2", true},
+		{"analyze_cost@2.0", "analyze_cost@2", true},
+		{"analyze_cost@2.1", "analyze_cost@2", false},
+		{"analyze_cost@2", "right_size@2", false},
+
+		// Range
+		{"analyze_cost@2.5", "analyze_cost@>=2,<3", true},
+		{"analyze_cost@3", "analyze_cost@>=2,<3", false},
+		{"analyze_cost@1.9", "analyze_cost@>=2", false},
+		{"analyze_cost@1.9", "analyze_cost@<=1.9", true},
+
+		// Unversioned
+		{"analyze_cost", "analyze_cost", true},
+		{"analyze_cost@4", "analyze_cost", true},
+		{"analyze_cost", "analyze_cost@>=1", false},
+		{"analyze_cost@2", "analyze_cost@bogus", false},
+	}
+	for _, tt := range tests {
+		if got := MatchCapability(tt.advertised, tt.requirement); got != tt.want {
+			t.Errorf("MatchCapability(%q, %q) = %v, want %v", tt.advertised, tt.requirement, got, tt.want)
+		}
+	}
+}
+
+func TestValidateCapability(t *testing.T) {
+	for _, capability := range []string{"analyze_cost", "analyze_cost@2", "analyze_cost@1.4.2"} {
+		if err := ValidateCapability(capability); err != nil {
+			t.Errorf("ValidateCapability(%q) = %v", capability, err)
+		}
+	}
+	for _, capability := range []string{"", "@2", "analyze_cost@", "analyze_cost@v2", "analyze_cost@>=2"} {
+		if err := ValidateCapability(capability); !errors.Is(err, ErrInvalidCapability) {
+			t.Errorf("ValidateCapability(%q) = %v, want ErrInvalidCapability", capability, err)
+		}
+	}
+}
+
+func TestUpdateCapabilities(t *testing.T) {
+	reg := newTestRegistry(t)
+	resp, err := reg.Register(registration("cost-1", AgentTypeCost, "analyze_cost@2", "right_size", "migrate_to_spot@1", "migrate_to_spot@2"))
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	// A plain name removes every version; a versioned one only that version
+	agent, err := reg.UpdateCapabilities(resp.AgentID, resp.AgentToken, &CapabilityUpdateRequest{
+		Add:    []string{"tune_inference@1.2"},
+		Remove: []string{"migrate_to_spot", "analyze_cost@1"},
+	})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	want := []string{"analyze_cost@2", "right_size", "tune_inference@1.2"}
+	if len(agent.Capabilities) != len(want) {
+		t.Fatalf("capabilities %v, want %v", agent.Capabilities, want)
+	}
+	for i := range want {
+		if agent.Capabilities[i] != want[i] {
+			t.Fatalf("capabilities %v, want %v", agent.Capabilities, want)
+		}
+	}
+
+	if _, err := reg.UpdateCapabilities(resp.AgentID, resp.AgentToken, &CapabilityUpdateRequest{
+		Add: []string{"tune_inference@latest"},
+	}); !errors.Is(err, ErrInvalidCapability) {
+		t.Errorf("adding an unparseable version: %v, want ErrInvalidCapability", err)
+	}
+
+	router := newTestRouter(reg)
+	rec := doJSON(t, router, http.MethodPatch, "/agents/"+resp.AgentID+"/capabilities", &CapabilityUpdateRequest{Add: []string{"@2"}})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("PATCH with an invalid capability: status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestGetAgentWithCapabilityMatchesVersions(t *testing.T) {
+	reg := newTestRegistry(t)
+	if _, err := reg.Register(registration("cost-1", AgentTypeCost, "analyze_cost@2.3")); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	for requirement, want := range map[string]bool{
+		"analyze_cost":          true,
+		"analyze_cost@2.3":      true,
+		"analyze_cost@>=2,<3":   true,
+		"analyze_cost@>=3":      false,
+		"balance_load":          false,
+		"balance_load@>=1":      false,
+		"analyze_cost@2.3,>2.4": false,
+	} {
+		agent, err := reg.GetAgentWithCapability(AgentTypeCost, requirement)
+		if got := err == nil && agent != nil; got != want {
+			t.Errorf("GetAgentWithCapability(%q) found = %v, want %v (%v)", requirement, got, want, err)
+		}
+
+		agents, err := reg.GetAgentsWithCapability(requirement, "")
+		if err != nil {
+			t.Fatalf("GetAgentsWithCapability(%q): %v", requirement, err)
+		}
+		if got := len(agents) == 1; got != want {
+			t.Errorf("GetAgentsWithCapability(%q) found %d agents, want match %v", requirement, len(agents), want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package registry
+
+import (
+	"fmt"
+	"time"
+)
+
+// Config holds the registry's liveness settings. HeartbeatTimeout only sets
+// the starting value; the admin API may change it while running.
+type Config struct {
+	AgentTTL            time.Duration // Minimum lifetime of a stored agent without heartbeats
+	HealthCheckInterval time.Duration
+	HeartbeatTimeout    time.Duration
+}
+
+// DefaultConfig returns the built-in liveness settings
+func DefaultConfig() Config {
+	return Config{
+		AgentTTL:            defaultAgentTTL,
+		HealthCheckInterval: defaultHealthCheckInterval,
+		HeartbeatTimeout:    defaultHeartbeatTimeout,
+	}
+}
+
+// Validate checks a config before it is given to a registry
+func (c Config) Validate() error {
+	if c.AgentTTL <= 0 {
+		return fmt.Errorf("agent TTL must be positive")
+	}
+	if c.HealthCheckInterval <= 0 {
+		return fmt.Errorf("health check interval must be positive")
+	}
+	if c.HeartbeatTimeout <= 0 {
+		return fmt.Errorf("heartbeat timeout must be positive")
+	}
+	return nil
+}
+
+// agentTTLSetter is implemented by stores whose agent TTL the registry
+// configures
+type agentTTLSetter interface {
+	SetTTL(ttl time.Duration)
+}
+
+// SetTTL sets the minimum TTL of stored agents; it must be called before the
+// store is used
+func (s *MemoryAgentStore) SetTTL(ttl time.Duration) {
+	s.ttl = ttl
+}
+
+// SetTTL sets the minimum TTL of stored agents; it must be called before the
+// store is used
+func (s *RedisAgentStore) SetTTL(ttl time.Duration) {
+	s.ttl = ttl
+}
@@ -0,0 +1,61 @@
+package registry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRegistryConfigOverrides(t *testing.T) {
+	store := NewMemoryAgentStore()
+	reg := NewRegistryWithConfig(store, Config{
+		AgentTTL:            2 * time.Minute,
+		HealthCheckInterval: 20 * time.Millisecond,
+		HeartbeatTimeout:    10 * time.Second,
+	})
+	if store.ttl != 2*time.Minute || reg.HeartbeatTimeout() != 10*time.Second {
+		t.Errorf("store TTL %v and heartbeat timeout %v, want 2m and 10s", store.ttl, reg.HeartbeatTimeout())
+	}
+
+	// Twenty seconds of silence is within the default timeout but not this
+	// one, for an agent heartbeating every 5s
+	req := registration("cost-1", AgentTypeCost)
+	req.HeartbeatInterval = 5
+	id := registerWithStatus(t, reg, req, AgentStatusHealthy)
+	ageAgent(t, reg, id, 20*time.Second)
+
+	// The scheduled check runs on the configured interval
+	reg.Start()
+	t.Cleanup(reg.Stop)
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		agent, err := reg.GetAgent(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if agent.Status == AgentStatusUnreachable {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("agent still %s after the configured timeout and interval", agent.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRegistryConfigValidate(t *testing.T) {
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Errorf("default config: %v", err)
+	}
+	invalid := map[string]func(*Config){
+		"no agent TTL":               func(c *Config) { c.AgentTTL = 0 },
+		"no health check interval":   func(c *Config) { c.HealthCheckInterval = 0 },
+		"negative heartbeat timeout": func(c *Config) { c.HeartbeatTimeout = -time.Second },
+	}
+	for name, change := range invalid {
+		cfg := DefaultConfig()
+		change(&cfg)
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%s: validated", name)
+		}
+	}
+}
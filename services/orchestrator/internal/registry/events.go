@@ -0,0 +1,52 @@
+package registry
+
+import (
+	"time"
+)
+
+// EventType identifies a registry lifecycle event
+type EventType string
+
+const (
+	EventAgentRegistered     EventType = "agent_registered"
+	EventAgentUnregistered   EventType = "agent_unregistered"
+	EventCapabilitiesUpdated EventType = "capabilities_updated"
+	EventTaskProgress        EventType = "task_progress"  // Details["task_progress"] is []TaskProgress
+	EventAgentReplaced       EventType = "agent_replaced" // Details["successor_id"] is the new agent's ID
+	EventAgentUpdated        EventType = "agent_updated"  // Re-registered under its existing ID
+)
+
+// Event describes a change to a registered agent
+type Event struct {
+	Type      EventType              `json:"type"`
+	AgentID   string                 `json:"agent_id"`
+	AgentType AgentType              `json:"agent_type,omitempty"`
+	Timestamp time.Time              `json:"timestamp"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+// EventListener receives registry events
+type EventListener func(Event)
+
+// Subscribe registers a listener called synchronously after each event
+func (r *Registry) Subscribe(listener EventListener) {
+	r.listenersMu.Lock()
+	defer r.listenersMu.Unlock()
+	r.listeners = append(r.listeners, listener)
+}
+
+// emit delivers an event to all listeners; it must not be called with r.mu held
+func (r *Registry) emit(event Event) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	r.listenersMu.RLock()
+	listeners := make([]EventListener, len(r.listeners))
+	copy(listeners, r.listeners)
+	r.listenersMu.RUnlock()
+
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
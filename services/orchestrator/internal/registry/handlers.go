@@ -1,9 +1,12 @@
 package registry
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"optiinfra/services/orchestrator/internal/handlers"
 )
 
 // Handler provides HTTP handlers for the registry
@@ -25,21 +28,43 @@ func (h *Handler) RegisterRoutes(router *gin.Engine) {
 		agents.POST("/register", h.Register)
 		agents.POST("/:id/heartbeat", h.Heartbeat)
 		agents.POST("/:id/unregister", h.Unregister)
+		agents.POST("/:id/replace", h.Replace)
+		agents.PUT("/:id", h.Upsert)
+		agents.PATCH("/:id/capabilities", h.UpdateCapabilities)
+		agents.POST("/health/refresh", h.RefreshHealth)
 		agents.GET("", h.List)
 		agents.GET("/:id", h.Get)
 		agents.GET("/type/:type", h.ListByType)
+		agents.GET("/capability/:capability", h.ListByCapability)
 	}
 }
 
 // Register handles agent registration
 func (h *Handler) Register(c *gin.Context) {
 	var req RegistrationRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := handlers.BindJSON(c, &req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	resp, err := h.registry.Register(&req)
+	if errors.Is(err, ErrInvalidMetadata) || errors.Is(err, ErrInvalidCapability) || errors.Is(err, ErrInvalidAgentType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, ErrAgentUnreachable) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, ErrRegistryFull) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, ErrRegistrationRateLimited) {
+		c.Header("Retry-After", "1")
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -48,17 +73,100 @@ func (h *Handler) Register(c *gin.Context) {
 	c.JSON(http.StatusCreated, resp)
 }
 
+// Upsert registers an agent under the ID in the path, or re-registers the
+// existing agent when called with its X-Agent-Token
+func (h *Handler) Upsert(c *gin.Context) {
+	var req RegistrationRequest
+	if err := handlers.BindJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, created, err := h.registry.Upsert(c.Param("id"), c.GetHeader("X-Agent-Token"), &req)
+	switch {
+	case errors.Is(err, ErrInvalidAgentToken):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrInvalidMetadata), errors.Is(err, ErrInvalidCapability), errors.Is(err, ErrInvalidAgentType), errors.Is(err, ErrAgentTypeChanged):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrAgentAlreadyReplaced):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrAgentUnreachable):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrRegistryFull):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrRegistrationRateLimited):
+		c.Header("Retry-After", "1")
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if created {
+		c.JSON(http.StatusCreated, resp)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Replace registers a successor for an agent and drains the agent
+func (h *Handler) Replace(c *gin.Context) {
+	var req RegistrationRequest
+	if err := handlers.BindJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.registry.Replace(c.Param("id"), &req)
+	switch {
+	case errors.Is(err, ErrAgentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrInvalidMetadata), errors.Is(err, ErrInvalidCapability), errors.Is(err, ErrInvalidAgentType), errors.Is(err, ErrReplacementTypeMismatch):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrAgentAlreadyReplaced):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrAgentUnreachable):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrRegistryFull):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrRegistrationRateLimited):
+		c.Header("Retry-After", "1")
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
 // Heartbeat handles agent heartbeat
 func (h *Handler) Heartbeat(c *gin.Context) {
 	agentID := c.Param("id")
 
 	var req HeartbeatRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := handlers.BindJSON(c, &req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	resp, err := h.registry.Heartbeat(agentID, &req)
+	if errors.Is(err, ErrInvalidMetadata) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -79,9 +187,57 @@ func (h *Handler) Unregister(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Agent unregistered successfully"})
 }
 
-// List returns all registered agents
+// UpdateCapabilities adds/removes capabilities on an existing agent
+func (h *Handler) UpdateCapabilities(c *gin.Context) {
+	agentID := c.Param("id")
+
+	var req CapabilityUpdateRequest
+	if err := handlers.BindJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Add) == 0 && len(req.Remove) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "add or remove required"})
+		return
+	}
+
+	agent, err := h.registry.UpdateCapabilities(agentID, c.GetHeader("X-Agent-Token"), &req)
+	if errors.Is(err, ErrInvalidCapability) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, ErrInvalidAgentToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, agent)
+}
+
+// List returns all registered agents. When the store is only partly
+// available it returns what could be loaded, marked degraded.
 func (h *Handler) List(c *gin.Context) {
-	agents, err := h.registry.GetAllAgents()
+	listing := h.registry.ListAgents()
+
+	resp := AgentListResponse{
+		Agents:         convertToAgentSlice(listing.Agents),
+		Count:          len(listing.Agents),
+		Degraded:       listing.Degraded,
+		UnavailableIDs: listing.Unavailable,
+	}
+	if listing.Err != nil {
+		resp.Error = listing.Err.Error()
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// RefreshHealth forces an immediate health check and returns all agents
+func (h *Handler) RefreshHealth(c *gin.Context) {
+	agents, err := h.registry.RefreshHealth()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -108,7 +264,11 @@ func (h *Handler) Get(c *gin.Context) {
 
 // ListByType returns agents of a specific type
 func (h *Handler) ListByType(c *gin.Context) {
-	agentType := AgentType(c.Param("type"))
+	agentType, err := ParseAgentType(c.Param("type"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	agents, err := h.registry.GetAgentsByType(agentType)
 	if err != nil {
@@ -122,6 +282,32 @@ func (h *Handler) ListByType(c *gin.Context) {
 	})
 }
 
+// ListByCapability returns healthy agents advertising a capability,
+// optionally filtered by the "type" query parameter
+func (h *Handler) ListByCapability(c *gin.Context) {
+	capability := c.Param("capability")
+	var agentType AgentType
+	if name := c.Query("type"); name != "" {
+		parsed, err := ParseAgentType(name)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		agentType = parsed
+	}
+
+	agents, err := h.registry.GetAgentsWithCapability(capability, agentType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, AgentListResponse{
+		Agents: convertToAgentSlice(agents),
+		Count:  len(agents),
+	})
+}
+
 func convertToAgentSlice(agents []*Agent) []Agent {
 	result := make([]Agent, len(agents))
 	for i, agent := range agents {
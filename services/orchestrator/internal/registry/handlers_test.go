@@ -0,0 +1,134 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseAgentType(t *testing.T) {
+	for _, agentType := range AgentTypes {
+		if got, err := ParseAgentType(string(agentType)); err != nil || got != agentType {
+			t.Errorf("ParseAgentType(%q) = %q, %v", agentType, got, err)
+		}
+	}
+	if _, err := ParseAgentType("quantum"); !errors.Is(err, ErrInvalidAgentType) {
+		t.Errorf("ParseAgentType(quantum) error = %v, want ErrInvalidAgentType", err)
+	}
+}
+
+func TestHandlersRejectUnknownAgentType(t *testing.T) {
+	reg := newTestRegistry(t)
+	router := newTestRouter(reg)
+
+	existing, err := reg.Register(registration("cost-1", AgentTypeCost))
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	invalid := registration("quantum-1", "quantum")
+	tests := []struct {
+		name, method, path string
+		body               interface{}
+	}{
+		{"register", http.MethodPost, "/agents/register", invalid},
+		{"upsert new", http.MethodPut, "/agents/quantum-1", invalid},
+		{"upsert existing", http.MethodPut, "/agents/" + existing.AgentID, invalid},
+		{"replace", http.MethodPost, "/agents/" + existing.AgentID + "/replace", invalid},
+		{"list by type", http.MethodGet, "/agents/type/quantum", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doJSON(t, router, tt.method, tt.path, tt.body)
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("status %d, want %d: %s", rec.Code, http.StatusBadRequest, rec.Body)
+			}
+		})
+	}
+
+	if listing := reg.ListAgents(); len(listing.Agents) != 1 {
+		t.Errorf("%d agents registered, want only the original", len(listing.Agents))
+	}
+	if agent, _ := reg.GetAgent(existing.AgentID); agent == nil || agent.Status == AgentStatusDraining {
+		t.Errorf("rejected replacement changed the original agent: %+v", agent)
+	}
+}
+
+// patchCapabilities sends a capability update for agentID with token
+func patchCapabilities(t *testing.T, router http.Handler, agentID, token string, update *CapabilityUpdateRequest) *httptest.ResponseRecorder {
+	t.Helper()
+	body, err := json.Marshal(update)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPatch, "/agents/"+agentID+"/capabilities", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Agent-Token", token)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestUpdateCapabilitiesEndpoint(t *testing.T) {
+	reg := newTestRegistry(t)
+	router := newTestRouter(reg)
+	resp, err := reg.Register(registration("resource-1", AgentTypeResource, "predict_scaling", "balance_load"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []Event
+	reg.Subscribe(func(event Event) {
+		if event.Type == EventCapabilitiesUpdated {
+			events = append(events, event)
+		}
+	})
+
+	// Adding a capability
+	w := patchCapabilities(t, router, resp.AgentID, resp.AgentToken, &CapabilityUpdateRequest{Add: []string{"forecast_demand"}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("add: status %d: %s", w.Code, w.Body.String())
+	}
+	stored, err := reg.GetAgent(resp.AgentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stored.HasCapability("forecast_demand") || !stored.HasCapability("balance_load") {
+		t.Errorf("capabilities after add = %v", stored.Capabilities)
+	}
+
+	// Removing one
+	w = patchCapabilities(t, router, resp.AgentID, resp.AgentToken, &CapabilityUpdateRequest{Remove: []string{"balance_load"}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("remove: status %d: %s", w.Code, w.Body.String())
+	}
+	if stored, _ = reg.GetAgent(resp.AgentID); stored.HasCapability("balance_load") || !stored.HasCapability("forecast_demand") {
+		t.Errorf("capabilities after remove = %v", stored.Capabilities)
+	}
+	if len(events) != 2 || events[0].AgentID != resp.AgentID {
+		t.Errorf("capability events = %+v, want 2 for %s", events, resp.AgentID)
+	}
+
+	tests := []struct {
+		name    string
+		agentID string
+		token   string
+		update  *CapabilityUpdateRequest
+		want    int
+	}{
+		{"wrong token", resp.AgentID, "wrong", &CapabilityUpdateRequest{Add: []string{"x"}}, http.StatusUnauthorized},
+		// Tokens are checked first, so an unknown agent is unauthorized too
+		{"unknown agent", "missing", resp.AgentToken, &CapabilityUpdateRequest{Add: []string{"x"}}, http.StatusUnauthorized},
+		{"empty update", resp.AgentID, resp.AgentToken, &CapabilityUpdateRequest{}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if w := patchCapabilities(t, router, tt.agentID, tt.token, tt.update); w.Code != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, w.Code, tt.want)
+		}
+	}
+	if len(events) != 2 {
+		t.Errorf("rejected updates emitted %d more events", len(events)-2)
+	}
+}
@@ -0,0 +1,90 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ageAgent moves an agent's last heartbeat into the past
+func ageAgent(t *testing.T, reg *Registry, agentID string, age time.Duration) {
+	t.Helper()
+	ctx := context.Background()
+	agent, err := reg.store.GetAgent(ctx, agentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	agent.LastSeen = time.Now().Add(-age)
+	if err := reg.store.SaveAgent(ctx, agent); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func agentStatuses(agents []Agent) map[string]AgentStatus {
+	statuses := make(map[string]AgentStatus, len(agents))
+	for _, agent := range agents {
+		statuses[agent.Name] = agent.Status
+	}
+	return statuses
+}
+
+func TestRefreshHealthMarksStaleAgentsUnreachable(t *testing.T) {
+	reg := newTestRegistry(t)
+	router := newTestRouter(reg)
+
+	stale := registerWithStatus(t, reg, registration("cost-1", AgentTypeCost), AgentStatusHealthy)
+	registerWithStatus(t, reg, registration("cost-2", AgentTypeCost), AgentStatusHealthy)
+	ageAgent(t, reg, stale, 2*reg.HeartbeatTimeout())
+
+	w := doJSON(t, router, http.MethodPost, "/agents/health/refresh", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var resp AgentListResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	statuses := agentStatuses(resp.Agents)
+	if statuses["cost-1"] != AgentStatusUnreachable || statuses["cost-2"] != AgentStatusHealthy {
+		t.Errorf("statuses after refresh = %v, want cost-1 unreachable and cost-2 healthy", statuses)
+	}
+
+	// The change is stored, not just reported
+	agent, err := reg.GetAgent(stale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if agent.Status != AgentStatusUnreachable {
+		t.Errorf("stored status = %s, want unreachable", agent.Status)
+	}
+}
+
+func TestConcurrentRefreshesAgree(t *testing.T) {
+	reg := newTestRegistry(t)
+	stale := registerWithStatus(t, reg, registration("cost-1", AgentTypeCost), AgentStatusHealthy)
+	ageAgent(t, reg, stale, 2*reg.HeartbeatTimeout())
+
+	var wg sync.WaitGroup
+	results := make([][]*Agent, 4)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			agents, err := reg.RefreshHealth()
+			if err != nil {
+				t.Error(err)
+			}
+			results[i] = agents
+		}(i)
+	}
+	wg.Wait()
+
+	for i, agents := range results {
+		if len(agents) != 1 || agents[0].Status != AgentStatusUnreachable {
+			t.Errorf("refresh %d returned %v", i, agents)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestRegistry creates a registry on an in-memory store without the
+// health monitor
+func newTestRegistry(t *testing.T) *Registry {
+	t.Helper()
+	return NewRegistryWithStore(NewMemoryAgentStore())
+}
+
+// newTestRouter serves the registry routes for reg
+func newTestRouter(reg *Registry) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	NewHandler(reg).RegisterRoutes(router)
+	return router
+}
+
+// doJSON sends body as JSON to the router and returns the recorded response
+func doJSON(t *testing.T, router http.Handler, method, path string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatalf("encode body: %v", err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// registration returns a valid registration request for an agent
+func registration(name string, agentType AgentType, capabilities ...string) *RegistrationRequest {
+	return &RegistrationRequest{
+		Name:         name,
+		Type:         agentType,
+		Host:         "localhost",
+		Port:         8001,
+		Capabilities: capabilities,
+		Version:      "1.0.0",
+	}
+}
@@ -0,0 +1,25 @@
+package registry
+
+import (
+	"testing"
+
+	"optiinfra/services/orchestrator/internal/idgen"
+)
+
+func TestPredictableAgentIDs(t *testing.T) {
+	reg := newTestRegistry(t)
+	reg.SetIDGenerator(idgen.NewSequentialGenerator("agent"))
+
+	for _, want := range []string{"agent-1", "agent-2"} {
+		resp, err := reg.Register(registration("cost", AgentTypeCost))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.AgentID != want || resp.HeartbeatURL != "/agents/"+want+"/heartbeat" {
+			t.Errorf("registered %s (heartbeat %s), want %s", resp.AgentID, resp.HeartbeatURL, want)
+		}
+	}
+	if agent, err := reg.GetAgent("agent-2"); err != nil || agent.Name != "cost" {
+		t.Errorf("agent-2 = %+v, %v", agent, err)
+	}
+}
@@ -0,0 +1,112 @@
+package registry
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// savingStore counts the agents a replica saves to a store shared with
+// other replicas
+type savingStore struct {
+	AgentStore
+	mu    sync.Mutex
+	saves int
+}
+
+func (s *savingStore) SaveAgent(ctx context.Context, agent *Agent) error {
+	s.mu.Lock()
+	s.saves++
+	s.mu.Unlock()
+	return s.AgentStore.SaveAgent(ctx, agent)
+}
+
+func (s *savingStore) saved() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.saves
+}
+
+// newReplica returns a registry on shared with leader election and a fast
+// health check
+func newReplica(shared AgentStore) (*Registry, *savingStore) {
+	store := &savingStore{AgentStore: shared}
+	cfg := DefaultConfig()
+	cfg.HealthCheckInterval = 50 * time.Millisecond
+	reg := NewRegistryWithConfig(store, cfg)
+	reg.SetLeaderElection(true)
+	return reg, store
+}
+
+func TestOnlyLockHolderChecksHealth(t *testing.T) {
+	shared := NewMemoryAgentStore()
+	setup := NewRegistryWithStore(shared)
+	var ids []string
+	for _, name := range []string{"cost-1", "cost-2", "cost-3"} {
+		id := registerWithStatus(t, setup, registration(name, AgentTypeCost), AgentStatusHealthy)
+		ageAgent(t, setup, id, 2*setup.HeartbeatTimeout())
+		ids = append(ids, id)
+	}
+
+	first, firstStore := newReplica(shared)
+	second, secondStore := newReplica(shared)
+	first.Start()
+	defer first.Stop()
+	second.Start()
+	defer second.Stop()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		agents, err := second.GetAllAgents()
+		if err != nil {
+			t.Fatal(err)
+		}
+		unreachable := 0
+		for _, agent := range agents {
+			if agent.Status == AgentStatusUnreachable {
+				unreachable++
+			}
+		}
+		if unreachable == len(ids) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d of %d stale agents marked unreachable", unreachable, len(ids))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Every change came from the replica holding the lock
+	leader, follower := firstStore, secondStore
+	if held, _ := shared.AcquireLock(context.Background(), healthCheckLockName, second.replicaID, time.Minute); held {
+		leader, follower = secondStore, firstStore
+	}
+	if leader.saved() != len(ids) || follower.saved() != 0 {
+		t.Errorf("leader saved %d agents and follower %d, want %d and 0", leader.saved(), follower.saved(), len(ids))
+	}
+}
+
+func TestHealthCheckLeadership(t *testing.T) {
+	shared := NewMemoryAgentStore()
+	first, _ := newReplica(shared)
+	second, _ := newReplica(shared)
+
+	if !first.isHealthCheckLeader() {
+		t.Fatal("first replica did not take the free lock")
+	}
+	for i := 0; i < 3; i++ {
+		if second.isHealthCheckLeader() {
+			t.Fatal("second replica led while the first held the lock")
+		}
+		if !first.isHealthCheckLeader() {
+			t.Fatal("holder lost the lock it renews")
+		}
+	}
+
+	// Without election every replica checks
+	solo := NewRegistryWithStore(shared)
+	if !solo.isHealthCheckLeader() {
+		t.Error("replica without leader election skipped its check")
+	}
+}
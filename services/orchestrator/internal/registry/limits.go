@@ -0,0 +1,246 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrRegistryFull is returned when a registration would exceed the overall
+// or per-type agent cap
+var ErrRegistryFull = errors.New("agent registry is full")
+
+// ErrRegistrationRateLimited is returned when registrations arrive faster
+// than the configured rate
+var ErrRegistrationRateLimited = errors.New("agent registrations are rate limited")
+
+// RegistrationLimits caps how many agents may be registered. Zero means no
+// cap.
+type RegistrationLimits struct {
+	MaxAgents       int
+	MaxAgentsByType map[AgentType]int
+}
+
+// ParseAgentTypeLimits parses per-type caps like "cost=10,resource=5"
+func ParseAgentTypeLimits(spec string) (map[AgentType]int, error) {
+	limits := make(map[AgentType]int)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		agentType, value, ok := strings.Cut(entry, "=")
+		agentType = strings.TrimSpace(agentType)
+		if !ok || agentType == "" {
+			return nil, fmt.Errorf("invalid agent limit entry %q", entry)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid limit for agent type %s: %q", agentType, value)
+		}
+		limits[AgentType(agentType)] = n
+	}
+	return limits, nil
+}
+
+// SetRegistrationLimits sets the overall and per-type agent caps enforced
+// on registration
+func (r *Registry) SetRegistrationLimits(limits RegistrationLimits) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.limits = limits
+}
+
+// SetRegistrationRate limits registrations to perSecond on average with
+// bursts of up to burst. A perSecond of 0 removes the limit.
+func (r *Registry) SetRegistrationRate(perSecond float64, burst int) {
+	if perSecond <= 0 {
+		r.registerRate.Store(nil)
+		return
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	r.registerRate.Store(newTokenBucket(perSecond, burst))
+}
+
+// allowRegistration takes a token from the registration rate limit, if any
+func (r *Registry) allowRegistration() error {
+	if bucket := r.registerRate.Load(); bucket != nil && !bucket.allow() {
+		return ErrRegistrationRateLimited
+	}
+	return nil
+}
+
+// checkCapacity refuses a registration of agentType that would exceed the
+// caps. An agent being replaced is not counted, as it is about to leave. It
+// must be called with r.mu held.
+func (r *Registry) checkCapacity(agentType AgentType, replacing string) error {
+	maxOfType := r.limits.MaxAgentsByType[agentType]
+	if r.limits.MaxAgents <= 0 && maxOfType <= 0 {
+		return nil
+	}
+
+	total, ofType := r.counts.count(agentType, replacing)
+	if r.limits.MaxAgents > 0 && total >= r.limits.MaxAgents {
+		return fmt.Errorf("%w: %d agents registered (max %d)", ErrRegistryFull, total, r.limits.MaxAgents)
+	}
+	if maxOfType > 0 && ofType >= maxOfType {
+		return fmt.Errorf("%w: %d %s agents registered (max %d)", ErrRegistryFull, ofType, agentType, maxOfType)
+	}
+	return nil
+}
+
+// syncAgentCounts recounts registered agents from the store, picking up
+// agents registered through other replicas
+func (r *Registry) syncAgentCounts() {
+	started := time.Now()
+	listing := r.ListAgents()
+	if listing.Err != nil {
+		log.Printf("Failed to recount agents: %v", listing.Err)
+		return
+	}
+	r.counts.sync(listing.Agents, started)
+}
+
+// agentCounts tracks registered agents by type so capacity checks need no
+// store reads. An agent stops counting when it unregisters or once its
+// stored record would have expired without being saved again.
+type agentCounts struct {
+	mu     sync.Mutex
+	ttl    time.Duration
+	now    func() time.Time
+	agents map[string]countedAgent
+}
+
+type countedAgent struct {
+	agentType  AgentType
+	observedAt time.Time
+	expiresAt  time.Time
+}
+
+func newAgentCounts(ttl time.Duration) *agentCounts {
+	return &agentCounts{
+		ttl:    ttl,
+		now:    time.Now,
+		agents: make(map[string]countedAgent),
+	}
+}
+
+// add starts counting an agent, or refreshes it when already counted
+func (c *agentCounts) add(agent *Agent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.addLocked(agent)
+}
+
+func (c *agentCounts) addLocked(agent *Agent) {
+	now := c.now()
+	c.agents[agent.ID] = countedAgent{
+		agentType:  agent.Type,
+		observedAt: now,
+		expiresAt:  now.Add(agentTTLFor(agent, c.ttl)),
+	}
+}
+
+// touch refreshes the expiry of a counted agent that was just saved
+func (c *agentCounts) touch(agent *Agent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.agents[agent.ID]; ok {
+		c.addLocked(agent)
+	}
+}
+
+// forget stops counting an agent
+func (c *agentCounts) forget(agentID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.agents, agentID)
+}
+
+// sync replaces the counted agents with a store listing taken at started.
+// Agents added since then are kept, as the listing may predate them.
+func (c *agentCounts) sync(agents []*Agent, started time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	listed := make(map[string]bool, len(agents))
+	for _, agent := range agents {
+		listed[agent.ID] = true
+		c.addLocked(agent)
+	}
+	for id, counted := range c.agents {
+		if !listed[id] && counted.observedAt.Before(started) {
+			delete(c.agents, id)
+		}
+	}
+}
+
+// count returns how many agents are registered in total and of agentType,
+// leaving out exclude and dropping agents that have expired
+func (c *agentCounts) count(agentType AgentType, exclude string) (int, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	total, ofType := 0, 0
+	for id, counted := range c.agents {
+		if !now.Before(counted.expiresAt) {
+			delete(c.agents, id)
+			continue
+		}
+		if id == exclude {
+			continue
+		}
+		total++
+		if counted.agentType == agentType {
+			ofType++
+		}
+	}
+	return total, ofType
+}
+
+// tokenBucket is a token bucket rate limiter safe for concurrent use
+type tokenBucket struct {
+	mu       sync.Mutex
+	rate     float64 // Tokens added per second
+	capacity float64
+	tokens   float64
+	last     time.Time
+	now      func() time.Time
+}
+
+func newTokenBucket(perSecond float64, burst int) *tokenBucket {
+	return &tokenBucket{
+		rate:     perSecond,
+		capacity: float64(burst),
+		tokens:   float64(burst),
+		last:     time.Now(),
+		now:      time.Now,
+	}
+}
+
+// allow takes a token if one is available
+func (b *tokenBucket) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,130 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingStore counts the agent reads made against a store
+type countingStore struct {
+	AgentStore
+	reads atomic.Int64
+}
+
+func (s *countingStore) GetAgent(ctx context.Context, agentID string) (*Agent, error) {
+	s.reads.Add(1)
+	return s.AgentStore.GetAgent(ctx, agentID)
+}
+
+func (s *countingStore) ActiveAgentIDs(ctx context.Context) ([]string, error) {
+	s.reads.Add(1)
+	return s.AgentStore.ActiveAgentIDs(ctx)
+}
+
+func TestParseAgentTypeLimits(t *testing.T) {
+	limits, err := ParseAgentTypeLimits(" cost=10, resource=0 ,")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(limits) != 2 || limits[AgentTypeCost] != 10 || limits[AgentTypeResource] != 0 {
+		t.Errorf("limits = %v", limits)
+	}
+
+	for _, spec := range []string{"cost", "=3", "cost=-1", "cost=many"} {
+		if _, err := ParseAgentTypeLimits(spec); err == nil {
+			t.Errorf("ParseAgentTypeLimits(%q) accepted", spec)
+		}
+	}
+}
+
+func TestRegistrationRefusedPastCap(t *testing.T) {
+	store := &countingStore{AgentStore: NewMemoryAgentStore()}
+	reg := NewRegistryWithStore(store)
+	reg.SetRegistrationLimits(RegistrationLimits{
+		MaxAgents:       3,
+		MaxAgentsByType: map[AgentType]int{AgentTypeCost: 2},
+	})
+
+	for _, name := range []string{"cost-1", "cost-2"} {
+		if _, err := reg.Register(registration(name, AgentTypeCost)); err != nil {
+			t.Fatalf("register %s: %v", name, err)
+		}
+	}
+	if _, err := reg.Register(registration("cost-3", AgentTypeCost)); !errors.Is(err, ErrRegistryFull) {
+		t.Errorf("third cost agent: %v, want ErrRegistryFull", err)
+	}
+	if _, err := reg.Register(registration("perf-1", AgentTypePerformance)); err != nil {
+		t.Fatalf("register perf-1: %v", err)
+	}
+	if _, err := reg.Register(registration("perf-2", AgentTypePerformance)); !errors.Is(err, ErrRegistryFull) {
+		t.Errorf("fourth agent: %v, want ErrRegistryFull", err)
+	}
+
+	// Capacity checks count agents without reading the store
+	if n := store.reads.Load(); n != 0 {
+		t.Errorf("registrations made %d store reads, want 0", n)
+	}
+}
+
+func TestRegistrationAllowedAfterUnregister(t *testing.T) {
+	reg := newTestRegistry(t)
+	reg.SetRegistrationLimits(RegistrationLimits{MaxAgentsByType: map[AgentType]int{AgentTypeCost: 1}})
+
+	first, err := reg.Register(registration("cost-1", AgentTypeCost))
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if _, err := reg.Register(registration("cost-2", AgentTypeCost)); !errors.Is(err, ErrRegistryFull) {
+		t.Fatalf("second cost agent: %v, want ErrRegistryFull", err)
+	}
+
+	if err := reg.Unregister(first.AgentID); err != nil {
+		t.Fatalf("unregister: %v", err)
+	}
+	if _, err := reg.Register(registration("cost-2", AgentTypeCost)); err != nil {
+		t.Errorf("register after unregister: %v", err)
+	}
+}
+
+func TestRegistrationAllowedAfterExpiry(t *testing.T) {
+	store := NewMemoryAgentStore()
+	reg := NewRegistryWithStore(store)
+	reg.SetRegistrationLimits(RegistrationLimits{MaxAgents: 1})
+
+	now := time.Now()
+	clock := func() time.Time { return now }
+	store.now, reg.counts.now = clock, clock
+
+	if _, err := reg.Register(registration("cost-1", AgentTypeCost)); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if _, err := reg.Register(registration("cost-2", AgentTypeCost)); !errors.Is(err, ErrRegistryFull) {
+		t.Fatalf("second agent: %v, want ErrRegistryFull", err)
+	}
+
+	// The first agent never heartbeats and its record expires
+	now = now.Add(2 * defaultAgentTTL)
+	if _, err := reg.Register(registration("cost-2", AgentTypeCost)); err != nil {
+		t.Errorf("register after expiry: %v", err)
+	}
+}
+
+func TestStartCountsExistingAgents(t *testing.T) {
+	store := NewMemoryAgentStore()
+	if _, err := NewRegistryWithStore(store).Register(registration("cost-1", AgentTypeCost)); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	// A second replica on the same store counts the agent once started
+	reg := NewRegistryWithStore(store)
+	reg.SetRegistrationLimits(RegistrationLimits{MaxAgents: 1})
+	reg.Start()
+	t.Cleanup(reg.Stop)
+
+	if _, err := reg.Register(registration("cost-2", AgentTypeCost)); !errors.Is(err, ErrRegistryFull) {
+		t.Errorf("register past the cap on a started replica: %v, want ErrRegistryFull", err)
+	}
+}
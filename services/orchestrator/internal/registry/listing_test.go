@@ -0,0 +1,90 @@
+package registry
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+// newRedisRegistry returns a registry on a miniredis store with two agents
+// registered, the server, and the agents' IDs
+func newRedisRegistry(t *testing.T) (*Registry, *miniredis.Miniredis, string, string) {
+	t.Helper()
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+	t.Cleanup(func() { client.Close() })
+	reg := NewRegistryWithStore(NewRedisAgentStore(client))
+
+	var ids []string
+	for _, name := range []string{"cost-1", "cost-2"} {
+		resp, err := reg.Register(registration(name, AgentTypeCost))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, resp.AgentID)
+	}
+	return reg, srv, ids[0], ids[1]
+}
+
+func listAgents(t *testing.T, reg *Registry) AgentListResponse {
+	t.Helper()
+	w := doJSON(t, newTestRouter(reg), http.MethodGet, "/agents", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("list agents: status %d: %s", w.Code, w.Body.String())
+	}
+	var resp AgentListResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestListingReportsMissingAgentRecords(t *testing.T) {
+	reg, srv, missing, kept := newRedisRegistry(t)
+	if listing := reg.ListAgents(); listing.Degraded || len(listing.Agents) != 2 {
+		t.Fatalf("listing = %+v, want both agents and not degraded", listing)
+	}
+
+	// The agent is still in the active set, but its record is gone
+	srv.Del(agentKeyPrefix + missing)
+
+	listing := reg.ListAgents()
+	if !listing.Degraded || listing.Err != nil || len(listing.Unavailable) != 1 || listing.Unavailable[0] != missing {
+		t.Errorf("listing degraded=%v unavailable=%v err=%v, want %s unavailable", listing.Degraded, listing.Unavailable, listing.Err, missing)
+	}
+	if len(listing.Agents) != 1 || listing.Agents[0].ID != kept {
+		t.Errorf("listed %d agents, want only %s", len(listing.Agents), kept)
+	}
+	if agents, err := reg.GetAllAgents(); err != nil || len(agents) != 1 {
+		t.Errorf("GetAllAgents = %d agents, %v; want the loadable one", len(agents), err)
+	}
+
+	resp := listAgents(t, reg)
+	if !resp.Degraded || resp.Count != 1 || len(resp.UnavailableIDs) != 1 || resp.UnavailableIDs[0] != missing || resp.Error != "" {
+		t.Errorf("GET /agents = %+v", resp)
+	}
+}
+
+func TestListingDegradedWhenActiveSetUnreadable(t *testing.T) {
+	reg, srv, _, _ := newRedisRegistry(t)
+
+	// SMEMBERS on a string fails with WRONGTYPE
+	srv.Del(activeAgentsSetKey)
+	srv.Set(activeAgentsSetKey, "corrupt")
+
+	listing := reg.ListAgents()
+	if !listing.Degraded || listing.Err == nil || len(listing.Agents) != 0 {
+		t.Errorf("listing degraded=%v err=%v with %d agents, want degraded with the error", listing.Degraded, listing.Err, len(listing.Agents))
+	}
+	if _, err := reg.GetAllAgents(); err == nil {
+		t.Error("GetAllAgents succeeded without the active set")
+	}
+
+	resp := listAgents(t, reg)
+	if !resp.Degraded || resp.Count != 0 || resp.Error == "" {
+		t.Errorf("GET /agents = %+v, want degraded with an error", resp)
+	}
+}
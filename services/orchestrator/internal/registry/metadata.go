@@ -0,0 +1,166 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidMetadata is returned when agent metadata does not match the
+// schema registered for its agent type
+var ErrInvalidMetadata = errors.New("invalid metadata")
+
+// MetadataFieldType is the JSON type a metadata field must have
+type MetadataFieldType string
+
+const (
+	MetadataString MetadataFieldType = "string"
+	MetadataNumber MetadataFieldType = "number"
+	MetadataBool   MetadataFieldType = "bool"
+)
+
+// MetadataSchema maps metadata keys to their required types. Keys not in
+// the schema are accepted as-is.
+type MetadataSchema map[string]MetadataFieldType
+
+// ParseMetadataSchemas parses a spec like
+// "cost=load:number,region:string;performance=gpu_count:number"
+func ParseMetadataSchemas(spec string) (map[AgentType]MetadataSchema, error) {
+	schemas := make(map[AgentType]MetadataSchema)
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		agentType, list, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(agentType) == "" {
+			return nil, fmt.Errorf("invalid metadata schema entry %q", entry)
+		}
+
+		schema := make(MetadataSchema)
+		for _, field := range strings.Split(list, ",") {
+			if field = strings.TrimSpace(field); field == "" {
+				continue
+			}
+			key, fieldType, ok := strings.Cut(field, ":")
+			key = strings.TrimSpace(key)
+			t := MetadataFieldType(strings.TrimSpace(fieldType))
+			if !ok || key == "" {
+				return nil, fmt.Errorf("invalid metadata field %q", field)
+			}
+			switch t {
+			case MetadataString, MetadataNumber, MetadataBool:
+			default:
+				return nil, fmt.Errorf("unknown type %q for metadata field %s", t, key)
+			}
+			schema[key] = t
+		}
+		schemas[AgentType(strings.TrimSpace(agentType))] = schema
+	}
+
+	return schemas, nil
+}
+
+// SetMetadataSchemas sets the metadata schema enforced for each agent type on
+// registration and heartbeat. With coerce, mismatched values convertible to
+// the schema type, such as "0.5" for a number, are converted instead of
+// rejected.
+func (r *Registry) SetMetadataSchemas(schemas map[AgentType]MetadataSchema, coerce bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metadataSchemas = schemas
+	r.coerceMetadata = coerce
+}
+
+// checkMetadata validates metadata against the agent type's schema and
+// returns it with coerced values applied. It must be called with r.mu held.
+func (r *Registry) checkMetadata(agentType AgentType, metadata map[string]interface{}) (map[string]interface{}, error) {
+	schema := r.metadataSchemas[agentType]
+	if len(schema) == 0 || len(metadata) == 0 {
+		return metadata, nil
+	}
+
+	checked := make(map[string]interface{}, len(metadata))
+	var problems []string
+	for key, value := range metadata {
+		checked[key] = value
+		want, ok := schema[key]
+		if !ok || hasMetadataType(value, want) {
+			continue
+		}
+		if r.coerceMetadata {
+			if coerced, ok := coerceMetadataValue(value, want); ok {
+				checked[key] = coerced
+				continue
+			}
+		}
+		problems = append(problems, fmt.Sprintf("%s must be a %s, got %s", key, want, metadataTypeName(value)))
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, fmt.Errorf("%w: %s", ErrInvalidMetadata, strings.Join(problems, "; "))
+	}
+	return checked, nil
+}
+
+// hasMetadataType reports whether a decoded JSON value has the given type
+func hasMetadataType(value interface{}, want MetadataFieldType) bool {
+	switch value.(type) {
+	case string:
+		return want == MetadataString
+	case float64, int, int64:
+		return want == MetadataNumber
+	case bool:
+		return want == MetadataBool
+	}
+	return false
+}
+
+// coerceMetadataValue converts a scalar to the given type where the
+// conversion is lossless
+func coerceMetadataValue(value interface{}, want MetadataFieldType) (interface{}, bool) {
+	switch want {
+	case MetadataString:
+		switch v := value.(type) {
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), true
+		case bool:
+			return strconv.FormatBool(v), true
+		}
+	case MetadataNumber:
+		if s, ok := value.(string); ok {
+			if f, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+				return f, true
+			}
+		}
+	case MetadataBool:
+		if s, ok := value.(string); ok {
+			if b, err := strconv.ParseBool(strings.TrimSpace(s)); err == nil {
+				return b, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func metadataTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return string(MetadataString)
+	case float64, int, int64:
+		return string(MetadataNumber)
+	case bool:
+		return string(MetadataBool)
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,119 @@
+package registry
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func schemaRegistry(t *testing.T, coerce bool) (*Registry, string) {
+	t.Helper()
+	reg := newTestRegistry(t)
+	schemas, err := ParseMetadataSchemas("cost=load:number,region:string,spot:bool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg.SetMetadataSchemas(schemas, coerce)
+	resp, err := reg.Register(registration("cost-1", AgentTypeCost))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reg, resp.AgentID
+}
+
+func TestConformingHeartbeatMetadataStored(t *testing.T) {
+	reg, id := schemaRegistry(t, false)
+
+	_, err := reg.Heartbeat(id, &HeartbeatRequest{
+		Status:   AgentStatusHealthy,
+		Metadata: map[string]interface{}{"load": 0.4, "region": "us-east-1", "spot": true, "build": "abc"},
+	})
+	if err != nil {
+		t.Fatalf("conforming heartbeat: %v", err)
+	}
+	agent, err := reg.GetAgent(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Keys outside the schema are kept as sent
+	if agent.Metadata["load"] != 0.4 || agent.Metadata["region"] != "us-east-1" || agent.Metadata["build"] != "abc" {
+		t.Errorf("metadata = %v", agent.Metadata)
+	}
+}
+
+func TestNonConformingHeartbeatRejected(t *testing.T) {
+	reg, id := schemaRegistry(t, false)
+	if _, err := reg.Heartbeat(id, &HeartbeatRequest{Status: AgentStatusHealthy, Metadata: map[string]interface{}{"load": 0.4}}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := reg.Heartbeat(id, &HeartbeatRequest{
+		Status:   AgentStatusHealthy,
+		Metadata: map[string]interface{}{"load": "high", "spot": "yes", "region": "eu-west-1"},
+	})
+	if !errors.Is(err, ErrInvalidMetadata) {
+		t.Fatalf("err = %v, want ErrInvalidMetadata", err)
+	}
+	if want := "load must be a number, got string; spot must be a bool, got string"; !strings.Contains(err.Error(), want) {
+		t.Errorf("err = %v, want %q", err, want)
+	}
+	// The rejected heartbeat changes nothing, including its valid fields
+	agent, _ := reg.GetAgent(id)
+	if agent.Metadata["load"] != 0.4 || agent.Metadata["region"] != nil {
+		t.Errorf("metadata after a rejected heartbeat = %v", agent.Metadata)
+	}
+
+	router := newTestRouter(reg)
+	rec := doJSON(t, router, http.MethodPost, "/agents/"+id+"/heartbeat", &HeartbeatRequest{
+		Status:   AgentStatusHealthy,
+		Metadata: map[string]interface{}{"load": "high"},
+	})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("POST heartbeat: status %d, want 400", rec.Code)
+	}
+
+	// Registration is checked the same way
+	req := registration("cost-2", AgentTypeCost)
+	req.Metadata = map[string]interface{}{"region": 7.0, "load": []interface{}{1.0}}
+	if _, err := reg.Register(req); !errors.Is(err, ErrInvalidMetadata) {
+		t.Errorf("register with invalid metadata: %v, want ErrInvalidMetadata", err)
+	}
+}
+
+func TestMetadataCoercion(t *testing.T) {
+	reg, id := schemaRegistry(t, true)
+
+	_, err := reg.Heartbeat(id, &HeartbeatRequest{
+		Status:   AgentStatusHealthy,
+		Metadata: map[string]interface{}{"load": " 0.5", "region": 42.0, "spot": "true"},
+	})
+	if err != nil {
+		t.Fatalf("coercible heartbeat: %v", err)
+	}
+	agent, _ := reg.GetAgent(id)
+	if agent.Metadata["load"] != 0.5 || agent.Metadata["region"] != "42" || agent.Metadata["spot"] != true {
+		t.Errorf("coerced metadata = %v", agent.Metadata)
+	}
+
+	// Values that cannot convert are still rejected
+	if _, err := reg.Heartbeat(id, &HeartbeatRequest{Status: AgentStatusHealthy, Metadata: map[string]interface{}{"load": "high"}}); !errors.Is(err, ErrInvalidMetadata) {
+		t.Errorf("unconvertible value: %v, want ErrInvalidMetadata", err)
+	}
+}
+
+func TestParseMetadataSchemas(t *testing.T) {
+	schemas, err := ParseMetadataSchemas(" cost=load:number, region:string ; performance=gpu_count:number")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schemas[AgentTypeCost]["load"] != MetadataNumber || schemas[AgentTypeCost]["region"] != MetadataString || schemas[AgentTypePerformance]["gpu_count"] != MetadataNumber {
+		t.Errorf("schemas = %v", schemas)
+	}
+
+	for _, spec := range []string{"cost", "=load:number", "cost=load", "cost=load:integer", "cost=:number"} {
+		if _, err := ParseMetadataSchemas(spec); err == nil {
+			t.Errorf("%q accepted", spec)
+		}
+	}
+}
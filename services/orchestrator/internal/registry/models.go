@@ -1,6 +1,9 @@
 package registry
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -14,6 +17,24 @@ const (
 	AgentTypeApplication AgentType = "application"
 )
 
+// AgentTypes lists every agent type
+var AgentTypes = []AgentType{AgentTypeCost, AgentTypePerformance, AgentTypeResource, AgentTypeApplication}
+
+// ErrInvalidAgentType is returned for an agent type not in AgentTypes
+var ErrInvalidAgentType = errors.New("invalid agent type")
+
+// ParseAgentType validates an agent type name
+func ParseAgentType(name string) (AgentType, error) {
+	valid := make([]string, len(AgentTypes))
+	for i, agentType := range AgentTypes {
+		if string(agentType) == name {
+			return agentType, nil
+		}
+		valid[i] = string(agentType)
+	}
+	return "", fmt.Errorf("%w: unknown agent type %q (valid: %s)", ErrInvalidAgentType, name, strings.Join(valid, ", "))
+}
+
 // AgentStatus represents the current status of an agent
 type AgentStatus string
 
@@ -22,6 +43,9 @@ const (
 	AgentStatusDegraded    AgentStatus = "degraded"
 	AgentStatusUnhealthy   AgentStatus = "unhealthy"
 	AgentStatusUnreachable AgentStatus = "unreachable"
+
+	// Replaced by a successor; finishes in-flight tasks but takes no new ones
+	AgentStatusDraining AgentStatus = "draining"
 )
 
 // Agent represents a registered agent
@@ -37,6 +61,20 @@ type Agent struct {
 	RegisteredAt time.Time              `json:"registered_at"`
 	LastSeen     time.Time              `json:"last_seen"`
 	Metadata     map[string]interface{} `json:"metadata,omitempty"`
+
+	// Negotiated at registration; zero for agents stored before negotiation
+	HeartbeatInterval int `json:"heartbeat_interval_seconds,omitempty"`
+
+	// Capabilities the agent is optimized for; routing prefers it for these
+	PreferredTaskTypes []string `json:"preferred_task_types,omitempty"`
+
+	// Tasks per second the agent reported it can accept; dispatch is paced
+	// to the lower of this and the configured ceiling
+	MaxDispatchRate float64 `json:"max_dispatch_rate,omitempty"`
+
+	// Links between an agent and the successor that replaced it
+	ReplacedBy string `json:"replaced_by,omitempty"`
+	Replaces   string `json:"replaces,omitempty"`
 }
 
 // RegistrationRequest is sent by agents to register
@@ -48,20 +86,43 @@ type RegistrationRequest struct {
 	Capabilities []string               `json:"capabilities"`
 	Version      string                 `json:"version"`
 	Metadata     map[string]interface{} `json:"metadata,omitempty"`
+
+	// Requested heartbeat interval; clamped by the registry, default 30s
+	HeartbeatInterval int `json:"heartbeat_interval_seconds,omitempty"`
+
+	// Subset of capabilities the agent is optimized for
+	PreferredTaskTypes []string `json:"preferred_task_types,omitempty"`
+
+	// Tasks per second the agent can accept; 0 means no limit of its own
+	MaxDispatchRate float64 `json:"max_dispatch_rate,omitempty"`
 }
 
 // RegistrationResponse is returned after successful registration
 type RegistrationResponse struct {
 	AgentID      string    `json:"agent_id"`
+	AgentToken   string    `json:"agent_token"` // Sent as X-Agent-Token on authenticated calls
 	RegisteredAt time.Time `json:"registered_at"`
 	HeartbeatURL string    `json:"heartbeat_url"`
 	Interval     int       `json:"heartbeat_interval_seconds"`
+
+	// Set when the agent was registered as another agent's successor
+	ReplacedAgentID string `json:"replaced_agent_id,omitempty"`
 }
 
 // HeartbeatRequest is sent by agents periodically
 type HeartbeatRequest struct {
 	Status   AgentStatus            `json:"status"`
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
+
+	// Progress of long-running tasks the agent is executing
+	TaskProgress []TaskProgress `json:"task_progress,omitempty"`
+}
+
+// TaskProgress reports partial progress of a task
+type TaskProgress struct {
+	TaskID  string  `json:"task_id"`
+	Percent float64 `json:"percent"`
+	Message string  `json:"message,omitempty"`
 }
 
 // HeartbeatResponse confirms heartbeat received
@@ -71,8 +132,30 @@ type HeartbeatResponse struct {
 	Timestamp    time.Time `json:"timestamp"`
 }
 
+// CapabilityUpdateRequest adds and removes capabilities on a registered agent
+type CapabilityUpdateRequest struct {
+	Add    []string `json:"add"`
+	Remove []string `json:"remove"`
+}
+
 // AgentListResponse returns list of agents
 type AgentListResponse struct {
 	Agents []Agent `json:"agents"`
 	Count  int     `json:"count"`
+
+	// Set when the store was only partly available and the list is incomplete
+	Degraded       bool     `json:"degraded,omitempty"`
+	UnavailableIDs []string `json:"unavailable_ids,omitempty"`
+	Error          string   `json:"error,omitempty"`
+}
+
+// AgentListing is every registered agent the store could load. When it was
+// only partly available, Degraded is set, Unavailable holds the IDs whose
+// records could not be loaded, and Err is why the active set itself could
+// not be read, if it could not.
+type AgentListing struct {
+	Agents      []*Agent
+	Degraded    bool
+	Unavailable []string
+	Err         error
 }
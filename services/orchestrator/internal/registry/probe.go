@@ -0,0 +1,102 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// defaultProbeTimeout bounds the reachability probe made at registration
+const defaultProbeTimeout = 2 * time.Second
+
+// ErrAgentUnreachable is returned when an agent fails its registration probe
+// and unreachable agents are rejected
+var ErrAgentUnreachable = errors.New("agent unreachable")
+
+// ReachabilityMode decides what happens when an agent's health endpoint
+// cannot be reached at registration
+type ReachabilityMode string
+
+const (
+	// ReachabilityOff registers agents without probing them
+	ReachabilityOff ReachabilityMode = "off"
+
+	// ReachabilityFlag registers unreachable agents as degraded, so they get
+	// no tasks until a heartbeat reports them healthy
+	ReachabilityFlag ReachabilityMode = "flag"
+
+	// ReachabilityReject refuses to register unreachable agents
+	ReachabilityReject ReachabilityMode = "reject"
+)
+
+// ParseReachabilityMode validates a reachability mode name
+func ParseReachabilityMode(name string) (ReachabilityMode, error) {
+	switch mode := ReachabilityMode(name); mode {
+	case ReachabilityOff, ReachabilityFlag, ReachabilityReject:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown reachability mode %q", name)
+	}
+}
+
+// SetReachabilityProbe makes registration probe GET /health on the agent's
+// host and port, handling failures per mode. A timeout of 0 uses the default.
+func (r *Registry) SetReachabilityProbe(mode ReachabilityMode, timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = defaultProbeTimeout
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.probeMode = mode
+	r.probeTimeout = timeout
+}
+
+// initialStatus probes a registering agent and returns the status it should
+// start in, or ErrAgentUnreachable if it must be refused
+func (r *Registry) initialStatus(req *RegistrationRequest) (AgentStatus, error) {
+	r.mu.RLock()
+	mode, timeout := r.probeMode, r.probeTimeout
+	r.mu.RUnlock()
+
+	if mode == "" || mode == ReachabilityOff {
+		return AgentStatusHealthy, nil
+	}
+
+	err := probeHealth(r.ctx, req.Host, req.Port, timeout)
+	if err == nil {
+		return AgentStatusHealthy, nil
+	}
+	if mode == ReachabilityReject {
+		return "", fmt.Errorf("%w: %s:%d: %v", ErrAgentUnreachable, req.Host, req.Port, err)
+	}
+
+	log.Printf("Agent %s failed its registration probe, registering as degraded: %v", req.Name, err)
+	return AgentStatusDegraded, nil
+}
+
+// probeHealth reports whether GET /health on the host and port succeeds
+// within timeout
+func probeHealth(ctx context.Context, host string, port int, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	url := fmt.Sprintf("http://%s:%d/health", host, port)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("health check returned %d", resp.StatusCode)
+	}
+	return nil
+}
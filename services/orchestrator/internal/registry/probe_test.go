@@ -0,0 +1,132 @@
+package registry
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// probeTarget returns a registration aimed at url
+func probeTarget(t *testing.T, name, url string) *RegistrationRequest {
+	t.Helper()
+	host, portStr, err := net.SplitHostPort(url[len("http://"):])
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, _ := strconv.Atoi(portStr)
+	req := registration(name, AgentTypeCost)
+	req.Host, req.Port = host, port
+	return req
+}
+
+// healthServer answers GET /health with status
+func healthServer(t *testing.T, status int) string {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, req *http.Request) { w.WriteHeader(status) })
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+// closedAddress returns the URL of a port nothing listens on
+func closedAddress(t *testing.T) string {
+	t.Helper()
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	return srv.URL
+}
+
+func TestRegistrationProbe(t *testing.T) {
+	reachable := healthServer(t, http.StatusOK)
+	failing := healthServer(t, http.StatusInternalServerError)
+	unreachable := closedAddress(t)
+
+	tests := []struct {
+		mode   ReachabilityMode
+		url    string
+		status AgentStatus // Empty when registration is refused
+	}{
+		{ReachabilityOff, unreachable, AgentStatusHealthy},
+		{ReachabilityFlag, reachable, AgentStatusHealthy},
+		{ReachabilityFlag, unreachable, AgentStatusDegraded},
+		{ReachabilityFlag, failing, AgentStatusDegraded},
+		{ReachabilityReject, reachable, AgentStatusHealthy},
+		{ReachabilityReject, unreachable, ""},
+		{ReachabilityReject, failing, ""},
+	}
+	for _, tt := range tests {
+		reg := newTestRegistry(t)
+		reg.SetReachabilityProbe(tt.mode, time.Second)
+
+		resp, err := reg.Register(probeTarget(t, "cost-1", tt.url))
+		if tt.status == "" {
+			if !errors.Is(err, ErrAgentUnreachable) {
+				t.Errorf("%s mode, %s: err = %v, want ErrAgentUnreachable", tt.mode, tt.url, err)
+			}
+			if listing := reg.ListAgents(); len(listing.Agents) != 0 {
+				t.Errorf("%s mode, %s: refused agent registered", tt.mode, tt.url)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s mode, %s: %v", tt.mode, tt.url, err)
+			continue
+		}
+		if agent, _ := reg.GetAgent(resp.AgentID); agent.Status != tt.status {
+			t.Errorf("%s mode, %s: registered %s, want %s", tt.mode, tt.url, agent.Status, tt.status)
+		}
+	}
+}
+
+func TestRegistrationProbeTimesOut(t *testing.T) {
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-release:
+		case <-req.Context().Done():
+		}
+	})
+	slow := httptest.NewServer(mux)
+	t.Cleanup(slow.Close)
+	t.Cleanup(func() { close(release) })
+
+	reg := newTestRegistry(t)
+	reg.SetReachabilityProbe(ReachabilityReject, 50*time.Millisecond)
+	start := time.Now()
+	if _, err := reg.Register(probeTarget(t, "cost-1", slow.URL)); !errors.Is(err, ErrAgentUnreachable) {
+		t.Errorf("err = %v, want ErrAgentUnreachable", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("probe took %v, want it bounded by the timeout", elapsed)
+	}
+}
+
+func TestRegisterEndpointRejectsUnreachableAgent(t *testing.T) {
+	reg := newTestRegistry(t)
+	reg.SetReachabilityProbe(ReachabilityReject, time.Second)
+	router := newTestRouter(reg)
+
+	if w := doJSON(t, router, http.MethodPost, "/agents/register", probeTarget(t, "cost-1", closedAddress(t))); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("unreachable agent: status %d, want 422", w.Code)
+	}
+	if w := doJSON(t, router, http.MethodPost, "/agents/register", probeTarget(t, "cost-2", healthServer(t, http.StatusOK))); w.Code != http.StatusCreated {
+		t.Errorf("reachable agent: status %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestParseReachabilityMode(t *testing.T) {
+	for _, name := range []string{"off", "flag", "reject"} {
+		if mode, err := ParseReachabilityMode(name); err != nil || string(mode) != name {
+			t.Errorf("parse %q = %q, %v", name, mode, err)
+		}
+	}
+	if _, err := ParseReachabilityMode("warn"); err == nil {
+		t.Error("parsed an unknown mode")
+	}
+}
@@ -2,67 +2,215 @@ package registry
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
+	mathrand "math/rand"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-redis/redis/v8"
-	"github.com/google/uuid"
+
+	"optiinfra/services/orchestrator/internal/idgen"
 )
 
 const (
 	// Redis keys
 	agentKeyPrefix     = "agent:"
+	agentTokenPrefix   = "agent:token:"
 	activeAgentsSetKey = "agents:active"
+	lockKeyPrefix      = "agents:lock:"
+
+	// Lock held by the replica that runs health checks
+	healthCheckLockName = "health-check"
 
-	// TTL for agent entries in Redis
-	agentTTL = 60 * time.Second
+	// Minimum TTL for agent entries in Redis; agents with long heartbeat
+	// intervals get twice their interval
+	defaultAgentTTL = 60 * time.Second
+
+	// Bounds on the heartbeat interval an agent may negotiate
+	defaultHeartbeatInterval = 30 * time.Second
+	minHeartbeatInterval     = 5 * time.Second
+	maxHeartbeatInterval     = 10 * time.Minute
 
 	// Health check interval
-	healthCheckInterval = 30 * time.Second
+	defaultHealthCheckInterval = 30 * time.Second
 
 	// Heartbeat timeout (if no heartbeat for this long, mark unhealthy)
-	heartbeatTimeout = 45 * time.Second
+	defaultHeartbeatTimeout = 45 * time.Second
 )
 
 // Registry manages agent registration and discovery
 type Registry struct {
-	redis  *redis.Client
-	ctx    context.Context
-	mu     sync.RWMutex
-	stopCh chan struct{}
+	store    AgentStore
+	ctx      context.Context
+	mu       sync.RWMutex
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+
+	statusLog *statusLogger
+
+	// Decayed task success rate per agent, fed by the task router
+	reliability *reliabilityTracker
+
+	// Capabilities merged into registrations by agent type
+	defaultCapabilities map[AgentType][]string
+
+	// Metadata types enforced by agent type
+	metadataSchemas map[AgentType]MetadataSchema
+	coerceMetadata  bool
+
+	// Serializes scheduled and on-demand health checks
+	healthCheckMu sync.Mutex
+
+	// When set, only the replica holding the health check lock runs checks
+	replicaID      string
+	leaderElection bool
+
+	// Silence after which an agent is marked unreachable; adjustable at runtime
+	heartbeatTimeout time.Duration
+
+	// How often agent health is checked
+	healthCheckInterval time.Duration
+
+	// Caps on registered agents, guarded by mu, the agents counted against
+	// them, and the registration rate
+	limits       RegistrationLimits
+	counts       *agentCounts
+	registerRate atomic.Pointer[tokenBucket]
+
+	// Health probe of registering agents
+	probeMode    ReachabilityMode
+	probeTimeout time.Duration
+
+	listenersMu sync.RWMutex
+	listeners   []EventListener
+
+	// Generates agent IDs, guarded by mu
+	ids idgen.IDGenerator
 }
 
-// NewRegistry creates a new agent registry
+// ErrInvalidAgentToken is returned when an agent-authenticated call presents
+// a missing or wrong token
+var ErrInvalidAgentToken = errors.New("invalid agent token")
+
+// NewRegistry creates a new agent registry backed by Redis
 func NewRegistry(redisClient *redis.Client) *Registry {
+	return NewRegistryWithStore(NewRedisAgentStore(redisClient))
+}
+
+// NewRegistryWithStore creates a new agent registry backed by the given store
+func NewRegistryWithStore(store AgentStore) *Registry {
+	return NewRegistryWithConfig(store, DefaultConfig())
+}
+
+// NewRegistryWithConfig creates a new agent registry backed by the given
+// store, with the given liveness settings. The store keeps agents for at
+// least the config's AgentTTL.
+func NewRegistryWithConfig(store AgentStore, cfg Config) *Registry {
+	if s, ok := store.(agentTTLSetter); ok {
+		s.SetTTL(cfg.AgentTTL)
+	}
+
+	ids := idgen.UUIDGenerator{}
 	return &Registry{
-		redis:  redisClient,
+		store:  store,
 		ctx:    context.Background(),
 		stopCh: make(chan struct{}),
+
+		statusLog:           newStatusLogger(statusLogWindow),
+		reliability:         newReliabilityTracker(reliabilityHalfLife),
+		counts:              newAgentCounts(cfg.AgentTTL),
+		defaultCapabilities: DefaultCapabilities(),
+		replicaID:           ids.NewID(),
+		heartbeatTimeout:    cfg.HeartbeatTimeout,
+		healthCheckInterval: cfg.HealthCheckInterval,
+		ids:                 ids,
 	}
 }
 
-// Start begins the health monitoring goroutine
+// Start counts the agents already registered and begins the health
+// monitoring goroutine
 func (r *Registry) Start() {
+	r.syncAgentCounts()
+	r.wg.Add(1)
 	go r.healthMonitor()
 	log.Println("Agent registry started")
 }
 
-// Stop stops the health monitoring
+// Stop stops the health monitoring and waits for it to exit
 func (r *Registry) Stop() {
-	close(r.stopCh)
+	r.stopOnce.Do(func() {
+		close(r.stopCh)
+	})
+	r.wg.Wait()
 	log.Println("Agent registry stopped")
 }
 
 // Register registers a new agent
 func (r *Registry) Register(req *RegistrationRequest) (*RegistrationResponse, error) {
+	// Types are fixed, so routing and listings by type can reach every agent
+	if _, err := ParseAgentType(string(req.Type)); err != nil {
+		return nil, err
+	}
+	if err := r.allowRegistration(); err != nil {
+		return nil, err
+	}
+
+	// Probe before taking the lock; the agent may take a while to answer
+	status, err := r.initialStatus(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.register(req, status)
+	if err != nil {
+		return nil, err
+	}
+
+	r.emit(Event{
+		Type:      EventAgentRegistered,
+		AgentID:   resp.AgentID,
+		AgentType: req.Type,
+	})
+
+	return resp, nil
+}
+
+func (r *Registry) register(req *RegistrationRequest, status AgentStatus) (*RegistrationResponse, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	return r.registerLocked("", req, status, "")
+}
+
+// registerLocked stores a new agent under agentID, or a generated ID if
+// empty, recording the agent it replaces if any. It must be called with
+// r.mu held.
+func (r *Registry) registerLocked(agentID string, req *RegistrationRequest, status AgentStatus, replaces string) (*RegistrationResponse, error) {
+	metadata, capabilities, preferred, err := r.registrationFields(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.checkCapacity(req.Type, replaces); err != nil {
+		return nil, err
+	}
 
 	// Generate agent ID
-	agentID := uuid.New().String()
+	if agentID == "" {
+		agentID = r.ids.NewID()
+	}
+
+	// Issue a token the agent presents on authenticated calls
+	token, err := generateAgentToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate agent token: %w", err)
+	}
 
 	// Create agent
 	agent := &Agent{
@@ -71,90 +219,252 @@ func (r *Registry) Register(req *RegistrationRequest) (*RegistrationResponse, er
 		Type:         req.Type,
 		Host:         req.Host,
 		Port:         req.Port,
-		Capabilities: req.Capabilities,
-		Status:       AgentStatusHealthy,
+		Capabilities: capabilities,
+		Status:       status,
 		Version:      req.Version,
 		RegisteredAt: time.Now(),
 		LastSeen:     time.Now(),
-		Metadata:     req.Metadata,
+		Metadata:     metadata,
+
+		HeartbeatInterval:  negotiateHeartbeatInterval(req.HeartbeatInterval),
+		PreferredTaskTypes: preferred,
+		MaxDispatchRate:    dispatchRate(req.MaxDispatchRate),
+		Replaces:           replaces,
 	}
 
 	// Store in Redis
+	if err := r.store.SaveTokenHash(r.ctx, agentID, hashAgentToken(token)); err != nil {
+		return nil, fmt.Errorf("failed to store agent token: %w", err)
+	}
 	if err := r.storeAgent(agent); err != nil {
 		return nil, fmt.Errorf("failed to store agent: %w", err)
 	}
 
 	// Add to active agents set
-	if err := r.redis.SAdd(r.ctx, activeAgentsSetKey, agentID).Err(); err != nil {
+	if err := r.store.AddActive(r.ctx, agentID); err != nil {
 		return nil, fmt.Errorf("failed to add to active set: %w", err)
 	}
+	r.counts.add(agent)
 
 	log.Printf("Agent registered: %s (%s) - %s", agent.Name, agent.Type, agent.ID)
 
 	return &RegistrationResponse{
 		AgentID:      agentID,
+		AgentToken:   token,
 		RegisteredAt: agent.RegisteredAt,
 		HeartbeatURL: fmt.Sprintf("/agents/%s/heartbeat", agentID),
-		Interval:     30, // heartbeat every 30 seconds
+		Interval:     agent.HeartbeatInterval,
 	}, nil
 }
 
+// registrationFields validates a registration's metadata and capabilities.
+// It returns the metadata, the capabilities with the type's defaults merged
+// in, and the preferred task types the agent has a capability for. It must
+// be called with r.mu held.
+func (r *Registry) registrationFields(req *RegistrationRequest) (map[string]interface{}, []string, []string, error) {
+	metadata, err := r.checkMetadata(req.Type, req.Metadata)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for _, capability := range req.Capabilities {
+		if err := ValidateCapability(capability); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	// Fill in standard capabilities the agent forgot to advertise
+	capabilities, added := withDefaultCapabilities(req.Capabilities, r.defaultCapabilities[req.Type])
+	if len(added) > 0 {
+		log.Printf("Added default %s capabilities to agent %s: %v", req.Type, req.Name, added)
+	}
+
+	// A preference only biases routing among agents with the capability
+	preferred, dropped := supportedPreferences(req.PreferredTaskTypes, capabilities)
+	if len(dropped) > 0 {
+		log.Printf("Ignoring preferred task types agent %s has no capability for: %v", req.Name, dropped)
+	}
+	return metadata, capabilities, preferred, nil
+}
+
 // Heartbeat updates agent's last seen time and status
 func (r *Registry) Heartbeat(agentID string, req *HeartbeatRequest) (*HeartbeatResponse, error) {
+	resp, agent, err := r.heartbeat(agentID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.TaskProgress) > 0 {
+		r.emit(Event{
+			Type:      EventTaskProgress,
+			AgentID:   agentID,
+			AgentType: agent.Type,
+			Details:   map[string]interface{}{"task_progress": req.TaskProgress},
+		})
+	}
+
+	return resp, nil
+}
+
+func (r *Registry) heartbeat(agentID string, req *HeartbeatRequest) (*HeartbeatResponse, *Agent, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	// Get existing agent
 	agent, err := r.getAgent(agentID)
 	if err != nil {
-		return nil, fmt.Errorf("agent not found: %w", err)
+		return nil, nil, fmt.Errorf("agent not found: %w", err)
+	}
+
+	metadata, err := r.checkMetadata(agent.Type, req.Metadata)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Update status and last seen
+	// Update status and last seen; a replaced agent stays draining
+	status := req.Status
+	if agent.ReplacedBy != "" {
+		status = AgentStatusDraining
+	}
+	if agent.Status != status {
+		r.statusLog.logTransition(agent, agent.Status, status, "reported via heartbeat")
+	}
 	agent.LastSeen = time.Now()
-	agent.Status = req.Status
+	agent.Status = status
 
 	// Merge metadata
-	if req.Metadata != nil {
+	if metadata != nil {
 		if agent.Metadata == nil {
 			agent.Metadata = make(map[string]interface{})
 		}
-		for k, v := range req.Metadata {
+		for k, v := range metadata {
 			agent.Metadata[k] = v
 		}
 	}
 
 	// Store updated agent
 	if err := r.storeAgent(agent); err != nil {
-		return nil, fmt.Errorf("failed to update agent: %w", err)
+		return nil, nil, fmt.Errorf("failed to update agent: %w", err)
 	}
 
 	return &HeartbeatResponse{
 		Received:     true,
-		NextInterval: 30,
+		NextInterval: int(agent.heartbeatInterval().Seconds()),
 		Timestamp:    time.Now(),
-	}, nil
+	}, agent, nil
 }
 
 // Unregister removes an agent from the registry
 func (r *Registry) Unregister(agentID string) error {
+	if err := r.unregister(agentID); err != nil {
+		return err
+	}
+
+	r.emit(Event{
+		Type:    EventAgentUnregistered,
+		AgentID: agentID,
+	})
+
+	return nil
+}
+
+func (r *Registry) unregister(agentID string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	// Remove from active set
-	if err := r.redis.SRem(r.ctx, activeAgentsSetKey, agentID).Err(); err != nil {
-		return fmt.Errorf("failed to remove from active set: %w", err)
-	}
-
-	// Delete agent key
-	if err := r.redis.Del(r.ctx, agentKey(agentID)).Err(); err != nil {
-		return fmt.Errorf("failed to delete agent: %w", err)
+	// Remove from active set and delete agent and token
+	if err := r.store.DeleteAgent(r.ctx, agentID); err != nil {
+		return err
 	}
+	r.counts.forget(agentID)
+	r.reliability.forget(agentID)
 
 	log.Printf("Agent unregistered: %s", agentID)
 	return nil
 }
 
+// UpdateCapabilities adds and removes capabilities on a registered agent
+// without re-registration. The caller must present the agent's token.
+// Removing a plain name removes every version of it; removing "name@version"
+// removes only that version.
+func (r *Registry) UpdateCapabilities(agentID, token string, req *CapabilityUpdateRequest) (*Agent, error) {
+	for _, capability := range req.Add {
+		if err := ValidateCapability(capability); err != nil {
+			return nil, err
+		}
+	}
+
+	agent, added, removed, err := r.updateCapabilities(agentID, token, req)
+	if err != nil {
+		return nil, err
+	}
+
+	r.emit(Event{
+		Type:      EventCapabilitiesUpdated,
+		AgentID:   agent.ID,
+		AgentType: agent.Type,
+		Details: map[string]interface{}{
+			"added":        added,
+			"removed":      removed,
+			"capabilities": agent.Capabilities,
+		},
+	})
+
+	return agent, nil
+}
+
+func (r *Registry) updateCapabilities(agentID, token string, req *CapabilityUpdateRequest) (*Agent, []string, []string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.verifyAgentToken(agentID, token); err != nil {
+		return nil, nil, nil, err
+	}
+
+	agent, err := r.getAgent(agentID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("agent not found: %w", err)
+	}
+
+	remove := make(map[string]bool, len(req.Remove))
+	for _, cap := range req.Remove {
+		remove[cap] = true
+	}
+	removing := func(cap string) bool {
+		name, _ := SplitCapability(cap)
+		return remove[cap] || remove[name]
+	}
+
+	present := make(map[string]bool, len(agent.Capabilities))
+	capabilities := make([]string, 0, len(agent.Capabilities)+len(req.Add))
+	removed := make([]string, 0)
+	for _, cap := range agent.Capabilities {
+		if removing(cap) {
+			removed = append(removed, cap)
+			continue
+		}
+		present[cap] = true
+		capabilities = append(capabilities, cap)
+	}
+
+	added := make([]string, 0)
+	for _, cap := range req.Add {
+		if present[cap] || removing(cap) {
+			continue
+		}
+		present[cap] = true
+		capabilities = append(capabilities, cap)
+		added = append(added, cap)
+	}
+
+	agent.Capabilities = capabilities
+	if err := r.storeAgent(agent); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to update agent: %w", err)
+	}
+
+	log.Printf("Agent %s capabilities updated: +%v -%v", agentID, added, removed)
+	return agent, added, removed, nil
+}
+
 // GetAgent retrieves a specific agent by ID
 func (r *Registry) GetAgent(agentID string) (*Agent, error) {
 	r.mu.RLock()
@@ -163,28 +473,45 @@ func (r *Registry) GetAgent(agentID string) (*Agent, error) {
 	return r.getAgent(agentID)
 }
 
-// GetAllAgents retrieves all registered agents
+// GetAllAgents retrieves all registered agents. Agents whose records cannot
+// be loaded are left out; use ListAgents to learn which.
 func (r *Registry) GetAllAgents() ([]*Agent, error) {
+	listing := r.ListAgents()
+	if listing.Err != nil {
+		return nil, listing.Err
+	}
+	return listing.Agents, nil
+}
+
+// ListAgents retrieves all registered agents, reporting a degraded listing
+// instead of failing when the store is only partly available
+func (r *Registry) ListAgents() *AgentListing {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
+	listing := &AgentListing{Agents: make([]*Agent, 0)}
+
 	// Get all active agent IDs
-	agentIDs, err := r.redis.SMembers(r.ctx, activeAgentsSetKey).Result()
+	agentIDs, err := r.store.ActiveAgentIDs(r.ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get active agents: %w", err)
+		log.Printf("Warning: failed to get active agents: %v", err)
+		listing.Degraded = true
+		listing.Err = fmt.Errorf("failed to get active agents: %w", err)
+		return listing
 	}
 
-	agents := make([]*Agent, 0, len(agentIDs))
 	for _, id := range agentIDs {
 		agent, err := r.getAgent(id)
 		if err != nil {
 			log.Printf("Warning: failed to get agent %s: %v", id, err)
+			listing.Degraded = true
+			listing.Unavailable = append(listing.Unavailable, id)
 			continue
 		}
-		agents = append(agents, agent)
+		listing.Agents = append(listing.Agents, agent)
 	}
 
-	return agents, nil
+	return listing
 }
 
 // GetAgentsByType retrieves all agents of a specific type
@@ -204,7 +531,8 @@ func (r *Registry) GetAgentsByType(agentType AgentType) ([]*Agent, error) {
 	return filtered, nil
 }
 
-// GetAgentWithCapability finds an agent with specific capability
+// GetAgentWithCapability finds a healthy agent of a type advertising a
+// capability that meets the requirement, "name" or "name@constraint"
 func (r *Registry) GetAgentWithCapability(agentType AgentType, capability string) (*Agent, error) {
 	agents, err := r.GetAgentsByType(agentType)
 	if err != nil {
@@ -213,18 +541,39 @@ func (r *Registry) GetAgentWithCapability(agentType AgentType, capability string
 
 	// Filter by capability and status
 	for _, agent := range agents {
-		if agent.Status == AgentStatusHealthy {
-			for _, cap := range agent.Capabilities {
-				if cap == capability {
-					return agent, nil
-				}
-			}
+		if agent.Status == AgentStatusHealthy && agent.HasCapability(capability) {
+			return agent, nil
 		}
 	}
 
 	return nil, fmt.Errorf("no healthy %s agent found with capability: %s", agentType, capability)
 }
 
+// GetAgentsWithCapability returns all healthy agents advertising a capability
+// that meets the requirement, "name" or "name@constraint", optionally
+// restricted to a single agent type (empty type matches all)
+func (r *Registry) GetAgentsWithCapability(capability string, agentType AgentType) ([]*Agent, error) {
+	allAgents, err := r.GetAllAgents()
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make([]*Agent, 0)
+	for _, agent := range allAgents {
+		if agent.Status != AgentStatusHealthy {
+			continue
+		}
+		if agentType != "" && agent.Type != agentType {
+			continue
+		}
+		if agent.HasCapability(capability) {
+			matched = append(matched, agent)
+		}
+	}
+
+	return matched, nil
+}
+
 // GetHealthyAgents returns only healthy agents
 func (r *Registry) GetHealthyAgents() ([]*Agent, error) {
 	allAgents, err := r.GetAllAgents()
@@ -246,58 +595,202 @@ func (r *Registry) GetHealthyAgents() ([]*Agent, error) {
 // INTERNAL HELPERS
 // ===================================================================
 
-func (r *Registry) storeAgent(agent *Agent) error {
-	data, err := json.Marshal(agent)
-	if err != nil {
-		return fmt.Errorf("failed to marshal agent: %w", err)
+// negotiateHeartbeatInterval clamps an agent's requested heartbeat interval
+// in seconds, using the default when none was requested
+func negotiateHeartbeatInterval(requested int) int {
+	interval := time.Duration(requested) * time.Second
+	switch {
+	case requested <= 0:
+		interval = defaultHeartbeatInterval
+	case interval < minHeartbeatInterval:
+		interval = minHeartbeatInterval
+	case interval > maxHeartbeatInterval:
+		interval = maxHeartbeatInterval
+	}
+	return int(interval.Seconds())
+}
+
+// dispatchRate sanitizes an agent's reported dispatch rate; a negative rate
+// is treated as none
+func dispatchRate(reported float64) float64 {
+	if reported < 0 {
+		return 0
+	}
+	return reported
+}
+
+// heartbeatInterval returns the agent's negotiated heartbeat interval
+func (a *Agent) heartbeatInterval() time.Duration {
+	if a.HeartbeatInterval <= 0 {
+		return defaultHeartbeatInterval
 	}
+	return time.Duration(a.HeartbeatInterval) * time.Second
+}
 
-	// Store with TTL
-	if err := r.redis.Set(r.ctx, agentKey(agent.ID), data, agentTTL).Err(); err != nil {
-		return fmt.Errorf("failed to store in redis: %w", err)
+// agentTTLFor returns how long a stored agent lives without a heartbeat: at
+// least base and at least twice its heartbeat interval
+func agentTTLFor(agent *Agent, base time.Duration) time.Duration {
+	if ttl := 2 * agent.heartbeatInterval(); ttl > base {
+		return ttl
 	}
+	return base
+}
 
+// heartbeatTimeoutFor stretches the heartbeat timeout for agents whose
+// interval would otherwise make them miss it between heartbeats
+func heartbeatTimeoutFor(agent *Agent, timeout time.Duration) time.Duration {
+	if grace := agent.heartbeatInterval() * 3 / 2; grace > timeout {
+		return grace
+	}
+	return timeout
+}
+
+func (r *Registry) storeAgent(agent *Agent) error {
+	if err := r.store.SaveAgent(r.ctx, agent); err != nil {
+		return err
+	}
+	r.counts.touch(agent)
 	return nil
 }
 
-func (r *Registry) getAgent(agentID string) (*Agent, error) {
-	data, err := r.redis.Get(r.ctx, agentKey(agentID)).Result()
-	if err == redis.Nil {
-		return nil, fmt.Errorf("agent not found")
+func (r *Registry) verifyAgentToken(agentID, token string) error {
+	if token == "" {
+		return ErrInvalidAgentToken
+	}
+
+	stored, err := r.store.GetTokenHash(r.ctx, agentID)
+	if errors.Is(err, ErrAgentNotFound) {
+		return ErrInvalidAgentToken
 	} else if err != nil {
-		return nil, fmt.Errorf("failed to get from redis: %w", err)
+		return fmt.Errorf("failed to get agent token: %w", err)
 	}
 
-	var agent Agent
-	if err := json.Unmarshal([]byte(data), &agent); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal agent: %w", err)
+	if subtle.ConstantTimeCompare([]byte(stored), []byte(hashAgentToken(token))) != 1 {
+		return ErrInvalidAgentToken
 	}
+	return nil
+}
 
-	return &agent, nil
+func generateAgentToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
 }
 
-func agentKey(agentID string) string {
-	return agentKeyPrefix + agentID
+// hashAgentToken hashes tokens at rest so a Redis dump doesn't leak them
+func hashAgentToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (r *Registry) getAgent(agentID string) (*Agent, error) {
+	agent, err := r.store.GetAgent(r.ctx, agentID)
+	if errors.Is(err, ErrAgentNotFound) {
+		// Expired; it no longer counts against the caps
+		r.counts.forget(agentID)
+	}
+	return agent, err
 }
 
 // ===================================================================
 // HEALTH MONITORING
 // ===================================================================
 
+// RefreshHealth re-evaluates agent health immediately instead of waiting for
+// the next tick and returns the resulting agents. A refresh that overlaps a
+// running check waits for it rather than running concurrently.
+func (r *Registry) RefreshHealth() ([]*Agent, error) {
+	r.healthCheckMu.Lock()
+	r.checkAgentHealth()
+	r.healthCheckMu.Unlock()
+
+	return r.GetAllAgents()
+}
+
+// HeartbeatTimeout returns how long an agent may be silent before it is
+// marked unreachable
+func (r *Registry) HeartbeatTimeout() time.Duration {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.heartbeatTimeout
+}
+
+// SetHeartbeatTimeout changes the heartbeat timeout; it takes effect on the
+// next health check
+func (r *Registry) SetHeartbeatTimeout(timeout time.Duration) error {
+	if timeout <= 0 {
+		return fmt.Errorf("heartbeat timeout must be positive")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.heartbeatTimeout = timeout
+	return nil
+}
+
+// SetIDGenerator sets how agent IDs are generated; it must be called before
+// any agent registers
+func (r *Registry) SetIDGenerator(ids idgen.IDGenerator) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ids = ids
+}
+
+// SetLeaderElection makes replicas compete for a store lock so only one runs
+// the scheduled health check at a time; it must be called before Start
+func (r *Registry) SetLeaderElection(enabled bool) {
+	r.leaderElection = enabled
+}
+
 func (r *Registry) healthMonitor() {
-	ticker := time.NewTicker(healthCheckInterval)
+	defer r.wg.Done()
+
+	// Spread replicas across the interval so they don't check in lockstep
+	jitter := time.Duration(mathrand.Int63n(int64(r.healthCheckInterval)))
+	select {
+	case <-time.After(jitter):
+	case <-r.stopCh:
+		return
+	}
+
+	ticker := time.NewTicker(r.healthCheckInterval)
 	defer ticker.Stop()
 
 	for {
+		r.syncAgentCounts()
+		if r.isHealthCheckLeader() {
+			r.healthCheckMu.Lock()
+			r.checkAgentHealth()
+			r.healthCheckMu.Unlock()
+		}
+		r.statusLog.flush()
+
 		select {
 		case <-ticker.C:
-			r.checkAgentHealth()
 		case <-r.stopCh:
 			return
 		}
 	}
 }
 
+// isHealthCheckLeader takes or renews the health check lock when leader
+// election is enabled. The lock outlives one interval so the holder keeps it
+// across ticks.
+func (r *Registry) isHealthCheckLeader() bool {
+	if !r.leaderElection {
+		return true
+	}
+
+	held, err := r.store.AcquireLock(r.ctx, healthCheckLockName, r.replicaID, 2*r.healthCheckInterval)
+	if err != nil {
+		log.Printf("Skipping health check: %v", err)
+		return false
+	}
+	return held
+}
+
 func (r *Registry) checkAgentHealth() {
 	// Don't lock here - GetAllAgents will handle its own locking
 	agents, err := r.GetAllAgents()
@@ -306,15 +799,16 @@ func (r *Registry) checkAgentHealth() {
 		return
 	}
 
+	timeout := r.HeartbeatTimeout()
 	now := time.Now()
 	for _, agent := range agents {
 		timeSinceLastSeen := now.Sub(agent.LastSeen)
 
 		// Mark unhealthy if no heartbeat for too long
-		if timeSinceLastSeen > heartbeatTimeout {
+		if timeSinceLastSeen > heartbeatTimeoutFor(agent, timeout) {
 			if agent.Status != AgentStatusUnreachable {
-				log.Printf("Agent %s (%s) is unreachable - last seen %v ago",
-					agent.Name, agent.ID, timeSinceLastSeen)
+				r.statusLog.logTransition(agent, agent.Status, AgentStatusUnreachable,
+					fmt.Sprintf("last seen %v ago", timeSinceLastSeen.Round(time.Second)))
 				agent.Status = AgentStatusUnreachable
 				
 				// Lock only for the update
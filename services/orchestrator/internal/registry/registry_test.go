@@ -0,0 +1,89 @@
+package registry
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"testing"
+)
+
+// registerWithStatus registers an agent and reports status in a heartbeat
+func registerWithStatus(t *testing.T, reg *Registry, req *RegistrationRequest, status AgentStatus) string {
+	t.Helper()
+	resp, err := reg.Register(req)
+	if err != nil {
+		t.Fatalf("register %s: %v", req.Name, err)
+	}
+	if status != AgentStatusHealthy {
+		if _, err := reg.Heartbeat(resp.AgentID, &HeartbeatRequest{Status: status}); err != nil {
+			t.Fatalf("heartbeat %s: %v", req.Name, err)
+		}
+	}
+	return resp.AgentID
+}
+
+func agentNames(agents []Agent) []string {
+	names := make([]string, len(agents))
+	for i, agent := range agents {
+		names[i] = agent.Name
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestListAgentsByCapability(t *testing.T) {
+	reg := newTestRegistry(t)
+	router := newTestRouter(reg)
+
+	registerWithStatus(t, reg, registration("cost-1", AgentTypeCost, "forecast_spend"), AgentStatusHealthy)
+	registerWithStatus(t, reg, registration("cost-2", AgentTypeCost, "forecast_spend"), AgentStatusUnhealthy)
+	registerWithStatus(t, reg, registration("resource-1", AgentTypeResource, "forecast_spend"), AgentStatusHealthy)
+	registerWithStatus(t, reg, registration("resource-2", AgentTypeResource, "balance_load"), AgentStatusHealthy)
+
+	agents, err := reg.GetAgentsWithCapability("forecast_spend", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []Agent
+	for _, agent := range agents {
+		got = append(got, *agent)
+	}
+	if names := agentNames(got); len(names) != 2 || names[0] != "cost-1" || names[1] != "resource-1" {
+		t.Errorf("healthy agents with forecast_spend = %v, want [cost-1 resource-1]", names)
+	}
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"/agents/capability/forecast_spend", []string{"cost-1", "resource-1"}},
+		{"/agents/capability/forecast_spend?type=resource", []string{"resource-1"}},
+		{"/agents/capability/unknown_capability", []string{}},
+	}
+	for _, tt := range tests {
+		w := doJSON(t, router, http.MethodGet, tt.path, nil)
+		if w.Code != http.StatusOK {
+			t.Errorf("GET %s: status %d", tt.path, w.Code)
+			continue
+		}
+		var resp AgentListResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		names := agentNames(resp.Agents)
+		if len(names) != len(tt.want) || resp.Count != len(tt.want) {
+			t.Errorf("GET %s = %v (count %d), want %v", tt.path, names, resp.Count, tt.want)
+			continue
+		}
+		for i := range names {
+			if names[i] != tt.want[i] {
+				t.Errorf("GET %s = %v, want %v", tt.path, names, tt.want)
+				break
+			}
+		}
+	}
+
+	if w := doJSON(t, router, http.MethodGet, "/agents/capability/forecast_spend?type=quantum", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown type filter: status %d, want 400", w.Code)
+	}
+}
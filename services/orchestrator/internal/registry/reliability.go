@@ -0,0 +1,112 @@
+package registry
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// reliabilityHalfLife is how long it takes a task outcome to count half as
+// much toward an agent's success rate
+const reliabilityHalfLife = 30 * time.Minute
+
+// AgentReliability is an agent's recent task success rate as observed by
+// this replica, with older outcomes decayed exponentially
+type AgentReliability struct {
+	AgentID     string    `json:"agent_id"`
+	SuccessRate float64   `json:"success_rate"`
+	Weight      float64   `json:"weight"` // Decayed number of outcomes behind the rate
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// reliabilityTracker keeps exponentially decayed success and outcome counts
+// per agent
+type reliabilityTracker struct {
+	mu       sync.Mutex
+	halfLife time.Duration
+	now      func() time.Time
+	entries  map[string]*reliabilityEntry
+}
+
+type reliabilityEntry struct {
+	successes float64
+	total     float64
+	updatedAt time.Time
+}
+
+func newReliabilityTracker(halfLife time.Duration) *reliabilityTracker {
+	return &reliabilityTracker{
+		halfLife: halfLife,
+		now:      time.Now,
+		entries:  make(map[string]*reliabilityEntry),
+	}
+}
+
+// record decays the agent's counts to now and adds one outcome
+func (t *reliabilityTracker) record(agentID string, success bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	entry, ok := t.entries[agentID]
+	if !ok {
+		entry = &reliabilityEntry{updatedAt: now}
+		t.entries[agentID] = entry
+	}
+	t.decay(entry, now)
+
+	entry.total++
+	if success {
+		entry.successes++
+	}
+}
+
+// get returns the agent's decayed success rate, if any outcome was recorded
+func (t *reliabilityTracker) get(agentID string) (AgentReliability, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, ok := t.entries[agentID]
+	if !ok || entry.total == 0 {
+		return AgentReliability{}, false
+	}
+	t.decay(entry, t.now())
+
+	return AgentReliability{
+		AgentID:     agentID,
+		SuccessRate: entry.successes / entry.total,
+		Weight:      entry.total,
+		UpdatedAt:   entry.updatedAt,
+	}, true
+}
+
+func (t *reliabilityTracker) forget(agentID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.entries, agentID)
+}
+
+// decay scales an entry's counts by the time elapsed since its last update.
+// It must be called with t.mu held.
+func (t *reliabilityTracker) decay(entry *reliabilityEntry, now time.Time) {
+	elapsed := now.Sub(entry.updatedAt)
+	if elapsed <= 0 {
+		return
+	}
+	factor := math.Pow(0.5, float64(elapsed)/float64(t.halfLife))
+	entry.successes *= factor
+	entry.total *= factor
+	entry.updatedAt = now
+}
+
+// RecordTaskOutcome folds one task attempt's outcome into the agent's
+// success rate
+func (r *Registry) RecordTaskOutcome(agentID string, success bool) {
+	r.reliability.record(agentID, success)
+}
+
+// GetAgentReliability returns the agent's recent success rate, or false if
+// no outcome has been recorded for it
+func (r *Registry) GetAgentReliability(agentID string) (AgentReliability, bool) {
+	return r.reliability.get(agentID)
+}
@@ -0,0 +1,67 @@
+package registry
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestReliabilityDecaysOlderOutcomes(t *testing.T) {
+	tracker := newReliabilityTracker(reliabilityHalfLife)
+	now := time.Now()
+	tracker.now = func() time.Time { return now }
+
+	if _, ok := tracker.get("agent-1"); ok {
+		t.Fatal("reliability reported before any outcome")
+	}
+
+	for i := 0; i < 3; i++ {
+		tracker.record("agent-1", false)
+	}
+	if rel, _ := tracker.get("agent-1"); rel.SuccessRate != 0 || rel.Weight != 3 {
+		t.Errorf("after 3 failures = %+v", rel)
+	}
+
+	// A half-life later the failures count as 1.5 outcomes against one success
+	now = now.Add(reliabilityHalfLife)
+	tracker.record("agent-1", true)
+	rel, _ := tracker.get("agent-1")
+	if math.Abs(rel.SuccessRate-1/2.5) > 1e-9 || math.Abs(rel.Weight-2.5) > 1e-9 {
+		t.Errorf("after a success one half-life later = %+v, want rate 0.4 over 2.5 outcomes", rel)
+	}
+
+	// Decay alone shrinks the weight but keeps the rate
+	now = now.Add(reliabilityHalfLife)
+	rel, _ = tracker.get("agent-1")
+	if math.Abs(rel.SuccessRate-0.4) > 1e-9 || math.Abs(rel.Weight-1.25) > 1e-9 {
+		t.Errorf("after another half-life = %+v", rel)
+	}
+
+	// Recent successes soon outweigh old failures
+	for i := 0; i < 5; i++ {
+		tracker.record("agent-1", true)
+	}
+	if rel, _ = tracker.get("agent-1"); math.Abs(rel.SuccessRate-5.5/6.25) > 1e-9 {
+		t.Errorf("after 5 recent successes = %+v, want rate 0.88", rel)
+	}
+}
+
+func TestUnregisterForgetsReliability(t *testing.T) {
+	reg := newTestRegistry(t)
+	resp, err := reg.Register(registration("cost-1", AgentTypeCost))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg.RecordTaskOutcome(resp.AgentID, true)
+	reg.RecordTaskOutcome(resp.AgentID, false)
+	if rel, ok := reg.GetAgentReliability(resp.AgentID); !ok || math.Abs(rel.SuccessRate-0.5) > 1e-6 {
+		t.Errorf("reliability = %+v, %v", rel, ok)
+	}
+
+	if err := reg.Unregister(resp.AgentID); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := reg.GetAgentReliability(resp.AgentID); ok {
+		t.Error("reliability kept for an unregistered agent")
+	}
+}
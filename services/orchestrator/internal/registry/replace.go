@@ -0,0 +1,91 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"log"
+)
+
+// ErrAgentAlreadyReplaced is returned when replacing an agent that already
+// has a successor
+var ErrAgentAlreadyReplaced = errors.New("agent already replaced")
+
+// ErrReplacementTypeMismatch is returned when a successor's type differs
+// from the agent it replaces
+var ErrReplacementTypeMismatch = errors.New("successor type does not match agent")
+
+// Replace registers a successor for an agent and marks the agent draining
+// in one step. Routing stops sending the old agent new tasks, and tasks
+// submitted for it by ID go to the successor, while its in-flight tasks
+// finish.
+func (r *Registry) Replace(agentID string, req *RegistrationRequest) (*RegistrationResponse, error) {
+	// Types are fixed, so routing and listings by type can reach every agent
+	if _, err := ParseAgentType(string(req.Type)); err != nil {
+		return nil, err
+	}
+	if err := r.allowRegistration(); err != nil {
+		return nil, err
+	}
+
+	// Probe before taking the lock; the agent may take a while to answer
+	status, err := r.initialStatus(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.replace(agentID, req, status)
+	if err != nil {
+		return nil, err
+	}
+
+	r.emit(Event{
+		Type:      EventAgentRegistered,
+		AgentID:   resp.AgentID,
+		AgentType: req.Type,
+	})
+	r.emit(Event{
+		Type:      EventAgentReplaced,
+		AgentID:   agentID,
+		AgentType: req.Type,
+		Details:   map[string]interface{}{"successor_id": resp.AgentID},
+	})
+
+	return resp, nil
+}
+
+func (r *Registry) replace(agentID string, req *RegistrationRequest, status AgentStatus) (*RegistrationResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old, err := r.getAgent(agentID)
+	if err != nil {
+		return nil, err
+	}
+	if old.ReplacedBy != "" {
+		return nil, fmt.Errorf("%w: agent %s was replaced by %s", ErrAgentAlreadyReplaced, agentID, old.ReplacedBy)
+	}
+	if old.Type != req.Type {
+		return nil, fmt.Errorf("%w: agent %s is %s, successor is %s", ErrReplacementTypeMismatch, agentID, old.Type, req.Type)
+	}
+
+	resp, err := r.registerLocked("", req, status, agentID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.statusLog.logTransition(old, old.Status, AgentStatusDraining, fmt.Sprintf("replaced by %s", resp.AgentID))
+	old.Status = AgentStatusDraining
+	old.ReplacedBy = resp.AgentID
+	if err := r.storeAgent(old); err != nil {
+		// Undo the successor so a retry starts clean
+		if delErr := r.store.DeleteAgent(r.ctx, resp.AgentID); delErr != nil {
+			log.Printf("Failed to remove successor %s after failed replacement: %v", resp.AgentID, delErr)
+		}
+		r.counts.forget(resp.AgentID)
+		return nil, fmt.Errorf("failed to mark agent draining: %w", err)
+	}
+
+	log.Printf("Agent %s replaced by %s; draining", agentID, resp.AgentID)
+	resp.ReplacedAgentID = agentID
+	return resp, nil
+}
@@ -0,0 +1,71 @@
+package registry
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestReplaceEndpoint(t *testing.T) {
+	reg := newTestRegistry(t)
+	router := newTestRouter(reg)
+	oldResp, err := reg.Register(registration("cost-1", AgentTypeCost, "analyze_cost"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := oldResp.AgentID
+
+	var events []Event
+	reg.Subscribe(func(e Event) { events = append(events, e) })
+
+	w := doJSON(t, router, http.MethodPost, "/agents/"+old+"/replace", registration("cost-1-v2", AgentTypeCost, "analyze_cost"))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("replace: status %d: %s", w.Code, w.Body.String())
+	}
+	var resp RegistrationResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	predecessor, _ := reg.GetAgent(old)
+	successor, err := reg.GetAgent(resp.AgentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if predecessor.Status != AgentStatusDraining || predecessor.ReplacedBy != successor.ID {
+		t.Errorf("old agent %s, replaced by %q; want draining, replaced by %s", predecessor.Status, predecessor.ReplacedBy, successor.ID)
+	}
+	if successor.Status != AgentStatusHealthy || successor.Replaces != old {
+		t.Errorf("successor %s, replaces %q; want healthy, replacing %s", successor.Status, successor.Replaces, old)
+	}
+	if len(events) != 2 || events[1].Type != EventAgentReplaced || events[1].AgentID != old || events[1].Details["successor_id"] != successor.ID {
+		t.Errorf("events = %+v, want the successor registered, then the old agent replaced", events)
+	}
+
+	// A heartbeat from the old agent does not bring it back into rotation
+	if _, err := reg.Heartbeat(old, &HeartbeatRequest{Status: AgentStatusHealthy}); err != nil {
+		t.Fatal(err)
+	}
+	if agent, _ := reg.GetAgent(old); agent.Status != AgentStatusDraining {
+		t.Errorf("old agent %s after a heartbeat, want draining", agent.Status)
+	}
+	if agents, _ := reg.GetAgentsWithCapability("analyze_cost", AgentTypeCost); len(agents) != 1 || agents[0].ID != successor.ID {
+		t.Errorf("%d routable agents, want only the successor", len(agents))
+	}
+
+	tests := []struct {
+		name   string
+		id     string
+		req    *RegistrationRequest
+		status int
+	}{
+		{"already replaced", old, registration("cost-1-v3", AgentTypeCost), http.StatusConflict},
+		{"type mismatch", successor.ID, registration("perf-1", AgentTypePerformance), http.StatusBadRequest},
+		{"unknown agent", "missing", registration("cost-2", AgentTypeCost), http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if w := doJSON(t, router, http.MethodPost, "/agents/"+tt.id+"/replace", tt.req); w.Code != tt.status {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, w.Code, tt.status, w.Body.String())
+		}
+	}
+}
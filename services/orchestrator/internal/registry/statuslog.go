@@ -0,0 +1,86 @@
+package registry
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// statusLogWindow is how long repeats of the same agent status transition
+// are suppressed after one has been logged
+const statusLogWindow = 5 * time.Minute
+
+// statusLogger deduplicates agent status transition logs so that a flapping
+// agent produces one line per transition per window plus a summary of the
+// repeats, instead of a line on every cycle
+type statusLogger struct {
+	mu      sync.Mutex
+	window  time.Duration
+	now     func() time.Time
+	entries map[string]*statusLogEntry
+}
+
+type statusLogEntry struct {
+	agentID    string
+	from       AgentStatus
+	to         AgentStatus
+	loggedAt   time.Time
+	suppressed int
+}
+
+func newStatusLogger(window time.Duration) *statusLogger {
+	return &statusLogger{
+		window:  window,
+		now:     time.Now,
+		entries: make(map[string]*statusLogEntry),
+	}
+}
+
+// logTransition logs a status transition unless the same transition for the
+// same agent was already logged within the window
+func (sl *statusLogger) logTransition(agent *Agent, from, to AgentStatus, detail string) {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+
+	now := sl.now()
+	key := agent.ID + "|" + string(from) + "|" + string(to)
+
+	entry, ok := sl.entries[key]
+	if ok && now.Sub(entry.loggedAt) < sl.window {
+		entry.suppressed++
+		return
+	}
+
+	if ok && entry.suppressed > 0 {
+		log.Printf("Agent %s (%s) %s -> %s: %s (%d similar transitions suppressed in last %v)",
+			agent.Name, agent.ID, from, to, detail, entry.suppressed, now.Sub(entry.loggedAt).Round(time.Second))
+	} else {
+		log.Printf("Agent %s (%s) %s -> %s: %s", agent.Name, agent.ID, from, to, detail)
+	}
+
+	sl.entries[key] = &statusLogEntry{
+		agentID:  agent.ID,
+		from:     from,
+		to:       to,
+		loggedAt: now,
+	}
+}
+
+// flush summarizes and drops entries whose window has elapsed so suppressed
+// repeats are reported even if the transition never happens again
+func (sl *statusLogger) flush() {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+
+	now := sl.now()
+	for key, entry := range sl.entries {
+		if now.Sub(entry.loggedAt) < sl.window {
+			continue
+		}
+		if entry.suppressed > 0 {
+			log.Printf("Agent %s: %s -> %s transition repeated %d times in last %v",
+				entry.agentID, entry.from, entry.to, entry.suppressed, now.Sub(entry.loggedAt).Round(time.Second))
+		}
+		delete(sl.entries, key)
+	}
+}
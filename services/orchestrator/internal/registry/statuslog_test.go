@@ -0,0 +1,83 @@
+package registry
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// captureLog redirects the standard logger to a buffer for the test
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return &buf
+}
+
+func logLines(buf *bytes.Buffer) []string {
+	var lines []string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func TestRepeatedTransitionsLogOnce(t *testing.T) {
+	buf := captureLog(t)
+	sl := newStatusLogger(time.Minute)
+	now := time.Now()
+	sl.now = func() time.Time { return now }
+	agent := &Agent{ID: "agent-1", Name: "cost-1"}
+
+	for i := 0; i < 5; i++ {
+		sl.logTransition(agent, AgentStatusHealthy, AgentStatusUnreachable, "missed heartbeat")
+		now = now.Add(time.Second)
+	}
+	if lines := logLines(buf); len(lines) != 1 {
+		t.Fatalf("logged %d lines within the window, want 1: %q", len(lines), lines)
+	}
+
+	// A different transition is logged on its own
+	sl.logTransition(agent, AgentStatusUnreachable, AgentStatusHealthy, "reported via heartbeat")
+	if lines := logLines(buf); len(lines) != 2 {
+		t.Fatalf("logged %d lines after a new transition, want 2", len(lines))
+	}
+
+	// Once the window passes the repeats are summarized in a single line
+	buf.Reset()
+	now = now.Add(time.Minute)
+	sl.flush()
+	lines := logLines(buf)
+	if len(lines) != 1 || !strings.Contains(lines[0], "repeated 4 times") {
+		t.Fatalf("summary = %q, want one line reporting 4 repeats", lines)
+	}
+	buf.Reset()
+	sl.flush()
+	if lines := logLines(buf); len(lines) != 0 {
+		t.Errorf("second flush logged %q", lines)
+	}
+}
+
+func TestTransitionAfterWindowReportsSuppressed(t *testing.T) {
+	buf := captureLog(t)
+	sl := newStatusLogger(time.Minute)
+	now := time.Now()
+	sl.now = func() time.Time { return now }
+	agent := &Agent{ID: "agent-1", Name: "cost-1"}
+
+	sl.logTransition(agent, AgentStatusHealthy, AgentStatusUnreachable, "missed heartbeat")
+	sl.logTransition(agent, AgentStatusHealthy, AgentStatusUnreachable, "missed heartbeat")
+	now = now.Add(2 * time.Minute)
+	sl.logTransition(agent, AgentStatusHealthy, AgentStatusUnreachable, "missed heartbeat")
+
+	lines := logLines(buf)
+	if len(lines) != 2 || !strings.Contains(lines[1], "1 similar transitions suppressed") {
+		t.Errorf("lines = %q, want the second to report 1 suppressed repeat", lines)
+	}
+}
@@ -0,0 +1,32 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrAgentNotFound is returned by an AgentStore when an agent does not exist
+var ErrAgentNotFound = errors.New("agent not found")
+
+// AgentStore persists registered agents, their tokens, and the active set
+type AgentStore interface {
+	// SaveAgent stores an agent, refreshing its TTL and its token's TTL
+	SaveAgent(ctx context.Context, agent *Agent) error
+	// GetAgent loads an agent, returning ErrAgentNotFound if it does not exist
+	GetAgent(ctx context.Context, agentID string) (*Agent, error)
+	// DeleteAgent removes an agent, its token, and its active set membership
+	DeleteAgent(ctx context.Context, agentID string) error
+	// AddActive adds an agent ID to the active set
+	AddActive(ctx context.Context, agentID string) error
+	// ActiveAgentIDs returns all IDs in the active set
+	ActiveAgentIDs(ctx context.Context) ([]string, error)
+	// SaveTokenHash stores the hash of an agent's token
+	SaveTokenHash(ctx context.Context, agentID, hash string) error
+	// GetTokenHash loads an agent's token hash, returning ErrAgentNotFound if none
+	GetTokenHash(ctx context.Context, agentID string) (string, error)
+
+	// AcquireLock takes or renews a named lock for owner, reporting whether
+	// owner holds it; the lock lapses after ttl unless renewed
+	AcquireLock(ctx context.Context, name, owner string, ttl time.Duration) (bool, error)
+}
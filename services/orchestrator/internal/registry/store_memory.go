@@ -0,0 +1,142 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// MemoryAgentStore is an in-process AgentStore for single-replica deployments
+// and tests. Agents are deep-copied on save and load and expire after the
+// same TTL the Redis store uses.
+type MemoryAgentStore struct {
+	mu     sync.RWMutex
+	ttl    time.Duration
+	now    func() time.Time
+	agents map[string]memoryAgent
+	tokens map[string]string
+	active map[string]bool
+	locks  map[string]memoryLock
+}
+
+type memoryLock struct {
+	owner     string
+	expiresAt time.Time
+}
+
+type memoryAgent struct {
+	data      []byte
+	expiresAt time.Time
+}
+
+// NewMemoryAgentStore creates an in-memory agent store
+func NewMemoryAgentStore() *MemoryAgentStore {
+	return &MemoryAgentStore{
+		ttl:    defaultAgentTTL,
+		now:    time.Now,
+		agents: make(map[string]memoryAgent),
+		tokens: make(map[string]string),
+		active: make(map[string]bool),
+		locks:  make(map[string]memoryLock),
+	}
+}
+
+// SaveAgent stores a copy of the agent with a TTL covering its heartbeat interval
+func (s *MemoryAgentStore) SaveAgent(ctx context.Context, agent *Agent) error {
+	data, err := json.Marshal(agent)
+	if err != nil {
+		return fmt.Errorf("failed to marshal agent: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.agents[agent.ID] = memoryAgent{data: data, expiresAt: s.now().Add(agentTTLFor(agent, s.ttl))}
+	return nil
+}
+
+// GetAgent returns a copy of a stored agent
+func (s *MemoryAgentStore) GetAgent(ctx context.Context, agentID string) (*Agent, error) {
+	s.mu.RLock()
+	entry, ok := s.agents[agentID]
+	s.mu.RUnlock()
+
+	if !ok || !s.now().Before(entry.expiresAt) {
+		return nil, ErrAgentNotFound
+	}
+
+	var agent Agent
+	if err := json.Unmarshal(entry.data, &agent); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal agent: %w", err)
+	}
+	return &agent, nil
+}
+
+// DeleteAgent removes an agent, its token, and its active set membership
+func (s *MemoryAgentStore) DeleteAgent(ctx context.Context, agentID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.agents, agentID)
+	delete(s.tokens, agentID)
+	delete(s.active, agentID)
+	return nil
+}
+
+// AddActive adds an agent ID to the active set
+func (s *MemoryAgentStore) AddActive(ctx context.Context, agentID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.active[agentID] = true
+	return nil
+}
+
+// ActiveAgentIDs returns all IDs in the active set
+func (s *MemoryAgentStore) ActiveAgentIDs(ctx context.Context) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make([]string, 0, len(s.active))
+	for id := range s.active {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// SaveTokenHash stores the hash of an agent's token
+func (s *MemoryAgentStore) SaveTokenHash(ctx context.Context, agentID, hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens[agentID] = hash
+	return nil
+}
+
+// GetTokenHash loads an agent's token hash; it lives as long as the agent
+func (s *MemoryAgentStore) GetTokenHash(ctx context.Context, agentID string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.agents[agentID]
+	hash, hasToken := s.tokens[agentID]
+	if !ok || !hasToken || !s.now().Before(entry.expiresAt) {
+		return "", ErrAgentNotFound
+	}
+	return hash, nil
+}
+
+// AcquireLock takes or renews a named lock
+func (s *MemoryAgentStore) AcquireLock(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if lock, ok := s.locks[name]; ok && lock.owner != owner && now.Before(lock.expiresAt) {
+		return false, nil
+	}
+	s.locks[name] = memoryLock{owner: owner, expiresAt: now.Add(ttl)}
+	return true, nil
+}
@@ -0,0 +1,127 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"optiinfra/services/orchestrator/internal/codec"
+)
+
+// RedisAgentStore stores agents as JSON in Redis with a TTL
+type RedisAgentStore struct {
+	redis *redis.Client
+	ttl   time.Duration
+}
+
+// NewRedisAgentStore creates a Redis-backed agent store
+func NewRedisAgentStore(redisClient *redis.Client) *RedisAgentStore {
+	return &RedisAgentStore{
+		redis: redisClient,
+		ttl:   defaultAgentTTL,
+	}
+}
+
+// SaveAgent stores an agent with a TTL covering its heartbeat interval,
+// keeping its token alive as long
+func (s *RedisAgentStore) SaveAgent(ctx context.Context, agent *Agent) error {
+	data, err := codec.Marshal(agent)
+	if err != nil {
+		return fmt.Errorf("failed to marshal agent: %w", err)
+	}
+
+	ttl := agentTTLFor(agent, s.ttl)
+	pipe := s.redis.TxPipeline()
+	pipe.Set(ctx, agentKey(agent.ID), data, ttl)
+	pipe.Expire(ctx, agentTokenKey(agent.ID), ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to store in redis: %w", err)
+	}
+
+	return nil
+}
+
+// GetAgent loads an agent from Redis
+func (s *RedisAgentStore) GetAgent(ctx context.Context, agentID string) (*Agent, error) {
+	data, err := s.redis.Get(ctx, agentKey(agentID)).Result()
+	if err == redis.Nil {
+		return nil, ErrAgentNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get from redis: %w", err)
+	}
+
+	var agent Agent
+	if err := codec.Unmarshal([]byte(data), &agent); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal agent: %w", err)
+	}
+
+	return &agent, nil
+}
+
+// DeleteAgent removes an agent, its token, and its active set membership
+func (s *RedisAgentStore) DeleteAgent(ctx context.Context, agentID string) error {
+	if err := s.redis.SRem(ctx, activeAgentsSetKey, agentID).Err(); err != nil {
+		return fmt.Errorf("failed to remove from active set: %w", err)
+	}
+
+	if err := s.redis.Del(ctx, agentKey(agentID), agentTokenKey(agentID)).Err(); err != nil {
+		return fmt.Errorf("failed to delete agent: %w", err)
+	}
+
+	return nil
+}
+
+// AddActive adds an agent ID to the active set
+func (s *RedisAgentStore) AddActive(ctx context.Context, agentID string) error {
+	return s.redis.SAdd(ctx, activeAgentsSetKey, agentID).Err()
+}
+
+// ActiveAgentIDs returns all IDs in the active set
+func (s *RedisAgentStore) ActiveAgentIDs(ctx context.Context) ([]string, error) {
+	return s.redis.SMembers(ctx, activeAgentsSetKey).Result()
+}
+
+// SaveTokenHash stores the hash of an agent's token with the agent TTL
+func (s *RedisAgentStore) SaveTokenHash(ctx context.Context, agentID, hash string) error {
+	return s.redis.Set(ctx, agentTokenKey(agentID), hash, s.ttl).Err()
+}
+
+// GetTokenHash loads an agent's token hash
+func (s *RedisAgentStore) GetTokenHash(ctx context.Context, agentID string) (string, error) {
+	hash, err := s.redis.Get(ctx, agentTokenKey(agentID)).Result()
+	if err == redis.Nil {
+		return "", ErrAgentNotFound
+	}
+	return hash, err
+}
+
+func agentKey(agentID string) string {
+	return agentKeyPrefix + agentID
+}
+
+func agentTokenKey(agentID string) string {
+	return agentTokenPrefix + agentID
+}
+
+// acquireLockScript renews the lock if owner holds it, otherwise takes it
+// only if free
+var acquireLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return 1
+end
+return 0
+`)
+
+// AcquireLock takes or renews a named lock atomically
+func (s *RedisAgentStore) AcquireLock(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	held, err := acquireLockScript.Run(ctx, s.redis, []string{lockKeyPrefix + name}, owner, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lock %s: %w", name, err)
+	}
+	return held == 1, nil
+}
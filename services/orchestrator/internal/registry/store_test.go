@@ -0,0 +1,231 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+// The tests in this file run against every AgentStore implementation. Each
+// constructor also returns a function moving the store's clock forward, so
+// expiry can be tested without sleeping.
+
+func agentStores() map[string]func(t *testing.T) (AgentStore, func(time.Duration)) {
+	return map[string]func(t *testing.T) (AgentStore, func(time.Duration)){
+		"memory": func(t *testing.T) (AgentStore, func(time.Duration)) {
+			store := NewMemoryAgentStore()
+			now := time.Now()
+			store.now = func() time.Time { return now }
+			return store, func(d time.Duration) { now = now.Add(d) }
+		},
+		"redis": func(t *testing.T) (AgentStore, func(time.Duration)) {
+			srv := miniredis.RunT(t)
+			client := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+			t.Cleanup(func() { client.Close() })
+			return NewRedisAgentStore(client), srv.FastForward
+		},
+	}
+}
+
+func forEachAgentStore(t *testing.T, test func(t *testing.T, store AgentStore, advance func(time.Duration))) {
+	for name, newStore := range agentStores() {
+		t.Run(name, func(t *testing.T) {
+			store, advance := newStore(t)
+			test(t, store, advance)
+		})
+	}
+}
+
+func saveTestAgent(t *testing.T, store AgentStore, id string) *Agent {
+	t.Helper()
+	agent := &Agent{
+		ID:           id,
+		Name:         id,
+		Type:         AgentTypeCost,
+		Capabilities: []string{"analyze_cost"},
+		Status:       AgentStatusHealthy,
+		RegisteredAt: time.Now().UTC().Truncate(time.Second),
+	}
+	ctx := context.Background()
+	if err := store.SaveTokenHash(ctx, id, "hash-"+id); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SaveAgent(ctx, agent); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AddActive(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	return agent
+}
+
+func TestAgentStoreSaveAndGet(t *testing.T) {
+	forEachAgentStore(t, func(t *testing.T, store AgentStore, advance func(time.Duration)) {
+		ctx := context.Background()
+		if _, err := store.GetAgent(ctx, "missing"); !errors.Is(err, ErrAgentNotFound) {
+			t.Errorf("get missing agent: %v, want ErrAgentNotFound", err)
+		}
+		if _, err := store.GetTokenHash(ctx, "missing"); !errors.Is(err, ErrAgentNotFound) {
+			t.Errorf("get missing token: %v, want ErrAgentNotFound", err)
+		}
+
+		saved := saveTestAgent(t, store, "agent-1")
+		got, err := store.GetAgent(ctx, "agent-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Name != saved.Name || got.Type != saved.Type || !got.RegisteredAt.Equal(saved.RegisteredAt) || len(got.Capabilities) != 1 {
+			t.Errorf("loaded agent = %+v", got)
+		}
+		if hash, err := store.GetTokenHash(ctx, "agent-1"); err != nil || hash != "hash-agent-1" {
+			t.Errorf("token hash = %q, %v", hash, err)
+		}
+	})
+}
+
+func TestAgentStoreDelete(t *testing.T) {
+	forEachAgentStore(t, func(t *testing.T, store AgentStore, advance func(time.Duration)) {
+		ctx := context.Background()
+		saveTestAgent(t, store, "agent-1")
+		saveTestAgent(t, store, "agent-2")
+		if err := store.DeleteAgent(ctx, "agent-1"); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := store.GetAgent(ctx, "agent-1"); !errors.Is(err, ErrAgentNotFound) {
+			t.Errorf("get deleted agent: %v", err)
+		}
+		if _, err := store.GetTokenHash(ctx, "agent-1"); !errors.Is(err, ErrAgentNotFound) {
+			t.Errorf("get deleted agent's token: %v", err)
+		}
+		ids, err := store.ActiveAgentIDs(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ids) != 1 || ids[0] != "agent-2" {
+			t.Errorf("active agents = %v, want [agent-2]", ids)
+		}
+	})
+}
+
+func TestAgentStoreActiveSet(t *testing.T) {
+	forEachAgentStore(t, func(t *testing.T, store AgentStore, advance func(time.Duration)) {
+		ctx := context.Background()
+		for _, id := range []string{"agent-2", "agent-1", "agent-2"} {
+			if err := store.AddActive(ctx, id); err != nil {
+				t.Fatal(err)
+			}
+		}
+		ids, err := store.ActiveAgentIDs(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Strings(ids)
+		if len(ids) != 2 || ids[0] != "agent-1" || ids[1] != "agent-2" {
+			t.Errorf("active agents = %v, want each once", ids)
+		}
+	})
+}
+
+func TestAgentStoreExpiresAgents(t *testing.T) {
+	forEachAgentStore(t, func(t *testing.T, store AgentStore, advance func(time.Duration)) {
+		ctx := context.Background()
+		saveTestAgent(t, store, "agent-1")
+
+		advance(defaultAgentTTL - time.Second)
+		if _, err := store.GetAgent(ctx, "agent-1"); err != nil {
+			t.Fatalf("agent expired before its TTL: %v", err)
+		}
+		// Saving again refreshes the TTL
+		if err := store.SaveAgent(ctx, &Agent{ID: "agent-1"}); err != nil {
+			t.Fatal(err)
+		}
+		advance(2 * time.Second)
+		if _, err := store.GetAgent(ctx, "agent-1"); err != nil {
+			t.Fatalf("agent expired after a refresh: %v", err)
+		}
+
+		advance(defaultAgentTTL)
+		if _, err := store.GetAgent(ctx, "agent-1"); !errors.Is(err, ErrAgentNotFound) {
+			t.Errorf("get expired agent: %v, want ErrAgentNotFound", err)
+		}
+	})
+}
+
+func TestLongHeartbeatIntervalOutlivesBaseTTL(t *testing.T) {
+	forEachAgentStore(t, func(t *testing.T, store AgentStore, advance func(time.Duration)) {
+		reg := NewRegistryWithStore(store)
+		req := registration("slow-1", AgentTypeCost)
+		req.HeartbeatInterval = 300
+		slow := registerWithStatus(t, reg, req, AgentStatusHealthy)
+		fast := registerWithStatus(t, reg, registration("fast-1", AgentTypeCost), AgentStatusHealthy)
+
+		// Well past the base TTL but within one heartbeat interval
+		advance(4 * time.Minute)
+		if _, err := store.GetAgent(context.Background(), slow); err != nil {
+			t.Fatalf("long-interval agent expired between heartbeats: %v", err)
+		}
+		if _, err := store.GetAgent(context.Background(), fast); !errors.Is(err, ErrAgentNotFound) {
+			t.Errorf("default-interval agent: %v, want expired after the base TTL", err)
+		}
+
+		// Each heartbeat extends the TTL by twice the interval again
+		for i := 0; i < 3; i++ {
+			if _, err := reg.Heartbeat(slow, &HeartbeatRequest{Status: AgentStatusHealthy}); err != nil {
+				t.Fatalf("heartbeat %d: %v", i, err)
+			}
+			advance(5 * time.Minute)
+			if _, err := store.GetAgent(context.Background(), slow); err != nil {
+				t.Fatalf("agent expired after heartbeat %d: %v", i, err)
+			}
+		}
+
+		// Silent for more than two intervals, it expires
+		advance(6 * time.Minute)
+		if _, err := store.GetAgent(context.Background(), slow); !errors.Is(err, ErrAgentNotFound) {
+			t.Errorf("silent agent: %v, want expired", err)
+		}
+	})
+}
+
+func TestAgentStoreLock(t *testing.T) {
+	forEachAgentStore(t, func(t *testing.T, store AgentStore, advance func(time.Duration)) {
+		ctx := context.Background()
+		acquire := func(owner string) bool {
+			t.Helper()
+			held, err := store.AcquireLock(ctx, "health", owner, 10*time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return held
+		}
+
+		if !acquire("replica-a") {
+			t.Fatal("free lock not acquired")
+		}
+		if acquire("replica-b") {
+			t.Error("lock taken while held by another owner")
+		}
+
+		// The holder renews its lock, keeping it past the first TTL
+		advance(8 * time.Second)
+		if !acquire("replica-a") {
+			t.Error("holder could not renew its lock")
+		}
+		advance(8 * time.Second)
+		if acquire("replica-b") {
+			t.Error("renewed lock taken by another owner")
+		}
+
+		// A lapsed lock is free again
+		advance(11 * time.Second)
+		if !acquire("replica-b") {
+			t.Error("lapsed lock not acquired")
+		}
+	})
+}
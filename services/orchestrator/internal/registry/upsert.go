@@ -0,0 +1,111 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ErrAgentTypeChanged is returned when re-registering an agent under its ID
+// with a different type
+var ErrAgentTypeChanged = errors.New("agent type cannot change")
+
+// Upsert registers an agent under an ID the caller chose, or re-registers
+// an existing one, so a restarting agent keeps its identity and the tasks
+// assigned to it. Re-registration requires the agent's token; it keeps the
+// ID, token and RegisteredAt and replaces every other field. It reports
+// whether the agent was created.
+func (r *Registry) Upsert(agentID, token string, req *RegistrationRequest) (*RegistrationResponse, bool, error) {
+	// Types are fixed, so routing and listings by type can reach every agent
+	if _, err := ParseAgentType(string(req.Type)); err != nil {
+		return nil, false, err
+	}
+	if err := r.allowRegistration(); err != nil {
+		return nil, false, err
+	}
+
+	// Probe before taking the lock; the agent may take a while to answer
+	status, err := r.initialStatus(req)
+	if err != nil {
+		return nil, false, err
+	}
+
+	resp, created, err := r.upsert(agentID, token, req, status)
+	if err != nil {
+		return nil, false, err
+	}
+
+	eventType := EventAgentUpdated
+	if created {
+		eventType = EventAgentRegistered
+	}
+	r.emit(Event{
+		Type:      eventType,
+		AgentID:   agentID,
+		AgentType: req.Type,
+	})
+
+	return resp, created, nil
+}
+
+func (r *Registry) upsert(agentID, token string, req *RegistrationRequest, status AgentStatus) (*RegistrationResponse, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	agent, err := r.getAgent(agentID)
+	if errors.Is(err, ErrAgentNotFound) {
+		resp, err := r.registerLocked(agentID, req, status, "")
+		return resp, err == nil, err
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get agent: %w", err)
+	}
+
+	if err := r.verifyAgentToken(agentID, token); err != nil {
+		return nil, false, err
+	}
+	if agent.ReplacedBy != "" {
+		return nil, false, fmt.Errorf("%w: agent %s was replaced by %s", ErrAgentAlreadyReplaced, agentID, agent.ReplacedBy)
+	}
+	if agent.Type != req.Type {
+		return nil, false, fmt.Errorf("%w: agent %s is %s, not %s", ErrAgentTypeChanged, agentID, agent.Type, req.Type)
+	}
+
+	metadata, capabilities, preferred, err := r.registrationFields(req)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if agent.Status != status {
+		r.statusLog.logTransition(agent, agent.Status, status, "re-registered")
+	}
+	agent.Name = req.Name
+	agent.Host = req.Host
+	agent.Port = req.Port
+	agent.Capabilities = capabilities
+	agent.Status = status
+	agent.Version = req.Version
+	agent.LastSeen = time.Now()
+	agent.Metadata = metadata
+	agent.HeartbeatInterval = negotiateHeartbeatInterval(req.HeartbeatInterval)
+	agent.PreferredTaskTypes = preferred
+	agent.MaxDispatchRate = dispatchRate(req.MaxDispatchRate)
+
+	if err := r.storeAgent(agent); err != nil {
+		return nil, false, fmt.Errorf("failed to store agent: %w", err)
+	}
+	if err := r.store.AddActive(r.ctx, agentID); err != nil {
+		return nil, false, fmt.Errorf("failed to add to active set: %w", err)
+	}
+
+	log.Printf("Agent re-registered: %s (%s) - %s", agent.Name, agent.Type, agent.ID)
+
+	return &RegistrationResponse{
+		AgentID:      agentID,
+		AgentToken:   token,
+		RegisteredAt: agent.RegisteredAt,
+		HeartbeatURL: fmt.Sprintf("/agents/%s/heartbeat", agentID),
+		Interval:     agent.HeartbeatInterval,
+	}, false, nil
+}
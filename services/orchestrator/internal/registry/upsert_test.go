@@ -0,0 +1,84 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// putAgent sends PUT /agents/:id with the agent token, if any
+func putAgent(t *testing.T, router http.Handler, id, token string, req *RegistrationRequest) (*httptest.ResponseRecorder, *RegistrationResponse) {
+	t.Helper()
+	body, _ := json.Marshal(req)
+	httpReq := httptest.NewRequest(http.MethodPut, "/agents/"+id, bytes.NewReader(body))
+	httpReq.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		httpReq.Header.Set("X-Agent-Token", token)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httpReq)
+
+	var resp RegistrationResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, &resp
+}
+
+func TestUpsertCreatesThenUpdatesAgent(t *testing.T) {
+	forEachAgentStore(t, func(t *testing.T, store AgentStore, advance func(time.Duration)) {
+		reg := NewRegistryWithStore(store)
+		router := newTestRouter(reg)
+
+		w, created := putAgent(t, router, "cost-agent-1", "", registration("cost-1", AgentTypeCost, "analyze_cost"))
+		if w.Code != http.StatusCreated || created.AgentID != "cost-agent-1" || created.AgentToken == "" {
+			t.Fatalf("create: status %d, body %s", w.Code, w.Body.String())
+		}
+
+		// The restarted agent comes back on another port with more capabilities
+		time.Sleep(10 * time.Millisecond)
+		restarted := registration("cost-1b", AgentTypeCost, "analyze_cost", "right_size")
+		restarted.Port = 9001
+		w, updated := putAgent(t, router, "cost-agent-1", created.AgentToken, restarted)
+		if w.Code != http.StatusOK {
+			t.Fatalf("update: status %d, body %s", w.Code, w.Body.String())
+		}
+		if updated.AgentID != created.AgentID || updated.AgentToken != created.AgentToken || !updated.RegisteredAt.Equal(created.RegisteredAt) {
+			t.Errorf("update = %+v, want the ID, token and RegisteredAt of %+v", updated, created)
+		}
+
+		agent, err := reg.GetAgent("cost-agent-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if agent.Name != "cost-1b" || agent.Port != 9001 || !agent.HasCapability("right_size") {
+			t.Errorf("agent after update = %+v", agent)
+		}
+		if !agent.RegisteredAt.Equal(created.RegisteredAt) || !agent.LastSeen.After(agent.RegisteredAt) {
+			t.Errorf("registered %v, last seen %v, want registration kept and last seen moved on", agent.RegisteredAt, agent.LastSeen)
+		}
+		if listing := reg.ListAgents(); len(listing.Agents) != 1 {
+			t.Errorf("%d agents listed, want the one agent", len(listing.Agents))
+		}
+	})
+}
+
+func TestUpsertRefusals(t *testing.T) {
+	reg := newTestRegistry(t)
+	router := newTestRouter(reg)
+	_, created := putAgent(t, router, "cost-agent-1", "", registration("cost-1", AgentTypeCost))
+
+	if w, _ := putAgent(t, router, "cost-agent-1", "", registration("cost-1", AgentTypeCost)); w.Code != http.StatusUnauthorized {
+		t.Errorf("update without token: status %d, want 401", w.Code)
+	}
+	if w, _ := putAgent(t, router, "cost-agent-1", "wrong", registration("cost-1", AgentTypeCost)); w.Code != http.StatusUnauthorized {
+		t.Errorf("update with wrong token: status %d, want 401", w.Code)
+	}
+	if w, _ := putAgent(t, router, "cost-agent-1", created.AgentToken, registration("cost-1", AgentTypePerformance)); w.Code != http.StatusBadRequest {
+		t.Errorf("type change: status %d, want 400", w.Code)
+	}
+	if w, _ := putAgent(t, router, "cost-agent-2", "", registration("cost-2", AgentType("storage"))); w.Code == http.StatusCreated {
+		t.Error("created an agent of an unknown type")
+	}
+}
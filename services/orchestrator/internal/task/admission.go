@@ -0,0 +1,122 @@
+package task
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// defaultAdmissionTimeout bounds a call to the admission webhook
+const defaultAdmissionTimeout = 5 * time.Second
+
+// ErrAdmissionDenied is returned when the admission webhook rejects a task
+var ErrAdmissionDenied = errors.New("task denied by admission policy")
+
+// ErrAdmissionUnavailable is returned when the admission webhook cannot be
+// reached and the webhook fails closed
+var ErrAdmissionUnavailable = errors.New("admission webhook unavailable")
+
+// AdmissionDecision is the admission webhook's reply
+type AdmissionDecision struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// AdmissionWebhook asks an external policy endpoint whether to accept each
+// submitted task. The submit request is POSTed as JSON and the endpoint
+// answers with an AdmissionDecision.
+type AdmissionWebhook struct {
+	url      string
+	failOpen bool
+	client   *http.Client
+}
+
+// NewAdmissionWebhook creates a webhook calling url. When failOpen is set,
+// tasks are accepted while the endpoint is unreachable or misbehaving;
+// otherwise they are refused.
+func NewAdmissionWebhook(url string, timeout time.Duration, failOpen bool) *AdmissionWebhook {
+	if timeout <= 0 {
+		timeout = defaultAdmissionTimeout
+	}
+	return &AdmissionWebhook{
+		url:      url,
+		failOpen: failOpen,
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+// Review returns nil if the task may be submitted, or an error wrapping
+// ErrAdmissionDenied or ErrAdmissionUnavailable
+func (w *AdmissionWebhook) Review(ctx context.Context, req *TaskSubmitRequest) error {
+	decision, err := w.call(ctx, req)
+	if err != nil {
+		if w.failOpen {
+			log.Printf("Admission webhook failed, admitting %s task: %v", req.TaskType, err)
+			return nil
+		}
+		return fmt.Errorf("%w: %v", ErrAdmissionUnavailable, err)
+	}
+	if !decision.Allowed {
+		if decision.Reason == "" {
+			return ErrAdmissionDenied
+		}
+		return fmt.Errorf("%w: %s", ErrAdmissionDenied, decision.Reason)
+	}
+	return nil
+}
+
+func (w *AdmissionWebhook) call(ctx context.Context, req *TaskSubmitRequest) (*AdmissionDecision, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", w.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	var decision AdmissionDecision
+	if err := json.NewDecoder(resp.Body).Decode(&decision); err != nil {
+		return nil, fmt.Errorf("invalid webhook response: %w", err)
+	}
+	return &decision, nil
+}
+
+// SetAdmissionWebhook reviews every submission, broadcasts and chained
+// tasks included, through webhook; nil disables admission review
+func (r *Router) SetAdmissionWebhook(webhook *AdmissionWebhook) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.admission = webhook
+}
+
+// admit reviews a submission with the admission webhook, if one is set. It
+// must be called without r.mu held, as the webhook is called over HTTP.
+func (r *Router) admit(ctx context.Context, req *TaskSubmitRequest) error {
+	r.mu.RLock()
+	webhook := r.admission
+	r.mu.RUnlock()
+
+	if webhook == nil {
+		return nil
+	}
+	return webhook.Review(ctx, req)
+}
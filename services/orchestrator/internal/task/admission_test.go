@@ -0,0 +1,87 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+// policyServer answers admission reviews, denying right_size tasks, and
+// records the task types it was asked about
+func policyServer(t *testing.T) (string, *[]TaskType) {
+	t.Helper()
+	var reviewed []TaskType
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var submitReq TaskSubmitRequest
+		json.NewDecoder(req.Body).Decode(&submitReq)
+		reviewed = append(reviewed, submitReq.TaskType)
+		if submitReq.TaskType == TaskTypeRightSize {
+			json.NewEncoder(w).Encode(AdmissionDecision{Reason: "no right-sizing during business hours"})
+			return
+		}
+		json.NewEncoder(w).Encode(AdmissionDecision{Allowed: true})
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL, &reviewed
+}
+
+func TestAdmissionWebhook(t *testing.T) {
+	policy, reviewed := policyServer(t)
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "policy engine down", http.StatusInternalServerError)
+	}))
+	t.Cleanup(broken.Close)
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	tests := []struct {
+		name     string
+		url      string
+		failOpen bool
+		taskType TaskType
+		status   int
+	}{
+		{"allowed", policy, false, TaskTypeAnalyzeCost, http.StatusCreated},
+		{"denied", policy, false, TaskTypeRightSize, http.StatusForbidden},
+		{"denied fail-open", policy, true, TaskTypeRightSize, http.StatusForbidden},
+		{"unreachable fail-closed", unreachable.URL, false, TaskTypeAnalyzeCost, http.StatusServiceUnavailable},
+		{"unreachable fail-open", unreachable.URL, true, TaskTypeAnalyzeCost, http.StatusCreated},
+		{"erroring fail-closed", broken.URL, false, TaskTypeAnalyzeCost, http.StatusServiceUnavailable},
+		{"erroring fail-open", broken.URL, true, TaskTypeAnalyzeCost, http.StatusCreated},
+	}
+	for _, tt := range tests {
+		agent := newAgentServer(t, completingAgent(map[string]interface{}{"ok": true}))
+		r, reg := newTestRouter(t)
+		registerAgent(t, reg, "cost-1", registry.AgentTypeCost, agent.URL, "analyze_cost", "right_size")
+		r.SetAdmissionWebhook(NewAdmissionWebhook(tt.url, time.Second, tt.failOpen))
+
+		if code := doJSON(t, r, http.MethodPost, "/tasks", TaskSubmitRequest{TaskType: tt.taskType, AgentType: "cost"}, nil); code != tt.status {
+			t.Errorf("%s: status %d, want %d", tt.name, code, tt.status)
+		}
+		if tt.status != http.StatusCreated {
+			if page, err := r.ListTasksPage("", 0, 10); err != nil || len(page.Tasks) != 0 {
+				t.Errorf("%s: refused task was stored", tt.name)
+			}
+		}
+	}
+
+	// Denials carry the webhook's reason
+	r, reg := newTestRouter(t)
+	registerAgent(t, reg, "cost-1", registry.AgentTypeCost, "http://127.0.0.1:1", "right_size")
+	r.SetAdmissionWebhook(NewAdmissionWebhook(policy, time.Second, false))
+	_, err := r.SubmitTask(context.Background(), &TaskSubmitRequest{TaskType: TaskTypeRightSize, AgentType: "cost"})
+	if !errors.Is(err, ErrAdmissionDenied) || !strings.Contains(err.Error(), "business hours") {
+		t.Errorf("denied submission: %v, want ErrAdmissionDenied with the reason", err)
+	}
+
+	if len(*reviewed) != 4 {
+		t.Errorf("policy reviewed %v, want the four submissions sent to it", *reviewed)
+	}
+}
@@ -0,0 +1,67 @@
+package task
+
+import (
+	"sort"
+	"time"
+)
+
+// AgentTask is an unfinished task assigned to an agent and how long it has
+// been waiting or running
+type AgentTask struct {
+	TaskID     string       `json:"task_id"`
+	TaskType   TaskType     `json:"task_type"`
+	Status     TaskStatus   `json:"status"`
+	Priority   TaskPriority `json:"priority"`
+	CreatedAt  time.Time    `json:"created_at"`
+	StartedAt  *time.Time   `json:"started_at,omitempty"`
+	RetryCount int          `json:"retry_count"`
+
+	AgeMs     int64 `json:"age_ms"`               // Since submission
+	RunningMs int64 `json:"running_ms,omitempty"` // Since the task started, once it has
+}
+
+// AgentTasksResponse lists the tasks an agent is working on, oldest first
+type AgentTasksResponse struct {
+	AgentID string      `json:"agent_id"`
+	Tasks   []AgentTask `json:"tasks"`
+	Count   int         `json:"count"`
+}
+
+// AgentTasks returns the unfinished tasks this replica has assigned to a
+// registered agent
+func (r *Router) AgentTasks(agentID string) (*AgentTasksResponse, error) {
+	if _, err := r.registry.GetAgent(agentID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	resp := &AgentTasksResponse{AgentID: agentID, Tasks: make([]AgentTask, 0)}
+
+	r.mu.RLock()
+	for _, task := range r.tasks {
+		if task.AgentID != agentID || isTerminal(task.Status) {
+			continue
+		}
+		entry := AgentTask{
+			TaskID:     task.ID,
+			TaskType:   task.Type,
+			Status:     task.Status,
+			Priority:   task.Priority,
+			CreatedAt:  task.CreatedAt,
+			StartedAt:  task.StartedAt,
+			RetryCount: task.RetryCount,
+			AgeMs:      now.Sub(task.CreatedAt).Milliseconds(),
+		}
+		if task.StartedAt != nil {
+			entry.RunningMs = now.Sub(*task.StartedAt).Milliseconds()
+		}
+		resp.Tasks = append(resp.Tasks, entry)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(resp.Tasks, func(i, j int) bool {
+		return resp.Tasks[i].CreatedAt.Before(resp.Tasks[j].CreatedAt)
+	})
+	resp.Count = len(resp.Tasks)
+	return resp, nil
+}
@@ -0,0 +1,53 @@
+package task
+
+import (
+	"net/http"
+	"testing"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+func TestAgentTasksListsOnlyThatAgentsActiveTasks(t *testing.T) {
+	r, reg := newTestRouter(t)
+	url := blockingAgent(t)
+	costID := registerAgent(t, reg, "cost-1", registry.AgentTypeCost, url, "analyze_cost", "right_size")
+	perfID := registerAgent(t, reg, "perf-1", registry.AgentTypePerformance, url, "optimize_kv_cache")
+
+	first := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeRightSize, AgentType: "cost"}).TaskID
+	second := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeRightSize, AgentType: "cost", Priority: PriorityHigh}).TaskID
+	finished := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost"}).TaskID
+	other := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeOptimizeKVCache, AgentType: "performance"}).TaskID
+	waitForStatus(t, r, first, TaskStatusSent)
+	waitForStatus(t, r, second, TaskStatusSent)
+	waitForStatus(t, r, finished, TaskStatusCompleted)
+	waitForStatus(t, r, other, TaskStatusSent)
+
+	var resp AgentTasksResponse
+	if code := getJSON(t, r, "/agents/"+costID+"/tasks", &resp); code != http.StatusOK {
+		t.Fatalf("status %d", code)
+	}
+	if resp.AgentID != costID || resp.Count != 2 || len(resp.Tasks) != 2 {
+		t.Fatalf("agent tasks = %+v, want the two held right_size tasks", resp)
+	}
+	if resp.Tasks[0].TaskID != first || resp.Tasks[1].TaskID != second {
+		t.Errorf("tasks %s, %s, want oldest first", resp.Tasks[0].TaskID, resp.Tasks[1].TaskID)
+	}
+	for _, task := range resp.Tasks {
+		if task.Status != TaskStatusSent || task.StartedAt == nil || task.TaskType != TaskTypeRightSize {
+			t.Errorf("task %s = %+v, want a right_size task sent to the agent", task.TaskID, task)
+		}
+		if task.AgeMs < task.RunningMs {
+			t.Errorf("task %s running %dms but only %dms old", task.TaskID, task.RunningMs, task.AgeMs)
+		}
+	}
+	if resp.Tasks[1].Priority != PriorityHigh {
+		t.Errorf("second task priority = %d, want %d", resp.Tasks[1].Priority, PriorityHigh)
+	}
+
+	if code := getJSON(t, r, "/agents/"+perfID+"/tasks", &resp); code != http.StatusOK || resp.Count != 1 || resp.Tasks[0].TaskID != other {
+		t.Errorf("performance agent: status %d, tasks %+v", code, resp.Tasks)
+	}
+	if code := getJSON(t, r, "/agents/missing/tasks", nil); code != http.StatusNotFound {
+		t.Errorf("unknown agent: status %d, want 404", code)
+	}
+}
@@ -0,0 +1,154 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// BroadcastTask sends the same task to every healthy agent of the requested
+// type with the task's capability. A parent task tracks one child task per
+// agent and completes once all children finish, aggregating their results.
+// ctx is handled as in SubmitTask.
+func (r *Router) BroadcastTask(ctx context.Context, req *TaskSubmitRequest) (*BroadcastResponse, error) {
+	if err := r.checkSubmission(ctx, req); err != nil {
+		return nil, err
+	}
+	if req.AgentID != "" {
+		return nil, fmt.Errorf("invalid task request: agent_id cannot be set on a broadcast")
+	}
+	if len(req.ChainOnSuccess) > 0 {
+		return nil, fmt.Errorf("invalid task request: chain_on_success is not supported on a broadcast")
+	}
+
+	// Consult the registry before taking the lock
+	ofType, err := r.lookupAgentsOfType(ctx, req.AgentType)
+	if err != nil {
+		return nil, err
+	}
+	agents, err := healthyWithCapability(ofType, capabilityRequirement(req.TaskType, req.CapabilityVersion))
+	if err != nil {
+		return nil, fmt.Errorf("no available agent: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkQueueCapacity(len(agents)); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("submission aborted: %w", err)
+	}
+
+	// The parent is never dispatched; it stays running until its children finish
+	parent := r.newTask(ctx, req)
+	parent.Status = TaskStatusRunning
+	parent.StartedAt = &parent.CreatedAt
+	children := make([]*Task, len(agents))
+	for i, agent := range agents {
+		child := r.newTask(ctx, req)
+		child.AgentID = agent.ID
+		child.ParentTaskID = parent.ID
+		children[i] = child
+		parent.ChildTaskIDs = append(parent.ChildTaskIDs, child.ID)
+	}
+
+	if err := r.storeTaskContext(ctx, parent); err != nil {
+		return nil, fmt.Errorf("failed to store task: %w", err)
+	}
+	r.tasks[parent.ID] = parent
+	r.recordEvent(parent, TaskEventSubmitted, fmt.Sprintf("broadcast to %d agents", len(agents)))
+
+	resp := &BroadcastResponse{
+		TaskID:    parent.ID,
+		Status:    parent.Status,
+		CreatedAt: parent.CreatedAt,
+		StatusURL: fmt.Sprintf("/tasks/%s", parent.ID),
+		Children:  make([]TaskSubmitResponse, 0, len(children)),
+	}
+	for i, child := range children {
+		if err := r.storeTaskContext(ctx, child); err != nil {
+			r.cancelTaskLocked(parent, fmt.Sprintf("failed to store child task: %v", err))
+			return nil, fmt.Errorf("failed to store task: %w", err)
+		}
+		r.recordEvent(child, TaskEventSubmitted, "")
+		r.enqueueLocked(child, agents[i])
+		resp.Children = append(resp.Children, *submitResponse(child))
+	}
+
+	log.Printf("Task broadcast: %s -> %d agents (%s)", parent.ID, len(agents), req.AgentType)
+
+	return resp, nil
+}
+
+// isBroadcastChild reports whether a task belongs to a broadcast. It must be
+// called with r.mu held.
+func (r *Router) isBroadcastChild(task *Task) bool {
+	parent, ok := r.tasks[task.ParentTaskID]
+	return ok && len(parent.ChildTaskIDs) > 0
+}
+
+// finishBroadcastChild completes a finished child's broadcast parent once
+// every child has finished. The parent completes only if all children did;
+// its result maps agent IDs to child results and errors either way. It must
+// be called with r.mu held.
+func (r *Router) finishBroadcastChild(child *Task) {
+	if child.ParentTaskID == "" || !r.isBroadcastChild(child) {
+		return
+	}
+	parent := r.tasks[child.ParentTaskID]
+	if isTerminal(parent.Status) {
+		return
+	}
+
+	results := make(map[string]interface{})
+	failures := make(map[string]interface{})
+	for _, id := range parent.ChildTaskIDs {
+		c, ok := r.tasks[id]
+		if !ok || !isTerminal(c.Status) {
+			return
+		}
+		if c.Status == TaskStatusCompleted {
+			results[c.AgentID] = c.Result
+		} else {
+			failures[c.AgentID] = c.Error
+		}
+	}
+
+	parent.Result = map[string]interface{}{
+		"agents":    len(parent.ChildTaskIDs),
+		"succeeded": len(results),
+		"failed":    len(failures),
+		"results":   results,
+		"errors":    failures,
+	}
+	parent.Status = TaskStatusCompleted
+	if len(failures) > 0 {
+		parent.Status = TaskStatusFailed
+		parent.Error = fmt.Sprintf("%d of %d agents failed", len(failures), len(parent.ChildTaskIDs))
+	}
+	now := time.Now()
+	parent.CompletedAt = &now
+
+	if err := r.storeTask(parent); err != nil {
+		log.Printf("Failed to store broadcast result %s: %v", parent.ID, err)
+	}
+
+	log.Printf("Broadcast %s finished: %d succeeded, %d failed", parent.ID, len(results), len(failures))
+}
+
+// cancelBroadcastChildren cancels the unfinished children of a cancelled
+// broadcast parent. It must be called with r.mu held.
+func (r *Router) cancelBroadcastChildren(parent *Task) {
+	for _, id := range parent.ChildTaskIDs {
+		child, ok := r.tasks[id]
+		if !ok || isTerminal(child.Status) {
+			continue
+		}
+		if err := r.cancelTaskLocked(child, fmt.Sprintf("broadcast %s cancelled", parent.ID)); err != nil {
+			log.Printf("Failed to cancel broadcast child %s: %v", id, err)
+		}
+	}
+}
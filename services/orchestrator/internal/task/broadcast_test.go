@@ -0,0 +1,114 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+// failingAgent rejects every task with message, marked as not worth retrying
+func failingAgent(message string) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		var taskReq TaskRequest
+		json.NewDecoder(req.Body).Decode(&taskReq)
+		retryable := false
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(TaskResponse{
+			TaskID:    taskReq.TaskID,
+			Status:    TaskStatusFailed,
+			Error:     message,
+			Retryable: &retryable,
+		})
+	}
+}
+
+func broadcast(t *testing.T, r *Router, req *TaskSubmitRequest) *BroadcastResponse {
+	t.Helper()
+	resp, err := r.BroadcastTask(context.Background(), req)
+	if err != nil {
+		t.Fatalf("broadcast: %v", err)
+	}
+	return resp
+}
+
+func TestBroadcastAggregatesMixedResults(t *testing.T) {
+	ok := newAgentServer(t, completingAgent(map[string]interface{}{"warmed": true}))
+	failing := newAgentServer(t, failingAgent("cache unavailable"))
+	r, reg := newTestRouter(t)
+	r.SetExtraTaskTypes([]string{"warm_cache"})
+
+	first := registerAgent(t, reg, "cache-1", registry.AgentTypeCost, ok.URL, "warm_cache")
+	second := registerAgent(t, reg, "cache-2", registry.AgentTypeCost, ok.URL, "warm_cache")
+	broken := registerAgent(t, reg, "cache-3", registry.AgentTypeCost, failing.URL, "warm_cache")
+	// Agents without the capability are left out
+	registerAgent(t, reg, "other", registry.AgentTypeCost, ok.URL)
+
+	resp := broadcast(t, r, &TaskSubmitRequest{TaskType: "warm_cache", AgentType: "cost"})
+	if len(resp.Children) != 3 {
+		t.Fatalf("%d children, want one per capable agent", len(resp.Children))
+	}
+
+	parent := waitForStatus(t, r, resp.TaskID, TaskStatusFailed)
+	if parent.Error != "1 of 3 agents failed" {
+		t.Errorf("parent error = %q", parent.Error)
+	}
+	if parent.Result["agents"] != 3 || parent.Result["succeeded"] != 2 || parent.Result["failed"] != 1 {
+		t.Errorf("aggregate counts = %v", parent.Result)
+	}
+	results := parent.Result["results"].(map[string]interface{})
+	for _, id := range []string{first, second} {
+		if result, _ := results[id].(map[string]interface{}); result["warmed"] != true {
+			t.Errorf("result for %s = %v", id, results[id])
+		}
+	}
+	errs := parent.Result["errors"].(map[string]interface{})
+	if msg, _ := errs[broken].(string); msg == "" || len(errs) != 1 {
+		t.Errorf("errors = %v, want only %s", errs, broken)
+	}
+
+	// Each child ran on its own agent under the parent
+	seen := map[string]bool{}
+	for _, child := range resp.Children {
+		status, err := r.GetTaskStatus(child.TaskID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.ParentTaskID != resp.TaskID {
+			t.Errorf("child %s has parent %q", child.TaskID, status.ParentTaskID)
+		}
+		seen[status.AgentID] = true
+	}
+	if !seen[first] || !seen[second] || !seen[broken] {
+		t.Errorf("children ran on %v", seen)
+	}
+}
+
+func TestBroadcastCompletesWhenAllChildrenSucceed(t *testing.T) {
+	ok := newAgentServer(t, completingAgent(map[string]interface{}{"warmed": true}))
+	r, reg := newTestRouter(t)
+	for _, name := range []string{"cache-1", "cache-2", "cache-3"} {
+		registerAgent(t, reg, name, registry.AgentTypeCost, ok.URL, "analyze_cost")
+	}
+
+	resp := broadcast(t, r, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost"})
+	parent := waitForStatus(t, r, resp.TaskID, TaskStatusCompleted)
+	if parent.Error != "" || parent.Result["succeeded"] != 3 || len(parent.ChildTaskIDs) != 3 {
+		t.Errorf("parent = %+v", parent)
+	}
+}
+
+func TestBroadcastRejectsInvalidRequests(t *testing.T) {
+	r, reg := newTestRouter(t)
+	r.SetExtraTaskTypes([]string{"warm_cache"})
+	id := registerAgent(t, reg, "cache-1", registry.AgentTypeCost, "", "analyze_cost")
+
+	if _, err := r.BroadcastTask(context.Background(), &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost", AgentID: id}); err == nil {
+		t.Error("broadcast pinned to one agent accepted")
+	}
+	if _, err := r.BroadcastTask(context.Background(), &TaskSubmitRequest{TaskType: "warm_cache", AgentType: "cost"}); err == nil {
+		t.Error("broadcast with no capable agent accepted")
+	}
+}
@@ -0,0 +1,77 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+)
+
+// maxBulkStatusIDs caps the task IDs in one bulk status lookup
+const maxBulkStatusIDs = 100
+
+// ErrTooManyTaskIDs is returned when a bulk status lookup names more than
+// maxBulkStatusIDs tasks
+var ErrTooManyTaskIDs = errors.New("too many task IDs")
+
+// BulkStatusRequest names the tasks for a bulk status lookup
+type BulkStatusRequest struct {
+	TaskIDs []string `json:"task_ids" binding:"required"`
+}
+
+// BulkStatusResponse returns the status of each task found, in request
+// order, and the IDs that could not be read
+type BulkStatusResponse struct {
+	Tasks    []*TaskStatusResponse `json:"tasks"`
+	Count    int                   `json:"count"`
+	NotFound []string              `json:"not_found,omitempty"`
+	Failed   map[string]string     `json:"failed,omitempty"` // Store errors other than not found, by task ID
+}
+
+// GetTaskStatuses looks up many tasks at once, reading the in-memory map
+// first and the store for the rest. Duplicate IDs are answered once.
+func (r *Router) GetTaskStatuses(taskIDs []string) (*BulkStatusResponse, error) {
+	if len(taskIDs) > maxBulkStatusIDs {
+		return nil, fmt.Errorf("%w: %d requested, at most %d", ErrTooManyTaskIDs, len(taskIDs), maxBulkStatusIDs)
+	}
+
+	ids := make([]string, 0, len(taskIDs))
+	seen := make(map[string]bool, len(taskIDs))
+	for _, id := range taskIDs {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	statuses := make(map[string]*TaskStatusResponse, len(ids))
+	r.mu.RLock()
+	for _, id := range ids {
+		if task, ok := r.tasks[id]; ok {
+			statuses[id] = r.taskToStatusResponse(task)
+		}
+	}
+	r.mu.RUnlock()
+
+	resp := &BulkStatusResponse{Tasks: make([]*TaskStatusResponse, 0, len(ids))}
+	for _, id := range ids {
+		status, ok := statuses[id]
+		if !ok {
+			task, err := r.getTask(id)
+			switch {
+			case errors.Is(err, ErrTaskNotFound):
+				resp.NotFound = append(resp.NotFound, id)
+				continue
+			case err != nil:
+				if resp.Failed == nil {
+					resp.Failed = make(map[string]string)
+				}
+				resp.Failed[id] = err.Error()
+				continue
+			}
+			status = r.taskToStatusResponse(task)
+		}
+		resp.Tasks = append(resp.Tasks, status)
+	}
+	resp.Count = len(resp.Tasks)
+
+	return resp, nil
+}
@@ -0,0 +1,73 @@
+package task
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+func TestBulkStatusMixesMemoryStoreAndUnknown(t *testing.T) {
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+	t.Cleanup(func() { client.Close() })
+	store := NewRedisTaskStore(client)
+
+	agent := newAgentServer(t, completingAgent(map[string]interface{}{"ok": true}))
+	r, reg := newStoreRouter(t, store)
+	registerAgent(t, reg, "cost-1", registry.AgentTypeCost, agent.URL, "analyze_cost")
+
+	// One task this replica ran, one only in Redis, and one unreadable
+	inMemory := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost"}).TaskID
+	waitForStatus(t, r, inMemory, TaskStatusCompleted)
+	stored := saveTasks(t, store, 1, TaskStatusFailed)[0].ID
+	srv.Set("task:corrupt", "not a task")
+
+	var resp BulkStatusResponse
+	ids := []string{stored, "missing", inMemory, "corrupt", stored}
+	if code := doJSON(t, r, http.MethodPost, "/tasks/status", BulkStatusRequest{TaskIDs: ids}, &resp); code != http.StatusOK {
+		t.Fatalf("status %d", code)
+	}
+
+	if resp.Count != 2 || len(resp.Tasks) != 2 {
+		t.Fatalf("%d statuses, want the 2 readable tasks once each", len(resp.Tasks))
+	}
+	if resp.Tasks[0].TaskID != stored || resp.Tasks[0].Status != TaskStatusFailed {
+		t.Errorf("first status = %s %s, want the stored task, failed", resp.Tasks[0].TaskID, resp.Tasks[0].Status)
+	}
+	if resp.Tasks[1].TaskID != inMemory || resp.Tasks[1].Status != TaskStatusCompleted || resp.Tasks[1].Result["ok"] != true {
+		t.Errorf("second status = %+v, want the completed in-memory task", resp.Tasks[1])
+	}
+	if len(resp.NotFound) != 1 || resp.NotFound[0] != "missing" {
+		t.Errorf("not found = %v, want [missing]", resp.NotFound)
+	}
+	if _, ok := resp.Failed["corrupt"]; !ok || len(resp.Failed) != 1 {
+		t.Errorf("failed = %v, want the corrupt record", resp.Failed)
+	}
+}
+
+func TestBulkStatusCapsIDs(t *testing.T) {
+	r, _ := newTestRouter(t)
+	ids := make([]string, maxBulkStatusIDs+1)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("task-%d", i)
+	}
+	if code := doJSON(t, r, http.MethodPost, "/tasks/status", BulkStatusRequest{TaskIDs: ids}, nil); code != http.StatusBadRequest {
+		t.Errorf("%d IDs: status %d, want 400", len(ids), code)
+	}
+
+	var resp BulkStatusResponse
+	if code := doJSON(t, r, http.MethodPost, "/tasks/status", BulkStatusRequest{TaskIDs: ids[:maxBulkStatusIDs]}, &resp); code != http.StatusOK {
+		t.Errorf("%d IDs: status %d, want 200", maxBulkStatusIDs, code)
+	}
+	if len(resp.NotFound) != maxBulkStatusIDs {
+		t.Errorf("%d not found, want all %d", len(resp.NotFound), maxBulkStatusIDs)
+	}
+	if code := doJSON(t, r, http.MethodPost, "/tasks/status", map[string]string{}, nil); code != http.StatusBadRequest {
+		t.Errorf("no IDs: status %d, want 400", code)
+	}
+}
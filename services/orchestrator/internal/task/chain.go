@@ -0,0 +1,161 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// ownPriorityKey is the metadata key holding a chained task's own priority
+// when it was raised to its prerequisite's
+const ownPriorityKey = "own_priority"
+
+// ChainStep describes a follow-on task submitted when the previous task in a
+// chain completes successfully
+type ChainStep struct {
+	TaskType   TaskType               `json:"task_type"`
+	AgentType  string                 `json:"agent_type"`
+	AgentID    string                 `json:"agent_id,omitempty"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"` // Static parameters
+	// Mapping sets parameters from the previous task. Keys are parameter
+	// names; values are dotted paths such as "result.recommendations.count"
+	// or "parameters.instance_id". A bare path is read from the result.
+	Mapping    map[string]string `json:"mapping,omitempty"`
+	Priority   TaskPriority      `json:"priority,omitempty"` // Raised to the previous task's priority if lower
+	Timeout    int               `json:"timeout_seconds,omitempty"`
+	MaxRetries int               `json:"max_retries,omitempty"`
+}
+
+// validateChain checks that every step in a chain is submittable
+func validateChain(chain []ChainStep) error {
+	for i, step := range chain {
+		if step.TaskType == "" {
+			return fmt.Errorf("chain step %d: task_type is required", i+1)
+		}
+		if step.AgentType == "" {
+			return fmt.Errorf("chain step %d: agent_type is required", i+1)
+		}
+		for param, path := range step.Mapping {
+			if strings.TrimSpace(path) == "" {
+				return fmt.Errorf("chain step %d: empty mapping for parameter %s", i+1, param)
+			}
+		}
+	}
+	return nil
+}
+
+// buildChainedRequest builds the submit request for the next step of a
+// completed task's chain, resolving mappings against its result
+func buildChainedRequest(task *Task) (*TaskSubmitRequest, error) {
+	step := task.Chain[0]
+
+	params := make(map[string]interface{}, len(step.Parameters)+len(step.Mapping))
+	for k, v := range step.Parameters {
+		params[k] = v
+	}
+	for param, path := range step.Mapping {
+		value, err := resolveChainPath(task, path)
+		if err != nil {
+			return nil, fmt.Errorf("mapping %s: %w", param, err)
+		}
+		params[param] = value
+	}
+
+	metadata := make(map[string]interface{}, len(task.Metadata)+1)
+	for k, v := range task.Metadata {
+		if k != ownPriorityKey {
+			metadata[k] = v
+		}
+	}
+	metadata["parent_task_id"] = task.ID
+
+	return &TaskSubmitRequest{
+		TaskType:          step.TaskType,
+		AgentType:         step.AgentType,
+		AgentID:           step.AgentID,
+		Parameters:        params,
+		Priority:          step.Priority,
+		Timeout:           step.Timeout,
+		MaxRetries:        step.MaxRetries,
+		Metadata:          metadata,
+		ChainOnSuccess:    task.Chain[1:],
+		InheritedPriority: task.Priority,
+	}, nil
+}
+
+// inheritPriority raises a chained task to its prerequisite's priority when
+// its own, after defaults, is lower, so a low-priority step does not stall an
+// urgent chain. The step's own priority is kept in its metadata under
+// ownPriorityKey. Inheritance carries down the chain one step at a time.
+func inheritPriority(task *Task, inherited TaskPriority) {
+	if inherited <= task.Priority {
+		return
+	}
+	if task.Metadata == nil {
+		task.Metadata = make(map[string]interface{})
+	}
+	task.Metadata[ownPriorityKey] = task.Priority
+	task.Priority = inherited
+}
+
+// resolveChainPath reads a dotted path from a task's result or parameters
+func resolveChainPath(task *Task, path string) (interface{}, error) {
+	parts := strings.Split(strings.TrimSpace(path), ".")
+
+	var current interface{} = task.Result
+	switch parts[0] {
+	case "result":
+		parts = parts[1:]
+	case "parameters":
+		current = task.Parameters
+		parts = parts[1:]
+	}
+
+	for _, part := range parts {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%q: %q is not an object", path, part)
+		}
+		current, ok = m[part]
+		if !ok {
+			return nil, fmt.Errorf("%q: field %q not found", path, part)
+		}
+	}
+
+	return current, nil
+}
+
+// submitChained submits the next task of a completed task's chain. A mapping
+// or submission failure aborts the rest of the chain and is recorded on the
+// completed task.
+func (r *Router) submitChained(task *Task) {
+	if len(task.Chain) == 0 {
+		return
+	}
+
+	req, err := buildChainedRequest(task)
+	if err == nil {
+		var resp *TaskSubmitResponse
+		// The finished task's context is cancelled; keep only its values
+		resp, err = r.SubmitTask(context.WithoutCancel(task.executionContext(r.ctx)), req)
+		if err == nil {
+			r.mu.Lock()
+			task.ChainedTaskID = resp.TaskID
+			r.storeTask(task)
+			r.mu.Unlock()
+			log.Printf("Task %s chained -> %s (%s)", task.ID, resp.TaskID, req.TaskType)
+			return
+		}
+	}
+
+	r.mu.Lock()
+	if task.Metadata == nil {
+		task.Metadata = make(map[string]interface{})
+	}
+	task.Metadata["chain_aborted"] = err.Error()
+	r.storeTask(task)
+	r.mu.Unlock()
+
+	log.Printf("Task chain aborted after %s: %v", task.ID, err)
+}
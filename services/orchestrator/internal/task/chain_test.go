@@ -0,0 +1,266 @@
+package task
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+// priorityAgent completes every task and records the priority each task
+// type was sent with
+type priorityAgent struct {
+	mu         sync.Mutex
+	priorities map[TaskType]TaskPriority
+}
+
+func (a *priorityAgent) handle(w http.ResponseWriter, req *http.Request) {
+	var taskReq TaskRequest
+	json.NewDecoder(req.Body).Decode(&taskReq)
+	a.mu.Lock()
+	a.priorities[taskReq.TaskType] = taskReq.Priority
+	a.mu.Unlock()
+	json.NewEncoder(w).Encode(TaskResponse{TaskID: taskReq.TaskID, Status: TaskStatusCompleted, Result: map[string]interface{}{}})
+}
+
+// runChain submits analyze_cost chained to right_size then migrate_to_spot
+// and returns the priority each step was sent to the agent with
+func runChain(t *testing.T, r *Router, reg *registry.Registry, first TaskPriority, steps [2]TaskPriority, metadata map[string]interface{}) map[TaskType]TaskPriority {
+	t.Helper()
+	agent := &priorityAgent{priorities: make(map[TaskType]TaskPriority)}
+	srv := newAgentServer(t, agent.handle)
+	registerAgent(t, reg, "cost-1", registry.AgentTypeCost, srv.URL, "analyze_cost", "right_size", "migrate_to_spot")
+
+	submit(t, r, &TaskSubmitRequest{
+		TaskType:  TaskTypeAnalyzeCost,
+		AgentType: "cost",
+		Priority:  first,
+		Metadata:  metadata,
+		ChainOnSuccess: []ChainStep{
+			{TaskType: TaskTypeRightSize, AgentType: "cost", Priority: steps[0]},
+			{TaskType: TaskTypeMigrateToSpot, AgentType: "cost", Priority: steps[1]},
+		},
+	})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		agent.mu.Lock()
+		n := len(agent.priorities)
+		agent.mu.Unlock()
+		if n == 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("chain ran %d of 3 steps", n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	agent.mu.Lock()
+	defer agent.mu.Unlock()
+	return agent.priorities
+}
+
+func TestChainedTaskInheritsCriticalPriority(t *testing.T) {
+	r, reg := newTestRouter(t)
+
+	got := runChain(t, r, reg, PriorityCritical, [2]TaskPriority{PriorityLow, 0}, nil)
+	for _, taskType := range []TaskType{TaskTypeRightSize, TaskTypeMigrateToSpot} {
+		if got[taskType] != PriorityCritical {
+			t.Errorf("%s ran at priority %d, want %d", taskType, got[taskType], PriorityCritical)
+		}
+	}
+
+	tasks, err := r.ListTasks("")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	for _, task := range tasks {
+		if task.Type == TaskTypeRightSize && task.Metadata[ownPriorityKey] != PriorityLow {
+			t.Errorf("right_size own priority = %v, want %d", task.Metadata[ownPriorityKey], PriorityLow)
+		}
+		// migrate_to_spot's own priority is its default, and it does not
+		// carry right_size's
+		if task.Type == TaskTypeMigrateToSpot && task.Metadata[ownPriorityKey] != PriorityNormal {
+			t.Errorf("migrate_to_spot own priority = %v, want %d", task.Metadata[ownPriorityKey], PriorityNormal)
+		}
+	}
+}
+
+func TestChainedTaskKeepsCustomerDefaultPriority(t *testing.T) {
+	r, reg := newTestRouter(t)
+	r.SetCustomerDefaults(map[string]CustomerDefaults{"standard": {Priority: PriorityLow}})
+
+	// Nothing in the chain sets a priority, so every step gets the
+	// customer's default rather than the global one
+	got := runChain(t, r, reg, 0, [2]TaskPriority{}, map[string]interface{}{"customer_id": "standard"})
+	for taskType, priority := range got {
+		if priority != PriorityLow {
+			t.Errorf("%s ran at priority %d, want %d", taskType, priority, PriorityLow)
+		}
+	}
+}
+
+func TestChainedTaskInheritsDefaultedPriority(t *testing.T) {
+	r, reg := newTestRouter(t)
+	r.SetCustomerDefaults(map[string]CustomerDefaults{"premium": {Priority: PriorityHigh}})
+
+	// The first step is raised by the customer default, not an explicit
+	// priority; the low-priority step still inherits it
+	got := runChain(t, r, reg, 0, [2]TaskPriority{PriorityLow, 0}, map[string]interface{}{"customer_id": "premium"})
+	for taskType, priority := range got {
+		if priority != PriorityHigh {
+			t.Errorf("%s ran at priority %d, want %d", taskType, priority, PriorityHigh)
+		}
+	}
+}
+
+func TestChainedTaskNotLoweredByPrerequisite(t *testing.T) {
+	r, reg := newTestRouter(t)
+
+	got := runChain(t, r, reg, PriorityLow, [2]TaskPriority{PriorityHigh, 0}, nil)
+	want := map[TaskType]TaskPriority{
+		TaskTypeAnalyzeCost:   PriorityLow,
+		TaskTypeRightSize:     PriorityHigh,
+		TaskTypeMigrateToSpot: PriorityHigh,
+	}
+	for taskType, priority := range want {
+		if got[taskType] != priority {
+			t.Errorf("%s ran at priority %d, want %d", taskType, got[taskType], priority)
+		}
+	}
+}
+
+// chainAgent answers analyze_cost with result, or an error if fail is set,
+// and records the parameters every other task type is sent with
+type chainAgent struct {
+	result map[string]interface{}
+	fail   bool
+
+	mu     sync.Mutex
+	params map[TaskType]map[string]interface{}
+}
+
+func (a *chainAgent) handle(w http.ResponseWriter, req *http.Request) {
+	var taskReq TaskRequest
+	json.NewDecoder(req.Body).Decode(&taskReq)
+	a.mu.Lock()
+	a.params[taskReq.TaskType] = taskReq.Parameters
+	a.mu.Unlock()
+
+	if taskReq.TaskType == TaskTypeAnalyzeCost && a.fail {
+		http.Error(w, "bad input", http.StatusBadRequest)
+		return
+	}
+	result := map[string]interface{}{}
+	if taskReq.TaskType == TaskTypeAnalyzeCost {
+		result = a.result
+	}
+	json.NewEncoder(w).Encode(TaskResponse{TaskID: taskReq.TaskID, Status: TaskStatusCompleted, Result: result})
+}
+
+func (a *chainAgent) received(taskType TaskType) (map[string]interface{}, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	params, ok := a.params[taskType]
+	return params, ok
+}
+
+// submitTwoStepChain submits analyze_cost chained to right_size, mapping
+// the instance from the first result
+func submitTwoStepChain(t *testing.T, agent *chainAgent) (*Router, string) {
+	t.Helper()
+	r, reg := newTestRouter(t)
+	srv := newAgentServer(t, agent.handle)
+	registerAgent(t, reg, "cost-1", registry.AgentTypeCost, srv.URL, "analyze_cost", "right_size")
+
+	id := submit(t, r, &TaskSubmitRequest{
+		TaskType:   TaskTypeAnalyzeCost,
+		AgentType:  "cost",
+		Parameters: map[string]interface{}{"region": "us-east-1"},
+		ChainOnSuccess: []ChainStep{{
+			TaskType:   TaskTypeRightSize,
+			AgentType:  "cost",
+			Parameters: map[string]interface{}{"mode": "conservative"},
+			Mapping: map[string]string{
+				"instance_id": "result.largest.instance_id",
+				"region":      "parameters.region",
+			},
+		}},
+	}).TaskID
+	return r, id
+}
+
+func TestTwoStepChainMapsResult(t *testing.T) {
+	agent := &chainAgent{
+		result: map[string]interface{}{"largest": map[string]interface{}{"instance_id": "i-123"}},
+		params: make(map[TaskType]map[string]interface{}),
+	}
+	r, id := submitTwoStepChain(t, agent)
+
+	first := waitForTask(t, r, id, func(s *TaskStatusResponse) bool { return s.ChainedTaskID != "" })
+	waitForStatus(t, r, first.ChainedTaskID, TaskStatusCompleted)
+
+	params, ok := agent.received(TaskTypeRightSize)
+	if !ok {
+		t.Fatal("agent never received the chained task")
+	}
+	want := map[string]interface{}{"instance_id": "i-123", "region": "us-east-1", "mode": "conservative"}
+	for k, v := range want {
+		if params[k] != v {
+			t.Errorf("chained parameter %s = %v, want %v", k, params[k], v)
+		}
+	}
+	chained, err := r.getTask(first.ChainedTaskID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chained.Type != TaskTypeRightSize {
+		t.Errorf("chained task type = %s", chained.Type)
+	}
+	if chained.Metadata["parent_task_id"] != id {
+		t.Errorf("chained task parent = %v, want %s", chained.Metadata["parent_task_id"], id)
+	}
+}
+
+func TestFailedTaskAbortsChain(t *testing.T) {
+	agent := &chainAgent{fail: true, params: make(map[TaskType]map[string]interface{})}
+	r, id := submitTwoStepChain(t, agent)
+
+	first := waitForStatus(t, r, id, TaskStatusFailed)
+	time.Sleep(50 * time.Millisecond)
+	if first.ChainedTaskID != "" {
+		t.Errorf("failed task chained to %s", first.ChainedTaskID)
+	}
+	if _, ok := agent.received(TaskTypeRightSize); ok {
+		t.Error("chained task ran after its prerequisite failed")
+	}
+}
+
+func TestUnresolvableMappingAbortsChain(t *testing.T) {
+	// The result lacks the mapped field
+	agent := &chainAgent{result: map[string]interface{}{}, params: make(map[TaskType]map[string]interface{})}
+	r, id := submitTwoStepChain(t, agent)
+
+	waitForStatus(t, r, id, TaskStatusCompleted)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		task, err := r.getTask(id)
+		if err == nil && task.Metadata["chain_aborted"] != nil {
+			if task.ChainedTaskID != "" {
+				t.Errorf("aborted chain still submitted %s", task.ChainedTaskID)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("chain not marked aborted")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if _, ok := agent.received(TaskTypeRightSize); ok {
+		t.Error("chained task ran with an unresolved mapping")
+	}
+}
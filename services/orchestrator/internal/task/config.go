@@ -0,0 +1,81 @@
+package task
+
+import (
+	"fmt"
+	"time"
+)
+
+// Config holds the router's timeout and retry settings fixed at startup.
+// The defaults and retries also seed the runtime config, which the admin API
+// may change later.
+type Config struct {
+	DefaultTaskTimeout time.Duration // For tasks that set none
+	MaxTaskTimeout     time.Duration // Longest timeout a task may have
+	ResultTTL          time.Duration // How long tasks and results are kept
+	DefaultMaxRetries  int
+	RetryDelay         time.Duration
+
+	// How long a submission waits on the registry to pick an agent, 0
+	// meaning no bound, and how long a listing of agents by type is reused,
+	// 0 meaning every submission lists them afresh
+	AgentSelectionTimeout time.Duration
+	AgentListCacheTTL     time.Duration
+}
+
+// DefaultConfig returns the built-in timeout and retry settings
+func DefaultConfig() Config {
+	return Config{
+		DefaultTaskTimeout: defaultTaskTimeout,
+		MaxTaskTimeout:     defaultMaxTaskTimeout,
+		ResultTTL:          defaultTaskResultTTL,
+		DefaultMaxRetries:  defaultMaxRetries,
+		RetryDelay:         defaultRetryDelay,
+
+		AgentSelectionTimeout: defaultAgentSelectionTimeout,
+		AgentListCacheTTL:     defaultAgentListCacheTTL,
+	}
+}
+
+// Validate checks a config before it is given to a router
+func (c Config) Validate() error {
+	if c.MaxTaskTimeout <= 0 {
+		return fmt.Errorf("max task timeout must be positive")
+	}
+	if c.DefaultTaskTimeout <= 0 || c.DefaultTaskTimeout > c.MaxTaskTimeout {
+		return fmt.Errorf("default task timeout must be between 0 and %s", c.MaxTaskTimeout)
+	}
+	if c.ResultTTL <= 0 {
+		return fmt.Errorf("task result TTL must be positive")
+	}
+	if c.DefaultMaxRetries < 0 {
+		return fmt.Errorf("default max retries cannot be negative")
+	}
+	if c.RetryDelay < 0 {
+		return fmt.Errorf("retry delay cannot be negative")
+	}
+	if c.AgentSelectionTimeout < 0 {
+		return fmt.Errorf("agent selection timeout cannot be negative")
+	}
+	if c.AgentListCacheTTL < 0 {
+		return fmt.Errorf("agent list cache TTL cannot be negative")
+	}
+	return nil
+}
+
+// resultTTLSetter is implemented by stores whose retention the router
+// configures
+type resultTTLSetter interface {
+	SetTTL(ttl time.Duration)
+}
+
+// SetTTL sets how long tasks and results are kept; it must be called before
+// the store is used
+func (s *MemoryTaskStore) SetTTL(ttl time.Duration) {
+	s.ttl = ttl
+}
+
+// SetTTL sets how long tasks and results are kept; it must be called before
+// the store is used
+func (s *RedisTaskStore) SetTTL(ttl time.Duration) {
+	s.ttl = ttl
+}
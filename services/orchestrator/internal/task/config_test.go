@@ -0,0 +1,79 @@
+package task
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+func TestRouterConfigOverrides(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.DefaultTaskTimeout = 7 * time.Second
+	cfg.MaxTaskTimeout = 20 * time.Second
+	cfg.ResultTTL = 90 * time.Second
+	cfg.DefaultMaxRetries = 1
+	cfg.RetryDelay = 10 * time.Millisecond
+	r, reg := newTestRouterWithConfig(t, cfg)
+	agent := newAgentServer(t, completingAgent(map[string]interface{}{"ok": true}))
+	registerAgent(t, reg, "cost-1", registry.AgentTypeCost, agent.URL, "analyze_cost")
+
+	if ttl := r.store.(*MemoryTaskStore).ttl; ttl != 90*time.Second {
+		t.Errorf("store TTL = %v, want 90s", ttl)
+	}
+	if runtime := r.RuntimeConfig(); runtime.DefaultTaskTimeout != 7*time.Second || runtime.DefaultMaxRetries != 1 || runtime.RetryDelay != 10*time.Millisecond {
+		t.Errorf("runtime config seeded with %+v", runtime)
+	}
+
+	id := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost"}).TaskID
+	task, err := r.getTask(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Timeout != 7*time.Second || task.MaxRetries != 1 {
+		t.Errorf("task timeout %v and retries %d, want the configured 7s and 1", task.Timeout, task.MaxRetries)
+	}
+
+	// The maximum bounds requests, customer defaults and runtime updates
+	if _, err := r.SubmitTask(context.Background(), &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost", Timeout: 21}); err == nil {
+		t.Error("accepted a timeout above the configured maximum")
+	}
+	submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost", Timeout: 20})
+
+	r.SetCustomerDefaults(map[string]CustomerDefaults{"premium": {Timeout: time.Minute}})
+	id = submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost", Metadata: map[string]interface{}{"customer_id": "premium"}}).TaskID
+	if task, _ := r.getTask(id); task.Timeout != 20*time.Second {
+		t.Errorf("customer default timeout %v, want capped at 20s", task.Timeout)
+	}
+
+	runtime := r.RuntimeConfig()
+	runtime.DefaultTaskTimeout = 30 * time.Second
+	if err := r.ValidateRuntimeConfig(runtime); err == nil {
+		t.Error("runtime default timeout above the configured maximum accepted")
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Errorf("default config: %v", err)
+	}
+
+	invalid := map[string]func(*Config){
+		"no max timeout":       func(c *Config) { c.MaxTaskTimeout = 0 },
+		"default above max":    func(c *Config) { c.DefaultTaskTimeout = c.MaxTaskTimeout + time.Second },
+		"no result TTL":        func(c *Config) { c.ResultTTL = 0 },
+		"negative retries":     func(c *Config) { c.DefaultMaxRetries = -1 },
+		"negative retry delay": func(c *Config) { c.RetryDelay = -time.Second },
+		"non-positive default": func(c *Config) { c.DefaultTaskTimeout = 0 },
+		"negative selection":   func(c *Config) { c.AgentSelectionTimeout = -time.Second },
+		"negative cache TTL":   func(c *Config) { c.AgentListCacheTTL = -time.Second },
+	}
+	for name, change := range invalid {
+		cfg := DefaultConfig()
+		change(&cfg)
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%s: validated", name)
+		}
+	}
+}
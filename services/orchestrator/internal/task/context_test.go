@@ -0,0 +1,115 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+type contextKey string
+
+func TestCancelledSubmitStoresNothing(t *testing.T) {
+	r, reg := newTestRouter(t)
+	registerAgent(t, reg, "cost-1", registry.AgentTypeCost, "", "analyze_cost")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := r.SubmitTask(ctx, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost"})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if s := stats(t, r); s.Total != 0 {
+		t.Errorf("%d tasks stored by a cancelled submit", s.Total)
+	}
+}
+
+func TestCancellingSubmitAbortsStalledLookup(t *testing.T) {
+	r, reg, store := newStallingRouter(t, 0)
+	registerAgent(t, reg, "cost-1", registry.AgentTypeCost, "", "analyze_cost")
+	store.stall(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		_, err := r.SubmitTask(ctx, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost"})
+		errs <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, context.Canceled) || errors.Is(err, ErrAgentSelectionTimeout) {
+			t.Errorf("err = %v, want the submission aborted by cancellation", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("submit still waiting on the registry after cancellation")
+	}
+}
+
+func TestClientDisconnectAbortsSubmitRequest(t *testing.T) {
+	r, reg := newTestRouter(t)
+	registerAgent(t, reg, "cost-1", registry.AgentTypeCost, "", "analyze_cost")
+	engine := gin.New()
+	NewHandler(r).RegisterRoutes(engine)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(`{"task_type": "analyze_cost", "agent_type": "cost"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req.WithContext(ctx))
+
+	if rec.Code == http.StatusCreated || !strings.Contains(rec.Body.String(), "submission aborted") {
+		t.Errorf("status %d: %s, want the submission aborted", rec.Code, rec.Body.String())
+	}
+	if s := stats(t, r); s.Total != 0 {
+		t.Errorf("%d tasks stored for a disconnected client", s.Total)
+	}
+}
+
+func TestExecutionOutlivesSubmitContext(t *testing.T) {
+	r, reg := newTestRouter(t)
+	registerAgent(t, reg, "cost-1", registry.AgentTypeCost, blockingAgent(t), "analyze_cost", "right_size")
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), contextKey("request"), "req-1"))
+	resp, err := r.SubmitTask(ctx, &TaskSubmitRequest{TaskType: TaskTypeRightSize, AgentType: "cost"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForStatus(t, r, resp.TaskID, TaskStatusSent)
+	cancel()
+	time.Sleep(20 * time.Millisecond)
+
+	// The request ending neither cancels the execution nor drops its values
+	r.mu.RLock()
+	execCtx := r.tasks[resp.TaskID].executionContext(nil)
+	r.mu.RUnlock()
+	if err := execCtx.Err(); err != nil {
+		t.Errorf("execution context ended with the request: %v", err)
+	}
+	if v := execCtx.Value(contextKey("request")); v != "req-1" {
+		t.Errorf("execution context value = %v, want the request's", v)
+	}
+	if status, _ := r.GetTaskStatus(resp.TaskID); status.Status != TaskStatusSent {
+		t.Errorf("task %s after the request ended, want still sent", status.Status)
+	}
+
+	// Cancelling the task ends its execution
+	if err := r.CancelTask(resp.TaskID); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-execCtx.Done():
+	case <-time.After(time.Second):
+		t.Error("execution context still live after cancelling the task")
+	}
+}
@@ -0,0 +1,41 @@
+package task
+
+import (
+	"log"
+	"sort"
+)
+
+// CorrelationIDKey is the task metadata key linking a task to the
+// coordination run it was spawned from
+const CorrelationIDKey = "correlation_id"
+
+// CancelCorrelated cancels this replica's unfinished tasks whose metadata
+// carries the correlation ID, returning the IDs of the tasks cancelled
+func (r *Router) CancelCorrelated(correlationID, reason string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var matched []*Task
+	for _, task := range r.tasks {
+		if id, _ := task.Metadata[CorrelationIDKey].(string); id == correlationID && !isTerminal(task.Status) {
+			matched = append(matched, task)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].CreatedAt.Before(matched[j].CreatedAt)
+	})
+
+	cancelled := make([]string, 0, len(matched))
+	for _, task := range matched {
+		// Cancelling a broadcast parent finishes its children too
+		if isTerminal(task.Status) {
+			continue
+		}
+		if err := r.cancelTaskLocked(task, reason); err != nil {
+			log.Printf("Warning: failed to cancel task %s of %s: %v", task.ID, correlationID, err)
+			continue
+		}
+		cancelled = append(cancelled, task.ID)
+	}
+	return cancelled
+}
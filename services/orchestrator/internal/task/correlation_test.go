@@ -0,0 +1,58 @@
+package task
+
+import (
+	"fmt"
+	"testing"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+func TestCancelCorrelatedCancelsOnlyThatRunsUnfinishedTasks(t *testing.T) {
+	r, reg := newTestRouter(t)
+	registerAgent(t, reg, "cost-1", registry.AgentTypeCost, blockingAgent(t), "analyze_cost", "right_size")
+
+	correlated := func(taskType TaskType, correlationID string) string {
+		return submit(t, r, &TaskSubmitRequest{
+			TaskType:  taskType,
+			AgentType: "cost",
+			Metadata:  map[string]interface{}{CorrelationIDKey: correlationID},
+		}).TaskID
+	}
+	first := correlated(TaskTypeRightSize, "coord-1")
+	second := correlated(TaskTypeRightSize, "coord-1")
+	finished := correlated(TaskTypeAnalyzeCost, "coord-1")
+	other := correlated(TaskTypeRightSize, "coord-2")
+	untagged := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeRightSize, AgentType: "cost"}).TaskID
+	for _, id := range []string{first, second, other, untagged} {
+		waitForStatus(t, r, id, TaskStatusSent)
+	}
+	waitForStatus(t, r, finished, TaskStatusCompleted)
+
+	cancelled := r.CancelCorrelated("coord-1", "coordination coord-1 cancelled")
+	if fmt.Sprint(cancelled) != fmt.Sprint([]string{first, second}) {
+		t.Fatalf("cancelled %v, want %s and %s oldest first", cancelled, first, second)
+	}
+	for _, id := range cancelled {
+		if status, _ := r.GetTaskStatus(id); status.Status != TaskStatusFailed || status.Error != "coordination coord-1 cancelled" {
+			t.Errorf("task %s = %s (%q), want failed with the reason", id, status.Status, status.Error)
+		}
+	}
+
+	// Finished tasks and other runs' tasks are left alone
+	if status, _ := r.GetTaskStatus(finished); status.Status != TaskStatusCompleted {
+		t.Errorf("finished task %s, want still completed", status.Status)
+	}
+	for _, id := range []string{other, untagged} {
+		if status, _ := r.GetTaskStatus(id); status.Status != TaskStatusSent {
+			t.Errorf("task %s %s, want still sent", id, status.Status)
+		}
+	}
+
+	// Nothing is left to cancel a second time
+	if again := r.CancelCorrelated("coord-1", "again"); len(again) != 0 {
+		t.Errorf("second cancel returned %v, want none", again)
+	}
+	if none := r.CancelCorrelated("missing", "unknown"); len(none) != 0 {
+		t.Errorf("unknown correlation cancelled %v", none)
+	}
+}
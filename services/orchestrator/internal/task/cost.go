@@ -0,0 +1,93 @@
+package task
+
+import (
+	"math"
+	"strconv"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+const (
+	// Agent metadata key holding the agent's price per task
+	costPerTaskKey = "cost_per_task"
+
+	// Weight of the newest sample in the per-agent latency average
+	latencySmoothing = 0.2
+
+	defaultCostWeight = 1.0
+)
+
+// recordLatency folds a completed task's execution time into the agent's
+// moving average. It must be called with r.mu held.
+func (r *Router) recordLatency(agentID string, executionMs int) {
+	if executionMs <= 0 {
+		return
+	}
+	sample := float64(executionMs)
+	if avg, ok := r.agentLatency[agentID]; ok {
+		sample = latencySmoothing*sample + (1-latencySmoothing)*avg
+	}
+	r.agentLatency[agentID] = sample
+}
+
+// cheapestAgent scores candidates by cost and latency, each normalized to
+// the highest among candidates, weighted by CostWeight. Agents without cost
+// data rank after those with it; if none report cost it falls back to the
+// first candidate. It must be called with r.mu held.
+func (r *Router) cheapestAgent(candidates []*registry.Agent) *registry.Agent {
+	type scored struct {
+		agent   *registry.Agent
+		cost    float64
+		latency float64
+	}
+
+	var priced []scored
+	var maxCost, maxLatency float64
+	for _, agent := range candidates {
+		cost, ok := agentCost(agent)
+		if !ok {
+			continue
+		}
+		latency := r.agentLatency[agent.ID]
+		priced = append(priced, scored{agent: agent, cost: cost, latency: latency})
+		maxCost = math.Max(maxCost, cost)
+		maxLatency = math.Max(maxLatency, latency)
+	}
+	if len(priced) == 0 {
+		return candidates[0]
+	}
+
+	weight := r.runtime.CostWeight
+	best, bestScore := priced[0].agent, math.Inf(1)
+	for _, p := range priced {
+		score := weight*normalize(p.cost, maxCost) + (1-weight)*normalize(p.latency, maxLatency)
+		// Candidates are sorted by ID, so strict < keeps ties deterministic
+		if score < bestScore {
+			best, bestScore = p.agent, score
+		}
+	}
+	return best
+}
+
+// agentCost reads cost_per_task from agent metadata, which arrives as a JSON
+// number or a numeric string
+func agentCost(agent *registry.Agent) (float64, bool) {
+	switch v := agent.Metadata[costPerTaskKey].(type) {
+	case float64:
+		return v, v >= 0
+	case int:
+		return float64(v), v >= 0
+	case string:
+		cost, err := strconv.ParseFloat(v, 64)
+		return cost, err == nil && cost >= 0
+	default:
+		return 0, false
+	}
+}
+
+func normalize(value, max float64) float64 {
+	if max <= 0 {
+		return 0
+	}
+	return value / max
+}
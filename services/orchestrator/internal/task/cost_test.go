@@ -0,0 +1,99 @@
+package task
+
+import (
+	"testing"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+// pricedAgents returns candidates sorted by ID, each priced at the matching
+// cost; a nil cost leaves the agent without cost data
+func pricedAgents(costs ...interface{}) []*registry.Agent {
+	ids := []string{"agent-a", "agent-b", "agent-c", "agent-d"}[:len(costs)]
+	agents := testAgents(ids...)
+	for i, cost := range costs {
+		if cost != nil {
+			agents[i].Metadata = map[string]interface{}{costPerTaskKey: cost}
+		}
+	}
+	return agents
+}
+
+func cheapest(r *Router, candidates []*registry.Agent) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cheapestAgent(candidates).ID
+}
+
+func TestCheapestAgentChosen(t *testing.T) {
+	r, _ := newTestRouter(t)
+
+	tests := []struct {
+		name  string
+		costs []interface{}
+		want  string
+	}{
+		{"lowest price", []interface{}{0.5, 0.2, 0.9}, "agent-b"},
+		{"numeric string", []interface{}{0.5, "0.1", 0.9}, "agent-b"},
+		{"tie keeps ID order", []interface{}{0.3, 0.3}, "agent-a"},
+		// Agents without cost data rank after priced ones
+		{"missing cost", []interface{}{nil, 0.9, nil}, "agent-b"},
+		{"unparseable cost", []interface{}{"cheap", 0.9}, "agent-b"},
+		{"negative cost", []interface{}{-1.0, 0.9}, "agent-b"},
+		// With no cost data at all, the first candidate is kept
+		{"no cost data", []interface{}{nil, nil, nil}, "agent-a"},
+	}
+	for _, tt := range tests {
+		if got := cheapest(r, pricedAgents(tt.costs...)); got != tt.want {
+			t.Errorf("%s: picked %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCostLatencyTradeoff(t *testing.T) {
+	r, _ := newTestRouter(t)
+	candidates := pricedAgents(0.1, 0.2)
+	r.mu.Lock()
+	r.agentLatency["agent-a"] = 900
+	r.agentLatency["agent-b"] = 100
+	r.mu.Unlock()
+
+	for weight, want := range map[float64]string{1: "agent-a", 0: "agent-b", 0.3: "agent-b"} {
+		r.mu.Lock()
+		r.runtime.CostWeight = weight
+		r.mu.Unlock()
+		if got := cheapest(r, candidates); got != want {
+			t.Errorf("cost weight %v: picked %s, want %s", weight, got, want)
+		}
+	}
+}
+
+func TestCheapestStrategyRoutesToCheapestAgent(t *testing.T) {
+	agent := newAgentServer(t, completingAgent(map[string]interface{}{"ok": true}))
+	host, port := hostPort(t, agent.URL)
+	r, reg := newTestRouter(t)
+	r.SetLoadBalancingStrategy(LoadBalanceCheapest)
+
+	var cheapestID string
+	for name, cost := range map[string]float64{"cost-1": 0.8, "cost-2": 0.05, "cost-3": 0.4} {
+		resp, err := reg.Register(&registry.RegistrationRequest{
+			Name:         name,
+			Type:         registry.AgentTypeCost,
+			Host:         host,
+			Port:         port,
+			Capabilities: []string{string(TaskTypeAnalyzeCost)},
+			Metadata:     map[string]interface{}{costPerTaskKey: cost},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name == "cost-2" {
+			cheapestID = resp.AgentID
+		}
+	}
+
+	id := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost"}).TaskID
+	if status := waitForStatus(t, r, id, TaskStatusCompleted); status.AgentID != cheapestID {
+		t.Errorf("routed to %s, want the cheapest agent %s", status.AgentID, cheapestID)
+	}
+}
@@ -0,0 +1,105 @@
+package task
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// countReconcileInterval is how often status counters are recounted from
+// the task index to heal drift from expired keys or crashed replicas
+const countReconcileInterval = 5 * time.Minute
+
+// TaskStats reports how many indexed tasks are in each status
+type TaskStats struct {
+	Counts map[TaskStatus]int64 `json:"counts"`
+	Total  int64                `json:"total"`
+}
+
+// Stats returns task counts across all replicas
+func (r *Router) Stats() (*TaskStats, error) {
+	counts, err := r.store.StatusCounts(r.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &TaskStats{Counts: counts}
+	for _, n := range counts {
+		stats.Total += n
+	}
+	return stats, nil
+}
+
+// recordStatus updates the status counters when a persisted task changes
+// status. Each task is owned by one replica, so transitions are counted once.
+// A task is forgotten once it finishes; every task is first stored
+// unfinished, so a finished task not remembered was already counted and its
+// later saves are skipped.
+func (r *Router) recordStatus(task *Task) {
+	r.countMu.Lock()
+	previous, seen := r.counted[task.ID]
+	if (seen && previous == task.Status) || (!seen && isTerminal(task.Status)) {
+		r.countMu.Unlock()
+		return
+	}
+	if isTerminal(task.Status) {
+		delete(r.counted, task.ID)
+	} else {
+		r.counted[task.ID] = task.Status
+	}
+	r.countMu.Unlock()
+
+	if err := r.store.RecordTransition(r.ctx, previous, task.Status); err != nil {
+		log.Printf("Warning: failed to update task counters for %s: %v", task.ID, err)
+	}
+}
+
+// uncount removes a finished task that left the index from the status
+// counters, where it was last counted under its final status
+func (r *Router) uncount(task *Task) {
+	r.countMu.Lock()
+	delete(r.counted, task.ID)
+	r.countMu.Unlock()
+
+	if err := r.store.RecordTransition(r.ctx, task.Status, ""); err != nil {
+		log.Printf("Warning: failed to update task counters for %s: %v", task.ID, err)
+	}
+}
+
+// countReconciler periodically recounts statuses from the task index
+func (r *Router) countReconciler() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(countReconcileInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := r.store.ReconcileCounts(r.ctx); err != nil {
+				log.Printf("Failed to reconcile task counters: %v", err)
+			}
+		case <-r.stopCh:
+			return
+		}
+	}
+}
+
+// countStatuses recounts indexed tasks by status by paging through the store
+func countStatuses(ctx context.Context, store TaskStore) (map[TaskStatus]int64, error) {
+	counts := make(map[TaskStatus]int64)
+	offset := 0
+	for {
+		page, err := store.ListTasks(ctx, "", offset, maxTaskPageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, task := range page.Tasks {
+			counts[task.Status]++
+		}
+		if page.NextOffset < 0 {
+			return counts, nil
+		}
+		offset = page.NextOffset
+	}
+}
@@ -0,0 +1,119 @@
+package task
+
+import (
+	"testing"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+func stats(t *testing.T, r *Router) *TaskStats {
+	t.Helper()
+	s, err := r.Stats()
+	if err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+	return s
+}
+
+func TestCountersFollowSubmitCompleteCancel(t *testing.T) {
+	for name, newStore := range taskStores() {
+		t.Run(name, func(t *testing.T) {
+			store := newStore(t)
+			r, reg := newStoreRouter(t, store)
+			registerAgent(t, reg, "cost-1", registry.AgentTypeCost, blockingAgent(t), "analyze_cost", "right_size")
+
+			completed := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost"}).TaskID
+			held := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeRightSize, AgentType: "cost"}).TaskID
+			waitForStatus(t, r, completed, TaskStatusCompleted)
+			waitForStatus(t, r, held, TaskStatusSent)
+
+			s := stats(t, r)
+			if s.Total != 2 || s.Counts[TaskStatusCompleted] != 1 || s.Counts[TaskStatusSent] != 1 {
+				t.Fatalf("stats before cancel = %+v", s)
+			}
+			// Intermediate statuses are moved out of, not left behind
+			for _, status := range []TaskStatus{TaskStatusPending, TaskStatusQueued} {
+				if n := s.Counts[status]; n != 0 {
+					t.Errorf("%d tasks counted as %s", n, status)
+				}
+			}
+
+			// A cancelled task leaves the index and the counters
+			if err := r.CancelTask(held); err != nil {
+				t.Fatalf("cancel: %v", err)
+			}
+			s = stats(t, r)
+			if s.Total != 1 || s.Counts[TaskStatusCompleted] != 1 || s.Counts[TaskStatusSent] != 0 {
+				t.Errorf("stats after cancel = %+v", s)
+			}
+
+			// The counters agree with a recount of the index
+			recounted, err := store.ReconcileCounts(r.ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for status, n := range recounted {
+				if s.Counts[status] != n {
+					t.Errorf("%s: counted %d, recounted %d", status, s.Counts[status], n)
+				}
+			}
+		})
+	}
+}
+
+func TestCountersForgetFinishedTasks(t *testing.T) {
+	for name, newStore := range taskStores() {
+		t.Run(name, func(t *testing.T) {
+			r, reg := newStoreRouter(t, newStore(t))
+			agent := newAgentServer(t, completingAgent(map[string]interface{}{"ok": true}))
+			registerAgent(t, reg, "cost-1", registry.AgentTypeCost, agent.URL, "analyze_cost", "right_size")
+
+			const n = 20
+			for i := 0; i < n; i++ {
+				id := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost"}).TaskID
+				waitForStatus(t, r, id, TaskStatusCompleted)
+			}
+
+			// A finished task saved again when its chained task is submitted
+			// is not counted twice
+			first := submit(t, r, &TaskSubmitRequest{
+				TaskType:       TaskTypeAnalyzeCost,
+				AgentType:      "cost",
+				ChainOnSuccess: []ChainStep{{TaskType: TaskTypeRightSize, AgentType: "cost"}},
+			}).TaskID
+			chained := waitForTask(t, r, first, func(s *TaskStatusResponse) bool { return s.ChainedTaskID != "" }).ChainedTaskID
+			waitForStatus(t, r, chained, TaskStatusCompleted)
+
+			if s := stats(t, r); s.Total != n+2 || s.Counts[TaskStatusCompleted] != n+2 {
+				t.Errorf("stats = %+v, want %d completed", s, n+2)
+			}
+			r.countMu.Lock()
+			remembered := len(r.counted)
+			r.countMu.Unlock()
+			if remembered != 0 {
+				t.Errorf("%d finished tasks still remembered by the counters", remembered)
+			}
+		})
+	}
+}
+
+func TestCountersSharedAcrossReplicas(t *testing.T) {
+	store := newMiniRedisTaskStore(t)
+	agent := newAgentServer(t, completingAgent(map[string]interface{}{"ok": true}))
+
+	var replicas []*Router
+	for i := 0; i < 2; i++ {
+		r, reg := newStoreRouter(t, store)
+		registerAgent(t, reg, "cost-1", registry.AgentTypeCost, agent.URL, "analyze_cost")
+		id := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost"}).TaskID
+		waitForStatus(t, r, id, TaskStatusCompleted)
+		replicas = append(replicas, r)
+	}
+
+	// Each replica sees the tasks completed on both
+	for i, r := range replicas {
+		if s := stats(t, r); s.Total != 2 || s.Counts[TaskStatusCompleted] != 2 {
+			t.Errorf("replica %d stats = %+v, want 2 completed", i, s)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package task
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// customerIDKey is the task metadata key naming the customer
+const customerIDKey = "customer_id"
+
+// CustomerDefaults are the priority and timeout given to a customer's tasks
+// that do not set them; zero fields fall through to the global defaults
+type CustomerDefaults struct {
+	Priority TaskPriority  `json:"priority,omitempty"`
+	Timeout  time.Duration `json:"timeout,omitempty"`
+}
+
+// ParseCustomerDefaults parses a spec like
+// "premium=priority:10,timeout:2m;standard=priority:1"
+func ParseCustomerDefaults(spec string) (map[string]CustomerDefaults, error) {
+	defaults := make(map[string]CustomerDefaults)
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		customer, settings, ok := strings.Cut(entry, "=")
+		customer = strings.TrimSpace(customer)
+		if !ok || customer == "" {
+			return nil, fmt.Errorf("invalid customer defaults entry %q", entry)
+		}
+
+		var d CustomerDefaults
+		for _, setting := range strings.Split(settings, ",") {
+			key, value, ok := strings.Cut(strings.TrimSpace(setting), ":")
+			value = strings.TrimSpace(value)
+			switch {
+			case !ok:
+				return nil, fmt.Errorf("invalid setting %q for customer %s", setting, customer)
+			case key == "priority":
+				n, err := strconv.Atoi(value)
+				if err != nil || n <= 0 {
+					return nil, fmt.Errorf("invalid priority for customer %s: %q", customer, value)
+				}
+				d.Priority = TaskPriority(n)
+			case key == "timeout":
+				timeout, err := time.ParseDuration(value)
+				if err != nil || timeout <= 0 {
+					return nil, fmt.Errorf("invalid timeout for customer %s: %q", customer, value)
+				}
+				d.Timeout = timeout
+			default:
+				return nil, fmt.Errorf("unknown setting %q for customer %s", key, customer)
+			}
+		}
+		defaults[customer] = d
+	}
+	return defaults, nil
+}
+
+// SetCustomerDefaults replaces the per-customer defaults. Tasks name their
+// customer with the customer_id metadata key.
+func (r *Router) SetCustomerDefaults(defaults map[string]CustomerDefaults) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.customerDefaults = defaults
+}
+
+// customerDefaultsFor returns the defaults of the customer named in a task's
+// metadata. It must be called with r.mu held.
+func (r *Router) customerDefaultsFor(metadata map[string]interface{}) CustomerDefaults {
+	customer, _ := metadata[customerIDKey].(string)
+	if customer == "" {
+		return CustomerDefaults{}
+	}
+	return r.customerDefaults[customer]
+}
@@ -0,0 +1,65 @@
+package task
+
+import (
+	"testing"
+	"time"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+func TestCustomerDefaultsApplied(t *testing.T) {
+	agent := newAgentServer(t, completingAgent(map[string]interface{}{"ok": true}))
+	r, reg := newTestRouter(t)
+	registerAgent(t, reg, "cost-1", registry.AgentTypeCost, agent.URL, "analyze_cost")
+	r.SetCustomerDefaults(map[string]CustomerDefaults{
+		"premium":  {Priority: PriorityHigh, Timeout: 2 * time.Minute},
+		"standard": {Priority: PriorityLow},
+	})
+
+	tests := []struct {
+		name     string
+		metadata map[string]interface{}
+		priority TaskPriority
+		timeout  int // Requested, in seconds
+		want     TaskPriority
+		wantTime time.Duration
+	}{
+		{"premium", map[string]interface{}{"customer_id": "premium"}, 0, 0, PriorityHigh, 2 * time.Minute},
+		{"standard", map[string]interface{}{"customer_id": "standard"}, 0, 0, PriorityLow, r.runtime.DefaultTaskTimeout},
+		{"unknown customer", map[string]interface{}{"customer_id": "trial"}, 0, 0, PriorityNormal, r.runtime.DefaultTaskTimeout},
+		{"no customer", nil, 0, 0, PriorityNormal, r.runtime.DefaultTaskTimeout},
+		{"request wins", map[string]interface{}{"customer_id": "premium"}, PriorityLow, 30, PriorityLow, 30 * time.Second},
+	}
+	for _, tt := range tests {
+		id := submit(t, r, &TaskSubmitRequest{
+			TaskType:  TaskTypeAnalyzeCost,
+			AgentType: "cost",
+			Priority:  tt.priority,
+			Timeout:   tt.timeout,
+			Metadata:  tt.metadata,
+		}).TaskID
+		task, err := r.getTask(id)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if task.Priority != tt.want || task.Timeout != tt.wantTime {
+			t.Errorf("%s: priority %d, timeout %v, want %d, %v", tt.name, task.Priority, task.Timeout, tt.want, tt.wantTime)
+		}
+	}
+}
+
+func TestParseCustomerDefaults(t *testing.T) {
+	defaults, err := ParseCustomerDefaults(" premium = priority:10, timeout:2m ; standard=priority:1;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(defaults) != 2 || defaults["premium"] != (CustomerDefaults{Priority: 10, Timeout: 2 * time.Minute}) || defaults["standard"] != (CustomerDefaults{Priority: 1}) {
+		t.Errorf("defaults = %+v", defaults)
+	}
+
+	for _, spec := range []string{"premium", "=priority:1", "premium=priority", "premium=priority:0", "premium=timeout:soon", "premium=retries:3"} {
+		if _, err := ParseCustomerDefaults(spec); err == nil {
+			t.Errorf("parsed %q", spec)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package task
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// AgentError is a task failure reported by the agent itself, optionally
+// classified so the router knows whether retrying can help
+type AgentError struct {
+	StatusCode int    // HTTP status the agent responded with
+	Code       string // Agent-defined error code, if any
+	Message    string
+	Retryable  *bool // nil when the agent did not classify the error
+}
+
+func (e *AgentError) Error() string {
+	msg := fmt.Sprintf("agent returned error: %d - %s", e.StatusCode, e.Message)
+	if e.Code != "" {
+		msg += fmt.Sprintf(" (code %s)", e.Code)
+	}
+	return msg
+}
+
+// permanent reports whether the agent marked the error as not worth retrying
+func (e *AgentError) permanent() bool {
+	return e.Retryable != nil && !*e.Retryable
+}
+
+// isPermanent reports whether err is an agent error marked non-retryable
+func isPermanent(err error) bool {
+	var agentErr *AgentError
+	return errors.As(err, &agentErr) && agentErr.permanent()
+}
+
+// errorCode returns the agent's code for err, if it reported one
+func errorCode(err error) string {
+	var agentErr *AgentError
+	if errors.As(err, &agentErr) {
+		return agentErr.Code
+	}
+	return ""
+}
+
+// parseAgentError builds an AgentError from a non-200 response body. A body
+// in TaskResponse form contributes its error, code and classification;
+// anything else is kept verbatim as the message.
+func parseAgentError(statusCode int, body []byte) *AgentError {
+	agentErr := &AgentError{StatusCode: statusCode, Message: string(body)}
+
+	var resp TaskResponse
+	if err := json.Unmarshal(body, &resp); err == nil && (resp.Error != "" || resp.ErrorCode != "") {
+		agentErr.Message = resp.Error
+		agentErr.Code = resp.ErrorCode
+		agentErr.Retryable = resp.Retryable
+	}
+	return agentErr
+}
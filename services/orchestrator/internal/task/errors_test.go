@@ -0,0 +1,79 @@
+package task
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+// classifiedAgent fails every attempt with code, classified as retryable or
+// not, answering with httpStatus; it counts the attempts it receives
+func classifiedAgent(httpStatus int, code string, retryable *bool, attempts *int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(attempts, 1)
+		var taskReq TaskRequest
+		json.NewDecoder(req.Body).Decode(&taskReq)
+		w.WriteHeader(httpStatus)
+		json.NewEncoder(w).Encode(TaskResponse{
+			TaskID:    taskReq.TaskID,
+			Status:    TaskStatusFailed,
+			Error:     "bad parameters",
+			ErrorCode: code,
+			Retryable: retryable,
+		})
+	}
+}
+
+func TestAgentErrorClassificationControlsRetries(t *testing.T) {
+	no, yes := false, true
+	tests := []struct {
+		name       string
+		httpStatus int
+		retryable  *bool
+		attempts   int32
+	}{
+		{"non-retryable", http.StatusUnprocessableEntity, &no, 1},
+		{"non-retryable with 200", http.StatusOK, &no, 1},
+		{"retryable", http.StatusUnprocessableEntity, &yes, 3},
+		{"unclassified", http.StatusUnprocessableEntity, nil, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int32
+			agent := newAgentServer(t, classifiedAgent(tt.httpStatus, "invalid_region", tt.retryable, &attempts))
+			r, reg := newTestRouter(t)
+			registerAgent(t, reg, "cost-1", registry.AgentTypeCost, agent.URL, "analyze_cost")
+
+			id := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost", MaxRetries: 2}).TaskID
+			status := waitForStatus(t, r, id, TaskStatusFailed)
+			if got := atomic.LoadInt32(&attempts); got != tt.attempts {
+				t.Errorf("%d attempts, want %d", got, tt.attempts)
+			}
+			if status.RetryCount != int(tt.attempts)-1 {
+				t.Errorf("retry count %d, want %d", status.RetryCount, tt.attempts-1)
+			}
+			if status.ErrorCode != "invalid_region" {
+				t.Errorf("error code = %q, want the agent's", status.ErrorCode)
+			}
+		})
+	}
+}
+
+func TestParseAgentError(t *testing.T) {
+	agentErr := parseAgentError(http.StatusBadRequest, []byte(`{"error": "unknown region", "error_code": "invalid_region", "retryable": false}`))
+	if agentErr.Message != "unknown region" || agentErr.Code != "invalid_region" || !agentErr.permanent() {
+		t.Errorf("classified error = %+v", agentErr)
+	}
+	if !isPermanent(agentErr) {
+		t.Error("isPermanent missed a non-retryable agent error")
+	}
+
+	// Anything else is kept verbatim and left unclassified
+	agentErr = parseAgentError(http.StatusBadGateway, []byte("upstream down"))
+	if agentErr.Message != "upstream down" || agentErr.Retryable != nil || agentErr.permanent() {
+		t.Errorf("plain error = %+v", agentErr)
+	}
+}
@@ -0,0 +1,79 @@
+package task
+
+import (
+	"log"
+	"time"
+)
+
+// maxTaskEvents caps the event log kept per task; older events are dropped
+const maxTaskEvents = 100
+
+// TaskEventType names a step in a task's lifecycle
+type TaskEventType string
+
+const (
+	TaskEventSubmitted   TaskEventType = "submitted"
+	TaskEventRouted      TaskEventType = "routed"
+	TaskEventSent        TaskEventType = "sent"
+	TaskEventRetry       TaskEventType = "retry"
+	TaskEventCompleted   TaskEventType = "completed"
+	TaskEventFailed      TaskEventType = "failed"
+	TaskEventCancelled   TaskEventType = "cancelled"
+	TaskEventTimeout     TaskEventType = "timeout"
+	TaskEventQuarantined TaskEventType = "quarantined"
+)
+
+// TaskEvent is one entry in a task's event log
+type TaskEvent struct {
+	Type      TaskEventType `json:"type"`
+	Status    TaskStatus    `json:"status"`
+	AgentID   string        `json:"agent_id,omitempty"`
+	Attempt   int           `json:"attempt,omitempty"`
+	Message   string        `json:"message,omitempty"`
+	Timestamp time.Time     `json:"timestamp"`
+}
+
+// TaskEventsResponse returns a task's event log, oldest first
+type TaskEventsResponse struct {
+	TaskID string      `json:"task_id"`
+	Events []TaskEvent `json:"events"`
+	Count  int         `json:"count"`
+}
+
+// recordEvent appends an event with the task's current status and agent to
+// its log and notifies outcome listeners if the event ends the task.
+// Failures to record are logged and otherwise ignored.
+func (r *Router) recordEvent(task *Task, eventType TaskEventType, message string) {
+	event := TaskEvent{
+		Type:      eventType,
+		Status:    task.Status,
+		AgentID:   task.AgentID,
+		Attempt:   task.RetryCount,
+		Message:   message,
+		Timestamp: time.Now(),
+	}
+	if err := r.store.AppendEvent(r.ctx, task.ID, event); err != nil {
+		log.Printf("Warning: failed to record %s event for task %s: %v", eventType, task.ID, err)
+	}
+	r.notifyOutcome(task, eventType)
+}
+
+// TaskEvents returns a task's event log, oldest first
+func (r *Router) TaskEvents(taskID string) (*TaskEventsResponse, error) {
+	events, err := r.store.ListEvents(r.ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		// Distinguish an unknown task from one whose log expired
+		if _, err := r.getTask(taskID); err != nil {
+			return nil, err
+		}
+	}
+
+	return &TaskEventsResponse{
+		TaskID: taskID,
+		Events: events,
+		Count:  len(events),
+	}, nil
+}
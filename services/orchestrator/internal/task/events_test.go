@@ -0,0 +1,95 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+// flakyAgent fails the first attempt it receives and completes the rest
+func flakyAgent() http.HandlerFunc {
+	var attempts int32
+	complete := completingAgent(map[string]interface{}{"ok": true})
+	return func(w http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			http.Error(w, "agent restarting", http.StatusServiceUnavailable)
+			return
+		}
+		complete(w, req)
+	}
+}
+
+func TestEventHistoryForRetriedTask(t *testing.T) {
+	forEachTaskStore(t, func(t *testing.T, store TaskStore) {
+		agent := newAgentServer(t, flakyAgent())
+		r, reg := newStoreRouter(t, store)
+		agentID := registerAgent(t, reg, "cost-1", registry.AgentTypeCost, agent.URL, "analyze_cost")
+
+		id := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost", MaxRetries: 2}).TaskID
+		waitForStatus(t, r, id, TaskStatusCompleted)
+
+		// The completed event lands just after the status
+		var history TaskEventsResponse
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			if code := getJSON(t, r, "/tasks/"+id+"/events/history", &history); code != http.StatusOK {
+				t.Fatalf("status %d", code)
+			}
+			if n := len(history.Events); (n > 0 && history.Events[n-1].Type == TaskEventCompleted) || time.Now().After(deadline) {
+				break
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		var types []TaskEventType
+		for _, event := range history.Events {
+			types = append(types, event.Type)
+		}
+		want := []TaskEventType{TaskEventSubmitted, TaskEventRouted, TaskEventSent, TaskEventRetry, TaskEventSent, TaskEventCompleted}
+		if fmt.Sprint(types) != fmt.Sprint(want) || history.Count != len(want) || history.TaskID != id {
+			t.Fatalf("events %v (count %d), want %v", types, history.Count, want)
+		}
+
+		for i, event := range history.Events {
+			if i > 0 && event.Timestamp.Before(history.Events[i-1].Timestamp) {
+				t.Errorf("event %d (%s) older than the one before it", i, event.Type)
+			}
+			if i > 0 && event.AgentID != agentID {
+				t.Errorf("%s event on agent %q, want %s", event.Type, event.AgentID, agentID)
+			}
+		}
+		retry, last := history.Events[3], history.Events[5]
+		if retry.Status != TaskStatusRetrying || retry.Attempt != 1 || retry.Message == "" {
+			t.Errorf("retry event = %+v", retry)
+		}
+		if last.Status != TaskStatusCompleted || last.Attempt != 1 {
+			t.Errorf("completed event = %+v", last)
+		}
+
+		if code := getJSON(t, r, "/tasks/missing/events/history", nil); code != http.StatusNotFound {
+			t.Errorf("unknown task: status %d, want 404", code)
+		}
+	})
+}
+
+func TestEventLogCapped(t *testing.T) {
+	forEachTaskStore(t, func(t *testing.T, store TaskStore) {
+		for i := 0; i < maxTaskEvents+5; i++ {
+			event := TaskEvent{Type: TaskEventRetry, Attempt: i}
+			if err := store.AppendEvent(context.Background(), "task-1", event); err != nil {
+				t.Fatal(err)
+			}
+		}
+		events, err := store.ListEvents(context.Background(), "task-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != maxTaskEvents || events[0].Attempt != 5 || events[len(events)-1].Attempt != maxTaskEvents+4 {
+			t.Errorf("%d events from attempt %d, want the latest %d", len(events), events[0].Attempt, maxTaskEvents)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package task
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// Finished tasks kept for the recent failure rate
+	recentOutcomeLimit = 200
+
+	// Default age beyond which finished tasks no longer count
+	defaultFailureRateWindow = 5 * time.Minute
+)
+
+// recentOutcomes is a ring of the latest finished tasks and whether each
+// failed
+type recentOutcomes struct {
+	mu       sync.Mutex
+	finished [recentOutcomeLimit]time.Time
+	failed   [recentOutcomeLimit]bool
+	next     int
+	n        int
+	now      func() time.Time
+}
+
+func newRecentOutcomes() *recentOutcomes {
+	return &recentOutcomes{now: time.Now}
+}
+
+// record adds a finished task
+func (o *recentOutcomes) record(failed bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.finished[o.next] = o.now()
+	o.failed[o.next] = failed
+	o.next = (o.next + 1) % recentOutcomeLimit
+	if o.n < recentOutcomeLimit {
+		o.n++
+	}
+}
+
+// rate returns the share of the tasks finished within window that failed,
+// and how many finished
+func (o *recentOutcomes) rate(window time.Duration) (float64, int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	since := o.now().Add(-window)
+	finished, failed := 0, 0
+	for i := 0; i < o.n; i++ {
+		if o.finished[i].Before(since) {
+			continue
+		}
+		finished++
+		if o.failed[i] {
+			failed++
+		}
+	}
+	if finished == 0 {
+		return 0, 0
+	}
+	return float64(failed) / float64(finished), finished
+}
+
+// RecentFailureRate returns the share of recently finished tasks that
+// failed, timed out or were quarantined, and how many tasks it covers. Only
+// the latest tasks finished on this replica within window count; a window of
+// 0 uses the default of 5 minutes.
+func (r *Router) RecentFailureRate(window time.Duration) (float64, int) {
+	if window <= 0 {
+		window = defaultFailureRateWindow
+	}
+	return r.recent.rate(window)
+}
@@ -0,0 +1,85 @@
+package task
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+func TestRecentOutcomesWindow(t *testing.T) {
+	o := newRecentOutcomes()
+	now := time.Now()
+	o.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		o.record(true)
+	}
+
+	// Old failures age out of the window
+	now = now.Add(10 * time.Minute)
+	o.record(false)
+	o.record(true)
+	if rate, n := o.rate(5 * time.Minute); rate != 0.5 || n != 2 {
+		t.Errorf("rate = %v over %d tasks, want 0.5 over 2", rate, n)
+	}
+	if rate, n := o.rate(time.Hour); rate != 0.8 || n != 5 {
+		t.Errorf("rate over an hour = %v over %d tasks, want 0.8 over 5", rate, n)
+	}
+}
+
+func TestRecentOutcomesKeepsLatest(t *testing.T) {
+	o := newRecentOutcomes()
+	for i := 0; i < recentOutcomeLimit; i++ {
+		o.record(true)
+	}
+	for i := 0; i < recentOutcomeLimit/2; i++ {
+		o.record(false)
+	}
+	if rate, n := o.rate(time.Hour); rate != 0.5 || n != recentOutcomeLimit {
+		t.Errorf("rate = %v over %d tasks, want 0.5 over %d", rate, n, recentOutcomeLimit)
+	}
+}
+
+func TestRecentFailureRateFollowsOutcomes(t *testing.T) {
+	agent := newAgentServer(t, func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "bad input", http.StatusBadRequest)
+	})
+	r, reg := newTestRouter(t)
+	registerAgent(t, reg, "cost-1", registry.AgentTypeCost, agent.URL, string(TaskTypeAnalyzeCost))
+
+	if rate, n := r.RecentFailureRate(0); rate != 0 || n != 0 {
+		t.Fatalf("rate before any task = %v over %d", rate, n)
+	}
+	id := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost"}).TaskID
+	waitForStatus(t, r, id, TaskStatusFailed)
+	if rate, n := r.RecentFailureRate(0); rate != 1 || n != 1 {
+		t.Errorf("rate = %v over %d tasks, want 1 over 1", rate, n)
+	}
+}
+
+func TestQueueDepthSkipsCancelledTasks(t *testing.T) {
+	// Not started, so nothing dispatches the queued tasks
+	reg := registry.NewRegistryWithStore(registry.NewMemoryAgentStore())
+	r := NewRouterWithConfig(NewMemoryTaskStore(), reg, DefaultConfig())
+	registerAgent(t, reg, "cost-1", registry.AgentTypeCost, "", string(TaskTypeAnalyzeCost))
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		ids = append(ids, submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost"}).TaskID)
+	}
+	if depth, _ := r.QueueDepth(); depth != 3 {
+		t.Fatalf("queue depth = %d, want 3", depth)
+	}
+
+	if err := r.CancelTask(ids[0]); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if depth, _ := r.QueueDepth(); depth != 2 {
+		t.Errorf("queue depth after a cancel = %d, want 2", depth)
+	}
+	if _, n := r.RecentFailureRate(0); n != 0 {
+		t.Errorf("cancelled task counted among %d finished tasks", n)
+	}
+}
@@ -1,9 +1,16 @@
 package task
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"optiinfra/services/orchestrator/internal/handlers"
+	"optiinfra/services/orchestrator/internal/registry"
 )
 
 // Handler provides HTTP handlers for task routing
@@ -23,21 +30,64 @@ func (h *Handler) RegisterRoutes(r *gin.Engine) {
 	tasks := r.Group("/tasks")
 	{
 		tasks.POST("", h.SubmitTask)
+		tasks.POST("/broadcast", h.BroadcastTask)
+		tasks.POST("/validate", h.ValidateTask)
+		tasks.POST("/status", h.GetTaskStatuses)
+		tasks.GET("/stats", h.Stats)
+		tasks.GET("/routing-table", h.RoutingTable)
+		tasks.GET("/dead-letter", h.ListDeadLetters)
 		tasks.GET("/:id", h.GetTaskStatus)
+		tasks.GET("/:id/events/history", h.GetTaskEvents)
+		tasks.GET("/:id/stream", h.StreamTask)
 		tasks.GET("", h.ListTasks)
 		tasks.DELETE("/:id", h.CancelTask)
 	}
+	r.GET("/agents/:id/tasks", h.AgentTasks)
 }
 
 // SubmitTask handles task submission
 func (h *Handler) SubmitTask(c *gin.Context) {
 	var req TaskSubmitRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := handlers.BindJSON(c, &req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	resp, err := h.router.SubmitTask(&req)
+	resp, err := h.router.SubmitTask(c.Request.Context(), &req)
+	if errors.Is(err, ErrAdmissionDenied) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, ErrExecutionPaused) || errors.Is(err, ErrQueueFull) || errors.Is(err, ErrAdmissionUnavailable) ||
+		errors.Is(err, ErrAgentSelectionTimeout) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// BroadcastTask submits a task to every matching healthy agent
+func (h *Handler) BroadcastTask(c *gin.Context) {
+	var req TaskSubmitRequest
+	if err := handlers.BindJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.router.BroadcastTask(c.Request.Context(), &req)
+	if errors.Is(err, ErrAdmissionDenied) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, ErrExecutionPaused) || errors.Is(err, ErrQueueFull) || errors.Is(err, ErrAdmissionUnavailable) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -46,6 +96,39 @@ func (h *Handler) SubmitTask(c *gin.Context) {
 	c.JSON(http.StatusCreated, resp)
 }
 
+// ValidateTask dry-runs a task submission. A request that parses always gets
+// 200, with valid=false and the reason when it would be rejected.
+func (h *Handler) ValidateTask(c *gin.Context) {
+	var req TaskSubmitRequest
+	if err := handlers.BindJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.router.ValidateTask(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusOK, TaskValidationResponse{Valid: false, Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// AgentTasks lists the unfinished tasks assigned to an agent
+func (h *Handler) AgentTasks(c *gin.Context) {
+	resp, err := h.router.AgentTasks(c.Param("id"))
+	if errors.Is(err, registry.ErrAgentNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
 // GetTaskStatus retrieves task status
 func (h *Handler) GetTaskStatus(c *gin.Context) {
 	taskID := c.Param("id")
@@ -59,9 +142,60 @@ func (h *Handler) GetTaskStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, status)
 }
 
-// ListTasks lists all tasks
+// GetTaskStatuses returns the status of several tasks in one call
+func (h *Handler) GetTaskStatuses(c *gin.Context) {
+	var req BulkStatusRequest
+	if err := handlers.BindJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.router.GetTaskStatuses(req.TaskIDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetTaskEvents returns a task's lifecycle event log, oldest first
+func (h *Handler) GetTaskEvents(c *gin.Context) {
+	events, err := h.router.TaskEvents(c.Param("id"))
+	if errors.Is(err, ErrTaskNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+// ListTasks lists all tasks. Passing limit or offset pages through the
+// Redis creation-time index (newest first) instead of this replica's memory;
+// passing since/until (RFC 3339) searches that index by creation time.
 func (h *Handler) ListTasks(c *gin.Context) {
-	statusFilter := TaskStatus(c.Query("status"))
+	var statusFilter TaskStatus
+	if name := c.Query("status"); name != "" {
+		status, err := ParseTaskStatus(name)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		statusFilter = status
+	}
+
+	if c.Query("since") != "" || c.Query("until") != "" {
+		h.listTasksBetween(c, statusFilter)
+		return
+	}
+	if c.Query("limit") != "" || c.Query("offset") != "" {
+		h.listTasksPage(c, statusFilter)
+		return
+	}
 
 	tasks, err := h.router.ListTasks(statusFilter)
 	if err != nil {
@@ -69,12 +203,191 @@ func (h *Handler) ListTasks(c *gin.Context) {
 		return
 	}
 
+	resp := TaskListResponse{
+		Tasks: convertToTaskSlice(tasks),
+		Count: len(tasks),
+	}
+	if stats, err := h.router.Stats(); err == nil {
+		resp.Total = statsTotal(stats, statusFilter)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Stats returns per-status task counts across all replicas
+func (h *Handler) Stats(c *gin.Context) {
+	stats, err := h.router.Stats()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// RoutingTable returns the agents currently eligible for each task type
+func (h *Handler) RoutingTable(c *gin.Context) {
+	table, err := h.router.RoutingTable()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, table)
+}
+
+// ListDeadLetters lists quarantined tasks, newest first
+func (h *Handler) ListDeadLetters(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return
+	}
+
+	tasks, err := h.router.ListDeadLetters(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusOK, TaskListResponse{
 		Tasks: convertToTaskSlice(tasks),
 		Count: len(tasks),
 	})
 }
 
+// statsTotal is the global task count, for one status if filtered
+func statsTotal(stats *TaskStats, status TaskStatus) int64 {
+	if status == "" {
+		return stats.Total
+	}
+	return stats.Counts[status]
+}
+
+func (h *Handler) listTasksPage(c *gin.Context, statusFilter TaskStatus) {
+	offset, limit, ok := pageParams(c)
+	if !ok {
+		return
+	}
+
+	page, err := h.router.ListTasksPage(statusFilter, offset, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := pageResponse(page)
+	if statusFilter != "" {
+		if stats, err := h.router.Stats(); err == nil {
+			resp.Total = statsTotal(stats, statusFilter)
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// listTasksBetween searches tasks created in [since, until). until defaults
+// to now; Total counts the range before status filtering.
+func (h *Handler) listTasksBetween(c *gin.Context, statusFilter TaskStatus) {
+	if c.Query("since") == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "since is required with until"})
+		return
+	}
+	since, err := time.Parse(time.RFC3339Nano, c.Query("since"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "since must be an RFC 3339 timestamp"})
+		return
+	}
+	until := time.Now()
+	if c.Query("until") != "" {
+		if until, err = time.Parse(time.RFC3339Nano, c.Query("until")); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "until must be an RFC 3339 timestamp"})
+			return
+		}
+	}
+	offset, limit, ok := pageParams(c)
+	if !ok {
+		return
+	}
+
+	page, err := h.router.ListTasksBetween(since, until, statusFilter, offset, limit)
+	if errors.Is(err, ErrInvalidTimeRange) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, pageResponse(page))
+}
+
+// pageParams parses offset and limit, writing a 400 if either is invalid
+func pageParams(c *gin.Context) (offset, limit int, ok bool) {
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "offset must be a non-negative integer"})
+		return 0, 0, false
+	}
+	limit, err = strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return 0, 0, false
+	}
+	return offset, limit, true
+}
+
+func pageResponse(page *TaskPage) TaskListResponse {
+	resp := TaskListResponse{
+		Tasks: convertToTaskSlice(page.Tasks),
+		Count: len(page.Tasks),
+		Total: page.Total,
+	}
+	if page.NextOffset >= 0 {
+		resp.NextOffset = &page.NextOffset
+	}
+	return resp
+}
+
+// StreamTask streams a task's partial results as server-sent "partial"
+// events, then its final status as an "end" event
+func (h *Handler) StreamTask(c *gin.Context) {
+	taskID := c.Param("id")
+
+	history, events, cancel, err := h.router.SubscribeStream(taskID)
+	switch {
+	case errors.Is(err, ErrTaskNotStreamable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+	defer cancel()
+
+	c.Header("Cache-Control", "no-cache")
+	for _, event := range history {
+		c.SSEvent("partial", event)
+	}
+	// Deliver the history now rather than with the next live chunk
+	c.Writer.Flush()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				if status, err := h.router.GetTaskStatus(taskID); err == nil {
+					c.SSEvent("end", status)
+				}
+				return false
+			}
+			c.SSEvent("partial", event)
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
 // CancelTask cancels a task
 func (h *Handler) CancelTask(c *gin.Context) {
 	taskID := c.Param("id")
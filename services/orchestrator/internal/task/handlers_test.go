@@ -0,0 +1,172 @@
+package task
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+// doJSON serves a request for path through the task routes of r, sending
+// body as JSON unless it is nil, and decodes a 200 response into out
+func doJSON(t *testing.T, r *Router, method, path string, body, out interface{}) int {
+	t.Helper()
+	engine := gin.New()
+	NewHandler(r).RegisterRoutes(engine)
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	if rec.Code == http.StatusOK && out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("decode %s: %v", rec.Body.String(), err)
+		}
+	}
+	return rec.Code
+}
+
+func getJSON(t *testing.T, r *Router, path string, out interface{}) int {
+	t.Helper()
+	return doJSON(t, r, http.MethodGet, path, nil, out)
+}
+
+func rangeQuery(since, until time.Time) string {
+	q := url.Values{}
+	q.Set("since", since.Format(time.RFC3339Nano))
+	if !until.IsZero() {
+		q.Set("until", until.Format(time.RFC3339Nano))
+	}
+	return "/tasks?" + q.Encode()
+}
+
+func listIDs(resp TaskListResponse) string {
+	ids := make([]string, len(resp.Tasks))
+	for i, task := range resp.Tasks {
+		ids[i] = task.ID
+	}
+	return fmt.Sprint(ids)
+}
+
+func TestListTasksByTimeRangeBoundaries(t *testing.T) {
+	forEachTaskStore(t, func(t *testing.T, store TaskStore) {
+		r, _ := newStoreRouter(t, store)
+		tasks := saveTasks(t, store, 5, TaskStatusCompleted, TaskStatusFailed)
+		at := func(i int) time.Time { return tasks[i].CreatedAt }
+
+		tests := []struct {
+			name         string
+			since, until time.Time
+			want         string
+		}{
+			{"since inclusive, until exclusive", at(1), at(3), "[task-2 task-1]"},
+			{"just after since", at(1).Add(time.Millisecond), at(3), "[task-2]"},
+			{"just after until", at(1), at(3).Add(time.Millisecond), "[task-3 task-2 task-1]"},
+			{"single instant", at(2), at(2).Add(time.Millisecond), "[task-2]"},
+			{"before every task", at(0).Add(-time.Hour), at(0), "[]"},
+			{"until defaults to now", at(3), time.Time{}, "[task-4 task-3]"},
+		}
+		for _, tt := range tests {
+			var resp TaskListResponse
+			if code := getJSON(t, r, rangeQuery(tt.since, tt.until), &resp); code != http.StatusOK {
+				t.Errorf("%s: status %d", tt.name, code)
+				continue
+			}
+			if got := listIDs(resp); got != tt.want {
+				t.Errorf("%s: tasks = %s, want %s", tt.name, got, tt.want)
+			}
+		}
+
+		// Pages through the range, filtering by status within it
+		var resp TaskListResponse
+		getJSON(t, r, rangeQuery(at(0), at(4))+"&limit=2", &resp)
+		if got := listIDs(resp); got != "[task-3 task-2]" || resp.Total != 4 || resp.NextOffset == nil || *resp.NextOffset != 2 {
+			t.Errorf("first page = %s, total %d, next %v", got, resp.Total, resp.NextOffset)
+		}
+		getJSON(t, r, rangeQuery(at(0), at(4))+"&status=failed", &resp)
+		if got := listIDs(resp); got != "[task-3 task-1]" {
+			t.Errorf("failed tasks in range = %s", got)
+		}
+	})
+}
+
+func TestListTasksByTimeRangeValidation(t *testing.T) {
+	r, _ := newTestRouter(t)
+	now := time.Now()
+
+	tests := map[string]string{
+		"until without since": "/tasks?until=" + url.QueryEscape(now.Format(time.RFC3339)),
+		"unparseable since":   "/tasks?since=yesterday",
+		"unparseable until":   "/tasks?since=" + url.QueryEscape(now.Format(time.RFC3339)) + "&until=tomorrow",
+		"empty range":         rangeQuery(now, now),
+		"reversed range":      rangeQuery(now, now.Add(-time.Hour)),
+		"span over the cap":   rangeQuery(now.Add(-maxTaskSearchSpan-time.Second), now),
+	}
+	for name, path := range tests {
+		if code := getJSON(t, r, path, nil); code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", name, code)
+		}
+	}
+
+	if code := getJSON(t, r, rangeQuery(now.Add(-maxTaskSearchSpan), now), nil); code != http.StatusOK {
+		t.Errorf("span at the cap: status %d, want 200", code)
+	}
+}
+
+func TestValidateTaskDryRun(t *testing.T) {
+	r, reg := newTestRouter(t)
+	id := registerAgent(t, reg, "cost-1", registry.AgentTypeCost, "", "analyze_cost")
+
+	validate := func(req *TaskSubmitRequest) TaskValidationResponse {
+		t.Helper()
+		var resp TaskValidationResponse
+		if code := doJSON(t, r, http.MethodPost, "/tasks/validate", req, &resp); code != http.StatusOK {
+			t.Fatalf("validate %+v: status %d", req, code)
+		}
+		return resp
+	}
+
+	resp := validate(&TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost"})
+	if !resp.Valid || resp.AgentID != id || resp.AgentName != "cost-1" || resp.Error != "" {
+		t.Errorf("valid request = %+v, want agent %s chosen", resp, id)
+	}
+
+	resp = validate(&TaskSubmitRequest{TaskType: "defragment_disks", AgentType: "cost"})
+	if resp.Valid || resp.AgentID != "" || !strings.Contains(resp.Error, "unknown task type: defragment_disks") {
+		t.Errorf("unknown task type = %+v", resp)
+	}
+
+	// The only agent of the type is unhealthy
+	perf := registerAgent(t, reg, "perf-1", registry.AgentTypePerformance, "", "optimize_kv_cache")
+	if _, err := reg.Heartbeat(perf, &registry.HeartbeatRequest{Status: registry.AgentStatusUnhealthy}); err != nil {
+		t.Fatal(err)
+	}
+	resp = validate(&TaskSubmitRequest{TaskType: TaskTypeOptimizeKVCache, AgentType: "performance"})
+	if resp.Valid || !strings.Contains(resp.Error, "no available agent") {
+		t.Errorf("no healthy agent = %+v", resp)
+	}
+
+	resp = validate(&TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost", Timeout: -1})
+	if resp.Valid || !strings.Contains(resp.Error, "invalid task request") {
+		t.Errorf("negative timeout = %+v", resp)
+	}
+
+	// Validation never creates a task
+	if s := stats(t, r); s.Total != 0 {
+		t.Errorf("%d tasks created by dry runs", s.Total)
+	}
+}
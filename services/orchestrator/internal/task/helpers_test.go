@@ -0,0 +1,135 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestRouter returns a started router and its registry, both in memory,
+// with a short retry delay
+func newTestRouter(t *testing.T) (*Router, *registry.Registry) {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.RetryDelay = 10 * time.Millisecond
+	return newTestRouterWithConfig(t, cfg)
+}
+
+// newTestRouterWithConfig is newTestRouter with the given settings. The
+// router is stopped when the test ends.
+func newTestRouterWithConfig(t *testing.T, cfg Config) (*Router, *registry.Registry) {
+	t.Helper()
+	reg := registry.NewRegistryWithStore(registry.NewMemoryAgentStore())
+	r := NewRouterWithConfig(NewMemoryTaskStore(), reg, cfg)
+	r.Start()
+	t.Cleanup(r.Stop)
+	return r, reg
+}
+
+// registerAgent registers a healthy agent served at url and returns its ID
+func registerAgent(t *testing.T, reg *registry.Registry, name string, agentType registry.AgentType, url string, capabilities ...string) string {
+	t.Helper()
+	host, port := "127.0.0.1", 1
+	if url != "" {
+		host, port = hostPort(t, url)
+	}
+	resp, err := reg.Register(&registry.RegistrationRequest{
+		Name:         name,
+		Type:         agentType,
+		Host:         host,
+		Port:         port,
+		Capabilities: capabilities,
+	})
+	if err != nil {
+		t.Fatalf("register %s: %v", name, err)
+	}
+	return resp.AgentID
+}
+
+// hostPort splits an httptest server URL into host and port
+func hostPort(t testing.TB, url string) (string, int) {
+	t.Helper()
+	host, portStr, err := net.SplitHostPort(url[len("http://"):])
+	if err != nil {
+		t.Fatalf("parse %s: %v", url, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		t.Fatalf("parse %s: %v", url, err)
+	}
+	return host, port
+}
+
+// newAgentServer serves an agent's /task endpoint with handle
+func newAgentServer(t *testing.T, handle http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/task", handle)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// completingAgent answers every task with a completed response carrying
+// result
+func completingAgent(result map[string]interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		var taskReq TaskRequest
+		json.NewDecoder(req.Body).Decode(&taskReq)
+		json.NewEncoder(w).Encode(TaskResponse{
+			TaskID:        taskReq.TaskID,
+			Status:        TaskStatusCompleted,
+			Result:        result,
+			ExecutionTime: 5,
+		})
+	}
+}
+
+// submit submits a task, failing the test on error
+func submit(t *testing.T, r *Router, req *TaskSubmitRequest) *TaskSubmitResponse {
+	t.Helper()
+	resp, err := r.SubmitTask(context.Background(), req)
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	return resp
+}
+
+// waitForTask polls a task until cond holds, failing the test after a few
+// seconds
+func waitForTask(t *testing.T, r *Router, taskID string, cond func(*TaskStatusResponse) bool) *TaskStatusResponse {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status, err := r.GetTaskStatus(taskID)
+		if err == nil && cond(status) {
+			return status
+		}
+		if time.Now().After(deadline) {
+			if err != nil {
+				t.Fatalf("task %s: %v", taskID, err)
+			}
+			t.Fatalf("task %s did not reach the expected state, last status %s (%s)", taskID, status.Status, status.Error)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// waitForStatus polls a task until it reaches status
+func waitForStatus(t *testing.T, r *Router, taskID string, status TaskStatus) *TaskStatusResponse {
+	t.Helper()
+	return waitForTask(t, r, taskID, func(s *TaskStatusResponse) bool { return s.Status == status })
+}
@@ -0,0 +1,31 @@
+package task
+
+import (
+	"net/http"
+	"testing"
+
+	"optiinfra/services/orchestrator/internal/idgen"
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+func TestPredictableTaskIDs(t *testing.T) {
+	r, reg := newTestRouter(t)
+	r.SetIDGenerator(idgen.NewSequentialGenerator("task"))
+	reg.SetIDGenerator(idgen.NewSequentialGenerator("agent"))
+	agent := newAgentServer(t, completingAgent(map[string]interface{}{"ok": true}))
+	if id := registerAgent(t, reg, "cost-1", registry.AgentTypeCost, agent.URL, "analyze_cost"); id != "agent-1" {
+		t.Fatalf("agent id = %s, want agent-1", id)
+	}
+
+	for _, want := range []string{"task-1", "task-2"} {
+		if resp := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost"}); resp.TaskID != want || resp.AgentID != "agent-1" {
+			t.Errorf("submitted %s to %s, want %s to agent-1", resp.TaskID, resp.AgentID, want)
+		}
+	}
+	waitForStatus(t, r, "task-2", TaskStatusCompleted)
+
+	var status TaskStatusResponse
+	if code := getJSON(t, r, "/tasks/task-1", &status); code != http.StatusOK || status.TaskID != "task-1" {
+		t.Errorf("GET /tasks/task-1: status %d, task %s", code, status.TaskID)
+	}
+}
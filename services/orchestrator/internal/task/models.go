@@ -1,6 +1,9 @@
 package task
 
 import (
+	"context"
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -24,6 +27,11 @@ const (
 	// Application Agent Tasks
 	TaskTypeValidateQuality  TaskType = "validate_quality"
 	TaskTypeDetectRegression TaskType = "detect_regression"
+
+	// Coordination Plan Steps
+	TaskTypeTakeSnapshot    TaskType = "take_snapshot"
+	TaskTypeMigrateWorkload TaskType = "migrate_workload"
+	TaskTypeScaleResources  TaskType = "scale_resources"
 )
 
 // TaskStatus represents the current status of a task
@@ -38,8 +46,34 @@ const (
 	TaskStatusFailed    TaskStatus = "failed"
 	TaskStatusTimeout   TaskStatus = "timeout"
 	TaskStatusRetrying  TaskStatus = "retrying"
+
+	// Failed on too many distinct agents; held in the dead-letter store
+	TaskStatusQuarantined TaskStatus = "quarantined"
 )
 
+// TaskStatuses lists every task status
+var TaskStatuses = []TaskStatus{
+	TaskStatusPending, TaskStatusQueued, TaskStatusSent, TaskStatusRunning, TaskStatusCompleted,
+	TaskStatusFailed, TaskStatusTimeout, TaskStatusRetrying, TaskStatusQuarantined,
+}
+
+// ParseTaskStatus validates a task status name
+func ParseTaskStatus(name string) (TaskStatus, error) {
+	valid := make([]string, len(TaskStatuses))
+	for i, status := range TaskStatuses {
+		if string(status) == name {
+			return status, nil
+		}
+		valid[i] = string(status)
+	}
+	return "", fmt.Errorf("unknown task status %q (valid: %s)", name, strings.Join(valid, ", "))
+}
+
+// IsTerminal reports whether a task in this status is finished
+func (s TaskStatus) IsTerminal() bool {
+	return isTerminal(s)
+}
+
 // TaskPriority represents task priority levels
 type TaskPriority int
 
@@ -61,6 +95,7 @@ type Task struct {
 	Status      TaskStatus             `json:"status"`
 	Result      map[string]interface{} `json:"result,omitempty"`
 	Error       string                 `json:"error,omitempty"`
+	ErrorCode   string                 `json:"error_code,omitempty"` // Agent-reported code of the final error
 	CreatedAt   time.Time              `json:"created_at"`
 	StartedAt   *time.Time             `json:"started_at,omitempty"`
 	CompletedAt *time.Time             `json:"completed_at,omitempty"`
@@ -68,6 +103,59 @@ type Task struct {
 	RetryCount  int                    `json:"retry_count"`
 	MaxRetries  int                    `json:"max_retries"`
 	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+
+	// Version constraint on the agent's capability for the task type
+	CapabilityVersion string `json:"capability_version,omitempty"`
+
+	// Chaining: remaining steps to submit on success, and the links
+	Chain         []ChainStep `json:"chain_on_success,omitempty"`
+	ParentTaskID  string      `json:"parent_task_id,omitempty"`
+	ChainedTaskID string      `json:"chained_task_id,omitempty"`
+
+	// Latest progress reported via agent heartbeats
+	Progress *TaskProgress `json:"progress,omitempty"`
+
+	// Broadcast: the per-agent child tasks this parent aggregates
+	ChildTaskIDs []string `json:"child_task_ids,omitempty"`
+
+	// Distinct agents an attempt failed on or that were lost holding the task
+	FailedAgents []string `json:"failed_agents,omitempty"`
+
+	// Related tasks routed to spread across agents
+	SpreadGroup string `json:"spread_group,omitempty"`
+
+	// Execution context: carries the submitter's values but not its
+	// cancellation, and is cancelled when the task is cancelled or finishes
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// When the watchdog last moved the task to another agent; its deadline
+	// then runs from here instead of CreatedAt
+	watchdogRetriedAt time.Time
+}
+
+// executionContext returns the task's execution context, or fallback for
+// tasks that were not submitted on this replica
+func (t *Task) executionContext(fallback context.Context) context.Context {
+	if t.ctx == nil {
+		return fallback
+	}
+	return t.ctx
+}
+
+// restartExecution replaces a cancelled execution context with a fresh one
+// carrying the same values
+func (t *Task) restartExecution(fallback context.Context) {
+	t.endExecution()
+	t.ctx, t.cancel = context.WithCancel(context.WithoutCancel(t.executionContext(fallback)))
+}
+
+// endExecution cancels the task's execution context, aborting any attempt
+// still in flight
+func (t *Task) endExecution() {
+	if t.cancel != nil {
+		t.cancel()
+	}
 }
 
 // TaskRequest is sent to an agent to execute a task
@@ -88,6 +176,10 @@ type TaskResponse struct {
 	Error         string                 `json:"error,omitempty"`
 	ExecutionTime int                    `json:"execution_time_ms"`
 	Metadata      map[string]interface{} `json:"metadata,omitempty"`
+
+	// Optional failure classification; Retryable false stops further retries
+	ErrorCode string `json:"error_code,omitempty"`
+	Retryable *bool  `json:"retryable,omitempty"`
 }
 
 // TaskSubmitRequest is used to submit a new task
@@ -100,6 +192,21 @@ type TaskSubmitRequest struct {
 	Timeout    int                    `json:"timeout_seconds"`
 	MaxRetries int                    `json:"max_retries"`
 	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+
+	// CapabilityVersion restricts routing to agents advertising the task
+	// type at a version it allows, such as ">=2" or ">=2,<3"
+	CapabilityVersion string `json:"capability_version,omitempty"`
+
+	// ChainOnSuccess submits these tasks in order, each fed by the previous result
+	ChainOnSuccess []ChainStep `json:"chain_on_success,omitempty"`
+
+	// SpreadGroup spreads related submissions sharing the ID across agents,
+	// reusing an agent only once every other candidate has one of the group
+	SpreadGroup string `json:"spread_group,omitempty"`
+
+	// InheritedPriority is the priority a chained task is raised to once
+	// defaults apply; set by the router from its prerequisite's priority
+	InheritedPriority TaskPriority `json:"-"`
 }
 
 // TaskSubmitResponse returns task details after submission
@@ -111,6 +218,15 @@ type TaskSubmitResponse struct {
 	StatusURL string     `json:"status_url"`
 }
 
+// TaskValidationResponse reports whether a submit request would be accepted
+// and which agent it would be sent to
+type TaskValidationResponse struct {
+	Valid     bool   `json:"valid"`
+	AgentID   string `json:"agent_id,omitempty"`
+	AgentName string `json:"agent_name,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
 // TaskStatusResponse returns current task status
 type TaskStatusResponse struct {
 	TaskID      string                 `json:"task_id"`
@@ -118,14 +234,34 @@ type TaskStatusResponse struct {
 	AgentID     string                 `json:"agent_id"`
 	Result      map[string]interface{} `json:"result,omitempty"`
 	Error       string                 `json:"error,omitempty"`
+	ErrorCode   string                 `json:"error_code,omitempty"`
 	CreatedAt   time.Time              `json:"created_at"`
 	StartedAt   *time.Time             `json:"started_at,omitempty"`
 	CompletedAt *time.Time             `json:"completed_at,omitempty"`
 	RetryCount  int                    `json:"retry_count"`
+	Progress    *TaskProgress          `json:"progress,omitempty"`
+
+	ParentTaskID  string   `json:"parent_task_id,omitempty"`
+	ChainedTaskID string   `json:"chained_task_id,omitempty"`
+	ChildTaskIDs  []string `json:"child_task_ids,omitempty"`
+}
+
+// BroadcastResponse returns the parent task of a broadcast and one child
+// task per agent it was sent to
+type BroadcastResponse struct {
+	TaskID    string               `json:"task_id"`
+	Status    TaskStatus           `json:"status"`
+	CreatedAt time.Time            `json:"created_at"`
+	StatusURL string               `json:"status_url"`
+	Children  []TaskSubmitResponse `json:"children"`
 }
 
 // TaskListResponse returns a list of tasks
 type TaskListResponse struct {
 	Tasks []Task `json:"tasks"`
 	Count int    `json:"count"`
+
+	// Set for paged listings
+	Total      int64 `json:"total,omitempty"`
+	NextOffset *int  `json:"next_offset,omitempty"`
 }
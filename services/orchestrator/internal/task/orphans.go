@@ -0,0 +1,116 @@
+package task
+
+import (
+	"fmt"
+	"log"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+// OrphanPolicy decides what happens to unfinished tasks whose agent unregisters
+type OrphanPolicy string
+
+const (
+	// OrphanCancel fails the tasks with a reason naming the agent
+	OrphanCancel OrphanPolicy = "cancel"
+	// OrphanReassign moves the tasks to another healthy agent with the
+	// required capability, cancelling those no agent can take
+	OrphanReassign OrphanPolicy = "reassign"
+)
+
+// ParseOrphanPolicy validates an orphan policy name
+func ParseOrphanPolicy(name string) (OrphanPolicy, error) {
+	switch policy := OrphanPolicy(name); policy {
+	case OrphanCancel, OrphanReassign:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown orphaned task policy %q", name)
+	}
+}
+
+// SetOrphanPolicy sets how tasks of unregistered agents are handled
+func (r *Router) SetOrphanPolicy(policy OrphanPolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.orphanPolicy = policy
+}
+
+// handleAgentUnregistered cancels or reassigns the agent's unfinished tasks.
+// Cancelling aborts an attempt in flight; reassigned tasks keep theirs and
+// move from their next retry. Broadcast children are always cancelled.
+func (r *Router) handleAgentUnregistered(agentID string) {
+	// Look up replacement agents before taking the lock
+	agentTypes := make(map[string]bool)
+	r.mu.RLock()
+	if r.orphanPolicy == OrphanReassign {
+		for _, task := range r.tasks {
+			if task.AgentID == agentID && !isTerminal(task.Status) {
+				agentTypes[task.AgentType] = true
+			}
+		}
+	}
+	r.mu.RUnlock()
+	listings := r.lookupAgentListings(r.ctx, agentTypes)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, task := range r.tasks {
+		if task.AgentID != agentID || isTerminal(task.Status) {
+			continue
+		}
+
+		// An agent lost while holding the task counts as a failure on it
+		if task.Status != TaskStatusQueued && r.recordAgentFailure(task, agentID) {
+			r.quarantineTaskLocked(task)
+			continue
+		}
+
+		reason := fmt.Sprintf("agent %s unregistered", agentID)
+		// A broadcast child is meant for its own agent, so it is never reassigned
+		if r.orphanPolicy == OrphanReassign && !r.isBroadcastChild(task) {
+			agent, err := r.pickAgentFor(listings, task)
+			if err == nil {
+				r.reassignTaskLocked(task, agent)
+				continue
+			}
+			reason = fmt.Sprintf("%s and no replacement agent available: %v", reason, err)
+		}
+
+		if err := r.cancelTaskLocked(task, reason); err != nil {
+			log.Printf("Failed to cancel orphaned task %s: %v", task.ID, err)
+		}
+	}
+}
+
+// reassignTaskLocked points a task at a new agent, re-queueing it if it was
+// waiting for dispatch. It must be called with r.mu held.
+func (r *Router) reassignTaskLocked(task *Task, agent *registry.Agent) {
+	previous := task.AgentID
+	task.AgentID = agent.ID
+	if task.Metadata == nil {
+		task.Metadata = make(map[string]interface{})
+	}
+	task.Metadata["reassigned_from"] = previous
+
+	if err := r.storeTask(task); err != nil {
+		log.Printf("Failed to store reassigned task %s: %v", task.ID, err)
+	}
+	r.recordEvent(task, TaskEventRouted, fmt.Sprintf("reassigned from agent %s", previous))
+
+	// The stale queue entry is skipped at dispatch since its agent no longer matches
+	if task.Status == TaskStatusQueued {
+		r.queue.Push(task, agent)
+	}
+
+	log.Printf("Task %s reassigned from agent %s to %s (%s)", task.ID, previous, agent.Name, agent.ID)
+}
+
+func isTerminal(status TaskStatus) bool {
+	switch status {
+	case TaskStatusCompleted, TaskStatusFailed, TaskStatusTimeout, TaskStatusQuarantined:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,101 @@
+package task
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+// queueForAgent returns a router that is not yet started, so the task it
+// queues for the first of two cost agents waits for dispatch. It returns
+// the router, its registry, the task and the two agent IDs.
+func queueForAgent(t *testing.T, policy OrphanPolicy) (*Router, *registry.Registry, string, string, string) {
+	t.Helper()
+	agent := newAgentServer(t, completingAgent(map[string]interface{}{"ok": true}))
+	reg := registry.NewRegistryWithStore(registry.NewMemoryAgentStore())
+	cfg := DefaultConfig()
+	cfg.RetryDelay = 10 * time.Millisecond
+	r := NewRouterWithConfig(NewMemoryTaskStore(), reg, cfg)
+	r.SetOrphanPolicy(policy)
+
+	first := registerAgent(t, reg, "cost-1", registry.AgentTypeCost, agent.URL, "analyze_cost")
+	second := registerAgent(t, reg, "cost-2", registry.AgentTypeCost, agent.URL, "analyze_cost")
+	taskID := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost", AgentID: first}).TaskID
+	if status, _ := r.GetTaskStatus(taskID); status.AgentID != first || status.Status != TaskStatusQueued {
+		t.Fatalf("task %s for agent %s, want queued for %s", status.Status, status.AgentID, first)
+	}
+	return r, reg, taskID, first, second
+}
+
+func startRouter(t *testing.T, r *Router) {
+	t.Helper()
+	r.Start()
+	t.Cleanup(r.Stop)
+}
+
+func TestOrphanedTaskReassigned(t *testing.T) {
+	r, reg, taskID, first, second := queueForAgent(t, OrphanReassign)
+	if err := reg.Unregister(first); err != nil {
+		t.Fatal(err)
+	}
+
+	task, err := r.getTask(taskID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.AgentID != second || task.Metadata["reassigned_from"] != first {
+		t.Errorf("task on agent %s (metadata %v), want moved from %s to %s", task.AgentID, task.Metadata, first, second)
+	}
+
+	startRouter(t, r)
+	if status := waitForStatus(t, r, taskID, TaskStatusCompleted); status.AgentID != second {
+		t.Errorf("completed on %s, want %s", status.AgentID, second)
+	}
+}
+
+func TestOrphanedTaskCancelled(t *testing.T) {
+	r, reg, taskID, first, _ := queueForAgent(t, OrphanCancel)
+	if err := reg.Unregister(first); err != nil {
+		t.Fatal(err)
+	}
+	startRouter(t, r)
+
+	status := waitForStatus(t, r, taskID, TaskStatusFailed)
+	if !strings.Contains(status.Error, "agent "+first+" unregistered") {
+		t.Errorf("error = %q, want the unregistered agent named", status.Error)
+	}
+	if status.AgentID != first {
+		t.Errorf("cancelled task moved to %s", status.AgentID)
+	}
+}
+
+func TestOrphanReassignWithoutReplacementCancels(t *testing.T) {
+	r, reg, taskID, first, second := queueForAgent(t, OrphanReassign)
+	if err := reg.Unregister(second); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.Unregister(first); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := r.GetTaskStatus(taskID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != TaskStatusFailed || !strings.Contains(status.Error, "no replacement agent available") {
+		t.Errorf("task %s (%q), want failed with no replacement", status.Status, status.Error)
+	}
+}
+
+func TestParseOrphanPolicy(t *testing.T) {
+	for _, name := range []string{"cancel", "reassign"} {
+		if policy, err := ParseOrphanPolicy(name); err != nil || string(policy) != name {
+			t.Errorf("parse %s: %v, %v", name, policy, err)
+		}
+	}
+	if _, err := ParseOrphanPolicy("ignore"); err == nil {
+		t.Error("unknown policy accepted")
+	}
+}
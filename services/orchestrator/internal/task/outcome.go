@@ -0,0 +1,64 @@
+package task
+
+import "sync"
+
+// TaskOutcome is the terminal state of a task, delivered to outcome
+// listeners
+type TaskOutcome struct {
+	TaskID   string
+	Type     TaskType
+	AgentID  string // Empty when the task never reached an agent
+	Status   TaskStatus
+	Result   map[string]interface{}
+	Error    string
+	Metadata map[string]interface{}
+}
+
+// OutcomeListener receives the outcome of each task that reaches a terminal
+// status on this replica. It may be called with the router's lock held, so
+// it must not block or call back into the router.
+type OutcomeListener func(TaskOutcome)
+
+// outcomeListeners holds the router's outcome listeners
+type outcomeListeners struct {
+	mu        sync.RWMutex
+	listeners []OutcomeListener
+}
+
+// SubscribeOutcomes registers a listener for terminal task outcomes
+func (r *Router) SubscribeOutcomes(listener OutcomeListener) {
+	r.outcomes.mu.Lock()
+	defer r.outcomes.mu.Unlock()
+	r.outcomes.listeners = append(r.outcomes.listeners, listener)
+}
+
+// notifyOutcome delivers a task's outcome to the listeners if the event ends
+// the task
+func (r *Router) notifyOutcome(task *Task, eventType TaskEventType) {
+	switch eventType {
+	case TaskEventCompleted, TaskEventFailed, TaskEventCancelled, TaskEventTimeout, TaskEventQuarantined:
+	default:
+		return
+	}
+
+	// A cancelled task neither succeeded nor failed
+	if eventType != TaskEventCancelled {
+		r.recent.record(eventType != TaskEventCompleted)
+	}
+
+	outcome := TaskOutcome{
+		TaskID:   task.ID,
+		Type:     task.Type,
+		AgentID:  task.AgentID,
+		Status:   task.Status,
+		Result:   task.Result,
+		Error:    task.Error,
+		Metadata: task.Metadata,
+	}
+
+	r.outcomes.mu.RLock()
+	defer r.outcomes.mu.RUnlock()
+	for _, listener := range r.outcomes.listeners {
+		listener(outcome)
+	}
+}
@@ -0,0 +1,57 @@
+package task
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+func TestOutcomeListenersSeeTerminalTasks(t *testing.T) {
+	r, reg := newTestRouter(t)
+	good := newAgentServer(t, completingAgent(map[string]interface{}{"ok": true}))
+	bad := newAgentServer(t, failingAgent("disk full"))
+	registerAgent(t, reg, "cost-1", registry.AgentTypeCost, good.URL, "analyze_cost")
+	registerAgent(t, reg, "perf-1", registry.AgentTypePerformance, bad.URL, "tune_inference")
+
+	var mu sync.Mutex
+	outcomes := make(map[string]TaskOutcome)
+	r.SubscribeOutcomes(func(outcome TaskOutcome) {
+		mu.Lock()
+		defer mu.Unlock()
+		if _, seen := outcomes[outcome.TaskID]; seen {
+			t.Errorf("task %s delivered twice", outcome.TaskID)
+		}
+		outcomes[outcome.TaskID] = outcome
+	})
+
+	metadata := map[string]interface{}{"plan_step_id": "step-1"}
+	completed := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost", Metadata: metadata}).TaskID
+	failed := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeTuneInference, AgentType: "performance"}).TaskID
+	waitForStatus(t, r, completed, TaskStatusCompleted)
+	waitForStatus(t, r, failed, TaskStatusFailed)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		n := len(outcomes)
+		mu.Unlock()
+		if n == 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got := outcomes[completed]; got.Status != TaskStatusCompleted || got.Result["ok"] != true || got.Metadata["plan_step_id"] != "step-1" || got.Type != TaskTypeAnalyzeCost {
+		t.Errorf("completed outcome = %+v", got)
+	}
+	if got := outcomes[failed]; got.Status != TaskStatusFailed || got.Error == "" || got.AgentID == "" {
+		t.Errorf("failed outcome = %+v", got)
+	}
+	if len(outcomes) != 2 {
+		t.Errorf("%d outcomes delivered, want 2", len(outcomes))
+	}
+}
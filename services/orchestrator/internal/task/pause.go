@@ -0,0 +1,80 @@
+package task
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// ErrExecutionPaused is returned for submissions while execution is paused
+var ErrExecutionPaused = errors.New("task execution is paused")
+
+// pauseState is the global kill switch. While paused, submissions are
+// rejected and dispatch workers hold queued tasks; tasks already sent to an
+// agent run to completion.
+type pauseState struct {
+	mu     sync.Mutex
+	since  *time.Time
+	resume chan struct{}
+}
+
+// PauseExecution rejects new submissions and holds queued tasks until
+// ResumeExecution. It reports whether execution was running before.
+func (r *Router) PauseExecution() bool {
+	r.pause.mu.Lock()
+	defer r.pause.mu.Unlock()
+
+	if r.pause.since != nil {
+		return false
+	}
+	now := time.Now()
+	r.pause.since = &now
+	r.pause.resume = make(chan struct{})
+
+	log.Println("Task execution paused")
+	return true
+}
+
+// ResumeExecution accepts submissions again and releases held tasks. It
+// reports whether execution was paused before.
+func (r *Router) ResumeExecution() bool {
+	r.pause.mu.Lock()
+	defer r.pause.mu.Unlock()
+
+	if r.pause.since == nil {
+		return false
+	}
+	r.pause.since = nil
+	close(r.pause.resume)
+	r.pause.resume = nil
+
+	log.Println("Task execution resumed")
+	return true
+}
+
+// ExecutionPausedSince returns when execution was paused, or nil if it is running
+func (r *Router) ExecutionPausedSince() *time.Time {
+	r.pause.mu.Lock()
+	defer r.pause.mu.Unlock()
+
+	return r.pause.since
+}
+
+// waitWhilePaused blocks a dispatch worker until execution resumes. It
+// returns false if the router stops first.
+func (r *Router) waitWhilePaused() bool {
+	r.pause.mu.Lock()
+	resume := r.pause.resume
+	r.pause.mu.Unlock()
+
+	if resume == nil {
+		return true
+	}
+	select {
+	case <-resume:
+		return true
+	case <-r.stopCh:
+		return false
+	}
+}
@@ -0,0 +1,29 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPausedRouterHoldsQueuedTasks(t *testing.T) {
+	r, _, taskID, _, _ := queueForAgent(t, OrphanCancel)
+	if !r.PauseExecution() || r.PauseExecution() {
+		t.Fatal("pause did not report the running state it replaced")
+	}
+	startRouter(t, r)
+
+	if _, err := r.SubmitTask(context.Background(), &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost"}); !errors.Is(err, ErrExecutionPaused) {
+		t.Errorf("submit while paused: %v, want ErrExecutionPaused", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if status, _ := r.GetTaskStatus(taskID); status.Status != TaskStatusQueued {
+		t.Errorf("task %s while paused, want held in the queue", status.Status)
+	}
+
+	if !r.ResumeExecution() || r.ExecutionPausedSince() != nil {
+		t.Fatal("resume did not clear the pause")
+	}
+	waitForStatus(t, r, taskID, TaskStatusCompleted)
+}
@@ -0,0 +1,94 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	// Distinct agents a task may fail on before it is quarantined
+	defaultPoisonThreshold = 2
+
+	// How long quarantined tasks are kept for inspection
+	deadLetterTTL = 7 * 24 * time.Hour
+)
+
+// SetPoisonThreshold sets how many distinct agents a task may fail on before
+// it is quarantined as a suspected poison task; 0 disables quarantine
+func (r *Router) SetPoisonThreshold(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.poisonThreshold = n
+}
+
+// ListDeadLetters returns the most recently quarantined tasks
+func (r *Router) ListDeadLetters(limit int) ([]*Task, error) {
+	if limit <= 0 || limit > maxTaskPageSize {
+		limit = maxTaskPageSize
+	}
+	return r.store.ListDeadLetters(r.ctx, limit)
+}
+
+// suggestsCrash reports whether a failed attempt may have taken the agent
+// down: it did not answer properly, or answered 5xx without classifying the
+// error as an ordinary task failure. An agent that is merely slow to answer
+// has not crashed.
+func suggestsCrash(err error) bool {
+	var agentErr *AgentError
+	if errors.As(err, &agentErr) {
+		return agentErr.StatusCode >= 500 && agentErr.Code == "" && agentErr.Retryable == nil
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var netErr net.Error
+	return !errors.As(err, &netErr) || !netErr.Timeout()
+}
+
+// failedOn reports whether the task is suspected to have crashed the agent.
+// It must be called with r.mu held.
+func failedOn(task *Task, agentID string) bool {
+	for _, id := range task.FailedAgents {
+		if id == agentID {
+			return true
+		}
+	}
+	return false
+}
+
+// recordAgentFailure notes that the task failed on an agent and reports
+// whether it has now failed on enough distinct agents to be quarantined. It
+// must be called with r.mu held.
+func (r *Router) recordAgentFailure(task *Task, agentID string) bool {
+	if !failedOn(task, agentID) {
+		task.FailedAgents = append(task.FailedAgents, agentID)
+	}
+	return r.poisonThreshold > 0 && len(task.FailedAgents) >= r.poisonThreshold
+}
+
+// quarantineTaskLocked stops a suspected poison task from reaching more
+// agents and copies it to the dead-letter store. It must be called with r.mu
+// held.
+func (r *Router) quarantineTaskLocked(task *Task) {
+	task.Status = TaskStatusQuarantined
+	task.Error = fmt.Sprintf("suspected poison task: failed on agents %s", strings.Join(task.FailedAgents, ", "))
+	now := time.Now()
+	task.CompletedAt = &now
+	task.endExecution()
+
+	if err := r.storeTask(task); err != nil {
+		log.Printf("Failed to store quarantined task %s: %v", task.ID, err)
+	}
+	if err := r.store.AddDeadLetter(r.ctx, task); err != nil {
+		log.Printf("Failed to dead-letter task %s: %v", task.ID, err)
+	}
+	r.recordEvent(task, TaskEventQuarantined, task.Error)
+	r.finishBroadcastChild(task)
+
+	log.Printf("Task %s quarantined after failing on %d agents", task.ID, len(task.FailedAgents))
+}
@@ -0,0 +1,79 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+// timeoutError is a net.Error reporting a timeout
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestSuggestsCrash(t *testing.T) {
+	retryable := true
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"connection dropped", fmt.Errorf("failed to send request: %w", &url.Error{Op: "Post", URL: "http://agent/task", Err: errors.New("EOF")}), true},
+		{"unclassified 5xx", &AgentError{StatusCode: 500, Message: "boom"}, true},
+		{"classified 5xx", &AgentError{StatusCode: 503, Message: "busy", Retryable: &retryable}, false},
+		{"4xx", &AgentError{StatusCode: 400, Message: "bad input"}, false},
+		{"client timeout", fmt.Errorf("failed to send request: %w", &url.Error{Op: "Post", URL: "http://agent/task", Err: timeoutError{}}), false},
+		{"context deadline", fmt.Errorf("failed to send request: %w", context.DeadlineExceeded), false},
+	}
+	for _, tt := range tests {
+		if got := suggestsCrash(tt.err); got != tt.want {
+			t.Errorf("%s: suggestsCrash = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCrashingTaskQuarantinedAfterTwoAgents(t *testing.T) {
+	var mu sync.Mutex
+	hits := make(map[string]int)
+	crashing := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			mu.Lock()
+			hits[name]++
+			mu.Unlock()
+			// Drop the connection without answering, as a crashed agent would
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+		}
+	}
+
+	r, reg := newTestRouter(t)
+	for _, name := range []string{"a", "b", "c"} {
+		agent := newAgentServer(t, crashing(name))
+		registerAgent(t, reg, name, registry.AgentTypeCost, agent.URL, string(TaskTypeAnalyzeCost))
+	}
+
+	id := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost", MaxRetries: 5}).TaskID
+	waitForStatus(t, r, id, TaskStatusQuarantined)
+
+	// The retry left the first agent, and the task never reached a third
+	mu.Lock()
+	defer mu.Unlock()
+	if len(hits) != 2 {
+		t.Errorf("agents reached = %v, want two", hits)
+	}
+	for name, n := range hits {
+		if n != 1 {
+			t.Errorf("agent %s received the task %d times, want once", name, n)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package task
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+// TaskProgress is the latest partial progress an agent reported for a task
+type TaskProgress struct {
+	Percent   float64   `json:"percent"`
+	Message   string    `json:"message,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// handleRegistryEvent applies task progress carried on agent heartbeats,
+// drains replaced agents and handles tasks orphaned by unregistered agents
+func (r *Router) handleRegistryEvent(event registry.Event) {
+	switch event.Type {
+	case registry.EventAgentRegistered, registry.EventAgentUnregistered, registry.EventAgentReplaced,
+		registry.EventAgentUpdated, registry.EventCapabilitiesUpdated:
+		// Route with this replica's registry changes right away
+		r.agentCache.invalidate()
+	}
+
+	switch event.Type {
+	case registry.EventAgentUnregistered:
+		r.handleAgentUnregistered(event.AgentID)
+		r.dispatchRate.forget(event.AgentID)
+	case registry.EventAgentReplaced:
+		successorID, _ := event.Details["successor_id"].(string)
+		r.drainReplacedAgent(event.AgentID, successorID)
+	case registry.EventTaskProgress:
+		reports, ok := event.Details["task_progress"].([]registry.TaskProgress)
+		if !ok {
+			return
+		}
+		for _, report := range reports {
+			if err := r.UpdateProgress(event.AgentID, report); err != nil {
+				log.Printf("Ignoring progress for task %s from agent %s: %v", report.TaskID, event.AgentID, err)
+			}
+		}
+	}
+}
+
+// UpdateProgress records partial progress for an in-flight task. Only the
+// agent the task was dispatched to may report on it.
+func (r *Router) UpdateProgress(agentID string, report registry.TaskProgress) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, ok := r.tasks[report.TaskID]
+	if !ok {
+		return fmt.Errorf("task not tracked by this router")
+	}
+	if task.AgentID != agentID {
+		return fmt.Errorf("task is assigned to a different agent")
+	}
+
+	switch task.Status {
+	case TaskStatusSent, TaskStatusRunning, TaskStatusRetrying:
+	default:
+		return fmt.Errorf("task is %s", task.Status)
+	}
+
+	percent := report.Percent
+	if percent < 0 {
+		percent = 0
+	}
+	if percent > 100 {
+		percent = 100
+	}
+
+	task.Status = TaskStatusRunning
+	task.Progress = &TaskProgress{
+		Percent:   percent,
+		Message:   report.Message,
+		UpdatedAt: time.Now(),
+	}
+
+	return r.storeTask(task)
+}
@@ -0,0 +1,83 @@
+package task
+
+import (
+	"testing"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+// heldTask submits a right_size task to an agent that holds it, returning
+// the task and agent IDs once the task is sent
+func heldTask(t *testing.T, r *Router, reg *registry.Registry) (string, string) {
+	t.Helper()
+	agentID := registerAgent(t, reg, "cost-1", registry.AgentTypeCost, blockingAgent(t), "right_size")
+	taskID := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeRightSize, AgentType: "cost"}).TaskID
+	waitForStatus(t, r, taskID, TaskStatusSent)
+	return taskID, agentID
+}
+
+func heartbeatProgress(t *testing.T, reg *registry.Registry, agentID string, progress ...registry.TaskProgress) {
+	t.Helper()
+	if _, err := reg.Heartbeat(agentID, &registry.HeartbeatRequest{
+		Status:       registry.AgentStatusHealthy,
+		TaskProgress: progress,
+	}); err != nil {
+		t.Fatalf("heartbeat: %v", err)
+	}
+}
+
+func TestHeartbeatUpdatesTaskProgress(t *testing.T) {
+	r, reg := newTestRouter(t)
+	taskID, agentID := heldTask(t, r, reg)
+
+	heartbeatProgress(t, reg, agentID, registry.TaskProgress{TaskID: taskID, Percent: 40, Message: "resizing"})
+	status, err := r.GetTaskStatus(taskID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != TaskStatusRunning {
+		t.Errorf("status = %s, want running", status.Status)
+	}
+	if status.Progress == nil || status.Progress.Percent != 40 || status.Progress.Message != "resizing" {
+		t.Fatalf("progress = %+v", status.Progress)
+	}
+
+	// Later reports replace earlier ones, clamped to 100%
+	heartbeatProgress(t, reg, agentID, registry.TaskProgress{TaskID: taskID, Percent: 150})
+	if status, _ := r.GetTaskStatus(taskID); status.Progress == nil || status.Progress.Percent != 100 || status.Progress.Message != "" {
+		t.Errorf("progress after second report = %+v", status.Progress)
+	}
+}
+
+func TestProgressFromOtherAgentIgnored(t *testing.T) {
+	r, reg := newTestRouter(t)
+	taskID, _ := heldTask(t, r, reg)
+	otherID := registerAgent(t, reg, "cost-2", registry.AgentTypeCost, "", "analyze_cost")
+
+	heartbeatProgress(t, reg, otherID, registry.TaskProgress{TaskID: taskID, Percent: 40})
+	status, err := r.GetTaskStatus(taskID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Progress != nil || status.Status != TaskStatusSent {
+		t.Errorf("task %s with progress %+v after another agent's report", status.Status, status.Progress)
+	}
+	if err := r.UpdateProgress(otherID, registry.TaskProgress{TaskID: taskID, Percent: 40}); err == nil {
+		t.Error("progress accepted from an agent not running the task")
+	}
+}
+
+func TestProgressForFinishedTaskRejected(t *testing.T) {
+	agent := newAgentServer(t, completingAgent(map[string]interface{}{"ok": true}))
+	r, reg := newTestRouter(t)
+	agentID := registerAgent(t, reg, "cost-1", registry.AgentTypeCost, agent.URL, "analyze_cost")
+	taskID := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost"}).TaskID
+	waitForStatus(t, r, taskID, TaskStatusCompleted)
+
+	if err := r.UpdateProgress(agentID, registry.TaskProgress{TaskID: taskID, Percent: 40}); err == nil {
+		t.Error("progress accepted for a completed task")
+	}
+	if status, _ := r.GetTaskStatus(taskID); status.Status != TaskStatusCompleted || status.Progress != nil {
+		t.Errorf("completed task now %s with progress %+v", status.Status, status.Progress)
+	}
+}
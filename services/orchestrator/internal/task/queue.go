@@ -0,0 +1,209 @@
+package task
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+// queuedTask is a task waiting for a dispatch worker
+type queuedTask struct {
+	task       *Task
+	agent      *registry.Agent
+	enqueuedAt time.Time
+	seq        uint64
+	index      int
+
+	// Set once the task holds a dispatch turn from the agent's rate limit
+	paced bool
+
+	// Retry boost; raises this entry's priority but not the task's
+	boost TaskPriority
+}
+
+// taskQueue is a blocking priority queue with priority aging.
+//
+// A task's effective priority is Priority + agingRate * minutesWaited. Since
+// every queued task ages at the same rate, comparing two tasks at any instant
+// reduces to comparing Priority - agingRate * enqueueMinute, which does not
+// change over time, so the heap ordering stays valid without re-sorting.
+type taskQueue struct {
+	mu        sync.Mutex
+	cond      *sync.Cond
+	items     queueHeap
+	base      time.Time
+	agingRate float64 // priority points gained per minute of waiting
+	seq       uint64
+	closed    bool
+
+	// Tasks held back by PushAfter, counted in Len
+	delayed []*queuedTask
+}
+
+func newTaskQueue(agingRate float64) *taskQueue {
+	q := &taskQueue{
+		base:      time.Now(),
+		agingRate: agingRate,
+	}
+	q.items.q = q
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// Push adds a task to the queue
+func (q *taskQueue) Push(task *Task, agent *registry.Agent) {
+	q.PushBoosted(task, agent, 0)
+}
+
+// PushBoosted adds a task to the queue ordered as if its priority were
+// raised by boost
+func (q *taskQueue) PushBoosted(task *Task, agent *registry.Agent, boost TaskPriority) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.seq++
+	heap.Push(&q.items, &queuedTask{
+		task:       task,
+		agent:      agent,
+		enqueuedAt: time.Now(),
+		seq:        q.seq,
+		boost:      boost,
+	})
+	q.cond.Signal()
+}
+
+// PushAfter returns a popped task to the queue once delay has passed,
+// keeping its place in the aging order. Until then it is counted in Len but
+// cannot be popped, so no worker waits on it.
+func (q *taskQueue) PushAfter(item *queuedTask, delay time.Duration) {
+	q.mu.Lock()
+	q.delayed = append(q.delayed, item)
+	q.mu.Unlock()
+
+	time.AfterFunc(delay, func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		for i, held := range q.delayed {
+			if held == item {
+				q.delayed = append(q.delayed[:i], q.delayed[i+1:]...)
+				break
+			}
+		}
+		if q.closed {
+			return
+		}
+		heap.Push(&q.items, item)
+		q.cond.Signal()
+	})
+}
+
+// Pop blocks until a task is available and returns the one with the highest
+// effective priority. It returns nil once the queue is closed.
+func (q *taskQueue) Pop() *queuedTask {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.items.entries) == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if q.closed {
+		return nil
+	}
+	return heap.Pop(&q.items).(*queuedTask)
+}
+
+// Len returns the number of queued tasks, including delayed ones
+func (q *taskQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items.entries) + len(q.delayed)
+}
+
+// Count returns the number of queued tasks, including delayed ones, for
+// which keep returns true. keep is called with the queue lock held.
+func (q *taskQueue) Count(keep func(*queuedTask) bool) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	n := 0
+	for _, item := range q.items.entries {
+		if keep(item) {
+			n++
+		}
+	}
+	for _, item := range q.delayed {
+		if keep(item) {
+			n++
+		}
+	}
+	return n
+}
+
+// SetAgingRate changes the aging rate and re-sorts the queue
+func (q *taskQueue) SetAgingRate(rate float64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.agingRate = rate
+	heap.Init(&q.items)
+}
+
+// AgingRate returns the current aging rate
+func (q *taskQueue) AgingRate() float64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.agingRate
+}
+
+// Close wakes all blocked workers and makes Pop return nil
+func (q *taskQueue) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+// effectiveScore is the time-invariant ordering key described on taskQueue
+func (q *taskQueue) effectiveScore(item *queuedTask) float64 {
+	enqueueMinute := item.enqueuedAt.Sub(q.base).Minutes()
+	return float64(item.task.Priority+item.boost) - q.agingRate*enqueueMinute
+}
+
+// queueHeap implements heap.Interface; callers must hold the queue lock
+type queueHeap struct {
+	q       *taskQueue
+	entries []*queuedTask
+}
+
+func (h queueHeap) Len() int { return len(h.entries) }
+
+func (h queueHeap) Less(i, j int) bool {
+	si, sj := h.q.effectiveScore(h.entries[i]), h.q.effectiveScore(h.entries[j])
+	if si != sj {
+		return si > sj
+	}
+	return h.entries[i].seq < h.entries[j].seq
+}
+
+func (h queueHeap) Swap(i, j int) {
+	h.entries[i], h.entries[j] = h.entries[j], h.entries[i]
+	h.entries[i].index = i
+	h.entries[j].index = j
+}
+
+func (h *queueHeap) Push(x interface{}) {
+	item := x.(*queuedTask)
+	item.index = len(h.entries)
+	h.entries = append(h.entries, item)
+}
+
+func (h *queueHeap) Pop() interface{} {
+	old := h.entries
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	item.index = -1
+	h.entries = old[:n-1]
+	return item
+}
@@ -0,0 +1,96 @@
+package task
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+// pushAt queues a task as if it had been enqueued at the given time
+func pushAt(q *taskQueue, task *Task, at time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.seq++
+	heap.Push(&q.items, &queuedTask{task: task, enqueuedAt: at, seq: q.seq})
+}
+
+func TestAgedLowPriorityTaskOvertakesNewArrivals(t *testing.T) {
+	q := newTaskQueue(1) // One priority point per minute waited
+	now := time.Now()
+
+	// After two minutes the low task still trails fresh normal tasks
+	pushAt(q, &Task{ID: "low", Priority: PriorityLow}, now.Add(-2*time.Minute))
+	pushAt(q, &Task{ID: "normal-1", Priority: PriorityNormal}, now)
+	if got := q.Pop().task.ID; got != "normal-1" {
+		t.Fatalf("popped %s, want normal-1 ahead of the briefly waiting low task", got)
+	}
+
+	// Tasks keep arriving while the low task waits; once it has aged past
+	// their priority it is served first
+	pushAt(q, &Task{ID: "normal-2", Priority: PriorityNormal}, now.Add(5*time.Minute))
+	pushAt(q, &Task{ID: "normal-3", Priority: PriorityNormal}, now.Add(5*time.Minute))
+	if got := q.Pop().task.ID; got != "low" {
+		t.Fatalf("popped %s, want the aged low task", got)
+	}
+	for _, want := range []string{"normal-2", "normal-3"} {
+		if got := q.Pop().task.ID; got != want {
+			t.Errorf("popped %s, want %s", got, want)
+		}
+	}
+}
+
+func TestQueueWithoutAgingKeepsPriorityOrder(t *testing.T) {
+	q := newTaskQueue(0)
+	now := time.Now()
+	pushAt(q, &Task{ID: "low", Priority: PriorityLow}, now.Add(-time.Hour))
+	pushAt(q, &Task{ID: "normal", Priority: PriorityNormal}, now)
+	pushAt(q, &Task{ID: "normal-later", Priority: PriorityNormal}, now.Add(time.Minute))
+
+	for _, want := range []string{"normal", "normal-later", "low"} {
+		if got := q.Pop().task.ID; got != want {
+			t.Errorf("popped %s, want %s", got, want)
+		}
+	}
+
+	// Raising the rate re-sorts queued tasks
+	pushAt(q, &Task{ID: "old-low", Priority: PriorityLow}, now.Add(-time.Hour))
+	pushAt(q, &Task{ID: "new-normal", Priority: PriorityNormal}, now)
+	q.SetAgingRate(1)
+	if got := q.Pop().task.ID; got != "old-low" {
+		t.Errorf("popped %s after enabling aging, want old-low", got)
+	}
+}
+
+func TestCancelledTasksFreeQueueCapacity(t *testing.T) {
+	reg := registry.NewRegistryWithStore(registry.NewMemoryAgentStore())
+	r := NewRouterWithConfig(NewMemoryTaskStore(), reg, DefaultConfig())
+	t.Cleanup(r.Stop)
+	registerAgent(t, reg, "cost-1", registry.AgentTypeCost, "", "right_size")
+	r.SetMaxQueuedTasks(2)
+
+	// The router is not dispatching, so submitted tasks stay queued
+	req := &TaskSubmitRequest{TaskType: TaskTypeRightSize, AgentType: "cost"}
+	first := submit(t, r, req).TaskID
+	submit(t, r, req)
+	if _, err := r.SubmitTask(context.Background(), req); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("submit to a full queue: %v, want ErrQueueFull", err)
+	}
+
+	// The cancelled task's entry stays in the queue but takes no room
+	if err := r.CancelTask(first); err != nil {
+		t.Fatal(err)
+	}
+	if r.queue.Len() != 2 {
+		t.Fatalf("queue holds %d entries, want the cancelled one left behind", r.queue.Len())
+	}
+	if _, err := r.SubmitTask(context.Background(), req); err != nil {
+		t.Errorf("submit after a cancel: %v, want room for one task", err)
+	}
+	if _, err := r.SubmitTask(context.Background(), req); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("submit past capacity: %v, want ErrQueueFull", err)
+	}
+}
@@ -0,0 +1,102 @@
+package task
+
+import (
+	"sync"
+	"time"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+// dispatchLimiter paces task dispatch to each agent with a token bucket, so
+// bursts of tasks do not overwhelm the agent's downstream APIs
+type dispatchLimiter struct {
+	mu      sync.Mutex
+	rate    float64 // Ceiling for every agent, tasks per second; 0 means none
+	burst   int
+	buckets map[string]*dispatchBucket
+}
+
+// dispatchBucket is one agent's token bucket. Tokens go negative while
+// dispatches wait for their turn.
+type dispatchBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newDispatchLimiter() *dispatchLimiter {
+	return &dispatchLimiter{burst: 1, buckets: make(map[string]*dispatchBucket)}
+}
+
+// SetAgentDispatchRate caps dispatch to each agent at perSecond tasks on
+// average, with bursts of up to burst. Agents that report a lower rate at
+// registration get their own. A perSecond of 0 leaves only agent-reported
+// rates.
+func (r *Router) SetAgentDispatchRate(perSecond float64, burst int) {
+	if burst < 1 {
+		burst = 1
+	}
+	r.dispatchRate.mu.Lock()
+	defer r.dispatchRate.mu.Unlock()
+	r.dispatchRate.rate = perSecond
+	r.dispatchRate.burst = burst
+}
+
+// agentRate returns the lower of the configured and agent-reported rates,
+// ignoring unset ones. It must be called with l.mu held.
+func (l *dispatchLimiter) agentRate(agent *registry.Agent) float64 {
+	rate := l.rate
+	if agent.MaxDispatchRate > 0 && (rate <= 0 || agent.MaxDispatchRate < rate) {
+		rate = agent.MaxDispatchRate
+	}
+	return rate
+}
+
+// reserve takes the agent's next dispatch turn and returns how long until
+// it comes up; zero means the task may be sent now
+func (l *dispatchLimiter) reserve(agent *registry.Agent) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	rate := l.agentRate(agent)
+	if rate <= 0 {
+		delete(l.buckets, agent.ID)
+		return 0
+	}
+
+	now := time.Now()
+	bucket, ok := l.buckets[agent.ID]
+	if !ok || bucket.rate != rate || bucket.burst != float64(l.burst) {
+		// New agent, or its rate changed; start from a full bucket
+		bucket = &dispatchBucket{rate: rate, burst: float64(l.burst), tokens: float64(l.burst), last: now}
+		l.buckets[agent.ID] = bucket
+	}
+	bucket.tokens += now.Sub(bucket.last).Seconds() * bucket.rate
+	if bucket.tokens > bucket.burst {
+		bucket.tokens = bucket.burst
+	}
+	bucket.last = now
+	bucket.tokens--
+	if bucket.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-bucket.tokens / bucket.rate * float64(time.Second))
+}
+
+// unreserve hands back a turn taken by reserve that will not be used, to
+// the dispatches queued behind it
+func (l *dispatchLimiter) unreserve(agentID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if bucket, ok := l.buckets[agentID]; ok {
+		bucket.tokens++
+	}
+}
+
+// forget drops an agent's bucket once it leaves the registry
+func (l *dispatchLimiter) forget(agentID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.buckets, agentID)
+}
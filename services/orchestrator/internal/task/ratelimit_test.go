@@ -0,0 +1,69 @@
+package task
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+func TestDispatchPacedToAgentRate(t *testing.T) {
+	var mu sync.Mutex
+	var arrivals []time.Time
+	done := completingAgent(nil)
+	agent := newAgentServer(t, func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		arrivals = append(arrivals, time.Now())
+		mu.Unlock()
+		done(w, req)
+	})
+
+	r, reg := newTestRouter(t)
+	r.SetAgentDispatchRate(20, 1)
+	registerAgent(t, reg, "analyzer", registry.AgentTypeCost, agent.URL, string(TaskTypeAnalyzeCost))
+
+	var ids []string
+	for i := 0; i < 5; i++ {
+		ids = append(ids, submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost"}).TaskID)
+	}
+	for _, id := range ids {
+		waitForStatus(t, r, id, TaskStatusCompleted)
+	}
+
+	// 20 per second with a burst of 1 spaces dispatches 50ms apart
+	mu.Lock()
+	defer mu.Unlock()
+	if len(arrivals) != 5 {
+		t.Fatalf("agent received %d tasks, want 5", len(arrivals))
+	}
+	for i := 1; i < len(arrivals); i++ {
+		if gap := arrivals[i].Sub(arrivals[i-1]); gap < 40*time.Millisecond {
+			t.Errorf("dispatch %d followed the previous after %v, want about 50ms", i+1, gap)
+		}
+	}
+}
+
+func TestPacedAgentDoesNotBlockOthers(t *testing.T) {
+	slow := newAgentServer(t, completingAgent(nil))
+	fast := newAgentServer(t, completingAgent(nil))
+
+	r, reg := newTestRouter(t)
+	r.SetAgentDispatchRate(1, 1)
+	registerAgent(t, reg, "slow", registry.AgentTypeCost, slow.URL, string(TaskTypeAnalyzeCost))
+	registerAgent(t, reg, "fast", registry.AgentTypePerformance, fast.URL, string(TaskTypeOptimizeKVCache))
+
+	// More tasks than dispatch workers wait on the slow agent's rate
+	for i := 0; i < 2*defaultDispatchWorkers; i++ {
+		submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost"})
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	start := time.Now()
+	id := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeOptimizeKVCache, AgentType: "performance"}).TaskID
+	waitForStatus(t, r, id, TaskStatusCompleted)
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("task for an unpaced agent took %v behind a paced one", elapsed)
+	}
+}
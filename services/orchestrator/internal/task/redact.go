@@ -0,0 +1,71 @@
+package task
+
+import "strings"
+
+// redactedMarker replaces the values of redacted result and metadata keys
+const redactedMarker = "[REDACTED]"
+
+// SetRedactedKeys sets the result and response metadata keys whose values
+// are replaced with a marker before a task result is stored or returned.
+// Keys match case-insensitively at any nesting depth. Redacted values are
+// also unavailable to chained task mappings.
+func (r *Router) SetRedactedKeys(keys []string) {
+	redacted := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if key = strings.ToLower(strings.TrimSpace(key)); key != "" {
+			redacted[key] = true
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.redactKeys = redacted
+}
+
+// redact returns a copy of m with the values of redacted keys replaced, or
+// m itself when nothing is configured. It must be called with r.mu held.
+func (r *Router) redact(m map[string]interface{}) map[string]interface{} {
+	if len(r.redactKeys) == 0 || m == nil {
+		return m
+	}
+	return redactMap(m, r.redactKeys)
+}
+
+func redactMap(m map[string]interface{}, keys map[string]bool) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		if keys[strings.ToLower(key)] {
+			out[key] = redactedMarker
+			continue
+		}
+		out[key] = redactValue(value, keys)
+	}
+	return out
+}
+
+func redactValue(value interface{}, keys map[string]bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return redactMap(v, keys)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = redactValue(item, keys)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+// redactResponse returns a copy of an agent response with its result and
+// metadata redacted. It must be called with r.mu held.
+func (r *Router) redactResponse(response *TaskResponse) *TaskResponse {
+	if len(r.redactKeys) == 0 {
+		return response
+	}
+	redacted := *response
+	redacted.Result = r.redact(response.Result)
+	redacted.Metadata = r.redact(response.Metadata)
+	return &redacted
+}
@@ -0,0 +1,94 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+const secret = "s3cr3t-value"
+
+// secretAgent completes every task with secrets at several depths of its
+// result and metadata
+func secretAgent(w http.ResponseWriter, req *http.Request) {
+	var taskReq TaskRequest
+	json.NewDecoder(req.Body).Decode(&taskReq)
+	json.NewEncoder(w).Encode(TaskResponse{
+		TaskID: taskReq.TaskID,
+		Status: TaskStatusCompleted,
+		Result: map[string]interface{}{
+			"connection_string": "postgres://admin:" + secret + "@db",
+			"savings":           12.5,
+			"credentials":       map[string]interface{}{"Token": secret, "user": "admin"},
+			"nodes":             []interface{}{map[string]interface{}{"name": "node-1", "api_key": secret}},
+		},
+		Metadata: map[string]interface{}{"token": secret, "region": "us-east-1"},
+	})
+}
+
+func TestRedactedKeysNeverStoredOrReturned(t *testing.T) {
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	agent := newAgentServer(t, secretAgent)
+	r, reg := newStoreRouter(t, NewRedisTaskStore(client))
+	r.SetRedactedKeys([]string{"connection_string", " token ", "API_KEY"})
+	registerAgent(t, reg, "cost-1", registry.AgentTypeCost, agent.URL, "analyze_cost")
+
+	id := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost"}).TaskID
+	status := waitForStatus(t, r, id, TaskStatusCompleted)
+
+	// Redacted values are replaced; everything else is kept
+	if status.Result["connection_string"] != redactedMarker || status.Result["savings"] != 12.5 {
+		t.Errorf("result = %v", status.Result)
+	}
+	if creds, _ := status.Result["credentials"].(map[string]interface{}); creds["Token"] != redactedMarker || creds["user"] != "admin" {
+		t.Errorf("nested result = %v", status.Result["credentials"])
+	}
+
+	var returned TaskStatusResponse
+	if code := getJSON(t, r, "/tasks/"+id, &returned); code != http.StatusOK {
+		t.Fatalf("status %d", code)
+	}
+	body, _ := json.Marshal(returned)
+	if strings.Contains(string(body), secret) {
+		t.Errorf("status response leaks a redacted value: %s", body)
+	}
+
+	// Neither the task nor the raw agent response is stored with the secret
+	for _, key := range srv.Keys() {
+		if srv.Type(key) != "string" {
+			continue
+		}
+		if value, _ := srv.Get(key); strings.Contains(value, secret) {
+			t.Errorf("redis key %s holds a redacted value", key)
+		}
+	}
+	stored, err := NewRedisTaskStore(client).GetTask(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Result["connection_string"] != redactedMarker {
+		t.Errorf("stored result = %v", stored.Result)
+	}
+}
+
+func TestNoRedactionByDefault(t *testing.T) {
+	agent := newAgentServer(t, secretAgent)
+	r, reg := newTestRouter(t)
+	registerAgent(t, reg, "cost-1", registry.AgentTypeCost, agent.URL, "analyze_cost")
+
+	id := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost"}).TaskID
+	status := waitForStatus(t, r, id, TaskStatusCompleted)
+	if value, _ := status.Result["connection_string"].(string); !strings.Contains(value, secret) {
+		t.Errorf("result = %v, want values kept when nothing is redacted", status.Result)
+	}
+}
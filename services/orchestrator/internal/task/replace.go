@@ -0,0 +1,68 @@
+package task
+
+import (
+	"log"
+	"time"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+// drainPollInterval is how often a replaced agent's unfinished tasks are
+// counted while it drains
+const drainPollInterval = 1 * time.Second
+
+// drainReplacedAgent unregisters a replaced agent once this replica has no
+// unfinished tasks on it. Routing already skips it, as it is no longer
+// healthy.
+func (r *Router) drainReplacedAgent(agentID, successorID string) {
+	select {
+	case <-r.stopCh:
+		return
+	default:
+	}
+
+	log.Printf("Draining agent %s in favour of %s", agentID, successorID)
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		ticker := time.NewTicker(drainPollInterval)
+		defer ticker.Stop()
+		for {
+			r.mu.RLock()
+			remaining := r.agentLoad()[agentID]
+			r.mu.RUnlock()
+
+			if remaining == 0 {
+				if err := r.registry.Unregister(agentID); err != nil {
+					log.Printf("Failed to unregister drained agent %s: %v", agentID, err)
+					return
+				}
+				log.Printf("Agent %s drained and unregistered", agentID)
+				return
+			}
+
+			select {
+			case <-ticker.C:
+			case <-r.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// successorOf follows an agent's replacements to the agent now taking its
+// tasks, or returns the agent itself if it was not replaced or its
+// successor is gone
+func (r *Router) successorOf(agent *registry.Agent) *registry.Agent {
+	seen := map[string]bool{agent.ID: true}
+	for agent.ReplacedBy != "" && !seen[agent.ReplacedBy] {
+		successor, err := r.registry.GetAgent(agent.ReplacedBy)
+		if err != nil {
+			break
+		}
+		seen[successor.ID] = true
+		agent = successor
+	}
+	return agent
+}
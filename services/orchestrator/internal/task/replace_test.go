@@ -0,0 +1,90 @@
+package task
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+// heldAgent completes tasks only once released, returning its URL and the
+// release func
+func heldAgent(t *testing.T) (string, func()) {
+	t.Helper()
+	release := make(chan struct{})
+	complete := completingAgent(map[string]interface{}{"ok": true})
+	srv := newAgentServer(t, func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-release:
+			complete(w, req)
+		case <-req.Context().Done():
+		}
+	})
+	released := false
+	releaseFunc := func() {
+		if !released {
+			released = true
+			close(release)
+		}
+	}
+	t.Cleanup(releaseFunc)
+	return srv.URL, releaseFunc
+}
+
+func TestReplacedAgentDrainsWhileSuccessorTakesTraffic(t *testing.T) {
+	r, reg := newTestRouter(t)
+	oldURL, release := heldAgent(t)
+	old := registerAgent(t, reg, "cost-1", registry.AgentTypeCost, oldURL, "analyze_cost")
+	inFlight := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost"}).TaskID
+	waitForStatus(t, r, inFlight, TaskStatusSent)
+
+	successorServer := newAgentServer(t, completingAgent(map[string]interface{}{"ok": true}))
+	host, port := hostPort(t, successorServer.URL)
+	resp, err := reg.Replace(old, &registry.RegistrationRequest{
+		Name:         "cost-1-v2",
+		Type:         registry.AgentTypeCost,
+		Host:         host,
+		Port:         port,
+		Capabilities: []string{"analyze_cost"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	successor := resp.AgentID
+
+	// New tasks, including ones naming the old agent, go to the successor
+	for _, req := range []*TaskSubmitRequest{
+		{TaskType: TaskTypeAnalyzeCost, AgentType: "cost"},
+		{TaskType: TaskTypeAnalyzeCost, AgentType: "cost"},
+		{TaskType: TaskTypeAnalyzeCost, AgentType: "cost", AgentID: old},
+	} {
+		id := submit(t, r, req).TaskID
+		if status := waitForStatus(t, r, id, TaskStatusCompleted); status.AgentID != successor {
+			t.Errorf("task for agent %q ran on %s, want the successor", req.AgentID, status.AgentID)
+		}
+	}
+
+	// The old agent keeps its in-flight task, and so its registration
+	if agent, err := reg.GetAgent(old); err != nil || agent.Status != registry.AgentStatusDraining || agent.ReplacedBy != successor {
+		t.Fatalf("old agent = %+v, %v; want draining, replaced by %s", agent, err, successor)
+	}
+
+	release()
+	if status := waitForStatus(t, r, inFlight, TaskStatusCompleted); status.AgentID != old {
+		t.Errorf("in-flight task finished on %s, want the old agent", status.AgentID)
+	}
+	deadline := time.Now().Add(3 * drainPollInterval)
+	for {
+		if _, err := reg.GetAgent(old); err != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("drained agent still registered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if agent, err := reg.GetAgent(successor); err != nil || agent.Status != registry.AgentStatusHealthy {
+		t.Errorf("successor = %+v, %v; want healthy", agent, err)
+	}
+}
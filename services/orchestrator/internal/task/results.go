@@ -0,0 +1,220 @@
+package task
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidResult is returned when a task result lacks a field or holds
+// one of the wrong type
+var ErrInvalidResult = errors.New("invalid task result")
+
+// Typed results of the built-in task types. Fields without omitempty must
+// be present in the agent's result; extra fields are ignored, and the raw
+// map stays on TaskResponse.Result. Only fields the reference agents return
+// (agents/cost_agent, the example handlers in shared/orchestrator and the
+// application agent's result models) are required; the rest are optional
+// until an agent defines them.
+
+// CostAnalysisResult is the result of an analyze_cost task. The cost agent
+// reports current_spend and the example handler total_spend.
+type CostAnalysisResult struct {
+	PotentialSavings  float64                  `json:"potential_savings"`
+	CurrentSpend      float64                  `json:"current_spend,omitempty"`
+	TotalSpend        float64                  `json:"total_spend,omitempty"`
+	SavingsPercentage float64                  `json:"savings_percentage,omitempty"`
+	AccountID         string                   `json:"account_id,omitempty"`
+	Period            string                   `json:"period,omitempty"`
+	Recommendations   []map[string]interface{} `json:"recommendations,omitempty"`
+}
+
+// SpotMigrationResult is the result of a migrate_to_spot task. The cost
+// agent reports migrated and monthly_savings, the example handler
+// migrated_instances and estimated_savings_per_month.
+type SpotMigrationResult struct {
+	Status                   string  `json:"status"`
+	Migrated                 int     `json:"migrated,omitempty"`
+	MigratedInstances        int     `json:"migrated_instances,omitempty"`
+	TotalInstances           int     `json:"total_instances,omitempty"`
+	Failed                   int     `json:"failed,omitempty"`
+	MonthlySavings           float64 `json:"monthly_savings,omitempty"`
+	EstimatedSavingsPerMonth float64 `json:"estimated_savings_per_month,omitempty"`
+}
+
+// RightSizeResult is the result of a right_size task
+type RightSizeResult struct {
+	TotalInstances       int     `json:"total_instances"`
+	Optimized            int     `json:"optimized"`
+	MonthlySavings       float64 `json:"monthly_savings"`
+	AverageSizeReduction string  `json:"average_size_reduction,omitempty"`
+	Status               string  `json:"status,omitempty"`
+}
+
+// KVCacheResult is the result of an optimize_kv_cache task
+type KVCacheResult struct {
+	CacheHitRate  float64 `json:"cache_hit_rate,omitempty"`
+	MemorySavedMB float64 `json:"memory_saved_mb,omitempty"`
+}
+
+// InferenceTuningResult is the result of a tune_inference task
+type InferenceTuningResult struct {
+	LatencyMs  float64 `json:"latency_ms,omitempty"`
+	Throughput float64 `json:"throughput,omitempty"`
+}
+
+// ScalingPredictionResult is the result of a predict_scaling task
+type ScalingPredictionResult struct {
+	RecommendedReplicas int     `json:"recommended_replicas,omitempty"`
+	Confidence          float64 `json:"confidence,omitempty"`
+}
+
+// LoadBalanceResult is the result of a balance_load task
+type LoadBalanceResult struct {
+	RebalancedWorkloads int `json:"rebalanced_workloads,omitempty"`
+}
+
+// QualityValidationResult is the result of a validate_quality task, after
+// the application agent's ValidationResult
+type QualityValidationResult struct {
+	Decision        string  `json:"decision"`
+	Confidence      float64 `json:"confidence"`
+	BaselineQuality float64 `json:"baseline_quality,omitempty"`
+	NewQuality      float64 `json:"new_quality,omitempty"`
+	QualityChange   float64 `json:"quality_change,omitempty"`
+	Recommendation  string  `json:"recommendation,omitempty"`
+}
+
+// RegressionResult is the result of a detect_regression task, after the
+// application agent's RegressionResult
+type RegressionResult struct {
+	RegressionDetected bool                   `json:"regression_detected"`
+	RegressionScore    float64                `json:"regression_score"`
+	Severity           string                 `json:"severity"`
+	QualityDrop        float64                `json:"quality_drop,omitempty"`
+	BaselineQuality    float64                `json:"baseline_quality,omitempty"`
+	CurrentQuality     float64                `json:"current_quality,omitempty"`
+	Details            map[string]interface{} `json:"details,omitempty"`
+}
+
+// resultTypes maps each built-in task type to its typed result
+var resultTypes = map[TaskType]func() interface{}{
+	TaskTypeAnalyzeCost:      func() interface{} { return &CostAnalysisResult{} },
+	TaskTypeMigrateToSpot:    func() interface{} { return &SpotMigrationResult{} },
+	TaskTypeRightSize:        func() interface{} { return &RightSizeResult{} },
+	TaskTypeOptimizeKVCache:  func() interface{} { return &KVCacheResult{} },
+	TaskTypeTuneInference:    func() interface{} { return &InferenceTuningResult{} },
+	TaskTypePredictScaling:   func() interface{} { return &ScalingPredictionResult{} },
+	TaskTypeBalanceLoad:      func() interface{} { return &LoadBalanceResult{} },
+	TaskTypeValidateQuality:  func() interface{} { return &QualityValidationResult{} },
+	TaskTypeDetectRegression: func() interface{} { return &RegressionResult{} },
+}
+
+// GetResultField reads one field of a response's result as T. JSON numbers
+// convert to any numeric T they fit; other mismatches fail with
+// ErrInvalidResult.
+func GetResultField[T any](resp *TaskResponse, key string) (T, error) {
+	var value T
+	if resp == nil {
+		return value, fmt.Errorf("%w: no response", ErrInvalidResult)
+	}
+	raw, ok := resp.Result[key]
+	if !ok {
+		return value, fmt.Errorf("%w: %s is missing", ErrInvalidResult, key)
+	}
+	if v, ok := raw.(T); ok {
+		return v, nil
+	}
+
+	// Convert through JSON, as the value arrived
+	data, err := json.Marshal(raw)
+	if err == nil {
+		err = json.Unmarshal(data, &value)
+	}
+	if err != nil {
+		return value, fmt.Errorf("%w: %s is %v, which cannot be read as %T", ErrInvalidResult, key, raw, value)
+	}
+	return value, nil
+}
+
+// DecodeResult decodes a response's result into T, a struct whose fields
+// without omitempty must all be present
+func DecodeResult[T any](resp *TaskResponse) (*T, error) {
+	var out T
+	if resp == nil {
+		return nil, fmt.Errorf("%w: no response", ErrInvalidResult)
+	}
+	if err := decodeResult(resp.Result, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DecodeTaskResult decodes a response's result into the typed result of a
+// built-in task type, such as *CostAnalysisResult for analyze_cost
+func DecodeTaskResult(taskType TaskType, resp *TaskResponse) (interface{}, error) {
+	newResult, ok := resultTypes[taskType]
+	if !ok {
+		return nil, fmt.Errorf("%w: no typed result for task type %s", ErrInvalidResult, taskType)
+	}
+	if resp == nil {
+		return nil, fmt.Errorf("%w: no response", ErrInvalidResult)
+	}
+	out := newResult()
+	if err := decodeResult(resp.Result, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// decodeResult checks that result has every required field of the struct
+// out points to, then decodes it
+func decodeResult(result map[string]interface{}, out interface{}) error {
+	if missing := missingResultFields(result, reflect.TypeOf(out).Elem()); len(missing) > 0 {
+		return fmt.Errorf("%w: missing %s", ErrInvalidResult, strings.Join(missing, ", "))
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidResult, err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return fmt.Errorf("%w: %s must be a %s, got %s", ErrInvalidResult, typeErr.Field, typeErr.Type, typeErr.Value)
+		}
+		return fmt.Errorf("%w: %v", ErrInvalidResult, err)
+	}
+	return nil
+}
+
+// missingResultFields lists the JSON names of t's fields without omitempty
+// that result lacks
+func missingResultFields(result map[string]interface{}, t reflect.Type) []string {
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var missing []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || strings.Contains(opts, "omitempty") {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if _, ok := result[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
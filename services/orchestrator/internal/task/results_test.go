@@ -0,0 +1,89 @@
+package task
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDecodeTaskResultCostAnalysis(t *testing.T) {
+	// As returned by the example handler in shared/orchestrator
+	resp := &TaskResponse{Result: map[string]interface{}{
+		"account_id":        "acct-1",
+		"period":            "last_7_days",
+		"total_spend":       12500.50,
+		"potential_savings": 3200.75,
+		"recommendations": []interface{}{
+			map[string]interface{}{"type": "spot_migration", "savings": float64(2000)},
+		},
+	}}
+
+	out, err := DecodeTaskResult(TaskTypeAnalyzeCost, resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, ok := out.(*CostAnalysisResult)
+	if !ok {
+		t.Fatalf("decoded %T, want *CostAnalysisResult", out)
+	}
+	if result.TotalSpend != 12500.50 || result.PotentialSavings != 3200.75 || len(result.Recommendations) != 1 {
+		t.Errorf("decoded %+v", result)
+	}
+}
+
+func TestDecodeResultRightSize(t *testing.T) {
+	// As returned by agents/cost_agent
+	resp := &TaskResponse{Result: map[string]interface{}{
+		"total_instances":        float64(5),
+		"optimized":              float64(5),
+		"monthly_savings":        float64(1400),
+		"average_size_reduction": "38%",
+		"status":                 "completed",
+	}}
+	result, err := DecodeResult[RightSizeResult](resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Optimized != 5 || result.MonthlySavings != 1400 {
+		t.Errorf("decoded %+v", result)
+	}
+}
+
+func TestDecodeTaskResultInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		result map[string]interface{}
+		want   string
+	}{
+		{"missing field", map[string]interface{}{"total_spend": 10.0}, "missing potential_savings"},
+		{"wrong type", map[string]interface{}{"potential_savings": "lots"}, "potential_savings must be a float64"},
+	}
+	for _, tt := range tests {
+		_, err := DecodeTaskResult(TaskTypeAnalyzeCost, &TaskResponse{Result: tt.result})
+		if !errors.Is(err, ErrInvalidResult) {
+			t.Errorf("%s: err = %v, want ErrInvalidResult", tt.name, err)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want it to mention %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestGetResultField(t *testing.T) {
+	resp := &TaskResponse{Result: map[string]interface{}{
+		"migrated": float64(3),
+		"status":   "completed",
+	}}
+
+	migrated, err := GetResultField[int](resp, "migrated")
+	if err != nil || migrated != 3 {
+		t.Errorf("migrated = %d, %v; want 3", migrated, err)
+	}
+	if _, err := GetResultField[int](resp, "status"); !errors.Is(err, ErrInvalidResult) {
+		t.Errorf("status as int: err = %v, want ErrInvalidResult", err)
+	}
+	if _, err := GetResultField[string](resp, "failed"); !errors.Is(err, ErrInvalidResult) {
+		t.Errorf("missing field: err = %v, want ErrInvalidResult", err)
+	}
+}
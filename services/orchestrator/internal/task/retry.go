@@ -0,0 +1,121 @@
+package task
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+// defaultRetryPriorityBoost is the priority a retried task gains per retry
+const defaultRetryPriorityBoost TaskPriority = 2
+
+// SetRetryPriorityBoost sets the priority points a failed task gains in the
+// queue for each retry, so it is served ahead of fresh tasks of the same
+// priority. The boost applies to the queue entry only; the task keeps its
+// priority. Boosts stop at PriorityCritical, and a task is boosted at most
+// MaxRetries times, so a failing task cannot starve others. 0 disables the
+// boost.
+func (r *Router) SetRetryPriorityBoost(points int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.retryBoost = TaskPriority(points)
+}
+
+// scheduleRetry marks a failed task as retrying and re-queues it with
+// boosted priority once the retry delay has passed
+func (r *Router) scheduleRetry(task *Task, agent *registry.Agent, cause error) {
+	r.mu.Lock()
+	// The task may have been cancelled or timed out while the attempt failed
+	if isTerminal(task.Status) {
+		r.mu.Unlock()
+		return
+	}
+	task.RetryCount++
+	task.Status = TaskStatusRetrying
+	priority := task.Priority + r.retryBoostFor(task)
+	delay := r.runtime.RetryDelay
+	r.storeTask(task)
+	r.recordEvent(task, TaskEventRetry, cause.Error())
+	r.mu.Unlock()
+
+	log.Printf("Retrying task %s (attempt %d/%d, priority %d) in %s",
+		task.ID, task.RetryCount, task.MaxRetries, priority, delay)
+
+	time.AfterFunc(delay, func() {
+		r.requeueRetry(task, agent)
+	})
+}
+
+// requeueRetry puts a retrying task back in the dispatch queue, on the agent
+// it is currently assigned to unless the task is suspected to have crashed
+// that agent, in which case it moves to an agent it has not failed on
+func (r *Router) requeueRetry(task *Task, agent *registry.Agent) {
+	agent, cancelled := r.currentAssignment(task, agent)
+	if cancelled {
+		log.Printf("Task %s cancelled, not retrying: %s", task.ID, task.Error)
+		return
+	}
+
+	// Look up the agents it may move to before taking the lock
+	r.mu.RLock()
+	moving := failedOn(task, agent.ID)
+	agentType := task.AgentType
+	r.mu.RUnlock()
+	var listings agentListings
+	if moving {
+		listings = r.lookupAgentListings(r.ctx, map[string]bool{agentType: true})
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	select {
+	case <-r.stopCh:
+		return
+	default:
+	}
+	if task.Status != TaskStatusRetrying {
+		return
+	}
+
+	if moving {
+		other, err := r.pickAgentFor(listings, task, task.FailedAgents...)
+		if err == nil {
+			if task.Metadata == nil {
+				task.Metadata = make(map[string]interface{})
+			}
+			task.Metadata["retried_from"] = agent.ID
+			r.recordEvent(task, TaskEventRouted, fmt.Sprintf("retry moved from agent %s", agent.ID))
+			log.Printf("Task %s suspected of crashing agent %s, retrying on %s (%s)", task.ID, agent.ID, other.Name, other.ID)
+			agent = other
+		} else {
+			log.Printf("Task %s suspected of crashing agent %s, retrying there: %v", task.ID, agent.ID, err)
+		}
+	}
+
+	task.AgentID = agent.ID
+	task.Status = TaskStatusQueued
+	r.storeTask(task)
+	r.queue.PushBoosted(task, agent, r.retryBoostFor(task))
+}
+
+// retryBoostFor is the queue priority boost a retrying task has earned over
+// its retries so far. It must be called with r.mu held.
+func (r *Router) retryBoostFor(task *Task) TaskPriority {
+	boost := r.retryBoost * TaskPriority(task.RetryCount)
+	return boostPriority(task.Priority, boost) - task.Priority
+}
+
+// boostPriority raises a priority by boost without taking it past
+// PriorityCritical
+func boostPriority(priority, boost TaskPriority) TaskPriority {
+	if boost <= 0 || priority >= PriorityCritical {
+		return priority
+	}
+	if priority += boost; priority > PriorityCritical {
+		priority = PriorityCritical
+	}
+	return priority
+}
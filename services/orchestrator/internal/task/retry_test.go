@@ -0,0 +1,131 @@
+package task
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+func TestBoostPriority(t *testing.T) {
+	tests := []struct {
+		priority, boost, want TaskPriority
+	}{
+		{PriorityNormal, 2, PriorityNormal + 2},
+		{PriorityNormal, 0, PriorityNormal},
+		{PriorityHigh, 10, PriorityCritical},
+		{PriorityCritical, 2, PriorityCritical},
+	}
+	for _, tt := range tests {
+		if got := boostPriority(tt.priority, tt.boost); got != tt.want {
+			t.Errorf("boostPriority(%d, %d) = %d, want %d", tt.priority, tt.boost, got, tt.want)
+		}
+	}
+}
+
+func TestQueueBoostOrdersEntryOnly(t *testing.T) {
+	q := newTaskQueue(0)
+	fresh := &Task{ID: "fresh", Priority: PriorityNormal}
+	retried := &Task{ID: "retried", Priority: PriorityNormal}
+	q.Push(fresh, nil)
+	q.PushBoosted(retried, nil, 2)
+
+	if got := q.Pop().task.ID; got != "retried" {
+		t.Errorf("popped %s first, want the boosted entry", got)
+	}
+	if retried.Priority != PriorityNormal {
+		t.Errorf("boosted task priority = %d, want %d", retried.Priority, PriorityNormal)
+	}
+}
+
+func TestRetriedTaskDequeuedAheadOfFreshTasks(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	attempts := make(map[string]int)
+	gate := make(chan struct{})
+	retryable := true
+
+	agent := newAgentServer(t, func(w http.ResponseWriter, req *http.Request) {
+		var taskReq TaskRequest
+		json.NewDecoder(req.Body).Decode(&taskReq)
+		name, _ := taskReq.Parameters["name"].(string)
+
+		mu.Lock()
+		order = append(order, name)
+		attempts[name]++
+		first := attempts[name] == 1
+		mu.Unlock()
+
+		resp := TaskResponse{TaskID: taskReq.TaskID, Status: TaskStatusCompleted}
+		switch {
+		case name == "blocker":
+			<-gate
+		case name == "retried" && first:
+			resp.Status, resp.Error, resp.Retryable = TaskStatusFailed, "busy", &retryable
+		}
+		json.NewEncoder(w).Encode(resp)
+	})
+
+	// One dispatch worker so tasks queue up behind the blocker
+	cfg := DefaultConfig()
+	cfg.RetryDelay = 50 * time.Millisecond
+	reg := registry.NewRegistryWithStore(registry.NewMemoryAgentStore())
+	r := NewRouterWithConfig(NewMemoryTaskStore(), reg, cfg)
+	r.workers = 1
+	r.Start()
+	t.Cleanup(r.Stop)
+	t.Cleanup(func() {
+		select {
+		case <-gate:
+		default:
+			close(gate)
+		}
+	})
+	registerAgent(t, reg, "cost-1", registry.AgentTypeCost, agent.URL, string(TaskTypeAnalyzeCost))
+
+	named := func(name string) *TaskSubmitRequest {
+		return &TaskSubmitRequest{
+			TaskType:   TaskTypeAnalyzeCost,
+			AgentType:  "cost",
+			Parameters: map[string]interface{}{"name": name},
+			MaxRetries: 2,
+		}
+	}
+
+	retried := submit(t, r, named("retried")).TaskID
+	waitForStatus(t, r, retried, TaskStatusRetrying)
+	submit(t, r, named("blocker"))
+	var fresh []string
+	for _, name := range []string{"fresh-1", "fresh-2"} {
+		fresh = append(fresh, submit(t, r, named(name)).TaskID)
+	}
+	waitForStatus(t, r, retried, TaskStatusQueued)
+	close(gate)
+
+	waitForStatus(t, r, retried, TaskStatusCompleted)
+	for _, id := range fresh {
+		waitForStatus(t, r, id, TaskStatusCompleted)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"retried", "blocker", "retried", "fresh-1", "fresh-2"}
+	if len(order) != len(want) {
+		t.Fatalf("dispatch order %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("dispatch order %v, want %v", order, want)
+		}
+	}
+	task, err := r.getTask(retried)
+	if err != nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if task.Priority != PriorityNormal {
+		t.Errorf("retried task priority = %d, want %d unchanged", task.Priority, PriorityNormal)
+	}
+}
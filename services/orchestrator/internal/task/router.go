@@ -4,16 +4,18 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/go-redis/redis/v8"
-	"github.com/google/uuid"
 
+	"optiinfra/services/orchestrator/internal/idgen"
 	"optiinfra/services/orchestrator/internal/registry"
 )
 
@@ -25,51 +27,352 @@ const (
 	taskResultPrefix  = "task:result:"
 	
 	// Timeouts
-	defaultTaskTimeout = 30 * time.Second
-	maxTaskTimeout     = 5 * time.Minute
-	taskResultTTL      = 1 * time.Hour
-	
+	defaultTaskTimeout    = 30 * time.Second
+	defaultMaxTaskTimeout = 5 * time.Minute
+	defaultTaskResultTTL  = 1 * time.Hour
+
 	// Retry settings
 	defaultMaxRetries = 3
-	retryDelay        = 5 * time.Second
+	defaultRetryDelay = 5 * time.Second
+
+	// Dispatch settings
+	defaultDispatchWorkers   = 16
+	defaultPriorityAgingRate = 1.0 // priority points per minute waited
+
+	// Upper bound on page size for index-backed listing
+	maxTaskPageSize = 500
+
+	// Longest creation-time range a task search may cover
+	maxTaskSearchSpan = 24 * time.Hour
 )
 
+// ErrQueueFull is returned when the dispatch queue is at capacity
+var ErrQueueFull = errors.New("task queue is full")
+
 // Router handles task routing and execution
 type Router struct {
-	redis    *redis.Client
+	store    TaskStore
 	registry *registry.Registry
 	client   *http.Client
 	ctx      context.Context
 	mu       sync.RWMutex
 	tasks    map[string]*Task // in-memory task tracking
+
+	transport    TransportConfig
+	runtime      RuntimeConfig
+	orphanPolicy OrphanPolicy
+
+	queue    *taskQueue
+	workers  int
+	wg       sync.WaitGroup
+	stopCh   chan struct{}
+	stopOnce sync.Once
+
+	// Last status counted for each unfinished task this replica owns
+	countMu sync.Mutex
+	counted map[string]TaskStatus
+
+	// Moving average execution time per agent, in milliseconds
+	agentLatency map[string]float64
+
+	// Distinct failing agents after which a task is quarantined
+	poisonThreshold int
+
+	// Global kill switch for submissions and dispatch
+	pause pauseState
+
+	// Queued tasks beyond which submissions are refused; 0 means unbounded
+	maxQueued int
+
+	// Priority points a task gains each time it is re-queued for a retry
+	retryBoost TaskPriority
+
+	// Priority and timeout for tasks that omit them, by customer
+	customerDefaults map[string]CustomerDefaults
+
+	// External policy check on submissions; nil admits everything
+	admission *AdmissionWebhook
+
+	// Lower-cased result and metadata keys whose values are redacted
+	redactKeys map[string]bool
+
+	// Task types accepted besides the built-in ones, and the cap on the
+	// encoded size of a task's parameters
+	extraTaskTypes    map[TaskType]bool
+	maxParameterBytes int
+
+	// Watchdog failing or retrying tasks left unfinished past their deadline
+	timeoutScanInterval time.Duration
+	timeoutGrace        time.Duration
+	watchdogPolicy      WatchdogPolicy
+
+	// Per-agent pacing of task dispatch
+	dispatchRate *dispatchLimiter
+
+	// Timeout and retry settings fixed at construction
+	config Config
+
+	// Notified when tasks reach a terminal status, and the latest of them
+	outcomes outcomeListeners
+	recent   *recentOutcomes
+
+	// Partial results streamed by agents, by task
+	streams taskStreams
+
+	// Recent listings of agents by type
+	agentCache *agentCache
+
+	// Generates task IDs
+	ids idgen.IDGenerator
 }
 
-// NewRouter creates a new task router
+// NewRouter creates a new task router backed by Redis
 func NewRouter(redisClient *redis.Client, reg *registry.Registry) *Router {
-	return &Router{
-		redis:    redisClient,
-		registry: reg,
-		client: &http.Client{
-			Timeout: maxTaskTimeout,
+	return NewRouterWithStore(NewRedisTaskStore(redisClient), reg)
+}
+
+// NewRouterWithStore creates a new task router backed by the given store
+func NewRouterWithStore(store TaskStore, reg *registry.Registry) *Router {
+	return NewRouterWithConfig(store, reg, DefaultConfig())
+}
+
+// NewRouterWithConfig creates a new task router backed by the given store,
+// with the given timeout and retry settings. The store keeps tasks for the
+// config's ResultTTL.
+func NewRouterWithConfig(store TaskStore, reg *registry.Registry, cfg Config) *Router {
+	if s, ok := store.(resultTTLSetter); ok {
+		s.SetTTL(cfg.ResultTTL)
+	}
+
+	r := &Router{
+		store:     store,
+		registry:  reg,
+		client:    newAgentClient(DefaultTransportConfig(), cfg.MaxTaskTimeout),
+		transport: DefaultTransportConfig(),
+		ctx:       context.Background(),
+		tasks:     make(map[string]*Task),
+		queue:     newTaskQueue(defaultPriorityAgingRate),
+		workers:   defaultDispatchWorkers,
+		stopCh:    make(chan struct{}),
+		counted:   make(map[string]TaskStatus),
+
+		agentLatency:      make(map[string]float64),
+		maxParameterBytes: defaultMaxParameterBytes,
+
+		poisonThreshold: defaultPoisonThreshold,
+		retryBoost:      defaultRetryPriorityBoost,
+
+		timeoutScanInterval: defaultTimeoutScanInterval,
+		timeoutGrace:        defaultTimeoutGrace,
+		watchdogPolicy:      WatchdogFail,
+
+		dispatchRate: newDispatchLimiter(),
+		config:       cfg,
+		recent:       newRecentOutcomes(),
+
+		agentCache: newAgentCache(cfg.AgentListCacheTTL),
+		ids:        idgen.UUIDGenerator{},
+
+		orphanPolicy: OrphanCancel,
+		runtime: RuntimeConfig{
+			LoadBalancing:      LoadBalanceFirst,
+			DefaultMaxRetries:  cfg.DefaultMaxRetries,
+			RetryDelay:         cfg.RetryDelay,
+			DefaultTaskTimeout: cfg.DefaultTaskTimeout,
+			CostWeight:         defaultCostWeight,
 		},
-		ctx:   context.Background(),
-		tasks: make(map[string]*Task),
 	}
+	reg.Subscribe(r.handleRegistryEvent)
+	r.SubscribeOutcomes(r.endStream)
+	return r
 }
 
-// SubmitTask submits a new task for execution
-func (r *Router) SubmitTask(req *TaskSubmitRequest) (*TaskSubmitResponse, error) {
+// SetTransportConfig replaces the agent HTTP client with one using the given
+// connection pooling and timeout settings
+func (r *Router) SetTransportConfig(cfg TransportConfig) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	// Validate request
-	if err := r.validateTaskRequest(req); err != nil {
-		return nil, fmt.Errorf("invalid task request: %w", err)
+	r.transport = cfg
+	r.client = newAgentClient(cfg, r.config.MaxTaskTimeout)
+}
+
+// SetIDGenerator sets how task IDs are generated; it must be called before
+// any task is submitted
+func (r *Router) SetIDGenerator(ids idgen.IDGenerator) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ids = ids
+}
+
+// SetPriorityAgingRate sets how many priority points a queued task gains per
+// minute of waiting, so low-priority tasks cannot starve
+func (r *Router) SetPriorityAgingRate(pointsPerMinute float64) {
+	r.queue.SetAgingRate(pointsPerMinute)
+}
+
+// SetDispatchWorkers sets the number of concurrent dispatch workers; it must
+// be called before Start
+func (r *Router) SetDispatchWorkers(n int) {
+	if n > 0 {
+		r.workers = n
 	}
+}
 
-	// Create task
+// Start launches the dispatch workers that drain the task queue
+func (r *Router) Start() {
+	for i := 0; i < r.workers; i++ {
+		r.wg.Add(1)
+		go r.dispatchWorker()
+	}
+	r.wg.Add(1)
+	go r.countReconciler()
+	if r.timeoutScanInterval > 0 {
+		r.wg.Add(1)
+		go r.timeoutWatchdog(r.timeoutScanInterval)
+	}
+	log.Printf("Task router started with %d dispatch workers", r.workers)
+}
+
+// Stop closes the queue and waits for workers to finish their current task
+func (r *Router) Stop() {
+	r.stopOnce.Do(func() {
+		r.queue.Close()
+		close(r.stopCh)
+	})
+	r.wg.Wait()
+	log.Println("Task router stopped")
+}
+
+// SubmitTask submits a new task for execution. Cancelling ctx aborts the
+// submission until the task is stored; execution then runs detached from
+// ctx's cancellation but keeps its values.
+func (r *Router) SubmitTask(ctx context.Context, req *TaskSubmitRequest) (*TaskSubmitResponse, error) {
+	if err := r.checkSubmission(ctx, req); err != nil {
+		return nil, err
+	}
+
+	// Consult the registry before taking the lock
+	lookup, err := r.lookupAgents(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkQueueCapacity(1); err != nil {
+		return nil, err
+	}
+
+	agent, err := r.resolveAgent(req, lookup)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("submission aborted: %w", err)
+	}
+
+	task := r.newTask(ctx, req)
+	task.AgentID = agent.ID
+
+	// Store task
+	if err := r.storeTaskContext(ctx, task); err != nil {
+		task.endExecution()
+		return nil, fmt.Errorf("failed to store task: %w", err)
+	}
+	r.recordEvent(task, TaskEventSubmitted, "")
+
+	r.enqueueLocked(task, agent)
+
+	log.Printf("Task submitted: %s -> Agent: %s (%s)", task.ID, agent.Name, agent.ID)
+
+	return submitResponse(task), nil
+}
+
+// SetMaxQueuedTasks bounds how many tasks may wait for dispatch; further
+// submissions fail with ErrQueueFull until the queue drains. 0 removes the
+// bound.
+func (r *Router) SetMaxQueuedTasks(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.maxQueued = n
+}
+
+// QueueDepth returns how many tasks wait for dispatch and the queue bound,
+// 0 if unbounded. Entries left behind by tasks cancelled or reassigned while
+// queued are not counted.
+func (r *Router) QueueDepth() (int, int) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.queue.Count(func(item *queuedTask) bool { return !staleLocked(item) }), r.maxQueued
+}
+
+// checkQueueCapacity refuses n more tasks if the queue cannot take them.
+// Entries left behind by cancelled or reassigned tasks do not take up room.
+// It must be called with r.mu held.
+func (r *Router) checkQueueCapacity(n int) error {
+	if r.maxQueued <= 0 {
+		return nil
+	}
+	queued := r.queue.Count(func(item *queuedTask) bool { return !staleLocked(item) })
+	if queued+n > r.maxQueued {
+		return fmt.Errorf("%w (%d queued, max %d)", ErrQueueFull, queued, r.maxQueued)
+	}
+	return nil
+}
+
+// ValidateTask makes the checks SubmitTask makes and resolves the agent the
+// task would be sent to, without creating a task. ctx bounds the admission
+// review and registry lookup as in SubmitTask.
+func (r *Router) ValidateTask(ctx context.Context, req *TaskSubmitRequest) (*TaskValidationResponse, error) {
+	if err := r.checkSubmission(ctx, req); err != nil {
+		return nil, err
+	}
+
+	lookup, err := r.lookupAgents(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if err := r.checkQueueCapacity(1); err != nil {
+		return nil, err
+	}
+	agent, err := r.resolveAgent(req, lookup)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TaskValidationResponse{
+		Valid:     true,
+		AgentID:   agent.ID,
+		AgentName: agent.Name,
+	}, nil
+}
+
+// resolveAgent returns the requested agent, or picks an available agent of
+// the requested type, from the registry lookup made before r.mu was taken.
+// It must be called with r.mu held.
+func (r *Router) resolveAgent(req *TaskSubmitRequest, lookup *agentLookup) (*registry.Agent, error) {
+	if lookup.requested != nil {
+		return lookup.requested, nil
+	}
+
+	agent, err := r.pickAvailableAgent(lookup.ofType, capabilityRequirement(req.TaskType, req.CapabilityVersion), req.SpreadGroup)
+	if err != nil {
+		return nil, fmt.Errorf("no available agent: %w", err)
+	}
+	return agent, nil
+}
+
+// newTask builds a pending task from a submit request, applying defaults.
+// It must be called with r.mu held.
+func (r *Router) newTask(ctx context.Context, req *TaskSubmitRequest) *Task {
 	task := &Task{
-		ID:         uuid.New().String(),
+		ID:         r.ids.NewID(),
 		Type:       req.TaskType,
 		AgentType:  req.AgentType,
 		AgentID:    req.AgentID,
@@ -81,58 +384,59 @@ func (r *Router) SubmitTask(req *TaskSubmitRequest) (*TaskSubmitResponse, error)
 		MaxRetries: req.MaxRetries,
 		RetryCount: 0,
 		Metadata:   req.Metadata,
+		Chain:      req.ChainOnSuccess,
+
+		CapabilityVersion: req.CapabilityVersion,
+		SpreadGroup:       req.SpreadGroup,
+	}
+	task.ctx, task.cancel = context.WithCancel(context.WithoutCancel(ctx))
+	if parentID, ok := req.Metadata["parent_task_id"].(string); ok {
+		task.ParentTaskID = parentID
 	}
 
-	// Set defaults
+	// Set defaults: the customer's, then global
+	customer := r.customerDefaultsFor(req.Metadata)
+	if task.Priority == 0 {
+		task.Priority = customer.Priority
+	}
 	if task.Priority == 0 {
 		task.Priority = PriorityNormal
 	}
+	inheritPriority(task, req.InheritedPriority)
 	if task.Timeout == 0 {
-		task.Timeout = defaultTaskTimeout
-	}
-	if task.MaxRetries == 0 {
-		task.MaxRetries = defaultMaxRetries
-	}
-
-	// Find agent if not specified
-	var agent *registry.Agent
-	var err error
-	
-	if task.AgentID != "" {
-		// Use specified agent
-		agent, err = r.registry.GetAgent(task.AgentID)
-		if err != nil {
-			return nil, fmt.Errorf("agent not found: %w", err)
-		}
-	} else {
-		// Find available agent of correct type
-		agent, err = r.findAvailableAgent(task.AgentType, string(task.Type))
-		if err != nil {
-			return nil, fmt.Errorf("no available agent: %w", err)
+		task.Timeout = customer.Timeout
+		if task.Timeout > r.config.MaxTaskTimeout {
+			task.Timeout = r.config.MaxTaskTimeout
 		}
-		task.AgentID = agent.ID
 	}
-
-	// Store task
-	if err := r.storeTask(task); err != nil {
-		return nil, fmt.Errorf("failed to store task: %w", err)
+	if task.Timeout == 0 {
+		task.Timeout = r.runtime.DefaultTaskTimeout
+	}
+	if task.MaxRetries == 0 {
+		task.MaxRetries = r.runtime.DefaultMaxRetries
 	}
+	return task
+}
 
-	// Track in memory
+// enqueueLocked tracks a stored task and queues it for dispatch by priority.
+// It must be called with r.mu held.
+func (r *Router) enqueueLocked(task *Task, agent *registry.Agent) {
 	r.tasks[task.ID] = task
 
-	// Send task to agent asynchronously
-	go r.executeTask(task, agent)
-
-	log.Printf("Task submitted: %s -> Agent: %s (%s)", task.ID, agent.Name, agent.ID)
+	task.Status = TaskStatusQueued
+	r.storeTask(task)
+	r.recordEvent(task, TaskEventRouted, "")
+	r.queue.Push(task, agent)
+}
 
+func submitResponse(task *Task) *TaskSubmitResponse {
 	return &TaskSubmitResponse{
 		TaskID:    task.ID,
 		Status:    task.Status,
 		AgentID:   task.AgentID,
 		CreatedAt: task.CreatedAt,
 		StatusURL: fmt.Sprintf("/tasks/%s", task.ID),
-	}, nil
+	}
 }
 
 // GetTaskStatus retrieves the current status of a task
@@ -169,6 +473,38 @@ func (r *Router) ListTasks(status TaskStatus) ([]*Task, error) {
 	return tasks, nil
 }
 
+// ListTasksPage lists tasks newest first from the store's creation-time
+// index, so it sees tasks from every replica
+func (r *Router) ListTasksPage(status TaskStatus, offset, limit int) (*TaskPage, error) {
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 || limit > maxTaskPageSize {
+		limit = maxTaskPageSize
+	}
+
+	return r.store.ListTasks(r.ctx, status, offset, limit)
+}
+
+// ListTasksBetween lists tasks created in [since, until) newest first from
+// the store's creation-time index. The range must not exceed maxTaskSearchSpan.
+func (r *Router) ListTasksBetween(since, until time.Time, status TaskStatus, offset, limit int) (*TaskPage, error) {
+	if !since.Before(until) {
+		return nil, fmt.Errorf("%w: since must be before until", ErrInvalidTimeRange)
+	}
+	if until.Sub(since) > maxTaskSearchSpan {
+		return nil, fmt.Errorf("%w: cannot exceed %s", ErrInvalidTimeRange, maxTaskSearchSpan)
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 || limit > maxTaskPageSize {
+		limit = maxTaskPageSize
+	}
+
+	return r.store.ListTasksBetween(r.ctx, since, until, status, offset, limit)
+}
+
 // CancelTask cancels a pending or running task
 func (r *Router) CancelTask(taskID string) error {
 	r.mu.Lock()
@@ -179,20 +515,37 @@ func (r *Router) CancelTask(taskID string) error {
 		return fmt.Errorf("task not found")
 	}
 
-	if task.Status == TaskStatusCompleted || task.Status == TaskStatusFailed {
+	if isTerminal(task.Status) {
 		return fmt.Errorf("cannot cancel completed task")
 	}
 
+	return r.cancelTaskLocked(task, "cancelled by user")
+}
+
+// cancelTaskLocked fails a task with the given reason and removes it from
+// the index. It must be called with r.mu held.
+func (r *Router) cancelTaskLocked(task *Task, reason string) error {
+	taskID := task.ID
 	task.Status = TaskStatusFailed
-	task.Error = "cancelled by user"
+	task.Error = reason
 	now := time.Now()
 	task.CompletedAt = &now
+	task.endExecution()
 
 	if err := r.storeTask(task); err != nil {
 		return fmt.Errorf("failed to update task: %w", err)
 	}
+	if err := r.store.Unindex(r.ctx, taskID); err != nil {
+		log.Printf("Warning: failed to remove task %s from index: %v", taskID, err)
+	} else {
+		r.uncount(task)
+	}
+	r.cancelBroadcastChildren(task)
+	r.finishBroadcastChild(task)
+
+	r.recordEvent(task, TaskEventCancelled, reason)
 
-	log.Printf("Task cancelled: %s", taskID)
+	log.Printf("Task cancelled: %s (%s)", taskID, reason)
 	return nil
 }
 
@@ -200,12 +553,74 @@ func (r *Router) CancelTask(taskID string) error {
 // INTERNAL METHODS
 // ===================================================================
 
-func (r *Router) executeTask(task *Task, agent *registry.Agent) {
-	// Update status to sent
+func (r *Router) dispatchWorker() {
+	defer r.wg.Done()
+
+	for {
+		item := r.queue.Pop()
+		if item == nil {
+			return
+		}
+		if !r.waitWhilePaused() {
+			return
+		}
+
+		// Skip tasks cancelled or reassigned while queued
+		r.mu.RLock()
+		stale := staleLocked(item)
+		r.mu.RUnlock()
+		if stale {
+			if item.paced {
+				r.dispatchRate.unreserve(item.agent.ID)
+			}
+			continue
+		}
+
+		// Stay under the agent's dispatch rate by holding the task back in
+		// the queue rather than parking this worker
+		if !item.paced {
+			item.paced = true
+			if delay := r.dispatchRate.reserve(item.agent); delay > 0 {
+				r.queue.PushAfter(item, delay)
+				continue
+			}
+		}
+
+		r.executeTask(item)
+	}
+}
+
+// staleLocked reports whether a queue entry's task was cancelled or
+// reassigned after it was queued. It must be called with r.mu held.
+func staleLocked(item *queuedTask) bool {
+	return item.task.Status != TaskStatusQueued || item.task.AgentID != item.agent.ID
+}
+
+// executeTask makes one attempt at a queued task, scheduling a retry
+// through the queue if it fails and has retries left
+func (r *Router) executeTask(item *queuedTask) {
+	task, agent := item.task, item.agent
+
+	// Update status to sent, keeping the first attempt's start across
+	// retries, unless the task was cancelled or reassigned since the worker
+	// checked it
+	r.mu.Lock()
+	if staleLocked(item) {
+		r.mu.Unlock()
+		if item.paced {
+			r.dispatchRate.unreserve(agent.ID)
+		}
+		return
+	}
 	task.Status = TaskStatusSent
-	now := time.Now()
-	task.StartedAt = &now
+	if task.StartedAt == nil {
+		now := time.Now()
+		task.StartedAt = &now
+	}
+	ctx := task.executionContext(r.ctx)
 	r.storeTask(task)
+	r.recordEvent(task, TaskEventSent, "")
+	r.mu.Unlock()
 
 	// Prepare request
 	taskReq := &TaskRequest{
@@ -217,34 +632,73 @@ func (r *Router) executeTask(task *Task, agent *registry.Agent) {
 		Metadata:   task.Metadata,
 	}
 
-	// Send to agent with retries
-	var lastErr error
-	for attempt := 0; attempt <= task.MaxRetries; attempt++ {
-		if attempt > 0 {
-			log.Printf("Retrying task %s (attempt %d/%d)", task.ID, attempt, task.MaxRetries)
-			task.Status = TaskStatusRetrying
-			task.RetryCount = attempt
-			r.storeTask(task)
-			time.Sleep(retryDelay)
-		}
+	// Send task
+	response, err := r.sendTaskToAgent(ctx, agent, taskReq)
+	if ctx.Err() == nil {
+		r.registry.RecordTaskOutcome(agent.ID, err == nil)
+	}
+	if err == nil {
+		// Success
+		r.handleTaskSuccess(task, response)
+		r.submitChained(task)
+		return
+	}
+	if ctx.Err() != nil {
+		// Cancelled mid-attempt; the cancellation already recorded the outcome
+		log.Printf("Task %s cancelled during attempt: %v", task.ID, err)
+		return
+	}
 
-		// Send task
-		response, err := r.sendTaskToAgent(agent, taskReq)
-		if err == nil {
-			// Success
-			r.handleTaskSuccess(task, response)
+	if suggestsCrash(err) {
+		r.mu.Lock()
+		poisoned := r.recordAgentFailure(task, agent.ID)
+		if poisoned {
+			r.quarantineTaskLocked(task)
+		}
+		r.mu.Unlock()
+		if poisoned {
 			return
 		}
+	}
+	if isPermanent(err) {
+		log.Printf("Task %s failed with non-retryable error, not retrying: %v", task.ID, err)
+		r.handleTaskFailure(task, err)
+		return
+	}
+	log.Printf("Task %s failed: %v", task.ID, err)
 
-		lastErr = err
-		log.Printf("Task %s failed: %v", task.ID, err)
+	if task.RetryCount >= task.MaxRetries {
+		// All retries exhausted
+		r.handleTaskFailure(task, err)
+		return
 	}
+	r.scheduleRetry(task, agent, err)
+}
 
-	// All retries exhausted
-	r.handleTaskFailure(task, lastErr)
+// currentAssignment returns the agent a task should be sent to now, or
+// reports that the task was cancelled while its attempt was in flight
+func (r *Router) currentAssignment(task *Task, agent *registry.Agent) (*registry.Agent, bool) {
+	r.mu.RLock()
+	agentID := task.AgentID
+	cancelled := isTerminal(task.Status)
+	r.mu.RUnlock()
+
+	if cancelled {
+		return nil, true
+	}
+	if agentID == agent.ID {
+		return agent, false
+	}
+
+	reassigned, err := r.registry.GetAgent(agentID)
+	if err != nil {
+		log.Printf("Reassigned agent %s for task %s not found, keeping %s", agentID, task.ID, agent.ID)
+		return agent, false
+	}
+	return reassigned, false
 }
 
-func (r *Router) sendTaskToAgent(agent *registry.Agent, taskReq *TaskRequest) (*TaskResponse, error) {
+func (r *Router) sendTaskToAgent(ctx context.Context, agent *registry.Agent, taskReq *TaskRequest) (*TaskResponse, error) {
 	// Build URL
 	url := fmt.Sprintf("http://%s:%d/task", agent.Host, agent.Port)
 
@@ -254,25 +708,48 @@ func (r *Router) sendTaskToAgent(agent *registry.Agent, taskReq *TaskRequest) (*
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
+	// Bound this attempt separately from the overall client timeout
+	r.mu.RLock()
+	client := r.client
+	attemptTimeout := r.transport.AttemptTimeout
+	r.mu.RUnlock()
+
+	timeout := time.Duration(taskReq.Timeout) * time.Second
+	if attemptTimeout > 0 && (timeout == 0 || attemptTimeout < timeout) {
+		timeout = attemptTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
 	// Create HTTP request
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, "+streamContentType)
 
 	// Send request
-	resp, err := r.client.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
-	defer resp.Body.Close()
+	defer func() {
+		// Drain so the keep-alive connection can be reused
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}()
 
 	// Check status code
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("agent returned error: %d - %s", resp.StatusCode, string(bodyBytes))
+		return nil, parseAgentError(resp.StatusCode, bodyBytes)
+	}
+
+	// Agents producing incremental output stream it
+	if isStreamResponse(resp) {
+		return r.readStream(taskReq.TaskID, resp)
 	}
 
 	// Parse response
@@ -281,6 +758,16 @@ func (r *Router) sendTaskToAgent(agent *registry.Agent, taskReq *TaskRequest) (*
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
+	// A classified failure is an error even when sent with 200
+	if taskResp.Status == TaskStatusFailed && (taskResp.ErrorCode != "" || taskResp.Retryable != nil) {
+		return nil, &AgentError{
+			StatusCode: resp.StatusCode,
+			Code:       taskResp.ErrorCode,
+			Message:    taskResp.Error,
+			Retryable:  taskResp.Retryable,
+		}
+	}
+
 	return &taskResp, nil
 }
 
@@ -288,10 +775,19 @@ func (r *Router) handleTaskSuccess(task *Task, response *TaskResponse) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	// The watchdog may have timed the task out while the response was in flight
+	if isTerminal(task.Status) {
+		log.Printf("Ignoring late result for task %s (%s)", task.ID, task.Status)
+		return
+	}
+
+	response = r.redactResponse(response)
 	task.Status = TaskStatusCompleted
 	task.Result = response.Result
 	now := time.Now()
 	task.CompletedAt = &now
+	task.endExecution()
+	r.recordLatency(task.AgentID, response.ExecutionTime)
 
 	if err := r.storeTask(task); err != nil {
 		log.Printf("Failed to store task result: %v", err)
@@ -299,6 +795,8 @@ func (r *Router) handleTaskSuccess(task *Task, response *TaskResponse) {
 
 	// Store result with TTL
 	r.storeTaskResult(task.ID, response)
+	r.recordEvent(task, TaskEventCompleted, "")
+	r.finishBroadcastChild(task)
 
 	log.Printf("Task completed: %s (execution time: %dms)", task.ID, response.ExecutionTime)
 }
@@ -307,41 +805,74 @@ func (r *Router) handleTaskFailure(task *Task, err error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if isTerminal(task.Status) {
+		return
+	}
+
 	task.Status = TaskStatusFailed
 	task.Error = err.Error()
+	task.ErrorCode = errorCode(err)
 	now := time.Now()
 	task.CompletedAt = &now
+	task.endExecution()
+	if len(task.Chain) > 0 {
+		if task.Metadata == nil {
+			task.Metadata = make(map[string]interface{})
+		}
+		task.Metadata["chain_aborted"] = fmt.Sprintf("task failed, %d chained steps not submitted", len(task.Chain))
+	}
 
 	if storeErr := r.storeTask(task); storeErr != nil {
 		log.Printf("Failed to store task failure: %v", storeErr)
 	}
+	r.recordEvent(task, TaskEventFailed, task.Error)
+	r.finishBroadcastChild(task)
 
 	log.Printf("Task failed permanently: %s - %v", task.ID, err)
 }
 
-func (r *Router) findAvailableAgent(agentType string, capability string) (*registry.Agent, error) {
-	// Get agents of correct type
-	agents, err := r.registry.GetAgentsByType(registry.AgentType(agentType))
+// pickAvailableAgent picks a healthy agent for a capability among agents of
+// the task's type, skipping the excluded agents. It must be called with r.mu
+// held.
+func (r *Router) pickAvailableAgent(agents []*registry.Agent, capability string, spreadGroup string, exclude ...string) (*registry.Agent, error) {
+	availableAgents, err := healthyWithCapability(agents, capability)
 	if err != nil {
 		return nil, err
 	}
+	if len(exclude) > 0 {
+		availableAgents = withoutAgents(availableAgents, exclude)
+		if len(availableAgents) == 0 {
+			return nil, fmt.Errorf("no other healthy agents available")
+		}
+	}
+	if spreadGroup != "" {
+		availableAgents = r.leastUsedInGroup(availableAgents, spreadGroup)
+	}
 
+	// Agents optimized for the task type win over ones that merely support it
+	taskType, _ := registry.SplitCapability(capability)
+	var preferred []*registry.Agent
+	for _, agent := range availableAgents {
+		if prefersTaskType(agent, taskType) {
+			preferred = append(preferred, agent)
+		}
+	}
+	if len(preferred) > 0 {
+		availableAgents = preferred
+	}
+	return r.selectAgent(availableAgents), nil
+}
+
+// healthyWithCapability returns the healthy agents with the capability,
+// sorted by ID, in a new slice
+func healthyWithCapability(agents []*registry.Agent, capability string) ([]*registry.Agent, error) {
 	// Filter by capability and health
 	var availableAgents []*registry.Agent
 	for _, agent := range agents {
 		if agent.Status == registry.AgentStatusHealthy {
 			// Check if agent has required capability
-			if capability != "" {
-				hasCapability := false
-				for _, cap := range agent.Capabilities {
-					if cap == capability {
-						hasCapability = true
-						break
-					}
-				}
-				if !hasCapability {
-					continue
-				}
+			if capability != "" && !hasCapability(agent, capability) {
+				continue
 			}
 			availableAgents = append(availableAgents, agent)
 		}
@@ -351,9 +882,12 @@ func (r *Router) findAvailableAgent(agentType string, capability string) (*regis
 		return nil, fmt.Errorf("no healthy agents available")
 	}
 
-	// Simple round-robin: return first available
-	// TODO: Implement proper load balancing
-	return availableAgents[0], nil
+	// Registry iteration order is not stable, so sort by ID to keep the
+	// strategy's choice (and its tie-breaks) deterministic
+	sort.Slice(availableAgents, func(i, j int) bool {
+		return availableAgents[i].ID < availableAgents[j].ID
+	})
+	return availableAgents, nil
 }
 
 func (r *Router) validateTaskRequest(req *TaskSubmitRequest) error {
@@ -363,54 +897,44 @@ func (r *Router) validateTaskRequest(req *TaskSubmitRequest) error {
 	if req.AgentType == "" {
 		return fmt.Errorf("agent_type is required")
 	}
+	if err := r.validatePayload(req); err != nil {
+		return err
+	}
 	if req.Timeout < 0 {
 		return fmt.Errorf("timeout cannot be negative")
 	}
-	if req.Timeout > int(maxTaskTimeout.Seconds()) {
+	if req.Timeout > int(r.config.MaxTaskTimeout.Seconds()) {
 		return fmt.Errorf("timeout exceeds maximum allowed")
 	}
+	if req.CapabilityVersion != "" {
+		if _, err := registry.ParseVersionConstraint(req.CapabilityVersion); err != nil {
+			return err
+		}
+	}
+	if err := validateChain(req.ChainOnSuccess); err != nil {
+		return err
+	}
 	return nil
 }
 
 func (r *Router) storeTask(task *Task) error {
-	data, err := json.Marshal(task)
-	if err != nil {
-		return fmt.Errorf("failed to marshal task: %w", err)
-	}
+	return r.storeTaskContext(r.ctx, task)
+}
 
-	key := taskKeyPrefix + task.ID
-	if err := r.redis.Set(r.ctx, key, data, taskResultTTL).Err(); err != nil {
-		return fmt.Errorf("failed to store in redis: %w", err)
+func (r *Router) storeTaskContext(ctx context.Context, task *Task) error {
+	if err := r.store.SaveTask(ctx, task); err != nil {
+		return err
 	}
-
+	r.recordStatus(task)
 	return nil
 }
 
 func (r *Router) getTask(taskID string) (*Task, error) {
-	key := taskKeyPrefix + taskID
-	data, err := r.redis.Get(r.ctx, key).Result()
-	if err == redis.Nil {
-		return nil, fmt.Errorf("task not found")
-	} else if err != nil {
-		return nil, fmt.Errorf("failed to get from redis: %w", err)
-	}
-
-	var task Task
-	if err := json.Unmarshal([]byte(data), &task); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal task: %w", err)
-	}
-
-	return &task, nil
+	return r.store.GetTask(r.ctx, taskID)
 }
 
 func (r *Router) storeTaskResult(taskID string, response *TaskResponse) error {
-	data, err := json.Marshal(response)
-	if err != nil {
-		return err
-	}
-
-	key := taskResultPrefix + taskID
-	return r.redis.Set(r.ctx, key, data, taskResultTTL).Err()
+	return r.store.SaveResult(r.ctx, taskID, response)
 }
 
 func (r *Router) taskToStatusResponse(task *Task) *TaskStatusResponse {
@@ -418,11 +942,17 @@ func (r *Router) taskToStatusResponse(task *Task) *TaskStatusResponse {
 		TaskID:      task.ID,
 		Status:      task.Status,
 		AgentID:     task.AgentID,
-		Result:      task.Result,
+		Result:      r.redact(task.Result),
 		Error:       task.Error,
+		ErrorCode:   task.ErrorCode,
 		CreatedAt:   task.CreatedAt,
 		StartedAt:   task.StartedAt,
 		CompletedAt: task.CompletedAt,
 		RetryCount:  task.RetryCount,
+		Progress:    task.Progress,
+
+		ParentTaskID:  task.ParentTaskID,
+		ChainedTaskID: task.ChainedTaskID,
+		ChildTaskIDs:  task.ChildTaskIDs,
 	}
 }
@@ -0,0 +1,116 @@
+package task
+
+import (
+	"sort"
+	"testing"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+func testAgents(ids ...string) []*registry.Agent {
+	agents := make([]*registry.Agent, len(ids))
+	for i, id := range ids {
+		agents[i] = &registry.Agent{
+			ID:           id,
+			Type:         registry.AgentTypeCost,
+			Status:       registry.AgentStatusHealthy,
+			Capabilities: []string{string(TaskTypeAnalyzeCost)},
+		}
+	}
+	return agents
+}
+
+func TestPickAgentIgnoresListingOrder(t *testing.T) {
+	r, _ := newTestRouter(t)
+
+	for _, order := range [][]string{
+		{"agent-b", "agent-a", "agent-c"},
+		{"agent-c", "agent-b", "agent-a"},
+		{"agent-a", "agent-c", "agent-b"},
+	} {
+		r.mu.Lock()
+		agent, err := r.pickAvailableAgent(testAgents(order...), string(TaskTypeAnalyzeCost), "")
+		r.mu.Unlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if agent.ID != "agent-a" {
+			t.Errorf("listing %v picked %s, want agent-a", order, agent.ID)
+		}
+	}
+}
+
+func TestHealthyWithCapabilitySortsByID(t *testing.T) {
+	agents := testAgents("agent-c", "agent-a", "agent-d", "agent-b")
+	agents[2].Status = registry.AgentStatusUnhealthy
+	agents[3].Capabilities = []string{string(TaskTypeRightSize)}
+
+	got, err := healthyWithCapability(agents, string(TaskTypeAnalyzeCost))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, agent := range got {
+		ids = append(ids, agent.ID)
+	}
+	if !sort.StringsAreSorted(ids) || len(ids) != 2 || ids[0] != "agent-a" || ids[1] != "agent-c" {
+		t.Errorf("candidates = %v, want [agent-a agent-c]", ids)
+	}
+	// The caller's listing is left in its order
+	if agents[0].ID != "agent-c" {
+		t.Error("listing reordered in place")
+	}
+}
+
+func TestSubmitsRouteToSameAgent(t *testing.T) {
+	agent := newAgentServer(t, completingAgent(map[string]interface{}{"ok": true}))
+	r, reg := newTestRouter(t)
+	var ids []string
+	for _, name := range []string{"cost-1", "cost-2", "cost-3"} {
+		ids = append(ids, registerAgent(t, reg, name, registry.AgentTypeCost, agent.URL, string(TaskTypeAnalyzeCost)))
+	}
+	sort.Strings(ids)
+
+	for i := 0; i < 5; i++ {
+		id := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost"}).TaskID
+		if status := waitForStatus(t, r, id, TaskStatusCompleted); status.AgentID != ids[0] {
+			t.Errorf("task %d routed to %s, want the lowest agent ID %s", i, status.AgentID, ids[0])
+		}
+	}
+}
+
+func TestDispatchSkipsTaskCancelledAfterCheck(t *testing.T) {
+	for name, newStore := range taskStores() {
+		t.Run(name, func(t *testing.T) {
+			store := newStore(t)
+			reg := registry.NewRegistryWithStore(registry.NewMemoryAgentStore())
+			r := NewRouterWithConfig(store, reg, DefaultConfig())
+			t.Cleanup(r.Stop)
+			registerAgent(t, reg, "cost-1", registry.AgentTypeCost, blockingAgent(t), "right_size")
+
+			// The router is not dispatching, so the task stays queued
+			id := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeRightSize, AgentType: "cost"}).TaskID
+			item := r.queue.Pop()
+
+			// Cancelled between the worker's check and the attempt
+			if err := r.CancelTask(id); err != nil {
+				t.Fatal(err)
+			}
+			r.executeTask(item)
+
+			if status, _ := r.GetTaskStatus(id); status.Status != TaskStatusFailed {
+				t.Errorf("task %s after dispatch, want it left cancelled", status.Status)
+			}
+			if s := stats(t, r); s.Total != 0 || s.Counts[TaskStatusSent] != 0 {
+				t.Errorf("stats = %+v, want the cancelled task uncounted", s)
+			}
+			page, err := store.ListTasks(r.ctx, "", 0, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if page.Total != 0 {
+				t.Errorf("%d tasks indexed, want the cancelled task left out", page.Total)
+			}
+		})
+	}
+}
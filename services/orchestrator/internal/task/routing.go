@@ -0,0 +1,165 @@
+package task
+
+import (
+	"sort"
+	"time"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+// Agent metadata key holding how many tasks the agent accepts at once
+const maxConcurrentTasksKey = "max_concurrent_tasks"
+
+// knownTaskTypes lists the task types reported in the routing table
+var knownTaskTypes = []TaskType{
+	TaskTypeAnalyzeCost,
+	TaskTypeMigrateToSpot,
+	TaskTypeRightSize,
+	TaskTypeOptimizeKVCache,
+	TaskTypeTuneInference,
+	TaskTypePredictScaling,
+	TaskTypeBalanceLoad,
+	TaskTypeValidateQuality,
+	TaskTypeDetectRegression,
+}
+
+// RoutingTable is a snapshot of which agents can take each task type
+type RoutingTable struct {
+	LoadBalancing LoadBalancingStrategy         `json:"load_balancing"`
+	Routes        map[TaskType][]RouteCandidate `json:"routes"`
+	GeneratedAt   time.Time                     `json:"generated_at"`
+}
+
+// RouteCandidate is an agent advertising a task type's capability
+type RouteCandidate struct {
+	AgentID   string               `json:"agent_id"`
+	AgentName string               `json:"agent_name"`
+	AgentType registry.AgentType   `json:"agent_type"`
+	Status    registry.AgentStatus `json:"status"`
+	Eligible  bool                 `json:"eligible"` // Healthy, so tasks can be routed to it
+	Load      int                  `json:"load"`     // Unfinished tasks from this replica
+	Capacity  *int                 `json:"capacity,omitempty"`
+	Preferred bool                 `json:"preferred"` // Chosen over agents that merely support the type
+
+	// Recent success rate, once this replica has seen outcomes
+	Reliability *float64 `json:"reliability,omitempty"`
+}
+
+// RoutingTable lists, for every known task type, the agents advertising
+// that capability along with their health and load. Agents without the
+// capability are left out, as routing never considers them.
+func (r *Router) RoutingTable() (*RoutingTable, error) {
+	agents, err := r.registry.GetAllAgents()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(agents, func(i, j int) bool {
+		return agents[i].ID < agents[j].ID
+	})
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	load := r.agentLoad()
+	reliability := make(map[string]*float64, len(agents))
+	for _, agent := range agents {
+		if rel, ok := r.registry.GetAgentReliability(agent.ID); ok {
+			reliability[agent.ID] = &rel.SuccessRate
+		}
+	}
+	table := &RoutingTable{
+		LoadBalancing: r.runtime.LoadBalancing,
+		Routes:        make(map[TaskType][]RouteCandidate, len(knownTaskTypes)),
+		GeneratedAt:   time.Now(),
+	}
+	for _, taskType := range knownTaskTypes {
+		candidates := make([]RouteCandidate, 0)
+		for _, agent := range agents {
+			if !hasCapability(agent, string(taskType)) {
+				continue
+			}
+			candidates = append(candidates, RouteCandidate{
+				AgentID:   agent.ID,
+				AgentName: agent.Name,
+				AgentType: agent.Type,
+				Status:    agent.Status,
+				Eligible:  agent.Status == registry.AgentStatusHealthy,
+				Load:      load[agent.ID],
+				Capacity:  agentCapacity(agent),
+				Preferred: prefersTaskType(agent, string(taskType)),
+
+				Reliability: reliability[agent.ID],
+			})
+		}
+		table.Routes[taskType] = candidates
+	}
+	return table, nil
+}
+
+// agentLoad counts unfinished tasks per agent. It must be called with r.mu
+// held.
+func (r *Router) agentLoad() map[string]int {
+	load := make(map[string]int)
+	for _, task := range r.tasks {
+		if !isTerminal(task.Status) && task.AgentID != "" {
+			load[task.AgentID]++
+		}
+	}
+	return load
+}
+
+// hasCapability reports whether the agent meets a capability requirement,
+// "name" or "name@constraint"
+func hasCapability(agent *registry.Agent, capability string) bool {
+	return agent.HasCapability(capability)
+}
+
+// withoutAgents drops the excluded agents from the candidates
+func withoutAgents(candidates []*registry.Agent, exclude []string) []*registry.Agent {
+	kept := make([]*registry.Agent, 0, len(candidates))
+	for _, agent := range candidates {
+		excluded := false
+		for _, id := range exclude {
+			if agent.ID == id {
+				excluded = true
+				break
+			}
+		}
+		if !excluded {
+			kept = append(kept, agent)
+		}
+	}
+	return kept
+}
+
+// capabilityRequirement is the capability an agent needs for a task type,
+// constrained to versions the constraint allows when one is given
+func capabilityRequirement(taskType TaskType, versionConstraint string) string {
+	if versionConstraint == "" {
+		return string(taskType)
+	}
+	return string(taskType) + "@" + versionConstraint
+}
+
+// prefersTaskType reports whether the agent declared itself optimized for the task type
+func prefersTaskType(agent *registry.Agent, taskType string) bool {
+	for _, t := range agent.PreferredTaskTypes {
+		if t == taskType {
+			return true
+		}
+	}
+	return false
+}
+
+// agentCapacity reads max_concurrent_tasks from agent metadata, if reported
+func agentCapacity(agent *registry.Agent) *int {
+	switch v := agent.Metadata[maxConcurrentTasksKey].(type) {
+	case float64:
+		n := int(v)
+		return &n
+	case int:
+		return &v
+	default:
+		return nil
+	}
+}
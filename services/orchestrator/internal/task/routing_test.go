@@ -0,0 +1,142 @@
+package task
+
+import (
+	"net/http"
+	"testing"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+func TestRoutingTableFiltersByCapability(t *testing.T) {
+	r, reg := newTestRouter(t)
+	reg.SetDefaultCapabilities(nil)
+	host, port := hostPort(t, blockingAgent(t))
+	register := func(name string, agentType registry.AgentType, req registry.RegistrationRequest) string {
+		t.Helper()
+		req.Name, req.Type, req.Host, req.Port = name, agentType, host, port
+		resp, err := reg.Register(&req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.AgentID
+	}
+
+	cost := register("cost-1", registry.AgentTypeCost, registry.RegistrationRequest{
+		Capabilities:       []string{"analyze_cost", "right_size"},
+		PreferredTaskTypes: []string{"right_size"},
+		Metadata:           map[string]interface{}{maxConcurrentTasksKey: 4},
+	})
+	down := register("cost-2", registry.AgentTypeCost, registry.RegistrationRequest{
+		Capabilities: []string{"analyze_cost"},
+	})
+	if _, err := reg.Heartbeat(down, &registry.HeartbeatRequest{Status: registry.AgentStatusUnhealthy}); err != nil {
+		t.Fatal(err)
+	}
+	perf := register("perf-1", registry.AgentTypePerformance, registry.RegistrationRequest{
+		Capabilities: []string{"optimize_kv_cache"},
+	})
+
+	// A task held on cost-1 counts toward its load
+	held := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeRightSize, AgentType: "cost"}).TaskID
+	waitForStatus(t, r, held, TaskStatusSent)
+
+	var table RoutingTable
+	if code := getJSON(t, r, "/tasks/routing-table", &table); code != http.StatusOK {
+		t.Fatalf("status %d", code)
+	}
+	if len(table.Routes) != len(knownTaskTypes) {
+		t.Errorf("%d task types, want every known type", len(table.Routes))
+	}
+
+	routes := func(taskType TaskType) map[string]RouteCandidate {
+		byID := make(map[string]RouteCandidate)
+		for _, candidate := range table.Routes[taskType] {
+			byID[candidate.AgentID] = candidate
+		}
+		return byID
+	}
+
+	analyze := routes(TaskTypeAnalyzeCost)
+	if len(analyze) != 2 || !analyze[cost].Eligible || analyze[down].Eligible || analyze[down].Status != registry.AgentStatusUnhealthy {
+		t.Errorf("analyze_cost routes = %+v, want cost-1 eligible and unhealthy cost-2 listed but not", table.Routes[TaskTypeAnalyzeCost])
+	}
+	if c := analyze[cost]; c.Load != 1 || c.Capacity == nil || *c.Capacity != 4 || c.Preferred {
+		t.Errorf("cost-1 for analyze_cost = %+v", c)
+	}
+
+	rightSize := routes(TaskTypeRightSize)
+	if len(rightSize) != 1 || !rightSize[cost].Preferred {
+		t.Errorf("right_size routes = %+v, want only cost-1, preferred", table.Routes[TaskTypeRightSize])
+	}
+
+	kv := routes(TaskTypeOptimizeKVCache)
+	if len(kv) != 1 || !kv[perf].Eligible || kv[perf].Load != 0 || kv[perf].Capacity != nil {
+		t.Errorf("optimize_kv_cache routes = %+v, want only perf-1", table.Routes[TaskTypeOptimizeKVCache])
+	}
+
+	// Types no agent advertises are listed with no candidates
+	if candidates, ok := table.Routes[TaskTypeBalanceLoad]; !ok || len(candidates) != 0 {
+		t.Errorf("balance_load routes = %+v, want an empty list", candidates)
+	}
+}
+
+func TestPreferredAgentChosenAmongCapable(t *testing.T) {
+	r, _ := newTestRouter(t)
+	pick := func(agents []*registry.Agent) string {
+		t.Helper()
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		agent, err := r.pickAvailableAgent(agents, string(TaskTypeAnalyzeCost), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return agent.ID
+	}
+
+	agents := testAgents("agent-a", "agent-b", "agent-c")
+	agents[2].PreferredTaskTypes = []string{string(TaskTypeAnalyzeCost)}
+	if got := pick(agents); got != "agent-c" {
+		t.Errorf("picked %s, want the agent preferring the task type", got)
+	}
+
+	// A preference for another type does not count
+	agents[2].PreferredTaskTypes = []string{string(TaskTypeRightSize)}
+	if got := pick(agents); got != "agent-a" {
+		t.Errorf("picked %s, want the first capable agent", got)
+	}
+
+	// Preference never overrides health or capability
+	agents = testAgents("agent-a", "agent-b", "agent-c")
+	agents[1].PreferredTaskTypes = []string{string(TaskTypeAnalyzeCost)}
+	agents[1].Status = registry.AgentStatusUnhealthy
+	agents[2].PreferredTaskTypes = []string{string(TaskTypeAnalyzeCost)}
+	agents[2].Capabilities = []string{string(TaskTypeRightSize)}
+	if got := pick(agents); got != "agent-a" {
+		t.Errorf("picked %s, want the only healthy capable agent", got)
+	}
+}
+
+func TestSubmitsRouteToPreferredAgent(t *testing.T) {
+	agent := newAgentServer(t, completingAgent(map[string]interface{}{"ok": true}))
+	host, port := hostPort(t, agent.URL)
+	r, reg := newTestRouter(t)
+	registerAgent(t, reg, "generalist", registry.AgentTypeCost, agent.URL, "analyze_cost", "right_size")
+	resp, err := reg.Register(&registry.RegistrationRequest{
+		Name:               "specialist",
+		Type:               registry.AgentTypeCost,
+		Host:               host,
+		Port:               port,
+		Capabilities:       []string{"analyze_cost", "right_size"},
+		PreferredTaskTypes: []string{"right_size"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		id := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeRightSize, AgentType: "cost"}).TaskID
+		if status := waitForStatus(t, r, id, TaskStatusCompleted); status.AgentID != resp.AgentID {
+			t.Errorf("right_size task %d ran on %s, want the specialist", i, status.AgentID)
+		}
+	}
+}
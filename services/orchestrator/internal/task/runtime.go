@@ -0,0 +1,159 @@
+package task
+
+import (
+	"fmt"
+	"time"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+// LoadBalancingStrategy decides which of several capable agents gets a task
+type LoadBalancingStrategy string
+
+const (
+	// LoadBalanceFirst picks the healthy agent with the lowest ID
+	LoadBalanceFirst LoadBalancingStrategy = "first"
+	// LoadBalanceLeastLoaded picks the agent with the fewest unfinished tasks
+	// from this replica, breaking ties by ID
+	LoadBalanceLeastLoaded LoadBalancingStrategy = "least_loaded"
+	// LoadBalanceCheapest picks the agent with the lowest reported
+	// cost_per_task, weighed against observed latency by CostWeight
+	LoadBalanceCheapest LoadBalancingStrategy = "cheapest"
+	// LoadBalanceReliability picks the agent with the highest recent task
+	// success rate; agents without outcomes yet count as fully reliable
+	LoadBalanceReliability LoadBalancingStrategy = "reliability"
+)
+
+// RuntimeConfig holds the router settings that can be changed while running
+type RuntimeConfig struct {
+	LoadBalancing      LoadBalancingStrategy
+	DefaultMaxRetries  int
+	RetryDelay         time.Duration
+	DefaultTaskTimeout time.Duration
+	AttemptTimeout     time.Duration
+	PriorityAgingRate  float64
+
+	// Share of the cheapest strategy's score given to cost versus latency,
+	// from 0 (latency only) to 1 (cost only)
+	CostWeight float64
+}
+
+// Validate checks a runtime config before it is applied
+func (c RuntimeConfig) Validate() error {
+	switch c.LoadBalancing {
+	case LoadBalanceFirst, LoadBalanceLeastLoaded, LoadBalanceCheapest, LoadBalanceReliability:
+	default:
+		return fmt.Errorf("unknown load balancing strategy %q", c.LoadBalancing)
+	}
+	if c.DefaultMaxRetries < 0 {
+		return fmt.Errorf("default max retries cannot be negative")
+	}
+	if c.RetryDelay < 0 {
+		return fmt.Errorf("retry delay cannot be negative")
+	}
+	if c.DefaultTaskTimeout <= 0 {
+		return fmt.Errorf("default task timeout must be positive")
+	}
+	if c.AttemptTimeout < 0 {
+		return fmt.Errorf("attempt timeout cannot be negative")
+	}
+	if c.PriorityAgingRate < 0 {
+		return fmt.Errorf("priority aging rate cannot be negative")
+	}
+	if c.CostWeight < 0 || c.CostWeight > 1 {
+		return fmt.Errorf("cost weight must be between 0 and 1")
+	}
+	return nil
+}
+
+// RuntimeConfig returns the router's current runtime settings
+func (r *Router) RuntimeConfig() RuntimeConfig {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	cfg := r.runtime
+	cfg.AttemptTimeout = r.transport.AttemptTimeout
+	cfg.PriorityAgingRate = r.queue.AgingRate()
+	return cfg
+}
+
+// ValidateRuntimeConfig checks runtime settings against the router's fixed
+// limits as well as on their own
+func (r *Router) ValidateRuntimeConfig(cfg RuntimeConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	if cfg.DefaultTaskTimeout > r.config.MaxTaskTimeout {
+		return fmt.Errorf("default task timeout must be between 0 and %s", r.config.MaxTaskTimeout)
+	}
+	return nil
+}
+
+// UpdateRuntimeConfig validates and applies new runtime settings at once.
+// Tasks already submitted keep the retries and timeout they were given.
+func (r *Router) UpdateRuntimeConfig(cfg RuntimeConfig) error {
+	if err := r.ValidateRuntimeConfig(cfg); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.runtime = cfg
+	r.transport.AttemptTimeout = cfg.AttemptTimeout
+	r.queue.SetAgingRate(cfg.PriorityAgingRate)
+	return nil
+}
+
+// SetLoadBalancingStrategy sets how an agent is picked among capable agents
+func (r *Router) SetLoadBalancingStrategy(strategy LoadBalancingStrategy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runtime.LoadBalancing = strategy
+}
+
+// selectAgent applies the load balancing strategy to candidates sorted by
+// ID. It must be called with r.mu held.
+func (r *Router) selectAgent(candidates []*registry.Agent) *registry.Agent {
+	switch r.runtime.LoadBalancing {
+	case LoadBalanceLeastLoaded:
+		return r.leastLoadedAgent(candidates)
+	case LoadBalanceCheapest:
+		return r.cheapestAgent(candidates)
+	case LoadBalanceReliability:
+		return r.mostReliableAgent(candidates)
+	default:
+		return candidates[0]
+	}
+}
+
+func (r *Router) leastLoadedAgent(candidates []*registry.Agent) *registry.Agent {
+	load := r.agentLoad()
+
+	best := candidates[0]
+	for _, agent := range candidates[1:] {
+		if load[agent.ID] < load[best.ID] {
+			best = agent
+		}
+	}
+	return best
+}
+
+func (r *Router) mostReliableAgent(candidates []*registry.Agent) *registry.Agent {
+	best, bestRate := candidates[0], r.successRate(candidates[0].ID)
+	for _, agent := range candidates[1:] {
+		if rate := r.successRate(agent.ID); rate > bestRate {
+			best, bestRate = agent, rate
+		}
+	}
+	return best
+}
+
+// successRate returns the agent's decayed success rate, 1 if it has none yet
+func (r *Router) successRate(agentID string) float64 {
+	reliability, ok := r.registry.GetAgentReliability(agentID)
+	if !ok {
+		return 1
+	}
+	return reliability.SuccessRate
+}
@@ -0,0 +1,39 @@
+package task
+
+import (
+	"testing"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+func TestReliabilityStrategyPrefersSuccessfulAgents(t *testing.T) {
+	r, reg := newTestRouter(t)
+	r.SetLoadBalancingStrategy(LoadBalanceReliability)
+	pick := func(candidates []*registry.Agent) string {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return r.selectAgent(candidates).ID
+	}
+	candidates := testAgents("agent-a", "agent-b", "agent-c")
+
+	// agent-a fails most tasks, agent-b most succeed
+	for i := 0; i < 4; i++ {
+		reg.RecordTaskOutcome("agent-a", i == 0)
+		reg.RecordTaskOutcome("agent-b", i != 0)
+	}
+	// An agent without outcomes counts as fully reliable
+	if got := pick(candidates); got != "agent-c" {
+		t.Errorf("picked %s, want the agent without failures", got)
+	}
+	if got := pick(candidates[:2]); got != "agent-b" {
+		t.Errorf("picked %s, want the agent with the higher success rate", got)
+	}
+
+	// Failures on agent-b turn the choice around
+	for i := 0; i < 10; i++ {
+		reg.RecordTaskOutcome("agent-b", false)
+	}
+	if got := pick(candidates[:2]); got != "agent-a" {
+		t.Errorf("picked %s after agent-b failed, want agent-a", got)
+	}
+}
@@ -0,0 +1,207 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+const (
+	// How long a submission may wait on the registry to pick an agent
+	defaultAgentSelectionTimeout = 2 * time.Second
+
+	// How long a listing of agents by type is reused for selection
+	defaultAgentListCacheTTL = time.Second
+)
+
+// ErrAgentSelectionTimeout is returned when the registry does not answer in
+// time for a submission to pick an agent
+var ErrAgentSelectionTimeout = errors.New("agent selection timed out")
+
+// agentLookup holds what selecting an agent for a submission needs from the
+// registry, fetched before r.mu is taken
+type agentLookup struct {
+	requested *registry.Agent   // The agent named by the request, if any
+	ofType    []*registry.Agent // Otherwise the agents of the requested type
+}
+
+// agentCache shares recent listings of agents by type between submissions,
+// with one registry lookup in flight per type
+type agentCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[registry.AgentType]*agentCacheEntry
+}
+
+type agentCacheEntry struct {
+	ready     chan struct{} // Closed once the lookup finished
+	agents    []*registry.Agent
+	err       error
+	fetchedAt time.Time
+}
+
+func newAgentCache(ttl time.Duration) *agentCache {
+	return &agentCache{ttl: ttl, entries: make(map[registry.AgentType]*agentCacheEntry)}
+}
+
+// get returns the cached agents of a type, calling fetch when the listing is
+// missing, stale or failed. Callers arriving during a fetch wait for it.
+// The returned agents are shared and must not be modified.
+func (c *agentCache) get(agentType registry.AgentType, fetch func() ([]*registry.Agent, error)) ([]*registry.Agent, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[agentType]
+	if ok {
+		select {
+		case <-entry.ready:
+			if entry.err != nil || time.Since(entry.fetchedAt) > c.ttl {
+				ok = false
+			}
+		default:
+		}
+	}
+	if !ok {
+		entry = &agentCacheEntry{ready: make(chan struct{})}
+		c.entries[agentType] = entry
+		c.mu.Unlock()
+
+		entry.agents, entry.err = fetch()
+		entry.fetchedAt = time.Now()
+		close(entry.ready)
+		return entry.agents, entry.err
+	}
+	c.mu.Unlock()
+
+	<-entry.ready
+	return entry.agents, entry.err
+}
+
+// invalidate drops every cached listing
+func (c *agentCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[registry.AgentType]*agentCacheEntry)
+}
+
+// agentsOfType returns the registered agents of a type, reusing a recent
+// listing
+func (r *Router) agentsOfType(agentType string) ([]*registry.Agent, error) {
+	t := registry.AgentType(agentType)
+	return r.agentCache.get(t, func() ([]*registry.Agent, error) {
+		return r.registry.GetAgentsByType(t)
+	})
+}
+
+// lookupAgents fetches the agents a submission may be routed to, giving up
+// after the selection timeout. It must be called without r.mu held, so a
+// slow registry store holds up only this submission.
+func (r *Router) lookupAgents(ctx context.Context, req *TaskSubmitRequest) (*agentLookup, error) {
+	var lookup *agentLookup
+	err := r.withSelectionTimeout(ctx, func() error {
+		if req.AgentID != "" {
+			agent, err := r.registry.GetAgent(req.AgentID)
+			if err != nil {
+				return fmt.Errorf("agent not found: %w", err)
+			}
+			// Tasks for a replaced agent go to its successor
+			lookup = &agentLookup{requested: r.successorOf(agent)}
+			return nil
+		}
+		agents, err := r.agentsOfType(req.AgentType)
+		if err != nil {
+			return fmt.Errorf("no available agent: %w", err)
+		}
+		lookup = &agentLookup{ofType: agents}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return lookup, nil
+}
+
+// lookupAgentsOfType returns the registered agents of a type as
+// lookupAgents does. It must be called without r.mu held.
+func (r *Router) lookupAgentsOfType(ctx context.Context, agentType string) ([]*registry.Agent, error) {
+	var agents []*registry.Agent
+	err := r.withSelectionTimeout(ctx, func() error {
+		var err error
+		agents, err = r.agentsOfType(agentType)
+		if err != nil {
+			return fmt.Errorf("no available agent: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return agents, nil
+}
+
+// agentListings holds the agents of each type looked up before r.mu is
+// taken, or the error the lookup failed with
+type agentListings map[string]agentListing
+
+type agentListing struct {
+	agents []*registry.Agent
+	err    error
+}
+
+// lookupAgentListings looks up the agents of each type for tasks that need
+// another agent. It must be called without r.mu held.
+func (r *Router) lookupAgentListings(ctx context.Context, agentTypes map[string]bool) agentListings {
+	listings := make(agentListings, len(agentTypes))
+	for agentType := range agentTypes {
+		agents, err := r.lookupAgentsOfType(ctx, agentType)
+		listings[agentType] = agentListing{agents: agents, err: err}
+	}
+	return listings
+}
+
+// pickAgentFor picks an available agent for a task from the listing of its
+// type, skipping the excluded agents. It must be called with r.mu held.
+func (r *Router) pickAgentFor(listings agentListings, task *Task, exclude ...string) (*registry.Agent, error) {
+	listing, ok := listings[task.AgentType]
+	if !ok {
+		return nil, fmt.Errorf("no available agent: agents of type %s were not looked up", task.AgentType)
+	}
+	if listing.err != nil {
+		return nil, listing.err
+	}
+	agent, err := r.pickAvailableAgent(listing.agents, capabilityRequirement(task.Type, task.CapabilityVersion), task.SpreadGroup, exclude...)
+	if err != nil {
+		return nil, fmt.Errorf("no available agent: %w", err)
+	}
+	return agent, nil
+}
+
+// withSelectionTimeout runs a registry lookup, giving up after the selection
+// timeout or when ctx ends. An abandoned lookup finishes in the background,
+// so it must only write results the caller reads on success.
+func (r *Router) withSelectionTimeout(ctx context.Context, lookup func() error) error {
+	timeout := r.config.AgentSelectionTimeout
+	parent := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- lookup()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		if err := parent.Err(); err != nil {
+			return fmt.Errorf("submission aborted: %w", err)
+		}
+		return fmt.Errorf("%w after %s", ErrAgentSelectionTimeout, timeout)
+	}
+}
@@ -0,0 +1,186 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+// stallingStore is an agent store whose agent listings block while stalled,
+// as a slow registry backend would
+type stallingStore struct {
+	registry.AgentStore
+
+	mu      sync.Mutex
+	release chan struct{} // Non-nil while stalled
+}
+
+func newStallingStore() *stallingStore {
+	return &stallingStore{AgentStore: registry.NewMemoryAgentStore()}
+}
+
+// stall blocks listings until the test ends or the returned func is called
+func (s *stallingStore) stall(t *testing.T) func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	release := make(chan struct{})
+	s.release = release
+	var once sync.Once
+	unstall := func() {
+		once.Do(func() {
+			s.mu.Lock()
+			s.release = nil
+			s.mu.Unlock()
+			close(release)
+		})
+	}
+	t.Cleanup(unstall)
+	return unstall
+}
+
+func (s *stallingStore) ActiveAgentIDs(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	release := s.release
+	s.mu.Unlock()
+	if release != nil {
+		<-release
+	}
+	return s.AgentStore.ActiveAgentIDs(ctx)
+}
+
+// newStallingRouter returns a started router over a registry backed by a
+// stallingStore, with the agent listing cache off
+func newStallingRouter(t *testing.T, selectionTimeout time.Duration) (*Router, *registry.Registry, *stallingStore) {
+	t.Helper()
+	store := newStallingStore()
+	reg := registry.NewRegistryWithStore(store)
+	cfg := DefaultConfig()
+	cfg.RetryDelay = 10 * time.Millisecond
+	cfg.AgentSelectionTimeout = selectionTimeout
+	cfg.AgentListCacheTTL = 0
+	r := NewRouterWithConfig(NewMemoryTaskStore(), reg, cfg)
+	r.Start()
+	t.Cleanup(r.Stop)
+	return r, reg, store
+}
+
+// lockFree fails the test unless r.mu can be taken promptly
+func lockFree(t *testing.T, r *Router) {
+	t.Helper()
+	locked := make(chan struct{})
+	go func() {
+		r.mu.Lock()
+		r.mu.Unlock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatal("router lock held during a registry lookup")
+	}
+}
+
+func TestAgentSelectionHonorsDeadline(t *testing.T) {
+	r, reg, store := newStallingRouter(t, 50*time.Millisecond)
+	registerAgent(t, reg, "analyzer", registry.AgentTypeCost, "", string(TaskTypeAnalyzeCost))
+	store.stall(t)
+
+	req := &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost"}
+	calls := map[string]func() error{
+		"submit": func() error {
+			_, err := r.SubmitTask(context.Background(), req)
+			return err
+		},
+		"validate": func() error {
+			_, err := r.ValidateTask(context.Background(), req)
+			return err
+		},
+		"broadcast": func() error {
+			_, err := r.BroadcastTask(context.Background(), req)
+			return err
+		},
+	}
+	for name, call := range calls {
+		start := time.Now()
+		err := call()
+		if !errors.Is(err, ErrAgentSelectionTimeout) {
+			t.Errorf("%s: err = %v, want ErrAgentSelectionTimeout", name, err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("%s: returned after %v, want about the 50ms deadline", name, elapsed)
+		}
+	}
+}
+
+func TestStalledLookupDoesNotSerializeSubmits(t *testing.T) {
+	r, reg, store := newStallingRouter(t, 0)
+	registerAgent(t, reg, "analyzer", registry.AgentTypeCost, "", string(TaskTypeAnalyzeCost))
+	unstall := store.stall(t)
+
+	// Submissions stalled on the registry hold up neither each other nor
+	// anything else needing the router
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := r.SubmitTask(context.Background(), &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost"})
+			errs <- err
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	lockFree(t, r)
+	if _, err := r.ListTasks(""); err != nil {
+		t.Fatalf("list tasks while submissions are stalled: %v", err)
+	}
+
+	unstall()
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("submit: %v", err)
+		}
+	}
+}
+
+func TestStalledLookupDoesNotHoldLockForOrphansOrWatchdog(t *testing.T) {
+	// The agent holds the task without answering
+	hold := make(chan struct{})
+	agent := newAgentServer(t, func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-hold:
+		case <-req.Context().Done():
+		}
+	})
+	t.Cleanup(func() { close(hold) })
+	r, reg, store := newStallingRouter(t, 0)
+	r.SetOrphanPolicy(OrphanReassign)
+	r.SetWatchdogPolicy(WatchdogRetryElsewhere)
+	agentID := registerAgent(t, reg, "analyzer", registry.AgentTypeCost, agent.URL, string(TaskTypeAnalyzeCost))
+	id := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost"}).TaskID
+	waitForStatus(t, r, id, TaskStatusSent)
+	unstall := store.stall(t)
+
+	done := make(chan struct{})
+	go func() {
+		r.handleAgentUnregistered(agentID)
+		r.expireOverdueTasks(time.Now().Add(24 * time.Hour))
+		close(done)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	lockFree(t, r)
+
+	unstall()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("orphan handling and watchdog did not finish")
+	}
+}
@@ -0,0 +1,30 @@
+package task
+
+import "optiinfra/services/orchestrator/internal/registry"
+
+// leastUsedInGroup keeps the candidates holding the fewest tasks of the
+// spread group, finished or not, so no agent gets a second task of the group
+// until every candidate has one. It must be called with r.mu held.
+func (r *Router) leastUsedInGroup(candidates []*registry.Agent, group string) []*registry.Agent {
+	used := make(map[string]int)
+	for _, task := range r.tasks {
+		if task.SpreadGroup == group && task.AgentID != "" {
+			used[task.AgentID]++
+		}
+	}
+
+	fewest := used[candidates[0].ID]
+	for _, agent := range candidates[1:] {
+		if used[agent.ID] < fewest {
+			fewest = used[agent.ID]
+		}
+	}
+
+	least := make([]*registry.Agent, 0, len(candidates))
+	for _, agent := range candidates {
+		if used[agent.ID] == fewest {
+			least = append(least, agent)
+		}
+	}
+	return least
+}
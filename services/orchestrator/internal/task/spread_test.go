@@ -0,0 +1,43 @@
+package task
+
+import (
+	"fmt"
+	"testing"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+func TestSpreadGroupUsesEveryAgentBeforeReuse(t *testing.T) {
+	agent := newAgentServer(t, completingAgent(map[string]interface{}{"ok": true}))
+	r, reg := newTestRouter(t)
+	// Without spreading, every task would go to the first agent
+	r.SetLoadBalancingStrategy(LoadBalanceFirst)
+	var agentIDs []string
+	for i := 1; i <= 3; i++ {
+		agentIDs = append(agentIDs, registerAgent(t, reg, fmt.Sprintf("cost-%d", i), registry.AgentTypeCost, agent.URL, "analyze_cost"))
+	}
+
+	// Another group's tasks do not count against the agents
+	other := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost", SpreadGroup: "other"})
+
+	perAgent := make(map[string]int)
+	for i := 0; i < 7; i++ {
+		resp := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost", SpreadGroup: "replicas"})
+		perAgent[resp.AgentID]++
+
+		// Each round of three covers every agent once
+		if round := i/3 + 1; perAgent[resp.AgentID] > round {
+			t.Fatalf("task %d reused agent %s before every agent had %d", i, resp.AgentID, round)
+		}
+	}
+	for _, id := range agentIDs {
+		if n := perAgent[id]; n < 2 || n > 3 {
+			t.Errorf("agent %s got %d of 7 tasks, want 2 or 3", id, n)
+		}
+	}
+
+	// Ungrouped tasks follow the load balancing strategy alone
+	if resp := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost"}); resp.AgentID != other.AgentID {
+		t.Errorf("ungrouped task went to %s, want the first agent %s", resp.AgentID, other.AgentID)
+	}
+}
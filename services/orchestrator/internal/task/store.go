@@ -0,0 +1,54 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrTaskNotFound is returned by a TaskStore when a task does not exist
+var ErrTaskNotFound = errors.New("task not found")
+
+// ErrInvalidTimeRange is returned when a task search range is empty or too wide
+var ErrInvalidTimeRange = errors.New("invalid time range")
+
+// TaskPage is one page of tasks read from the creation-time index
+type TaskPage struct {
+	Tasks      []*Task
+	Total      int64 // Tasks in the index (or time range), before status filtering
+	NextOffset int   // Offset to pass for the next page; -1 when exhausted
+}
+
+// TaskStore persists tasks and their results
+type TaskStore interface {
+	// SaveTask stores a task and adds it to the creation-time index
+	SaveTask(ctx context.Context, task *Task) error
+	// GetTask loads a task, returning ErrTaskNotFound if it does not exist
+	GetTask(ctx context.Context, taskID string) (*Task, error)
+	// SaveResult stores the raw agent response for a task
+	SaveResult(ctx context.Context, taskID string, response *TaskResponse) error
+	// Unindex removes a task from the creation-time index
+	Unindex(ctx context.Context, taskID string) error
+	// ListTasks pages through tasks newest first, optionally filtered by status
+	ListTasks(ctx context.Context, status TaskStatus, offset, limit int) (*TaskPage, error)
+	// ListTasksBetween is ListTasks restricted to tasks created in [since, until)
+	ListTasksBetween(ctx context.Context, since, until time.Time, status TaskStatus, offset, limit int) (*TaskPage, error)
+
+	// RecordTransition moves one task between status counters; an empty
+	// status means the task is entering or leaving the index
+	RecordTransition(ctx context.Context, from, to TaskStatus) error
+	// StatusCounts returns the current per-status counters
+	StatusCounts(ctx context.Context) (map[TaskStatus]int64, error)
+	// ReconcileCounts recounts statuses from the index and overwrites the counters
+	ReconcileCounts(ctx context.Context) (map[TaskStatus]int64, error)
+
+	// AddDeadLetter keeps a copy of a quarantined task for deadLetterTTL
+	AddDeadLetter(ctx context.Context, task *Task) error
+	// ListDeadLetters returns up to limit quarantined tasks, newest first
+	ListDeadLetters(ctx context.Context, limit int) ([]*Task, error)
+
+	// AppendEvent adds an event to the task's log, keeping the latest maxTaskEvents
+	AppendEvent(ctx context.Context, taskID string, event TaskEvent) error
+	// ListEvents returns the task's event log, oldest first
+	ListEvents(ctx context.Context, taskID string) ([]TaskEvent, error)
+}
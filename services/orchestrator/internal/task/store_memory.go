@@ -0,0 +1,307 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryTaskStore is an in-process TaskStore for single-replica deployments
+// and tests. Tasks are deep-copied on save and load, and expire after the
+// same TTL the Redis store uses.
+type MemoryTaskStore struct {
+	mu        sync.RWMutex
+	ttl       time.Duration
+	now       func() time.Time
+	tasks     map[string]memoryEntry
+	results   map[string]memoryEntry
+	unindexed map[string]bool
+	counts    map[TaskStatus]int64
+	dead      map[string]memoryEntry
+	events    map[string]*memoryEvents
+}
+
+type memoryEntry struct {
+	data      []byte
+	createdAt time.Time
+	expiresAt time.Time
+}
+
+type memoryEvents struct {
+	events    []TaskEvent
+	expiresAt time.Time
+}
+
+// NewMemoryTaskStore creates an in-memory task store
+func NewMemoryTaskStore() *MemoryTaskStore {
+	return &MemoryTaskStore{
+		ttl:       defaultTaskResultTTL,
+		now:       time.Now,
+		tasks:     make(map[string]memoryEntry),
+		results:   make(map[string]memoryEntry),
+		unindexed: make(map[string]bool),
+		counts:    make(map[TaskStatus]int64),
+		dead:      make(map[string]memoryEntry),
+		events:    make(map[string]*memoryEvents),
+	}
+}
+
+// SaveTask stores a copy of the task and indexes it
+func (s *MemoryTaskStore) SaveTask(ctx context.Context, task *Task) error {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tasks[task.ID] = memoryEntry{
+		data:      data,
+		createdAt: task.CreatedAt,
+		expiresAt: s.now().Add(s.ttl),
+	}
+	delete(s.unindexed, task.ID)
+	return nil
+}
+
+// GetTask returns a copy of a stored task
+func (s *MemoryTaskStore) GetTask(ctx context.Context, taskID string) (*Task, error) {
+	s.mu.RLock()
+	entry, ok := s.tasks[taskID]
+	s.mu.RUnlock()
+
+	if !ok || !s.now().Before(entry.expiresAt) {
+		return nil, ErrTaskNotFound
+	}
+
+	var task Task
+	if err := json.Unmarshal(entry.data, &task); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal task: %w", err)
+	}
+	return &task, nil
+}
+
+// SaveResult stores a copy of the agent response
+func (s *MemoryTaskStore) SaveResult(ctx context.Context, taskID string, response *TaskResponse) error {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.results[taskID] = memoryEntry{data: data, expiresAt: s.now().Add(s.ttl)}
+	return nil
+}
+
+// Unindex hides a task from listings without deleting it
+func (s *MemoryTaskStore) Unindex(ctx context.Context, taskID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.unindexed[taskID] = true
+	return nil
+}
+
+// ListTasks pages through indexed tasks newest first
+func (s *MemoryTaskStore) ListTasks(ctx context.Context, status TaskStatus, offset, limit int) (*TaskPage, error) {
+	return s.listIndexed(status, offset, limit, func(time.Time) bool { return true }), nil
+}
+
+// ListTasksBetween pages newest first through indexed tasks created in
+// [since, until), compared at millisecond resolution like the Redis index
+func (s *MemoryTaskStore) ListTasksBetween(ctx context.Context, since, until time.Time, status TaskStatus, offset, limit int) (*TaskPage, error) {
+	from, to := since.UnixMilli(), until.UnixMilli()
+	return s.listIndexed(status, offset, limit, func(createdAt time.Time) bool {
+		ms := createdAt.UnixMilli()
+		return ms >= from && ms < to
+	}), nil
+}
+
+// listIndexed pages through indexed tasks newest first whose creation time
+// passes include
+func (s *MemoryTaskStore) listIndexed(status TaskStatus, offset, limit int, include func(time.Time) bool) *TaskPage {
+	s.mu.Lock()
+	now := s.now()
+	for id, entry := range s.tasks {
+		if !now.Before(entry.expiresAt) {
+			delete(s.tasks, id)
+			delete(s.results, id)
+			delete(s.unindexed, id)
+			delete(s.events, id)
+		}
+	}
+
+	type indexed struct {
+		id        string
+		createdAt time.Time
+		data      []byte
+	}
+	index := make([]indexed, 0, len(s.tasks))
+	for id, entry := range s.tasks {
+		if s.unindexed[id] || !include(entry.createdAt) {
+			continue
+		}
+		index = append(index, indexed{id: id, createdAt: entry.createdAt, data: entry.data})
+	}
+	s.mu.Unlock()
+
+	sort.Slice(index, func(i, j int) bool {
+		if !index[i].createdAt.Equal(index[j].createdAt) {
+			return index[i].createdAt.After(index[j].createdAt)
+		}
+		return index[i].id > index[j].id
+	})
+
+	page := &TaskPage{
+		Tasks:      make([]*Task, 0, limit),
+		Total:      int64(len(index)),
+		NextOffset: -1,
+	}
+
+	position := offset
+	for ; position < len(index) && len(page.Tasks) < limit; position++ {
+		var task Task
+		if err := json.Unmarshal(index[position].data, &task); err != nil {
+			continue
+		}
+		if status != "" && task.Status != status {
+			continue
+		}
+		page.Tasks = append(page.Tasks, &task)
+	}
+
+	if position < len(index) {
+		page.NextOffset = position
+	}
+
+	return page
+}
+
+// RecordTransition moves one task between status counters
+func (s *MemoryTaskStore) RecordTransition(ctx context.Context, from, to TaskStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if from != "" {
+		s.counts[from]--
+	}
+	if to != "" {
+		s.counts[to]++
+	}
+	return nil
+}
+
+// StatusCounts returns a copy of the per-status counters
+func (s *MemoryTaskStore) StatusCounts(ctx context.Context) (map[TaskStatus]int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[TaskStatus]int64, len(s.counts))
+	for status, n := range s.counts {
+		if n > 0 {
+			counts[status] = n
+		}
+	}
+	return counts, nil
+}
+
+// ReconcileCounts recounts statuses from the index and replaces the counters
+func (s *MemoryTaskStore) ReconcileCounts(ctx context.Context) (map[TaskStatus]int64, error) {
+	counts, err := countStatuses(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.counts = make(map[TaskStatus]int64, len(counts))
+	for status, n := range counts {
+		s.counts[status] = n
+	}
+	return counts, nil
+}
+
+// AddDeadLetter keeps a copy of a quarantined task
+func (s *MemoryTaskStore) AddDeadLetter(ctx context.Context, task *Task) error {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.dead[task.ID] = memoryEntry{data: data, createdAt: now, expiresAt: now.Add(deadLetterTTL)}
+	return nil
+}
+
+// ListDeadLetters returns quarantined tasks newest first
+func (s *MemoryTaskStore) ListDeadLetters(ctx context.Context, limit int) ([]*Task, error) {
+	s.mu.Lock()
+	now := s.now()
+	entries := make([]memoryEntry, 0, len(s.dead))
+	for id, entry := range s.dead {
+		if !now.Before(entry.expiresAt) {
+			delete(s.dead, id)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].createdAt.After(entries[j].createdAt)
+	})
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	tasks := make([]*Task, 0, len(entries))
+	for _, entry := range entries {
+		var task Task
+		if err := json.Unmarshal(entry.data, &task); err != nil {
+			continue
+		}
+		tasks = append(tasks, &task)
+	}
+	return tasks, nil
+}
+
+// AppendEvent adds an event to the task's log, dropping the oldest beyond
+// maxTaskEvents
+func (s *MemoryTaskStore) AppendEvent(ctx context.Context, taskID string, event TaskEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.events[taskID]
+	if !ok || !s.now().Before(entry.expiresAt) {
+		entry = &memoryEvents{}
+		s.events[taskID] = entry
+	}
+	entry.events = append(entry.events, event)
+	if len(entry.events) > maxTaskEvents {
+		entry.events = entry.events[len(entry.events)-maxTaskEvents:]
+	}
+	entry.expiresAt = s.now().Add(s.ttl)
+	return nil
+}
+
+// ListEvents returns a copy of the task's event log
+func (s *MemoryTaskStore) ListEvents(ctx context.Context, taskID string) ([]TaskEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.events[taskID]
+	if !ok || !s.now().Before(entry.expiresAt) {
+		return []TaskEvent{}, nil
+	}
+	return append([]TaskEvent(nil), entry.events...), nil
+}
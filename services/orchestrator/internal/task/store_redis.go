@@ -0,0 +1,359 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"optiinfra/services/orchestrator/internal/codec"
+)
+
+const (
+	// Sorted set of task IDs scored by creation time (unix milliseconds)
+	taskCreatedIndexKey = "tasks:index:created"
+
+	// Hash of status -> number of indexed tasks in that status
+	taskStatusCountsKey = "tasks:counts:status"
+
+	// Quarantined task copies and their index scored by quarantine time
+	taskDeadLetterPrefix   = "task:deadletter:"
+	taskDeadLetterIndexKey = "tasks:deadletter"
+
+	// Stream of lifecycle events per task
+	taskEventsPrefix = "task:events:"
+)
+
+// RedisTaskStore stores tasks as JSON in Redis with a creation-time index
+type RedisTaskStore struct {
+	redis *redis.Client
+	ttl   time.Duration
+}
+
+// NewRedisTaskStore creates a Redis-backed task store
+func NewRedisTaskStore(redisClient *redis.Client) *RedisTaskStore {
+	return &RedisTaskStore{
+		redis: redisClient,
+		ttl:   defaultTaskResultTTL,
+	}
+}
+
+// SaveTask stores a task with TTL and indexes it by creation time
+func (s *RedisTaskStore) SaveTask(ctx context.Context, task *Task) error {
+	data, err := codec.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task: %w", err)
+	}
+
+	pipe := s.redis.TxPipeline()
+	pipe.Set(ctx, taskKeyPrefix+task.ID, data, s.ttl)
+	pipe.ZAdd(ctx, taskCreatedIndexKey, &redis.Z{
+		Score:  float64(task.CreatedAt.UnixMilli()),
+		Member: task.ID,
+	})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to store in redis: %w", err)
+	}
+
+	return nil
+}
+
+// GetTask loads a task from Redis
+func (s *RedisTaskStore) GetTask(ctx context.Context, taskID string) (*Task, error) {
+	data, err := s.redis.Get(ctx, taskKeyPrefix+taskID).Result()
+	if err == redis.Nil {
+		return nil, ErrTaskNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get from redis: %w", err)
+	}
+
+	var task Task
+	if err := codec.Unmarshal([]byte(data), &task); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal task: %w", err)
+	}
+
+	return &task, nil
+}
+
+// SaveResult stores the raw agent response with TTL
+func (s *RedisTaskStore) SaveResult(ctx context.Context, taskID string, response *TaskResponse) error {
+	data, err := codec.Marshal(response)
+	if err != nil {
+		return err
+	}
+
+	return s.redis.Set(ctx, taskResultPrefix+taskID, data, s.ttl).Err()
+}
+
+// Unindex removes a task from the creation-time index
+func (s *RedisTaskStore) Unindex(ctx context.Context, taskID string) error {
+	return s.redis.ZRem(ctx, taskCreatedIndexKey, taskID).Err()
+}
+
+// ListTasks reads the creation-time index newest first, so it sees tasks from
+// every replica without scanning the keyspace. When a status filter is given,
+// index entries are read in batches until the page is full.
+func (s *RedisTaskStore) ListTasks(ctx context.Context, status TaskStatus, offset, limit int) (*TaskPage, error) {
+	s.pruneIndex(ctx)
+
+	total, err := s.redis.ZCard(ctx, taskCreatedIndexKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read task index: %w", err)
+	}
+
+	return s.loadPage(ctx, status, offset, limit, total, func(start, count int) ([]string, error) {
+		return s.redis.ZRevRange(ctx, taskCreatedIndexKey, int64(start), int64(start+count-1)).Result()
+	})
+}
+
+// ListTasksBetween pages newest first through tasks created in [since, until)
+// using a score range on the creation-time index
+func (s *RedisTaskStore) ListTasksBetween(ctx context.Context, since, until time.Time, status TaskStatus, offset, limit int) (*TaskPage, error) {
+	s.pruneIndex(ctx)
+
+	min := strconv.FormatInt(since.UnixMilli(), 10)
+	max := "(" + strconv.FormatInt(until.UnixMilli(), 10)
+	total, err := s.redis.ZCount(ctx, taskCreatedIndexKey, min, max).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read task index: %w", err)
+	}
+
+	return s.loadPage(ctx, status, offset, limit, total, func(start, count int) ([]string, error) {
+		return s.redis.ZRevRangeByScore(ctx, taskCreatedIndexKey, &redis.ZRangeBy{
+			Min:    min,
+			Max:    max,
+			Offset: int64(start),
+			Count:  int64(count),
+		}).Result()
+	})
+}
+
+// pruneIndex drops index entries for tasks whose keys have expired
+func (s *RedisTaskStore) pruneIndex(ctx context.Context) {
+	cutoff := time.Now().Add(-s.ttl).UnixMilli()
+	if err := s.redis.ZRemRangeByScore(ctx, taskCreatedIndexKey, "-inf", "("+strconv.FormatInt(cutoff, 10)).Err(); err != nil {
+		log.Printf("Warning: failed to prune task index: %v", err)
+	}
+}
+
+// loadPage reads index entries in batches from fetch, which returns count IDs
+// starting at a position, and loads tasks until the page is full
+func (s *RedisTaskStore) loadPage(ctx context.Context, status TaskStatus, offset, limit int, total int64, fetch func(start, count int) ([]string, error)) (*TaskPage, error) {
+	page := &TaskPage{
+		Tasks:      make([]*Task, 0, limit),
+		Total:      total,
+		NextOffset: -1,
+	}
+
+	position := offset
+	for len(page.Tasks) < limit && int64(position) < total {
+		ids, err := fetch(position, limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read task index: %w", err)
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		keys := make([]string, len(ids))
+		for i, id := range ids {
+			keys[i] = taskKeyPrefix + id
+		}
+		values, err := s.redis.MGet(ctx, keys...).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load tasks: %w", err)
+		}
+
+		for i, value := range values {
+			position++
+
+			data, ok := value.(string)
+			if !ok {
+				// Key expired before the index was pruned
+				continue
+			}
+			var task Task
+			if err := codec.Unmarshal([]byte(data), &task); err != nil {
+				log.Printf("Warning: failed to decode task %s: %v", ids[i], err)
+				continue
+			}
+			if status != "" && task.Status != status {
+				continue
+			}
+
+			page.Tasks = append(page.Tasks, &task)
+			if len(page.Tasks) == limit {
+				break
+			}
+		}
+	}
+
+	if int64(position) < total {
+		page.NextOffset = position
+	}
+
+	return page, nil
+}
+
+// RecordTransition atomically moves one task between status counters
+func (s *RedisTaskStore) RecordTransition(ctx context.Context, from, to TaskStatus) error {
+	pipe := s.redis.TxPipeline()
+	if from != "" {
+		pipe.HIncrBy(ctx, taskStatusCountsKey, string(from), -1)
+	}
+	if to != "" {
+		pipe.HIncrBy(ctx, taskStatusCountsKey, string(to), 1)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// StatusCounts reads the per-status counters, omitting empty statuses
+func (s *RedisTaskStore) StatusCounts(ctx context.Context) (map[TaskStatus]int64, error) {
+	values, err := s.redis.HGetAll(ctx, taskStatusCountsKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read task counters: %w", err)
+	}
+
+	counts := make(map[TaskStatus]int64, len(values))
+	for status, value := range values {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n <= 0 {
+			continue
+		}
+		counts[TaskStatus(status)] = n
+	}
+	return counts, nil
+}
+
+// ReconcileCounts recounts statuses from the index and replaces the
+// counters. Transitions recorded while the recount runs may be lost until
+// the next reconcile.
+func (s *RedisTaskStore) ReconcileCounts(ctx context.Context) (map[TaskStatus]int64, error) {
+	counts, err := countStatuses(ctx, s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recount tasks: %w", err)
+	}
+
+	fields := make(map[string]interface{}, len(counts))
+	for status, n := range counts {
+		fields[string(status)] = n
+	}
+
+	pipe := s.redis.TxPipeline()
+	pipe.Del(ctx, taskStatusCountsKey)
+	if len(fields) > 0 {
+		pipe.HSet(ctx, taskStatusCountsKey, fields)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to store task counters: %w", err)
+	}
+
+	return counts, nil
+}
+
+// AddDeadLetter stores a quarantined task outside the normal task TTL
+func (s *RedisTaskStore) AddDeadLetter(ctx context.Context, task *Task) error {
+	data, err := codec.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task: %w", err)
+	}
+
+	pipe := s.redis.TxPipeline()
+	pipe.Set(ctx, taskDeadLetterPrefix+task.ID, data, deadLetterTTL)
+	pipe.ZAdd(ctx, taskDeadLetterIndexKey, &redis.Z{
+		Score:  float64(time.Now().UnixMilli()),
+		Member: task.ID,
+	})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to store dead letter: %w", err)
+	}
+	return nil
+}
+
+// ListDeadLetters reads quarantined tasks newest first
+func (s *RedisTaskStore) ListDeadLetters(ctx context.Context, limit int) ([]*Task, error) {
+	cutoff := time.Now().Add(-deadLetterTTL).UnixMilli()
+	if err := s.redis.ZRemRangeByScore(ctx, taskDeadLetterIndexKey, "-inf", "("+strconv.FormatInt(cutoff, 10)).Err(); err != nil {
+		log.Printf("Warning: failed to prune dead letter index: %v", err)
+	}
+
+	ids, err := s.redis.ZRevRange(ctx, taskDeadLetterIndexKey, 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dead letter index: %w", err)
+	}
+	tasks := make([]*Task, 0, len(ids))
+	if len(ids) == 0 {
+		return tasks, nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = taskDeadLetterPrefix + id
+	}
+	values, err := s.redis.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load dead letters: %w", err)
+	}
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var task Task
+		if err := codec.Unmarshal([]byte(data), &task); err != nil {
+			log.Printf("Warning: failed to decode dead letter %s: %v", ids[i], err)
+			continue
+		}
+		tasks = append(tasks, &task)
+	}
+	return tasks, nil
+}
+
+// AppendEvent adds an event to the task's stream, trimmed to maxTaskEvents
+// and expiring with the task
+func (s *RedisTaskStore) AppendEvent(ctx context.Context, taskID string, event TaskEvent) error {
+	data, err := codec.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	key := taskEventsPrefix + taskID
+	pipe := s.redis.TxPipeline()
+	pipe.XAdd(ctx, &redis.XAddArgs{
+		Stream: key,
+		MaxLen: maxTaskEvents,
+		Values: map[string]interface{}{"event": data},
+	})
+	pipe.Expire(ctx, key, s.ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to append event: %w", err)
+	}
+	return nil
+}
+
+// ListEvents reads the task's stream oldest first
+func (s *RedisTaskStore) ListEvents(ctx context.Context, taskID string) ([]TaskEvent, error) {
+	messages, err := s.redis.XRange(ctx, taskEventsPrefix+taskID, "-", "+").Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read events: %w", err)
+	}
+
+	events := make([]TaskEvent, 0, len(messages))
+	for _, message := range messages {
+		data, ok := message.Values["event"].(string)
+		if !ok {
+			continue
+		}
+		var event TaskEvent
+		if err := codec.Unmarshal([]byte(data), &event); err != nil {
+			log.Printf("Warning: failed to decode event %s of task %s: %v", message.ID, taskID, err)
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
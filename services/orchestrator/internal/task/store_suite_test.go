@@ -0,0 +1,214 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// The tests in this file run against every TaskStore implementation, so the
+// in-memory store stands in for Redis only where they agree
+
+func forEachTaskStore(t *testing.T, test func(t *testing.T, store TaskStore)) {
+	for name, newStore := range taskStores() {
+		t.Run(name, func(t *testing.T) { test(t, newStore(t)) })
+	}
+}
+
+// saveTasks stores n tasks created a second apart, oldest first, with
+// statuses taken in turn from statuses
+func saveTasks(t *testing.T, store TaskStore, n int, statuses ...TaskStatus) []*Task {
+	t.Helper()
+	created := time.Now().Add(-time.Minute).Truncate(time.Millisecond)
+	tasks := make([]*Task, n)
+	for i := range tasks {
+		tasks[i] = &Task{
+			ID:        fmt.Sprintf("task-%d", i),
+			Type:      TaskTypeAnalyzeCost,
+			Status:    statuses[i%len(statuses)],
+			CreatedAt: created.Add(time.Duration(i) * time.Second),
+		}
+		if err := store.SaveTask(context.Background(), tasks[i]); err != nil {
+			t.Fatalf("save %s: %v", tasks[i].ID, err)
+		}
+	}
+	return tasks
+}
+
+func pageIDs(page *TaskPage) []string {
+	ids := make([]string, len(page.Tasks))
+	for i, task := range page.Tasks {
+		ids[i] = task.ID
+	}
+	return ids
+}
+
+func TestTaskStoreSaveAndGet(t *testing.T) {
+	forEachTaskStore(t, func(t *testing.T, store TaskStore) {
+		ctx := context.Background()
+		if _, err := store.GetTask(ctx, "missing"); !errors.Is(err, ErrTaskNotFound) {
+			t.Errorf("get missing task: %v, want ErrTaskNotFound", err)
+		}
+
+		task := saveTasks(t, store, 1, TaskStatusPending)[0]
+		task.Status = TaskStatusCompleted
+		task.Result = map[string]interface{}{"savings": 12.5}
+		if err := store.SaveTask(ctx, task); err != nil {
+			t.Fatal(err)
+		}
+		got, err := store.GetTask(ctx, task.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Status != TaskStatusCompleted || got.Result["savings"] != 12.5 || !got.CreatedAt.Equal(task.CreatedAt) {
+			t.Errorf("loaded task = %+v", got)
+		}
+	})
+}
+
+func TestTaskStoreListsNewestFirst(t *testing.T) {
+	forEachTaskStore(t, func(t *testing.T, store TaskStore) {
+		ctx := context.Background()
+		saveTasks(t, store, 5, TaskStatusCompleted, TaskStatusFailed)
+
+		page, err := store.ListTasks(ctx, "", 0, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fmt.Sprint(pageIDs(page)); got != "[task-4 task-3 task-2]" || page.Total != 5 || page.NextOffset != 3 {
+			t.Errorf("first page = %s, total %d, next %d", got, page.Total, page.NextOffset)
+		}
+		page, err = store.ListTasks(ctx, "", page.NextOffset, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fmt.Sprint(pageIDs(page)); got != "[task-1 task-0]" || page.NextOffset != -1 {
+			t.Errorf("last page = %s, next %d", got, page.NextOffset)
+		}
+
+		page, err = store.ListTasks(ctx, TaskStatusFailed, 0, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fmt.Sprint(pageIDs(page)); got != "[task-3 task-1]" {
+			t.Errorf("failed tasks = %s", got)
+		}
+	})
+}
+
+func TestTaskStoreListsBetween(t *testing.T) {
+	forEachTaskStore(t, func(t *testing.T, store TaskStore) {
+		tasks := saveTasks(t, store, 5, TaskStatusCompleted)
+
+		// The range includes since and excludes until
+		page, err := store.ListTasksBetween(context.Background(), tasks[1].CreatedAt, tasks[3].CreatedAt, "", 0, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fmt.Sprint(pageIDs(page)); got != "[task-2 task-1]" || page.Total != 2 {
+			t.Errorf("tasks in range = %s, total %d", got, page.Total)
+		}
+	})
+}
+
+func TestTaskStoreUnindex(t *testing.T) {
+	forEachTaskStore(t, func(t *testing.T, store TaskStore) {
+		ctx := context.Background()
+		saveTasks(t, store, 2, TaskStatusPending)
+		if err := store.Unindex(ctx, "task-0"); err != nil {
+			t.Fatal(err)
+		}
+
+		page, err := store.ListTasks(ctx, "", 0, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fmt.Sprint(pageIDs(page)); got != "[task-1]" || page.Total != 1 {
+			t.Errorf("indexed tasks = %s, total %d", got, page.Total)
+		}
+		// Unindexed tasks stay readable by ID
+		if _, err := store.GetTask(ctx, "task-0"); err != nil {
+			t.Errorf("get unindexed task: %v", err)
+		}
+	})
+}
+
+func TestTaskStoreCounts(t *testing.T) {
+	forEachTaskStore(t, func(t *testing.T, store TaskStore) {
+		ctx := context.Background()
+		for _, move := range [][2]TaskStatus{
+			{"", TaskStatusPending},
+			{"", TaskStatusPending},
+			{TaskStatusPending, TaskStatusCompleted},
+		} {
+			if err := store.RecordTransition(ctx, move[0], move[1]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		counts, err := store.StatusCounts(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if counts[TaskStatusPending] != 1 || counts[TaskStatusCompleted] != 1 {
+			t.Errorf("counts = %v", counts)
+		}
+
+		// Reconciling replaces the counters with what the index holds
+		saveTasks(t, store, 3, TaskStatusFailed)
+		counts, err = store.ReconcileCounts(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if counts[TaskStatusFailed] != 3 || counts[TaskStatusPending] != 0 || counts[TaskStatusCompleted] != 0 {
+			t.Errorf("reconciled counts = %v", counts)
+		}
+		if stored, _ := store.StatusCounts(ctx); stored[TaskStatusFailed] != 3 || stored[TaskStatusPending] != 0 {
+			t.Errorf("counts after reconcile = %v", stored)
+		}
+	})
+}
+
+func TestTaskStoreDeadLetters(t *testing.T) {
+	forEachTaskStore(t, func(t *testing.T, store TaskStore) {
+		ctx := context.Background()
+		for _, task := range saveTasks(t, store, 3, TaskStatusQuarantined) {
+			if err := store.AddDeadLetter(ctx, task); err != nil {
+				t.Fatal(err)
+			}
+			time.Sleep(2 * time.Millisecond)
+		}
+
+		dead, err := store.ListDeadLetters(ctx, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(dead) != 2 || dead[0].ID != "task-2" || dead[1].ID != "task-1" {
+			t.Errorf("dead letters = %v", dead)
+		}
+	})
+}
+
+func TestTaskStoreEvents(t *testing.T) {
+	forEachTaskStore(t, func(t *testing.T, store TaskStore) {
+		ctx := context.Background()
+		if events, err := store.ListEvents(ctx, "task-0"); err != nil || len(events) != 0 {
+			t.Errorf("events before any = %v, %v", events, err)
+		}
+
+		for i := 0; i < maxTaskEvents+5; i++ {
+			if err := store.AppendEvent(ctx, "task-0", TaskEvent{Type: TaskEventSent, Attempt: i}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		events, err := store.ListEvents(ctx, "task-0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The oldest events are dropped beyond the cap
+		if len(events) != maxTaskEvents || events[0].Attempt != 5 || events[len(events)-1].Attempt != maxTaskEvents+4 {
+			t.Errorf("%d events from attempt %d", len(events), events[0].Attempt)
+		}
+	})
+}
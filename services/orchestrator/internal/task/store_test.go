@@ -0,0 +1,184 @@
+package task
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+
+	"optiinfra/services/orchestrator/internal/codec"
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+// newMiniRedisTaskStore returns a Redis task store backed by an in-process
+// Redis server that lives for the test
+func newMiniRedisTaskStore(t *testing.T) *RedisTaskStore {
+	t.Helper()
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedisTaskStore(client)
+}
+
+// taskStores returns a constructor for each TaskStore implementation
+func taskStores() map[string]func(t *testing.T) TaskStore {
+	return map[string]func(t *testing.T) TaskStore{
+		"memory": func(t *testing.T) TaskStore { return NewMemoryTaskStore() },
+		"redis":  func(t *testing.T) TaskStore { return newMiniRedisTaskStore(t) },
+	}
+}
+
+// newStoreRouter returns a started router on store with an in-memory registry
+func newStoreRouter(t *testing.T, store TaskStore) (*Router, *registry.Registry) {
+	t.Helper()
+	reg := registry.NewRegistryWithStore(registry.NewMemoryAgentStore())
+	cfg := DefaultConfig()
+	cfg.RetryDelay = 10 * time.Millisecond
+	r := NewRouterWithConfig(store, reg, cfg)
+	r.Start()
+	t.Cleanup(r.Stop)
+	return r, reg
+}
+
+// blockingAgent completes analyze_cost tasks and holds every other task until
+// the test ends
+func blockingAgent(t *testing.T) string {
+	t.Helper()
+	release := make(chan struct{})
+	complete := completingAgent(map[string]interface{}{"ok": true})
+	srv := newAgentServer(t, func(w http.ResponseWriter, req *http.Request) {
+		var taskReq TaskRequest
+		body, _ := io.ReadAll(req.Body)
+		json.Unmarshal(body, &taskReq)
+		if taskReq.TaskType != TaskTypeAnalyzeCost {
+			select {
+			case <-release:
+			case <-req.Context().Done():
+			}
+			return
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		complete(w, req)
+	})
+	t.Cleanup(func() { close(release) })
+	return srv.URL
+}
+
+func pageStatuses(t *testing.T, r *Router) (map[string]TaskStatus, int64) {
+	t.Helper()
+	page, err := r.ListTasksPage("", 0, 0)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	statuses := make(map[string]TaskStatus, len(page.Tasks))
+	for _, task := range page.Tasks {
+		statuses[task.ID] = task.Status
+	}
+	return statuses, page.Total
+}
+
+func TestTaskIndexFollowsSubmitCompleteCancel(t *testing.T) {
+	for name, newStore := range taskStores() {
+		t.Run(name, func(t *testing.T) {
+			r, reg := newStoreRouter(t, newStore(t))
+			registerAgent(t, reg, "cost-1", registry.AgentTypeCost, blockingAgent(t), "analyze_cost", "right_size")
+
+			completed := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost"}).TaskID
+			held := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeRightSize, AgentType: "cost"}).TaskID
+
+			waitForStatus(t, r, completed, TaskStatusCompleted)
+			waitForStatus(t, r, held, TaskStatusSent)
+			statuses, total := pageStatuses(t, r)
+			if total != 2 || statuses[completed] != TaskStatusCompleted || statuses[held] != TaskStatusSent {
+				t.Fatalf("index before cancel = %v (total %d)", statuses, total)
+			}
+
+			if err := r.CancelTask(held); err != nil {
+				t.Fatalf("cancel: %v", err)
+			}
+			statuses, total = pageStatuses(t, r)
+			if total != 1 || len(statuses) != 1 || statuses[completed] != TaskStatusCompleted {
+				t.Errorf("index after cancel = %v (total %d), want only the completed task", statuses, total)
+			}
+
+			// The cancelled task is still readable, just no longer listed
+			if status, err := r.GetTaskStatus(held); err != nil || status.Status != TaskStatusFailed {
+				t.Errorf("cancelled task status = %v, %v", status, err)
+			}
+		})
+	}
+}
+
+func TestRedisIndexDropsExpiredTasks(t *testing.T) {
+	store := newMiniRedisTaskStore(t)
+	ctx := context.Background()
+
+	old := &Task{ID: "old", Type: TaskTypeAnalyzeCost, Status: TaskStatusCompleted, CreatedAt: time.Now().Add(-2 * store.ttl)}
+	recent := &Task{ID: "recent", Type: TaskTypeAnalyzeCost, Status: TaskStatusPending, CreatedAt: time.Now()}
+	for _, task := range []*Task{old, recent} {
+		if err := store.SaveTask(ctx, task); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	page, err := store.ListTasks(ctx, "", 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 1 || len(page.Tasks) != 1 || page.Tasks[0].ID != "recent" {
+		t.Errorf("listed %d of %d tasks, want only the recent one", len(page.Tasks), page.Total)
+	}
+	if n, err := store.redis.ZCard(ctx, taskCreatedIndexKey).Result(); err != nil || n != 1 {
+		t.Errorf("index holds %d entries (%v), want 1", n, err)
+	}
+}
+
+func TestRedisStoreReadsMixedEncodings(t *testing.T) {
+	t.Cleanup(func() { codec.SetDefault(codec.JSON) })
+	store := newMiniRedisTaskStore(t)
+	ctx := context.Background()
+
+	// Tasks written before and after switching to msgpack read back alike
+	tasks := saveTasks(t, store, 1, TaskStatusCompleted)
+	codec.SetDefault(codec.Msgpack)
+	tasks = append(tasks, &Task{
+		ID:         "task-msgpack",
+		Type:       TaskTypeRightSize,
+		Status:     TaskStatusCompleted,
+		CreatedAt:  tasks[0].CreatedAt.Add(time.Second),
+		Parameters: map[string]interface{}{"region": "us-east-1"},
+		Result:     map[string]interface{}{"savings": 12.5},
+	})
+	if err := store.SaveTask(ctx, tasks[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range tasks {
+		got, err := store.GetTask(ctx, want.ID)
+		if err != nil {
+			t.Fatalf("get %s: %v", want.ID, err)
+		}
+		if got.Type != want.Type || got.Status != want.Status || !got.CreatedAt.Equal(want.CreatedAt) {
+			t.Errorf("task %s = %+v", want.ID, got)
+		}
+	}
+	got, _ := store.GetTask(ctx, "task-msgpack")
+	if got.Parameters["region"] != "us-east-1" || got.Result["savings"] != 12.5 {
+		t.Errorf("msgpack task parameters %v, result %v", got.Parameters, got.Result)
+	}
+
+	page, err := store.ListTasks(ctx, "", 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := fmt.Sprint(pageIDs(page)); ids != "[task-msgpack task-0]" {
+		t.Errorf("listed %s", ids)
+	}
+}
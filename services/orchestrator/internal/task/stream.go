@@ -0,0 +1,231 @@
+package task
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// streamContentType marks an agent response streamed as one JSON
+	// StreamChunk per line
+	streamContentType = "application/x-ndjson"
+
+	// Longest line accepted from a streaming agent
+	maxStreamLine = 1 << 20
+
+	// Chunks of the current attempt kept for subscribers that join late
+	maxStreamHistory = 256
+
+	// Chunks buffered per subscriber; a slower subscriber misses chunks
+	streamSubscriberBuffer = 64
+)
+
+// ErrTaskNotStreamable is returned when subscribing to the stream of an
+// unfinished task this replica is not running
+var ErrTaskNotStreamable = errors.New("task is not running on this replica")
+
+// StreamChunk is one line of a streamed agent response. Agents stream by
+// answering with Content-Type application/x-ndjson; the last line sets Done,
+// or Error if the task failed part way.
+type StreamChunk struct {
+	Partial map[string]interface{} `json:"partial,omitempty"` // Incremental output
+
+	// Final result; when omitted, the partial outputs merged in order
+	Done          bool                   `json:"done,omitempty"`
+	Result        map[string]interface{} `json:"result,omitempty"`
+	ExecutionTime int                    `json:"execution_time_ms,omitempty"`
+
+	// Mid-stream failure, classified like a failed TaskResponse
+	Error     string `json:"error,omitempty"`
+	ErrorCode string `json:"error_code,omitempty"`
+	Retryable *bool  `json:"retryable,omitempty"`
+}
+
+// StreamEvent is a partial result forwarded to a task's stream subscribers
+type StreamEvent struct {
+	TaskID    string                 `json:"task_id"`
+	Attempt   int                    `json:"attempt"`
+	Partial   map[string]interface{} `json:"partial"`
+	Timestamp time.Time              `json:"timestamp"`
+}
+
+// taskStream fans a task's partial results out to its subscribers
+type taskStream struct {
+	history     []StreamEvent
+	subscribers map[chan StreamEvent]bool
+}
+
+// taskStreams holds the streams of tasks with partial results or
+// subscribers
+type taskStreams struct {
+	mu      sync.Mutex
+	streams map[string]*taskStream
+}
+
+// stream returns a task's stream, creating it if needed. It must be called
+// with s.mu held.
+func (s *taskStreams) stream(taskID string) *taskStream {
+	if s.streams == nil {
+		s.streams = make(map[string]*taskStream)
+	}
+	stream, ok := s.streams[taskID]
+	if !ok {
+		stream = &taskStream{subscribers: make(map[chan StreamEvent]bool)}
+		s.streams[taskID] = stream
+	}
+	return stream
+}
+
+// SubscribeStream returns the partial results a task has streamed so far in
+// its current attempt and a channel of those that follow. The channel is
+// closed once the task finishes; cancel must be called when done reading.
+func (r *Router) SubscribeStream(taskID string) ([]StreamEvent, <-chan StreamEvent, func(), error) {
+	r.mu.RLock()
+	_, local := r.tasks[taskID]
+	r.mu.RUnlock()
+	if !local {
+		status, err := r.GetTaskStatus(taskID)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if !isTerminal(status.Status) {
+			return nil, nil, nil, ErrTaskNotStreamable
+		}
+	}
+
+	ch := make(chan StreamEvent, streamSubscriberBuffer)
+	r.streams.mu.Lock()
+	stream := r.streams.stream(taskID)
+	stream.subscribers[ch] = true
+	history := append([]StreamEvent(nil), stream.history...)
+	r.streams.mu.Unlock()
+
+	cancel := func() {
+		r.streams.mu.Lock()
+		defer r.streams.mu.Unlock()
+		if stream.subscribers[ch] {
+			delete(stream.subscribers, ch)
+			close(ch)
+		}
+		if len(stream.subscribers) == 0 && r.streams.streams[taskID] == stream {
+			delete(r.streams.streams, taskID)
+		}
+	}
+
+	// The task may have finished before the subscription; its stream has
+	// then already been closed
+	if status, err := r.GetTaskStatus(taskID); err == nil && isTerminal(status.Status) {
+		cancel()
+	}
+	return history, ch, cancel, nil
+}
+
+// publishPartial forwards a partial result to the task's subscribers
+func (r *Router) publishPartial(taskID string, partial map[string]interface{}) {
+	r.mu.RLock()
+	attempt := 0
+	if task, ok := r.tasks[taskID]; ok {
+		attempt = task.RetryCount
+	}
+	partial = r.redact(partial)
+	r.mu.RUnlock()
+
+	event := StreamEvent{TaskID: taskID, Attempt: attempt, Partial: partial, Timestamp: time.Now()}
+
+	r.streams.mu.Lock()
+	defer r.streams.mu.Unlock()
+	stream := r.streams.stream(taskID)
+	if len(stream.history) > 0 && stream.history[0].Attempt != attempt {
+		// A retry streams from scratch
+		stream.history = nil
+	}
+	if len(stream.history) < maxStreamHistory {
+		stream.history = append(stream.history, event)
+	}
+	for ch := range stream.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// endStream closes a finished task's stream. It is an outcome listener, so
+// it runs with r.mu held.
+func (r *Router) endStream(outcome TaskOutcome) {
+	r.streams.mu.Lock()
+	defer r.streams.mu.Unlock()
+
+	stream, ok := r.streams.streams[outcome.TaskID]
+	if !ok {
+		return
+	}
+	for ch := range stream.subscribers {
+		delete(stream.subscribers, ch)
+		close(ch)
+	}
+	delete(r.streams.streams, outcome.TaskID)
+}
+
+// isStreamResponse reports whether an agent answered with a result stream
+func isStreamResponse(resp *http.Response) bool {
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return err == nil && mediaType == streamContentType
+}
+
+// readStream forwards a streamed agent response to the task's subscribers
+// and returns the final result once the agent marks the stream done
+func (r *Router) readStream(taskID string, resp *http.Response) (*TaskResponse, error) {
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxStreamLine)
+
+	assembled := make(map[string]interface{})
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var chunk StreamChunk
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return nil, fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+		if chunk.Error != "" {
+			return nil, &AgentError{
+				StatusCode: resp.StatusCode,
+				Code:       chunk.ErrorCode,
+				Message:    chunk.Error,
+				Retryable:  chunk.Retryable,
+			}
+		}
+		if len(chunk.Partial) > 0 {
+			for key, value := range chunk.Partial {
+				assembled[key] = value
+			}
+			r.publishPartial(taskID, chunk.Partial)
+		}
+		if chunk.Done {
+			result := chunk.Result
+			if result == nil {
+				result = assembled
+			}
+			return &TaskResponse{
+				TaskID:        taskID,
+				Status:        TaskStatusCompleted,
+				Result:        result,
+				ExecutionTime: chunk.ExecutionTime,
+			}, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("stream interrupted: %w", err)
+	}
+	return nil, fmt.Errorf("stream ended before the agent finished")
+}
@@ -0,0 +1,165 @@
+package task
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"optiinfra/services/orchestrator/internal/handlers"
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+// sseEvent is one server-sent event read from a task stream
+type sseEvent struct {
+	name string
+	data string
+}
+
+// readSSE reads server-sent events from body until it closes
+func readSSE(body *bufio.Reader, events chan<- sseEvent) {
+	defer close(events)
+	var event sseEvent
+	for {
+		line, err := body.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			event.name = strings.TrimPrefix(line, "event:")
+		case strings.HasPrefix(line, "data:"):
+			event.data = strings.TrimPrefix(line, "data:")
+		case line == "" && event.name != "":
+			events <- event
+			event = sseEvent{}
+		}
+	}
+}
+
+func nextEvent(t *testing.T, events <-chan sseEvent) sseEvent {
+	t.Helper()
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatal("stream closed early")
+		}
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a stream event")
+	}
+	return sseEvent{}
+}
+
+func TestStreamForwardsChunksAndStoresResult(t *testing.T) {
+	started := make(chan struct{})
+	next := make(chan struct{})
+	agent := newAgentServer(t, func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", streamContentType)
+		flusher := w.(http.Flusher)
+		chunks := []string{
+			`{"partial":{"rows_scanned":10}}`,
+			`{"partial":{"summary":"ok"}}`,
+			`{"done":true,"execution_time_ms":7}`,
+		}
+		for i, chunk := range chunks {
+			fmt.Fprintln(w, chunk)
+			flusher.Flush()
+			if i == 0 {
+				close(started)
+			}
+			if i < len(chunks)-1 {
+				select {
+				case <-next:
+				case <-time.After(5 * time.Second):
+					return
+				}
+			}
+		}
+	})
+
+	r, reg := newTestRouter(t)
+	registerAgent(t, reg, "analyzer", registry.AgentTypeCost, agent.URL, string(TaskTypeAnalyzeCost))
+	resp := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost"})
+	<-started
+
+	// Serve the stream the way the server does, behind the request timeout
+	engine := gin.New()
+	NewHandler(r).RegisterRoutes(engine)
+	srv := httptest.NewServer(handlers.WithTimeout(engine, time.Minute, "/tasks/*/stream"))
+	defer srv.Close()
+
+	httpResp, err := http.Get(srv.URL + "/tasks/" + resp.TaskID + "/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer httpResp.Body.Close()
+	events := make(chan sseEvent)
+	go readSSE(bufio.NewReader(httpResp.Body), events)
+
+	// The chunk streamed before subscribing is replayed from history
+	var partial StreamEvent
+	event := nextEvent(t, events)
+	if event.name != "partial" {
+		t.Fatalf("first event = %q, want partial", event.name)
+	}
+	json.Unmarshal([]byte(event.data), &partial)
+	if partial.Partial["rows_scanned"] != float64(10) {
+		t.Errorf("first partial = %v", partial.Partial)
+	}
+
+	// A live chunk reaches the subscriber while the agent is still running
+	next <- struct{}{}
+	event = nextEvent(t, events)
+	if event.name != "partial" {
+		t.Fatalf("second event = %q, want partial", event.name)
+	}
+	json.Unmarshal([]byte(event.data), &partial)
+	if partial.Partial["summary"] != "ok" {
+		t.Errorf("second partial = %v", partial.Partial)
+	}
+
+	next <- struct{}{}
+	event = nextEvent(t, events)
+	if event.name != "end" {
+		t.Fatalf("last event = %q, want end", event.name)
+	}
+	var final TaskStatusResponse
+	json.Unmarshal([]byte(event.data), &final)
+	if final.Status != TaskStatusCompleted {
+		t.Errorf("end status = %s, want completed", final.Status)
+	}
+
+	// The stored result is the partial outputs merged in order
+	status := waitForStatus(t, r, resp.TaskID, TaskStatusCompleted)
+	if status.Result["rows_scanned"] != float64(10) || status.Result["summary"] != "ok" {
+		t.Errorf("stored result = %v", status.Result)
+	}
+}
+
+func TestStreamMidStreamErrorFailsTask(t *testing.T) {
+	agent := newAgentServer(t, func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", streamContentType)
+		fmt.Fprintln(w, `{"partial":{"rows_scanned":10}}`)
+		fmt.Fprintln(w, `{"error":"source went away","error_code":"source_lost","retryable":false}`)
+	})
+
+	r, reg := newTestRouter(t)
+	registerAgent(t, reg, "analyzer", registry.AgentTypeCost, agent.URL, string(TaskTypeAnalyzeCost))
+	resp := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost"})
+
+	status := waitForStatus(t, r, resp.TaskID, TaskStatusFailed)
+	if status.ErrorCode != "source_lost" {
+		t.Errorf("error code = %q, want source_lost", status.ErrorCode)
+	}
+	if status.RetryCount != 0 {
+		t.Errorf("retry count = %d, want no retries of a non-retryable error", status.RetryCount)
+	}
+}
@@ -0,0 +1,52 @@
+package task
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// TransportConfig tunes connection pooling for requests to agents
+type TransportConfig struct {
+	MaxIdleConns        int           // Idle connections kept across all agents
+	MaxIdleConnsPerHost int           // Idle connections kept per agent
+	MaxConnsPerHost     int           // 0 means unlimited
+	IdleConnTimeout     time.Duration // How long an idle connection is kept
+	DialTimeout         time.Duration
+	KeepAlive           time.Duration // TCP keep-alive probe interval
+	AttemptTimeout      time.Duration // Per-attempt cap; 0 uses the task timeout
+}
+
+// DefaultTransportConfig returns pooling defaults suited to a fleet of agents
+// receiving repeated requests
+func DefaultTransportConfig() TransportConfig {
+	return TransportConfig{
+		MaxIdleConns:        200,
+		MaxIdleConnsPerHost: 16,
+		IdleConnTimeout:     90 * time.Second,
+		DialTimeout:         5 * time.Second,
+		KeepAlive:           30 * time.Second,
+	}
+}
+
+// newAgentClient builds an HTTP client from a transport config. The client
+// timeout is the overall ceiling; per-attempt deadlines are set per request.
+func newAgentClient(cfg TransportConfig, maxTimeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   cfg.DialTimeout,
+		KeepAlive: cfg.KeepAlive,
+	}
+
+	return &http.Client{
+		Timeout: maxTimeout,
+		Transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			DialContext:         dialer.DialContext,
+			MaxIdleConns:        cfg.MaxIdleConns,
+			MaxIdleConnsPerHost: cfg.MaxIdleConnsPerHost,
+			MaxConnsPerHost:     cfg.MaxConnsPerHost,
+			IdleConnTimeout:     cfg.IdleConnTimeout,
+			ForceAttemptHTTP2:   true,
+		},
+	}
+}
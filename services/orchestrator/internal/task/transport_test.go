@@ -0,0 +1,97 @@
+package task
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+// countingAgent serves completed tasks and counts the connections opened to it
+func countingAgent(t testing.TB, conns *int64) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/task", completingAgent(map[string]interface{}{"ok": true}))
+	srv := httptest.NewUnstartedServer(mux)
+	srv.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt64(conns, 1)
+		}
+	}
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestAgentConnectionsReused(t *testing.T) {
+	var conns int64
+	agent := countingAgent(t, &conns)
+	r, reg := newTestRouter(t)
+	registerAgent(t, reg, "cost-1", registry.AgentTypeCost, agent.URL, string(TaskTypeAnalyzeCost))
+
+	for i := 0; i < 5; i++ {
+		id := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost"}).TaskID
+		waitForStatus(t, r, id, TaskStatusCompleted)
+	}
+	if n := atomic.LoadInt64(&conns); n != 1 {
+		t.Errorf("opened %d connections for 5 sequential tasks, want 1", n)
+	}
+}
+
+func TestAttemptTimeoutBoundsSlowAgent(t *testing.T) {
+	agent := newAgentServer(t, func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+	cfg := DefaultConfig()
+	cfg.RetryDelay = 10 * time.Millisecond
+	cfg.DefaultMaxRetries = 0
+	r, reg := newTestRouterWithConfig(t, cfg)
+	transport := DefaultTransportConfig()
+	transport.AttemptTimeout = 50 * time.Millisecond
+	r.SetTransportConfig(transport)
+	registerAgent(t, reg, "cost-1", registry.AgentTypeCost, agent.URL, string(TaskTypeAnalyzeCost))
+
+	start := time.Now()
+	id := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeAnalyzeCost, AgentType: "cost", Timeout: 60}).TaskID
+	waitForTask(t, r, id, func(s *TaskStatusResponse) bool {
+		return s.Status != TaskStatusPending && s.Status != TaskStatusRunning
+	})
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("attempt took %v despite a 50ms attempt timeout", elapsed)
+	}
+}
+
+func BenchmarkAgentRoundTrip(b *testing.B) {
+	var conns int64
+	agent := countingAgent(b, &conns)
+	reg := registry.NewRegistryWithStore(registry.NewMemoryAgentStore())
+	r := NewRouterWithConfig(NewMemoryTaskStore(), reg, DefaultConfig())
+	host, port := hostPort(b, agent.URL)
+	resp, err := reg.Register(&registry.RegistrationRequest{
+		Name: "cost-1", Type: registry.AgentTypeCost, Host: host, Port: port,
+		Capabilities: []string{string(TaskTypeAnalyzeCost)},
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	agentRecord, err := reg.GetAgent(resp.AgentID)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := r.sendTaskToAgent(context.Background(), agentRecord, &TaskRequest{TaskID: "bench", TaskType: TaskTypeAnalyzeCost, Timeout: 5}); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(atomic.LoadInt64(&conns)), "conns")
+}
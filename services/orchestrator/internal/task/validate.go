@@ -0,0 +1,108 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// defaultMaxParameterBytes caps the JSON-encoded parameters of a task
+const defaultMaxParameterBytes = 256 << 10 // 256 KiB
+
+// ErrUnknownTaskType is returned for a task type the router does not know
+var ErrUnknownTaskType = errors.New("unknown task type")
+
+// builtinTaskTypes are the task types every router accepts
+var builtinTaskTypes = map[TaskType]bool{
+	TaskTypeAnalyzeCost:      true,
+	TaskTypeMigrateToSpot:    true,
+	TaskTypeRightSize:        true,
+	TaskTypeOptimizeKVCache:  true,
+	TaskTypeTuneInference:    true,
+	TaskTypePredictScaling:   true,
+	TaskTypeBalanceLoad:      true,
+	TaskTypeValidateQuality:  true,
+	TaskTypeDetectRegression: true,
+	TaskTypeTakeSnapshot:     true,
+	TaskTypeMigrateWorkload:  true,
+	TaskTypeScaleResources:   true,
+}
+
+// SetExtraTaskTypes sets task types accepted besides the built-in ones, for
+// agents advertising capabilities the router does not define
+func (r *Router) SetExtraTaskTypes(types []string) {
+	extra := make(map[TaskType]bool, len(types))
+	for _, taskType := range types {
+		if taskType = strings.TrimSpace(taskType); taskType != "" {
+			extra[TaskType(taskType)] = true
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.extraTaskTypes = extra
+}
+
+// SetMaxParameterBytes caps the JSON-encoded size of a task's parameters; 0
+// restores the default
+func (r *Router) SetMaxParameterBytes(n int) {
+	if n <= 0 {
+		n = defaultMaxParameterBytes
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.maxParameterBytes = n
+}
+
+// checkSubmission runs the checks every submission and dry run makes before
+// an agent is looked up: the kill switch, the admission webhook and the
+// request itself
+func (r *Router) checkSubmission(ctx context.Context, req *TaskSubmitRequest) error {
+	if r.ExecutionPausedSince() != nil {
+		return ErrExecutionPaused
+	}
+	if err := r.admit(ctx, req); err != nil {
+		return err
+	}
+	if err := r.validateTaskRequest(req); err != nil {
+		return fmt.Errorf("invalid task request: %w", err)
+	}
+	return nil
+}
+
+// validatePayload checks a request's task type, and those of its chain, are
+// known and its parameters fit the size limit
+func (r *Router) validatePayload(req *TaskSubmitRequest) error {
+	r.mu.RLock()
+	known := func(taskType TaskType) bool {
+		return builtinTaskTypes[taskType] || r.extraTaskTypes[taskType]
+	}
+	unknown := ""
+	if !known(req.TaskType) {
+		unknown = string(req.TaskType)
+	}
+	for i, step := range req.ChainOnSuccess {
+		if unknown == "" && !known(step.TaskType) {
+			unknown = fmt.Sprintf("%s (chain step %d)", step.TaskType, i+1)
+		}
+	}
+	maxBytes := r.maxParameterBytes
+	r.mu.RUnlock()
+
+	if unknown != "" {
+		return fmt.Errorf("%w: %s", ErrUnknownTaskType, unknown)
+	}
+	if len(req.Parameters) > 0 {
+		encoded, err := json.Marshal(req.Parameters)
+		if err != nil {
+			return fmt.Errorf("parameters cannot be encoded: %w", err)
+		}
+		if len(encoded) > maxBytes {
+			return fmt.Errorf("parameters are %d bytes, max %d", len(encoded), maxBytes)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+// submitAndValidate returns the errors SubmitTask and ValidateTask give req
+func submitAndValidate(r *Router, req *TaskSubmitRequest) (submitErr, validateErr error) {
+	_, validateErr = r.ValidateTask(context.Background(), req)
+	_, submitErr = r.SubmitTask(context.Background(), req)
+	return submitErr, validateErr
+}
+
+func TestSubmitRejectsUnknownTaskTypes(t *testing.T) {
+	r, reg := newTestRouter(t)
+	registerAgent(t, reg, "cost-1", registry.AgentTypeCost, "", "analyze_cost", "warm_cache")
+
+	requests := []*TaskSubmitRequest{
+		{TaskType: "warm_cache", AgentType: "cost"},
+		{TaskType: TaskTypeAnalyzeCost, AgentType: "cost", ChainOnSuccess: []ChainStep{{TaskType: "warm_cache", AgentType: "cost"}}},
+	}
+	for _, req := range requests {
+		submitErr, validateErr := submitAndValidate(r, req)
+		for _, err := range []error{submitErr, validateErr} {
+			if !errors.Is(err, ErrUnknownTaskType) {
+				t.Errorf("%s: err = %v, want ErrUnknownTaskType", req.TaskType, err)
+			}
+		}
+	}
+
+	// Types agents advertise beyond the built-in ones are accepted once
+	// configured
+	r.SetExtraTaskTypes([]string{" warm_cache "})
+	if _, err := r.ValidateTask(context.Background(), requests[0]); err != nil {
+		t.Errorf("configured extra type: %v", err)
+	}
+}
+
+func TestSubmitRejectsOversizedParameters(t *testing.T) {
+	r, reg := newTestRouter(t)
+	registerAgent(t, reg, "cost-1", registry.AgentTypeCost, "", "analyze_cost")
+	r.SetMaxParameterBytes(64)
+
+	req := &TaskSubmitRequest{
+		TaskType:   TaskTypeAnalyzeCost,
+		AgentType:  "cost",
+		Parameters: map[string]interface{}{"nodes": strings.Repeat("n", 64)},
+	}
+	submitErr, validateErr := submitAndValidate(r, req)
+	for _, err := range []error{submitErr, validateErr} {
+		if err == nil || !strings.Contains(err.Error(), "parameters are 76 bytes, max 64") {
+			t.Errorf("oversized parameters: err = %v", err)
+		}
+	}
+
+	req.Parameters = map[string]interface{}{"nodes": "n"}
+	if _, err := r.ValidateTask(context.Background(), req); err != nil {
+		t.Errorf("parameters under the limit: %v", err)
+	}
+}
+
+func TestValidateTaskMakesSubmitChecks(t *testing.T) {
+	req := &TaskSubmitRequest{TaskType: TaskTypeRightSize, AgentType: "cost"}
+
+	// Execution paused by the kill switch
+	r, reg := newTestRouter(t)
+	registerAgent(t, reg, "cost-1", registry.AgentTypeCost, "", "right_size")
+	r.PauseExecution()
+	if _, err := r.ValidateTask(context.Background(), req); !errors.Is(err, ErrExecutionPaused) {
+		t.Errorf("paused: err = %v, want ErrExecutionPaused", err)
+	}
+	r.ResumeExecution()
+
+	// Denied by the admission webhook
+	url, reviewed := policyServer(t)
+	r.SetAdmissionWebhook(NewAdmissionWebhook(url, time.Second, false))
+	if _, err := r.ValidateTask(context.Background(), req); !errors.Is(err, ErrAdmissionDenied) {
+		t.Errorf("denied: err = %v, want ErrAdmissionDenied", err)
+	}
+	if len(*reviewed) != 1 {
+		t.Errorf("webhook reviewed %v, want the dry run", *reviewed)
+	}
+
+	// The queue is full; the router is not dispatching, so the task stays
+	queued := registry.NewRegistryWithStore(registry.NewMemoryAgentStore())
+	idle := NewRouterWithConfig(NewMemoryTaskStore(), queued, DefaultConfig())
+	t.Cleanup(idle.Stop)
+	registerAgent(t, queued, "cost-1", registry.AgentTypeCost, "", "right_size")
+	idle.SetMaxQueuedTasks(1)
+	submit(t, idle, req)
+	if _, err := idle.ValidateTask(context.Background(), req); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("queue full: err = %v, want ErrQueueFull", err)
+	}
+}
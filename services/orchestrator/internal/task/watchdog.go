@@ -0,0 +1,206 @@
+package task
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+const (
+	// How often unfinished tasks are checked against their deadline
+	defaultTimeoutScanInterval = 15 * time.Second
+
+	// Allowance past a task's timeout before the watchdog fails it, covering
+	// queueing and retries
+	defaultTimeoutGrace = 2 * time.Minute
+)
+
+// WatchdogPolicy decides what the timeout watchdog does with an overdue task
+type WatchdogPolicy string
+
+const (
+	// WatchdogFail fails the task with TaskStatusTimeout
+	WatchdogFail WatchdogPolicy = "fail"
+
+	// WatchdogRetryElsewhere retries the task on another agent while it has
+	// retries left and one is available, failing it otherwise
+	WatchdogRetryElsewhere WatchdogPolicy = "retry_elsewhere"
+)
+
+// ParseWatchdogPolicy validates a watchdog policy name
+func ParseWatchdogPolicy(name string) (WatchdogPolicy, error) {
+	switch policy := WatchdogPolicy(name); policy {
+	case WatchdogFail, WatchdogRetryElsewhere:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown watchdog policy %q", name)
+	}
+}
+
+// SetWatchdogPolicy sets what the timeout watchdog does with overdue tasks
+func (r *Router) SetWatchdogPolicy(policy WatchdogPolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.watchdogPolicy = policy
+}
+
+// SetTimeoutWatchdog sets how often tasks are checked against their deadline
+// of CreatedAt + Timeout + grace. A zero interval disables the watchdog. It
+// must be called before Start.
+func (r *Router) SetTimeoutWatchdog(interval, grace time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.timeoutScanInterval = interval
+	r.timeoutGrace = grace
+}
+
+// TimeoutWatchdog returns the watchdog scan interval and grace period
+func (r *Router) TimeoutWatchdog() (time.Duration, time.Duration) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.timeoutScanInterval, r.timeoutGrace
+}
+
+// timeoutWatchdog times out tasks that never reached a terminal status, such
+// as tasks whose agent accepted them but never reported back
+func (r *Router) timeoutWatchdog(interval time.Duration) {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			timedOut, retried := r.expireOverdueTasks(time.Now())
+			if timedOut > 0 || retried > 0 {
+				log.Printf("Timeout watchdog timed out %d tasks and retried %d elsewhere", timedOut, retried)
+			}
+		case <-r.stopCh:
+			return
+		}
+	}
+}
+
+// expireOverdueTasks times out unfinished tasks past their deadline, or
+// retries them on another agent per the watchdog policy, and returns how
+// many it timed out and retried
+func (r *Router) expireOverdueTasks(now time.Time) (int, int) {
+	type retry struct {
+		task  *Task
+		agent *registry.Agent
+		cause error
+	}
+	var retries []retry
+
+	// Look up the agents overdue tasks may move to before taking the lock
+	agentTypes := make(map[string]bool)
+	r.mu.RLock()
+	if r.watchdogPolicy == WatchdogRetryElsewhere {
+		for _, task := range r.tasks {
+			if r.overdue(task, now) {
+				agentTypes[task.AgentType] = true
+			}
+		}
+	}
+	r.mu.RUnlock()
+	listings := r.lookupAgentListings(r.ctx, agentTypes)
+
+	r.mu.Lock()
+	expired := 0
+	for _, task := range r.tasks {
+		if !r.overdue(task, now) {
+			continue
+		}
+		if agent, cause := r.retryElsewhereLocked(task, now, listings); agent != nil {
+			retries = append(retries, retry{task, agent, cause})
+			continue
+		}
+		r.timeoutTaskLocked(task, now)
+		expired++
+	}
+	r.mu.Unlock()
+
+	for _, retry := range retries {
+		r.scheduleRetry(retry.task, retry.agent, retry.cause)
+	}
+	return expired, len(retries)
+}
+
+// overdue reports whether an unfinished task is past its deadline. It must
+// be called with r.mu held.
+func (r *Router) overdue(task *Task, now time.Time) bool {
+	// Broadcast parents finish with their children
+	if isTerminal(task.Status) || len(task.ChildTaskIDs) > 0 {
+		return false
+	}
+	start := task.CreatedAt
+	if !task.watchdogRetriedAt.IsZero() {
+		start = task.watchdogRetriedAt
+	}
+	return !now.Before(start.Add(task.Timeout + r.timeoutGrace))
+}
+
+// retryElsewhereLocked moves an overdue task to another agent under the
+// retry_elsewhere policy, aborting the attempt in flight, and returns the
+// new agent and the cause to retry with. It returns nil if the task should
+// time out instead. The agent is picked from listings looked up before
+// r.mu was taken. It must be called with r.mu held.
+func (r *Router) retryElsewhereLocked(task *Task, now time.Time, listings agentListings) (*registry.Agent, error) {
+	// A broadcast child is meant for its own agent
+	if r.watchdogPolicy != WatchdogRetryElsewhere || task.RetryCount >= task.MaxRetries || r.isBroadcastChild(task) {
+		return nil, nil
+	}
+	previous := task.AgentID
+	agent, err := r.pickAgentFor(listings, task, previous)
+	if err != nil {
+		log.Printf("Task %s overdue and cannot be retried elsewhere: %v", task.ID, err)
+		return nil, nil
+	}
+
+	if previous != "" && task.Status != TaskStatusQueued {
+		r.registry.RecordTaskOutcome(previous, false)
+	}
+	task.restartExecution(r.ctx)
+	task.watchdogRetriedAt = now
+	task.AgentID = agent.ID
+	if task.Metadata == nil {
+		task.Metadata = make(map[string]interface{})
+	}
+	task.Metadata["timed_out_on"] = previous
+
+	log.Printf("Task %s overdue on agent %s, retrying on %s (%s)", task.ID, previous, agent.Name, agent.ID)
+	return agent, fmt.Errorf("task did not finish within its %s timeout on agent %s", task.Timeout, previous)
+}
+
+// timeoutTaskLocked fails an overdue task with TaskStatusTimeout, aborting
+// any attempt in flight. It must be called with r.mu held.
+func (r *Router) timeoutTaskLocked(task *Task, now time.Time) {
+	previous := task.Status
+	task.Status = TaskStatusTimeout
+	task.Error = fmt.Sprintf("task did not finish within its %s timeout (status was %s)", task.Timeout, previous)
+	task.CompletedAt = &now
+	task.endExecution()
+	if len(task.Chain) > 0 {
+		if task.Metadata == nil {
+			task.Metadata = make(map[string]interface{})
+		}
+		task.Metadata["chain_aborted"] = fmt.Sprintf("task timed out, %d chained steps not submitted", len(task.Chain))
+	}
+
+	if task.AgentID != "" && previous != TaskStatusQueued {
+		r.registry.RecordTaskOutcome(task.AgentID, false)
+	}
+
+	if err := r.storeTask(task); err != nil {
+		log.Printf("Failed to store task timeout %s: %v", task.ID, err)
+	}
+	r.recordEvent(task, TaskEventTimeout, task.Error)
+	r.finishBroadcastChild(task)
+
+	log.Printf("Task timed out: %s (was %s)", task.ID, previous)
+}
@@ -0,0 +1,157 @@
+package task
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"optiinfra/services/orchestrator/internal/registry"
+)
+
+// stuckTask submits a task the agent accepts but never answers, and returns
+// it once sent
+func stuckTask(t *testing.T, r *Router, reg *registry.Registry) *Task {
+	t.Helper()
+	registerAgent(t, reg, "cost-1", registry.AgentTypeCost, blockingAgent(t), "analyze_cost", "right_size")
+	id := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeRightSize, AgentType: "cost", Timeout: 60}).TaskID
+	waitForStatus(t, r, id, TaskStatusSent)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.tasks[id]
+}
+
+func TestWatchdogTimesOutTaskPastDeadline(t *testing.T) {
+	r, reg := newTestRouter(t)
+	task := stuckTask(t, r, reg)
+	_, grace := r.TimeoutWatchdog()
+	deadline := task.CreatedAt.Add(task.Timeout + grace)
+	execCtx := task.executionContext(nil)
+
+	if timedOut, _ := r.expireOverdueTasks(deadline.Add(-time.Millisecond)); timedOut != 0 {
+		t.Fatalf("timed out %d tasks before the deadline", timedOut)
+	}
+	if status, _ := r.GetTaskStatus(task.ID); status.Status != TaskStatusSent {
+		t.Fatalf("task %s before its deadline, want sent", status.Status)
+	}
+
+	if timedOut, retried := r.expireOverdueTasks(deadline); timedOut != 1 || retried != 0 {
+		t.Fatalf("timed out %d and retried %d at the deadline, want 1 timed out", timedOut, retried)
+	}
+	status, err := r.GetTaskStatus(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != TaskStatusTimeout || !strings.Contains(status.Error, "status was sent") || status.CompletedAt == nil {
+		t.Errorf("task %s (%q), want timed out from sent", status.Status, status.Error)
+	}
+	// The attempt still waiting on the agent is aborted
+	select {
+	case <-execCtx.Done():
+	case <-time.After(time.Second):
+		t.Error("attempt still in flight after the timeout")
+	}
+
+	// A finished task is never timed out again
+	if timedOut, _ := r.expireOverdueTasks(deadline.Add(time.Hour)); timedOut != 0 {
+		t.Errorf("timed out %d finished tasks", timedOut)
+	}
+}
+
+func TestWatchdogScansOnInterval(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.RetryDelay = 10 * time.Millisecond
+	reg := registry.NewRegistryWithStore(registry.NewMemoryAgentStore())
+	r := NewRouterWithConfig(NewMemoryTaskStore(), reg, cfg)
+	r.SetTimeoutWatchdog(10*time.Millisecond, 0)
+	startRouter(t, r)
+	registerAgent(t, reg, "cost-1", registry.AgentTypeCost, blockingAgent(t), "analyze_cost", "right_size")
+
+	start := time.Now()
+	id := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeRightSize, AgentType: "cost", Timeout: 1}).TaskID
+	waitForStatus(t, r, id, TaskStatusTimeout)
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("timed out after %v, before the 1s deadline", elapsed)
+	}
+}
+
+func TestWatchdogPolicies(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    WatchdogPolicy
+		exhausted bool // Whether the task has used its retries
+		spare     bool // Whether another capable agent is registered
+		retried   bool
+	}{
+		{"fail", WatchdogFail, false, true, false},
+		{"retry elsewhere", WatchdogRetryElsewhere, false, true, true},
+		{"retry elsewhere without retries left", WatchdogRetryElsewhere, true, true, false},
+		{"retry elsewhere with no other agent", WatchdogRetryElsewhere, false, false, false},
+	}
+	for _, tt := range tests {
+		r, reg := newTestRouter(t)
+		r.SetWatchdogPolicy(tt.policy)
+		stuckOn := registerAgent(t, reg, "cost-1", registry.AgentTypeCost, blockingAgent(t), "right_size")
+		id := submit(t, r, &TaskSubmitRequest{TaskType: TaskTypeRightSize, AgentType: "cost", Timeout: 60, MaxRetries: 1}).TaskID
+		waitForStatus(t, r, id, TaskStatusSent)
+		var spareID string
+		if tt.spare {
+			agent := newAgentServer(t, completingAgent(map[string]interface{}{"ok": true}))
+			spareID = registerAgent(t, reg, "cost-2", registry.AgentTypeCost, agent.URL, "right_size")
+		}
+
+		r.mu.Lock()
+		task := r.tasks[id]
+		if tt.exhausted {
+			task.RetryCount = task.MaxRetries
+		}
+		deadline := task.CreatedAt.Add(task.Timeout + r.timeoutGrace)
+		execCtx := task.executionContext(nil)
+		r.mu.Unlock()
+
+		timedOut, retried := r.expireOverdueTasks(deadline)
+		if !tt.retried {
+			if timedOut != 1 || retried != 0 {
+				t.Errorf("%s: timed out %d and retried %d, want 1 timed out", tt.name, timedOut, retried)
+			}
+			if status, _ := r.GetTaskStatus(id); status.Status != TaskStatusTimeout || status.AgentID != stuckOn {
+				t.Errorf("%s: task %s on %s, want timed out on the stuck agent", tt.name, status.Status, status.AgentID)
+			}
+			continue
+		}
+
+		if timedOut != 0 || retried != 1 {
+			t.Fatalf("%s: timed out %d and retried %d, want 1 retried", tt.name, timedOut, retried)
+		}
+		select {
+		case <-execCtx.Done():
+		case <-time.After(time.Second):
+			t.Errorf("%s: attempt on the stuck agent still in flight", tt.name)
+		}
+		status := waitForStatus(t, r, id, TaskStatusCompleted)
+		if status.AgentID != spareID || status.RetryCount != 1 {
+			t.Errorf("%s: completed on %s after %d retries, want the spare agent after 1", tt.name, status.AgentID, status.RetryCount)
+		}
+		r.mu.RLock()
+		movedFrom, movedAt := task.Metadata["timed_out_on"], task.watchdogRetriedAt
+		r.mu.RUnlock()
+		if movedFrom != stuckOn {
+			t.Errorf("%s: timed_out_on = %v, want %s", tt.name, movedFrom, stuckOn)
+		}
+		// The deadline restarts from the move
+		if !movedAt.Equal(deadline) {
+			t.Errorf("%s: deadline restarted from %v, want %v", tt.name, movedAt, deadline)
+		}
+	}
+}
+
+func TestParseWatchdogPolicy(t *testing.T) {
+	for _, name := range []string{"fail", "retry_elsewhere"} {
+		if policy, err := ParseWatchdogPolicy(name); err != nil || string(policy) != name {
+			t.Errorf("parse %q = %q, %v", name, policy, err)
+		}
+	}
+	if _, err := ParseWatchdogPolicy("requeue"); err == nil {
+		t.Error("parsed an unknown policy")
+	}
+}